@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gruntwork-io/terragrunt/aws_helper"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The prefix of an audit log destination that's an S3 bucket rather than a local file
+const S3_URL_PREFIX = "s3://"
+
+// The xxx-all commands run Terraform in many modules at once, so make sure their records don't get interleaved
+var localFileLock sync.Mutex
+
+// A record of one run of Terraform
+type Record struct {
+	Timestamp         time.Time `json:"timestamp"`
+	User              string    `json:"user"`
+	Host              string    `json:"host"`
+	ModulePath        string    `json:"module_path"`
+	WorkingDir        string    `json:"working_dir"`
+	Command           string    `json:"command"`
+	Args              []string  `json:"args"`
+	TerraformVersion  string    `json:"terraform_version"`
+	TerragruntVersion string    `json:"terragrunt_version"`
+	IamRoleArn        string    `json:"iam_role_arn,omitempty"`
+	ExitCode          int       `json:"exit_code"`
+	DurationSeconds   float64   `json:"duration_seconds"`
+}
+
+// Create the record of a run of Terraform with the given args, in the module of the given options, that started at the
+// given time and exited with the given code
+func NewRecord(terragruntOptions *options.TerragruntOptions, args []string, startTime time.Time, exitCode int) Record {
+	record := Record{
+		Timestamp:         startTime.UTC(),
+		User:              currentUser(),
+		ModulePath:        filepath.ToSlash(filepath.Dir(terragruntOptions.TerragruntConfigPath)),
+		WorkingDir:        terragruntOptions.WorkingDir,
+		Args:              redactArgs(terragruntOptions, args),
+		TerragruntVersion: terragruntOptions.TerragruntVersion,
+		ExitCode:          exitCode,
+		DurationSeconds:   time.Since(startTime).Seconds(),
+	}
+
+	if len(args) > 0 {
+		record.Command = args[0]
+	}
+	if host, err := os.Hostname(); err == nil {
+		record.Host = host
+	}
+	if terragruntOptions.TerraformVersion != nil {
+		record.TerraformVersion = terragruntOptions.TerraformVersion.String()
+	}
+	// The last role in the chain is the one Terraform runs as
+	if iamRoleChain := terragruntOptions.IamRoleChain(); len(iamRoleChain) > 0 {
+		record.IamRoleArn = iamRoleChain[len(iamRoleChain)-1]
+	}
+
+	return record
+}
+
+// Return a copy of the given args with the secrets in them redacted, as for the logs, so they don't end up in the audit
+// log: the values of the -var args that look like secrets, and the secrets the Redactor of the given options knows
+func redactArgs(terragruntOptions *options.TerragruntOptions, args []string) []string {
+	redacted := util.RedactVarArgs(args)
+	for i, arg := range redacted {
+		redacted[i] = terragruntOptions.Redactor.Redact(arg)
+	}
+	return redacted
+}
+
+// Return the name of the user running Terragrunt. os/user doesn't work in some cross-compiled binaries, so fall back to
+// the environment variables the shell sets.
+func currentUser() string {
+	if currentUser, err := user.Current(); err == nil && currentUser.Username != "" {
+		return currentUser.Username
+	}
+	if username := os.Getenv("USER"); username != "" {
+		return username
+	}
+	return os.Getenv("USERNAME")
+}
+
+// Write the given record to the audit log in the given options
+func Write(record Record, terragruntOptions *options.TerragruntOptions) error {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if strings.HasPrefix(terragruntOptions.AuditLog, S3_URL_PREFIX) {
+		return writeToS3(encoded, record, terragruntOptions)
+	}
+	return appendToLocalFile(terragruntOptions.AuditLog, encoded)
+}
+
+// Append the given encoded record, as a single line, to the local file at the given path
+func appendToLocalFile(path string, encoded []byte) error {
+	localFileLock.Lock()
+	defer localFileLock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(encoded, '\n'))
+	return errors.WithStackTrace(err)
+}
+
+// Write the given encoded record to its own object in the S3 bucket in the audit log URL, as S3 objects can't be
+// appended to. The objects are named after the time and module of the run, so listing them sorts them by time.
+func writeToS3(encoded []byte, record Record, terragruntOptions *options.TerragruntOptions) error {
+	bucket, prefix := ParseS3Url(terragruntOptions.AuditLog)
+	if bucket == "" {
+		return errors.WithStackTrace(InvalidAuditLogUrl(terragruntOptions.AuditLog))
+	}
+
+	sess, err := aws_helper.CreateDefaultAwsSession(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	input := s3.PutObjectInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(S3ObjectKey(prefix, record)),
+		Body:                 bytes.NewReader(encoded),
+		ContentType:          aws.String("application/json"),
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+	}
+
+	_, err = s3.New(sess).PutObjectWithContext(terragruntOptions.GetContext(), &input)
+	return errors.WithStackTrace(err)
+}
+
+// Return the bucket and key prefix in the given s3://bucket/prefix URL
+func ParseS3Url(url string) (string, string) {
+	path := strings.TrimPrefix(url, S3_URL_PREFIX)
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], strings.Trim(parts[1], "/")
+}
+
+// Return the key of the S3 object for the given record under the given prefix
+func S3ObjectKey(prefix string, record Record) string {
+	name := fmt.Sprintf("%s-%s-%s.json", record.Timestamp.Format("20060102T150405.000000000Z"), record.Command, util.EncodeBase64Sha1(record.ModulePath))
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+// Custom error types
+
+type InvalidAuditLogUrl string
+
+func (url InvalidAuditLogUrl) Error() string {
+	return fmt.Sprintf("Invalid audit log URL %s. Expected a URL of the form s3://bucket/prefix.", string(url))
+}
@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+)
+
+func createAuditTestOptions(t *testing.T, configPath string) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return terragruntOptions
+}
+
+func TestNewRecord(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAuditTestOptions(t, "/live/prod/vpc/terraform.tfvars")
+	terragruntOptions.WorkingDir = "/home/ci/.terragrunt/abc/vpc"
+	terragruntOptions.TerragruntVersion = "v0.14.0"
+	terragruntOptions.TerraformVersion = version.Must(version.NewVersion("0.11.7"))
+	terragruntOptions.IamRoles = []string{"arn:aws:iam::111111111111:role/bastion"}
+	terragruntOptions.IamRole = "arn:aws:iam::222222222222:role/terraform"
+
+	startTime := time.Now().Add(-2 * time.Second)
+	record := NewRecord(terragruntOptions, []string{"apply", "-input=false"}, startTime, 1)
+
+	assert.Equal(t, startTime.UTC(), record.Timestamp)
+	assert.Equal(t, "/live/prod/vpc", record.ModulePath)
+	assert.Equal(t, "/home/ci/.terragrunt/abc/vpc", record.WorkingDir)
+	assert.Equal(t, "apply", record.Command)
+	assert.Equal(t, []string{"apply", "-input=false"}, record.Args)
+	assert.Equal(t, "0.11.7", record.TerraformVersion)
+	assert.Equal(t, "v0.14.0", record.TerragruntVersion)
+	assert.Equal(t, "arn:aws:iam::222222222222:role/terraform", record.IamRoleArn)
+	assert.Equal(t, 1, record.ExitCode)
+	assert.True(t, record.DurationSeconds >= 2, "Expected a duration of at least 2 seconds, but got %f", record.DurationSeconds)
+}
+
+func TestNewRecordRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAuditTestOptions(t, "/live/prod/db/terraform.tfvars")
+	terragruntOptions.Redactor = util.NewRedactor(nil)
+	terragruntOptions.Redactor.AddSecretsFromEnv(map[string]string{"TF_VAR_api_token": "abcdef123456"})
+
+	args := []string{"apply", "-var", "password=hunter22", "-var=name=db", "-var=token=abcdef123456x", "-var-file=prod.tfvars"}
+	record := NewRecord(terragruntOptions, args, time.Now(), 0)
+
+	assert.Equal(t, []string{"apply", "-var", "password=REDACTED", "-var=name=db", "-var=token=REDACTED", "-var-file=prod.tfvars"}, record.Args)
+	assert.Equal(t, "password=hunter22", args[2], "The given args must not change")
+
+	record = NewRecord(terragruntOptions, []string{"apply", "-var=label=abcdef123456"}, time.Now(), 0)
+	assert.Equal(t, []string{"apply", "-var=label=REDACTED"}, record.Args)
+}
+
+func TestWriteToLocalFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "audit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	auditLog := filepath.Join(tmpDir, "logs", "audit.jsonl")
+	terragruntOptions := createAuditTestOptions(t, "/live/prod/vpc/terraform.tfvars")
+	terragruntOptions.AuditLog = auditLog
+
+	// Write concurrently, as the xxx-all commands do, to check the records don't get interleaved
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			err := Write(NewRecord(terragruntOptions, []string{"plan"}, time.Now(), 0), terragruntOptions)
+			assert.Nil(t, err, "Unexpected error: %v", err)
+		}()
+	}
+	waitGroup.Wait()
+
+	file, err := os.Open(auditLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	numRecords := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &record), "Invalid record: %s", scanner.Text())
+		assert.Equal(t, "plan", record.Command)
+		numRecords++
+	}
+	assert.Equal(t, 10, numRecords)
+}
+
+func TestParseS3Url(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		url            string
+		expectedBucket string
+		expectedPrefix string
+	}{
+		{"s3://audit-bucket", "audit-bucket", ""},
+		{"s3://audit-bucket/", "audit-bucket", ""},
+		{"s3://audit-bucket/terragrunt", "audit-bucket", "terragrunt"},
+		{"s3://audit-bucket/terragrunt/prod/", "audit-bucket", "terragrunt/prod"},
+		{"s3://", "", ""},
+	}
+
+	for _, testCase := range testCases {
+		bucket, prefix := ParseS3Url(testCase.url)
+		assert.Equal(t, testCase.expectedBucket, bucket, testCase.url)
+		assert.Equal(t, testCase.expectedPrefix, prefix, testCase.url)
+	}
+}
+
+func TestS3ObjectKey(t *testing.T) {
+	t.Parallel()
+
+	record := Record{
+		Timestamp:  time.Date(2018, 5, 17, 9, 30, 0, 123, time.UTC),
+		Command:    "apply",
+		ModulePath: "/live/prod/vpc",
+	}
+
+	assert.Equal(t, "terragrunt/20180517T093000.000000123Z-apply-GvtwswoaKZv0U1qPF4TMJx4GdFg.json", S3ObjectKey("terragrunt", record))
+	assert.Equal(t, "20180517T093000.000000123Z-apply-GvtwswoaKZv0U1qPF4TMJx4GdFg.json", S3ObjectKey("", record))
+}
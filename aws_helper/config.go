@@ -3,18 +3,33 @@ package aws_helper
 import (
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
 	"time"
 )
 
 // Returns an AWS session object for the given region (required), profile name (optional), and IAM role to assume
-// (optional), ensuring that the credentials are available
+// (optional), ensuring that the credentials are available. If the given options have an AwsSessionFactory, the session
+// comes from that instead.
 func CreateAwsSession(awsRegion, customS3Endpoint string, awsProfile string, iamRoleArn string, terragruntOptions *options.TerragruntOptions) (*session.Session, error) {
+	if terragruntOptions.AwsSessionFactory != nil {
+		sess, err := terragruntOptions.AwsSessionFactory(awsRegion, customS3Endpoint, awsProfile, iamRoleArn)
+		if err != nil {
+			return nil, errors.WithStackTraceAndPrefix(err, "Error initializing session")
+		}
+		return sess, nil
+	}
+
+	if err := terragruntOptions.CheckNetworkAccess("call the AWS APIs"); err != nil {
+		return nil, err
+	}
+
 	defaultResolver := endpoints.DefaultResolver()
 	s3CustResolverFn := func(service, region string, optFns ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
 		if service == "s3" && customS3Endpoint != "" {
@@ -27,9 +42,15 @@ func CreateAwsSession(awsRegion, customS3Endpoint string, awsProfile string, iam
 		return defaultResolver.EndpointFor(service, region, optFns...)
 	}
 
+	httpClient, err := terragruntOptions.HttpClient(0)
+	if err != nil {
+		return nil, err
+	}
+
 	var awsConfig = aws.Config{
 		Region:           aws.String(awsRegion),
 		EndpointResolver: endpoints.ResolverFunc(s3CustResolverFn),
+		HTTPClient:       httpClient,
 	}
 
 	sess, err := session.NewSessionWithOptions(session.Options{
@@ -43,8 +64,11 @@ func CreateAwsSession(awsRegion, customS3Endpoint string, awsProfile string, iam
 
 	if iamRoleArn != "" {
 		sess.Config.Credentials = stscreds.NewCredentials(sess, iamRoleArn)
-	} else if terragruntOptions.IamRole != "" {
-		sess.Config.Credentials = stscreds.NewCredentials(sess, terragruntOptions.IamRole)
+	} else if iamRoleChain := terragruntOptions.IamRoleChain(); len(iamRoleChain) > 0 || terragruntOptions.IamWebIdentityToken != "" {
+		sess.Config.Credentials, err = ChainedIamRoleCredentials(sess, iamRoleChain, terragruntOptions)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	_, err = sess.Config.Credentials.Get()
@@ -55,29 +79,235 @@ func CreateAwsSession(awsRegion, customS3Endpoint string, awsProfile string, iam
 	return sess, nil
 }
 
-// Make API calls to AWS to assume the IAM role specified and return the temporary AWS credentials to use that role
-func AssumeIamRole(iamRoleArn string) (*sts.Credentials, error) {
-	sess, err := session.NewSession()
+// Returns an AWS session object with the default region and credentials from the standard AWS environment variables
+// and config files, or, if the given options have an AwsSessionFactory, from that
+func CreateDefaultAwsSession(terragruntOptions *options.TerragruntOptions) (*session.Session, error) {
+	if terragruntOptions.AwsSessionFactory != nil {
+		sess, err := terragruntOptions.AwsSessionFactory("", "", "", "")
+		if err != nil {
+			return nil, errors.WithStackTraceAndPrefix(err, "Error initializing session")
+		}
+		return sess, nil
+	}
+
+	if err := terragruntOptions.CheckNetworkAccess("call the AWS APIs"); err != nil {
+		return nil, err
+	}
+
+	httpClient, err := terragruntOptions.HttpClient(0)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := session.NewSession(&aws.Config{HTTPClient: httpClient})
 	if err != nil {
 		return nil, errors.WithStackTrace(err)
 	}
+	return sess, nil
+}
 
-	_, err = sess.Config.Credentials.Get()
+// Returns an AWS session object with the region, profile, and credentials that the AWS environment variables in the
+// given env set, rather than those of the Terragrunt process, so it sees the same account as a Terraform run with that
+// env. If the given options have an AwsSessionFactory, the session comes from that, with the credentials of the env.
+func CreateAwsSessionFromEnv(env map[string]string, terragruntOptions *options.TerragruntOptions) (*session.Session, error) {
+	// The AWS SDKs use AWS_REGION over AWS_DEFAULT_REGION
+	awsRegion := env["AWS_REGION"]
+	if awsRegion == "" {
+		awsRegion = env["AWS_DEFAULT_REGION"]
+	}
+	awsProfile := env["AWS_PROFILE"]
+
+	var sess *session.Session
+	var err error
+
+	if terragruntOptions.AwsSessionFactory != nil {
+		sess, err = terragruntOptions.AwsSessionFactory(awsRegion, "", awsProfile, "")
+		if err != nil {
+			return nil, errors.WithStackTraceAndPrefix(err, "Error initializing session")
+		}
+	} else {
+		if err := terragruntOptions.CheckNetworkAccess("call the AWS APIs"); err != nil {
+			return nil, err
+		}
+
+		httpClient, err := terragruntOptions.HttpClient(0)
+		if err != nil {
+			return nil, err
+		}
+
+		awsConfig := aws.Config{HTTPClient: httpClient}
+		if awsRegion != "" {
+			awsConfig.Region = aws.String(awsRegion)
+		}
+
+		sess, err = session.NewSessionWithOptions(session.Options{
+			Config:            awsConfig,
+			Profile:           awsProfile,
+			SharedConfigState: session.SharedConfigEnable,
+		})
+		if err != nil {
+			return nil, errors.WithStackTraceAndPrefix(err, "Error initializing session")
+		}
+	}
+
+	// As in the AWS SDKs, credentials in the env win over those of the profile
+	if env["AWS_ACCESS_KEY_ID"] != "" && env["AWS_SECRET_ACCESS_KEY"] != "" {
+		sess.Config.Credentials = credentials.NewStaticCredentials(env["AWS_ACCESS_KEY_ID"], env["AWS_SECRET_ACCESS_KEY"], env["AWS_SESSION_TOKEN"])
+	}
+
+	return sess, nil
+}
+
+// Returns an AWS session object like CreateDefaultAwsSession, but that uses the IAM roles (or web identity token) in the
+// given options, if any, and the given region, if not empty. This is the session the interpolation functions that call
+// AWS use, so they see the same account as the Terraform run.
+func CreateDefaultAwsSessionWithIamRoles(awsRegion string, terragruntOptions *options.TerragruntOptions) (*session.Session, error) {
+	sess, err := CreateDefaultAwsSession(terragruntOptions)
 	if err != nil {
-		return nil, errors.WithStackTraceAndPrefix(err, "Error finding AWS credentials (did you set the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables?)")
+		return nil, err
 	}
 
-	stsClient := sts.New(sess)
+	if awsRegion != "" {
+		sess = sess.Copy(&aws.Config{Region: aws.String(awsRegion)})
+	}
 
+	if iamRoleChain := terragruntOptions.IamRoleChain(); len(iamRoleChain) > 0 || terragruntOptions.IamWebIdentityToken != "" {
+		sess.Config.Credentials, err = ChainedIamRoleCredentials(sess, iamRoleChain, terragruntOptions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sess, nil
+}
+
+// Return credentials for the given session that assume each of the given IAM roles in turn, using the credentials of
+// the role before it. The first role is assumed with the credentials of the session or, if the given options have an
+// IamWebIdentityToken, with that token. The roles are assumed lazily, the first time the credentials are used, and
+// assumed again when they expire.
+func ChainedIamRoleCredentials(sess *session.Session, iamRoleArns []string, terragruntOptions *options.TerragruntOptions) (*credentials.Credentials, error) {
+	creds := sess.Config.Credentials
+
+	if terragruntOptions.IamWebIdentityToken != "" {
+		if len(iamRoleArns) == 0 {
+			return nil, errors.WithStackTrace(WebIdentityTokenWithoutIamRole{})
+		}
+		anonymousSession := sess.Copy(&aws.Config{Credentials: credentials.AnonymousCredentials})
+		creds = credentials.NewCredentials(&webIdentityCredentialsProvider{
+			stsClient:         sts.New(anonymousSession),
+			iamRoleArn:        iamRoleArns[0],
+			terragruntOptions: terragruntOptions,
+		})
+		iamRoleArns = iamRoleArns[1:]
+	}
+
+	for _, iamRoleArn := range iamRoleArns {
+		creds = stscreds.NewCredentials(sess.Copy(&aws.Config{Credentials: creds}), iamRoleArn)
+	}
+	return creds, nil
+}
+
+// Make API calls to AWS to assume the IAM role specified and return the temporary AWS credentials to use that role. The
+// API calls are aborted if the context of the given options is cancelled.
+func AssumeIamRole(iamRoleArn string, terragruntOptions *options.TerragruntOptions) (*sts.Credentials, error) {
+	return AssumeIamRoleChain([]string{iamRoleArn}, terragruntOptions)
+}
+
+// Make API calls to AWS to assume each of the IAM roles specified in turn, using the credentials of the role before
+// it, and return the temporary AWS credentials to use the last role. This allows, for example, going through a role
+// in a bastion account to get to a role in a workload account. If the given options have an IamWebIdentityToken, the
+// first role is assumed with that token, so no AWS credentials are needed at all. The API calls are aborted if the
+// context of the given options is cancelled.
+func AssumeIamRoleChain(iamRoleArns []string, terragruntOptions *options.TerragruntOptions) (*sts.Credentials, error) {
+	sess, err := CreateDefaultAwsSession(terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	usesWebIdentity := terragruntOptions.IamWebIdentityToken != ""
+	if usesWebIdentity && len(iamRoleArns) == 0 {
+		return nil, errors.WithStackTrace(WebIdentityTokenWithoutIamRole{})
+	}
+
+	if !usesWebIdentity {
+		_, err = sess.Config.Credentials.Get()
+		if err != nil {
+			return nil, errors.WithStackTraceAndPrefix(err, "Error finding AWS credentials (did you set the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables?)")
+		}
+	}
+
+	return assumeIamRoleChain(iamRoleArns, terragruntOptions, func(creds *sts.Credentials) stsiface.STSAPI {
+		if creds == nil && usesWebIdentity {
+			return sts.New(sess, aws.NewConfig().WithCredentials(credentials.AnonymousCredentials))
+		} else if creds == nil {
+			return sts.New(sess)
+		}
+		return sts.New(sess, aws.NewConfig().WithCredentials(credentials.NewStaticCredentials(aws.StringValue(creds.AccessKeyId), aws.StringValue(creds.SecretAccessKey), aws.StringValue(creds.SessionToken))))
+	})
+}
+
+// Assume each of the given IAM roles in turn, using the STS client that newStsClient returns for the credentials of the
+// role before it, which are nil for the first role. If the given options have an IamWebIdentityToken, the first role
+// is assumed with that token.
+func assumeIamRoleChain(iamRoleArns []string, terragruntOptions *options.TerragruntOptions, newStsClient func(creds *sts.Credentials) stsiface.STSAPI) (*sts.Credentials, error) {
+	var creds *sts.Credentials
+
+	for i, iamRoleArn := range iamRoleArns {
+		assumeIamRole := AssumeIamRoleWithClient
+		if i == 0 && terragruntOptions.IamWebIdentityToken != "" {
+			assumeIamRole = AssumeIamRoleWithWebIdentityWithClient
+		}
+
+		nextCreds, err := assumeIamRole(newStsClient(creds), iamRoleArn, terragruntOptions)
+		if err != nil {
+			return nil, errors.WithStackTrace(FailedToAssumeIamRole{IamRoleArn: iamRoleArn, Underlying: err})
+		}
+		creds = nextCreds
+	}
+
+	return creds, nil
+}
+
+// Assume the IAM role specified using the given STS client and return the temporary AWS credentials to use that role
+func AssumeIamRoleWithClient(stsClient stsiface.STSAPI, iamRoleArn string, terragruntOptions *options.TerragruntOptions) (*sts.Credentials, error) {
 	input := sts.AssumeRoleInput{
 		RoleArn:         aws.String(iamRoleArn),
 		RoleSessionName: aws.String(fmt.Sprintf("terragrunt-%d", time.Now().UTC().UnixNano())),
 	}
 
-	output, err := stsClient.AssumeRole(&input)
+	output, err := stsClient.AssumeRoleWithContext(terragruntOptions.GetContext(), &input)
 	if err != nil {
 		return nil, errors.WithStackTrace(err)
 	}
 
 	return output.Credentials, nil
 }
+
+// Return the id of the AWS account the credentials of the given STS client belong to
+func GetAwsAccountId(stsClient stsiface.STSAPI, terragruntOptions *options.TerragruntOptions) (string, error) {
+	identity, err := stsClient.GetCallerIdentityWithContext(terragruntOptions.GetContext(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return aws.StringValue(identity.Account), nil
+}
+
+// Custom error types
+
+type FailedToAssumeIamRole struct {
+	IamRoleArn string
+	Underlying error
+}
+
+func (err FailedToAssumeIamRole) Error() string {
+	return fmt.Sprintf("Error assuming IAM role %s: %v", err.IamRoleArn, err.Underlying)
+}
+
+func (err FailedToAssumeIamRole) Unwrap() error {
+	return err.Underlying
+}
+
+func (err FailedToAssumeIamRole) Is(target error) bool {
+	return target == errors.ErrAssumeRoleFailed
+}
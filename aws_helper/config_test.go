@@ -0,0 +1,208 @@
+package aws_helper
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+// A mock STS client. Calling any method it doesn't override panics, since the embedded interface is nil.
+type mockStsClient struct {
+	stsiface.STSAPI
+	assumedRoleArn string
+	accountId      string
+	err            error
+
+	// The access key of the credentials the client was created with, and the one it returns when assuming a role
+	callerAccessKey  string
+	assumedAccessKey string
+
+	// The web identity token the role was assumed with, if any
+	webIdentityToken string
+}
+
+func (client *mockStsClient) AssumeRoleWithContext(ctx aws.Context, input *sts.AssumeRoleInput, opts ...request.Option) (*sts.AssumeRoleOutput, error) {
+	if client.err != nil {
+		return nil, client.err
+	}
+	client.assumedRoleArn = aws.StringValue(input.RoleArn)
+	accessKey := client.assumedAccessKey
+	if accessKey == "" {
+		accessKey = "mock-access-key"
+	}
+	return &sts.AssumeRoleOutput{Credentials: &sts.Credentials{AccessKeyId: aws.String(accessKey)}}, nil
+}
+
+func (client *mockStsClient) AssumeRoleWithWebIdentityWithContext(ctx aws.Context, input *sts.AssumeRoleWithWebIdentityInput, opts ...request.Option) (*sts.AssumeRoleWithWebIdentityOutput, error) {
+	if client.err != nil {
+		return nil, client.err
+	}
+	client.assumedRoleArn = aws.StringValue(input.RoleArn)
+	client.webIdentityToken = aws.StringValue(input.WebIdentityToken)
+	return &sts.AssumeRoleWithWebIdentityOutput{Credentials: &sts.Credentials{AccessKeyId: aws.String(client.assumedAccessKey)}}, nil
+}
+
+func (client *mockStsClient) GetCallerIdentityWithContext(ctx aws.Context, input *sts.GetCallerIdentityInput, opts ...request.Option) (*sts.GetCallerIdentityOutput, error) {
+	if client.err != nil {
+		return nil, client.err
+	}
+	return &sts.GetCallerIdentityOutput{Account: aws.String(client.accountId)}, nil
+}
+
+func createAwsHelperTestOptions(t *testing.T) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("aws_helper_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return terragruntOptions
+}
+
+func TestAssumeIamRoleWithClient(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAwsHelperTestOptions(t)
+	client := &mockStsClient{}
+
+	credentials, err := AssumeIamRoleWithClient(client, "arn:aws:iam::123456789012:role/test", terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/test", client.assumedRoleArn)
+	assert.Equal(t, "mock-access-key", aws.StringValue(credentials.AccessKeyId))
+
+	client = &mockStsClient{err: awserr.New("AccessDenied", "not allowed", nil)}
+	_, err = AssumeIamRoleWithClient(client, "arn:aws:iam::123456789012:role/test", terragruntOptions)
+	assert.NotNil(t, err)
+}
+
+func TestAssumeIamRoleChain(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAwsHelperTestOptions(t)
+	iamRoleArns := []string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::222222222222:role/prod"}
+	clients := []*mockStsClient{}
+
+	credentials, err := assumeIamRoleChain(iamRoleArns, terragruntOptions, func(creds *sts.Credentials) stsiface.STSAPI {
+		client := &mockStsClient{assumedAccessKey: fmt.Sprintf("hop-%d", len(clients)+1)}
+		if creds != nil {
+			client.callerAccessKey = aws.StringValue(creds.AccessKeyId)
+		}
+		clients = append(clients, client)
+		return client
+	})
+
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "hop-2", aws.StringValue(credentials.AccessKeyId))
+	if assert.Len(t, clients, 2) {
+		// The first role is assumed with the original credentials, and each other role with the ones of the role before
+		assert.Equal(t, "", clients[0].callerAccessKey)
+		assert.Equal(t, "arn:aws:iam::111111111111:role/bastion", clients[0].assumedRoleArn)
+		assert.Equal(t, "hop-1", clients[1].callerAccessKey)
+		assert.Equal(t, "arn:aws:iam::222222222222:role/prod", clients[1].assumedRoleArn)
+	}
+}
+
+func TestAssumeIamRoleChainFailure(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAwsHelperTestOptions(t)
+	iamRoleArns := []string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::222222222222:role/prod"}
+	calls := 0
+
+	_, err := assumeIamRoleChain(iamRoleArns, terragruntOptions, func(creds *sts.Credentials) stsiface.STSAPI {
+		calls++
+		if creds == nil {
+			return &mockStsClient{}
+		}
+		return &mockStsClient{err: awserr.New("AccessDenied", "not allowed", nil)}
+	})
+
+	failedErr, isFailedErr := errors.Unwrap(err).(FailedToAssumeIamRole)
+	if assert.True(t, isFailedErr, "Expected a FailedToAssumeIamRole error but got: %v", err) {
+		assert.Equal(t, "arn:aws:iam::222222222222:role/prod", failedErr.IamRoleArn)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestGetAwsAccountId(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAwsHelperTestOptions(t)
+
+	accountId, err := GetAwsAccountId(&mockStsClient{accountId: "123456789012"}, terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "123456789012", accountId)
+}
+
+func TestCreateAwsSessionUsesSessionFactory(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAwsHelperTestOptions(t)
+	factoryArgs := []string{}
+	terragruntOptions.AwsSessionFactory = func(awsRegion string, customS3Endpoint string, awsProfile string, iamRoleArn string) (*session.Session, error) {
+		factoryArgs = append(factoryArgs, awsRegion, customS3Endpoint, awsProfile, iamRoleArn)
+		return session.NewSession(aws.NewConfig().WithRegion(awsRegion))
+	}
+
+	sess, err := CreateAwsSession("eu-west-1", "http://localhost:4572", "dev", "arn:aws:iam::123456789012:role/test", terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "eu-west-1", aws.StringValue(sess.Config.Region))
+	assert.Equal(t, []string{"eu-west-1", "http://localhost:4572", "dev", "arn:aws:iam::123456789012:role/test"}, factoryArgs)
+
+	_, err = CreateDefaultAwsSession(terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, []string{"", "", "", ""}, factoryArgs[4:])
+}
+
+func TestCreateAwsSessionFromEnv(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAwsHelperTestOptions(t)
+	factoryArgs := []string{}
+	terragruntOptions.AwsSessionFactory = func(awsRegion string, customS3Endpoint string, awsProfile string, iamRoleArn string) (*session.Session, error) {
+		factoryArgs = append(factoryArgs, awsRegion, customS3Endpoint, awsProfile, iamRoleArn)
+		return session.NewSession(aws.NewConfig().WithRegion(awsRegion))
+	}
+
+	env := map[string]string{
+		"AWS_DEFAULT_REGION":    "us-west-2",
+		"AWS_REGION":            "eu-west-1",
+		"AWS_PROFILE":           "dev",
+		"AWS_ACCESS_KEY_ID":     "access-key",
+		"AWS_SECRET_ACCESS_KEY": "secret-key",
+		"AWS_SESSION_TOKEN":     "session-token",
+	}
+
+	sess, err := CreateAwsSessionFromEnv(env, terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, []string{"eu-west-1", "", "dev", ""}, factoryArgs)
+
+	creds, err := sess.Config.Credentials.Get()
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "access-key", creds.AccessKeyID)
+	assert.Equal(t, "secret-key", creds.SecretAccessKey)
+	assert.Equal(t, "session-token", creds.SessionToken)
+
+	_, err = CreateAwsSessionFromEnv(map[string]string{"AWS_DEFAULT_REGION": "us-west-2"}, terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, []string{"us-west-2", "", "", ""}, factoryArgs[4:])
+}
+
+func TestCreateAwsSessionReturnsSessionFactoryErrors(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAwsHelperTestOptions(t)
+	terragruntOptions.AwsSessionFactory = func(awsRegion string, customS3Endpoint string, awsProfile string, iamRoleArn string) (*session.Session, error) {
+		return nil, awserr.New("NoCredentialProviders", "no valid providers in chain", nil)
+	}
+
+	_, err := CreateAwsSession("eu-west-1", "", "", "", terragruntOptions)
+	assert.NotNil(t, err)
+}
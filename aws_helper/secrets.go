@@ -0,0 +1,97 @@
+package aws_helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// Return the value of the SSM Parameter Store parameter with the given name, decrypted if it's a SecureString
+func GetSsmParameter(ssmClient ssmiface.SSMAPI, name string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	input := ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	}
+
+	output, err := ssmClient.GetParameterWithContext(terragruntOptions.GetContext(), &input)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return aws.StringValue(output.Parameter.Value), nil
+}
+
+// Return the current value of the Secrets Manager secret with the given name or ARN. If key is not empty, the secret
+// must be a JSON object, as the secrets Secrets Manager creates for databases are, and the value of that key in it is
+// returned instead.
+func GetSecretsManagerSecret(secretsManagerClient secretsmanageriface.SecretsManagerAPI, secretId string, key string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	input := secretsmanager.GetSecretValueInput{SecretId: aws.String(secretId)}
+
+	output, err := secretsManagerClient.GetSecretValueWithContext(terragruntOptions.GetContext(), &input)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	secret := aws.StringValue(output.SecretString)
+	if output.SecretString == nil {
+		secret = string(output.SecretBinary)
+	}
+
+	if key == "" {
+		return secret, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", errors.WithStackTrace(SecretNotJsonObject(secretId))
+	}
+
+	value, found := fields[key]
+	if !found {
+		return "", errors.WithStackTrace(SecretKeyNotFound{SecretId: secretId, Key: key})
+	}
+
+	if str, isString := value.(string); isString {
+		return str, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return string(encoded), nil
+}
+
+// Return the region in the given ARN, or an empty string if it's not an ARN. This lets a Secrets Manager secret in any
+// region be read by its ARN.
+func RegionFromArn(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[3]
+}
+
+// Custom error types
+
+type SecretNotJsonObject string
+
+func (secretId SecretNotJsonObject) Error() string {
+	return fmt.Sprintf("The Secrets Manager secret %s is not a JSON object, so a key can't be read from it", string(secretId))
+}
+
+type SecretKeyNotFound struct {
+	SecretId string
+	Key      string
+}
+
+func (err SecretKeyNotFound) Error() string {
+	return fmt.Sprintf("The Secrets Manager secret %s has no key named %s", err.SecretId, err.Key)
+}
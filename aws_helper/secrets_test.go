@@ -0,0 +1,90 @@
+package aws_helper
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSsmClient struct {
+	ssmiface.SSMAPI
+	requestedName  string
+	withDecryption bool
+}
+
+func (client *mockSsmClient) GetParameterWithContext(ctx aws.Context, input *ssm.GetParameterInput, opts ...request.Option) (*ssm.GetParameterOutput, error) {
+	client.requestedName = aws.StringValue(input.Name)
+	client.withDecryption = aws.BoolValue(input.WithDecryption)
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Value: aws.String("ami-0123456789")}}, nil
+}
+
+type mockSecretsManagerClient struct {
+	secretsmanageriface.SecretsManagerAPI
+	secretString *string
+	secretBinary []byte
+}
+
+func (client *mockSecretsManagerClient) GetSecretValueWithContext(ctx aws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	return &secretsmanager.GetSecretValueOutput{SecretString: client.secretString, SecretBinary: client.secretBinary}, nil
+}
+
+func TestGetSsmParameter(t *testing.T) {
+	t.Parallel()
+
+	client := &mockSsmClient{}
+	value, err := GetSsmParameter(client, "/prod/ami-id", createAwsHelperTestOptions(t))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "ami-0123456789", value)
+	assert.Equal(t, "/prod/ami-id", client.requestedName)
+	assert.True(t, client.withDecryption)
+}
+
+func TestGetSecretsManagerSecret(t *testing.T) {
+	t.Parallel()
+
+	jsonSecret := &mockSecretsManagerClient{secretString: aws.String(`{"username": "admin", "password": "hunter2", "port": 5432}`)}
+	plainSecret := &mockSecretsManagerClient{secretString: aws.String("hunter2")}
+	binarySecret := &mockSecretsManagerClient{secretBinary: []byte("binary-secret")}
+
+	testCases := []struct {
+		client        *mockSecretsManagerClient
+		key           string
+		expectedValue string
+		expectedErr   error
+	}{
+		{plainSecret, "", "hunter2", nil},
+		{binarySecret, "", "binary-secret", nil},
+		{jsonSecret, "", `{"username": "admin", "password": "hunter2", "port": 5432}`, nil},
+		{jsonSecret, "password", "hunter2", nil},
+		{jsonSecret, "port", "5432", nil},
+		{jsonSecret, "host", "", SecretKeyNotFound{}},
+		{plainSecret, "password", "", SecretNotJsonObject("")},
+	}
+
+	for _, testCase := range testCases {
+		value, err := GetSecretsManagerSecret(testCase.client, "prod/db", testCase.key, createAwsHelperTestOptions(t))
+		if testCase.expectedErr != nil {
+			if assert.Error(t, err) {
+				assert.IsType(t, testCase.expectedErr, errors.Unwrap(err))
+			}
+		} else {
+			assert.Nil(t, err, "Unexpected error: %v", err)
+			assert.Equal(t, testCase.expectedValue, value)
+		}
+	}
+}
+
+func TestRegionFromArn(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "eu-west-1", RegionFromArn("arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/db-AbCdEf"))
+	assert.Equal(t, "", RegionFromArn("prod/db"))
+	assert.Equal(t, "", RegionFromArn("arn:aws:secretsmanager"))
+}
@@ -0,0 +1,101 @@
+package aws_helper
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Refresh the credentials of a role assumed with a web identity this long before they expire
+const WEB_IDENTITY_CREDENTIALS_EXPIRY_WINDOW = 1 * time.Minute
+
+// Return the web identity token in the given value, which is either the token itself, or the path to a file that
+// contains it, such as the file in AWS_WEB_IDENTITY_TOKEN_FILE on EKS
+func ReadWebIdentityToken(tokenOrPath string) (string, error) {
+	if !util.FileExists(tokenOrPath) {
+		return strings.TrimSpace(tokenOrPath), nil
+	}
+
+	// Read the file every time, as some CI systems rotate the token in the file during long runs
+	token, err := util.ReadFileAsString(tokenOrPath)
+	if err != nil {
+		return "", err
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", errors.WithStackTrace(EmptyWebIdentityTokenFile(tokenOrPath))
+	}
+	return token, nil
+}
+
+// Assume the IAM role specified with the web identity token in the given options, using the given STS client, and
+// return the temporary AWS credentials to use that role. AssumeRoleWithWebIdentity doesn't need AWS credentials, so
+// the client may be anonymous.
+func AssumeIamRoleWithWebIdentityWithClient(stsClient stsiface.STSAPI, iamRoleArn string, terragruntOptions *options.TerragruntOptions) (*sts.Credentials, error) {
+	token, err := ReadWebIdentityToken(terragruntOptions.IamWebIdentityToken)
+	if err != nil {
+		return nil, err
+	}
+
+	input := sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(iamRoleArn),
+		RoleSessionName:  aws.String(fmt.Sprintf("terragrunt-%d", time.Now().UTC().UnixNano())),
+		WebIdentityToken: aws.String(token),
+	}
+
+	output, err := stsClient.AssumeRoleWithWebIdentityWithContext(terragruntOptions.GetContext(), &input)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	return output.Credentials, nil
+}
+
+// A credentials provider for the AWS SDK that assumes an IAM role with the web identity token in the given options,
+// and assumes it again when the credentials are about to expire
+type webIdentityCredentialsProvider struct {
+	credentials.Expiry
+
+	stsClient         stsiface.STSAPI
+	iamRoleArn        string
+	terragruntOptions *options.TerragruntOptions
+}
+
+func (provider *webIdentityCredentialsProvider) Retrieve() (credentials.Value, error) {
+	creds, err := AssumeIamRoleWithWebIdentityWithClient(provider.stsClient, provider.iamRoleArn, provider.terragruntOptions)
+	if err != nil {
+		return credentials.Value{ProviderName: "WebIdentityCredentialsProvider"}, err
+	}
+
+	provider.SetExpiration(aws.TimeValue(creds.Expiration), WEB_IDENTITY_CREDENTIALS_EXPIRY_WINDOW)
+
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(creds.AccessKeyId),
+		SecretAccessKey: aws.StringValue(creds.SecretAccessKey),
+		SessionToken:    aws.StringValue(creds.SessionToken),
+		ProviderName:    "WebIdentityCredentialsProvider",
+	}, nil
+}
+
+// Custom error types
+
+type EmptyWebIdentityTokenFile string
+
+func (path EmptyWebIdentityTokenFile) Error() string {
+	return fmt.Sprintf("The web identity token file %s is empty", string(path))
+}
+
+type WebIdentityTokenWithoutIamRole struct{}
+
+func (err WebIdentityTokenWithoutIamRole) Error() string {
+	return "A web identity token was set, but no IAM role to assume with it. Set one with --terragrunt-iam-role, --terragrunt-iam-roles, or iam_roles in the Terragrunt config."
+}
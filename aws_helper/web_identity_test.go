@@ -0,0 +1,91 @@
+package aws_helper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadWebIdentityToken(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "web-identity-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tokenFile := filepath.Join(tmpDir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("token-from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	emptyTokenFile := filepath.Join(tmpDir, "empty-token")
+	if err := ioutil.WriteFile(emptyTokenFile, []byte("\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := ReadWebIdentityToken("eyJhbGciOiJSUzI1NiJ9.token")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "eyJhbGciOiJSUzI1NiJ9.token", token)
+
+	token, err = ReadWebIdentityToken(tokenFile)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "token-from-file", token)
+
+	_, err = ReadWebIdentityToken(emptyTokenFile)
+	_, isEmptyTokenFileErr := errors.Unwrap(err).(EmptyWebIdentityTokenFile)
+	assert.True(t, isEmptyTokenFileErr, "Expected an EmptyWebIdentityTokenFile error but got: %v", err)
+}
+
+func TestAssumeIamRoleChainWithWebIdentity(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAwsHelperTestOptions(t)
+	terragruntOptions.IamWebIdentityToken = "oidc-token"
+	iamRoleArns := []string{"arn:aws:iam::111111111111:role/ci", "arn:aws:iam::222222222222:role/prod"}
+	clients := []*mockStsClient{}
+
+	credentials, err := assumeIamRoleChain(iamRoleArns, terragruntOptions, func(creds *sts.Credentials) stsiface.STSAPI {
+		client := &mockStsClient{assumedAccessKey: "hop-" + iamRoleArns[len(clients)]}
+		clients = append(clients, client)
+		return client
+	})
+
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "hop-arn:aws:iam::222222222222:role/prod", aws.StringValue(credentials.AccessKeyId))
+	if assert.Len(t, clients, 2) {
+		// Only the first role is assumed with the web identity token
+		assert.Equal(t, "oidc-token", clients[0].webIdentityToken)
+		assert.Equal(t, "arn:aws:iam::111111111111:role/ci", clients[0].assumedRoleArn)
+		assert.Equal(t, "", clients[1].webIdentityToken)
+		assert.Equal(t, "arn:aws:iam::222222222222:role/prod", clients[1].assumedRoleArn)
+	}
+}
+
+func TestWebIdentityTokenWithoutIamRole(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createAwsHelperTestOptions(t)
+	terragruntOptions.IamWebIdentityToken = "oidc-token"
+
+	sess, err := session.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ChainedIamRoleCredentials(sess, []string{}, terragruntOptions)
+	_, isWithoutIamRoleErr := errors.Unwrap(err).(WebIdentityTokenWithoutIamRole)
+	assert.True(t, isWithoutIamRoleErr, "Expected a WebIdentityTokenWithoutIamRole error but got: %v", err)
+
+	_, err = AssumeIamRoleChain([]string{}, terragruntOptions)
+	_, isWithoutIamRoleErr = errors.Unwrap(err).(WebIdentityTokenWithoutIamRole)
+	assert.True(t, isWithoutIamRoleErr, "Expected a WebIdentityTokenWithoutIamRole error but got: %v", err)
+}
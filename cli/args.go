@@ -5,8 +5,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gruntwork-io/terragrunt/audit"
 	"github.com/gruntwork-io/terragrunt/config"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
@@ -20,6 +25,7 @@ func ParseTerragruntOptions(cliContext *cli.Context) (*options.TerragruntOptions
 	if err != nil {
 		return nil, err
 	}
+	terragruntOptions.TerragruntVersion = cliContext.App.Version
 	return terragruntOptions, nil
 }
 
@@ -45,12 +51,31 @@ func parseTerragruntOptionsFromArgs(args []string, writer, errWriter io.Writer)
 		return nil, err
 	}
 
+	args, rcFiles, err := addDefaultsFromRcFiles(args, workingDir, userHomeDir())
+	if err != nil {
+		return nil, err
+	}
+
 	terragruntConfigPath, err := parseStringArg(args, OPT_TERRAGRUNT_CONFIG, os.Getenv("TERRAGRUNT_CONFIG"))
 	if err != nil {
 		return nil, err
 	}
+	searchParentDirs := parseBooleanArg(args, OPT_TERRAGRUNT_SEARCH_PARENT_DIRS, os.Getenv("TERRAGRUNT_SEARCH_PARENT_DIRS") == "true" || os.Getenv("TERRAGRUNT_SEARCH_PARENT_DIRS") == "1")
+	configFoundInParentDir := false
 	if terragruntConfigPath == "" {
 		terragruntConfigPath = config.DefaultConfigPath(workingDir)
+
+		if searchParentDirs && !util.FileExists(terragruntConfigPath) && searchesParentDirsForConfig(firstArg(filterTerragruntArgs(args))) {
+			parentConfigPath, err := config.FindConfigPathInParentFolders(workingDir, options.DEFAULT_MAX_FOLDERS_TO_CHECK)
+			if err != nil {
+				return nil, err
+			}
+			if parentConfigPath != "" {
+				terragruntConfigPath = parentConfigPath
+				workingDir = filepath.Dir(parentConfigPath)
+				configFoundInParentDir = true
+			}
+		}
 	}
 
 	terraformPath, err := parseStringArg(args, OPT_TERRAGRUNT_TFPATH, os.Getenv("TERRAGRUNT_TFPATH"))
@@ -61,6 +86,11 @@ func parseTerragruntOptionsFromArgs(args []string, writer, errWriter io.Writer)
 		terraformPath = "terraform"
 	}
 
+	terraformDockerImage, err := parseStringArg(args, OPT_TERRAGRUNT_DOCKER_IMAGE, os.Getenv("TERRAGRUNT_DOCKER_IMAGE"))
+	if err != nil {
+		return nil, err
+	}
+
 	terraformSource, err := parseStringArg(args, OPT_TERRAGRUNT_SOURCE, os.Getenv("TERRAGRUNT_SOURCE"))
 	if err != nil {
 		return nil, err
@@ -75,38 +105,344 @@ func parseTerragruntOptionsFromArgs(args []string, writer, errWriter io.Writer)
 		return nil, err
 	}
 
+	iamRoles, err := parseStringListArg(args, OPT_TERRAGRUNT_IAM_ROLES, os.Getenv("TERRAGRUNT_IAM_ROLES"))
+	if err != nil {
+		return nil, err
+	}
+
+	iamWebIdentityToken, err := parseStringArg(args, OPT_TERRAGRUNT_IAM_WEB_IDENTITY_TOKEN, os.Getenv("TERRAGRUNT_IAM_WEB_IDENTITY_TOKEN"))
+	if err != nil {
+		return nil, err
+	}
+
+	gitDiffRef, err := parseStringArg(args, OPT_TERRAGRUNT_GIT_DIFF, os.Getenv("TERRAGRUNT_GIT_DIFF"))
+	if err != nil {
+		return nil, err
+	}
+
+	modulesThatInclude, err := parseStringListArg(args, OPT_TERRAGRUNT_MODULES_THAT_INCLUDE, os.Getenv("TERRAGRUNT_MODULES_THAT_INCLUDE"))
+	if err != nil {
+		return nil, err
+	}
+
+	extraDependencies, err := parseStringListArg(args, OPT_TERRAGRUNT_EXTRA_DEPENDENCIES, os.Getenv("TERRAGRUNT_EXTRA_DEPENDENCIES"))
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := parseMultiStringArg(args, OPT_TERRAGRUNT_TARGET, os.Getenv("TERRAGRUNT_TARGET"))
+	if err != nil {
+		return nil, err
+	}
+	// Accept the address in the same form as Terraform's own -target arg too
+	for i, target := range targets {
+		targets[i] = strings.TrimPrefix(target, "-target=")
+	}
+
+	// Each feature flag can also be set with a TERRAGRUNT_FEATURE_<NAME> environment variable, which get_feature() reads
+	featureArgs, err := parseMultiStringArg(args, OPT_TERRAGRUNT_FEATURE, "")
+	if err != nil {
+		return nil, err
+	}
+	features := map[string]string{}
+	for _, featureArg := range featureArgs {
+		parts := strings.SplitN(featureArg, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errors.WithStackTrace(InvalidArgValue{Arg: OPT_TERRAGRUNT_FEATURE, Value: featureArg, Expected: "<name>=<value>, such as new_vpc=true"})
+		}
+		features[strings.TrimSpace(parts[0])] = parts[1]
+	}
+
+	catalogSources, err := parseStringListArg(args, OPT_TERRAGRUNT_CATALOG, os.Getenv("TERRAGRUNT_CATALOG"))
+	if err != nil {
+		return nil, err
+	}
+
+	terraformArgs, err := parseMultiStringArg(args, OPT_TERRAGRUNT_TF_ARG, os.Getenv("TERRAGRUNT_TF_ARG"))
+	if err != nil {
+		return nil, err
+	}
+	for _, terraformArg := range terraformArgs {
+		if _, _, isValid := splitTerraformArg(terraformArg); !isValid {
+			return nil, errors.WithStackTrace(InvalidArgValue{Arg: OPT_TERRAGRUNT_TF_ARG, Value: terraformArg, Expected: "<command>=<arg>, such as plan=-refresh=false"})
+		}
+	}
+
+	beforeHook, err := parseStringArg(args, OPT_TERRAGRUNT_BEFORE_HOOK, os.Getenv("TERRAGRUNT_BEFORE_HOOK"))
+	if err != nil {
+		return nil, err
+	}
+
+	afterHook, err := parseStringArg(args, OPT_TERRAGRUNT_AFTER_HOOK, os.Getenv("TERRAGRUNT_AFTER_HOOK"))
+	if err != nil {
+		return nil, err
+	}
+
+	redactPatternArgs, err := parseMultiStringArg(args, OPT_TERRAGRUNT_REDACT_PATTERN, os.Getenv("TERRAGRUNT_REDACT_PATTERN"))
+	if err != nil {
+		return nil, err
+	}
+	redactPatterns := []*regexp.Regexp{}
+	for _, redactPatternArg := range redactPatternArgs {
+		redactPattern, err := regexp.Compile(redactPatternArg)
+		if err != nil {
+			return nil, errors.WithStackTrace(InvalidArgValue{Arg: OPT_TERRAGRUNT_REDACT_PATTERN, Value: redactPatternArg, Expected: fmt.Sprintf("a regular expression (%v)", err)})
+		}
+		redactPatterns = append(redactPatterns, redactPattern)
+	}
+
+	strictControls, err := parseStringListArg(args, OPT_TERRAGRUNT_STRICT_CONTROL, os.Getenv("TERRAGRUNT_STRICT_CONTROL"))
+	if err != nil {
+		return nil, err
+	}
+	for _, control := range strictControls {
+		if !util.ListContainsElement(options.STRICT_CONTROLS, control) {
+			return nil, errors.WithStackTrace(InvalidArgValue{Arg: OPT_TERRAGRUNT_STRICT_CONTROL, Value: control, Expected: "one of " + strings.Join(options.STRICT_CONTROLS, ", ")})
+		}
+	}
+
+	sourceSshKeyPath, err := parseStringArg(args, OPT_TERRAGRUNT_SOURCE_SSH_KEY, os.Getenv("TERRAGRUNT_SOURCE_SSH_KEY"))
+	if err != nil {
+		return nil, err
+	}
+
+	sourceTokenEnvVar, err := parseStringArg(args, OPT_TERRAGRUNT_SOURCE_TOKEN_ENV_VAR, os.Getenv("TERRAGRUNT_SOURCE_TOKEN_ENV_VAR"))
+	if err != nil {
+		return nil, err
+	}
+
+	downloadMaxAge, err := parseDurationArg(args, OPT_TERRAGRUNT_DOWNLOAD_MAX_AGE, os.Getenv("TERRAGRUNT_DOWNLOAD_MAX_AGE"))
+	if err != nil {
+		return nil, err
+	}
+
+	downloadMaxSize, err := parseByteSizeArg(args, OPT_TERRAGRUNT_DOWNLOAD_MAX_SIZE, os.Getenv("TERRAGRUNT_DOWNLOAD_MAX_SIZE"))
+	if err != nil {
+		return nil, err
+	}
+
+	downloadMaxEntries, err := parseIntArg(args, OPT_TERRAGRUNT_DOWNLOAD_MAX_ENTRIES, os.Getenv("TERRAGRUNT_DOWNLOAD_MAX_ENTRIES"))
+	if err != nil {
+		return nil, err
+	}
+
+	promptTimeout, err := parseDurationArg(args, OPT_TERRAGRUNT_PROMPT_TIMEOUT, os.Getenv("TERRAGRUNT_PROMPT_TIMEOUT"))
+	if err != nil {
+		return nil, err
+	}
+
+	runLockTimeout, err := parseDurationArg(args, OPT_TERRAGRUNT_RUN_LOCK_TIMEOUT, os.Getenv("TERRAGRUNT_RUN_LOCK_TIMEOUT"))
+	if err != nil {
+		return nil, err
+	}
+
+	outputCacheTTL, err := parseDurationArg(args, OPT_TERRAGRUNT_OUTPUT_CACHE_TTL, os.Getenv(OUTPUT_CACHE_TTL_ENV_VAR))
+	if err != nil {
+		return nil, err
+	}
+
+	logDir, err := parseStringArg(args, OPT_TERRAGRUNT_LOG_DIR, os.Getenv("TERRAGRUNT_LOG_DIR"))
+	if err != nil {
+		return nil, err
+	}
+	if logDir != "" {
+		logDir, err = filepath.Abs(logDir)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+	}
+
+	auditLog, err := parseStringArg(args, OPT_TERRAGRUNT_AUDIT_LOG, os.Getenv("TERRAGRUNT_AUDIT_LOG"))
+	if err != nil {
+		return nil, err
+	}
+	if auditLog != "" && !strings.HasPrefix(auditLog, audit.S3_URL_PREFIX) {
+		auditLog, err = filepath.Abs(auditLog)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		auditLog = filepath.ToSlash(auditLog)
+	}
+
+	profile, err := parseStringArg(args, OPT_TERRAGRUNT_PROFILE, os.Getenv("TERRAGRUNT_PROFILE"))
+	if err != nil {
+		return nil, err
+	}
+	if profile != "" {
+		profile, err = filepath.Abs(profile)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		profile = filepath.ToSlash(profile)
+	}
+
+	caBundlePath, err := parseStringArg(args, OPT_TERRAGRUNT_CA_BUNDLE, os.Getenv("TERRAGRUNT_CA_BUNDLE"))
+	if err != nil {
+		return nil, err
+	}
+	if caBundlePath != "" {
+		caBundlePath, err = filepath.Abs(caBundlePath)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		caBundlePath = filepath.ToSlash(caBundlePath)
+	}
+
+	downloadDir, err := parseStringArg(args, OPT_TERRAGRUNT_DOWNLOAD_DIR, os.Getenv("TERRAGRUNT_DOWNLOAD_DIR"))
+	if err != nil {
+		return nil, err
+	}
+	if downloadDir != "" {
+		downloadDir, err = filepath.Abs(downloadDir)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+	}
+
 	opts, err := options.NewTerragruntOptions(filepath.ToSlash(terragruntConfigPath))
 	if err != nil {
 		return nil, err
 	}
+	if downloadDir != "" {
+		opts.DownloadDir = filepath.ToSlash(downloadDir)
+	}
 
 	opts.TerraformPath = filepath.ToSlash(terraformPath)
+	opts.TerraformDockerImage = terraformDockerImage
 	opts.AutoInit = !parseBooleanArg(args, OPT_TERRAGRUNT_NO_AUTO_INIT, os.Getenv("TERRAGRUNT_AUTO_INIT") == "false")
 	opts.NonInteractive = parseBooleanArg(args, OPT_NON_INTERACTIVE, os.Getenv("TF_INPUT") == "false" || os.Getenv("TF_INPUT") == "0")
+	opts.AutoApprove = parseBooleanArg(args, OPT_TERRAGRUNT_AUTO_APPROVE, os.Getenv("TERRAGRUNT_AUTO_APPROVE") == "true" || os.Getenv("TERRAGRUNT_AUTO_APPROVE") == "1")
+	opts.AssumeNo = parseBooleanArg(args, OPT_TERRAGRUNT_ASSUME_NO, os.Getenv("TERRAGRUNT_ASSUME_NO") == "true" || os.Getenv("TERRAGRUNT_ASSUME_NO") == "1")
+	opts.PromptTimeout = promptTimeout
+	opts.NoPty = parseBooleanArg(args, OPT_TERRAGRUNT_NO_PTY, os.Getenv("TERRAGRUNT_NO_PTY") == "true" || os.Getenv("TERRAGRUNT_NO_PTY") == "1")
+
+	opts.NoProgress = parseBooleanArg(args, OPT_TERRAGRUNT_NO_PROGRESS, os.Getenv("TERRAGRUNT_NO_PROGRESS") == "true" || os.Getenv("TERRAGRUNT_NO_PROGRESS") == "1")
+
+	opts.FailFast = parseBooleanArg(args, OPT_TERRAGRUNT_FAIL_FAST, os.Getenv("TERRAGRUNT_FAIL_FAST") == "true" || os.Getenv("TERRAGRUNT_FAIL_FAST") == "1")
+	opts.FailFastInterrupt = parseBooleanArg(args, OPT_TERRAGRUNT_FAIL_FAST_INTERRUPT, os.Getenv("TERRAGRUNT_FAIL_FAST_INTERRUPT") == "true" || os.Getenv("TERRAGRUNT_FAIL_FAST_INTERRUPT") == "1")
+
+	opts.Resume = parseBooleanArg(args, OPT_TERRAGRUNT_RESUME, os.Getenv("TERRAGRUNT_RESUME") == "true" || os.Getenv("TERRAGRUNT_RESUME") == "1")
+
+	opts.DebugArgs = parseBooleanArg(args, OPT_TERRAGRUNT_DEBUG_ARGS, os.Getenv("TERRAGRUNT_DEBUG_ARGS") == "true" || os.Getenv("TERRAGRUNT_DEBUG_ARGS") == "1")
+	opts.Debug = parseBooleanArg(args, OPT_TERRAGRUNT_DEBUG, os.Getenv("TERRAGRUNT_DEBUG") == "true" || os.Getenv("TERRAGRUNT_DEBUG") == "1")
+
+	opts.CaBundlePath = caBundlePath
+	opts.Offline = parseBooleanArg(args, OPT_TERRAGRUNT_OFFLINE, os.Getenv("TERRAGRUNT_OFFLINE") == "true" || os.Getenv("TERRAGRUNT_OFFLINE") == "1")
+	// Offline, Terraform's checkpoint calls would only fail, after a timeout
+	opts.DisableCheckpoint = opts.Offline || parseBooleanArg(args, OPT_TERRAGRUNT_DISABLE_CHECKPOINT, os.Getenv("TERRAGRUNT_DISABLE_CHECKPOINT") == "true" || os.Getenv("TERRAGRUNT_DISABLE_CHECKPOINT") == "1")
+
+	opts.FixS3Region = parseBooleanArg(args, OPT_TERRAGRUNT_FIX_S3_REGION, os.Getenv("TERRAGRUNT_FIX_S3_REGION") == "true" || os.Getenv("TERRAGRUNT_FIX_S3_REGION") == "1")
+
+	opts.StrictValidate = parseBooleanArg(args, OPT_TERRAGRUNT_STRICT_VALIDATE, os.Getenv("TERRAGRUNT_STRICT_VALIDATE") == "true" || os.Getenv("TERRAGRUNT_STRICT_VALIDATE") == "1")
+
+	opts.Strict = parseBooleanArg(args, OPT_TERRAGRUNT_STRICT, os.Getenv("TERRAGRUNT_STRICT") == "true" || os.Getenv("TERRAGRUNT_STRICT") == "1")
+	opts.StrictControls = strictControls
+
+	// Honor the NO_COLOR convention (https://no-color.org) too: any value disables colors
+	opts.NoColor = parseBooleanArg(args, OPT_TERRAGRUNT_NO_COLOR, os.Getenv("TERRAGRUNT_NO_COLOR") == "true" || os.Getenv("TERRAGRUNT_NO_COLOR") == "1" || os.Getenv("NO_COLOR") != "")
 	opts.TerraformCliArgs = filterTerragruntArgs(args)
 	opts.WorkingDir = filepath.ToSlash(workingDir)
-	opts.Logger = util.CreateLoggerWithWriter(errWriter, "")
+	opts.ErrWriter = errWriter
+	opts.Redactor = util.NewRedactor(redactPatterns)
+	opts.RedactTerraformOutput = parseBooleanArg(args, OPT_TERRAGRUNT_REDACT_TERRAFORM_OUTPUT, os.Getenv("TERRAGRUNT_REDACT_TERRAFORM_OUTPUT") == "true" || os.Getenv("TERRAGRUNT_REDACT_TERRAFORM_OUTPUT") == "1")
+	opts.Quiet = parseBooleanArg(args, OPT_TERRAGRUNT_QUIET, os.Getenv("TERRAGRUNT_QUIET") == "true" || os.Getenv("TERRAGRUNT_QUIET") == "1")
+	opts.Logger = util.CreateLoggerWithWriter(opts.LogWriter(), "")
+	for _, rcFile := range rcFiles {
+		opts.Logger.Printf("Using the defaults for the Terragrunt options in %s", rcFile)
+	}
+	if configFoundInParentDir {
+		opts.Logger.Printf("Found no Terragrunt config in the current folder, so using the one in %s", workingDir)
+	}
 	opts.RunTerragrunt = runTerragrunt
 	opts.Source = terraformSource
 	opts.SourceUpdate = sourceUpdate
+	opts.SourceSshKeyPath = sourceSshKeyPath
+	opts.SourceTokenEnvVar = sourceTokenEnvVar
+	opts.SourceShallowClone = parseBooleanArg(args, OPT_TERRAGRUNT_SOURCE_SHALLOW_CLONE, os.Getenv("TERRAGRUNT_SOURCE_SHALLOW_CLONE") == "true" || os.Getenv("TERRAGRUNT_SOURCE_SHALLOW_CLONE") == "1")
+	opts.SourceSparseCheckout = parseBooleanArg(args, OPT_TERRAGRUNT_SOURCE_SPARSE_CHECKOUT, os.Getenv("TERRAGRUNT_SOURCE_SPARSE_CHECKOUT") == "true" || os.Getenv("TERRAGRUNT_SOURCE_SPARSE_CHECKOUT") == "1")
+	opts.SourceNoSubmodules = parseBooleanArg(args, OPT_TERRAGRUNT_SOURCE_NO_SUBMODULES, os.Getenv("TERRAGRUNT_SOURCE_NO_SUBMODULES") == "true" || os.Getenv("TERRAGRUNT_SOURCE_NO_SUBMODULES") == "1")
+	opts.DownloadMaxAge = downloadMaxAge
+	opts.DownloadMaxSize = downloadMaxSize
+	opts.DownloadMaxEntries = downloadMaxEntries
 	opts.IgnoreDependencyErrors = ignoreDependencyErrors
 	opts.Writer = writer
-	opts.ErrWriter = errWriter
 	opts.Env = parseEnvironmentVariables(os.Environ())
+	opts.Redactor.AddSecretsFromEnv(opts.Env)
+	if opts.DisableCheckpoint {
+		opts.Env[CHECKPOINT_DISABLE_ENV_VAR] = "1"
+	}
+	opts.SetCaBundleEnvVars()
 	opts.IamRole = iamRole
+	opts.IamRoles = iamRoles
+	opts.IamWebIdentityToken = iamWebIdentityToken
+	opts.GitDiffRef = gitDiffRef
+	opts.FollowSymlinks = parseBooleanArg(args, OPT_TERRAGRUNT_FOLLOW_SYMLINKS, os.Getenv("TERRAGRUNT_FOLLOW_SYMLINKS") == "true" || os.Getenv("TERRAGRUNT_FOLLOW_SYMLINKS") == "1")
+	opts.ModulesThatInclude = modulesThatInclude
+	opts.StrictInclude = parseBooleanArg(args, OPT_TERRAGRUNT_STRICT_INCLUDE, os.Getenv("TERRAGRUNT_STRICT_INCLUDE") == "true" || os.Getenv("TERRAGRUNT_STRICT_INCLUDE") == "1")
+	opts.AllowMissingConfig = parseBooleanArg(args, OPT_TERRAGRUNT_ALLOW_MISSING_CONFIG, os.Getenv("TERRAGRUNT_ALLOW_MISSING_CONFIG") == "true" || os.Getenv("TERRAGRUNT_ALLOW_MISSING_CONFIG") == "1")
+	opts.ParseCache = parseBooleanArg(args, OPT_TERRAGRUNT_PARSE_CACHE, os.Getenv("TERRAGRUNT_PARSE_CACHE") == "true" || os.Getenv("TERRAGRUNT_PARSE_CACHE") == "1")
+	opts.ExtraDependencies = extraDependencies
+	opts.Targets = targets
+	opts.Features = features
+	opts.TargetsFromState = parseBooleanArg(args, OPT_TERRAGRUNT_TARGET_FROM_STATE, os.Getenv("TERRAGRUNT_TARGET_FROM_STATE") == "true" || os.Getenv("TERRAGRUNT_TARGET_FROM_STATE") == "1")
+	opts.LogDir = filepath.ToSlash(logDir)
+	opts.AuditLog = auditLog
+	opts.Profile = profile
+	opts.CatalogSources = catalogSources
+	opts.OutputCacheTTL = outputCacheTTL
+	if outputCacheTTL > 0 {
+		// Pass the TTL on to the output-from commands that hooks run, so all the modules of a stack share the disk cache
+		opts.Env[OUTPUT_CACHE_TTL_ENV_VAR] = outputCacheTTL.String()
+	}
+	opts.RunLockTimeout = runLockTimeout
+	opts.TerraformArgs = terraformArgs
+	opts.BeforeHook = beforeHook
+	opts.AfterHook = afterHook
+	opts.RedactPatterns = redactPatterns
+
+	if opts.AssumeNo && opts.AutoApprove {
+		return nil, errors.WithStackTrace(ConflictingArgs{Arg: OPT_TERRAGRUNT_ASSUME_NO, ConflictingArg: OPT_TERRAGRUNT_AUTO_APPROVE})
+	}
 
 	return opts, nil
 }
 
-func filterTerraformExtraArgs(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) []string {
+// Return true if Terragrunt should look for a Terragrunt config in the parent folders when running the given command
+// in a folder without one. The xxx-all commands run on every module below the current folder, and some other commands
+// take paths relative to it, so they always run in the current folder.
+func searchesParentDirsForConfig(command string) bool {
+	return !isMultiModuleCommand(command) && command != CMD_OUTPUT_FROM && command != CMD_VALIDATE_CONFIG
+}
+
+// Return the args from the extra_arguments blocks in the given config that apply to the command in the given options.
+// The blocks are processed in the order given by their order parameter, and blocks whose condition isn't met are
+// skipped.
+func filterTerraformExtraArgs(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) ([]string, error) {
 	out := []string{}
 	cmd := firstArg(terragruntOptions.TerraformCliArgs)
 
-	for _, arg := range terragruntConfig.Terraform.ExtraArgs {
+	extraArgs := make([]config.TerraformExtraArguments, len(terragruntConfig.Terraform.ExtraArgs))
+	copy(extraArgs, terragruntConfig.Terraform.ExtraArgs)
+	sort.Stable(extraArgsByOrder(extraArgs))
+
+	for _, arg := range extraArgs {
 		for _, arg_cmd := range arg.Commands {
 			if cmd == arg_cmd {
+				if !extraArgsConditionMet(arg.Condition, terragruntOptions) {
+					terragruntOptions.Logger.Printf("Skipping extra_arguments '%s' as its condition is not met", arg.Name)
+					continue
+				}
+
 				out = append(out, arg.Arguments...)
 
+				if arg.ArgumentsFile != "" {
+					argsFromFile, err := readArgumentsFile(arg.ArgumentsFile, terragruntOptions)
+					if err != nil {
+						return nil, err
+					}
+					out = append(out, argsFromFile...)
+				}
+
 				// If RequiredVarFiles is specified, add -var-file=<file> for each specified files
 				for _, file := range util.RemoveDuplicatesFromListKeepLast(arg.RequiredVarFiles) {
 					out = append(out, fmt.Sprintf("-var-file=%s", file))
@@ -125,7 +461,79 @@ func filterTerraformExtraArgs(terragruntOptions *options.TerragruntOptions, terr
 		}
 	}
 
-	return out
+	return out, nil
+}
+
+// Read the args from the given arguments_file of an extra_arguments block. Each line of the file is one arg, used as is,
+// without any shell quoting or splitting, so args may contain spaces. Leading and trailing whitespace, empty lines, and
+// lines starting with # are ignored. Relative paths are relative to the folder of the Terragrunt config file.
+func readArgumentsFile(argumentsFile string, terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	path, err := util.CanonicalPath(argumentsFile, filepath.Dir(terragruntOptions.TerragruntConfigPath))
+	if err != nil {
+		return nil, err
+	}
+
+	if !util.FileExists(path) {
+		return nil, errors.WithStackTrace(ArgumentsFileNotFound(path))
+	}
+
+	contents, err := util.ReadFileAsString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{}
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args = append(args, line)
+	}
+	return args, nil
+}
+
+// Returns true if the given extra_arguments condition is nil or if every environment variable it lists is set to a
+// non-empty value and every argument it lists was passed after the command
+func extraArgsConditionMet(condition *config.ExtraArgumentsCondition, terragruntOptions *options.TerragruntOptions) bool {
+	if condition == nil {
+		return true
+	}
+
+	for _, envVar := range condition.EnvVars {
+		if terragruntOptions.Env[envVar] == "" {
+			return false
+		}
+	}
+
+	argsAfterCommand := []string{}
+	if len(terragruntOptions.TerraformCliArgs) > 1 {
+		argsAfterCommand = terragruntOptions.TerraformCliArgs[1:]
+	}
+
+	for _, arg := range condition.Args {
+		if !hasArg(argsAfterCommand, arg) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Sort extra_arguments blocks by their order parameter. Use with sort.Stable so blocks with the same order keep their
+// original order.
+type extraArgsByOrder []config.TerraformExtraArguments
+
+func (extraArgs extraArgsByOrder) Len() int {
+	return len(extraArgs)
+}
+
+func (extraArgs extraArgsByOrder) Swap(i, j int) {
+	extraArgs[i], extraArgs[j] = extraArgs[j], extraArgs[i]
+}
+
+func (extraArgs extraArgsByOrder) Less(i, j int) bool {
+	return extraArgs[i].Order < extraArgs[j].Order
 }
 
 func parseEnvironmentVariables(environment []string) map[string]string {
@@ -195,6 +603,105 @@ func parseStringArg(args []string, argName string, defaultValue string) (string,
 	return defaultValue, nil
 }
 
+// Find every occurrence of a string argument (e.g. --foo bar --foo baz) of the given name in the given list of
+// arguments, and return their values in order. If there is none, return defaultValue, if it isn't empty.
+func parseMultiStringArg(args []string, argName string, defaultValue string) ([]string, error) {
+	values := []string{}
+	for i, arg := range args {
+		if arg == fmt.Sprintf("--%s", argName) {
+			if (i + 1) >= len(args) {
+				return nil, errors.WithStackTrace(ArgMissingValue(argName))
+			}
+			values = append(values, args[i+1])
+		}
+	}
+
+	if len(values) == 0 && defaultValue != "" {
+		values = append(values, defaultValue)
+	}
+	return values, nil
+}
+
+// Find a duration argument (e.g. --foo 24h) of the given name in the given list of arguments and parse it using the
+// syntax of time.ParseDuration. If it isn't present, parse defaultValue instead. Returns 0 if neither is set.
+func parseDurationArg(args []string, argName string, defaultValue string) (time.Duration, error) {
+	value, err := parseStringArg(args, argName, defaultValue)
+	if err != nil || value == "" {
+		return 0, err
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, errors.WithStackTrace(InvalidArgValue{Arg: argName, Value: value, Expected: "a duration, such as 90m or 168h"})
+	}
+	return duration, nil
+}
+
+// Find an integer argument (e.g. --foo 10) of the given name in the given list of arguments. If it isn't present, parse
+// defaultValue instead. Returns 0 if neither is set.
+func parseIntArg(args []string, argName string, defaultValue string) (int, error) {
+	value, err := parseStringArg(args, argName, defaultValue)
+	if err != nil || value == "" {
+		return 0, err
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return 0, errors.WithStackTrace(InvalidArgValue{Arg: argName, Value: value, Expected: "a positive whole number"})
+	}
+	return number, nil
+}
+
+// Find a comma-separated list argument (e.g. --foo "a,b,c") of the given name in the given list of arguments and return
+// its non-empty items, with any whitespace around them trimmed. If it isn't present, parse defaultValue instead.
+func parseStringListArg(args []string, argName string, defaultValue string) ([]string, error) {
+	value, err := parseStringArg(args, argName, defaultValue)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+var byteSizeRegexp = regexp.MustCompile(`^(\d+)\s*([KMGT]?)B?$`)
+
+// The number of bytes in each of the units that byteSizeRegexp accepts
+var BYTE_SIZE_UNITS = map[string]int64{
+	"":  1,
+	"K": 1024,
+	"M": 1024 * 1024,
+	"G": 1024 * 1024 * 1024,
+	"T": 1024 * 1024 * 1024 * 1024,
+}
+
+// Find a size argument (e.g. --foo 10GB) of the given name in the given list of arguments and return it in bytes. The
+// size is a number of bytes, optionally followed by KB, MB, GB, or TB, each of which is 1024 times the previous one. If
+// the argument isn't present, parse defaultValue instead. Returns 0 if neither is set.
+func parseByteSizeArg(args []string, argName string, defaultValue string) (int64, error) {
+	value, err := parseStringArg(args, argName, defaultValue)
+	if err != nil || value == "" {
+		return 0, err
+	}
+
+	matches := byteSizeRegexp.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(value)))
+	if len(matches) != 3 {
+		return 0, errors.WithStackTrace(InvalidArgValue{Arg: argName, Value: value, Expected: "a size, such as 500MB or 10GB"})
+	}
+
+	size, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0, errors.WithStackTrace(InvalidArgValue{Arg: argName, Value: value, Expected: "a size, such as 500MB or 10GB"})
+	}
+
+	return size * BYTE_SIZE_UNITS[matches[2]], nil
+}
+
 // A convenience method that returns the first item (0th index) in the given list or an empty string if this is an
 // empty list
 func firstArg(args []string) string {
@@ -220,3 +727,28 @@ type ArgMissingValue string
 func (err ArgMissingValue) Error() string {
 	return fmt.Sprintf("You must specify a value for the --%s option", string(err))
 }
+
+type InvalidArgValue struct {
+	Arg      string
+	Value    string
+	Expected string
+}
+
+func (err InvalidArgValue) Error() string {
+	return fmt.Sprintf("Invalid value '%s' for the --%s option. Expected %s.", err.Value, err.Arg, err.Expected)
+}
+
+type ArgumentsFileNotFound string
+
+func (path ArgumentsFileNotFound) Error() string {
+	return fmt.Sprintf("The arguments_file %s in extra_arguments does not exist", string(path))
+}
+
+type ConflictingArgs struct {
+	Arg            string
+	ConflictingArg string
+}
+
+func (err ConflictingArgs) Error() string {
+	return fmt.Sprintf("The --%s option can't be combined with the --%s option", err.Arg, err.ConflictingArg)
+}
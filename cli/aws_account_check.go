@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/gruntwork-io/terragrunt/aws_helper"
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// If the given config has allowed_account_ids or forbidden_account_ids, and the command uses the state, look up the AWS
+// account of the credentials Terraform will run with, and return an error if it's not one of the allowed accounts or
+// is one of the forbidden ones. This runs before init, so Terragrunt doesn't create the remote state resources in the
+// wrong account either.
+func checkAwsAccountIfNecessary(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	if len(terragruntConfig.AllowedAccountIds) == 0 && len(terragruntConfig.ForbiddenAccountIds) == 0 {
+		return nil
+	}
+	if !util.ListContainsElement(TERRAFORM_COMMANDS_THAT_USE_STATE, firstArg(terragruntOptions.TerraformCliArgs)) {
+		return nil
+	}
+
+	accountId, err := getCurrentAwsAccountId(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	return checkAwsAccountId(accountId, terragruntConfig, terragruntOptions)
+}
+
+// Return the id of the AWS account of the credentials Terraform will run with: those of the env vars Terraform gets,
+// which are the ones of the IAM role Terragrunt assumed, if any, or of the AWS_PROFILE or credentials in the env
+// otherwise
+func getCurrentAwsAccountId(terragruntOptions *options.TerragruntOptions) (string, error) {
+	sess, err := aws_helper.CreateAwsSessionFromEnv(terragruntOptions.Env, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+
+	return aws_helper.GetAwsAccountId(sts.New(sess), terragruntOptions)
+}
+
+// Return an error if the given AWS account id isn't in the allowed_account_ids of the given config, if it has any, or is
+// in its forbidden_account_ids
+func checkAwsAccountId(accountId string, terragruntConfig *config.TerragruntConfig, terragruntOptions *options.TerragruntOptions) error {
+	if len(terragruntConfig.AllowedAccountIds) > 0 && !util.ListContainsElement(terragruntConfig.AllowedAccountIds, accountId) {
+		return errors.WithStackTrace(AwsAccountNotAllowed{AccountId: accountId, AllowedAccountIds: terragruntConfig.AllowedAccountIds, ConfigPath: terragruntOptions.TerragruntConfigPath})
+	}
+	if util.ListContainsElement(terragruntConfig.ForbiddenAccountIds, accountId) {
+		return errors.WithStackTrace(AwsAccountForbidden{AccountId: accountId, ConfigPath: terragruntOptions.TerragruntConfigPath})
+	}
+
+	terragruntOptions.Logger.Printf("Running in AWS account %s", accountId)
+	return nil
+}
+
+// Custom error types
+
+type AwsAccountNotAllowed struct {
+	AccountId         string
+	AllowedAccountIds []string
+	ConfigPath        string
+}
+
+func (err AwsAccountNotAllowed) Error() string {
+	return fmt.Sprintf("The AWS credentials are for account %s, but the allowed_account_ids in %s only allow %s. Check that you are using the right credentials or IAM role.", err.AccountId, err.ConfigPath, strings.Join(err.AllowedAccountIds, ", "))
+}
+
+func (err AwsAccountNotAllowed) Is(target error) bool {
+	return target == errors.ErrWrongAwsAccount
+}
+
+type AwsAccountForbidden struct {
+	AccountId  string
+	ConfigPath string
+}
+
+func (err AwsAccountForbidden) Error() string {
+	return fmt.Sprintf("The AWS credentials are for account %s, which the forbidden_account_ids in %s don't allow. Check that you are using the right credentials or IAM role.", err.AccountId, err.ConfigPath)
+}
+
+func (err AwsAccountForbidden) Is(target error) bool {
+	return target == errors.ErrWrongAwsAccount
+}
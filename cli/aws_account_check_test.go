@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAwsAccountId(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("aws_account_check_test")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	testCases := []struct {
+		accountId   string
+		config      *config.TerragruntConfig
+		expectedErr error
+	}{
+		{"111111111111", &config.TerragruntConfig{}, nil},
+		{"111111111111", &config.TerragruntConfig{AllowedAccountIds: []string{"111111111111", "222222222222"}}, nil},
+		{"333333333333", &config.TerragruntConfig{AllowedAccountIds: []string{"111111111111", "222222222222"}}, AwsAccountNotAllowed{}},
+		{"111111111111", &config.TerragruntConfig{ForbiddenAccountIds: []string{"222222222222"}}, nil},
+		{"222222222222", &config.TerragruntConfig{ForbiddenAccountIds: []string{"222222222222"}}, AwsAccountForbidden{}},
+		{"111111111111", &config.TerragruntConfig{AllowedAccountIds: []string{"111111111111"}, ForbiddenAccountIds: []string{"111111111111"}}, AwsAccountForbidden{}},
+	}
+
+	for _, testCase := range testCases {
+		err := checkAwsAccountId(testCase.accountId, testCase.config, terragruntOptions)
+		if testCase.expectedErr == nil {
+			assert.Nil(t, err, "Unexpected error for account %s and config %v: %v", testCase.accountId, testCase.config, err)
+		} else {
+			assert.IsType(t, testCase.expectedErr, errors.Unwrap(err), "For account %s and config %v", testCase.accountId, testCase.config)
+		}
+	}
+}
+
+func TestCheckAwsAccountIfNecessarySkipsCommandsWithoutState(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("aws_account_check_test")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	// Neither of these looks up the account, so they don't need AWS credentials
+	terragruntOptions.TerraformCliArgs = []string{"apply"}
+	assert.Nil(t, checkAwsAccountIfNecessary(terragruntOptions, &config.TerragruntConfig{}))
+
+	terragruntOptions.TerraformCliArgs = []string{"fmt"}
+	assert.Nil(t, checkAwsAccountIfNecessary(terragruntOptions, &config.TerragruntConfig{AllowedAccountIds: []string{"111111111111"}}))
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The file in the working dir of a module that Terragrunt writes the provider "aws" block of the aws_provider config
+// into
+const AWS_PROVIDER_FILE = "terragrunt_aws_provider.tf"
+
+// The environment variable Terragrunt passes the default_tags config to Terraform in, as a map
+const DEFAULT_TAGS_ENV_VAR = "TF_VAR_default_tags"
+
+// The first line of AWS_PROVIDER_FILE, so Terragrunt only ever overwrites a file it wrote itself
+const AWS_PROVIDER_FILE_HEADER = "# Generated by Terragrunt from the aws_provider block of the Terragrunt config. Do not edit."
+
+// If the given config has an aws_provider block, write a provider "aws" block with its settings into AWS_PROVIDER_FILE
+// in the working dir, replacing the one from the last run, if any, so Terraform picks it up along with the rest of the
+// code of the module. If it doesn't, remove the file from the last run, if any. Returns an error if the code of the
+// module already configures the default aws provider itself, as Terraform doesn't allow two.
+func writeAwsProviderFromConfig(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	providerFile := util.JoinPath(terragruntOptions.WorkingDir, AWS_PROVIDER_FILE)
+	if util.FileExists(providerFile) {
+		contents, err := util.ReadFileAsString(providerFile)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(contents, AWS_PROVIDER_FILE_HEADER) {
+			if terragruntConfig.AwsProvider == nil {
+				return nil
+			}
+			return errors.WithStackTrace(AwsProviderFileNotGenerated(providerFile))
+		}
+		// Remove the file from the last run first, so it isn't mistaken for a provider block in the code itself
+		if err := os.Remove(providerFile); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+
+	if terragruntConfig.AwsProvider == nil {
+		return nil
+	}
+
+	terraformCode, err := util.ParseTerraformCode(terragruntOptions.WorkingDir)
+	if err != nil {
+		return err
+	}
+	for _, provider := range terraformCode.Providers {
+		if provider.Name == "aws" && provider.Alias == "" {
+			return errors.WithStackTrace(AwsProviderAlreadyConfigured(terragruntOptions.WorkingDir))
+		}
+	}
+
+	// The default_tags of the config apply to the provider too, under the provider's own default_tags
+	awsProvider := *terragruntConfig.AwsProvider
+	awsProvider.DefaultTags = util.CloneStringMap(terragruntConfig.DefaultTags)
+	for key, value := range terragruntConfig.AwsProvider.DefaultTags {
+		awsProvider.DefaultTags[key] = value
+	}
+
+	terragruntOptions.Logger.Printf("Writing the aws provider config to %s", providerFile)
+	if err := ioutil.WriteFile(providerFile, []byte(renderAwsProvider(&awsProvider)), 0644); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return nil
+}
+
+// Render the given aws_provider config as a provider "aws" block, preceded by AWS_PROVIDER_FILE_HEADER
+func renderAwsProvider(awsProvider *config.AwsProviderConfig) string {
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "%s\n\n", AWS_PROVIDER_FILE_HEADER)
+	fmt.Fprintln(&out, `provider "aws" {`)
+
+	if awsProvider.Region != "" {
+		fmt.Fprintf(&out, "  region = %s\n", quoteTerraformString(awsProvider.Region))
+	}
+
+	if awsProvider.AssumeRole != nil {
+		fmt.Fprintln(&out, "\n  assume_role {")
+		fmt.Fprintf(&out, "    role_arn = %s\n", quoteTerraformString(awsProvider.AssumeRole.RoleArn))
+		if awsProvider.AssumeRole.SessionName != "" {
+			fmt.Fprintf(&out, "    session_name = %s\n", quoteTerraformString(awsProvider.AssumeRole.SessionName))
+		}
+		if awsProvider.AssumeRole.ExternalId != "" {
+			fmt.Fprintf(&out, "    external_id = %s\n", quoteTerraformString(awsProvider.AssumeRole.ExternalId))
+		}
+		fmt.Fprintln(&out, "  }")
+	}
+
+	if len(awsProvider.DefaultTags) > 0 {
+		fmt.Fprintln(&out, "\n  default_tags {")
+		fmt.Fprintln(&out, "    tags = {")
+		for _, key := range util.SortedKeys(awsProvider.DefaultTags) {
+			fmt.Fprintf(&out, "      %s = %s\n", quoteTerraformString(key), quoteTerraformString(awsProvider.DefaultTags[key]))
+		}
+		fmt.Fprintln(&out, "    }")
+		fmt.Fprintln(&out, "  }")
+	}
+
+	fmt.Fprintln(&out, "}")
+	return out.String()
+}
+
+// Format the given map in the syntax Terraform expects for the value of a map variable in an environment variable, such
+// as {"Team" = "payments"}, which every version of Terraform can parse
+func formatTerraformStringMap(values map[string]string) string {
+	entries := []string{}
+	for _, key := range util.SortedKeys(values) {
+		entries = append(entries, fmt.Sprintf("%s = %s", quoteTerraformString(key), quoteTerraformString(values[key])))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// Return the given string as a quoted Terraform string, escaped so Terraform uses it as is, without any interpolation
+func quoteTerraformString(str string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${").Replace(str)
+	return `"` + escaped + `"`
+}
+
+// Custom error types
+
+type AwsProviderFileNotGenerated string
+
+func (path AwsProviderFileNotGenerated) Error() string {
+	return fmt.Sprintf("The aws_provider config would overwrite %s, which Terragrunt didn't generate. Rename that file, or remove the aws_provider config.", string(path))
+}
+
+type AwsProviderAlreadyConfigured string
+
+func (workingDir AwsProviderAlreadyConfigured) Error() string {
+	return fmt.Sprintf("The Terraform code in %s already has a provider \"aws\" block without an alias, so Terragrunt can't add the one from the aws_provider config. Remove one or the other.", string(workingDir))
+}
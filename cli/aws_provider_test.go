@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderAwsProvider(t *testing.T) {
+	t.Parallel()
+
+	awsProvider := &config.AwsProviderConfig{
+		Region:      "us-east-1",
+		AssumeRole:  &config.AwsProviderAssumeRole{RoleArn: "arn:aws:iam::111111111111:role/terraform", ExternalId: "abc"},
+		DefaultTags: map[string]string{"Team": "payments", "Cost Center": `"42" ${var.x}`},
+	}
+
+	expected := AWS_PROVIDER_FILE_HEADER + `
+
+provider "aws" {
+  region = "us-east-1"
+
+  assume_role {
+    role_arn = "arn:aws:iam::111111111111:role/terraform"
+    external_id = "abc"
+  }
+
+  default_tags {
+    tags = {
+      "Cost Center" = "\"42\" $${var.x}"
+      "Team" = "payments"
+    }
+  }
+}
+`
+	assert.Equal(t, expected, renderAwsProvider(awsProvider))
+	assert.Equal(t, AWS_PROVIDER_FILE_HEADER+"\n\nprovider \"aws\" {\n}\n", renderAwsProvider(&config.AwsProviderConfig{}))
+}
+
+func TestWriteAwsProviderFromConfig(t *testing.T) {
+	t.Parallel()
+
+	workingDir, err := ioutil.TempDir("", "aws-provider-test")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	defer os.RemoveAll(workingDir)
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(workingDir, config.DefaultTerragruntConfigPath))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	terragruntOptions.WorkingDir = workingDir
+
+	providerFile := util.JoinPath(workingDir, AWS_PROVIDER_FILE)
+	terragruntConfig := &config.TerragruntConfig{AwsProvider: &config.AwsProviderConfig{Region: "us-east-1"}}
+
+	// Writing it again replaces the file from the last run
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, writeAwsProviderFromConfig(terragruntOptions, terragruntConfig))
+		assert.Equal(t, renderAwsProvider(terragruntConfig.AwsProvider), readFile(t, providerFile))
+	}
+
+	assert.Nil(t, ioutil.WriteFile(providerFile, []byte(`provider "aws" {}`), 0644))
+	err = writeAwsProviderFromConfig(terragruntOptions, terragruntConfig)
+	_, isNotGeneratedErr := errors.Unwrap(err).(AwsProviderFileNotGenerated)
+	assert.True(t, isNotGeneratedErr, "Expected an AwsProviderFileNotGenerated error but got: %v", err)
+
+	assert.Nil(t, os.Remove(providerFile))
+	assert.Nil(t, ioutil.WriteFile(util.JoinPath(workingDir, "main.tf"), []byte(`provider "aws" { region = "us-west-2" }`), 0644))
+	err = writeAwsProviderFromConfig(terragruntOptions, terragruntConfig)
+	_, isAlreadyConfiguredErr := errors.Unwrap(err).(AwsProviderAlreadyConfigured)
+	assert.True(t, isAlreadyConfiguredErr, "Expected an AwsProviderAlreadyConfigured error but got: %v", err)
+	assert.False(t, util.FileExists(providerFile))
+
+	// A provider with an alias doesn't clash with the default one
+	assert.Nil(t, ioutil.WriteFile(util.JoinPath(workingDir, "main.tf"), []byte(`provider "aws" { alias = "west" }`), 0644))
+	assert.Nil(t, writeAwsProviderFromConfig(terragruntOptions, terragruntConfig))
+	assert.True(t, util.FileExists(providerFile))
+
+	// The default_tags of the config are added under the provider's own
+	terragruntConfig = &config.TerragruntConfig{
+		AwsProvider: &config.AwsProviderConfig{DefaultTags: map[string]string{"Env": "prod"}},
+		DefaultTags: map[string]string{"Env": "stage", "Team": "payments"},
+	}
+	assert.Nil(t, writeAwsProviderFromConfig(terragruntOptions, terragruntConfig))
+	assert.Equal(t, renderAwsProvider(&config.AwsProviderConfig{DefaultTags: map[string]string{"Env": "prod", "Team": "payments"}}), readFile(t, providerFile))
+	assert.Equal(t, map[string]string{"Env": "prod"}, terragruntConfig.AwsProvider.DefaultTags)
+
+	// Once the aws_provider config is gone, so is the file
+	assert.Nil(t, writeAwsProviderFromConfig(terragruntOptions, &config.TerragruntConfig{}))
+	assert.False(t, util.FileExists(providerFile))
+}
+
+func TestSetDefaultTagsFromConfig(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("aws_provider_test")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	setDefaultTagsFromConfig(terragruntOptions, &config.TerragruntConfig{})
+	assert.Nil(t, terragruntOptions.DefaultTags)
+	assert.NotContains(t, terragruntOptions.Env, DEFAULT_TAGS_ENV_VAR)
+
+	tags := map[string]string{"Team": "payments", "Cost Center": "42"}
+	setDefaultTagsFromConfig(terragruntOptions, &config.TerragruntConfig{DefaultTags: tags})
+	assert.Equal(t, tags, terragruntOptions.DefaultTags)
+	assert.Equal(t, `{"Cost Center" = "42", "Team" = "payments"}`, terragruntOptions.Env[DEFAULT_TAGS_ENV_VAR])
+
+	// A value set by the user wins
+	terragruntOptions.Env[DEFAULT_TAGS_ENV_VAR] = `{"Team" = "other"}`
+	setDefaultTagsFromConfig(terragruntOptions, &config.TerragruntConfig{DefaultTags: tags})
+	assert.Equal(t, `{"Team" = "other"}`, terragruntOptions.Env[DEFAULT_TAGS_ENV_VAR])
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// If the given config has an expected_region, and the command uses the state, return an error if Terraform would run
+// in another region: if the region of the aws_provider block, or the region in the environment, is set to anything
+// else. If neither is set, the region comes from somewhere Terragrunt can't see, such as the code of the module, so
+// Terragrunt only logs a warning.
+func checkAwsRegionIfNecessary(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	if terragruntConfig.ExpectedRegion == "" {
+		return nil
+	}
+	if !util.ListContainsElement(TERRAFORM_COMMANDS_THAT_USE_STATE, firstArg(terragruntOptions.TerraformCliArgs)) {
+		return nil
+	}
+
+	regionsChecked := 0
+
+	if terragruntConfig.AwsProvider != nil && terragruntConfig.AwsProvider.Region != "" {
+		if terragruntConfig.AwsProvider.Region != terragruntConfig.ExpectedRegion {
+			return errors.WithStackTrace(UnexpectedAwsRegion{Region: terragruntConfig.AwsProvider.Region, Source: "the region of the aws_provider block", ExpectedRegion: terragruntConfig.ExpectedRegion, ConfigPath: terragruntOptions.TerragruntConfigPath})
+		}
+		regionsChecked++
+	}
+
+	// The AWS SDKs, and so Terraform's AWS provider, use AWS_REGION over AWS_DEFAULT_REGION
+	for _, envVar := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		region := terragruntOptions.Env[envVar]
+		if region == "" {
+			continue
+		}
+		if region != terragruntConfig.ExpectedRegion {
+			return errors.WithStackTrace(UnexpectedAwsRegion{Region: region, Source: fmt.Sprintf("the %s environment variable", envVar), ExpectedRegion: terragruntConfig.ExpectedRegion, ConfigPath: terragruntOptions.TerragruntConfigPath})
+		}
+		regionsChecked++
+		break
+	}
+
+	if regionsChecked == 0 {
+		terragruntOptions.Logger.Printf("WARNING: The config at %s has expected_region %s, but neither an aws_provider block nor the AWS_REGION or AWS_DEFAULT_REGION environment variables set the region, so Terragrunt can't check it", terragruntOptions.TerragruntConfigPath, terragruntConfig.ExpectedRegion)
+	}
+	return nil
+}
+
+// Custom error types
+
+type UnexpectedAwsRegion struct {
+	Region         string
+	Source         string
+	ExpectedRegion string
+	ConfigPath     string
+}
+
+func (err UnexpectedAwsRegion) Error() string {
+	return fmt.Sprintf("Terraform would run in region %s, from %s, but the expected_region in %s is %s. Check that you are using the right region.", err.Region, err.Source, err.ConfigPath, err.ExpectedRegion)
+}
+
+func (err UnexpectedAwsRegion) Is(target error) bool {
+	return target == errors.ErrWrongAwsRegion
+}
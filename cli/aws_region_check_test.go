@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAwsRegionIfNecessary(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		command     string
+		env         map[string]string
+		config      *config.TerragruntConfig
+		expectedErr error
+	}{
+		{"apply", map[string]string{"AWS_REGION": "eu-west-1"}, &config.TerragruntConfig{}, nil},
+		{"apply", map[string]string{"AWS_REGION": "us-east-1"}, &config.TerragruntConfig{ExpectedRegion: "us-east-1"}, nil},
+		{"apply", map[string]string{"AWS_REGION": "eu-west-1"}, &config.TerragruntConfig{ExpectedRegion: "us-east-1"}, UnexpectedAwsRegion{}},
+		{"apply", map[string]string{"AWS_DEFAULT_REGION": "eu-west-1"}, &config.TerragruntConfig{ExpectedRegion: "us-east-1"}, UnexpectedAwsRegion{}},
+		{"apply", map[string]string{"AWS_REGION": "us-east-1", "AWS_DEFAULT_REGION": "eu-west-1"}, &config.TerragruntConfig{ExpectedRegion: "us-east-1"}, nil},
+		{"apply", map[string]string{}, &config.TerragruntConfig{ExpectedRegion: "us-east-1"}, nil},
+		{"apply", map[string]string{}, &config.TerragruntConfig{ExpectedRegion: "us-east-1", AwsProvider: &config.AwsProviderConfig{Region: "us-east-1"}}, nil},
+		{"apply", map[string]string{}, &config.TerragruntConfig{ExpectedRegion: "us-east-1", AwsProvider: &config.AwsProviderConfig{Region: "eu-west-1"}}, UnexpectedAwsRegion{}},
+		{"apply", map[string]string{"AWS_REGION": "eu-west-1"}, &config.TerragruntConfig{ExpectedRegion: "us-east-1", AwsProvider: &config.AwsProviderConfig{Region: "us-east-1"}}, UnexpectedAwsRegion{}},
+		{"fmt", map[string]string{"AWS_REGION": "eu-west-1"}, &config.TerragruntConfig{ExpectedRegion: "us-east-1"}, nil},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("aws_region_check_test")
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		terragruntOptions.TerraformCliArgs = []string{testCase.command}
+		terragruntOptions.Env = testCase.env
+
+		err = checkAwsRegionIfNecessary(terragruntOptions, testCase.config)
+		if testCase.expectedErr == nil {
+			assert.Nil(t, err, "Unexpected error for command %s, env %v, and config %v: %v", testCase.command, testCase.env, testCase.config, err)
+		} else {
+			assert.IsType(t, testCase.expectedErr, errors.Unwrap(err), "For command %s, env %v, and config %v", testCase.command, testCase.env, testCase.config)
+		}
+	}
+}
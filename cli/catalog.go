@@ -0,0 +1,434 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+	version "github.com/hashicorp/go-version"
+)
+
+const CMD_CATALOG = "catalog"
+
+// The folders in a modules repo that contain Terraform code, but not modules to use directly
+var CATALOG_SKIPPED_FOLDERS = []string{".terraform", "examples", "test", "tests"}
+
+// A module found in one of the sources of the catalog
+type catalogModule struct {
+	Name        string
+	Version     string
+	Source      string
+	Description string
+}
+
+// The response body of the list endpoint of the modules service of a Terraform Registry
+type registryModuleList struct {
+	Meta struct {
+		NextOffset *int `json:"next_offset"`
+	} `json:"meta"`
+	Modules []struct {
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+		Provider    string `json:"provider"`
+		Version     string `json:"version"`
+		Description string `json:"description"`
+	} `json:"modules"`
+}
+
+// List the modules in the sources passed after the catalog command, or, if there are none, in the sources in the
+// terragrunt-catalog option, and offer to scaffold one of them. A source is either a namespace in a Terraform Registry
+// (tfr://<host>/<namespace>), in which case we list the modules the registry has in that namespace, or any other source
+// URL, such as a Git repo or a local folder, in which case we download it and list each folder with Terraform code in
+// it. For Git repos, we use the newest version tag, unless the source URL has a ref.
+func catalog(terragruntOptions *options.TerragruntOptions) error {
+	sources := terragruntOptions.TerraformCliArgs[1:]
+	if len(sources) == 0 {
+		sources = terragruntOptions.CatalogSources
+	}
+	if len(sources) == 0 {
+		return errors.WithStackTrace(NoCatalogSources{})
+	}
+
+	httpClient, err := terragruntOptions.HttpClient(0)
+	if err != nil {
+		return err
+	}
+
+	modules := []catalogModule{}
+	for _, source := range sources {
+		sourceModules, err := listCatalogModules(source, httpClient, terragruntOptions)
+		if err != nil {
+			return err
+		}
+		modules = append(modules, sourceModules...)
+	}
+
+	if len(modules) == 0 {
+		terragruntOptions.Logger.Printf("Found no modules in %s", strings.Join(sources, ", "))
+		return nil
+	}
+
+	writeCatalog(modules, terragruntOptions.Writer)
+
+	// Without a user to pick a module, the list is all we can do
+	if terragruntOptions.NonInteractive || terragruntOptions.AssumeNo {
+		terragruntOptions.Logger.Printf("Run 'terragrunt %s <source>' with the source of one of the modules to create a config for it", CMD_SCAFFOLD)
+		return nil
+	}
+
+	answer, err := shell.PromptUserForInput("Enter the number of the module to scaffold, optionally followed by the folder to create it in, or nothing to quit: ", terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	scaffoldArgs, err := parseCatalogSelection(answer, modules)
+	if err != nil || len(scaffoldArgs) == 0 {
+		return err
+	}
+
+	scaffoldOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	scaffoldOptions.WorkingDir = terragruntOptions.WorkingDir
+	scaffoldOptions.TerraformCliArgs = append([]string{CMD_SCAFFOLD}, scaffoldArgs...)
+	return scaffold(scaffoldOptions)
+}
+
+// Return the modules in the given catalog source
+func listCatalogModules(source string, httpClient *http.Client, terragruntOptions *options.TerragruntOptions) ([]catalogModule, error) {
+	terragruntOptions.Logger.Printf("Looking for modules in %s", source)
+
+	if isRegistrySource(source) {
+		if err := terragruntOptions.CheckNetworkAccess(fmt.Sprintf("list the modules in the Terraform Registry namespace %s", source)); err != nil {
+			return nil, err
+		}
+		return listRegistryCatalogModules(source, httpClient, terragruntOptions)
+	}
+
+	root, subdir := splitCatalogSource(source)
+	if util.IsLocalModuleSource(root) || filepath.IsAbs(root) {
+		rootPath, err := util.CanonicalPath(root, terragruntOptions.WorkingDir)
+		if err != nil {
+			return nil, err
+		}
+		return findCatalogModules(rootPath, subdir, localCatalogSourceRoot(root, rootPath, terragruntOptions.WorkingDir), "", "")
+	}
+
+	if err := terragruntOptions.CheckNetworkAccess(fmt.Sprintf("download the catalog source %s", source)); err != nil {
+		return nil, err
+	}
+	return listRemoteCatalogModules(source, root, subdir, terragruntOptions)
+}
+
+// Return the modules in the given namespace of a Terraform Registry, such as tfr://registry.acme.com/acme. The registry
+// returns the newest version of each module, a page at a time.
+func listRegistryCatalogModules(source string, httpClient *http.Client, terragruntOptions *options.TerragruntOptions) ([]catalogModule, error) {
+	sourceUrl, err := url.Parse(source)
+	if err != nil {
+		return nil, errors.WithStackTrace(MalformedRegistrySource{Source: source})
+	}
+
+	namespace := strings.Trim(sourceUrl.Path, "/")
+	if namespace == "" || strings.Contains(namespace, "/") {
+		return nil, errors.WithStackTrace(MalformedRegistrySource{Source: source})
+	}
+
+	registry := &registryModule{Host: sourceUrl.Host, Namespace: namespace}
+	if registry.Host == "" {
+		registry.Host = DEFAULT_TERRAFORM_REGISTRY_HOST
+	}
+
+	modulesServiceUrl, err := getRegistryModulesServiceUrl(registry, httpClient, terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := []catalogModule{}
+	offset := 0
+	for {
+		listUrl := resolveRegistryPath(modulesServiceUrl, namespace)
+		listUrl.RawQuery = url.Values{"offset": []string{strconv.Itoa(offset)}}.Encode()
+
+		moduleList := registryModuleList{}
+		if _, err := sendRegistryRequest(listUrl, registry, httpClient, terragruntOptions, &moduleList); err != nil {
+			return nil, err
+		}
+
+		for _, module := range moduleList.Modules {
+			modules = append(modules, catalogModule{
+				Name:        fmt.Sprintf("%s/%s/%s", module.Namespace, module.Name, module.Provider),
+				Version:     module.Version,
+				Source:      fmt.Sprintf("%s://%s/%s/%s/%s?version=%s", TERRAFORM_REGISTRY_SCHEME, sourceUrl.Host, module.Namespace, module.Name, module.Provider, module.Version),
+				Description: module.Description,
+			})
+		}
+
+		// Stop at the last page, and at a registry that doesn't page forward, rather than looping forever
+		if moduleList.Meta.NextOffset == nil || *moduleList.Meta.NextOffset <= offset {
+			return modules, nil
+		}
+		offset = *moduleList.Meta.NextOffset
+	}
+}
+
+// Download the given remote source and return the modules in it. For a Git repo, the versions are its version tags, and
+// we download the newest one, unless the source has a ref.
+func listRemoteCatalogModules(source string, root string, subdir string, terragruntOptions *options.TerragruntOptions) ([]catalogModule, error) {
+	downloadOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	downloadOptions.WorkingDir = terragruntOptions.WorkingDir
+
+	terraformSource, err := processTerraformSource(root, downloadOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := ""
+	if sourceUrl, err := toSourceUrl(source, terragruntOptions.WorkingDir); err == nil {
+		ref = sourceUrl.Query().Get("ref")
+	}
+
+	forcedGetter, _ := getForcedGetter(terraformSource.CanonicalSourceURL.Scheme)
+	if ref == "" && forcedGetter == GIT_FORCED_GETTER {
+		tags, err := getGitVersionTags(terraformSource, downloadOptions)
+		if err != nil {
+			return nil, err
+		}
+		if len(tags) > 0 {
+			terragruntOptions.Logger.Printf("Versions of %s, newest first: %s", root, strings.Join(tags, ", "))
+			ref = tags[0]
+		}
+	}
+
+	downloadSource := root
+	if ref != "" {
+		downloadSource = root + "?ref=" + ref
+		if terraformSource, err = processTerraformSource(downloadSource, downloadOptions); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := downloadTerraformSourceIfNecessary(terraformSource, downloadOptions, &config.TerragruntConfig{}); err != nil {
+		return nil, err
+	}
+	markDownloadFolderUsed(terraformSource, downloadOptions)
+
+	query := ""
+	if ref != "" {
+		query = "?ref=" + ref
+	}
+	return findCatalogModules(terraformSource.DownloadDir, subdir, root, query, ref)
+}
+
+// Return the version tags of the Git repo of the given source, newest first. Tags that aren't versions are skipped.
+func getGitVersionTags(terraformSource *TerraformSource, terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	repoUrl, _ := getGitRepoUrlAndRef(terraformSource.CanonicalSourceURL)
+
+	gitOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	gitOptions.WorkingDir = terragruntOptions.WorkingDir
+
+	cleanupCredentials, err := configureSourceDownloadCredentials(terraformSource, gitOptions, terragruntOptions)
+	defer cleanupCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := shell.RunShellCommandAndCaptureOutput(gitOptions, "git", "ls-remote", "--tags", "--refs", repoUrl)
+	if err != nil {
+		return nil, errors.WithStackTrace(GitCloneFailed{Url: repoUrl, Underlying: err})
+	}
+
+	return parseGitVersionTags(out), nil
+}
+
+// Return the version tags in the given output of git ls-remote --tags, newest first
+func parseGitVersionTags(out string) []string {
+	versions := version.Collection{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			continue
+		}
+		parsedVersion, err := version.NewVersion(strings.TrimPrefix(fields[1], "refs/tags/"))
+		if err != nil || parsedVersion.Prerelease() != "" {
+			continue
+		}
+		versions = append(versions, parsedVersion)
+	}
+
+	sort.Sort(sort.Reverse(versions))
+
+	tags := []string{}
+	for _, parsedVersion := range versions {
+		tags = append(tags, parsedVersion.Original())
+	}
+	return tags
+}
+
+// Return each folder with Terraform code in the given subdir of the given folder as a module, with a source URL made of
+// the given root, the path of the folder after a double-slash, and the given query string
+func findCatalogModules(rootPath string, subdir string, sourceRoot string, sourceQuery string, moduleVersion string) ([]catalogModule, error) {
+	searchPath := util.JoinPath(rootPath, subdir)
+	if !util.IsDir(searchPath) {
+		return nil, errors.WithStackTrace(CatalogFolderNotFound(searchPath))
+	}
+
+	modules := []catalogModule{}
+	err := filepath.Walk(searchPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != searchPath && (strings.HasPrefix(info.Name(), ".") || util.ListContainsElement(CATALOG_SKIPPED_FOLDERS, info.Name())) {
+			return filepath.SkipDir
+		}
+
+		tfFiles, err := filepath.Glob(filepath.Join(path, "*.tf"))
+		if err != nil || len(tfFiles) == 0 {
+			return err
+		}
+
+		modulePath, err := util.GetPathRelativeTo(path, rootPath)
+		if err != nil {
+			return err
+		}
+
+		source := sourceRoot + sourceQuery
+		if modulePath != "." {
+			source = sourceRoot + "//" + modulePath + sourceQuery
+		}
+
+		name := modulePath
+		if name == "." {
+			name = getScaffoldFolderName(sourceRoot)
+		}
+
+		modules = append(modules, catalogModule{Name: name, Version: moduleVersion, Source: source, Description: readModuleDescription(path)})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	return modules, nil
+}
+
+// Split the given source URL into the part before the double-slash and the path after it, dropping any query string
+func splitCatalogSource(source string) (string, string) {
+	if index := strings.Index(source, "?"); index >= 0 {
+		source = source[:index]
+	}
+
+	// Skip the go-getter prefix and the scheme, so the double-slash of a URL such as https://... isn't taken for a subdir
+	prefixLength := 0
+	if matches := forcedRegexp.FindStringSubmatch(source); matches != nil {
+		prefixLength = len(matches[1]) + len("::")
+	}
+	if location := SOURCE_URL_SCHEME_REGEXP.FindStringIndex(source[prefixLength:]); location != nil {
+		prefixLength += location[1]
+	}
+
+	if index := strings.Index(source[prefixLength:], "//"); index >= 0 {
+		return source[:prefixLength+index], strings.Trim(source[prefixLength+index+2:], "/")
+	}
+	return source, ""
+}
+
+// Return the root of the sources of the modules in the given local catalog folder. A relative folder stays relative to
+// the working dir, where scaffold runs and rewrites it relative to the new module folder.
+func localCatalogSourceRoot(root string, rootPath string, workingDir string) string {
+	if filepath.IsAbs(root) {
+		return filepath.ToSlash(root)
+	}
+
+	relativePath, err := util.GetPathRelativeTo(rootPath, workingDir)
+	if err != nil {
+		return root
+	}
+	if !util.IsLocalModuleSource(relativePath) {
+		relativePath = "./" + relativePath
+	}
+	return relativePath
+}
+
+// Return the first line of text in the README of the module in the given folder, or an empty string if there isn't one
+func readModuleDescription(modulePath string) string {
+	contents, err := util.ReadFileAsString(util.JoinPath(modulePath, "README.md"))
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "[!") && !strings.HasPrefix(line, "<") {
+			return line
+		}
+	}
+	return ""
+}
+
+// Write a numbered list of the given modules to the given writer
+func writeCatalog(modules []catalogModule, out io.Writer) {
+	for index, module := range modules {
+		name := module.Name
+		if module.Version != "" {
+			name = fmt.Sprintf("%s (%s)", name, module.Version)
+		}
+		fmt.Fprintf(out, "%3d) %s\n", index+1, name)
+		fmt.Fprintf(out, "     %s\n", module.Source)
+		if module.Description != "" {
+			fmt.Fprintf(out, "     %s\n", module.Description)
+		}
+	}
+}
+
+// Parse the answer to the catalog prompt, which is the number of a module and optionally a folder, into the args for
+// the scaffold command. An empty answer means the user doesn't want to scaffold a module, so there are no args.
+func parseCatalogSelection(answer string, modules []catalogModule) ([]string, error) {
+	fields := strings.Fields(answer)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	index, err := strconv.Atoi(fields[0])
+	if err != nil || index < 1 || index > len(modules) || len(fields) > 2 {
+		return nil, errors.WithStackTrace(InvalidCatalogSelection{Answer: answer, NumModules: len(modules)})
+	}
+
+	return append([]string{modules[index-1].Source}, fields[1:]...), nil
+}
+
+// Custom error types
+
+type NoCatalogSources struct{}
+
+func (err NoCatalogSources) Error() string {
+	return fmt.Sprintf("No module sources to list. Pass them after the %s command, or set them with the --%s option or the TERRAGRUNT_CATALOG environment variable.", CMD_CATALOG, OPT_TERRAGRUNT_CATALOG)
+}
+
+type CatalogFolderNotFound string
+
+func (path CatalogFolderNotFound) Error() string {
+	return fmt.Sprintf("The catalog source folder %s does not exist", string(path))
+}
+
+type InvalidCatalogSelection struct {
+	Answer     string
+	NumModules int
+}
+
+func (err InvalidCatalogSelection) Error() string {
+	return fmt.Sprintf("Invalid answer '%s'. Expected a number from 1 to %d, optionally followed by a folder.", err.Answer, err.NumModules)
+}
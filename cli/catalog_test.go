@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListRegistryCatalogModules(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(TERRAFORM_REGISTRY_DISCOVERY_PATH, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"modules.v1": "/v1/modules/"}`)
+	})
+	mux.HandleFunc("/v1/modules/acme", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "0" {
+			fmt.Fprint(w, `{"meta": {"next_offset": 1}, "modules": [{"namespace": "acme", "name": "vpc", "provider": "aws", "version": "1.2.0", "description": "A VPC"}]}`)
+		} else {
+			fmt.Fprint(w, `{"meta": {}, "modules": [{"namespace": "acme", "name": "mysql", "provider": "aws", "version": "0.3.0"}]}`)
+		}
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("terraform.tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host := registryHost(t, server)
+	modules, err := listRegistryCatalogModules("tfr://"+host+"/acme", insecureHttpClient(), terragruntOptions)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		expected := []catalogModule{
+			{Name: "acme/vpc/aws", Version: "1.2.0", Source: "tfr://" + host + "/acme/vpc/aws?version=1.2.0", Description: "A VPC"},
+			{Name: "acme/mysql/aws", Version: "0.3.0", Source: "tfr://" + host + "/acme/mysql/aws?version=0.3.0"},
+		}
+		assert.Equal(t, expected, modules)
+	}
+
+	_, err = listRegistryCatalogModules("tfr://"+host+"/acme/vpc/aws", insecureHttpClient(), terragruntOptions)
+	_, isMalformedSourceErr := errors.Unwrap(err).(MalformedRegistrySource)
+	assert.True(t, isMalformedSourceErr, "Expected a MalformedRegistrySource error, but got %v", err)
+}
+
+func TestFindCatalogModules(t *testing.T) {
+	t.Parallel()
+
+	root := tmpDir(t)
+	defer os.RemoveAll(root)
+
+	writeSourceHashTestFile(t, root, "networking/vpc/main.tf", "")
+	writeSourceHashTestFile(t, root, "networking/vpc/README.md", "# VPC\n\nCreates a VPC.\n")
+	writeSourceHashTestFile(t, root, "networking/vpc/examples/basic/main.tf", "")
+	writeSourceHashTestFile(t, root, "mysql/variables.tf", "")
+	writeSourceHashTestFile(t, root, "test/main.tf", "")
+	writeSourceHashTestFile(t, root, ".terraform/modules/main.tf", "")
+	writeSourceHashTestFile(t, root, "docs/README.md", "")
+
+	modules, err := findCatalogModules(root, "", "git::git@github.com:acme/modules.git", "?ref=v0.2.0", "v0.2.0")
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		expected := []catalogModule{
+			{Name: "mysql", Version: "v0.2.0", Source: "git::git@github.com:acme/modules.git//mysql?ref=v0.2.0"},
+			{Name: "networking/vpc", Version: "v0.2.0", Source: "git::git@github.com:acme/modules.git//networking/vpc?ref=v0.2.0", Description: "Creates a VPC."},
+		}
+		assert.Equal(t, expected, modules)
+	}
+
+	modules, err = findCatalogModules(filepath.Join(root, "mysql"), "", "../modules", "", "")
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		assert.Equal(t, []catalogModule{{Name: "modules", Source: "../modules"}}, modules)
+	}
+
+	_, err = findCatalogModules(root, "services", "../modules", "", "")
+	_, isFolderNotFoundErr := errors.Unwrap(err).(CatalogFolderNotFound)
+	assert.True(t, isFolderNotFoundErr, "Expected a CatalogFolderNotFound error, but got %v", err)
+}
+
+func TestListLocalCatalogModules(t *testing.T) {
+	t.Parallel()
+
+	root := tmpDir(t)
+	defer os.RemoveAll(root)
+
+	writeSourceHashTestFile(t, root, "modules/networking/vpc/main.tf", "")
+	writeSourceHashTestFile(t, root, "modules/mysql/main.tf", "")
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(filepath.Join(root, "live", "terraform.tfvars"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modules, err := listCatalogModules("../modules//networking", http.DefaultClient, terragruntOptions)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		assert.Equal(t, []catalogModule{{Name: "networking/vpc", Source: "../modules//networking/vpc"}}, modules)
+	}
+}
+
+func TestParseGitVersionTags(t *testing.T) {
+	t.Parallel()
+
+	out := `0123456789abcdef0123456789abcdef01234567	refs/tags/v0.1.0
+1123456789abcdef0123456789abcdef01234567	refs/tags/v0.10.0
+2123456789abcdef0123456789abcdef01234567	refs/tags/v0.2.0
+3123456789abcdef0123456789abcdef01234567	refs/tags/v0.11.0-rc1
+4123456789abcdef0123456789abcdef01234567	refs/tags/latest
+`
+	assert.Equal(t, []string{"v0.10.0", "v0.2.0", "v0.1.0"}, parseGitVersionTags(out))
+	assert.Equal(t, []string{}, parseGitVersionTags(""))
+}
+
+func TestSplitCatalogSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source         string
+		expectedRoot   string
+		expectedSubdir string
+	}{
+		{"git::git@github.com:acme/modules.git", "git::git@github.com:acme/modules.git", ""},
+		{"git::https://github.com/acme/modules.git//networking/?ref=v0.1.0", "git::https://github.com/acme/modules.git", "networking"},
+		{"github.com/acme/modules//mysql", "github.com/acme/modules", "mysql"},
+		{"../modules//networking", "../modules", "networking"},
+		{"https://example.com/modules.tar.gz", "https://example.com/modules.tar.gz", ""},
+	}
+
+	for _, testCase := range testCases {
+		root, subdir := splitCatalogSource(testCase.source)
+		assert.Equal(t, testCase.expectedRoot, root, "For source %s", testCase.source)
+		assert.Equal(t, testCase.expectedSubdir, subdir, "For source %s", testCase.source)
+	}
+}
+
+func TestParseCatalogSelection(t *testing.T) {
+	t.Parallel()
+
+	modules := []catalogModule{{Name: "vpc", Source: "../modules//vpc"}, {Name: "mysql", Source: "../modules//mysql"}}
+
+	args, err := parseCatalogSelection(" 2 ", modules)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		assert.Equal(t, []string{"../modules//mysql"}, args)
+	}
+
+	args, err = parseCatalogSelection("1 prod/vpc", modules)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		assert.Equal(t, []string{"../modules//vpc", "prod/vpc"}, args)
+	}
+
+	args, err = parseCatalogSelection("", modules)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Empty(t, args)
+
+	for _, answer := range []string{"3", "0", "vpc", "1 prod/vpc extra"} {
+		_, err = parseCatalogSelection(answer, modules)
+		_, isInvalidSelectionErr := errors.Unwrap(err).(InvalidCatalogSelection)
+		assert.True(t, isInvalidSelectionErr, "Expected an InvalidCatalogSelection error for %s, but got %v", answer, err)
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+const CMD_CHECK_DUPLICATE_STATE_KEYS = "check-duplicate-state-keys"
+
+// Check that no two modules of the stack in the subfolders of the working dir store their remote state in the same
+// location, such as the same key in the same S3 bucket, as they would overwrite each other's state. Since this only
+// parses the Terragrunt configs, it's fast enough to run in CI on every change. The other *-all commands do the same
+// check before running Terraform in any module.
+func checkDuplicateStateKeys(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
+	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stack.CheckForDuplicateStateKeys()
+
+	modulesWithDuplicateKeys := map[string]bool{}
+	for _, modulePaths := range stack.FindDuplicateStateKeys() {
+		for _, modulePath := range modulePaths {
+			modulesWithDuplicateKeys[modulePath] = true
+		}
+	}
+
+	results := []configstack.ModuleResult{}
+	for _, module := range stack.Modules {
+		result := configstack.ModuleResult{Path: module.Path, Status: configstack.ModuleSucceeded}
+		if modulesWithDuplicateKeys[module.Path] {
+			result.Status = configstack.ModuleFailed
+			result.Err = err
+		}
+		results = append(results, result)
+	}
+
+	if err == nil {
+		terragruntOptions.Logger.Printf("Each of the %d modules of the stack stores its remote state in its own location", len(stack.Modules))
+	}
+	return results, err
+}
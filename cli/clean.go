@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const CMD_CLEAN = "clean"
+const CMD_CLEAN_ALL = "clean-all"
+
+// Pass this flag to the clean commands to list what would be deleted without deleting anything
+const CLEAN_DRY_RUN_FLAG = "--dry-run"
+
+// Pass this flag to the clean commands to also delete the .terraform folder in each module, which contains the
+// provider plugins and modules downloaded by terraform init, as well as the local copy of the remote state config
+const CLEAN_INCLUDE_TERRAFORM_DIR_FLAG = "--include-terraform-dir"
+
+// The files that Terragrunt itself writes into the working dir of a module
+var TERRAGRUNT_GENERATED_FILES = []string{TERRAGRUNT_PLAN_FILE, TERRAGRUNT_PLAN_JSON_FILE, configstack.COST_ESTIMATE_FILE, DEBUG_BUNDLE_FOLDER, AWS_PROVIDER_FILE}
+
+// Delete the files Terragrunt created for the module in the working dir of the given options: all the source code it
+// downloaded for the module, the outputs of the module it cached for each set of credentials they were read with, the
+// files it generated in the module, and, if the user passed the CLEAN_INCLUDE_TERRAFORM_DIR_FLAG, the module's
+// .terraform folder.
+func clean(terragruntOptions *options.TerragruntOptions) error {
+	paths, err := getPathsToClean(terragruntOptions.WorkingDir, terragruntOptions.TerragruntConfigPath, terragruntOptions)
+	if err != nil {
+		return err
+	}
+	return deletePaths(paths, terragruntOptions)
+}
+
+// Delete the files Terragrunt created for every module in the subfolders of the working dir of the given options. See
+// clean for details. Unlike the other xxx-all commands, we don't need to parse the Terragrunt configs or resolve the
+// dependencies between the modules, so this works even if the configs are broken.
+func cleanAll(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
+	terragruntConfigFiles, err := config.FindConfigFilesInWorkingDir(terragruntOptions)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	results := []configstack.ModuleResult{}
+
+	for _, terragruntConfigFile := range terragruntConfigFiles {
+		modulePath := filepath.Dir(terragruntConfigFile)
+
+		paths, err := getPathsToClean(modulePath, terragruntConfigFile, terragruntOptions)
+		if err == nil {
+			err = deletePaths(paths, terragruntOptions)
+		}
+
+		status := configstack.ModuleSucceeded
+		if err != nil {
+			status = configstack.ModuleFailed
+		}
+		results = append(results, configstack.ModuleResult{Path: modulePath, Status: status, Err: err})
+
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// Return the paths of the files and folders Terragrunt created for the module in the given folder, with the given config,
+// that exist and should be deleted according to the args in the given options
+func getPathsToClean(modulePath string, terragruntConfigPath string, terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	canonicalModulePath, err := util.CanonicalPath(modulePath, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// All the source code downloaded for a module is in a single folder in the download dir, named after the module's
+	// working dir. See processTerraformSource for details.
+	candidates := []string{util.JoinPath(terragruntOptions.DownloadDir, util.EncodeBase64Sha1(canonicalModulePath))}
+
+	// The outputs of the module are cached in a folder per module, with a file per set of credentials and IAM roles
+	// they were read with. See outputCacheKey for details.
+	candidates = append(candidates, util.JoinPath(terragruntOptions.DownloadDir, OUTPUT_CACHE_FOLDER, outputCacheModuleKey(terragruntConfigPath)))
+
+	for _, generatedFile := range TERRAGRUNT_GENERATED_FILES {
+		candidates = append(candidates, util.JoinPath(canonicalModulePath, generatedFile))
+	}
+
+	if util.ListContainsElement(terragruntOptions.TerraformCliArgs, CLEAN_INCLUDE_TERRAFORM_DIR_FLAG) {
+		candidates = append(candidates, util.JoinPath(canonicalModulePath, ".terraform"))
+	}
+
+	paths := []string{}
+	for _, candidate := range candidates {
+		if util.FileExists(candidate) {
+			paths = append(paths, candidate)
+		}
+	}
+	return paths, nil
+}
+
+// Delete the given files and folders, or, if the user passed the CLEAN_DRY_RUN_FLAG, just log what would be deleted
+func deletePaths(paths []string, terragruntOptions *options.TerragruntOptions) error {
+	dryRun := util.ListContainsElement(terragruntOptions.TerraformCliArgs, CLEAN_DRY_RUN_FLAG)
+
+	for _, path := range paths {
+		if dryRun {
+			terragruntOptions.Logger.Printf("Would delete %s", path)
+			continue
+		}
+
+		terragruntOptions.Logger.Printf("Deleting %s", path)
+		if err := os.RemoveAll(path); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPathsToClean(t *testing.T) {
+	t.Parallel()
+
+	modulePath, downloadDir := createCleanTestModule(t)
+	defer os.RemoveAll(modulePath)
+	defer os.RemoveAll(downloadDir)
+
+	moduleDownloadDir := util.JoinPath(downloadDir, util.EncodeBase64Sha1(modulePath))
+	moduleOutputCacheDir := util.JoinPath(downloadDir, OUTPUT_CACHE_FOLDER, outputCacheModuleKey(util.JoinPath(modulePath, config.DefaultTerragruntConfigPath)))
+
+	testCases := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{}, []string{moduleDownloadDir, moduleOutputCacheDir, util.JoinPath(modulePath, TERRAGRUNT_PLAN_FILE)}},
+		{[]string{CLEAN_DRY_RUN_FLAG}, []string{moduleDownloadDir, moduleOutputCacheDir, util.JoinPath(modulePath, TERRAGRUNT_PLAN_FILE)}},
+		{[]string{CLEAN_INCLUDE_TERRAFORM_DIR_FLAG}, []string{moduleDownloadDir, moduleOutputCacheDir, util.JoinPath(modulePath, TERRAGRUNT_PLAN_FILE), util.JoinPath(modulePath, ".terraform")}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions := createCleanTestOptions(t, modulePath, downloadDir, testCase.args)
+
+		actual, err := getPathsToClean(modulePath, terragruntOptions.TerragruntConfigPath, terragruntOptions)
+		assert.Nil(t, err, "Unexpected error for args %v: %v", testCase.args, err)
+		assert.Equal(t, testCase.expected, actual, "For args %v", testCase.args)
+	}
+}
+
+func TestCleanDryRun(t *testing.T) {
+	t.Parallel()
+
+	modulePath, downloadDir := createCleanTestModule(t)
+	defer os.RemoveAll(modulePath)
+	defer os.RemoveAll(downloadDir)
+
+	terragruntOptions := createCleanTestOptions(t, modulePath, downloadDir, []string{CMD_CLEAN, CLEAN_DRY_RUN_FLAG, CLEAN_INCLUDE_TERRAFORM_DIR_FLAG})
+
+	err := clean(terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	assert.True(t, util.FileExists(util.JoinPath(downloadDir, util.EncodeBase64Sha1(modulePath))))
+	assert.True(t, util.FileExists(util.JoinPath(modulePath, TERRAGRUNT_PLAN_FILE)))
+	assert.True(t, util.FileExists(util.JoinPath(modulePath, ".terraform")))
+}
+
+func TestClean(t *testing.T) {
+	t.Parallel()
+
+	modulePath, downloadDir := createCleanTestModule(t)
+	defer os.RemoveAll(modulePath)
+	defer os.RemoveAll(downloadDir)
+
+	terragruntOptions := createCleanTestOptions(t, modulePath, downloadDir, []string{CMD_CLEAN})
+
+	err := clean(terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	assert.False(t, util.FileExists(util.JoinPath(downloadDir, util.EncodeBase64Sha1(modulePath))))
+	assert.False(t, util.FileExists(util.JoinPath(downloadDir, OUTPUT_CACHE_FOLDER, outputCacheModuleKey(terragruntOptions.TerragruntConfigPath))))
+	assert.False(t, util.FileExists(util.JoinPath(modulePath, TERRAGRUNT_PLAN_FILE)))
+	assert.True(t, util.FileExists(util.JoinPath(modulePath, ".terraform")), "Expected the .terraform folder to be kept without %s", CLEAN_INCLUDE_TERRAFORM_DIR_FLAG)
+	assert.True(t, util.FileExists(util.JoinPath(modulePath, config.DefaultTerragruntConfigPath)))
+}
+
+func TestCleanAll(t *testing.T) {
+	t.Parallel()
+
+	stackPath := tmpDir(t)
+	defer os.RemoveAll(stackPath)
+
+	downloadDir := tmpDir(t)
+	defer os.RemoveAll(downloadDir)
+
+	for _, module := range []string{"vpc", "app"} {
+		writeSourceHashTestFile(t, stackPath, util.JoinPath(module, config.DefaultTerragruntConfigPath), "terragrunt = {}")
+		writeSourceHashTestFile(t, stackPath, util.JoinPath(module, TERRAGRUNT_PLAN_JSON_FILE), "{}")
+		writeSourceHashTestFile(t, downloadDir, util.JoinPath(util.EncodeBase64Sha1(util.JoinPath(stackPath, module)), "source", "main.tf"), "# main")
+	}
+
+	terragruntOptions := createCleanTestOptions(t, stackPath, downloadDir, []string{})
+
+	results, err := cleanAll(terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, 2, len(results))
+
+	for _, result := range results {
+		assert.Equal(t, configstack.ModuleSucceeded, result.Status, "For module %s", result.Path)
+		assert.False(t, util.FileExists(util.JoinPath(result.Path, TERRAGRUNT_PLAN_JSON_FILE)), "For module %s", result.Path)
+		assert.False(t, util.FileExists(util.JoinPath(downloadDir, util.EncodeBase64Sha1(result.Path))), "For module %s", result.Path)
+	}
+}
+
+// Create a module with a downloaded source, cached outputs, a generated plan file, and a .terraform folder, and return
+// the paths of the module and the download dir
+func createCleanTestModule(t *testing.T) (string, string) {
+	modulePath := tmpDir(t)
+	downloadDir := tmpDir(t)
+
+	writeSourceHashTestFile(t, modulePath, config.DefaultTerragruntConfigPath, "terragrunt = {}")
+	writeSourceHashTestFile(t, modulePath, TERRAGRUNT_PLAN_FILE, "plan")
+	writeSourceHashTestFile(t, modulePath, ".terraform/terraform.tfstate", "{}")
+	writeSourceHashTestFile(t, downloadDir, util.JoinPath(util.EncodeBase64Sha1(modulePath), "source", "main.tf"), "# main")
+	writeSourceHashTestFile(t, downloadDir, util.JoinPath(OUTPUT_CACHE_FOLDER, outputCacheModuleKey(util.JoinPath(modulePath, config.DefaultTerragruntConfigPath)), "outputs.json"), "{}")
+
+	return modulePath, downloadDir
+}
+
+func createCleanTestOptions(t *testing.T, workingDir string, downloadDir string, args []string) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(workingDir, config.DefaultTerragruntConfigPath))
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.WorkingDir = workingDir
+	terragruntOptions.DownloadDir = downloadDir
+	terragruntOptions.TerraformCliArgs = args
+	return terragruntOptions
+}
@@ -3,7 +3,8 @@ package cli
 import (
 	"fmt"
 	"io"
-	"regexp"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -14,6 +15,7 @@ import (
 	"github.com/gruntwork-io/terragrunt/options"
 	"github.com/gruntwork-io/terragrunt/remote"
 	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/telemetry"
 	"github.com/gruntwork-io/terragrunt/util"
 	version "github.com/hashicorp/go-version"
 	"github.com/urfave/cli"
@@ -24,22 +26,82 @@ const OPT_TERRAGRUNT_CONFIG = "terragrunt-config"
 const OPT_TERRAGRUNT_TFPATH = "terragrunt-tfpath"
 const OPT_TERRAGRUNT_NO_AUTO_INIT = "terragrunt-no-auto-init"
 const OPT_NON_INTERACTIVE = "terragrunt-non-interactive"
+const OPT_TERRAGRUNT_AUTO_APPROVE = "terragrunt-auto-approve"
+const OPT_TERRAGRUNT_ASSUME_NO = "terragrunt-assume-no"
+const OPT_TERRAGRUNT_PROMPT_TIMEOUT = "terragrunt-prompt-timeout"
+const OPT_TERRAGRUNT_NO_PTY = "terragrunt-no-pty"
+const OPT_TERRAGRUNT_NO_COLOR = "terragrunt-no-color"
+const OPT_TERRAGRUNT_NO_PROGRESS = "terragrunt-no-progress"
+const OPT_TERRAGRUNT_QUIET = "terragrunt-quiet"
+const OPT_TERRAGRUNT_FAIL_FAST = "terragrunt-fail-fast"
+const OPT_TERRAGRUNT_FAIL_FAST_INTERRUPT = "terragrunt-fail-fast-interrupt"
+const OPT_TERRAGRUNT_RESUME = "terragrunt-resume"
+const OPT_TERRAGRUNT_LOG_DIR = "terragrunt-log-dir"
+const OPT_TERRAGRUNT_AUDIT_LOG = "terragrunt-audit-log"
+const OPT_TERRAGRUNT_PROFILE = "terragrunt-profile"
+const OPT_TERRAGRUNT_DEBUG_ARGS = "terragrunt-debug-args"
+const OPT_TERRAGRUNT_DEBUG = "terragrunt-debug"
+const OPT_TERRAGRUNT_STRICT_VALIDATE = "terragrunt-strict-validate"
+const OPT_TERRAGRUNT_STRICT = "terragrunt-strict"
+const OPT_TERRAGRUNT_STRICT_CONTROL = "terragrunt-strict-control"
+const OPT_TERRAGRUNT_ALLOW_MISSING_CONFIG = "terragrunt-allow-missing-config"
+const OPT_TERRAGRUNT_TF_ARG = "terragrunt-tf-arg"
+const OPT_TERRAGRUNT_BEFORE_HOOK = "terragrunt-before-hook"
+const OPT_TERRAGRUNT_AFTER_HOOK = "terragrunt-after-hook"
+const OPT_TERRAGRUNT_REDACT_PATTERN = "terragrunt-redact-pattern"
+const OPT_TERRAGRUNT_REDACT_TERRAFORM_OUTPUT = "terragrunt-redact-terraform-output"
+const OPT_TERRAGRUNT_FIX_S3_REGION = "terragrunt-fix-s3-region"
+const OPT_TERRAGRUNT_DISABLE_CHECKPOINT = "terragrunt-disable-checkpoint"
+const OPT_TERRAGRUNT_OFFLINE = "terragrunt-offline"
 const OPT_WORKING_DIR = "terragrunt-working-dir"
 const OPT_TERRAGRUNT_SOURCE = "terragrunt-source"
 const OPT_TERRAGRUNT_SOURCE_UPDATE = "terragrunt-source-update"
 const OPT_TERRAGRUNT_IAM_ROLE = "terragrunt-iam-role"
+const OPT_TERRAGRUNT_IAM_ROLES = "terragrunt-iam-roles"
+const OPT_TERRAGRUNT_IAM_WEB_IDENTITY_TOKEN = "terragrunt-iam-web-identity-token"
 const OPT_TERRAGRUNT_IGNORE_DEPENDENCY_ERRORS = "terragrunt-ignore-dependency-errors"
-
-var ALL_TERRAGRUNT_BOOLEAN_OPTS = []string{OPT_NON_INTERACTIVE, OPT_TERRAGRUNT_SOURCE_UPDATE, OPT_TERRAGRUNT_IGNORE_DEPENDENCY_ERRORS, OPT_TERRAGRUNT_NO_AUTO_INIT}
-var ALL_TERRAGRUNT_STRING_OPTS = []string{OPT_TERRAGRUNT_CONFIG, OPT_TERRAGRUNT_TFPATH, OPT_WORKING_DIR, OPT_TERRAGRUNT_SOURCE, OPT_TERRAGRUNT_IAM_ROLE}
+const OPT_TERRAGRUNT_GIT_DIFF = "terragrunt-git-diff"
+const OPT_TERRAGRUNT_SEARCH_PARENT_DIRS = "terragrunt-search-parent-dirs"
+const OPT_TERRAGRUNT_FOLLOW_SYMLINKS = "terragrunt-follow-symlinks"
+const OPT_TERRAGRUNT_MODULES_THAT_INCLUDE = "terragrunt-modules-that-include"
+const OPT_TERRAGRUNT_STRICT_INCLUDE = "terragrunt-strict-include"
+const OPT_TERRAGRUNT_PARSE_CACHE = "terragrunt-parse-cache"
+const OPT_TERRAGRUNT_EXTRA_DEPENDENCIES = "terragrunt-extra-dependencies"
+const OPT_TERRAGRUNT_TARGET = "terragrunt-target"
+const OPT_TERRAGRUNT_TARGET_FROM_STATE = "terragrunt-target-from-state"
+const OPT_TERRAGRUNT_FEATURE = "terragrunt-feature"
+const OPT_TERRAGRUNT_SOURCE_SSH_KEY = "terragrunt-source-ssh-key"
+const OPT_TERRAGRUNT_SOURCE_TOKEN_ENV_VAR = "terragrunt-source-token-env-var"
+const OPT_TERRAGRUNT_DOWNLOAD_DIR = "terragrunt-download-dir"
+const OPT_TERRAGRUNT_DOWNLOAD_MAX_AGE = "terragrunt-download-max-age"
+const OPT_TERRAGRUNT_DOWNLOAD_MAX_SIZE = "terragrunt-download-max-size"
+const OPT_TERRAGRUNT_DOWNLOAD_MAX_ENTRIES = "terragrunt-download-max-entries"
+const OPT_TERRAGRUNT_SOURCE_SHALLOW_CLONE = "terragrunt-source-shallow-clone"
+const OPT_TERRAGRUNT_SOURCE_SPARSE_CHECKOUT = "terragrunt-source-sparse-checkout"
+const OPT_TERRAGRUNT_SOURCE_NO_SUBMODULES = "terragrunt-source-no-submodules"
+const OPT_TERRAGRUNT_CATALOG = "terragrunt-catalog"
+const OPT_TERRAGRUNT_OUTPUT_CACHE_TTL = "terragrunt-output-cache-ttl"
+const OPT_TERRAGRUNT_DOCKER_IMAGE = "terragrunt-docker-image"
+const OPT_TERRAGRUNT_RUN_LOCK_TIMEOUT = "terragrunt-run-lock-timeout"
+const OPT_TERRAGRUNT_CA_BUNDLE = "terragrunt-ca-bundle"
+
+var ALL_TERRAGRUNT_BOOLEAN_OPTS = []string{OPT_NON_INTERACTIVE, OPT_TERRAGRUNT_AUTO_APPROVE, OPT_TERRAGRUNT_ASSUME_NO, OPT_TERRAGRUNT_SOURCE_UPDATE, OPT_TERRAGRUNT_IGNORE_DEPENDENCY_ERRORS, OPT_TERRAGRUNT_NO_AUTO_INIT, OPT_TERRAGRUNT_SOURCE_SHALLOW_CLONE, OPT_TERRAGRUNT_SOURCE_SPARSE_CHECKOUT, OPT_TERRAGRUNT_SOURCE_NO_SUBMODULES, OPT_TERRAGRUNT_NO_PTY, OPT_TERRAGRUNT_NO_COLOR, OPT_TERRAGRUNT_NO_PROGRESS, OPT_TERRAGRUNT_QUIET, OPT_TERRAGRUNT_FAIL_FAST, OPT_TERRAGRUNT_FAIL_FAST_INTERRUPT, OPT_TERRAGRUNT_RESUME, OPT_TERRAGRUNT_DEBUG_ARGS, OPT_TERRAGRUNT_DEBUG, OPT_TERRAGRUNT_STRICT_VALIDATE, OPT_TERRAGRUNT_STRICT, OPT_TERRAGRUNT_FIX_S3_REGION, OPT_TERRAGRUNT_STRICT_INCLUDE, OPT_TERRAGRUNT_FOLLOW_SYMLINKS, OPT_TERRAGRUNT_SEARCH_PARENT_DIRS, OPT_TERRAGRUNT_PARSE_CACHE, OPT_TERRAGRUNT_ALLOW_MISSING_CONFIG, OPT_TERRAGRUNT_TARGET_FROM_STATE, OPT_TERRAGRUNT_DISABLE_CHECKPOINT, OPT_TERRAGRUNT_OFFLINE, OPT_TERRAGRUNT_REDACT_TERRAFORM_OUTPUT}
+var ALL_TERRAGRUNT_STRING_OPTS = []string{OPT_TERRAGRUNT_CONFIG, OPT_TERRAGRUNT_TFPATH, OPT_WORKING_DIR, OPT_TERRAGRUNT_SOURCE, OPT_TERRAGRUNT_IAM_ROLE, OPT_TERRAGRUNT_IAM_ROLES, OPT_TERRAGRUNT_IAM_WEB_IDENTITY_TOKEN, OPT_TERRAGRUNT_GIT_DIFF, OPT_TERRAGRUNT_MODULES_THAT_INCLUDE, OPT_TERRAGRUNT_EXTRA_DEPENDENCIES, OPT_TERRAGRUNT_SOURCE_SSH_KEY, OPT_TERRAGRUNT_SOURCE_TOKEN_ENV_VAR, OPT_TERRAGRUNT_DOWNLOAD_DIR, OPT_TERRAGRUNT_DOWNLOAD_MAX_AGE, OPT_TERRAGRUNT_DOWNLOAD_MAX_SIZE, OPT_TERRAGRUNT_DOWNLOAD_MAX_ENTRIES, OPT_TERRAGRUNT_PROMPT_TIMEOUT, OPT_TERRAGRUNT_LOG_DIR, OPT_TERRAGRUNT_AUDIT_LOG, OPT_TERRAGRUNT_PROFILE, OPT_TERRAGRUNT_CATALOG, OPT_TERRAGRUNT_OUTPUT_CACHE_TTL, OPT_TERRAGRUNT_DOCKER_IMAGE, OPT_TERRAGRUNT_RUN_LOCK_TIMEOUT, OPT_TERRAGRUNT_STRICT_CONTROL, OPT_TERRAGRUNT_TF_ARG, OPT_TERRAGRUNT_BEFORE_HOOK, OPT_TERRAGRUNT_AFTER_HOOK, OPT_TERRAGRUNT_REDACT_PATTERN, OPT_TERRAGRUNT_TARGET, OPT_TERRAGRUNT_FEATURE, OPT_TERRAGRUNT_CA_BUNDLE}
+
+// The key of the options of the run in the Metadata of the app, which ErrorLogWriter uses
+const TERRAGRUNT_OPTIONS_METADATA_KEY = "terragruntOptions"
 
 const CMD_PLAN_ALL = "plan-all"
 const CMD_APPLY_ALL = "apply-all"
 const CMD_DESTROY_ALL = "destroy-all"
 const CMD_OUTPUT_ALL = "output-all"
 const CMD_VALIDATE_ALL = "validate-all"
+const CMD_STATE_ALL = "state-all"
+const CMD_DRIFT_ALL = "drift-all"
 
 const CMD_INIT = "init"
+const CMD_APPLY = "apply"
+const CMD_DESTROY = "destroy"
 
 // CMD_SPIN_UP is deprecated.
 const CMD_SPIN_UP = "spin-up"
@@ -47,7 +109,15 @@ const CMD_SPIN_UP = "spin-up"
 // CMD_TEAR_DOWN is deprecated.
 const CMD_TEAR_DOWN = "tear-down"
 
-var MULTI_MODULE_COMMANDS = []string{CMD_APPLY_ALL, CMD_DESTROY_ALL, CMD_OUTPUT_ALL, CMD_PLAN_ALL, CMD_VALIDATE_ALL}
+// The commands that --terragrunt-target works with. Terraform either rejects -target for the others, such as output,
+// or means something else by it, such as for validate.
+var COMMANDS_THAT_SUPPORT_TARGETS = []string{CMD_PLAN_ALL, CMD_APPLY_ALL, CMD_DESTROY_ALL}
+
+var MULTI_MODULE_COMMANDS = []string{CMD_APPLY_ALL, CMD_DESTROY_ALL, CMD_OUTPUT_ALL, CMD_PLAN_ALL, CMD_VALIDATE_ALL, CMD_STATE_ALL, CMD_STATE_INVENTORY, CMD_DRIFT_ALL, CMD_IMPORT_ALL, CMD_CLEAN_ALL, CMD_CHECK_DUPLICATE_STATE_KEYS}
+
+// The 'terraform state' subcommands that are supported by state-all. We only support read-only subcommands, as the
+// resource addresses that other subcommands (e.g. mv or rm) operate on differ from module to module.
+var STATE_ALL_SUBCOMMANDS = []string{"list"}
 
 // DEPRECATED_COMMANDS is a map of deprecated commands to the commands that replace them.
 var DEPRECATED_COMMANDS = map[string]string{
@@ -78,6 +148,22 @@ var TERRAFORM_COMMANDS_THAT_DO_NOT_NEED_INIT = []string{
 	"version",
 }
 
+// The Terraform commands that accept the -no-color flag
+var TERRAFORM_COMMANDS_WITH_NO_COLOR = []string{
+	"apply",
+	"destroy",
+	"get",
+	"import",
+	"init",
+	"output",
+	"plan",
+	"refresh",
+	"show",
+	"taint",
+	"untaint",
+	"validate",
+}
+
 // Since Terragrunt is just a thin wrapper for Terraform, and we don't want to repeat every single Terraform command
 // in its definition, we don't quite fit into the model of any Go CLI library. Fortunately, urfave/cli allows us to
 // override the whole template used for the Usage Text.
@@ -97,18 +183,88 @@ COMMANDS:
    output-all           Display the outputs of a 'stack' by running 'terragrunt output' in each subfolder
    destroy-all          Destroy a 'stack' by running 'terragrunt destroy' in each subfolder
    validate-all         Validate 'stack' by running 'terragrunt validate' in each subfolder
+   state-all list       List the resources in the state of each module of a 'stack' by running 'terragrunt state list' in each subfolder
+   state-inventory      Print the resources in the state of each module of a 'stack', with their type, name, ID, and module path, as JSON. Add --format csv for CSV.
+   drift-all            Check each module of a 'stack' for drift with a refresh-only plan, and print a report as JSON. Exits with 2 if any module has drifted, and 1 on errors.
+   import-all           Import the existing resources in the YAML file passed with --mapping-file into the state of the modules of a 'stack' with 'terragrunt import'.
+   check-duplicate-state-keys  Check that no two modules of a 'stack' store their remote state in the same location, such as the same S3 key.
+   clean                Delete the source code Terragrunt downloaded and the files it generated for a module. Add --dry-run to only list them.
+   clean-all            Run 'terragrunt clean' in each subfolder of a 'stack'
+   validate-inputs      Check that the inputs of a module set all its required variables. Add --strict to also fail on inputs that don't match any variable.
+   render-json          Print the Terragrunt config of a module as JSON, after merging its includes and resolving its interpolations. Add --out <file> to write it to a file.
+   validate-config      Check the Terragrunt config of a module, or the given config files, for syntax errors and unknown settings.
+   output-from          Print the outputs of the module at the given path as JSON, without changing to its folder. Add an output name to print only that output.
+   generate-ci          Print a CI config with a job for each module of a 'stack', in dependency order. Add --format atlantis, gitlab, or github, and --out <file> to write it to a file.
+   scaffold             Create a folder with a Terragrunt config for the module at the given source URL, with an input for each of its variables. Add a folder to use instead of the module name.
+   catalog              List the modules in the given module sources, or the ones in terragrunt-catalog, and scaffold the one you pick. A source is a Git repo, a local folder, or tfr://<host>/<namespace>.
+   version              Print the versions of Terragrunt, Terraform, and Go, the path of Terraform, and the platform. Add --json to print them as JSON.
+   self-update          Replace the Terragrunt binary with the newest release, or the newest one that matches the given version constraint, after verifying its checksum.
+   completion           Print a script that completes Terragrunt and Terraform commands and Terragrunt options in the given shell: bash, zsh, or fish.
    *                    Terragrunt forwards all other commands directly to Terraform
 
 GLOBAL OPTIONS:
    terragrunt-config                    Path to the Terragrunt config file. Default is terraform.tfvars.
    terragrunt-tfpath                    Path to the Terraform binary. Default is terraform (on PATH).
+   terragrunt-docker-image              Run Terraform in a container of the specified Docker image, rather than on the host.
    terragrunt-no-auto-init              Don't automatically run 'terraform init' during other terragrunt commands. You must run 'terragrunt init' manually.
-   terragrunt-non-interactive           Assume "yes" for all prompts.
+   terragrunt-non-interactive           Assume "yes" for all prompts, except those that approve destructive operations such as destroy-all.
+   terragrunt-auto-approve              Approve destructive operations, such as destroy-all, without prompting, and pass -auto-approve to Terraform.
+   terragrunt-assume-no                 Assume "no" for all prompts, even if terragrunt-non-interactive is set. Can't be combined with terragrunt-auto-approve.
+   terragrunt-prompt-timeout            If a prompt isn't answered within the specified duration (e.g. 5m), use its default answer.
+   terragrunt-no-pty                    Don't run Terraform in a pseudo-terminal, even if stdin and stdout are terminals.
+   terragrunt-no-color                  Don't use colors in the log output, and pass -no-color to the Terraform commands that support it.
+   terragrunt-no-progress               Don't log the progress (modules done, running, and pending) of the xxx-all commands.
+   terragrunt-quiet                     Don't log anything but errors and prompts, so stderr only has the output of Terraform and the errors.
    terragrunt-working-dir               The path to the Terraform templates. Default is current directory.
+   terragrunt-search-parent-dirs        If the working dir has no Terragrunt config, use the one in the nearest parent directory, and run there.
+   terragrunt-allow-missing-config      If there is no Terragrunt config, run Terraform in the working dir without one, instead of failing.
+   terragrunt-before-hook               Run the specified shell command in the working dir before Terraform. If it fails, Terraform doesn't run.
+   terragrunt-after-hook                Run the specified shell command in the working dir after Terraform, whether or not Terraform succeeded.
+   terragrunt-redact-pattern            Replace each match of the specified regular expression with REDACTED in the log output and the output of the hooks. Can be repeated.
+   terragrunt-redact-terraform-output   Redact secrets, such as the values of AWS_SECRET_ACCESS_KEY and TF_VAR_*password*, from the output of Terraform too, not just the log output.
    terragrunt-source                    Download Terraform configurations from the specified source into a temporary folder, and run Terraform in that temporary folder.
    terragrunt-source-update             Delete the contents of the temporary folder to clear out any old, cached source code before downloading new source code into it.
+   terragrunt-source-ssh-key            Path to an SSH private key to use when downloading Terraform configurations from Git repos over SSH.
+   terragrunt-source-token-env-var      Name of an environment variable that contains a token to use when downloading Terraform configurations over HTTPS.
+   terragrunt-source-shallow-clone      Only fetch the latest commit of Git repos when downloading Terraform configurations.
+   terragrunt-source-sparse-checkout    Only check out the folder after the double-slash of Git repos when downloading Terraform configurations.
+   terragrunt-source-no-submodules      Don't check out submodules of Git repos when downloading Terraform configurations.
+   terragrunt-download-dir              The folder to download Terraform configurations into. Default is .terragrunt in the home directory.
+   terragrunt-download-max-age          Delete downloaded Terraform configurations that haven't been used for longer than the specified duration (e.g. 168h).
+   terragrunt-download-max-size         Delete the least recently used downloaded Terraform configurations until they take up at most the specified size (e.g. 10GB).
+   terragrunt-download-max-entries      Delete the least recently used downloaded Terraform configurations until at most the specified number remain.
    terragrunt-iam-role             		Assume the specified IAM role before executing Terraform. Can also be set via the TERRAGRUNT_IAM_ROLE environment variable.
+   terragrunt-iam-roles                 Assume the specified comma-separated IAM roles one after the other, each with the credentials of the one before it.
+   terragrunt-iam-web-identity-token    Assume the first IAM role with this web identity token, or the token in this file, instead of AWS credentials.
    terragrunt-ignore-dependency-errors  *-all commands continue processing components even if a dependency fails.
+   terragrunt-fail-fast                 *-all commands don't start any more modules once a module fails.
+   terragrunt-fail-fast-interrupt       *-all commands don't start any more modules, and interrupt the running ones, once a module fails.
+   terragrunt-resume                    *-all commands only run the modules that failed or didn't run in the previous run of the same command.
+   terragrunt-tf-arg                    Add an arg to one Terraform command in every module, as <command>=<arg> (e.g. apply=-target=module.vpc). Can be repeated.
+   terragrunt-debug-args                Log where each of the args Terragrunt passes to Terraform came from, and the final list of args.
+   terragrunt-debug                     Write the Terraform command, its environment, and its var files to .terragrunt-debug in the module, with a script that runs the command without Terragrunt.
+   terragrunt-strict-validate           Fail on any setting in a Terragrunt config that Terragrunt doesn't know about, instead of ignoring it.
+   terragrunt-strict                    Fail on every deprecated behavior, such as the spin-up command or a .terragrunt config file, instead of warning.
+   terragrunt-strict-control            Fail on the specified comma-separated deprecated behaviors only: deprecated-commands, old-config-file, lock-table.
+   terragrunt-disable-checkpoint        Set CHECKPOINT_DISABLE for Terraform and skip Terragrunt's own update check, so nothing phones home.
+   terragrunt-ca-bundle                 Trust the CA certificates in the specified PEM file, e.g. of a TLS-intercepting proxy, for AWS calls, source downloads, and all other outbound calls.
+   terragrunt-offline                   Fail with an error on any network call Terragrunt itself would make, such as downloading a source or calling AWS, and use local caches.
+   terragrunt-fix-s3-region             If the remote state S3 bucket is in a different region than the config says, use the bucket's region instead of failing.
+   terragrunt-log-dir                   *-all commands also write the Terraform output of each module to <module path>.log in the specified folder.
+   terragrunt-audit-log                 Write a record of every Terraform command to the specified JSON lines file, or to the specified s3://bucket/prefix.
+   terragrunt-profile                   Write a CPU profile of the run to the specified file, plus a heap profile and how long each phase of the run took next to it.
+   terragrunt-git-diff                  *-all commands only process the modules that changed relative to the specified git ref, plus the modules that depend on them.
+   terragrunt-follow-symlinks           *-all commands also look for modules in the folders that symlinks point to.
+   terragrunt-modules-that-include      *-all commands only process the modules that include one of the specified comma-separated config files, plus their dependencies.
+   terragrunt-strict-include            *-all commands don't process the dependencies of the modules selected with terragrunt-modules-that-include.
+   terragrunt-extra-dependencies        *-all commands treat each of the specified comma-separated <module>=<dependency> pairs as a dependency.
+   terragrunt-target                    plan-all, apply-all, and destroy-all only process the modules that contain the specified resource address, with -target set to it. Can be repeated.
+   terragrunt-target-from-state         Find the modules that contain the terragrunt-target addresses in their state, instead of in their Terraform code.
+   terragrunt-feature                   Set the feature flag with the specified <name>=<value>, which configs read with get_feature(). Can be repeated.
+   terragrunt-parse-cache               Cache parsed configs, and reuse them until the files and environment variables they depend on change.
+   terragrunt-catalog                   The comma-separated module sources the catalog command lists modules from.
+   terragrunt-output-cache-ttl          Cache the outputs output-from reads on disk for the specified duration (e.g. 10m), until the module is applied.
+   terragrunt-run-lock-timeout          How long to wait for another run to release the run lock of a module (e.g. 10m). Default is not to wait.
 
 VERSION:
    {{.Version}}{{if len .Authors}}
@@ -118,12 +274,11 @@ AUTHOR(S):
    {{end}}
 `
 
-var MODULE_REGEX = regexp.MustCompile(`module[[:blank:]]+".+"`)
-
 // This uses the constraint syntax from https://github.com/hashicorp/go-version
 const DEFAULT_TERRAFORM_VERSION_CONSTRAINT = ">= v0.9.3"
 
-const TERRAFORM_EXTENSION_GLOB = "*.tf"
+// The destroy command supports -auto-approve (rather than just -force) as of this version of Terraform
+const MINIMUM_TERRAFORM_VERSION_FOR_DESTROY_AUTO_APPROVE = "v0.11.4"
 
 // Create the Terragrunt CLI App
 func CreateTerragruntCli(version string, writer io.Writer, errwriter io.Writer) *cli.App {
@@ -143,14 +298,30 @@ func CreateTerragruntCli(version string, writer io.Writer, errwriter io.Writer)
 	app.Usage = "terragrunt <COMMAND>"
 	app.Writer = writer
 	app.ErrWriter = errwriter
+	app.Metadata = map[string]interface{}{}
 	app.UsageText = `Terragrunt is a thin wrapper for Terraform that provides extra tools for working with multiple
    Terraform modules, remote state, and locking. For documentation, see https://github.com/gruntwork-io/terragrunt/.`
 
 	return app
 }
 
+// Return the writer for the error Terragrunt exits with: the ErrorLogWriter of the options the given app parsed, so the
+// secrets it learned during the run are redacted, or, if it didn't get that far, the ErrWriter of the app, with the
+// secrets in the environment variables redacted
+func ErrorLogWriter(app *cli.App) io.Writer {
+	if terragruntOptions, hasOptions := app.Metadata[TERRAGRUNT_OPTIONS_METADATA_KEY].(*options.TerragruntOptions); hasOptions {
+		return terragruntOptions.ErrorLogWriter()
+	}
+
+	redactor := util.NewRedactor(nil)
+	redactor.AddSecretsFromEnv(parseEnvironmentVariables(os.Environ()))
+	return util.NewRedactingWriter(app.ErrWriter, redactor)
+}
+
 // The sole action for the app
 func runApp(cliContext *cli.Context) (finalErr error) {
+	var terragruntOptions *options.TerragruntOptions
+	defer func() { reportCrashIfPanicked(finalErr, cliContext, terragruntOptions) }()
 	defer errors.Recover(func(cause error) { finalErr = cause })
 
 	// If someone calls us with no args at all, show the help text and exit
@@ -159,61 +330,186 @@ func runApp(cliContext *cli.Context) (finalErr error) {
 		return nil
 	}
 
+	// Generating a completion script doesn't need any of the options, or even Terraform, as it only prints a script
+	if cliContext.Args().First() == CMD_COMPLETION {
+		return printCompletionScript(cliContext.Args().Tail(), cliContext.App.Writer)
+	}
+
 	terragruntOptions, err := ParseTerragruntOptions(cliContext)
 	if err != nil {
 		return err
 	}
+	cliContext.App.Metadata[TERRAGRUNT_OPTIONS_METADATA_KEY] = terragruntOptions
+
+	// The version command also prints which Terraform Terragrunt runs, so it must not fail if that can't be run
+	if cliContext.Args().First() == CMD_VERSION {
+		return printVersion(terragruntOptions)
+	}
+
+	// Updating Terragrunt doesn't need Terraform either, and may be how someone gets a version that supports theirs
+	if cliContext.Args().First() == CMD_SELF_UPDATE {
+		return selfUpdate(terragruntOptions)
+	}
+
+	checkForUpdate(terragruntOptions)
+
+	ctx, cancel := shell.CancelContextOnSignals(terragruntOptions.GetContext(), terragruntOptions.Logger)
+	defer cancel()
+	terragruntOptions.Context = ctx
+
+	telemetry.Start(terragruntOptions)
+	defer telemetry.Shutdown(terragruntOptions)
+
+	profiler := telemetry.StartProfile(terragruntOptions)
+	defer profiler.Stop(terragruntOptions)
 
-	if err := PopulateTerraformVersion(terragruntOptions); err != nil {
+	err = telemetry.Trace(terragruntOptions, "check terraform version", nil, func() error {
+		return PopulateTerraformVersion(terragruntOptions)
+	})
+	if err != nil {
 		return err
 	}
 
-	if err := CheckTerraformVersion(DEFAULT_TERRAFORM_VERSION_CONSTRAINT, terragruntOptions); err != nil {
+	if err := CheckTerraformVersion(DEFAULT_TERRAFORM_VERSION_CONSTRAINT, DEFAULT_TERRAFORM_VERSION_CONSTRAINT_SOURCE, terragruntOptions); err != nil {
 		return err
 	}
 
 	givenCommand := cliContext.Args().First()
-	command := checkDeprecated(givenCommand, terragruntOptions)
-	return runCommand(command, terragruntOptions)
+	command, err := checkDeprecated(givenCommand, terragruntOptions)
+	if err != nil {
+		return err
+	}
+	return telemetry.Trace(terragruntOptions, "terragrunt "+command, map[string]string{"command": command, "working_dir": terragruntOptions.WorkingDir}, func() error {
+		_, err := runCommand(command, terragruntOptions)
+		return err
+	})
 }
 
-// checkDeprecated checks if the given command is deprecated.  If so: prints a message and returns the new command.
-func checkDeprecated(command string, terragruntOptions *options.TerragruntOptions) string {
+// checkDeprecated checks if the given command is deprecated.  If so: prints a message and returns the new command, or,
+// if strict mode is on for deprecated commands, returns an error.
+func checkDeprecated(command string, terragruntOptions *options.TerragruntOptions) (string, error) {
 	newCommand, deprecated := DEPRECATED_COMMANDS[command]
 	if deprecated {
-		terragruntOptions.Logger.Printf("%v is deprecated; running %v instead.\n", command, newCommand)
-		return newCommand
+		if err := terragruntOptions.Deprecated(options.STRICT_CONTROL_DEPRECATED_COMMANDS, fmt.Sprintf("%v is deprecated; running %v instead.", command, newCommand)); err != nil {
+			return "", err
+		}
+		return newCommand, nil
 	}
-	return command
+	return command, nil
 }
 
 // runCommand runs one or many terraform commands based on the type of
-// terragrunt command
-func runCommand(command string, terragruntOptions *options.TerragruntOptions) (finalEff error) {
+// terragrunt command and returns the result of each module it ran
+func runCommand(command string, terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
+	collectDownloadGarbageIfNecessary(terragruntOptions)
+
+	if len(terragruntOptions.Targets) > 0 && !util.ListContainsElement(COMMANDS_THAT_SUPPORT_TARGETS, command) {
+		return nil, errors.WithStackTrace(TargetNotSupported(command))
+	}
+
 	if isMultiModuleCommand(command) {
 		return runMultiModuleCommand(command, terragruntOptions)
 	}
-	return runTerragrunt(terragruntOptions)
+
+	modulePath := terragruntOptions.WorkingDir
+	var err error
+	if command == CMD_CLEAN {
+		err = clean(terragruntOptions)
+	} else if command == CMD_VALIDATE_CONFIG {
+		err = validateConfig(terragruntOptions)
+	} else if command == CMD_OUTPUT_FROM {
+		err = outputFrom(terragruntOptions)
+	} else if command == CMD_GENERATE_CI {
+		err = generateCI(terragruntOptions)
+	} else if command == CMD_SCAFFOLD {
+		err = scaffold(terragruntOptions)
+	} else if command == CMD_CATALOG {
+		err = catalog(terragruntOptions)
+	} else if command == CMD_DESTROY {
+		err = destroy(terragruntOptions)
+	} else {
+		err = runTerragrunt(terragruntOptions)
+	}
+
+	status := configstack.ModuleSucceeded
+	if err != nil {
+		status = configstack.ModuleFailed
+	}
+	return []configstack.ModuleResult{{Path: modulePath, Status: status, Err: err}}, err
 }
 
 // Downloads terraform source if necessary, then runs terraform with the given options and CLI args.
 // This will forward all the args and extra_arguments directly to Terraform.
 func runTerragrunt(terragruntOptions *options.TerragruntOptions) error {
-	terragruntConfig, err := config.ReadTerragruntConfig(terragruntOptions)
+	var terragruntConfig *config.TerragruntConfig
+	err := telemetry.Trace(terragruntOptions, "parse config", map[string]string{"path": terragruntOptions.TerragruntConfigPath}, func() (err error) {
+		terragruntConfig, err = readTerragruntConfig(terragruntOptions)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
+	if firstArg(terragruntOptions.TerraformCliArgs) == CMD_RENDER_JSON {
+		return renderJSON(terragruntOptions, terragruntConfig)
+	}
+
+	if !terragruntConfig.IsEnabled() {
+		terragruntOptions.Logger.Printf("The config at %s sets enabled = false, so Terragrunt will not run Terraform in this module", terragruntOptions.TerragruntConfigPath)
+		return nil
+	}
+
+	if terragruntConfig.TerraformVersionConstraint != "" {
+		constraintSource := fmt.Sprintf("the terraform_version_constraint of %s", terragruntOptions.TerragruntConfigPath)
+		if err := CheckTerraformVersion(terragruntConfig.TerraformVersionConstraint, constraintSource, terragruntOptions); err != nil {
+			return err
+		}
+	}
+
+	setEnvVarsFromConfig(terragruntOptions, terragruntConfig)
+	setTerraformBinaryWrapperFromConfig(terragruntOptions, terragruntConfig)
+	setTerraformDockerImageFromConfig(terragruntOptions, terragruntConfig)
+	setRemoteExecFromConfig(terragruntOptions, terragruntConfig)
+	setIamRolesFromConfig(terragruntOptions, terragruntConfig)
+	setDefaultTagsFromConfig(terragruntOptions, terragruntConfig)
+
 	if err := assumeRoleIfNecessary(terragruntOptions); err != nil {
 		return err
 	}
 
+	if err := checkAwsRegionIfNecessary(terragruntOptions, terragruntConfig); err != nil {
+		return err
+	}
+
+	if err := checkAwsAccountIfNecessary(terragruntOptions, terragruntConfig); err != nil {
+		return err
+	}
+
 	if sourceUrl := getTerraformSourceUrl(terragruntOptions, terragruntConfig); sourceUrl != "" {
-		if err := downloadTerraformSource(sourceUrl, terragruntOptions, terragruntConfig); err != nil {
+		// Downloading the source changes the working dir, so resolve any file paths in the args first
+		if err := makeStateFileArgAbsolute(terragruntOptions); err != nil {
+			return err
+		}
+		err = telemetry.Trace(terragruntOptions, "download source", map[string]string{"source": sourceUrl}, func() error {
+			return downloadTerraformSource(sourceUrl, terragruntOptions, terragruntConfig)
+		})
+		if err != nil {
 			return err
 		}
 	}
 
+	if err := copyExtraFilesFromConfig(terragruntOptions, terragruntConfig); err != nil {
+		return err
+	}
+
+	if firstArg(terragruntOptions.TerraformCliArgs) == CMD_VALIDATE_INPUTS {
+		return validateInputs(terragruntOptions, terragruntConfig)
+	}
+
+	if err := writeAwsProviderFromConfig(terragruntOptions, terragruntConfig); err != nil {
+		return err
+	}
+
 	if terragruntConfig.RemoteState != nil {
 		if err := checkTerraformCodeDefinesBackend(terragruntOptions, terragruntConfig.RemoteState.Backend); err != nil {
 			return err
@@ -223,15 +519,124 @@ func runTerragrunt(terragruntOptions *options.TerragruntOptions) error {
 	return runTerragruntWithConfig(terragruntOptions, terragruntConfig, false)
 }
 
-// Assume an IAM role, if one is specified, by making API calls to Amazon STS and setting the environment variables
-// we get back inside of terragruntOptions.Env
+// The 'terraform state push' command takes the path of a state file as its last argument. If that path is relative,
+// make it absolute, relative to the current working dir, so it still points to the same file when Terraform runs in
+// a different folder (e.g. the folder Terragrunt downloads the Terraform source into).
+func makeStateFileArgAbsolute(terragruntOptions *options.TerragruntOptions) error {
+	args := terragruntOptions.TerraformCliArgs
+	if firstArg(args) != "state" || secondArg(args) != "push" || len(args) < 3 {
+		return nil
+	}
+
+	stateFile := args[len(args)-1]
+	// A path of "-" means stdin, and anything else starting with a dash is a flag rather than a path
+	if strings.HasPrefix(stateFile, "-") || filepath.IsAbs(stateFile) {
+		return nil
+	}
+
+	absoluteStateFile, err := util.CanonicalPath(stateFile, terragruntOptions.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	args[len(args)-1] = absoluteStateFile
+	return nil
+}
+
+// Set the environment variables from the env_vars setting of the terraform block in the Terragrunt config, if any, so
+// they are passed to every command Terragrunt runs for this module
+func setEnvVarsFromConfig(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) {
+	if terragruntConfig.Terraform == nil {
+		return
+	}
+
+	for key, value := range terragruntConfig.Terraform.EnvVars {
+		terragruntOptions.Env[key] = value
+	}
+	terragruntOptions.Redactor.AddSecretsFromEnv(terragruntConfig.Terraform.EnvVars)
+}
+
+// Run Terraform with the terraform_binary_wrapper in the given config, if any
+func setTerraformBinaryWrapperFromConfig(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) {
+	if terragruntConfig.Terraform == nil || len(terragruntConfig.Terraform.BinaryWrapper) == 0 {
+		return
+	}
+
+	terragruntOptions.Logger.Printf("Running Terraform with the wrapper %v", terragruntConfig.Terraform.BinaryWrapper)
+	terragruntOptions.TerraformBinaryWrapper = terragruntConfig.Terraform.BinaryWrapper
+}
+
+// Run Terraform in a container of the docker_image in the given config, if any, unless an image was set on the command
+// line or in the environment
+func setTerraformDockerImageFromConfig(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) {
+	if terragruntConfig.Terraform == nil || terragruntConfig.Terraform.DockerImage == "" || terragruntOptions.TerraformDockerImage != "" {
+		return
+	}
+
+	terragruntOptions.Logger.Printf("Running Terraform in a container of the Docker image %s", terragruntConfig.Terraform.DockerImage)
+	terragruntOptions.TerraformDockerImage = terragruntConfig.Terraform.DockerImage
+}
+
+// Run the Terraform commands on the host in the remote_exec block of the given config, if any
+func setRemoteExecFromConfig(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) {
+	if terragruntConfig.RemoteExec == nil {
+		return
+	}
+
+	terragruntOptions.Logger.Printf("Running Terraform on %s over SSH", terragruntConfig.RemoteExec.SshHost)
+	terragruntOptions.RemoteExec = &options.RemoteExecOptions{
+		Host:        terragruntConfig.RemoteExec.SshHost,
+		SshArgs:     terragruntConfig.RemoteExec.SshArgs,
+		SyncFolders: terragruntConfig.RemoteExec.SyncFolders,
+	}
+}
+
+// Use the iam_roles from the Terragrunt config as the chain of IAM roles to assume, and its iam_web_identity_token as
+// the token to assume the first one with, unless they were set on the command line or in the environment
+func setIamRolesFromConfig(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) {
+	if len(terragruntConfig.IamRoles) > 0 && len(terragruntOptions.IamRoles) == 0 {
+		terragruntOptions.IamRoles = terragruntConfig.IamRoles
+	}
+	if terragruntConfig.IamWebIdentityToken != "" && terragruntOptions.IamWebIdentityToken == "" {
+		terragruntOptions.IamWebIdentityToken = terragruntConfig.IamWebIdentityToken
+	}
+}
+
+// Use the default_tags in the given config, if any, for the resources Terragrunt creates, and pass them to Terraform in
+// the DEFAULT_TAGS_ENV_VAR environment variable, unless it's already set, so the code of a module can use them by
+// declaring a default_tags variable
+func setDefaultTagsFromConfig(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) {
+	if len(terragruntConfig.DefaultTags) == 0 {
+		return
+	}
+
+	terragruntOptions.DefaultTags = terragruntConfig.DefaultTags
+	if _, alreadySet := terragruntOptions.Env[DEFAULT_TAGS_ENV_VAR]; !alreadySet {
+		terragruntOptions.Env[DEFAULT_TAGS_ENV_VAR] = formatTerraformStringMap(terragruntConfig.DefaultTags)
+	}
+}
+
+// Assume an IAM role, or a chain of IAM roles, if any are specified, by making API calls to Amazon STS and setting the
+// environment variables we get back inside of terragruntOptions.Env
 func assumeRoleIfNecessary(terragruntOptions *options.TerragruntOptions) error {
-	if terragruntOptions.IamRole == "" {
+	iamRoleChain := terragruntOptions.IamRoleChain()
+	if len(iamRoleChain) == 0 {
 		return nil
 	}
 
-	terragruntOptions.Logger.Printf("Assuming IAM role %s", terragruntOptions.IamRole)
-	creds, err := aws_helper.AssumeIamRole(terragruntOptions.IamRole)
+	// Offline, STS can't be called, but credentials that were already assumed (e.g. exported by a wrapper script) can
+	// still be used as they are
+	if terragruntOptions.Offline && hasCachedAwsCredentials(terragruntOptions) {
+		terragruntOptions.Logger.Printf("Terragrunt is running in offline mode, so it will use the AWS credentials in the environment instead of assuming IAM role %s", strings.Join(iamRoleChain, " -> "))
+		return nil
+	}
+
+	if err := terragruntOptions.CheckNetworkAccess(fmt.Sprintf("assume IAM role %s", strings.Join(iamRoleChain, " -> "))); err != nil {
+		return err
+	}
+
+	terragruntOptions.Logger.Printf("Assuming IAM role %s", strings.Join(iamRoleChain, " -> "))
+	creds, err := aws_helper.AssumeIamRoleChain(iamRoleChain, terragruntOptions)
 	if err != nil {
 		return err
 	}
@@ -239,18 +644,50 @@ func assumeRoleIfNecessary(terragruntOptions *options.TerragruntOptions) error {
 	terragruntOptions.Env["AWS_ACCESS_KEY_ID"] = aws.StringValue(creds.AccessKeyId)
 	terragruntOptions.Env["AWS_SECRET_ACCESS_KEY"] = aws.StringValue(creds.SecretAccessKey)
 	terragruntOptions.Env["AWS_SESSION_TOKEN"] = aws.StringValue(creds.SessionToken)
+	terragruntOptions.Redactor.AddSecretsFromEnv(terragruntOptions.Env)
 
 	return nil
 }
 
+// Return true if the env vars of the given options already have a complete set of temporary AWS credentials, such as
+// those of an IAM role that was assumed earlier
+func hasCachedAwsCredentials(terragruntOptions *options.TerragruntOptions) bool {
+	for _, envVar := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"} {
+		if terragruntOptions.Env[envVar] == "" {
+			return false
+		}
+	}
+	return true
+}
+
 // Runs terraform with the given options and CLI args.
 // This will forward all the args and extra_arguments directly to Terraform.
 func runTerragruntWithConfig(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig, allowSourceDownload bool) error {
+	logArgsForDebug(terragruntOptions, "the command line", terragruntOptions.TerraformCliArgs)
+
+	if err := mergeTfCliArgsEnvVars(terragruntOptions); err != nil {
+		return err
+	}
 
 	// Add extra_arguments to the command
 	if terragruntConfig.Terraform != nil && terragruntConfig.Terraform.ExtraArgs != nil && len(terragruntConfig.Terraform.ExtraArgs) > 0 {
-		terragruntOptions.InsertTerraformCliArgs(filterTerraformExtraArgs(terragruntOptions, terragruntConfig)...)
+		extraArgs, err := filterTerraformExtraArgs(terragruntOptions, terragruntConfig)
+		if err != nil {
+			return err
+		}
+		logArgsForDebug(terragruntOptions, "extra_arguments", extraArgs)
+		terragruntOptions.InsertTerraformCliArgs(extraArgs...)
+	}
+
+	if err := addAutomationArgs(terragruntOptions); err != nil {
+		return err
+	}
+
+	releaseRunLocks, err := acquireRunLocksIfNecessary(terragruntOptions, terragruntConfig)
+	if err != nil {
+		return err
 	}
+	defer releaseRunLocks()
 
 	if firstArg(terragruntOptions.TerraformCliArgs) == CMD_INIT {
 		if err := prepareInitCommand(terragruntOptions, terragruntConfig, allowSourceDownload); err != nil {
@@ -261,7 +698,112 @@ func runTerragruntWithConfig(terragruntOptions *options.TerragruntOptions, terra
 			return err
 		}
 	}
-	return shell.RunTerraformCommand(terragruntOptions, terragruntOptions.TerraformCliArgs...)
+
+	if err := backupStateIfNecessary(terragruntOptions, terragruntConfig); err != nil {
+		return err
+	}
+
+	// If policies or cost estimation are configured, the plan must be written to a file so we can process it after
+	// the plan command runs
+	planFile := ""
+	if firstArg(terragruntOptions.TerraformCliArgs) == CMD_PLAN && needsPlanFile(terragruntConfig) {
+		planFile = ensurePlanFileArg(terragruntOptions)
+		if err := removeCostEstimateFile(terragruntOptions); err != nil {
+			return err
+		}
+	}
+
+	logArgsForDebug(terragruntOptions, "all sources combined, which is what Terraform gets", terragruntOptions.TerraformCliArgs)
+	if terragruntOptions.Debug {
+		if err := writeDebugBundle(terragruntOptions); err != nil {
+			return err
+		}
+	}
+
+	command := firstArg(terragruntOptions.TerraformCliArgs)
+	runErr := runTerraformCommandWithHooks(terragruntOptions, func() error {
+		return telemetry.Trace(terragruntOptions, "terraform "+command, map[string]string{"command": command, "working_dir": terragruntOptions.WorkingDir}, func() error {
+			return shell.RunTerraformCommand(withoutTfCliArgsEnvVars(terragruntOptions), terragruntOptions.TerraformCliArgs...)
+		})
+	})
+	telemetry.Count("terragrunt.terraform.commands", map[string]string{"command": command, "succeeded": strconv.FormatBool(runErr == nil)})
+	invalidateOutputCacheIfNecessary(terragruntOptions)
+
+	if planFile != "" && (runErr == nil || isPlanWithChanges(runErr, terragruntOptions)) {
+		if err := processPlan(planFile, terragruntOptions, terragruntConfig); err != nil {
+			return err
+		}
+	}
+
+	return runErr
+}
+
+// Pass the non-interactive and auto-approve settings on to Terraform, unless the user already set the corresponding
+// arguments themselves. If auto-approve is set, we add -auto-approve to apply and destroy (or -force to destroy on older
+// Terraform versions). If non-interactive is set, we add -input=false to the commands that accept it, except for apply,
+// as Terraform refuses to apply with -input=false unless the apply is also auto-approved.
+func addAutomationArgs(terragruntOptions *options.TerragruntOptions) error {
+	command := firstArg(terragruntOptions.TerraformCliArgs)
+
+	if terragruntOptions.AutoApprove && (command == CMD_APPLY || command == CMD_DESTROY) && !hasArg(terragruntOptions.TerraformCliArgs, "-auto-approve", "-force") {
+		autoApproveArg, err := getAutoApproveArg(command, terragruntOptions)
+		if err != nil {
+			return err
+		}
+		terragruntOptions.InsertTerraformCliArgs(autoApproveArg)
+	}
+
+	if terragruntOptions.NonInteractive && command != CMD_APPLY && util.ListContainsElement(config.TERRAFORM_COMMANDS_NEED_INPUT, command) && !hasArg(terragruntOptions.TerraformCliArgs, "-input") {
+		terragruntOptions.InsertTerraformCliArgs("-input=false")
+	}
+
+	if terragruntOptions.NoColor && util.ListContainsElement(TERRAFORM_COMMANDS_WITH_NO_COLOR, command) && !hasArg(terragruntOptions.TerraformCliArgs, "-no-color") {
+		terragruntOptions.InsertTerraformCliArgs("-no-color")
+	}
+
+	return nil
+}
+
+// Return the argument that tells the given Terraform command to skip its approval prompt. Before Terraform 0.11.4, the
+// destroy command only supported -force.
+func getAutoApproveArg(command string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	if command != CMD_DESTROY || terragruntOptions.TerraformVersion == nil {
+		return "-auto-approve", nil
+	}
+
+	minimumVersion, err := version.NewVersion(MINIMUM_TERRAFORM_VERSION_FOR_DESTROY_AUTO_APPROVE)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	if terragruntOptions.TerraformVersion.LessThan(minimumVersion) {
+		return "-force", nil
+	}
+	return "-auto-approve", nil
+}
+
+// Returns true if the given list of args contains any of the given flags, either on their own (e.g. -input) or with a
+// value (e.g. -input=false)
+func hasArg(args []string, flags ...string) bool {
+	for _, arg := range args {
+		for _, flag := range flags {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// When run with -detailed-exitcode, 'terraform plan' exits with code 2 if it succeeded and there are changes. Return
+// true if the given error from running the plan command is this exit code.
+func isPlanWithChanges(planErr error, terragruntOptions *options.TerragruntOptions) bool {
+	if !util.ListContainsElement(terragruntOptions.TerraformCliArgs, "-detailed-exitcode") {
+		return false
+	}
+
+	exitCode, err := shell.GetExitCode(planErr)
+	return err == nil && exitCode == 2
 }
 
 // Prepare for running 'terraform init' by
@@ -298,29 +840,11 @@ func prepareInitCommand(terragruntOptions *options.TerragruntOptions, terragrunt
 
 // Check that the specified Terraform code defines a backend { ... } block and return an error if doesn't
 func checkTerraformCodeDefinesBackend(terragruntOptions *options.TerragruntOptions, backendType string) error {
-	terraformBackendRegexp, err := regexp.Compile(fmt.Sprintf(`backend[[:blank:]]+"%s"`, backendType))
-	if err != nil {
-		return errors.WithStackTrace(err)
-	}
-
-	definesBackend, err := util.Grep(terraformBackendRegexp, fmt.Sprintf("%s/**/*.tf", terragruntOptions.WorkingDir))
-	if err != nil {
-		return err
-	}
-	if definesBackend {
-		return nil
-	}
-
-	terraformJSONBackendRegexp, err := regexp.Compile(fmt.Sprintf(`(?m)"backend":[[:space:]]*{[[:space:]]*"%s"`, backendType))
-	if err != nil {
-		return errors.WithStackTrace(err)
-	}
-
-	definesJSONBackend, err := util.Grep(terraformJSONBackendRegexp, fmt.Sprintf("%s/**/*.tf.json", terragruntOptions.WorkingDir))
+	terraformCode, err := util.ParseTerraformCode(terragruntOptions.WorkingDir)
 	if err != nil {
 		return err
 	}
-	if definesJSONBackend {
+	if terraformCode.DefinesBackend(backendType) {
 		return nil
 	}
 
@@ -330,6 +854,10 @@ func checkTerraformCodeDefinesBackend(terragruntOptions *options.TerragruntOptio
 // Prepare for running any command other than 'terraform init' by
 // running 'terraform init' if necessary
 func prepareNonInitCommand(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	if err := checkRemoteStateDrift(terragruntOptions, terragruntConfig); err != nil {
+		return err
+	}
+
 	needsInit, err := needsInit(terragruntOptions, terragruntConfig)
 	if err != nil {
 		return err
@@ -395,6 +923,10 @@ func runTerraformInit(terragruntOptions *options.TerragruntOptions, terragruntCo
 	// Don't pollute stdout with the stdout from Aoto Init
 	initOptions.Writer = initOptions.ErrWriter
 
+	// The hooks are for the command the user asked for, not for the init Terragrunt runs before it
+	initOptions.BeforeHook = ""
+	initOptions.AfterHook = ""
+
 	// Only add the arguments to download source if terraformSource was specified
 	downloadSource := terraformSource != nil
 	if downloadSource {
@@ -418,6 +950,12 @@ func runTerraformInit(terragruntOptions *options.TerragruntOptions, terragruntCo
 			initOptions.AppendTerraformCliArgs("-from-module=" + terraformSource.CanonicalSourceURL.String())
 		}
 		initOptions.AppendTerraformCliArgs(terraformSource.DownloadDir)
+
+		cleanupCredentials, err := configureSourceDownloadCredentials(terraformSource, initOptions, terragruntOptions)
+		defer cleanupCredentials()
+		if err != nil {
+			return err
+		}
 	}
 
 	return runTerragruntWithConfig(initOptions, terragruntConfig, downloadSource)
@@ -454,7 +992,7 @@ func isMultiModuleCommand(command string) bool {
 }
 
 // Execute a command that affects multiple Terraform modules, such as the apply-all or destroy-all command.
-func runMultiModuleCommand(command string, terragruntOptions *options.TerragruntOptions) error {
+func runMultiModuleCommand(command string, terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
 	switch command {
 	case CMD_PLAN_ALL:
 		return planAll(terragruntOptions)
@@ -466,12 +1004,25 @@ func runMultiModuleCommand(command string, terragruntOptions *options.Terragrunt
 		return outputAll(terragruntOptions)
 	case CMD_VALIDATE_ALL:
 		return validateAll(terragruntOptions)
+	case CMD_STATE_ALL:
+		return stateAll(terragruntOptions)
+	case CMD_STATE_INVENTORY:
+		return stateInventory(terragruntOptions)
+	case CMD_DRIFT_ALL:
+		return driftAll(terragruntOptions)
+	case CMD_IMPORT_ALL:
+		return importAll(terragruntOptions)
+	case CMD_CLEAN_ALL:
+		return cleanAll(terragruntOptions)
+	case CMD_CHECK_DUPLICATE_STATE_KEYS:
+		return checkDuplicateStateKeys(terragruntOptions)
 	default:
-		return errors.WithStackTrace(UnrecognizedCommand(command))
+		return nil, errors.WithStackTrace(UnrecognizedCommand(command))
 	}
 }
 
-// Return true if modules aren't already downloaded and the Terraform templates in this project reference modules.
+// Return true if modules aren't already downloaded and the Terraform templates in this project (*.tf and *.tf.json)
+// reference modules, either directly, or in the local modules they use.
 // Note that to keep the logic in this code very simple, this code ONLY detects the case where you haven't downloaded
 // modules at all. Detecting if your downloaded modules are out of date (as opposed to missing entirely) is more
 // complicated and not something we handle at the moment.
@@ -481,7 +1032,11 @@ func modulesNeedInit(terragruntOptions *options.TerragruntOptions) (bool, error)
 		return false, nil
 	}
 
-	return util.Grep(MODULE_REGEX, fmt.Sprintf("%s/%s", terragruntOptions.WorkingDir, TERRAFORM_EXTENSION_GLOB))
+	modules, err := util.FindTerraformModules(terragruntOptions.WorkingDir)
+	if err != nil {
+		return false, err
+	}
+	return len(modules) > 0, nil
 }
 
 // If the user entered a Terraform command that uses state (e.g. plan, apply), make sure remote state is configured
@@ -498,10 +1053,10 @@ func remoteStateNeedsInit(remoteState *remote.RemoteState, terragruntOptions *op
 
 // planAll prints the plans from all configuration in a stack, in the order
 // specified in the terraform_remote_state dependencies
-func planAll(terragruntOptions *options.TerragruntOptions) error {
+func planAll(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
 	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	terragruntOptions.Logger.Printf("%s", stack.String())
@@ -510,52 +1065,77 @@ func planAll(terragruntOptions *options.TerragruntOptions) error {
 
 // Spin up an entire "stack" by running 'terragrunt apply' in each subfolder, processing them in the right order based
 // on terraform_remote_state dependencies.
-func applyAll(terragruntOptions *options.TerragruntOptions) error {
+func applyAll(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
 	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	terragruntOptions.Logger.Printf("%s", stack.String())
-	shouldApplyAll, err := shell.PromptUserForYesNo("Are you sure you want to run 'terragrunt apply' in each folder of the stack described above?", terragruntOptions)
-	if err != nil {
-		return err
+	shouldApplyAll := terragruntOptions.AutoApprove
+	if !shouldApplyAll {
+		shouldApplyAll, err = shell.PromptUserForYesNo("Are you sure you want to run 'terragrunt apply' in each folder of the stack described above?", terragruntOptions)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if shouldApplyAll {
 		return stack.Apply(terragruntOptions)
 	}
 
-	return nil
+	return nil, nil
 }
 
 // Tear down an entire "stack" by running 'terragrunt destroy' in each subfolder, processing them in the right order
 // based on terraform_remote_state dependencies.
-func destroyAll(terragruntOptions *options.TerragruntOptions) error {
+func destroyAll(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
 	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	terragruntOptions.Logger.Printf("%s", stack.String())
-	shouldDestroyAll, err := shell.PromptUserForYesNo("WARNING: Are you sure you want to run `terragrunt destroy` in each folder of the stack described above? There is no undo!", terragruntOptions)
+
+	// The modules the filters skip (e.g. --terragrunt-git-diff) aren't destroyed, so any of them can be dependents
+	modulePaths := []string{}
+	for _, module := range stack.Modules {
+		if !module.AssumeAlreadyApplied {
+			modulePaths = append(modulePaths, module.Path)
+		}
+	}
+	dependents, err := findDependentsInRepo(modulePaths, terragruntOptions)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if len(dependents) > 0 {
+		logDependentsWarning(dependents, terragruntOptions)
+	}
+
+	shouldDestroyAll, err := shell.PromptUserForApproval("WARNING: Are you sure you want to run `terragrunt destroy` in each folder of the stack described above? There is no undo!", terragruntOptions)
+	if err != nil {
+		return nil, err
 	}
 
 	if shouldDestroyAll {
 		return stack.Destroy(terragruntOptions)
 	}
 
-	return nil
+	// When running non-interactively (e.g. in CI), exit with an error, as otherwise it would look like the destroy
+	// succeeded
+	if terragruntOptions.NonInteractive || terragruntOptions.AssumeNo {
+		return nil, errors.WithStackTrace(DestroyAllNotApproved(terragruntOptions.WorkingDir))
+	}
+
+	return nil, nil
 }
 
 // outputAll prints the outputs from all configuration in a stack, in the order
 // specified in the terraform_remote_state dependencies
-func outputAll(terragruntOptions *options.TerragruntOptions) error {
+func outputAll(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
 	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	terragruntOptions.Logger.Printf("%s", stack.String())
@@ -563,16 +1143,35 @@ func outputAll(terragruntOptions *options.TerragruntOptions) error {
 }
 
 // validateAll validates runs terraform validate on all the modules
-func validateAll(terragruntOptions *options.TerragruntOptions) error {
+func validateAll(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
 	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	terragruntOptions.Logger.Printf("%s", stack.String())
 	return stack.Validate(terragruntOptions)
 }
 
+// stateAll runs the given 'terraform state' subcommand (e.g. list) on all the modules in a stack
+func stateAll(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
+	subcommand := firstArg(terragruntOptions.TerraformCliArgs)
+	if !util.ListContainsElement(STATE_ALL_SUBCOMMANDS, subcommand) {
+		return nil, errors.WithStackTrace(UnsupportedStateAllSubcommand(subcommand))
+	}
+
+	// The stack adds the subcommand itself, so pass on only the remaining args
+	terragruntOptions.TerraformCliArgs = terragruntOptions.TerraformCliArgs[1:]
+
+	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	terragruntOptions.Logger.Printf("%s", stack.String())
+	return stack.StateList(terragruntOptions)
+}
+
 // Custom error types
 
 type UnrecognizedCommand string
@@ -581,6 +1180,18 @@ func (commandName UnrecognizedCommand) Error() string {
 	return fmt.Sprintf("Unrecognized command: %s", string(commandName))
 }
 
+type TargetNotSupported string
+
+func (command TargetNotSupported) Error() string {
+	return fmt.Sprintf("The --%s option only works with the %s commands, not with %s", OPT_TERRAGRUNT_TARGET, strings.Join(COMMANDS_THAT_SUPPORT_TARGETS, ", "), string(command))
+}
+
+type UnsupportedStateAllSubcommand string
+
+func (subcommand UnsupportedStateAllSubcommand) Error() string {
+	return fmt.Sprintf("Unsupported subcommand for %s: '%s'. Supported subcommands are: %s", CMD_STATE_ALL, string(subcommand), strings.Join(STATE_ALL_SUBCOMMANDS, ", "))
+}
+
 type ArgumentNotAllowed struct {
 	Argument string
 	Message  string
@@ -596,6 +1207,12 @@ func (err InitNeededButDisabled) Error() string {
 	return string(err)
 }
 
+type DestroyAllNotApproved string
+
+func (workingDir DestroyAllNotApproved) Error() string {
+	return fmt.Sprintf("Refusing to run destroy-all in %s: the --%s flag is set, but destructive operations also require the --%s flag.", string(workingDir), OPT_NON_INTERACTIVE, OPT_TERRAGRUNT_AUTO_APPROVE)
+}
+
 type BackendNotDefined struct {
 	Opts        *options.TerragruntOptions
 	BackendType string
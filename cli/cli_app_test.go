@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAutomationArgs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args             []string
+		nonInteractive   bool
+		autoApprove      bool
+		terraformVersion string
+		expected         []string
+	}{
+		{[]string{"plan"}, false, false, "", []string{"plan"}},
+		{[]string{"plan"}, true, false, "", []string{"plan", "-input=false"}},
+		{[]string{"plan", "-input=true"}, true, false, "", []string{"plan", "-input=true"}},
+		{[]string{"init"}, true, true, "", []string{"init", "-input=false"}},
+		{[]string{"output"}, true, true, "", []string{"output"}},
+		{[]string{"apply"}, true, false, "", []string{"apply"}},
+		{[]string{"apply"}, false, true, "", []string{"apply", "-auto-approve"}},
+		{[]string{"apply", "-auto-approve=false"}, false, true, "", []string{"apply", "-auto-approve=false"}},
+		{[]string{"destroy"}, true, false, "", []string{"destroy"}},
+		{[]string{"destroy"}, true, true, "v0.11.7", []string{"destroy", "-auto-approve"}},
+		{[]string{"destroy"}, true, true, "v0.10.8", []string{"destroy", "-force"}},
+		{[]string{"destroy", "-force"}, false, true, "v0.11.7", []string{"destroy", "-force"}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+		terragruntOptions.TerraformCliArgs = testCase.args
+		terragruntOptions.NonInteractive = testCase.nonInteractive
+		terragruntOptions.AutoApprove = testCase.autoApprove
+		if testCase.terraformVersion != "" {
+			terragruntOptions.TerraformVersion, err = version.NewVersion(testCase.terraformVersion)
+			assert.Nil(t, err, "Unexpected error parsing version %s: %v", testCase.terraformVersion, err)
+		}
+
+		err = addAutomationArgs(terragruntOptions)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, testCase.expected, terragruntOptions.TerraformCliArgs, "For test case %v", testCase)
+	}
+}
+
+func TestAddAutomationArgsNoColor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args     []string
+		noColor  bool
+		expected []string
+	}{
+		{[]string{"plan"}, false, []string{"plan"}},
+		{[]string{"plan"}, true, []string{"plan", "-no-color"}},
+		{[]string{"plan", "-no-color"}, true, []string{"plan", "-no-color"}},
+		{[]string{"output", "vpc_id"}, true, []string{"output", "-no-color", "vpc_id"}},
+		{[]string{"fmt"}, true, []string{"fmt"}},
+		{[]string{"version"}, true, []string{"version"}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+		terragruntOptions.TerraformCliArgs = testCase.args
+		terragruntOptions.NonInteractive = false
+		terragruntOptions.NoColor = testCase.noColor
+
+		err = addAutomationArgs(terragruntOptions)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, testCase.expected, terragruntOptions.TerraformCliArgs, "For test case %v", testCase)
+	}
+}
+
+func TestMakeStateFileArgAbsolute(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"state", "push", "backup.tfstate"}, []string{"state", "push", "/working/dir/backup.tfstate"}},
+		{[]string{"state", "push", "-force", "../backup.tfstate"}, []string{"state", "push", "-force", "/working/backup.tfstate"}},
+		{[]string{"state", "push", "/backups/backup.tfstate"}, []string{"state", "push", "/backups/backup.tfstate"}},
+		{[]string{"state", "push", "-"}, []string{"state", "push", "-"}},
+		{[]string{"state", "push"}, []string{"state", "push"}},
+		{[]string{"state", "list", "foo"}, []string{"state", "list", "foo"}},
+		{[]string{"apply", "foo"}, []string{"apply", "foo"}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+		terragruntOptions.TerraformCliArgs = testCase.args
+		terragruntOptions.WorkingDir = "/working/dir"
+
+		err = makeStateFileArgAbsolute(terragruntOptions)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, testCase.expected, terragruntOptions.TerraformCliArgs, "For args %v", testCase.args)
+	}
+}
+
+func TestSetEnvVarsFromConfig(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	terragruntOptions.Env = map[string]string{"FOO": "original", "BAR": "original"}
+
+	terragruntConfig := &config.TerragruntConfig{Terraform: &config.TerraformConfig{EnvVars: map[string]string{"FOO": "from-config", "BAZ": "from-config"}}}
+	setEnvVarsFromConfig(terragruntOptions, terragruntConfig)
+
+	assert.Equal(t, map[string]string{"FOO": "from-config", "BAR": "original", "BAZ": "from-config"}, terragruntOptions.Env)
+
+	// A config without a terraform block should leave the env untouched
+	setEnvVarsFromConfig(terragruntOptions, &config.TerragruntConfig{})
+	assert.Equal(t, map[string]string{"FOO": "from-config", "BAR": "original", "BAZ": "from-config"}, terragruntOptions.Env)
+}
+
+func TestErrorLogWriter(t *testing.T) {
+	t.Parallel()
+
+	appErrWriter := &bytes.Buffer{}
+	app := CreateTerragruntCli("", &bytes.Buffer{}, appErrWriter)
+
+	_, err := io.WriteString(ErrorLogWriter(app), "error before parsing the options")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "error before parsing the options", appErrWriter.String())
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("cli_app_test")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	optionsErrWriter := &bytes.Buffer{}
+	terragruntOptions.ErrWriter = optionsErrWriter
+	terragruntOptions.Redactor = util.NewRedactor(nil)
+	terragruntOptions.Redactor.AddSecretsFromEnv(map[string]string{"AWS_SECRET_ACCESS_KEY": "wJalrXUtnFEMI"})
+	app.Metadata[TERRAGRUNT_OPTIONS_METADATA_KEY] = terragruntOptions
+
+	_, err = io.WriteString(ErrorLogWriter(app), "error with key wJalrXUtnFEMI")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "error with key "+util.REDACTED_VALUE, optionsErrWriter.String())
+}
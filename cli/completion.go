@@ -0,0 +1,348 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const CMD_COMPLETION = "completion"
+
+// The shells the completion command can generate a completion script for
+var COMPLETION_SHELLS = []string{"bash", "zsh", "fish"}
+
+// The Terraform commands, which Terragrunt forwards to Terraform as they are, so the completion scripts offer them too
+var TERRAFORM_COMMANDS = []string{
+	"apply",
+	"console",
+	"destroy",
+	"env",
+	"fmt",
+	"force-unlock",
+	"get",
+	"graph",
+	"import",
+	"init",
+	"output",
+	"plan",
+	"providers",
+	"push",
+	"refresh",
+	"show",
+	"state",
+	"taint",
+	"untaint",
+	"validate",
+	"version",
+	"workspace",
+}
+
+// The commands that take a subcommand, with the subcommands the completion scripts offer for them
+var COMPLETION_SUBCOMMANDS = map[string][]string{
+	CMD_STATE_ALL:  STATE_ALL_SUBCOMMANDS,
+	CMD_COMPLETION: COMPLETION_SHELLS,
+}
+
+// The description of the Terraform commands in the completion scripts
+const TERRAFORM_COMMAND_DESCRIPTION = "Forwarded to Terraform"
+
+// A line of a section of CUSTOM_USAGE_TEXT, such as "   plan-all             Display the plans of a 'stack' ...",
+// where the name may be followed by a subcommand, and the description by tabs or at least two spaces
+var USAGE_TEXT_LINE_REGEX = regexp.MustCompile(`^\s+(\S+)(?: \S+)*\s{2,}(\S.*)$`)
+
+// A command or option the completion scripts offer, with the description shells that support them show next to it
+type completionItem struct {
+	Name        string
+	Description string
+}
+
+// Write the completion script for the shell passed after the completion command to the given writer. urfave/cli's own
+// completion only knows about the commands it defines, while most Terragrunt commands are forwarded to Terraform and
+// all the options are parsed by Terragrunt itself, so the scripts are generated from Terragrunt's own lists instead.
+func printCompletionScript(args []string, writer io.Writer) error {
+	shell := firstArg(args)
+
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletionScript(completionCommands(), completionOptions())
+	case "zsh":
+		script = zshCompletionScript(completionCommands(), completionOptions())
+	case "fish":
+		script = fishCompletionScript(completionCommands(), completionOptions())
+	default:
+		return errors.WithStackTrace(UnsupportedCompletionShell(shell))
+	}
+
+	_, err := fmt.Fprint(writer, script)
+	return errors.WithStackTrace(err)
+}
+
+// Return the Terragrunt commands, as listed in the help text, followed by the Terraform commands
+func completionCommands() []completionItem {
+	commands := []completionItem{}
+	names := []string{}
+
+	for _, command := range parseUsageTextSection("COMMANDS") {
+		// The help text lists * for all the other commands, which are forwarded to Terraform
+		if command.Name == "*" {
+			continue
+		}
+		commands = append(commands, command)
+		names = append(names, command.Name)
+	}
+
+	for _, name := range TERRAFORM_COMMANDS {
+		if !util.ListContainsElement(names, name) {
+			commands = append(commands, completionItem{Name: name, Description: TERRAFORM_COMMAND_DESCRIPTION})
+		}
+	}
+
+	return commands
+}
+
+// Return the --terragrunt-xxx options, in alphabetical order, with their descriptions from the help text
+func completionOptions() []completionItem {
+	descriptions := map[string]string{}
+	for _, option := range parseUsageTextSection("GLOBAL OPTIONS") {
+		descriptions[option.Name] = option.Description
+	}
+
+	names := append(append([]string{}, ALL_TERRAGRUNT_BOOLEAN_OPTS...), ALL_TERRAGRUNT_STRING_OPTS...)
+	sort.Strings(names)
+
+	options := []completionItem{}
+	for _, name := range names {
+		options = append(options, completionItem{Name: "--" + name, Description: descriptions[name]})
+	}
+	return options
+}
+
+// Return the names and descriptions in the section of CUSTOM_USAGE_TEXT with the given title
+func parseUsageTextSection(title string) []completionItem {
+	items := []completionItem{}
+	inSection := false
+
+	for _, line := range strings.Split(CUSTOM_USAGE_TEXT, "\n") {
+		if line == title+":" {
+			inSection = true
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		if matches := USAGE_TEXT_LINE_REGEX.FindStringSubmatch(line); matches != nil {
+			items = append(items, completionItem{Name: matches[1], Description: matches[2]})
+		}
+	}
+
+	return items
+}
+
+// Return the names of the given commands or options
+func completionItemNames(items []completionItem) []string {
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+// Return the options that take a value, which the completion scripts complete with file names, as most values are paths
+func completionValueOptions() []string {
+	names := []string{}
+	for _, name := range ALL_TERRAGRUNT_STRING_OPTS {
+		names = append(names, "--"+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Return the commands that take a subcommand, in alphabetical order
+func completionSubcommandCommands() []string {
+	commands := []string{}
+	for command := range COMPLETION_SUBCOMMANDS {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+func bashCompletionScript(commands []completionItem, options []completionItem) string {
+	var script bytes.Buffer
+
+	fmt.Fprintf(&script, "# bash completion for terragrunt. To load it, run: source <(terragrunt completion bash)\n\n")
+	fmt.Fprintf(&script, "_terragrunt() {\n")
+	fmt.Fprintf(&script, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(&script, "    local prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(&script, "    local commands=%s\n", quoteForShell(strings.Join(completionItemNames(commands), " ")))
+	fmt.Fprintf(&script, "    local options=%s\n", quoteForShell(strings.Join(completionItemNames(options), " ")))
+	fmt.Fprintf(&script, "    local value_options=%s\n", quoteForShell(" "+strings.Join(completionValueOptions(), " ")+" "))
+	fmt.Fprint(&script, `
+    if [[ "$value_options" == *" $prev "* ]]; then
+        COMPREPLY=($(compgen -f -- "$cur"))
+        return
+    fi
+
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "$options" -- "$cur"))
+        return
+    fi
+
+    local i command=""
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        if [[ "$value_options" == *" ${COMP_WORDS[i]} "* ]]; then
+            ((i++))
+        elif [[ "${COMP_WORDS[i]}" != -* ]]; then
+            command="${COMP_WORDS[i]}"
+            break
+        fi
+    done
+
+    if [[ -z "$command" ]]; then
+        COMPREPLY=($(compgen -W "$commands" -- "$cur"))
+        return
+    fi
+
+    if ((i + 1 == COMP_CWORD)); then
+        case "$command" in
+`)
+	for _, command := range completionSubcommandCommands() {
+		fmt.Fprintf(&script, "            %s) COMPREPLY=($(compgen -W %s -- \"$cur\")); return ;;\n", command, quoteForShell(strings.Join(COMPLETION_SUBCOMMANDS[command], " ")))
+	}
+	fmt.Fprint(&script, `        esac
+    fi
+
+    COMPREPLY=($(compgen -f -- "$cur"))
+}
+
+complete -o filenames -F _terragrunt terragrunt
+`)
+
+	return script.String()
+}
+
+func zshCompletionScript(commands []completionItem, options []completionItem) string {
+	var script bytes.Buffer
+
+	fmt.Fprintf(&script, "#compdef terragrunt\n")
+	fmt.Fprintf(&script, "# zsh completion for terragrunt. To load it, run: source <(terragrunt completion zsh)\n\n")
+	fmt.Fprintf(&script, "_terragrunt() {\n")
+	fmt.Fprintf(&script, "    local -a commands options value_options\n")
+	fmt.Fprintf(&script, "    commands=(\n")
+	for _, command := range commands {
+		fmt.Fprintf(&script, "        %s\n", quoteForShell(command.Name+":"+command.Description))
+	}
+	fmt.Fprintf(&script, "    )\n")
+	fmt.Fprintf(&script, "    options=(\n")
+	for _, option := range options {
+		fmt.Fprintf(&script, "        %s\n", quoteForShell(option.Name+":"+option.Description))
+	}
+	fmt.Fprintf(&script, "    )\n")
+	fmt.Fprintf(&script, "    value_options=(%s)\n", strings.Join(completionValueOptions(), " "))
+	fmt.Fprint(&script, `
+    if (( ${value_options[(Ie)${words[CURRENT-1]}]} )); then
+        _files
+        return
+    fi
+
+    if [[ "${words[CURRENT]}" == -* ]]; then
+        _describe 'option' options
+        return
+    fi
+
+    local i=2 command=""
+    while (( i < CURRENT )); do
+        if (( ${value_options[(Ie)${words[i]}]} )); then
+            (( i += 2 ))
+        elif [[ "${words[i]}" != -* ]]; then
+            command="${words[i]}"
+            break
+        else
+            (( i++ ))
+        fi
+    done
+
+    if [[ -z "$command" ]]; then
+        _describe 'command' commands
+        return
+    fi
+
+    if (( i + 1 == CURRENT )); then
+        case "$command" in
+`)
+	for _, command := range completionSubcommandCommands() {
+		fmt.Fprintf(&script, "            %s) compadd %s; return ;;\n", command, strings.Join(COMPLETION_SUBCOMMANDS[command], " "))
+	}
+	fmt.Fprint(&script, `        esac
+    fi
+
+    _files
+}
+
+if [[ "$funcstack[1]" == "_terragrunt" ]]; then
+    _terragrunt "$@"
+else
+    compdef _terragrunt terragrunt
+fi
+`)
+
+	return script.String()
+}
+
+func fishCompletionScript(commands []completionItem, options []completionItem) string {
+	var script bytes.Buffer
+
+	fmt.Fprintf(&script, "# fish completion for terragrunt. To load it, run: terragrunt completion fish | source\n\n")
+	fmt.Fprintf(&script, "function __terragrunt_needs_command\n")
+	fmt.Fprintf(&script, "    set -l value_options %s\n", strings.Join(completionValueOptions(), " "))
+	fmt.Fprint(&script, `    set -l words (commandline -opc)
+    set -e words[1]
+    set -l skip_value 0
+    for word in $words
+        if test $skip_value -eq 1
+            set skip_value 0
+        else if contains -- $word $value_options
+            set skip_value 1
+        else if not string match -q -- '-*' $word
+            return 1
+        end
+    end
+    return 0
+end
+
+`)
+
+	for _, command := range commands {
+		fmt.Fprintf(&script, "complete -c terragrunt -f -n __terragrunt_needs_command -a %s -d %s\n", quoteForShell(command.Name), quoteForShell(command.Description))
+	}
+	for _, command := range completionSubcommandCommands() {
+		fmt.Fprintf(&script, "complete -c terragrunt -f -n %s -a %s\n", quoteForShell("__fish_seen_subcommand_from "+command), quoteForShell(strings.Join(COMPLETION_SUBCOMMANDS[command], " ")))
+	}
+	for _, option := range options {
+		requiresValue := ""
+		if util.ListContainsElement(completionValueOptions(), option.Name) {
+			requiresValue = " -r"
+		}
+		fmt.Fprintf(&script, "complete -c terragrunt -l %s%s -d %s\n", strings.TrimPrefix(option.Name, "--"), requiresValue, quoteForShell(option.Description))
+	}
+
+	return script.String()
+}
+
+// Custom error types
+
+type UnsupportedCompletionShell string
+
+func (shell UnsupportedCompletionShell) Error() string {
+	return fmt.Sprintf("Unsupported shell '%s' for the %s command. Supported shells: %s.", string(shell), CMD_COMPLETION, strings.Join(COMPLETION_SHELLS, ", "))
+}
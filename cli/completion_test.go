@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletionCommands(t *testing.T) {
+	t.Parallel()
+
+	commands := completionCommands()
+	names := completionItemNames(commands)
+
+	for _, name := range []string{CMD_PLAN_ALL, CMD_STATE_ALL, CMD_CLEAN, CMD_OUTPUT_FROM, CMD_COMPLETION, "plan", "workspace"} {
+		assert.Contains(t, names, name)
+	}
+	assert.NotContains(t, names, "*")
+
+	for _, command := range commands {
+		assert.NotEmpty(t, command.Description, "Expected a description for command %s", command.Name)
+	}
+}
+
+func TestCompletionOptions(t *testing.T) {
+	t.Parallel()
+
+	options := completionOptions()
+	assert.Equal(t, len(ALL_TERRAGRUNT_BOOLEAN_OPTS)+len(ALL_TERRAGRUNT_STRING_OPTS), len(options))
+
+	// The descriptions come from the help text, so this also checks every option is in the help text
+	for _, option := range options {
+		assert.NotEmpty(t, option.Description, "Expected a description for option %s", option.Name)
+	}
+}
+
+func TestParseUsageTextSection(t *testing.T) {
+	t.Parallel()
+
+	for _, command := range parseUsageTextSection("COMMANDS") {
+		if command.Name == CMD_STATE_ALL {
+			assert.Equal(t, "List the resources in the state of each module of a 'stack' by running 'terragrunt state list' in each subfolder", command.Description)
+			return
+		}
+	}
+	t.Fatalf("Expected to find the %s command", CMD_STATE_ALL)
+}
+
+func TestPrintCompletionScript(t *testing.T) {
+	t.Parallel()
+
+	for _, shell := range COMPLETION_SHELLS {
+		var out bytes.Buffer
+		err := printCompletionScript([]string{shell}, &out)
+		if assert.Nil(t, err, "Unexpected error for shell %s: %v", shell, err) {
+			assert.Contains(t, out.String(), "terragrunt-tfpath", "For shell %s", shell)
+			assert.Contains(t, out.String(), CMD_APPLY_ALL, "For shell %s", shell)
+		}
+	}
+}
+
+func TestPrintCompletionScriptUnsupportedShell(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{{}, {"powershell"}} {
+		var out bytes.Buffer
+		err := printCompletionScript(args, &out)
+		_, isUnsupportedShellErr := errors.Unwrap(err).(UnsupportedCompletionShell)
+		assert.True(t, isUnsupportedShellErr, "Expected an UnsupportedCompletionShell error for args %v, but got %v", args, err)
+		assert.Empty(t, out.String())
+	}
+}
+
+func TestFishCompletionScript(t *testing.T) {
+	t.Parallel()
+
+	commands := []completionItem{{Name: "plan-all", Description: "Don't panic"}}
+	options := []completionItem{{Name: "--" + OPT_TERRAGRUNT_NO_PTY, Description: "No pty"}, {Name: "--" + OPT_TERRAGRUNT_TFPATH, Description: "Path"}}
+	script := fishCompletionScript(commands, options)
+
+	assert.Contains(t, script, `complete -c terragrunt -f -n __terragrunt_needs_command -a 'plan-all' -d 'Don'\''t panic'`)
+	assert.Contains(t, script, "complete -c terragrunt -l terragrunt-no-pty -d 'No pty'\n")
+	assert.Contains(t, script, "complete -c terragrunt -l terragrunt-tfpath -r -d 'Path'\n")
+	assert.Contains(t, script, "complete -c terragrunt -f -n '__fish_seen_subcommand_from state-all' -a 'list'\n")
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The environment variables we use to hand off the plan to the cost estimation command
+const ENV_VAR_PLAN_JSON = "TERRAGRUNT_PLAN_JSON"
+const ENV_VAR_MODULE_PATH = "TERRAGRUNT_MODULE_PATH"
+
+// Run the cost estimation command configured in the cost_estimation block of the Terragrunt config against the JSON
+// plan at the given path. The command's output must be a JSON object, which we log and write to a file in the working
+// dir so the xxx-all commands can include it in their summary.
+func runCostEstimation(planJsonPath string, terragruntOptions *options.TerragruntOptions, costEstimationConfig *config.CostEstimationConfig) error {
+	modulePath := filepath.Dir(terragruntOptions.TerragruntConfigPath)
+	terragruntOptions.Logger.Printf("Estimating cost of plan in %s using %s", terragruntOptions.WorkingDir, costEstimationConfig.Command)
+
+	costOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	costOptions.WorkingDir = terragruntOptions.WorkingDir
+	costOptions.Env[ENV_VAR_PLAN_JSON] = planJsonPath
+	costOptions.Env[ENV_VAR_MODULE_PATH] = modulePath
+
+	output, err := shell.RunShellCommandAndCaptureOutput(costOptions, costEstimationConfig.Command, costEstimationConfig.Arguments...)
+	if err != nil {
+		return err
+	}
+
+	estimate, err := configstack.ParseCostEstimate(modulePath, output)
+	if err != nil {
+		return err
+	}
+
+	if err := configstack.WriteCostEstimate(output, terragruntOptions.WorkingDir); err != nil {
+		return err
+	}
+
+	terragruntOptions.Logger.Printf("Cost estimate for %s", estimate.String())
+	return nil
+}
+
+// Remove the cost estimate written by a previous run, if any, so we never report a stale estimate for a module whose
+// plan fails
+func removeCostEstimateFile(terragruntOptions *options.TerragruntOptions) error {
+	path := util.JoinPath(terragruntOptions.WorkingDir, configstack.COST_ESTIMATE_FILE)
+	if !util.FileExists(path) {
+		return nil
+	}
+
+	return errors.WithStackTrace(os.Remove(path))
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/urfave/cli"
+)
+
+// The name of the crash report file Terragrunt writes to the temp folder when it panics. The * is replaced with a
+// random string, so the reports of several crashes don't overwrite each other.
+const CRASH_REPORT_FILE_PATTERN = "terragrunt-crash-*.txt"
+
+// Where to report a crash
+const CRASH_REPORT_ISSUES_URL = "https://github.com/gruntwork-io/terragrunt/issues"
+
+// The settings of a run that help to reproduce a crash, as written to a crash report. The environment variables and
+// -var args that look like secrets are redacted, as for the debug bundle, and the other options are left out.
+type crashReportOptions struct {
+	TerragruntConfigPath string            `json:"terragrunt_config_path"`
+	WorkingDir           string            `json:"working_dir"`
+	DownloadDir          string            `json:"download_dir"`
+	Source               string            `json:"source"`
+	TerraformPath        string            `json:"terraform_path"`
+	TerraformVersion     string            `json:"terraform_version"`
+	TerraformCliArgs     []string          `json:"terraform_cli_args"`
+	IamRoles             []string          `json:"iam_roles"`
+	NonInteractive       bool              `json:"non_interactive"`
+	AutoInit             bool              `json:"auto_init"`
+	ParseCache           bool              `json:"parse_cache"`
+	Features             map[string]string `json:"features"`
+	Env                  map[string]string `json:"env"`
+}
+
+// If the given error is, or wraps, a panic that errors.Recover caught, write a crash report and log where it is, so
+// the user can attach it to a bug report. The options are nil if Terragrunt panicked before it parsed them.
+func reportCrashIfPanicked(err error, cliContext *cli.Context, terragruntOptions *options.TerragruntOptions) {
+	var panicErr errors.PanicError
+	if !errors.As(err, &panicErr) {
+		return
+	}
+
+	logger := util.CreateLoggerWithWriter(cliContext.App.ErrWriter, "")
+	if terragruntOptions != nil {
+		logger = terragruntOptions.ErrorLogger()
+	}
+
+	reportPath, writeErr := writeCrashReport(panicErr, cliContext.App.Version, cliContext.Args(), terragruntOptions, time.Now())
+	if writeErr != nil {
+		logger.Printf("Terragrunt crashed, and could not write a crash report: %v. The stack trace of the crash is:\n%s", writeErr, panicErr.Stack)
+		return
+	}
+	logger.Printf("Terragrunt crashed. This is a bug in Terragrunt. Please report it at %s, and attach the crash report in %s, after checking it for anything you'd rather not share.", CRASH_REPORT_ISSUES_URL, reportPath)
+}
+
+// Write a crash report for the given panic to a new file in the temp folder, and return its path
+func writeCrashReport(panicErr errors.PanicError, version string, args []string, terragruntOptions *options.TerragruntOptions, crashTime time.Time) (string, error) {
+	report, err := crashReport(panicErr, version, args, terragruntOptions, crashTime)
+	if err != nil {
+		return "", err
+	}
+
+	reportFile, err := ioutil.TempFile("", CRASH_REPORT_FILE_PATTERN)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	defer reportFile.Close()
+
+	if _, err := reportFile.WriteString(report); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return reportFile.Name(), nil
+}
+
+// Return the contents of a crash report for the given panic: the versions of Terragrunt and Go, the platform, the
+// command, the settings of the run, and the stack trace of the panic
+func crashReport(panicErr errors.PanicError, version string, args []string, terragruntOptions *options.TerragruntOptions, crashTime time.Time) (string, error) {
+	var report bytes.Buffer
+
+	if version == "" {
+		// The version is only set in release builds
+		version = "unknown"
+	}
+
+	fmt.Fprintf(&report, "Terragrunt crash report\n\n")
+	fmt.Fprintf(&report, "Time:       %s\n", crashTime.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&report, "Terragrunt: %s\n", version)
+	fmt.Fprintf(&report, "Go:         %s\n", runtime.Version())
+	fmt.Fprintf(&report, "Platform:   %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&report, "Command:    %v\n\n", util.RedactVarArgs(args))
+	fmt.Fprintf(&report, "Panic: %v\n\n", panicErr.Value)
+	fmt.Fprintf(&report, "Stack trace:\n%s\n", panicErr.Stack)
+
+	if terragruntOptions != nil {
+		encoded, err := json.MarshalIndent(sanitizedOptionsForCrashReport(terragruntOptions), "", "  ")
+		if err != nil {
+			return "", errors.WithStackTrace(err)
+		}
+		fmt.Fprintf(&report, "Options:\n%s\n", encoded)
+	}
+
+	return report.String(), nil
+}
+
+func sanitizedOptionsForCrashReport(terragruntOptions *options.TerragruntOptions) crashReportOptions {
+	sanitized := crashReportOptions{
+		TerragruntConfigPath: terragruntOptions.TerragruntConfigPath,
+		WorkingDir:           terragruntOptions.WorkingDir,
+		DownloadDir:          terragruntOptions.DownloadDir,
+		Source:               terragruntOptions.Source,
+		TerraformPath:        terragruntOptions.TerraformPath,
+		TerraformCliArgs:     util.RedactVarArgs(terragruntOptions.TerraformCliArgs),
+		IamRoles:             terragruntOptions.IamRoles,
+		NonInteractive:       terragruntOptions.NonInteractive,
+		AutoInit:             terragruntOptions.AutoInit,
+		ParseCache:           terragruntOptions.ParseCache,
+		Features:             terragruntOptions.Features,
+		Env:                  redactEnvVars(terragruntOptions.Env),
+	}
+	if terragruntOptions.TerraformVersion != nil {
+		sanitized.TerraformVersion = terragruntOptions.TerraformVersion.String()
+	}
+	return sanitized
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrashReport(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("/infrastructure-live/prod/vpc/terraform.tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.TerraformCliArgs = []string{"plan", "-var", "db_password=hunter2"}
+	terragruntOptions.Env = map[string]string{"AWS_REGION": "us-east-1", "AWS_SECRET_ACCESS_KEY": "abc123"}
+
+	panicErr := errors.PanicError{Value: "runtime error: index out of range", Stack: "goroutine 1 [running]:\nmain.main()"}
+	crashTime := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	report, err := crashReport(panicErr, "v0.18.0", []string{"plan", "-var", "db_password=hunter2"}, terragruntOptions, crashTime)
+	assert.Nil(t, err)
+
+	assert.Contains(t, report, "Time:       2019-03-01T12:00:00Z\n")
+	assert.Contains(t, report, "Terragrunt: v0.18.0\n")
+	assert.Contains(t, report, "Panic: runtime error: index out of range\n")
+	assert.Contains(t, report, "Stack trace:\ngoroutine 1 [running]:\nmain.main()\n")
+	assert.Contains(t, report, `"terragrunt_config_path": "/infrastructure-live/prod/vpc/terraform.tfvars"`)
+	assert.Contains(t, report, `"AWS_REGION": "us-east-1"`)
+	assert.Contains(t, report, fmt.Sprintf(`"AWS_SECRET_ACCESS_KEY": "%s"`, DEBUG_REDACTED_VALUE))
+	assert.Contains(t, report, "db_password="+DEBUG_REDACTED_VALUE)
+	assert.NotContains(t, report, "hunter2")
+	assert.NotContains(t, report, "abc123")
+}
+
+func TestCrashReportWithoutOptions(t *testing.T) {
+	t.Parallel()
+
+	report, err := crashReport(errors.PanicError{Value: "boom"}, "", []string{"plan"}, nil, time.Now())
+	assert.Nil(t, err)
+	assert.Contains(t, report, "Terragrunt: unknown\n")
+	assert.Contains(t, report, "Panic: boom\n")
+	assert.NotContains(t, report, "Options:")
+}
+
+func TestWriteCrashReport(t *testing.T) {
+	t.Parallel()
+
+	var cause error
+	func() {
+		defer errors.Recover(func(err error) { cause = err })
+		var modules map[string]string
+		modules["vpc"] = "boom"
+	}()
+
+	var panicErr errors.PanicError
+	if !assert.True(t, errors.As(cause, &panicErr)) {
+		return
+	}
+
+	reportPath, err := writeCrashReport(panicErr, "v0.18.0", []string{"plan"}, nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(reportPath)
+
+	contents, err := ioutil.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(contents), "Panic: assignment to entry in nil map\n")
+	assert.Contains(t, string(contents), "crash_report_test.go")
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The folder in a module that --terragrunt-debug writes the debug bundle to
+const DEBUG_BUNDLE_FOLDER = ".terragrunt-debug"
+
+// The files in the debug bundle: the details of the Terraform command as JSON, a script that runs the command without
+// Terragrunt, and a folder with copies of the var files the command uses
+const DEBUG_BUNDLE_INFO_FILE = "debug.json"
+const DEBUG_BUNDLE_SCRIPT_FILE = "terraform.sh"
+const DEBUG_BUNDLE_VAR_FILES_FOLDER = "var-files"
+
+// What the values of environment variables and -var args that look like secrets are replaced with in the debug bundle
+const DEBUG_REDACTED_VALUE = "REDACTED"
+
+// The names of environment variables a POSIX shell can set
+var SHELL_VAR_NAME_REGEX = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// The details of a Terraform command Terragrunt ran, as written to DEBUG_BUNDLE_INFO_FILE
+type debugBundle struct {
+	ModulePath        string            `json:"module_path"`
+	ConfigPath        string            `json:"config_path"`
+	WorkingDir        string            `json:"working_dir"`
+	Command           string            `json:"command"`
+	Args              []string          `json:"args"`
+	Env               map[string]string `json:"env"`
+	VarFiles          map[string]string `json:"var_files"`
+	TerraformVersion  string            `json:"terraform_version"`
+	TerragruntVersion string            `json:"terragrunt_version"`
+}
+
+// Write a debug bundle for the Terraform command in the given options to DEBUG_BUNDLE_FOLDER in the module, so the
+// command can be reproduced without Terragrunt, e.g. to tell whether a bug is in Terragrunt or in Terraform. The
+// bundle has the command, its working dir and environment, with the values that look like secrets redacted, copies of
+// the var files it uses, and a script that runs it. The script is also logged, so it can be copied from the output.
+func writeDebugBundle(terragruntOptions *options.TerragruntOptions) error {
+	modulePath := filepath.ToSlash(filepath.Dir(terragruntOptions.TerragruntConfigPath))
+	bundlePath := util.JoinPath(modulePath, DEBUG_BUNDLE_FOLDER)
+
+	// Start from scratch, so the bundle never has var files from an earlier command
+	if err := os.RemoveAll(bundlePath); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if err := os.MkdirAll(util.JoinPath(bundlePath, DEBUG_BUNDLE_VAR_FILES_FOLDER), 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	runOptions := withoutTfCliArgsEnvVars(terragruntOptions)
+	command, args := shell.TerraformCommandWithWrapper(runOptions, runOptions.TerraformCliArgs)
+
+	bundle := debugBundle{
+		ModulePath:        modulePath,
+		ConfigPath:        terragruntOptions.TerragruntConfigPath,
+		WorkingDir:        terragruntOptions.WorkingDir,
+		Command:           command,
+		Args:              util.RedactVarArgs(args),
+		Env:               redactEnvVars(runOptions.Env),
+		VarFiles:          map[string]string{},
+		TerragruntVersion: terragruntOptions.TerragruntVersion,
+	}
+	if terragruntOptions.TerraformVersion != nil {
+		bundle.TerraformVersion = terragruntOptions.TerraformVersion.String()
+	}
+
+	varFiles, err := findVarFiles(terragruntOptions)
+	if err != nil {
+		return err
+	}
+	for index, varFile := range varFiles {
+		copyPath := util.JoinPath(bundlePath, DEBUG_BUNDLE_VAR_FILES_FOLDER, fmt.Sprintf("%d-%s", index, filepath.Base(varFile)))
+		if err := util.CopyFile(varFile, copyPath); err != nil {
+			return err
+		}
+		bundle.VarFiles[varFile] = copyPath
+	}
+
+	encoded, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if err := ioutil.WriteFile(util.JoinPath(bundlePath, DEBUG_BUNDLE_INFO_FILE), encoded, 0600); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	script := debugScript(bundle, runOptions.Env, parseEnvironmentVariables(os.Environ()))
+	scriptPath := util.JoinPath(bundlePath, DEBUG_BUNDLE_SCRIPT_FILE)
+	if err := ioutil.WriteFile(scriptPath, []byte(script), 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	terragruntOptions.Logger.Printf("Wrote a debug bundle to %s. To run the Terraform command without Terragrunt, run %s, or run these commands in a shell:\n%s", bundlePath, scriptPath, script)
+	return nil
+}
+
+// Return the paths of the var files the Terraform command in the given options uses: the ones Terraform loads from the
+// working dir automatically, and the ones in -var-file args, relative to the working dir
+func findVarFiles(terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	varFiles := []string{}
+
+	for _, glob := range AUTO_LOADED_VAR_FILE_GLOBS {
+		paths, err := filepath.Glob(util.JoinPath(terragruntOptions.WorkingDir, glob))
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		varFiles = append(varFiles, paths...)
+	}
+
+	args := terragruntOptions.TerraformCliArgs
+	for i, arg := range args {
+		path := ""
+		if arg == "-var-file" && i+1 < len(args) {
+			path = args[i+1]
+		} else if strings.HasPrefix(arg, "-var-file=") {
+			path = strings.TrimPrefix(arg, "-var-file=")
+		}
+		if path == "" {
+			continue
+		}
+
+		if !filepath.IsAbs(path) {
+			path = util.JoinPath(terragruntOptions.WorkingDir, path)
+		}
+		// Terraform will fail on a var file that doesn't exist, which is worth reproducing, so don't fail here
+		if util.FileExists(path) {
+			varFiles = append(varFiles, filepath.ToSlash(path))
+		}
+	}
+
+	return util.RemoveDuplicatesFromList(varFiles), nil
+}
+
+// Return a shell script that runs the Terraform command in the given bundle, with the given environment, without
+// Terragrunt, from a shell with the given current environment. It only sets the environment variables Terragrunt
+// changed, as the shell already has the others, and leaves the ones that look like secrets for the user to set.
+func debugScript(bundle debugBundle, runEnv map[string]string, currentEnv map[string]string) string {
+	var script bytes.Buffer
+
+	fmt.Fprintf(&script, "#!/bin/sh\n")
+	fmt.Fprintf(&script, "# Runs the Terraform command Terragrunt ran for the module in %s, without Terragrunt.\n", bundle.ModulePath)
+	fmt.Fprintf(&script, "# Replace the values that are %s with the real ones before running it.\n", DEBUG_REDACTED_VALUE)
+	fmt.Fprintf(&script, "cd %s\n", quoteForShell(bundle.WorkingDir))
+
+	for _, name := range sortedEnvVarNames(currentEnv) {
+		if _, isSet := runEnv[name]; !isSet && SHELL_VAR_NAME_REGEX.MatchString(name) {
+			fmt.Fprintf(&script, "unset %s\n", name)
+		}
+	}
+	for _, name := range sortedEnvVarNames(runEnv) {
+		value := runEnv[name]
+		currentValue, isSet := currentEnv[name]
+		// A shell can't set some variables, such as the =C: variables of cmd.exe
+		if (isSet && value == currentValue) || !SHELL_VAR_NAME_REGEX.MatchString(name) {
+			continue
+		}
+
+		if util.IsSensitiveName(name) {
+			fmt.Fprintf(&script, "# export %s=%s\n", name, DEBUG_REDACTED_VALUE)
+		} else {
+			fmt.Fprintf(&script, "export %s=%s\n", name, quoteForShell(value))
+		}
+	}
+
+	parts := []string{quoteForShell(bundle.Command)}
+	for _, arg := range bundle.Args {
+		parts = append(parts, quoteForShell(arg))
+	}
+	fmt.Fprintf(&script, "%s\n", strings.Join(parts, " "))
+
+	return script.String()
+}
+
+// Return a copy of the given environment variables with the values of the ones that look like secrets redacted
+func redactEnvVars(env map[string]string) map[string]string {
+	redacted := map[string]string{}
+	for name, value := range env {
+		if util.IsSensitiveName(name) {
+			value = DEBUG_REDACTED_VALUE
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+func sortedEnvVarNames(env map[string]string) []string {
+	names := []string{}
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Quote the given string with single quotes, so a POSIX shell passes it on as is
+func quoteForShell(str string) string {
+	return "'" + strings.Replace(str, "'", `'\''`, -1) + "'"
+}
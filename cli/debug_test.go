@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDebugBundle(t *testing.T) {
+	t.Parallel()
+
+	modulePath := tmpDir(t)
+	defer os.RemoveAll(modulePath)
+
+	writeSourceHashTestFile(t, modulePath, config.DefaultTerragruntConfigPath, "terragrunt = {}")
+	writeSourceHashTestFile(t, modulePath, "common.tfvars", `region = "us-east-1"`)
+	writeSourceHashTestFile(t, modulePath, "prod.auto.tfvars", `name = "app"`)
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(modulePath, config.DefaultTerragruntConfigPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.WorkingDir = util.JoinPath(modulePath)
+	terragruntOptions.TerraformCliArgs = []string{"plan", "-var-file=common.tfvars", "-var", "db_password=hunter2", "-input=false"}
+	terragruntOptions.Env = map[string]string{"AWS_REGION": "us-east-1", "AWS_SECRET_ACCESS_KEY": "abc123", "TF_CLI_ARGS_plan": "-lock=false"}
+
+	assert.Nil(t, writeDebugBundle(terragruntOptions))
+
+	bundlePath := util.JoinPath(modulePath, DEBUG_BUNDLE_FOLDER)
+	contents, err := ioutil.ReadFile(util.JoinPath(bundlePath, DEBUG_BUNDLE_INFO_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bundle debugBundle
+	assert.Nil(t, json.Unmarshal(contents, &bundle))
+	assert.Equal(t, "terraform", bundle.Command)
+	assert.Equal(t, []string{"plan", "-var-file=common.tfvars", "-var", "db_password=REDACTED", "-input=false"}, bundle.Args)
+	assert.Equal(t, map[string]string{"AWS_REGION": "us-east-1", "AWS_SECRET_ACCESS_KEY": "REDACTED"}, bundle.Env)
+	assert.Equal(t, 3, len(bundle.VarFiles))
+
+	// The var files, including the Terragrunt config, which Terraform loads as terraform.tfvars, are copied as they are
+	for original, copyPath := range bundle.VarFiles {
+		originalContents, err := ioutil.ReadFile(original)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		copyContents, err := ioutil.ReadFile(copyPath)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, string(originalContents), string(copyContents))
+	}
+
+	assert.True(t, util.FileExists(util.JoinPath(bundlePath, DEBUG_BUNDLE_SCRIPT_FILE)))
+}
+
+func TestDebugScript(t *testing.T) {
+	t.Parallel()
+
+	bundle := debugBundle{
+		ModulePath: "/live/app",
+		WorkingDir: "/tmp/terragrunt/abc/app",
+		Command:    "terraform",
+		Args:       []string{"plan", "-var", "name=it's me"},
+	}
+	runEnv := map[string]string{"HOME": "/home/me", "AWS_REGION": "us-east-1", "AWS_SESSION_TOKEN": "abc123", "GITHUB_TOKEN": "def456"}
+	currentEnv := map[string]string{"HOME": "/home/me", "AWS_REGION": "eu-west-1", "GITHUB_TOKEN": "def456", "TF_CLI_ARGS": "-no-color"}
+
+	expected := `#!/bin/sh
+# Runs the Terraform command Terragrunt ran for the module in /live/app, without Terragrunt.
+# Replace the values that are REDACTED with the real ones before running it.
+cd '/tmp/terragrunt/abc/app'
+unset TF_CLI_ARGS
+export AWS_REGION='us-east-1'
+# export AWS_SESSION_TOKEN=REDACTED
+'terraform' 'plan' '-var' 'name=it'\''s me'
+`
+	assert.Equal(t, expected, debugScript(bundle, runEnv, currentEnv))
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Run destroy in a single module, but if other modules in the repo depend on it, list them and ask for approval first,
+// as destroying a module that others use, such as a VPC, breaks them too
+func destroy(terragruntOptions *options.TerragruntOptions) error {
+	modulePath := filepath.Dir(terragruntOptions.TerragruntConfigPath)
+
+	dependents, err := findDependentsInRepo([]string{modulePath}, terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	if len(dependents) > 0 {
+		logDependentsWarning(dependents, terragruntOptions)
+		shouldDestroy, err := shell.PromptUserForApproval(fmt.Sprintf("WARNING: Are you sure you want to destroy %s, even though the modules above depend on it?", modulePath), terragruntOptions)
+		if err != nil {
+			return err
+		}
+		if !shouldDestroy {
+			// When running non-interactively (e.g. in CI), exit with an error, as otherwise it would look like the
+			// destroy succeeded
+			if terragruntOptions.NonInteractive || terragruntOptions.AssumeNo {
+				return errors.WithStackTrace(DestroyWithDependentsNotApproved{ModulePath: modulePath, Dependents: dependents})
+			}
+			return nil
+		}
+	}
+
+	return runTerragrunt(terragruntOptions)
+}
+
+// Return the modules in the repo of the working dir that depend on any of the given modules, and are not one of them.
+// The repo is the Git repo the working dir is in. Outside of a Git repo, there's no telling where the modules that could
+// depend on the given ones are, so we log that we can't check and return no modules.
+func findDependentsInRepo(modulePaths []string, terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	repoRoot, err := getGitRepoRoot(terragruntOptions)
+	if err != nil {
+		terragruntOptions.Logger.Printf("%s is not in a Git repo, so not checking whether other modules depend on the modules being destroyed", terragruntOptions.WorkingDir)
+		return nil, nil
+	}
+
+	terragruntOptions.Logger.Printf("Checking whether any of the modules in %s depend on the modules being destroyed", repoRoot)
+	return configstack.FindDependentModules(modulePaths, repoRoot, terragruntOptions)
+}
+
+// Return the root folder of the Git repo the working dir of the given options is in
+func getGitRepoRoot(terragruntOptions *options.TerragruntOptions) (string, error) {
+	gitOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	gitOptions.WorkingDir = terragruntOptions.WorkingDir
+
+	out, err := shell.RunShellCommandAndCaptureOutput(gitOptions, "git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return util.CanonicalPath(strings.TrimSpace(out), ".")
+}
+
+// Log a warning that lists the given modules, which depend on the modules being destroyed
+func logDependentsWarning(dependents []string, terragruntOptions *options.TerragruntOptions) {
+	lines := []string{}
+	for _, dependent := range dependents {
+		lines = append(lines, fmt.Sprintf("  => %s", dependent))
+	}
+	terragruntOptions.Logger.Printf("WARNING: These modules depend on the modules being destroyed, and will break if they're destroyed:\n%s", strings.Join(lines, "\n"))
+}
+
+// Custom error types
+
+type DestroyWithDependentsNotApproved struct {
+	ModulePath string
+	Dependents []string
+}
+
+func (err DestroyWithDependentsNotApproved) Error() string {
+	return fmt.Sprintf("Refusing to destroy %s, as %s depend on it: the --%s flag is set, but destroying a module that other modules depend on also requires the --%s flag.", err.ModulePath, strings.Join(err.Dependents, ", "), OPT_NON_INTERACTIVE, OPT_TERRAGRUNT_AUTO_APPROVE)
+}
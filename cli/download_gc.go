@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Download folders used more recently than this are never deleted, no matter what the limits are, as another
+// Terragrunt process (e.g. one run by apply-all or by a parallel CI job) may be using them right now
+const DOWNLOAD_GC_MIN_AGE = 1 * time.Hour
+
+// A folder in DownloadDir that Terragrunt downloaded Terraform source code into
+type downloadFolder struct {
+	Path     string
+	LastUsed time.Time
+	Size     int64
+}
+
+// Sort download folders from most to least recently used
+type downloadFoldersByLastUsed []downloadFolder
+
+func (folders downloadFoldersByLastUsed) Len() int {
+	return len(folders)
+}
+
+func (folders downloadFoldersByLastUsed) Swap(i, j int) {
+	folders[i], folders[j] = folders[j], folders[i]
+}
+
+func (folders downloadFoldersByLastUsed) Less(i, j int) bool {
+	return folders[i].LastUsed.After(folders[j].LastUsed)
+}
+
+// Returns true if the user set any limits on the folders in DownloadDir
+func hasDownloadLimits(terragruntOptions *options.TerragruntOptions) bool {
+	return terragruntOptions.DownloadMaxAge > 0 || terragruntOptions.DownloadMaxSize > 0 || terragruntOptions.DownloadMaxEntries > 0
+}
+
+// If the user set any limits on the folders in DownloadDir, delete the folders that exceed them. Cleaning up is not
+// essential for the command the user is running, so any errors are logged rather than returned.
+func collectDownloadGarbageIfNecessary(terragruntOptions *options.TerragruntOptions) {
+	if !hasDownloadLimits(terragruntOptions) {
+		return
+	}
+
+	if _, err := collectDownloadGarbage(terragruntOptions, time.Now(), false); err != nil {
+		terragruntOptions.Logger.Printf("WARNING: failed to clean up old Terraform source code in %s: %v", terragruntOptions.DownloadDir, err)
+	}
+}
+
+// Delete the folders in DownloadDir that exceed the download limits in the given options, and return their paths. If
+// dryRun is true, return the paths without deleting anything. Each folder in DownloadDir contains the code downloaded
+// from one source URL for one working dir (see processTerraformSource), including its .terraform folder with the
+// downloaded providers and modules. We delete the least recently used folders first.
+func collectDownloadGarbage(terragruntOptions *options.TerragruntOptions, now time.Time, dryRun bool) ([]string, error) {
+	folders, err := findDownloadFolders(terragruntOptions.DownloadDir, terragruntOptions.DownloadMaxSize > 0)
+	if err != nil {
+		return nil, err
+	}
+
+	foldersToDelete := selectDownloadFoldersToDelete(folders, terragruntOptions, now)
+	deletedPaths := []string{}
+
+	for _, folder := range foldersToDelete {
+		if dryRun {
+			terragruntOptions.Logger.Printf("Would delete %s, which was last used %s", folder.Path, folder.LastUsed.Format(time.RFC3339))
+			deletedPaths = append(deletedPaths, folder.Path)
+			continue
+		}
+
+		terragruntOptions.Logger.Printf("Deleting %s, which was last used %s", folder.Path, folder.LastUsed.Format(time.RFC3339))
+		if err := os.RemoveAll(folder.Path); err != nil {
+			return deletedPaths, errors.WithStackTrace(err)
+		}
+		deletedPaths = append(deletedPaths, folder.Path)
+
+		// Also delete the parent folder for the working dir once it has no more downloads in it
+		if err := removeFolderIfEmpty(filepath.Dir(folder.Path)); err != nil {
+			return deletedPaths, err
+		}
+	}
+
+	return deletedPaths, nil
+}
+
+// Return the folders in the given download dir that exceed the limits in the given options, from least to most
+// recently used. We go through the folders from most to least recently used, keeping each one until keeping it would
+// exceed a limit. Folders used within DOWNLOAD_GC_MIN_AGE are always kept, but still count towards the limits.
+func selectDownloadFoldersToDelete(folders []downloadFolder, terragruntOptions *options.TerragruntOptions, now time.Time) []downloadFolder {
+	sortedFolders := make([]downloadFolder, len(folders))
+	copy(sortedFolders, folders)
+	sort.Sort(downloadFoldersByLastUsed(sortedFolders))
+
+	keptCount := 0
+	keptSize := int64(0)
+	foldersToDelete := []downloadFolder{}
+
+	for _, folder := range sortedFolders {
+		age := now.Sub(folder.LastUsed)
+
+		exceedsLimits := (terragruntOptions.DownloadMaxAge > 0 && age > terragruntOptions.DownloadMaxAge) ||
+			(terragruntOptions.DownloadMaxEntries > 0 && keptCount+1 > terragruntOptions.DownloadMaxEntries) ||
+			(terragruntOptions.DownloadMaxSize > 0 && keptSize+folder.Size > terragruntOptions.DownloadMaxSize)
+
+		if exceedsLimits && age > DOWNLOAD_GC_MIN_AGE {
+			foldersToDelete = append([]downloadFolder{folder}, foldersToDelete...)
+		} else {
+			keptCount++
+			keptSize += folder.Size
+		}
+	}
+
+	return foldersToDelete
+}
+
+// Return all the folders Terragrunt downloaded source code into in the given download dir. These are the folders two
+// levels down: one level for the working dir and one for the source URL. We only calculate the size of each folder if
+// calculateSize is true, as that requires reading every file in it.
+func findDownloadFolders(downloadDir string, calculateSize bool) ([]downloadFolder, error) {
+	folders := []downloadFolder{}
+
+	if !util.FileExists(downloadDir) {
+		return folders, nil
+	}
+
+	workingDirFolders, err := ioutil.ReadDir(downloadDir)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	for _, workingDirFolder := range workingDirFolders {
+		if !workingDirFolder.IsDir() {
+			continue
+		}
+
+		sourceFolders, err := ioutil.ReadDir(filepath.Join(downloadDir, workingDirFolder.Name()))
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+
+		for _, sourceFolder := range sourceFolders {
+			if !sourceFolder.IsDir() {
+				continue
+			}
+
+			folder := downloadFolder{
+				Path:     util.JoinPath(downloadDir, workingDirFolder.Name(), sourceFolder.Name()),
+				LastUsed: sourceFolder.ModTime(),
+			}
+
+			if calculateSize {
+				size, err := getFolderSize(folder.Path)
+				if err != nil {
+					return nil, err
+				}
+				folder.Size = size
+			}
+
+			folders = append(folders, folder)
+		}
+	}
+
+	return folders, nil
+}
+
+// Return the total size, in bytes, of all the files in the given folder and its subfolders
+func getFolderSize(folder string) (int64, error) {
+	size := int64(0)
+	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, errors.WithStackTrace(err)
+}
+
+// Record that the given download folder was just used, so it's not deleted by collectDownloadGarbage. We use the
+// modification time of the folder itself for this, so we don't have to add any files to the downloaded code.
+func markDownloadFolderUsed(terraformSource *TerraformSource, terragruntOptions *options.TerragruntOptions) {
+	now := time.Now()
+	if err := os.Chtimes(terraformSource.DownloadDir, now, now); err != nil {
+		terragruntOptions.Logger.Printf("WARNING: failed to update the modification time of %s: %v", terraformSource.DownloadDir, err)
+	}
+}
+
+// Delete the given folder if it has nothing in it
+func removeFolderIfEmpty(folder string) error {
+	files, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if len(files) > 0 {
+		return nil
+	}
+	return errors.WithStackTrace(os.Remove(folder))
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectDownloadFoldersToDelete(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	folders := []downloadFolder{
+		{Path: "/downloads/a/old", LastUsed: now.Add(-30 * 24 * time.Hour), Size: 100},
+		{Path: "/downloads/a/recent", LastUsed: now.Add(-5 * time.Minute), Size: 100},
+		{Path: "/downloads/b/day", LastUsed: now.Add(-24 * time.Hour), Size: 100},
+		{Path: "/downloads/c/week", LastUsed: now.Add(-7 * 24 * time.Hour), Size: 100},
+	}
+
+	testCases := []struct {
+		maxAge     time.Duration
+		maxSize    int64
+		maxEntries int
+		expected   []string
+	}{
+		{0, 0, 0, []string{}},
+		{48 * time.Hour, 0, 0, []string{"/downloads/a/old", "/downloads/c/week"}},
+		{0, 0, 2, []string{"/downloads/a/old", "/downloads/c/week"}},
+		{0, 0, 3, []string{"/downloads/a/old"}},
+		{0, 250, 0, []string{"/downloads/a/old", "/downloads/c/week"}},
+		{10 * 24 * time.Hour, 0, 3, []string{"/downloads/a/old"}},
+		// Folders used within DOWNLOAD_GC_MIN_AGE are never deleted, even if they exceed the limits
+		{time.Minute, 0, 0, []string{"/downloads/a/old", "/downloads/c/week", "/downloads/b/day"}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.DownloadMaxAge = testCase.maxAge
+		terragruntOptions.DownloadMaxSize = testCase.maxSize
+		terragruntOptions.DownloadMaxEntries = testCase.maxEntries
+
+		actual := []string{}
+		for _, folder := range selectDownloadFoldersToDelete(folders, terragruntOptions, now) {
+			actual = append(actual, folder.Path)
+		}
+
+		assert.Equal(t, testCase.expected, actual, "For max age %v, max size %d, max entries %d", testCase.maxAge, testCase.maxSize, testCase.maxEntries)
+	}
+}
+
+func TestCollectDownloadGarbage(t *testing.T) {
+	t.Parallel()
+
+	downloadDir := tmpDir(t)
+	defer os.RemoveAll(downloadDir)
+
+	now := time.Now()
+	oldFolder := createDownloadGcTestFolder(t, downloadDir, "working-dir-1/source", now.Add(-48*time.Hour))
+	newFolder := createDownloadGcTestFolder(t, downloadDir, "working-dir-2/source", now.Add(-2*time.Hour))
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.DownloadDir = downloadDir
+	terragruntOptions.DownloadMaxAge = 24 * time.Hour
+
+	deletedPaths, err := collectDownloadGarbage(terragruntOptions, now, true)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, []string{oldFolder}, deletedPaths)
+	assert.True(t, util.FileExists(oldFolder), "Expected a dry run not to delete %s", oldFolder)
+
+	deletedPaths, err = collectDownloadGarbage(terragruntOptions, now, false)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, []string{oldFolder}, deletedPaths)
+	assert.False(t, util.FileExists(oldFolder), "Expected %s to be deleted", oldFolder)
+	assert.False(t, util.FileExists(util.JoinPath(downloadDir, "working-dir-1")), "Expected the empty working dir folder to be deleted")
+	assert.True(t, util.FileExists(newFolder), "Expected %s not to be deleted", newFolder)
+}
+
+func TestCollectDownloadGarbageMissingDownloadDir(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.DownloadDir = "/this/folder/does/not/exist"
+	terragruntOptions.DownloadMaxEntries = 1
+
+	deletedPaths, err := collectDownloadGarbage(terragruntOptions, time.Now(), false)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Empty(t, deletedPaths)
+}
+
+// Create a download folder with a file in it at the given path in the given download dir, and set its last used time
+func createDownloadGcTestFolder(t *testing.T, downloadDir string, path string, lastUsed time.Time) string {
+	writeSourceHashTestFile(t, downloadDir, util.JoinPath(path, "main.tf"), "# main")
+
+	folder := util.JoinPath(downloadDir, path)
+	if err := os.Chtimes(folder, lastUsed, lastUsed); err != nil {
+		t.Fatal(err)
+	}
+	return folder
+}
@@ -31,10 +31,14 @@ type TerraformSource struct {
 
 	// The path to a file in DownloadDir that stores the version number of the code
 	VersionFile string
+
+	// The expected hash of the code in DownloadDir, as set via source_hash in the Terragrunt configuration. If this is
+	// empty, the downloaded code is not verified.
+	ExpectedHash string
 }
 
 func (src *TerraformSource) String() string {
-	return fmt.Sprintf("TerraformSource{CanonicalSourceURL = %v, DownloadDir = %v, WorkingDir = %v, VersionFile = %v}", src.CanonicalSourceURL, src.DownloadDir, src.WorkingDir, src.VersionFile)
+	return fmt.Sprintf("TerraformSource{CanonicalSourceURL = %v, DownloadDir = %v, WorkingDir = %v, VersionFile = %v, ExpectedHash = %v}", src.CanonicalSourceURL, src.DownloadDir, src.WorkingDir, src.VersionFile, src.ExpectedHash)
 }
 
 var forcedRegexp = regexp.MustCompile(`^([A-Za-z0-9]+)::(.+)$`)
@@ -51,10 +55,20 @@ func downloadTerraformSource(source string, terragruntOptions *options.Terragrun
 		return err
 	}
 
+	if terragruntConfig.Terraform != nil && terragruntConfig.Terraform.SourceHash != "" {
+		if terragruntOptions.Source != "" {
+			terragruntOptions.Logger.Printf("The --%s option overrides the source in the Terragrunt configuration, so source_hash will not be verified", OPT_TERRAGRUNT_SOURCE)
+		} else {
+			terraformSource.ExpectedHash = terragruntConfig.Terraform.SourceHash
+		}
+	}
+
 	if err := downloadTerraformSourceIfNecessary(terraformSource, terragruntOptions, terragruntConfig); err != nil {
 		return err
 	}
 
+	markDownloadFolderUsed(terraformSource, terragruntOptions)
+
 	terragruntOptions.Logger.Printf("Copying files from %s into %s", terragruntOptions.WorkingDir, terraformSource.WorkingDir)
 	if err := util.CopyFolderContents(terragruntOptions.WorkingDir, terraformSource.WorkingDir); err != nil {
 		return err
@@ -68,6 +82,15 @@ func downloadTerraformSource(source string, terragruntOptions *options.Terragrun
 
 // Download the specified TerraformSource if the latest code hasn't already been downloaded.
 func downloadTerraformSourceIfNecessary(terraformSource *TerraformSource, terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	isRemoteSource := !isLocalSource(terraformSource.CanonicalSourceURL)
+
+	// Check before deleting anything, so an offline run never throws away the only copy of the code it has
+	if terragruntOptions.SourceUpdate && isRemoteSource {
+		if err := terragruntOptions.CheckNetworkAccess(fmt.Sprintf("download the Terraform source at %s again (--%s is set)", terraformSource.CanonicalSourceURL, OPT_TERRAGRUNT_SOURCE_UPDATE)); err != nil {
+			return err
+		}
+	}
+
 	if terragruntOptions.SourceUpdate {
 		terragruntOptions.Logger.Printf("The --%s flag is set, so deleting the temporary folder %s before downloading source.", OPT_TERRAGRUNT_SOURCE_UPDATE, terraformSource.DownloadDir)
 		if err := os.RemoveAll(terraformSource.DownloadDir); err != nil {
@@ -85,11 +108,32 @@ func downloadTerraformSourceIfNecessary(terraformSource *TerraformSource, terrag
 		return nil
 	}
 
-	if err := cleanupTerraformFiles(terraformSource.DownloadDir, terragruntOptions); err != nil {
+	if isRemoteSource {
+		if err := terragruntOptions.CheckNetworkAccess(fmt.Sprintf("download the Terraform source at %s, which isn't in the download dir %s yet", terraformSource.CanonicalSourceURL, terraformSource.DownloadDir)); err != nil {
+			return err
+		}
+	}
+
+	if terraformSource.ExpectedHash != "" {
+		// Any files left over from a previous download (e.g. the files copied from the working dir) would change the
+		// hash of the downloaded code, so start from an empty folder
+		terragruntOptions.Logger.Printf("Deleting the temporary folder %s before downloading source, so the downloaded code can be verified against source_hash", terraformSource.DownloadDir)
+		if err := os.RemoveAll(terraformSource.DownloadDir); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	} else if err := cleanupTerraformFiles(terraformSource.DownloadDir, terragruntOptions); err != nil {
 		return err
 	}
 
-	if err := terraformInit(terraformSource, terragruntOptions, terragruntConfig); err != nil {
+	if shouldCloneGitSource(terraformSource, terragruntOptions) {
+		if err := cloneGitSource(terraformSource, terragruntOptions); err != nil {
+			return err
+		}
+	} else if err := terraformInit(terraformSource, terragruntOptions, terragruntConfig); err != nil {
+		return err
+	}
+
+	if err := verifySourceHash(terraformSource, terragruntOptions); err != nil {
 		return err
 	}
 
@@ -123,7 +167,7 @@ func alreadyHaveLatestCode(terraformSource *TerraformSource, terragruntOptions *
 		return false, nil
 	}
 
-	currentVersion := encodeSourceVersion(terraformSource.CanonicalSourceURL)
+	currentVersion := getSourceVersion(terraformSource)
 	previousVersion, err := readVersionFile(terraformSource)
 
 	if err != nil {
@@ -135,15 +179,15 @@ func alreadyHaveLatestCode(terraformSource *TerraformSource, terragruntOptions *
 
 // Return the version number stored in the DownloadDir. This version number can be used to check if the Terraform code
 // that has already been downloaded is the same as the version the user is currently requesting. The version number is
-// calculated using the encodeSourceVersion method.
+// calculated using the getSourceVersion method.
 func readVersionFile(terraformSource *TerraformSource) (string, error) {
 	return util.ReadFileAsString(terraformSource.VersionFile)
 }
 
 // Write a file into the DownloadDir that contains the version number of this source code. The version number is
-// calculated using the encodeSourceVersion method.
+// calculated using the getSourceVersion method.
 func writeVersionFile(terraformSource *TerraformSource) error {
-	version := encodeSourceVersion(terraformSource.CanonicalSourceURL)
+	version := getSourceVersion(terraformSource)
 	return errors.WithStackTrace(ioutil.WriteFile(terraformSource.VersionFile, []byte(version), 0640))
 }
 
@@ -174,7 +218,23 @@ func writeVersionFile(terraformSource *TerraformSource) error {
 // 1. Always download source URLs pointing to local file paths.
 // 2. Only download source URLs pointing to remote paths if /T/W/H doesn't already exist or, if it does exist, if the
 //    version number in /T/W/H/.terragrunt-source-version doesn't match the current version.
+//
+// If the source URL points to a module in a Terraform Registry (e.g. tfr:///terraform-aws-modules/vpc/aws?version=~>2.0),
+// we first resolve it to the URL the registry says that module's code is stored at and use that as s. See the
+// resolveRegistrySource method for details.
 func processTerraformSource(source string, terragruntOptions *options.TerragruntOptions) (*TerraformSource, error) {
+	if isRegistrySource(source) {
+		httpClient, err := terragruntOptions.HttpClient(0)
+		if err != nil {
+			return nil, err
+		}
+		resolvedSource, err := resolveRegistrySource(source, httpClient, terragruntOptions)
+		if err != nil {
+			return nil, err
+		}
+		source = resolvedSource
+	}
+
 	canonicalWorkingDir, err := util.CanonicalPath(terragruntOptions.WorkingDir, "")
 	if err != nil {
 		return nil, err
@@ -284,6 +344,17 @@ func splitSourceUrl(sourceUrl *url.URL, terragruntOptions *options.TerragruntOpt
 	}
 }
 
+// Return the version number to store in the version file of the given source. This is the version number of its
+// source URL, as calculated by the encodeSourceVersion method, unless the source has an expected hash, in which case
+// the hash is part of the version too. That way, changing the source_hash in the Terragrunt configuration causes the
+// code to be downloaded, and therefore verified, again.
+func getSourceVersion(terraformSource *TerraformSource) string {
+	if terraformSource.ExpectedHash == "" {
+		return encodeSourceVersion(terraformSource.CanonicalSourceURL)
+	}
+	return util.EncodeBase64Sha1(terraformSource.CanonicalSourceURL.Query().Encode() + terraformSource.ExpectedHash)
+}
+
 // Encode a version number for the given source URL. When calculating a version number, we simply take the query
 // string of the source URL, calculate its sha1, and base 64 encode it. For remote URLs (e.g. Git URLs), this is
 // based on the assumption that the scheme/host/path of the URL (e.g. git::github.com/foo/bar) identifies the module
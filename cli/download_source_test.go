@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
 	"github.com/gruntwork-io/terragrunt/util"
 	"github.com/stretchr/testify/assert"
@@ -158,6 +159,54 @@ func TestDownloadTerraformSourceIfNecessaryRemoteUrlOverrideSource(t *testing.T)
 	testDownloadTerraformSourceIfNecessary(t, canonicalUrl, downloadDir, true, "# Hello, World")
 }
 
+func TestDownloadTerraformSourceIfNecessaryOffline(t *testing.T) {
+	t.Parallel()
+
+	canonicalUrl := "github.com/gruntwork-io/terragrunt//test/fixture-download-source/hello-world?ref=v0.9.7"
+
+	testCases := []struct {
+		fixture      string
+		sourceUpdate bool
+		expectedErr  bool
+	}{
+		{"", false, true},
+		{"../test/fixture-download-source/hello-world-2", false, true},
+		{"../test/fixture-download-source/hello-world-version-remote", false, false},
+		{"../test/fixture-download-source/hello-world-version-remote", true, true},
+	}
+
+	for _, testCase := range testCases {
+		downloadDir := tmpDir(t)
+		defer os.RemoveAll(downloadDir)
+		if testCase.fixture != "" {
+			copyFolder(t, testCase.fixture, downloadDir)
+		}
+
+		terraformSource := &TerraformSource{
+			CanonicalSourceURL: parseUrl(t, canonicalUrl),
+			DownloadDir:        downloadDir,
+			WorkingDir:         downloadDir,
+			VersionFile:        util.JoinPath(downloadDir, "version-file.txt"),
+		}
+
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("./should-not-be-used")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.Offline = true
+		terragruntOptions.SourceUpdate = testCase.sourceUpdate
+
+		err = downloadTerraformSourceIfNecessary(terraformSource, terragruntOptions, &config.TerragruntConfig{})
+		if testCase.expectedErr {
+			_, isOfflineErr := errors.Unwrap(err).(options.NetworkAccessInOfflineMode)
+			assert.True(t, isOfflineErr, "Expected a NetworkAccessInOfflineMode error for fixture %s but got: %v", testCase.fixture, err)
+			// Nothing cached may be deleted
+			assert.Equal(t, testCase.fixture != "", util.FileExists(util.JoinPath(downloadDir, "main.tf")), "For fixture %s", testCase.fixture)
+		} else {
+			assert.Nil(t, err, "Unexpected error for fixture %s: %v", testCase.fixture, err)
+			assert.Equal(t, "# Hello, World version remote", readFile(t, util.JoinPath(downloadDir, "main.tf")))
+		}
+	}
+}
+
 func testDownloadTerraformSourceIfNecessary(t *testing.T, canonicalUrl string, downloadDir string, sourceUpdate bool, expectedFileContents string) {
 	terraformSource := &TerraformSource{
 		CanonicalSourceURL: parseUrl(t, canonicalUrl),
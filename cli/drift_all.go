@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// The drift-all command runs 'terraform plan -refresh-only', which Terraform supports as of this version
+const DRIFT_ALL_TERRAFORM_VERSION_CONSTRAINT = ">= v0.15.4"
+
+// Check every module in the stack in the subfolders of the working dir for drift with a refresh-only plan. Log the
+// drift report as a table, and write it as JSON to the Writer of the given options, so it can be saved or processed by
+// a scheduled job. The returned error has exit code 2 if any module has drifted, and 1 if any module couldn't be
+// checked.
+func driftAll(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
+	if err := CheckTerraformVersion(DRIFT_ALL_TERRAFORM_VERSION_CONSTRAINT, fmt.Sprintf("the %s command", CMD_DRIFT_ALL), terragruntOptions); err != nil {
+		return nil, err
+	}
+
+	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	terragruntOptions.Logger.Printf("%s", stack.String())
+	report, results, err := stack.Drift(terragruntOptions)
+	if report == nil {
+		return results, err
+	}
+
+	terragruntOptions.Logger.Printf("Drift report:\n%s", report)
+
+	reportJSON, err := report.JSON()
+	if err != nil {
+		return results, err
+	}
+	fmt.Fprintln(terragruntOptions.Writer, reportJSON)
+
+	return results, report.Err()
+}
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The file in the working dir of a module where Terragrunt records the files it copied there from the extra_files
+// blocks of the config, with the SHA256 hash of each, so it can tell its own copies from files that were there already,
+// or that were changed since, such as a lock file Terraform updated
+const EXTRA_FILES_MANIFEST_FILE = ".terragrunt-extra-files"
+
+// A file of an extra_files block that Terragrunt copies, or copied on an earlier run, into the working dir
+type extraFileCopy struct {
+	sourcePath  string
+	destination string
+	hash        string
+	upToDate    bool
+}
+
+// Copy the files of the extra_files blocks of the given config into the working dir, so Terraform picks them up along
+// with the rest of the code of the module. A file Terragrunt copied on the last run, and that wasn't changed since, is
+// replaced with the new copy, or removed, if the config no longer has it. Any other file of the same name that is
+// different is a conflict, which is handled as the on_conflict parameter of the block says. All the files are checked
+// before any is copied, so a conflict doesn't leave the working dir half done.
+func copyExtraFilesFromConfig(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	manifestPath := util.JoinPath(terragruntOptions.WorkingDir, EXTRA_FILES_MANIFEST_FILE)
+	previousCopies, err := readExtraFilesManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	fileCopies := []extraFileCopy{}
+	blockNames := map[string]string{}
+
+	if terragruntConfig.Terraform != nil {
+		for _, extraFiles := range terragruntConfig.Terraform.ExtraFiles {
+			for _, path := range extraFiles.Paths {
+				sourcePath := path
+				if !filepath.IsAbs(sourcePath) {
+					sourcePath = util.JoinPath(filepath.Dir(terragruntOptions.TerragruntConfigPath), sourcePath)
+				}
+				if !util.FileExists(sourcePath) || util.IsDir(sourcePath) {
+					return errors.WithStackTrace(ExtraFileNotFound{Name: extraFiles.Name, Path: sourcePath})
+				}
+
+				fileName := filepath.Base(sourcePath)
+				if otherName, alreadyCopied := blockNames[fileName]; alreadyCopied {
+					return errors.WithStackTrace(DuplicateExtraFile{FileName: fileName, FirstName: otherName, SecondName: extraFiles.Name})
+				}
+				blockNames[fileName] = extraFiles.Name
+
+				fileCopy, err := planExtraFileCopy(terragruntOptions, extraFiles, sourcePath, previousCopies[fileName])
+				if err != nil {
+					return err
+				}
+				if fileCopy != nil {
+					fileCopies = append(fileCopies, *fileCopy)
+				}
+			}
+		}
+	}
+
+	copies := map[string]string{}
+	for _, fileCopy := range fileCopies {
+		if !fileCopy.upToDate {
+			terragruntOptions.Logger.Printf("Copying %s into %s", fileCopy.sourcePath, terragruntOptions.WorkingDir)
+			if err := util.CopyFile(fileCopy.sourcePath, fileCopy.destination); err != nil {
+				return err
+			}
+		}
+		copies[filepath.Base(fileCopy.destination)] = fileCopy.hash
+	}
+
+	if err := removeStaleExtraFiles(terragruntOptions, previousCopies, copies); err != nil {
+		return err
+	}
+
+	return writeExtraFilesManifest(manifestPath, copies)
+}
+
+// Work out whether to copy the given file of the given extra_files block into the working dir. The given hash is that
+// of the copy from the last run, if any. Returns nil if there is a conflict and the block says to skip the file, or if
+// the working dir already has the same file, and Terragrunt didn't copy it there.
+func planExtraFileCopy(terragruntOptions *options.TerragruntOptions, extraFiles config.TerraformExtraFiles, sourcePath string, previousHash string) (*extraFileCopy, error) {
+	sourceHash, err := computeFileHash(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileCopy := &extraFileCopy{
+		sourcePath:  sourcePath,
+		destination: util.JoinPath(terragruntOptions.WorkingDir, filepath.Base(sourcePath)),
+		hash:        sourceHash,
+	}
+	if !util.FileExists(fileCopy.destination) {
+		return fileCopy, nil
+	}
+
+	existingHash, err := computeFileHash(fileCopy.destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if existingHash == sourceHash {
+		// Nothing to copy. The file is still only ours if we copied it in the first place.
+		if existingHash != previousHash {
+			return nil, nil
+		}
+		fileCopy.upToDate = true
+		return fileCopy, nil
+	}
+
+	if existingHash != previousHash {
+		switch extraFiles.OnConflict {
+		case config.ExtraFilesConflictSkip:
+			terragruntOptions.Logger.Printf("Not copying %s from the extra_files block '%s', as %s already exists and is different", sourcePath, extraFiles.Name, fileCopy.destination)
+			return nil, nil
+		case config.ExtraFilesConflictOverwrite:
+			terragruntOptions.Logger.Printf("Overwriting %s with %s from the extra_files block '%s'", fileCopy.destination, sourcePath, extraFiles.Name)
+		default:
+			return nil, errors.WithStackTrace(ExtraFileConflict{Name: extraFiles.Name, Path: sourcePath, Destination: fileCopy.destination})
+		}
+	}
+
+	return fileCopy, nil
+}
+
+// Remove the files Terragrunt copied into the working dir on the last run that it didn't copy this time, as long as
+// they weren't changed since
+func removeStaleExtraFiles(terragruntOptions *options.TerragruntOptions, previousCopies map[string]string, copies map[string]string) error {
+	for fileName, previousHash := range previousCopies {
+		if _, stillCopied := copies[fileName]; stillCopied {
+			continue
+		}
+
+		path := util.JoinPath(terragruntOptions.WorkingDir, fileName)
+		if !util.FileExists(path) {
+			continue
+		}
+		existingHash, err := computeFileHash(path)
+		if err != nil {
+			return err
+		}
+		if existingHash != previousHash {
+			continue
+		}
+
+		terragruntOptions.Logger.Printf("Removing %s, which was copied from an extra_files block that is no longer in the config", path)
+		if err := os.Remove(path); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+	return nil
+}
+
+// Read the names and hashes of the files Terragrunt copied on the last run from the given manifest, if there is one
+func readExtraFilesManifest(manifestPath string) (map[string]string, error) {
+	copies := map[string]string{}
+	if !util.FileExists(manifestPath) {
+		return copies, nil
+	}
+
+	contents, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if err := json.Unmarshal(contents, &copies); err != nil {
+		return nil, errors.WithStackTrace(InvalidExtraFilesManifest{Path: manifestPath, Underlying: err})
+	}
+	return copies, nil
+}
+
+// Write the names and hashes of the given copies to the given manifest, or remove the manifest if there are none
+func writeExtraFilesManifest(manifestPath string, copies map[string]string) error {
+	if len(copies) == 0 {
+		if util.FileExists(manifestPath) {
+			return errors.WithStackTrace(os.Remove(manifestPath))
+		}
+		return nil
+	}
+
+	contents, err := json.MarshalIndent(copies, "", "  ")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return errors.WithStackTrace(ioutil.WriteFile(manifestPath, contents, 0644))
+}
+
+// Custom error types
+
+type ExtraFileNotFound struct {
+	Name string
+	Path string
+}
+
+func (err ExtraFileNotFound) Error() string {
+	return fmt.Sprintf("The file %s in the extra_files block '%s' does not exist or is a folder", err.Path, err.Name)
+}
+
+type DuplicateExtraFile struct {
+	FileName   string
+	FirstName  string
+	SecondName string
+}
+
+func (err DuplicateExtraFile) Error() string {
+	return fmt.Sprintf("The extra_files blocks '%s' and '%s' both copy a file named %s. Each file name can only be copied once.", err.FirstName, err.SecondName, err.FileName)
+}
+
+type ExtraFileConflict struct {
+	Name        string
+	Path        string
+	Destination string
+}
+
+func (err ExtraFileConflict) Error() string {
+	return fmt.Sprintf("Cannot copy %s from the extra_files block '%s', as %s already exists and is different. Remove it, or set on_conflict in the block to '%s' or '%s'.", err.Path, err.Name, err.Destination, config.ExtraFilesConflictSkip, config.ExtraFilesConflictOverwrite)
+}
+
+type InvalidExtraFilesManifest struct {
+	Path       string
+	Underlying error
+}
+
+func (err InvalidExtraFilesManifest) Error() string {
+	return fmt.Sprintf("Could not parse %s, the record of the files Terragrunt copied from extra_files blocks: %v. Remove it, and any copies you don't want, and run again.", err.Path, err.Underlying)
+}
+
+func (err InvalidExtraFilesManifest) Unwrap() error {
+	return err.Underlying
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyExtraFilesFromConfig(t *testing.T) {
+	t.Parallel()
+
+	configDir, err := ioutil.TempDir("", "extra-files-config")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	defer os.RemoveAll(configDir)
+
+	workingDir, err := ioutil.TempDir("", "extra-files-working-dir")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	defer os.RemoveAll(workingDir)
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(configDir, config.DefaultTerragruntConfigPath))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	terragruntOptions.WorkingDir = workingDir
+
+	assert.Nil(t, ioutil.WriteFile(util.JoinPath(configDir, "versions.tf"), []byte("shared versions"), 0644))
+	assert.Nil(t, ioutil.WriteFile(util.JoinPath(configDir, "variables.tf"), []byte("shared variables"), 0644))
+	assert.Nil(t, ioutil.WriteFile(util.JoinPath(workingDir, "variables.tf"), []byte("module variables"), 0644))
+
+	extraFilesConfig := func(onConflict string, paths ...string) *config.TerragruntConfig {
+		return &config.TerragruntConfig{Terraform: &config.TerraformConfig{ExtraFiles: []config.TerraformExtraFiles{
+			{Name: "shared", Paths: paths, OnConflict: onConflict},
+		}}}
+	}
+
+	// A different file that Terragrunt didn't copy is a conflict
+	err = copyExtraFilesFromConfig(terragruntOptions, extraFilesConfig(config.ExtraFilesConflictError, "versions.tf", "variables.tf"))
+	assert.True(t, errors.IsError(err, ExtraFileConflict{Name: "shared", Path: util.JoinPath(configDir, "variables.tf"), Destination: util.JoinPath(workingDir, "variables.tf")}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+
+	// Skipping it keeps the file of the module
+	err = copyExtraFilesFromConfig(terragruntOptions, extraFilesConfig(config.ExtraFilesConflictSkip, "versions.tf", "variables.tf"))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assertFileContents(t, util.JoinPath(workingDir, "versions.tf"), "shared versions")
+	assertFileContents(t, util.JoinPath(workingDir, "variables.tf"), "module variables")
+	assert.True(t, util.FileExists(util.JoinPath(workingDir, EXTRA_FILES_MANIFEST_FILE)))
+
+	// A copy from the last run is replaced with the new version, without a conflict
+	assert.Nil(t, ioutil.WriteFile(util.JoinPath(configDir, "versions.tf"), []byte("shared versions v2"), 0644))
+	err = copyExtraFilesFromConfig(terragruntOptions, extraFilesConfig(config.ExtraFilesConflictError, "versions.tf"))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assertFileContents(t, util.JoinPath(workingDir, "versions.tf"), "shared versions v2")
+
+	// Overwriting replaces the file of the module
+	err = copyExtraFilesFromConfig(terragruntOptions, extraFilesConfig(config.ExtraFilesConflictOverwrite, "versions.tf", "variables.tf"))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assertFileContents(t, util.JoinPath(workingDir, "variables.tf"), "shared variables")
+
+	// A copy that was changed since, e.g. by Terraform, is ours no longer, so it's kept when it's no longer configured,
+	// while an unchanged copy is removed, and so is the manifest, as there are no copies left
+	assert.Nil(t, ioutil.WriteFile(util.JoinPath(workingDir, "variables.tf"), []byte("changed variables"), 0644))
+	err = copyExtraFilesFromConfig(terragruntOptions, &config.TerragruntConfig{})
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.False(t, util.FileExists(util.JoinPath(workingDir, "versions.tf")))
+	assertFileContents(t, util.JoinPath(workingDir, "variables.tf"), "changed variables")
+	assert.False(t, util.FileExists(util.JoinPath(workingDir, EXTRA_FILES_MANIFEST_FILE)))
+
+	err = copyExtraFilesFromConfig(terragruntOptions, extraFilesConfig(config.ExtraFilesConflictError, "missing.tf"))
+	assert.True(t, errors.IsError(err, ExtraFileNotFound{Name: "shared", Path: util.JoinPath(configDir, "missing.tf")}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+
+	duplicates := &config.TerragruntConfig{Terraform: &config.TerraformConfig{ExtraFiles: []config.TerraformExtraFiles{
+		{Name: "first", Paths: []string{"versions.tf"}, OnConflict: config.ExtraFilesConflictError},
+		{Name: "second", Paths: []string{util.JoinPath(configDir, "versions.tf")}, OnConflict: config.ExtraFilesConflictError},
+	}}}
+	err = copyExtraFilesFromConfig(terragruntOptions, duplicates)
+	assert.True(t, errors.IsError(err, DuplicateExtraFile{FileName: "versions.tf", FirstName: "first", SecondName: "second"}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
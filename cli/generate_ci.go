@@ -0,0 +1,460 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const CMD_GENERATE_CI = "generate-ci"
+
+// Pass this flag, followed by one of the GENERATE_CI_FORMATS, to the generate-ci command to pick the CI system
+const GENERATE_CI_FORMAT_FLAG = "--format"
+
+// Pass this flag, followed by a path, to the generate-ci command to write the config to that file instead of stdout.
+// Relative paths are relative to the working dir.
+const GENERATE_CI_OUT_FLAG = "--out"
+
+// Pass this flag, followed by a Terraform command, such as "apply -auto-approve", to the generate-ci command to run
+// that command in each module instead of GENERATE_CI_DEFAULT_COMMAND. Atlantis runs its own plan and apply, so it's
+// ignored for that format.
+const GENERATE_CI_COMMAND_FLAG = "--command"
+const GENERATE_CI_DEFAULT_COMMAND = "plan"
+
+const GENERATE_CI_FORMAT_ATLANTIS = "atlantis"
+const GENERATE_CI_FORMAT_GITLAB = "gitlab"
+const GENERATE_CI_FORMAT_GITHUB = "github"
+
+var GENERATE_CI_FORMATS = []string{GENERATE_CI_FORMAT_ATLANTIS, GENERATE_CI_FORMAT_GITLAB, GENERATE_CI_FORMAT_GITHUB}
+
+// The files in a module folder that affect its plan, relative to that folder
+var CI_PROJECT_FILE_PATTERNS = []string{"*.tf", "*.tf.json", "*.tfvars"}
+
+// The args that a shell passes on as is, so the commands in the CI config only quote the args that need it
+var SAFE_SHELL_ARG = regexp.MustCompile("^[A-Za-z0-9_./=:@%+-]+$")
+
+// The characters GitHub doesn't allow in the ID of a job
+var GITHUB_JOB_ID_INVALID_CHARS = regexp.MustCompile("[^A-Za-z0-9_-]")
+
+// The arguments of the generate-ci command
+type generateCIArgs struct {
+	Format  string
+	OutPath string
+	Command []string
+}
+
+// A module of the stack, as a project (or job) of the CI config
+type ciProject struct {
+	// The path of the module folder, relative to the working dir, with forward slashes
+	Dir string
+
+	// The Dirs of the modules this module depends on
+	Dependencies []string
+
+	// The number of modules in the longest chain of dependencies of this module, so the modules on the same level can
+	// run in parallel, once all the modules on the levels before it are done
+	Level int
+
+	// The patterns of the files that affect the plan of this module, relative to Dir
+	WhenModified []string
+}
+
+// Write the CI config, in the format passed with GENERATE_CI_FORMAT_FLAG, for the stack of the modules in the subfolders
+// of the working dir, which should be the root of the repo. Each module gets its own project or job, which only runs if
+// the files of the module, its included config, or its local Terraform source change, and which runs after the jobs of
+// the modules it depends on. As the config comes from the same dependency graph as the xxx-all commands, running this
+// command in CI and failing if the result differs from the committed config catches a config that has drifted.
+func generateCI(terragruntOptions *options.TerragruntOptions) error {
+	args, err := parseGenerateCIArgs(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	// Nothing is changed, so there's no need to prompt about external dependencies, which aren't part of the config
+	terragruntOptions.NonInteractive = true
+
+	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	projects, err := getCIProjects(stack.Modules, terragruntOptions.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	switch args.Format {
+	case GENERATE_CI_FORMAT_ATLANTIS:
+		writeAtlantisConfig(projects, &out)
+	case GENERATE_CI_FORMAT_GITLAB:
+		writeGitlabCIConfig(projects, args.Command, &out)
+	case GENERATE_CI_FORMAT_GITHUB:
+		writeGithubActionsConfig(projects, args.Command, &out)
+	}
+
+	if args.OutPath == "" {
+		_, err := terragruntOptions.Writer.Write(out.Bytes())
+		return errors.WithStackTrace(err)
+	}
+
+	terragruntOptions.Logger.Printf("Writing the %s config for %d modules to %s", args.Format, len(projects), args.OutPath)
+	return errors.WithStackTrace(ioutil.WriteFile(args.OutPath, out.Bytes(), os.FileMode(0644)))
+}
+
+// Return the format, the canonical out path, and the command from the args after the generate-ci command
+func parseGenerateCIArgs(terragruntOptions *options.TerragruntOptions) (*generateCIArgs, error) {
+	args := &generateCIArgs{Command: []string{GENERATE_CI_DEFAULT_COMMAND}}
+	values := map[string]string{}
+
+	cliArgs := terragruntOptions.TerraformCliArgs
+	for i := 1; i < len(cliArgs); i++ {
+		arg := cliArgs[i]
+		flag := ""
+		for _, candidate := range []string{GENERATE_CI_FORMAT_FLAG, GENERATE_CI_OUT_FLAG, GENERATE_CI_COMMAND_FLAG} {
+			if arg == candidate || strings.HasPrefix(arg, candidate+"=") {
+				flag = candidate
+			}
+		}
+		if flag == "" {
+			return nil, errors.WithStackTrace(InvalidGenerateCIArgs(cliArgs[1:]))
+		}
+
+		if arg == flag {
+			if i+1 >= len(cliArgs) {
+				return nil, errors.WithStackTrace(MissingGenerateCIFlagValue(flag))
+			}
+			values[flag] = cliArgs[i+1]
+			i++
+		} else {
+			values[flag] = strings.TrimPrefix(arg, flag+"=")
+		}
+	}
+
+	args.Format = values[GENERATE_CI_FORMAT_FLAG]
+	if !util.ListContainsElement(GENERATE_CI_FORMATS, args.Format) {
+		return nil, errors.WithStackTrace(UnsupportedCIFormat(args.Format))
+	}
+
+	if command := strings.Fields(values[GENERATE_CI_COMMAND_FLAG]); len(command) > 0 {
+		args.Command = command
+	}
+
+	if outPath := values[GENERATE_CI_OUT_FLAG]; outPath != "" {
+		canonicalPath, err := util.CanonicalPath(outPath, terragruntOptions.WorkingDir)
+		if err != nil {
+			return nil, err
+		}
+		args.OutPath = canonicalPath
+	}
+
+	return args, nil
+}
+
+// Return a project for each of the given modules in the given working dir, sorted by their folder. External
+// dependencies, which are outside of the working dir, aren't part of the CI config of the working dir, so they're
+// left out, and so are the dependencies on them.
+func getCIProjects(modules []*configstack.TerraformModule, workingDir string) ([]ciProject, error) {
+	dirs := map[string]string{}
+	for _, module := range modules {
+		dir, err := util.GetPathRelativeTo(module.Path, workingDir)
+		if err != nil {
+			return nil, err
+		}
+		if dir != ".." && !strings.HasPrefix(dir, "../") {
+			dirs[module.Path] = dir
+		}
+	}
+
+	levels := map[string]int{}
+	projects := []ciProject{}
+
+	for _, module := range modules {
+		dir, inWorkingDir := dirs[module.Path]
+		if !inWorkingDir {
+			continue
+		}
+
+		project := ciProject{Dir: dir, Dependencies: []string{}, Level: getCIProjectLevel(module, dirs, levels)}
+		for _, dependency := range module.Dependencies {
+			if dependencyDir, inWorkingDir := dirs[dependency.Path]; inWorkingDir {
+				project.Dependencies = append(project.Dependencies, dependencyDir)
+			}
+		}
+		sort.Strings(project.Dependencies)
+
+		whenModified, err := getCIProjectWhenModified(module)
+		if err != nil {
+			return nil, err
+		}
+		project.WhenModified = whenModified
+
+		projects = append(projects, project)
+	}
+
+	sort.Sort(ciProjectsByDir(projects))
+	return projects, nil
+}
+
+// Return the level of the given module: 0 if it doesn't depend on any module in the working dir, or else one more than
+// the highest level of those it depends on. The stack has already been checked for cycles, so this always ends.
+func getCIProjectLevel(module *configstack.TerraformModule, dirs map[string]string, levels map[string]int) int {
+	if level, alreadyFound := levels[module.Path]; alreadyFound {
+		return level
+	}
+
+	level := 0
+	for _, dependency := range module.Dependencies {
+		if _, inWorkingDir := dirs[dependency.Path]; !inWorkingDir {
+			continue
+		}
+		if dependencyLevel := getCIProjectLevel(dependency, dirs, levels) + 1; dependencyLevel > level {
+			level = dependencyLevel
+		}
+	}
+
+	levels[module.Path] = level
+	return level
+}
+
+// Return the patterns, relative to the module folder, of the files that change the plan of the given module: its own
+// Terraform and Terragrunt files, the config file it includes, and its Terraform source, if that's a local folder
+func getCIProjectWhenModified(module *configstack.TerraformModule) ([]string, error) {
+	patterns := append([]string{}, CI_PROJECT_FILE_PATTERNS...)
+
+	if configFile := filepath.Base(module.TerragruntOptions.TerragruntConfigPath); !strings.HasSuffix(configFile, ".tfvars") {
+		patterns = append(patterns, configFile)
+	}
+
+	if module.Config.IncludedConfigPath != "" {
+		includePath, err := util.GetPathRelativeTo(module.Config.IncludedConfigPath, module.Path)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, includePath)
+	}
+
+	if module.Config.Terraform != nil {
+		if sourcePath := localSourceRoot(module.Config.Terraform.Source); sourcePath != "" {
+			if !filepath.IsAbs(sourcePath) {
+				sourcePath = filepath.Join(module.Path, sourcePath)
+			}
+			sourceDir, err := util.GetPathRelativeTo(sourcePath, module.Path)
+			if err != nil {
+				return nil, err
+			}
+			for _, pattern := range CI_PROJECT_FILE_PATTERNS {
+				patterns = append(patterns, fmt.Sprintf("%s/**/%s", sourceDir, pattern))
+			}
+		}
+	}
+
+	return patterns, nil
+}
+
+// Return the folder Terraform downloads the given source from, which is the part before the double-slash, if the
+// source is a local path, or an empty string if it's a remote source
+func localSourceRoot(source string) string {
+	if !util.IsLocalModuleSource(source) && !filepath.IsAbs(source) {
+		return ""
+	}
+	if index := strings.Index(source, "//"); index >= 0 {
+		source = source[:index]
+	}
+	return source
+}
+
+// Write an atlantis.yaml with a project for each module, which uses a workflow that runs Terragrunt instead of
+// Terraform. The projects depend on the projects of the modules they depend on, and are in the execution order group
+// of their level, so Atlantis applies them in dependency order.
+func writeAtlantisConfig(projects []ciProject, out *bytes.Buffer) {
+	writeGeneratedCIConfigHeader(GENERATE_CI_FORMAT_ATLANTIS, out)
+	out.WriteString("version: 3\n")
+	out.WriteString("projects:\n")
+	for _, project := range projects {
+		fmt.Fprintf(out, "- name: %s\n", yamlString(project.Dir))
+		fmt.Fprintf(out, "  dir: %s\n", yamlString(project.Dir))
+		out.WriteString("  workflow: terragrunt\n")
+		fmt.Fprintf(out, "  execution_order_group: %d\n", project.Level)
+		if len(project.Dependencies) > 0 {
+			fmt.Fprintf(out, "  depends_on: %s\n", yamlList(project.Dependencies))
+		}
+		out.WriteString("  autoplan:\n")
+		out.WriteString("    enabled: true\n")
+		fmt.Fprintf(out, "    when_modified: %s\n", yamlList(project.WhenModified))
+	}
+	out.WriteString("workflows:\n")
+	out.WriteString("  terragrunt:\n")
+	out.WriteString("    plan:\n")
+	out.WriteString("      steps:\n")
+	fmt.Fprintf(out, "      - run: %s\n", yamlString("terragrunt plan --"+OPT_NON_INTERACTIVE+" -no-color -out $PLANFILE"))
+	out.WriteString("    apply:\n")
+	out.WriteString("      steps:\n")
+	fmt.Fprintf(out, "      - run: %s\n", yamlString("terragrunt apply --"+OPT_NON_INTERACTIVE+" -no-color $PLANFILE"))
+}
+
+// Write a .gitlab-ci.yml with a stage for each level, and a job for each module in the stage of its level, which only
+// runs if the files of the module change
+func writeGitlabCIConfig(projects []ciProject, command []string, out *bytes.Buffer) {
+	writeGeneratedCIConfigHeader(GENERATE_CI_FORMAT_GITLAB, out)
+	out.WriteString("stages:\n")
+	for level := 0; level <= maxCIProjectLevel(projects); level++ {
+		fmt.Fprintf(out, "- %s\n", yamlString(gitlabCIStage(level)))
+	}
+	for _, project := range projects {
+		out.WriteString("\n")
+		fmt.Fprintf(out, "%s:\n", yamlString(fmt.Sprintf("%s %s", command[0], project.Dir)))
+		fmt.Fprintf(out, "  stage: %s\n", yamlString(gitlabCIStage(project.Level)))
+		out.WriteString("  script:\n")
+		fmt.Fprintf(out, "  - %s\n", yamlString(ciProjectCommand(project, command)))
+		out.WriteString("  rules:\n")
+		fmt.Fprintf(out, "  - changes: %s\n", yamlList(ciProjectPaths(project)))
+	}
+}
+
+func gitlabCIStage(level int) string {
+	return fmt.Sprintf("terragrunt-%d", level)
+}
+
+// Write a GitHub Actions workflow with a job for each module, which needs the jobs of the modules it depends on. The
+// workflow runs on pull requests that change the files of any module.
+func writeGithubActionsConfig(projects []ciProject, command []string, out *bytes.Buffer) {
+	jobIds := githubJobIds(projects)
+	paths := []string{}
+	for _, project := range projects {
+		for _, path := range ciProjectPaths(project) {
+			if !util.ListContainsElement(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	writeGeneratedCIConfigHeader(GENERATE_CI_FORMAT_GITHUB, out)
+	out.WriteString("name: terragrunt\n")
+	out.WriteString("on:\n")
+	out.WriteString("  pull_request:\n")
+	fmt.Fprintf(out, "    paths: %s\n", yamlList(paths))
+	out.WriteString("jobs:\n")
+	for _, project := range projects {
+		fmt.Fprintf(out, "  %s:\n", jobIds[project.Dir])
+		fmt.Fprintf(out, "    name: %s\n", yamlString(fmt.Sprintf("%s %s", command[0], project.Dir)))
+		out.WriteString("    runs-on: ubuntu-latest\n")
+		if len(project.Dependencies) > 0 {
+			needs := []string{}
+			for _, dependency := range project.Dependencies {
+				needs = append(needs, jobIds[dependency])
+			}
+			fmt.Fprintf(out, "    needs: %s\n", yamlList(needs))
+		}
+		out.WriteString("    steps:\n")
+		out.WriteString("    - uses: actions/checkout@v4\n")
+		fmt.Fprintf(out, "    - run: %s\n", yamlString(ciProjectCommand(project, command)))
+	}
+}
+
+// Return a unique GitHub job ID for the folder of each of the given projects
+func githubJobIds(projects []ciProject) map[string]string {
+	jobIds := map[string]string{}
+	usedIds := []string{}
+
+	for _, project := range projects {
+		id := "module_" + GITHUB_JOB_ID_INVALID_CHARS.ReplaceAllString(project.Dir, "_")
+		uniqueId := id
+		for i := 2; util.ListContainsElement(usedIds, uniqueId); i++ {
+			uniqueId = fmt.Sprintf("%s_%d", id, i)
+		}
+		usedIds = append(usedIds, uniqueId)
+		jobIds[project.Dir] = uniqueId
+	}
+
+	return jobIds
+}
+
+// Return the command that runs the given Terraform command with Terragrunt in the folder of the given project
+func ciProjectCommand(project ciProject, command []string) string {
+	args := append([]string{"terragrunt"}, command...)
+	args = append(args, "--"+OPT_WORKING_DIR, project.Dir, "--"+OPT_NON_INTERACTIVE)
+	quotedArgs := []string{}
+	for _, arg := range args {
+		if !SAFE_SHELL_ARG.MatchString(arg) {
+			arg = quoteForShell(arg)
+		}
+		quotedArgs = append(quotedArgs, arg)
+	}
+	return strings.Join(quotedArgs, " ")
+}
+
+// Return the patterns of the files that affect the plan of the given project, relative to the working dir
+func ciProjectPaths(project ciProject) []string {
+	paths := []string{}
+	for _, pattern := range project.WhenModified {
+		paths = append(paths, filepath.ToSlash(filepath.Clean(filepath.Join(project.Dir, pattern))))
+	}
+	return paths
+}
+
+func maxCIProjectLevel(projects []ciProject) int {
+	maxLevel := 0
+	for _, project := range projects {
+		if project.Level > maxLevel {
+			maxLevel = project.Level
+		}
+	}
+	return maxLevel
+}
+
+func writeGeneratedCIConfigHeader(format string, out *bytes.Buffer) {
+	fmt.Fprintf(out, "# Generated by 'terragrunt %s %s %s' from the dependencies between the modules. To change it, change\n", CMD_GENERATE_CI, GENERATE_CI_FORMAT_FLAG, format)
+	out.WriteString("# the Terragrunt configs and run that command again, rather than editing this file.\n")
+}
+
+// Return the given string as a double-quoted YAML string. YAML double-quoted strings use the same escapes as Go.
+func yamlString(value string) string {
+	return strconv.Quote(value)
+}
+
+// Return the given strings as a YAML flow sequence of double-quoted strings
+func yamlList(values []string) string {
+	quotedValues := []string{}
+	for _, value := range values {
+		quotedValues = append(quotedValues, yamlString(value))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(quotedValues, ", "))
+}
+
+type ciProjectsByDir []ciProject
+
+func (projects ciProjectsByDir) Len() int           { return len(projects) }
+func (projects ciProjectsByDir) Swap(i, j int)      { projects[i], projects[j] = projects[j], projects[i] }
+func (projects ciProjectsByDir) Less(i, j int) bool { return projects[i].Dir < projects[j].Dir }
+
+// Custom error types
+
+type InvalidGenerateCIArgs []string
+
+func (args InvalidGenerateCIArgs) Error() string {
+	return fmt.Sprintf("Invalid arguments for the %s command: %v. Expected %s <format>, and optionally %s <path> and %s <terraform command>.", CMD_GENERATE_CI, []string(args), GENERATE_CI_FORMAT_FLAG, GENERATE_CI_OUT_FLAG, GENERATE_CI_COMMAND_FLAG)
+}
+
+type MissingGenerateCIFlagValue string
+
+func (flag MissingGenerateCIFlagValue) Error() string {
+	return fmt.Sprintf("You must specify a value after the %s flag of the %s command", string(flag), CMD_GENERATE_CI)
+}
+
+type UnsupportedCIFormat string
+
+func (format UnsupportedCIFormat) Error() string {
+	return fmt.Sprintf("Unsupported format '%s' for the %s command. Pass %s with one of: %s.", string(format), CMD_GENERATE_CI, GENERATE_CI_FORMAT_FLAG, strings.Join(GENERATE_CI_FORMATS, ", "))
+}
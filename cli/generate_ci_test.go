@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCIProjects(t *testing.T) {
+	t.Parallel()
+
+	external := ciModuleForTest(t, "/other/shared", config.TerragruntConfig{})
+	vpc := ciModuleForTest(t, "/repo/vpc", config.TerragruntConfig{
+		Terraform:          &config.TerraformConfig{Source: "../modules//vpc"},
+		IncludedConfigPath: "/repo/terraform.tfvars",
+	})
+	mysql := ciModuleForTest(t, "/repo/data/mysql", config.TerragruntConfig{
+		Terraform: &config.TerraformConfig{Source: "git::git@github.com:acme/modules.git//mysql?ref=v0.1.0"},
+	})
+	app := ciModuleForTest(t, "/repo/app", config.TerragruntConfig{})
+
+	mysql.Dependencies = []*configstack.TerraformModule{vpc, external}
+	app.Dependencies = []*configstack.TerraformModule{mysql, vpc}
+
+	projects, err := getCIProjects([]*configstack.TerraformModule{app, external, mysql, vpc}, "/repo")
+	if !assert.Nil(t, err, "Unexpected error: %v", err) {
+		return
+	}
+
+	expected := []ciProject{
+		{Dir: "app", Dependencies: []string{"data/mysql", "vpc"}, Level: 2, WhenModified: []string{"*.tf", "*.tf.json", "*.tfvars"}},
+		{Dir: "data/mysql", Dependencies: []string{"vpc"}, Level: 1, WhenModified: []string{"*.tf", "*.tf.json", "*.tfvars"}},
+		{Dir: "vpc", Dependencies: []string{}, Level: 0, WhenModified: []string{"*.tf", "*.tf.json", "*.tfvars", "../terraform.tfvars", "../modules/**/*.tf", "../modules/**/*.tf.json", "../modules/**/*.tfvars"}},
+	}
+	assert.Equal(t, expected, projects)
+}
+
+func TestWriteAtlantisConfig(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	writeAtlantisConfig(ciProjectsForTest(), &out)
+
+	assert.Contains(t, out.String(), `version: 3
+projects:
+- name: "app"
+  dir: "app"
+  workflow: terragrunt
+  execution_order_group: 1
+  depends_on: ["vpc"]
+  autoplan:
+    enabled: true
+    when_modified: ["*.tf", "*.tfvars"]
+- name: "vpc"
+  dir: "vpc"
+  workflow: terragrunt
+  execution_order_group: 0
+  autoplan:
+    enabled: true
+    when_modified: ["*.tf", "../terraform.tfvars"]
+workflows:
+`)
+	assert.Contains(t, out.String(), `- run: "terragrunt plan --terragrunt-non-interactive -no-color -out $PLANFILE"`)
+}
+
+func TestWriteGitlabCIConfig(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	writeGitlabCIConfig(ciProjectsForTest(), []string{"plan"}, &out)
+
+	assert.Contains(t, out.String(), `stages:
+- "terragrunt-0"
+- "terragrunt-1"
+
+"plan app":
+  stage: "terragrunt-1"
+  script:
+  - "terragrunt plan --terragrunt-working-dir app --terragrunt-non-interactive"
+  rules:
+  - changes: ["app/*.tf", "app/*.tfvars"]
+
+"plan vpc":
+  stage: "terragrunt-0"
+  script:
+  - "terragrunt plan --terragrunt-working-dir vpc --terragrunt-non-interactive"
+  rules:
+  - changes: ["vpc/*.tf", "terraform.tfvars"]
+`)
+}
+
+func TestWriteGithubActionsConfig(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	writeGithubActionsConfig(ciProjectsForTest(), []string{"apply", "-auto-approve"}, &out)
+
+	assert.Contains(t, out.String(), `    paths: ["app/*.tf", "app/*.tfvars", "vpc/*.tf", "terraform.tfvars"]
+jobs:
+  module_app:
+    name: "apply app"
+    runs-on: ubuntu-latest
+    needs: ["module_vpc"]
+    steps:
+    - uses: actions/checkout@v4
+    - run: "terragrunt apply -auto-approve --terragrunt-working-dir app --terragrunt-non-interactive"
+  module_vpc:
+`)
+}
+
+func TestGithubJobIds(t *testing.T) {
+	t.Parallel()
+
+	jobIds := githubJobIds([]ciProject{{Dir: "."}, {Dir: "us-east-1/vpc"}, {Dir: "us-east-1_vpc"}})
+	assert.Equal(t, map[string]string{".": "module__", "us-east-1/vpc": "module_us-east-1_vpc", "us-east-1_vpc": "module_us-east-1_vpc_2"}, jobIds)
+}
+
+func TestCIProjectCommand(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "terragrunt plan -var 'name=my app' --terragrunt-working-dir 'my app' --terragrunt-non-interactive", ciProjectCommand(ciProject{Dir: "my app"}, []string{"plan", "-var", "name=my app"}))
+}
+
+func TestParseGenerateCIArgs(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("/repo/terraform.tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	terragruntOptions.TerraformCliArgs = []string{CMD_GENERATE_CI, GENERATE_CI_FORMAT_FLAG, "gitlab", GENERATE_CI_OUT_FLAG + "=.gitlab-ci.yml", GENERATE_CI_COMMAND_FLAG, "apply -auto-approve"}
+	args, err := parseGenerateCIArgs(terragruntOptions)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		assert.Equal(t, generateCIArgs{Format: "gitlab", OutPath: "/repo/.gitlab-ci.yml", Command: []string{"apply", "-auto-approve"}}, *args)
+	}
+
+	terragruntOptions.TerraformCliArgs = []string{CMD_GENERATE_CI, GENERATE_CI_FORMAT_FLAG, "jenkins"}
+	_, err = parseGenerateCIArgs(terragruntOptions)
+	_, isUnsupportedFormatErr := errors.Unwrap(err).(UnsupportedCIFormat)
+	assert.True(t, isUnsupportedFormatErr, "Expected an UnsupportedCIFormat error, but got %v", err)
+
+	terragruntOptions.TerraformCliArgs = []string{CMD_GENERATE_CI, GENERATE_CI_FORMAT_FLAG, "github", "extra"}
+	_, err = parseGenerateCIArgs(terragruntOptions)
+	_, isInvalidArgsErr := errors.Unwrap(err).(InvalidGenerateCIArgs)
+	assert.True(t, isInvalidArgsErr, "Expected an InvalidGenerateCIArgs error, but got %v", err)
+}
+
+func ciProjectsForTest() []ciProject {
+	return []ciProject{
+		{Dir: "app", Dependencies: []string{"vpc"}, Level: 1, WhenModified: []string{"*.tf", "*.tfvars"}},
+		{Dir: "vpc", Dependencies: []string{}, Level: 0, WhenModified: []string{"*.tf", "../terraform.tfvars"}},
+	}
+}
+
+func ciModuleForTest(t *testing.T, path string, terragruntConfig config.TerragruntConfig) *configstack.TerraformModule {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(config.DefaultConfigPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &configstack.TerraformModule{Path: path, Config: terragruntConfig, TerragruntOptions: terragruntOptions}
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The go-getter prefix that marks a source URL as a Git repo
+const GIT_FORCED_GETTER = "git"
+
+// Returns true if we should download the given source by running git ourselves, rather than via terraform init. We only
+// do that for Git sources, and only if the user asked for a shallow clone, a sparse checkout, or no submodules, as
+// terraform init doesn't support those.
+func shouldCloneGitSource(terraformSource *TerraformSource, terragruntOptions *options.TerragruntOptions) bool {
+	if !terragruntOptions.SourceShallowClone && !terragruntOptions.SourceSparseCheckout && !terragruntOptions.SourceNoSubmodules {
+		return false
+	}
+
+	forcedGetter, _ := getForcedGetter(terraformSource.CanonicalSourceURL.Scheme)
+	return forcedGetter == GIT_FORCED_GETTER
+}
+
+// Download the given Git source into its DownloadDir by running git directly. Unlike terraform init, this can fetch
+// just the latest commit of the ref in the source URL (a shallow clone), check out just the folder after the
+// double-slash (a sparse checkout), and skip submodules, which makes downloading large monorepos much faster. We clone
+// into a temporary folder and copy everything except the .git folders into the DownloadDir, so the result looks the same
+// as a download by terraform init. Terraform itself is then initialized by Auto-Init when the command runs.
+func cloneGitSource(terraformSource *TerraformSource, terragruntOptions *options.TerragruntOptions) error {
+	repoUrl, ref := getGitRepoUrlAndRef(terraformSource.CanonicalSourceURL)
+
+	cloneDir, err := ioutil.TempDir("", "terragrunt-git-clone")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer os.RemoveAll(cloneDir)
+
+	terragruntOptions.Logger.Printf("Downloading Terraform configurations from %s into %s using git", terraformSource.CanonicalSourceURL, terraformSource.DownloadDir)
+
+	gitOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	gitOptions.WorkingDir = cloneDir
+	// Don't pollute stdout with the output of git, just like we don't with the output of terraform init
+	gitOptions.Writer = gitOptions.ErrWriter
+
+	cleanupCredentials, err := configureSourceDownloadCredentials(terraformSource, gitOptions, terragruntOptions)
+	defer cleanupCredentials()
+	if err != nil {
+		return err
+	}
+
+	// We use git init and git fetch, rather than git clone, because only git fetch can fetch a single commit by its SHA,
+	// as well as by a branch or tag name
+	if err := runGitCommands(gitOptions, repoUrl, []string{"init", "--quiet"}, []string{"remote", "add", "origin", repoUrl}); err != nil {
+		return err
+	}
+
+	if err := configureSparseCheckout(getSparseCheckoutPath(terraformSource), gitOptions, terragruntOptions); err != nil {
+		return err
+	}
+
+	if err := runGitCommands(gitOptions, repoUrl, getGitCheckoutArgs(ref, terragruntOptions)...); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(terraformSource.DownloadDir, 0777); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	return util.CopyFolderContentsWithFilter(cloneDir, terraformSource.DownloadDir, func(path string) bool {
+		return !pathContainsGitFolder(cloneDir, path)
+	})
+}
+
+// Run git in the working dir of the given options once for each of the given lists of args
+func runGitCommands(gitOptions *options.TerragruntOptions, repoUrl string, argsList ...[]string) error {
+	for _, args := range argsList {
+		if err := shell.RunShellCommand(gitOptions, "git", args...); err != nil {
+			return errors.WithStackTrace(GitCloneFailed{Url: repoUrl, Underlying: err})
+		}
+	}
+	return nil
+}
+
+// Return the args for the git commands that check out the given ref (or the default branch if ref is empty) of the
+// origin remote, and its submodules, according to the given options
+func getGitCheckoutArgs(ref string, terragruntOptions *options.TerragruntOptions) [][]string {
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	fetchArgs := []string{"fetch", "--quiet"}
+	if terragruntOptions.SourceShallowClone {
+		fetchArgs = append(fetchArgs, "--depth", "1")
+	}
+	fetchArgs = append(fetchArgs, "origin", ref)
+
+	argsList := [][]string{fetchArgs, []string{"checkout", "--quiet", "FETCH_HEAD"}}
+
+	if !terragruntOptions.SourceNoSubmodules {
+		submoduleArgs := []string{"submodule", "update", "--init", "--recursive"}
+		if terragruntOptions.SourceShallowClone {
+			submoduleArgs = append(submoduleArgs, "--depth", "1")
+		}
+		argsList = append(argsList, submoduleArgs)
+	}
+
+	return argsList
+}
+
+// If the user asked for a sparse checkout, configure the repo in the working dir of the given git options to only
+// check out the given path
+func configureSparseCheckout(sparseCheckoutPath string, gitOptions *options.TerragruntOptions, terragruntOptions *options.TerragruntOptions) error {
+	if !terragruntOptions.SourceSparseCheckout {
+		return nil
+	}
+
+	if sparseCheckoutPath == "" {
+		terragruntOptions.Logger.Printf("WARNING: the --%s option is set, but the source URL has no path after the double-slash (//), so the whole repo will be checked out", OPT_TERRAGRUNT_SOURCE_SPARSE_CHECKOUT)
+		return nil
+	}
+
+	if err := shell.RunShellCommand(gitOptions, "git", "config", "core.sparseCheckout", "true"); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	sparseCheckoutFile := util.JoinPath(gitOptions.WorkingDir, ".git", "info", "sparse-checkout")
+	if err := os.MkdirAll(filepath.Dir(sparseCheckoutFile), 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	pattern := fmt.Sprintf("/%s/\n", strings.Trim(sparseCheckoutPath, "/"))
+	return errors.WithStackTrace(ioutil.WriteFile(sparseCheckoutFile, []byte(pattern), 0644))
+}
+
+// Return the URL to pass to git for the given Git source URL, without the go-getter prefix and query string, and the
+// ref in its query string, if any. Other go-getter parameters in the query string (e.g. depth) are not used.
+func getGitRepoUrlAndRef(sourceUrl *url.URL) (string, string) {
+	repoUrl := *sourceUrl
+	_, repoUrl.Scheme = getForcedGetter(sourceUrl.Scheme)
+	repoUrl.RawQuery = ""
+
+	return repoUrl.String(), sourceUrl.Query().Get("ref")
+}
+
+// Return the path of the working dir of the given source relative to its download dir (i.e. the path after the
+// double-slash in the source URL), or an empty string if the working dir is the download dir
+func getSparseCheckoutPath(terraformSource *TerraformSource) string {
+	relativePath, err := filepath.Rel(terraformSource.DownloadDir, terraformSource.WorkingDir)
+	if err != nil || relativePath == "." {
+		return ""
+	}
+	return filepath.ToSlash(relativePath)
+}
+
+// Returns true if the given path, relative to the given root folder, is or is in a .git folder (or is one of the .git
+// files that Git uses for submodules)
+func pathContainsGitFolder(root string, path string) bool {
+	relativePath, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	for _, pathPart := range strings.Split(filepath.ToSlash(relativePath), "/") {
+		if pathPart == ".git" {
+			return true
+		}
+	}
+	return false
+}
+
+// Custom error types
+
+type GitCloneFailed struct {
+	Url        string
+	Underlying error
+}
+
+func (err GitCloneFailed) Error() string {
+	return fmt.Sprintf("Unable to download Terraform configurations from Git repo %s: %v", err.Url, err.Underlying)
+}
+
+func (err GitCloneFailed) Unwrap() error {
+	return err.Underlying
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldCloneGitSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		sourceUrl       string
+		shallowClone    bool
+		sparseCheckout  bool
+		noSubmodules    bool
+		expectedToClone bool
+	}{
+		{"git::https://github.com/foo/bar.git", false, false, false, false},
+		{"git::https://github.com/foo/bar.git", true, false, false, true},
+		{"git::ssh://git@github.com/foo/bar.git", false, true, false, true},
+		{"git::ssh://git@github.com/foo/bar.git", false, false, true, true},
+		{"https://example.com/bar.tar.gz", true, true, true, false},
+		{"file:///foo/bar", true, false, false, false},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.SourceShallowClone = testCase.shallowClone
+		terragruntOptions.SourceSparseCheckout = testCase.sparseCheckout
+		terragruntOptions.SourceNoSubmodules = testCase.noSubmodules
+
+		terraformSource := createSourceAuthTestSource(t, testCase.sourceUrl)
+		assert.Equal(t, testCase.expectedToClone, shouldCloneGitSource(terraformSource, terragruntOptions), "For source URL %s", testCase.sourceUrl)
+	}
+}
+
+func TestGetGitRepoUrlAndRef(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		sourceUrl       string
+		expectedRepoUrl string
+		expectedRef     string
+	}{
+		{"git::https://github.com/foo/bar.git", "https://github.com/foo/bar.git", ""},
+		{"git::https://github.com/foo/bar.git?ref=v0.0.1", "https://github.com/foo/bar.git", "v0.0.1"},
+		{"git::ssh://git@github.com/foo/bar.git?ref=master&depth=1", "ssh://git@github.com/foo/bar.git", "master"},
+	}
+
+	for _, testCase := range testCases {
+		sourceUrl, err := parseSourceUrl(testCase.sourceUrl)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+
+		repoUrl, ref := getGitRepoUrlAndRef(sourceUrl)
+		assert.Equal(t, testCase.expectedRepoUrl, repoUrl, "For source URL %s", testCase.sourceUrl)
+		assert.Equal(t, testCase.expectedRef, ref, "For source URL %s", testCase.sourceUrl)
+	}
+}
+
+func TestGetGitCheckoutArgs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		ref          string
+		shallowClone bool
+		noSubmodules bool
+		expected     [][]string
+	}{
+		{"", false, false, [][]string{{"fetch", "--quiet", "origin", "HEAD"}, {"checkout", "--quiet", "FETCH_HEAD"}, {"submodule", "update", "--init", "--recursive"}}},
+		{"v0.0.1", true, false, [][]string{{"fetch", "--quiet", "--depth", "1", "origin", "v0.0.1"}, {"checkout", "--quiet", "FETCH_HEAD"}, {"submodule", "update", "--init", "--recursive", "--depth", "1"}}},
+		{"master", false, true, [][]string{{"fetch", "--quiet", "origin", "master"}, {"checkout", "--quiet", "FETCH_HEAD"}}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.SourceShallowClone = testCase.shallowClone
+		terragruntOptions.SourceNoSubmodules = testCase.noSubmodules
+
+		assert.Equal(t, testCase.expected, getGitCheckoutArgs(testCase.ref, terragruntOptions), "For ref %s", testCase.ref)
+	}
+}
+
+func TestPathContainsGitFolder(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{"/root/main.tf", false},
+		{"/root/.git", true},
+		{"/root/.git/HEAD", true},
+		{"/root/modules/submodule/.git", true},
+		{"/root/.github/workflows/ci.yml", false},
+		{"/root/.gitignore", false},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, pathContainsGitFolder(filepath.FromSlash("/root"), filepath.FromSlash(testCase.path)), "For path %s", testCase.path)
+	}
+}
+
+func TestCloneGitSourceShallowSparseCheckout(t *testing.T) {
+	t.Parallel()
+
+	repoDir := tmpDir(t)
+	defer os.RemoveAll(repoDir)
+
+	writeSourceHashTestFile(t, repoDir, "app/main.tf", "# app")
+	writeSourceHashTestFile(t, repoDir, "vpc/main.tf", "# vpc")
+	runGitForTest(t, repoDir, "init", "--quiet")
+	runGitForTest(t, repoDir, "add", ".")
+	runGitForTest(t, repoDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "Initial commit")
+
+	downloadDir := tmpDir(t)
+	defer os.RemoveAll(downloadDir)
+
+	terraformSource := createSourceAuthTestSource(t, fmt.Sprintf("git::file://%s", filepath.ToSlash(repoDir)))
+	terraformSource.DownloadDir = downloadDir
+	terraformSource.WorkingDir = util.JoinPath(downloadDir, "app")
+	terraformSource.VersionFile = util.JoinPath(downloadDir, "version-file.txt")
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.Env = parseEnvironmentVariables(os.Environ())
+	terragruntOptions.SourceShallowClone = true
+	terragruntOptions.SourceSparseCheckout = true
+	terragruntOptions.SourceNoSubmodules = true
+
+	err = cloneGitSource(terraformSource, terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	assert.Equal(t, "# app", readFile(t, util.JoinPath(downloadDir, "app", "main.tf")))
+	assert.False(t, util.FileExists(util.JoinPath(downloadDir, "vpc")), "Expected the sparse checkout to skip the vpc folder")
+	assert.False(t, util.FileExists(util.JoinPath(downloadDir, ".git")), "Expected the .git folder not to be copied")
+}
+
+func runGitForTest(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, string(output))
+	}
+}
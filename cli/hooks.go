@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The env vars Terragrunt sets for the hooks: the Terraform command, e.g. plan, and, for the after hook only, the exit
+// code of Terraform
+const HOOK_TERRAFORM_COMMAND_ENV_VAR = "TERRAGRUNT_TERRAFORM_COMMAND"
+const HOOK_TERRAFORM_EXIT_CODE_ENV_VAR = "TERRAGRUNT_TERRAFORM_EXIT_CODE"
+
+// Run the given Terraform command between the BeforeHook and the AfterHook in the given options, if any. If the before
+// hook fails, Terraform doesn't run. The after hook runs whether or not Terraform succeeded, so it can report or clean
+// up either way; if Terraform failed, its error is returned rather than that of the after hook.
+func runTerraformCommandWithHooks(terragruntOptions *options.TerragruntOptions, runTerraformCommand func() error) error {
+	if terragruntOptions.BeforeHook != "" {
+		if err := runHook("before", terragruntOptions.BeforeHook, terragruntOptions, nil); err != nil {
+			return err
+		}
+	}
+
+	runErr := runTerraformCommand()
+
+	if terragruntOptions.AfterHook != "" {
+		hookErr := runHook("after", terragruntOptions.AfterHook, terragruntOptions, runErr)
+		if runErr == nil {
+			return hookErr
+		}
+		if hookErr != nil {
+			terragruntOptions.ErrorLogger().Printf("%v", hookErr)
+		}
+	}
+
+	return runErr
+}
+
+// Run the given hook command line with the shell of the OS, in the working dir of the given options. For the after
+// hook, the given error is the result of the Terraform command. The output of the hook is captured, and logged once it
+// finishes, with the matches of the RedactPatterns of the given options redacted, as hooks often handle secrets.
+func runHook(name string, commandLine string, terragruntOptions *options.TerragruntOptions, terraformErr error) error {
+	env := util.CloneStringMap(terragruntOptions.Env)
+	env[HOOK_TERRAFORM_COMMAND_ENV_VAR] = firstArg(terragruntOptions.TerraformCliArgs)
+	if name == "after" {
+		env[HOOK_TERRAFORM_EXIT_CODE_ENV_VAR] = strconv.Itoa(getExitCodeForResult(terraformErr))
+	}
+
+	hookOptions := *terragruntOptions
+	hookOptions.Env = env
+
+	terragruntOptions.Logger.Printf("Running the %s hook: %s", name, commandLine)
+	command, args := shellCommandLine(commandLine)
+	captureOptions := shell.CaptureOptions{RedactPatterns: terragruntOptions.RedactPatterns, CombineOutput: true, LogOutput: true}
+	output, err := shell.RunCommandAndCaptureOutput(&hookOptions, captureOptions, command, args...)
+	if err != nil {
+		// In quiet mode, the output isn't logged, but it's likely to say why the hook failed
+		if terragruntOptions.Quiet && output.Stdout != "" {
+			terragruntOptions.ErrorLogger().Printf("Output of the %s hook:\n%s", name, output.Stdout)
+		}
+		return errors.WithStackTrace(HookFailed{Name: name, CommandLine: commandLine, Underlying: err})
+	}
+	return nil
+}
+
+// Return the command and args that run the given command line with the shell of the OS
+func shellCommandLine(commandLine string) (string, []string) {
+	if runtime.GOOS == "windows" {
+		return "cmd.exe", []string{"/C", commandLine}
+	}
+	return "sh", []string{"-c", commandLine}
+}
+
+// Custom error types
+
+type HookFailed struct {
+	Name        string
+	CommandLine string
+	Underlying  error
+}
+
+func (err HookFailed) Error() string {
+	return fmt.Sprintf("The %s hook '%s' failed: %v", err.Name, err.CommandLine, err.Underlying)
+}
+
+func (err HookFailed) Unwrap() error {
+	return err.Underlying
+}
+
+func (err HookFailed) Is(target error) bool {
+	return target == errors.ErrHookFailed
+}
+
+func (err HookFailed) ExitStatus() (int, error) {
+	return shell.GetExitCode(err.Underlying)
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func createHookTestOptions(t *testing.T, modulePath string) *options.TerragruntOptions {
+	if runtime.GOOS == "windows" {
+		t.Skip("The hooks in this test are sh commands")
+	}
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(modulePath, config.DefaultTerragruntConfigPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.TerraformCliArgs = []string{CMD_PLAN}
+	terragruntOptions.Env = map[string]string{"PATH": os.Getenv("PATH")}
+	return terragruntOptions
+}
+
+func readHookTestFile(t *testing.T, path string) string {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(contents))
+}
+
+func TestRunTerraformCommandWithHooks(t *testing.T) {
+	t.Parallel()
+
+	modulePath := tmpDir(t)
+	defer os.RemoveAll(modulePath)
+
+	terragruntOptions := createHookTestOptions(t, modulePath)
+	terragruntOptions.BeforeHook = "echo before $TERRAGRUNT_TERRAFORM_COMMAND > hooks.log"
+	terragruntOptions.AfterHook = "echo after $TERRAGRUNT_TERRAFORM_EXIT_CODE >> hooks.log"
+
+	terraformRan := false
+	err := runTerraformCommandWithHooks(terragruntOptions, func() error {
+		terraformRan = true
+		return nil
+	})
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.True(t, terraformRan)
+	assert.Equal(t, "before plan\nafter 0", readHookTestFile(t, util.JoinPath(modulePath, "hooks.log")))
+
+	// The after hook runs even if Terraform fails, and the error of Terraform is returned
+	terraformErr := fmt.Errorf("terraform failed")
+	err = runTerraformCommandWithHooks(terragruntOptions, func() error {
+		return terraformErr
+	})
+	assert.Equal(t, terraformErr, err)
+	assert.Equal(t, "before plan\nafter 1", readHookTestFile(t, util.JoinPath(modulePath, "hooks.log")))
+}
+
+func TestRunTerraformCommandWithHooksRedactsOutput(t *testing.T) {
+	t.Parallel()
+
+	modulePath := tmpDir(t)
+	defer os.RemoveAll(modulePath)
+
+	var logs bytes.Buffer
+	terragruntOptions := createHookTestOptions(t, modulePath)
+	terragruntOptions.Logger = util.CreateLoggerWithWriter(&logs, "")
+	terragruntOptions.BeforeHook = "echo token=abc123; echo password=hunter2 >&2"
+	terragruntOptions.RedactPatterns = []*regexp.Regexp{regexp.MustCompile(`token=(\w+)`), regexp.MustCompile(`hunter2`)}
+
+	err := runTerraformCommandWithHooks(terragruntOptions, func() error { return nil })
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Contains(t, logs.String(), "token=REDACTED\npassword=REDACTED")
+	assert.NotContains(t, logs.String(), "abc123")
+	assert.NotContains(t, logs.String(), "hunter2")
+}
+
+func TestRunTerraformCommandWithFailingBeforeHook(t *testing.T) {
+	t.Parallel()
+
+	modulePath := tmpDir(t)
+	defer os.RemoveAll(modulePath)
+
+	terragruntOptions := createHookTestOptions(t, modulePath)
+	terragruntOptions.BeforeHook = "exit 3"
+
+	terraformRan := false
+	err := runTerraformCommandWithHooks(terragruntOptions, func() error {
+		terraformRan = true
+		return nil
+	})
+	assert.False(t, terraformRan)
+	if _, isHookFailedErr := errors.Unwrap(err).(HookFailed); assert.True(t, isHookFailedErr, "Expected a HookFailed error but got: %v", err) {
+		assert.Equal(t, 3, getExitCodeForResult(err))
+	}
+}
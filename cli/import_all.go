@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"gopkg.in/yaml.v2"
+)
+
+const CMD_IMPORT_ALL = "import-all"
+
+// Pass this flag, followed by the path of a mapping file, to the import-all command to pick the resources to import.
+// Relative paths are relative to the working dir.
+const IMPORT_ALL_MAPPING_FILE_FLAG = "--mapping-file"
+
+// Import the existing resources listed in the mapping file passed with IMPORT_ALL_MAPPING_FILE_FLAG into the state of
+// the modules of the stack in the subfolders of the working dir. The mapping file is YAML (or JSON), with the path of
+// each module, relative to the working dir, mapped to the addresses of the resources to import into it, each mapped to
+// the ID of the existing resource:
+//
+//	vpc:
+//	  aws_vpc.main: vpc-0a1b2c3d
+//	app:
+//	  aws_instance.web: i-0a1b2c3d
+//
+// Any other args are passed on to terraform import in each module.
+func importAll(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
+	mappingFile, extraArgs, err := parseImportAllArgs(terragruntOptions.TerraformCliArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	mappingFilePath, err := util.CanonicalPath(mappingFile, terragruntOptions.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := readImportMappings(mappingFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The stack adds the import command and the extra args itself
+	terragruntOptions.TerraformCliArgs = []string{}
+
+	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	terragruntOptions.Logger.Printf("%s", stack.String())
+	return stack.Import(mappings, extraArgs, terragruntOptions)
+}
+
+// Return the path passed with IMPORT_ALL_MAPPING_FILE_FLAG in the given args after the import-all command, plus the
+// other args, which are meant for terraform import
+func parseImportAllArgs(args []string) (string, []string, error) {
+	mappingFile := ""
+	extraArgs := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == IMPORT_ALL_MAPPING_FILE_FLAG:
+			if i+1 >= len(args) {
+				return "", nil, errors.WithStackTrace(InvalidImportAllArgs(args))
+			}
+			mappingFile = args[i+1]
+			i++
+		case strings.HasPrefix(arg, IMPORT_ALL_MAPPING_FILE_FLAG+"="):
+			mappingFile = strings.TrimPrefix(arg, IMPORT_ALL_MAPPING_FILE_FLAG+"=")
+		default:
+			extraArgs = append(extraArgs, arg)
+		}
+	}
+
+	if mappingFile == "" {
+		return "", nil, errors.WithStackTrace(InvalidImportAllArgs(args))
+	}
+	return mappingFile, extraArgs, nil
+}
+
+// Read the resources to import from the mapping file at the given path, sorted by module path and address
+func readImportMappings(path string) ([]configstack.ImportMapping, error) {
+	contents, err := util.ReadFileAsString(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseImportMappings(contents, path)
+}
+
+// Parse the resources to import from the given contents of the mapping file at the given path
+func parseImportMappings(contents string, path string) ([]configstack.ImportMapping, error) {
+	parsed := map[string]map[string]string{}
+	if err := yaml.Unmarshal([]byte(contents), &parsed); err != nil {
+		return nil, errors.WithStackTrace(InvalidImportMappingFile{Path: path, Underlying: err})
+	}
+
+	mappings := []configstack.ImportMapping{}
+	for modulePath, resources := range parsed {
+		for address, id := range resources {
+			if strings.TrimSpace(address) == "" || strings.TrimSpace(id) == "" {
+				return nil, errors.WithStackTrace(InvalidImportMappingFile{Path: path, Underlying: fmt.Errorf("module %s has a resource with an empty address or ID", modulePath)})
+			}
+			mappings = append(mappings, configstack.ImportMapping{ModulePath: modulePath, Address: address, Id: id})
+		}
+	}
+
+	if len(mappings) == 0 {
+		return nil, errors.WithStackTrace(InvalidImportMappingFile{Path: path, Underlying: fmt.Errorf("it has no resources to import")})
+	}
+
+	sort.Sort(importMappingsByModulePathAndAddress(mappings))
+	return mappings, nil
+}
+
+type importMappingsByModulePathAndAddress []configstack.ImportMapping
+
+func (mappings importMappingsByModulePathAndAddress) Len() int {
+	return len(mappings)
+}
+
+func (mappings importMappingsByModulePathAndAddress) Swap(i, j int) {
+	mappings[i], mappings[j] = mappings[j], mappings[i]
+}
+
+func (mappings importMappingsByModulePathAndAddress) Less(i, j int) bool {
+	if mappings[i].ModulePath != mappings[j].ModulePath {
+		return mappings[i].ModulePath < mappings[j].ModulePath
+	}
+	return mappings[i].Address < mappings[j].Address
+}
+
+// Custom error types
+
+type InvalidImportAllArgs []string
+
+func (args InvalidImportAllArgs) Error() string {
+	return fmt.Sprintf("Invalid args for the %s command: %v. Usage: terragrunt %s %s <file> [terraform import args]", CMD_IMPORT_ALL, []string(args), CMD_IMPORT_ALL, IMPORT_ALL_MAPPING_FILE_FLAG)
+}
+
+type InvalidImportMappingFile struct {
+	Path       string
+	Underlying error
+}
+
+func (err InvalidImportMappingFile) Error() string {
+	return fmt.Sprintf("Invalid import mapping file %s: %v. It must map the path of each module to the addresses of the resources to import into it, each mapped to the ID of the resource.", err.Path, err.Underlying)
+}
+
+func (err InvalidImportMappingFile) Unwrap() error {
+	return err.Underlying
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImportAllArgs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args              []string
+		expectedFile      string
+		expectedExtraArgs []string
+	}{
+		{[]string{"--mapping-file", "imports.yaml"}, "imports.yaml", []string{}},
+		{[]string{"--mapping-file=imports.yaml", "-var-file=prod.tfvars"}, "imports.yaml", []string{"-var-file=prod.tfvars"}},
+		{[]string{"-lock=false", "--mapping-file", "/tmp/imports.json"}, "/tmp/imports.json", []string{"-lock=false"}},
+	}
+
+	for _, testCase := range testCases {
+		file, extraArgs, err := parseImportAllArgs(testCase.args)
+		assert.Nil(t, err, "Unexpected error for args %v: %v", testCase.args, err)
+		assert.Equal(t, testCase.expectedFile, file, "For args %v", testCase.args)
+		assert.Equal(t, testCase.expectedExtraArgs, extraArgs, "For args %v", testCase.args)
+	}
+
+	for _, args := range [][]string{{}, {"--mapping-file"}, {"-var-file=prod.tfvars"}} {
+		_, _, err := parseImportAllArgs(args)
+		_, isInvalidArgsErr := errors.Unwrap(err).(InvalidImportAllArgs)
+		assert.True(t, isInvalidArgsErr, "Expected an InvalidImportAllArgs error for args %v but got: %v", args, err)
+	}
+}
+
+func TestParseImportMappings(t *testing.T) {
+	t.Parallel()
+
+	yamlContents := `
+vpc:
+  aws_vpc.main: vpc-0a1b2c3d
+  "aws_subnet.private[0]": subnet-0a1b2c3d
+app:
+  aws_instance.web: i-0a1b2c3d
+`
+	expected := []configstack.ImportMapping{
+		{ModulePath: "app", Address: "aws_instance.web", Id: "i-0a1b2c3d"},
+		{ModulePath: "vpc", Address: "aws_subnet.private[0]", Id: "subnet-0a1b2c3d"},
+		{ModulePath: "vpc", Address: "aws_vpc.main", Id: "vpc-0a1b2c3d"},
+	}
+
+	actual, err := parseImportMappings(yamlContents, "imports.yaml")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, expected, actual)
+
+	actual, err = parseImportMappings(`{"app": {"aws_instance.web": "i-0a1b2c3d"}}`, "imports.json")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, expected[:1], actual)
+
+	for _, contents := range []string{"", "vpc: [aws_vpc.main]", "vpc:\n  aws_vpc.main: \"\"", "not: valid: yaml"} {
+		_, err := parseImportMappings(contents, "imports.yaml")
+		_, isInvalidFileErr := errors.Unwrap(err).(InvalidImportMappingFile)
+		assert.True(t, isInvalidFileErr, "Expected an InvalidImportMappingFile error for %q but got: %v", contents, err)
+	}
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The most config files to list in a MissingTerragruntConfig error, so a folder with many modules below it doesn't
+// bury the rest of the message
+const MAX_NEARBY_CONFIG_FILES = 10
+
+// Read the Terragrunt config at the path in the given options. If there is no Terragrunt config there, because the
+// file doesn't exist or has no terragrunt block, then if the AllowMissingConfig option is set, return an empty config,
+// so Terraform runs in the working dir as if there were no Terragrunt, and otherwise return a MissingTerragruntConfig
+// error that explains where Terragrunt looks for its config and lists the config files nearby.
+func readTerragruntConfig(terragruntOptions *options.TerragruntOptions) (*config.TerragruntConfig, error) {
+	isTerragruntConfig, err := config.IsTerragruntConfigFile(terragruntOptions.TerragruntConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if isTerragruntConfig {
+		return config.ReadTerragruntConfig(terragruntOptions)
+	}
+
+	if terragruntOptions.AllowMissingConfig {
+		terragruntOptions.Logger.Printf("No Terragrunt config found at %s, so running Terraform in %s without one", terragruntOptions.TerragruntConfigPath, terragruntOptions.WorkingDir)
+		return &config.TerragruntConfig{}, nil
+	}
+
+	nearbyConfigPaths, err := findNearbyConfigFiles(terragruntOptions.TerragruntConfigPath, terragruntOptions.MaxFoldersToCheck)
+	if err != nil {
+		return nil, err
+	}
+	return nil, errors.WithStackTrace(MissingTerragruntConfig{
+		ConfigPath:        terragruntOptions.TerragruntConfigPath,
+		FileExists:        util.FileExists(terragruntOptions.TerragruntConfigPath),
+		NearbyConfigPaths: nearbyConfigPaths,
+	})
+}
+
+// Return the paths of the Terragrunt config files near the given config path that doesn't have a Terragrunt config,
+// which are likely what the user meant: other files in the same folder with a terragrunt block (e.g. a config that was
+// misnamed), the config in the nearest parent folder that has one, and the configs in the subfolders. At most
+// MAX_NEARBY_CONFIG_FILES are returned.
+func findNearbyConfigFiles(configPath string, maxFoldersToCheck int) ([]string, error) {
+	configDir := filepath.Dir(configPath)
+	nearbyConfigPaths := []string{}
+
+	files, err := ioutil.ReadDir(configDir)
+	if err != nil {
+		// The folder itself may not exist, e.g. if --terragrunt-config has a typo, in which case there's nothing nearby
+		return nearbyConfigPaths, nil
+	}
+
+	subfolderConfigPaths := []string{}
+	for _, file := range files {
+		path := util.JoinPath(configDir, file.Name())
+		if file.IsDir() {
+			if !strings.HasPrefix(file.Name(), ".") {
+				subfolderConfigPaths = append(subfolderConfigPaths, config.DefaultConfigPath(path))
+			}
+			continue
+		}
+		if file.Name() == filepath.Base(configPath) || (filepath.Ext(path) != ".tfvars" && file.Name() != config.OldTerragruntConfigPath) {
+			continue
+		}
+		if isTerragruntConfig, err := config.IsTerragruntConfigFile(path); err == nil && isTerragruntConfig {
+			nearbyConfigPaths = append(nearbyConfigPaths, path)
+		}
+	}
+
+	parentConfigPath, err := config.FindConfigPathInParentFolders(configDir, maxFoldersToCheck)
+	if err != nil {
+		return nil, err
+	}
+	if parentConfigPath != "" {
+		nearbyConfigPaths = append(nearbyConfigPaths, parentConfigPath)
+	}
+
+	for _, path := range subfolderConfigPaths {
+		if isTerragruntConfig, err := config.IsTerragruntConfigFile(path); err == nil && isTerragruntConfig {
+			nearbyConfigPaths = append(nearbyConfigPaths, path)
+		}
+	}
+
+	if len(nearbyConfigPaths) > MAX_NEARBY_CONFIG_FILES {
+		nearbyConfigPaths = nearbyConfigPaths[:MAX_NEARBY_CONFIG_FILES]
+	}
+	return nearbyConfigPaths, nil
+}
+
+// Custom error types
+
+type MissingTerragruntConfig struct {
+	ConfigPath        string
+	FileExists        bool
+	NearbyConfigPaths []string
+}
+
+func (err MissingTerragruntConfig) Error() string {
+	var message bytes.Buffer
+
+	if err.FileExists {
+		fmt.Fprintf(&message, "Could not find Terragrunt configuration settings in %s: it has no terragrunt = { ... } block.\n", err.ConfigPath)
+	} else {
+		fmt.Fprintf(&message, "Could not find a Terragrunt config at %s: the file does not exist.\n", err.ConfigPath)
+	}
+
+	fmt.Fprintf(&message, "Terragrunt uses the config file passed with --%s or the TERRAGRUNT_CONFIG environment variable, if any. Otherwise, it uses %s in the working dir, if it exists, and else %s in the working dir. With --%s, it uses the config in the nearest parent folder if there is none in the working dir.\n", OPT_TERRAGRUNT_CONFIG, config.OldTerragruntConfigPath, config.DefaultTerragruntConfigPath, OPT_TERRAGRUNT_SEARCH_PARENT_DIRS)
+
+	if len(err.NearbyConfigPaths) > 0 {
+		fmt.Fprintf(&message, "Found these Terragrunt config files nearby:\n")
+		for _, path := range err.NearbyConfigPaths {
+			fmt.Fprintf(&message, "  %s\n", path)
+		}
+		fmt.Fprintf(&message, "To use one of them, pass its path with --%s. ", OPT_TERRAGRUNT_CONFIG)
+	} else {
+		fmt.Fprintf(&message, "Found no Terragrunt config files nearby. To use a config elsewhere, pass its path with --%s. ", OPT_TERRAGRUNT_CONFIG)
+	}
+
+	fmt.Fprintf(&message, "To run Terraform in this folder without a Terragrunt config, pass --%s.", OPT_TERRAGRUNT_ALLOW_MISSING_CONFIG)
+	return message.String()
+}
+
+func (err MissingTerragruntConfig) Is(target error) bool {
+	return target == errors.ErrConfigNotFound
+}
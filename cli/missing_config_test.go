@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeMissingConfigTestFile(t *testing.T, path string, contents string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadTerragruntConfigMissingConfig(t *testing.T) {
+	t.Parallel()
+
+	rootPath := filepath.ToSlash(tmpDir(t))
+	defer os.RemoveAll(rootPath)
+
+	terragruntBlock := "terragrunt = {\n}\n"
+	appPath := util.JoinPath(rootPath, "app")
+	writeMissingConfigTestFile(t, util.JoinPath(rootPath, config.DefaultTerragruntConfigPath), terragruntBlock)
+	writeMissingConfigTestFile(t, util.JoinPath(appPath, "terragrunt.tfvars"), terragruntBlock)
+	writeMissingConfigTestFile(t, util.JoinPath(appPath, "region.tfvars"), "region = \"us-east-1\"\n")
+	writeMissingConfigTestFile(t, util.JoinPath(appPath, "vpc", config.DefaultTerragruntConfigPath), terragruntBlock)
+	writeMissingConfigTestFile(t, util.JoinPath(appPath, ".terragrunt-cache", config.DefaultTerragruntConfigPath), terragruntBlock)
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(appPath, config.DefaultTerragruntConfigPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readTerragruntConfig(terragruntOptions)
+	if missingConfigErr, isMissingConfigErr := errors.Unwrap(err).(MissingTerragruntConfig); assert.True(t, isMissingConfigErr, "Expected a MissingTerragruntConfig error but got: %v", err) {
+		assert.False(t, missingConfigErr.FileExists)
+		assert.Equal(t, []string{
+			util.JoinPath(appPath, "terragrunt.tfvars"),
+			util.JoinPath(rootPath, config.DefaultTerragruntConfigPath),
+			util.JoinPath(appPath, "vpc", config.DefaultTerragruntConfigPath),
+		}, missingConfigErr.NearbyConfigPaths)
+		assert.Contains(t, err.Error(), "--"+OPT_TERRAGRUNT_CONFIG)
+	}
+
+	// A terraform.tfvars without a terragrunt block isn't a Terragrunt config either
+	writeMissingConfigTestFile(t, util.JoinPath(appPath, config.DefaultTerragruntConfigPath), "name = \"app\"\n")
+	_, err = readTerragruntConfig(terragruntOptions)
+	if missingConfigErr, isMissingConfigErr := errors.Unwrap(err).(MissingTerragruntConfig); assert.True(t, isMissingConfigErr, "Expected a MissingTerragruntConfig error but got: %v", err) {
+		assert.True(t, missingConfigErr.FileExists)
+	}
+
+	terragruntOptions.AllowMissingConfig = true
+	terragruntConfig, err := readTerragruntConfig(terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, &config.TerragruntConfig{}, terragruntConfig)
+}
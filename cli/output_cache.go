@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The folder in the download dir with the output cache: a folder per module, with one JSON file per output-from call
+const OUTPUT_CACHE_FOLDER = "output-cache"
+
+// The environment variable that sets the OutputCacheTTL, and that Terragrunt passes on to the commands it runs
+const OUTPUT_CACHE_TTL_ENV_VAR = "TERRAGRUNT_OUTPUT_CACHE_TTL"
+
+// The Terraform commands that can change the outputs of a module, so its cached outputs are dropped when one runs
+var TERRAFORM_COMMANDS_THAT_CHANGE_OUTPUTS = []string{
+	CMD_APPLY,
+	CMD_DESTROY,
+	"import",
+	"refresh",
+	"state",
+}
+
+// The environment variables that pick the credentials and region Terraform reads the outputs with. Outputs read with
+// different values of these, or with different IAM roles, are cached separately.
+var OUTPUT_CACHE_ENV_VARS = []string{
+	"AWS_PROFILE",
+	"AWS_ACCESS_KEY_ID",
+	"AWS_REGION",
+	"AWS_DEFAULT_REGION",
+}
+
+// How long to wait between checks of the lock file of an output cache entry that another Terragrunt process holds
+const OUTPUT_CACHE_LOCK_RETRY_INTERVAL = time.Second
+
+// How long to wait for another Terragrunt process to read the outputs of a module before reading them anyway. A lock
+// file older than this was left by a process that died.
+const OUTPUT_CACHE_LOCK_TIMEOUT = 5 * time.Minute
+
+// The outputs read so far in this run, by the key of their cache entry, so a module's outputs are read at most once per
+// run, no matter how many other modules need them
+var outputCache = struct {
+	sync.Mutex
+	outputs map[string]string
+}{outputs: map[string]string{}}
+
+// A lock per cache entry, so that when several modules of a stack read the outputs of the same module at once, only the
+// first runs Terraform, and the others wait for its outputs
+var outputCacheLocks = util.KeyedMutex{}
+
+// The outputs of a module, as stored in a file of the output cache
+type outputCacheEntry struct {
+	ConfigPath string    `json:"config_path"`
+	OutputName string    `json:"output_name"`
+	WrittenAt  time.Time `json:"written_at"`
+	Output     string    `json:"output"`
+}
+
+// Return the output of 'terraform output -json' (followed by the output name, if any) in the module of the given
+// options, running it only if it's not in the cache yet. Every output is cached for the rest of the run, and if
+// OutputCacheTTL is set, on disk too, for that long, so that a stack in which many modules read the outputs of the same
+// module, such as a VPC, doesn't run Terraform for each of them. Concurrent calls for the same outputs, whether in this
+// process or, with the disk cache, in others, wait for the first one rather than each running Terraform.
+func getModuleOutputs(moduleOptions *options.TerragruntOptions, outputName string, now time.Time) (string, error) {
+	key := outputCacheKey(moduleOptions, outputName)
+	outputCacheLocks.Lock(key)
+	defer outputCacheLocks.Unlock(key)
+
+	outputCache.Lock()
+	output, found := outputCache.outputs[key]
+	outputCache.Unlock()
+	if found {
+		moduleOptions.Logger.Printf("Using the outputs of the module in %s read earlier in this run", filepath.Dir(moduleOptions.TerragruntConfigPath))
+		return output, nil
+	}
+
+	entryPath := outputCacheEntryPath(moduleOptions, outputName)
+	if moduleOptions.OutputCacheTTL > 0 {
+		if output, found := readOutputsCachedOnDisk(moduleOptions, key, entryPath, now); found {
+			return output, nil
+		}
+
+		unlock := lockOutputCacheEntry(moduleOptions, entryPath)
+		defer unlock()
+
+		// Another process may have read the outputs while we waited for the lock
+		if output, found := readOutputsCachedOnDisk(moduleOptions, key, entryPath, now); found {
+			return output, nil
+		}
+	}
+
+	var stdout bytes.Buffer
+	moduleOptions.Writer = &stdout
+	if err := moduleOptions.RunTerragrunt(moduleOptions); err != nil {
+		return "", err
+	}
+	output = stdout.String()
+
+	storeOutputsForRun(key, output)
+	if moduleOptions.OutputCacheTTL > 0 {
+		entry := outputCacheEntry{ConfigPath: moduleOptions.TerragruntConfigPath, OutputName: outputName, WrittenAt: now, Output: output}
+		if err := writeOutputCacheEntry(entryPath, entry); err != nil {
+			moduleOptions.Logger.Printf("WARNING: failed to cache the outputs of the module in %s: %v", filepath.Dir(moduleOptions.TerragruntConfigPath), err)
+		}
+	}
+
+	return output, nil
+}
+
+// Return the outputs in the given entry of the disk cache, and true, if they're younger than the OutputCacheTTL
+func readOutputsCachedOnDisk(moduleOptions *options.TerragruntOptions, key string, entryPath string, now time.Time) (string, bool) {
+	entry, err := readOutputCacheEntry(entryPath)
+	if err != nil || now.Sub(entry.WrittenAt) >= moduleOptions.OutputCacheTTL {
+		return "", false
+	}
+
+	moduleOptions.Logger.Printf("Using the outputs of the module in %s cached at %s", filepath.Dir(moduleOptions.TerragruntConfigPath), entry.WrittenAt.Format(time.RFC3339))
+	storeOutputsForRun(key, entry.Output)
+	return entry.Output, true
+}
+
+// Take the lock file of the given entry of the disk cache, waiting up to OUTPUT_CACHE_LOCK_TIMEOUT for another process
+// that holds it, such as the hook of another module in the same stack, and return a function that releases it. The
+// cache only saves time, so if the lock can't be taken, this logs a warning, and the outputs are read without it.
+func lockOutputCacheEntry(terragruntOptions *options.TerragruntOptions, entryPath string) func() {
+	noop := func() {}
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0700); err != nil {
+		terragruntOptions.Logger.Printf("WARNING: failed to create the output cache folder %s: %v", filepath.Dir(entryPath), err)
+		return noop
+	}
+
+	lock := fileRunLock{Path: entryPath + ".lock"}
+	deadline := time.Now().Add(OUTPUT_CACHE_LOCK_TIMEOUT)
+	for {
+		acquired, _, err := lock.TryAcquire(getRunLockInfo(terragruntOptions, time.Now()), terragruntOptions)
+		if err != nil {
+			terragruntOptions.Logger.Printf("WARNING: failed to take the output cache lock %s: %v", lock, err)
+			return noop
+		}
+		if acquired {
+			return func() {
+				if err := lock.Release(terragruntOptions); err != nil {
+					terragruntOptions.Logger.Printf("WARNING: failed to release the output cache lock %s: %v", lock, err)
+				}
+			}
+		}
+
+		if info, err := os.Stat(lock.Path); err == nil && time.Since(info.ModTime()) > OUTPUT_CACHE_LOCK_TIMEOUT {
+			terragruntOptions.Logger.Printf("Deleting the output cache lock %s, which is older than %s", lock, OUTPUT_CACHE_LOCK_TIMEOUT)
+			os.Remove(lock.Path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			terragruntOptions.Logger.Printf("WARNING: timed out waiting for the output cache lock %s, so reading the outputs without it", lock)
+			return noop
+		}
+		if err := util.SleepWithContext(terragruntOptions.GetContext(), OUTPUT_CACHE_LOCK_RETRY_INTERVAL); err != nil {
+			return noop
+		}
+	}
+}
+
+// If the command in the given options can change the outputs of its module, drop the cached outputs of the module, both
+// the ones read in this run and the ones on disk
+func invalidateOutputCacheIfNecessary(terragruntOptions *options.TerragruntOptions) {
+	if !util.ListContainsElement(TERRAFORM_COMMANDS_THAT_CHANGE_OUTPUTS, firstArg(terragruntOptions.TerraformCliArgs)) {
+		return
+	}
+
+	moduleKey := outputCacheModuleKey(terragruntOptions.TerragruntConfigPath)
+	outputCache.Lock()
+	for key := range outputCache.outputs {
+		if strings.HasPrefix(key, moduleKey+"/") {
+			delete(outputCache.outputs, key)
+		}
+	}
+	outputCache.Unlock()
+
+	moduleCacheDir := filepath.Join(terragruntOptions.DownloadDir, OUTPUT_CACHE_FOLDER, outputCacheModuleKey(terragruntOptions.TerragruntConfigPath))
+	if err := os.RemoveAll(moduleCacheDir); err != nil {
+		terragruntOptions.Logger.Printf("WARNING: failed to delete the cached outputs of the module in %s: %v", filepath.Dir(terragruntOptions.TerragruntConfigPath), err)
+	}
+}
+
+// Return the key of the cached outputs of the module of the given options: a folder per module, and a file per output
+// name, with an empty name standing for all the outputs, and per set of credentials, as given by the IAM roles and the
+// OUTPUT_CACHE_ENV_VARS, so outputs read with other credentials are never reused
+func outputCacheKey(terragruntOptions *options.TerragruntOptions, outputName string) string {
+	credentials := []string{}
+	for _, envVar := range OUTPUT_CACHE_ENV_VARS {
+		credentials = append(credentials, envVar+"="+terragruntOptions.Env[envVar])
+	}
+	credentials = append(credentials, terragruntOptions.IamRoleChain()...)
+
+	return outputCacheModuleKey(terragruntOptions.TerragruntConfigPath) + "/" + util.EncodeBase64Sha1(outputName+"\n"+strings.Join(credentials, "\n"))
+}
+
+// Return the key of the folder with the cached outputs of the module with the given config
+func outputCacheModuleKey(terragruntConfigPath string) string {
+	canonicalConfigPath, err := util.CanonicalPath(terragruntConfigPath, ".")
+	if err != nil {
+		canonicalConfigPath = util.CleanPath(terragruntConfigPath)
+	}
+	return util.EncodeBase64Sha1(canonicalConfigPath)
+}
+
+// Return the path of the file in the output cache for the given output of the module of the given options
+func outputCacheEntryPath(terragruntOptions *options.TerragruntOptions, outputName string) string {
+	return filepath.Join(terragruntOptions.DownloadDir, OUTPUT_CACHE_FOLDER, filepath.FromSlash(outputCacheKey(terragruntOptions, outputName))+".json")
+}
+
+func storeOutputsForRun(key string, output string) {
+	outputCache.Lock()
+	defer outputCache.Unlock()
+	outputCache.outputs[key] = output
+}
+
+func readOutputCacheEntry(entryPath string) (*outputCacheEntry, error) {
+	contents, err := ioutil.ReadFile(entryPath)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	entry := &outputCacheEntry{}
+	if err := json.Unmarshal(contents, entry); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return entry, nil
+}
+
+// Write the given entry to the given path. The outputs may include sensitive values, so only the user can read it.
+func writeOutputCacheEntry(entryPath string, entry outputCacheEntry) error {
+	contents, err := json.Marshal(entry)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return errors.WithStackTrace(ioutil.WriteFile(entryPath, contents, 0600))
+}
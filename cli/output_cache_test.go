@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// Create options for the module in the given folder that count the times Terraform runs and print the given output
+func createOutputCacheTestOptions(t *testing.T, moduleDir string, output string, runs *int) *options.TerragruntOptions {
+	terragruntOptions := createOutputFromTestOptions(t, moduleDir, []string{"output", "-json"})
+	terragruntOptions.DownloadDir = util.JoinPath(moduleDir, ".terragrunt-cache")
+	terragruntOptions.OutputCacheTTL = time.Hour
+	terragruntOptions.RunTerragrunt = func(terragruntOptions *options.TerragruntOptions) error {
+		*runs++
+		fmt.Fprint(terragruntOptions.Writer, output)
+		return nil
+	}
+	return terragruntOptions
+}
+
+func TestGetModuleOutputsCachesOutputs(t *testing.T) {
+	t.Parallel()
+
+	moduleDir := tmpDir(t)
+	defer os.RemoveAll(moduleDir)
+	writeSourceHashTestFile(t, moduleDir, config.DefaultTerragruntConfigPath, "terragrunt = {}")
+
+	now := time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+	runs := 0
+
+	output, err := getModuleOutputs(createOutputCacheTestOptions(t, moduleDir, `"vpc-123"`, &runs), "vpc_id", now)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, `"vpc-123"`, output)
+	assert.Equal(t, 1, runs)
+
+	output, err = getModuleOutputs(createOutputCacheTestOptions(t, moduleDir, `"vpc-456"`, &runs), "vpc_id", now)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, `"vpc-123"`, output)
+	assert.Equal(t, 1, runs)
+
+	output, err = getModuleOutputs(createOutputCacheTestOptions(t, moduleDir, `{"vpc_id": "vpc-456"}`, &runs), "", now)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, `{"vpc_id": "vpc-456"}`, output)
+	assert.Equal(t, 2, runs)
+}
+
+func TestGetModuleOutputsReadsConcurrentlyOnlyOnce(t *testing.T) {
+	t.Parallel()
+
+	moduleDir := tmpDir(t)
+	defer os.RemoveAll(moduleDir)
+	writeSourceHashTestFile(t, moduleDir, config.DefaultTerragruntConfigPath, "terragrunt = {}")
+
+	now := time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+	runsMutex := sync.Mutex{}
+	runs := 0
+
+	outputs := make(chan string, 10)
+	waitGroup := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		terragruntOptions := createOutputCacheTestOptions(t, moduleDir, "", nil)
+		terragruntOptions.RunTerragrunt = func(terragruntOptions *options.TerragruntOptions) error {
+			runsMutex.Lock()
+			runs++
+			runsMutex.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprint(terragruntOptions.Writer, `"vpc-123"`)
+			return nil
+		}
+
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			output, err := getModuleOutputs(terragruntOptions, "vpc_id", now)
+			assert.Nil(t, err, "Unexpected error: %v", err)
+			outputs <- output
+		}()
+	}
+	waitGroup.Wait()
+	close(outputs)
+
+	for output := range outputs {
+		assert.Equal(t, `"vpc-123"`, output)
+	}
+	assert.Equal(t, 1, runs)
+}
+
+func TestGetModuleOutputsCachesOutputsPerCredentials(t *testing.T) {
+	t.Parallel()
+
+	moduleDir := tmpDir(t)
+	defer os.RemoveAll(moduleDir)
+	writeSourceHashTestFile(t, moduleDir, config.DefaultTerragruntConfigPath, "terragrunt = {}")
+
+	now := time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+	runs := 0
+
+	output, err := getModuleOutputs(createOutputCacheTestOptions(t, moduleDir, `"vpc-123"`, &runs), "vpc_id", now)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, `"vpc-123"`, output)
+
+	roleOptions := createOutputCacheTestOptions(t, moduleDir, `"vpc-456"`, &runs)
+	roleOptions.IamRole = "arn:aws:iam::123456789012:role/other"
+	output, err = getModuleOutputs(roleOptions, "vpc_id", now)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, `"vpc-456"`, output)
+
+	profileOptions := createOutputCacheTestOptions(t, moduleDir, `"vpc-789"`, &runs)
+	profileOptions.Env["AWS_PROFILE"] = "other"
+	output, err = getModuleOutputs(profileOptions, "vpc_id", now)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, `"vpc-789"`, output)
+
+	assert.Equal(t, 3, runs)
+}
+
+func TestGetModuleOutputsReadsOutputsCachedOnDisk(t *testing.T) {
+	t.Parallel()
+
+	moduleDir := tmpDir(t)
+	defer os.RemoveAll(moduleDir)
+	writeSourceHashTestFile(t, moduleDir, config.DefaultTerragruntConfigPath, "terragrunt = {}")
+
+	writtenAt := time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+	runs := 0
+	terragruntOptions := createOutputCacheTestOptions(t, moduleDir, `"vpc-456"`, &runs)
+
+	entry := outputCacheEntry{ConfigPath: terragruntOptions.TerragruntConfigPath, OutputName: "vpc_id", WrittenAt: writtenAt, Output: `"vpc-123"`}
+	if err := writeOutputCacheEntry(outputCacheEntryPath(terragruntOptions, "vpc_id"), entry); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := getModuleOutputs(terragruntOptions, "vpc_id", writtenAt.Add(30*time.Minute))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, `"vpc-123"`, output)
+	assert.Equal(t, 0, runs)
+
+	// Forget the outputs read in this run, so the next call has to look at the disk cache again
+	outputCache.Lock()
+	delete(outputCache.outputs, outputCacheKey(terragruntOptions, "vpc_id"))
+	outputCache.Unlock()
+
+	output, err = getModuleOutputs(createOutputCacheTestOptions(t, moduleDir, `"vpc-456"`, &runs), "vpc_id", writtenAt.Add(2*time.Hour))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, `"vpc-456"`, output)
+	assert.Equal(t, 1, runs)
+}
+
+func TestInvalidateOutputCacheIfNecessary(t *testing.T) {
+	t.Parallel()
+
+	moduleDir := tmpDir(t)
+	defer os.RemoveAll(moduleDir)
+	writeSourceHashTestFile(t, moduleDir, config.DefaultTerragruntConfigPath, "terragrunt = {}")
+
+	now := time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+	runs := 0
+	terragruntOptions := createOutputCacheTestOptions(t, moduleDir, `"vpc-123"`, &runs)
+	entryPath := outputCacheEntryPath(terragruntOptions, "vpc_id")
+
+	if _, err := getModuleOutputs(terragruntOptions, "vpc_id", now); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, util.FileExists(entryPath), "Expected the outputs to be cached in %s", entryPath)
+
+	planOptions := createOutputFromTestOptions(t, moduleDir, []string{CMD_PLAN})
+	planOptions.DownloadDir = terragruntOptions.DownloadDir
+	invalidateOutputCacheIfNecessary(planOptions)
+	assert.True(t, util.FileExists(entryPath), "Expected plan to keep the outputs cached in %s", entryPath)
+
+	applyOptions := createOutputFromTestOptions(t, moduleDir, []string{CMD_APPLY, "-auto-approve"})
+	applyOptions.DownloadDir = terragruntOptions.DownloadDir
+	invalidateOutputCacheIfNecessary(applyOptions)
+	assert.False(t, util.FileExists(entryPath), "Expected apply to delete the outputs cached in %s", entryPath)
+
+	output, err := getModuleOutputs(createOutputCacheTestOptions(t, moduleDir, `"vpc-456"`, &runs), "vpc_id", now)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, `"vpc-456"`, output)
+	assert.Equal(t, 2, runs)
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const CMD_OUTPUT_FROM = "output-from"
+
+// Print the outputs of the module at the path passed after the output-from command as JSON, as if running
+// 'terragrunt output -json' in that module's folder. This reads the module's Terragrunt config, so it uses the same
+// source, remote state backend, IAM roles, and so on, as any other command run in that folder. If an output name is
+// passed after the path, only that output is printed.
+func outputFrom(terragruntOptions *options.TerragruntOptions) error {
+	modulePath, outputName, err := parseOutputFromArgs(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	moduleOptions, err := createOutputFromOptions(modulePath, outputName, terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	terragruntOptions.Logger.Printf("Reading the outputs of the module in %s", moduleOptions.WorkingDir)
+	output, err := getModuleOutputs(moduleOptions, outputName, time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(terragruntOptions.Writer, output)
+	return errors.WithStackTrace(err)
+}
+
+// Return the path and the optional output name passed as args after the output-from command
+func parseOutputFromArgs(terragruntOptions *options.TerragruntOptions) (string, string, error) {
+	args := []string{}
+	for _, arg := range terragruntOptions.TerraformCliArgs[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			args = append(args, arg)
+		}
+	}
+
+	switch len(args) {
+	case 1:
+		return args[0], "", nil
+	case 2:
+		return args[0], args[1], nil
+	default:
+		return "", "", errors.WithStackTrace(InvalidOutputFromArgs(terragruntOptions.TerraformCliArgs[1:]))
+	}
+}
+
+// Create the options to run 'terraform output -json' in the module at the given path, which is either the folder of
+// the module or its Terragrunt config file, relative to the working dir
+func createOutputFromOptions(modulePath string, outputName string, terragruntOptions *options.TerragruntOptions) (*options.TerragruntOptions, error) {
+	canonicalPath, err := util.CanonicalPath(modulePath, terragruntOptions.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	configPath := canonicalPath
+	if util.IsDir(canonicalPath) {
+		configPath = config.DefaultConfigPath(canonicalPath)
+	}
+	if !util.FileExists(configPath) {
+		return nil, errors.WithStackTrace(OutputFromModuleNotFound(modulePath))
+	}
+
+	moduleOptions := terragruntOptions.Clone(configPath)
+	moduleOptions.TerraformCliArgs = []string{"output", "-json"}
+	if outputName != "" {
+		moduleOptions.AppendTerraformCliArgs(outputName)
+	}
+
+	return moduleOptions, nil
+}
+
+// Custom error types
+
+type InvalidOutputFromArgs []string
+
+func (args InvalidOutputFromArgs) Error() string {
+	return fmt.Sprintf("The %s command takes the path of a module, optionally followed by the name of an output, but got: %v", CMD_OUTPUT_FROM, []string(args))
+}
+
+type OutputFromModuleNotFound string
+
+func (path OutputFromModuleNotFound) Error() string {
+	return fmt.Sprintf("Could not find a Terragrunt config for the module at %s", string(path))
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func createOutputFromTestOptions(t *testing.T, workingDir string, args []string) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(workingDir, config.DefaultTerragruntConfigPath))
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.WorkingDir = workingDir
+	terragruntOptions.TerraformCliArgs = args
+	return terragruntOptions
+}
+
+func TestParseOutputFromArgs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args               []string
+		expectedPath       string
+		expectedOutputName string
+		expectErr          bool
+	}{
+		{[]string{CMD_OUTPUT_FROM, "../vpc"}, "../vpc", "", false},
+		{[]string{CMD_OUTPUT_FROM, "../vpc", "vpc_id"}, "../vpc", "vpc_id", false},
+		{[]string{CMD_OUTPUT_FROM}, "", "", true},
+		{[]string{CMD_OUTPUT_FROM, "../vpc", "vpc_id", "extra"}, "", "", true},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions := createOutputFromTestOptions(t, "/live/prod/app", testCase.args)
+		path, outputName, err := parseOutputFromArgs(terragruntOptions)
+
+		if testCase.expectErr {
+			_, isInvalidArgsErr := errors.Unwrap(err).(InvalidOutputFromArgs)
+			assert.True(t, isInvalidArgsErr, "Expected an InvalidOutputFromArgs error for args %v but got: %v", testCase.args, err)
+		} else {
+			assert.Nil(t, err, "Unexpected error for args %v: %v", testCase.args, err)
+			assert.Equal(t, testCase.expectedPath, path, "For args %v", testCase.args)
+			assert.Equal(t, testCase.expectedOutputName, outputName, "For args %v", testCase.args)
+		}
+	}
+}
+
+func TestCreateOutputFromOptions(t *testing.T) {
+	t.Parallel()
+
+	rootDir := tmpDir(t)
+	defer os.RemoveAll(rootDir)
+	writeSourceHashTestFile(t, rootDir, "vpc/"+config.DefaultTerragruntConfigPath, "terragrunt = {}")
+
+	appDir := util.JoinPath(rootDir, "app")
+	expectedConfigPath := util.JoinPath(rootDir, "vpc", config.DefaultTerragruntConfigPath)
+
+	for _, modulePath := range []string{"../vpc", "../vpc/" + config.DefaultTerragruntConfigPath} {
+		terragruntOptions := createOutputFromTestOptions(t, appDir, []string{CMD_OUTPUT_FROM, modulePath, "vpc_id"})
+
+		moduleOptions, err := createOutputFromOptions(modulePath, "vpc_id", terragruntOptions)
+		assert.Nil(t, err, "Unexpected error for path %s: %v", modulePath, err)
+		assert.Equal(t, expectedConfigPath, moduleOptions.TerragruntConfigPath, "For path %s", modulePath)
+		assert.Equal(t, util.JoinPath(rootDir, "vpc"), moduleOptions.WorkingDir, "For path %s", modulePath)
+		assert.Equal(t, []string{"output", "-json", "vpc_id"}, moduleOptions.TerraformCliArgs, "For path %s", modulePath)
+	}
+}
+
+func TestCreateOutputFromOptionsModuleNotFound(t *testing.T) {
+	t.Parallel()
+
+	rootDir := tmpDir(t)
+	defer os.RemoveAll(rootDir)
+
+	terragruntOptions := createOutputFromTestOptions(t, rootDir, []string{CMD_OUTPUT_FROM, "vpc"})
+
+	_, err := createOutputFromOptions("vpc", "", terragruntOptions)
+	_, isNotFoundErr := errors.Unwrap(err).(OutputFromModuleNotFound)
+	assert.True(t, isNotFoundErr, "Expected an OutputFromModuleNotFound error but got: %v", err)
+}
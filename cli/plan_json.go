@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/hashicorp/go-version"
+)
+
+const CMD_PLAN = "plan"
+
+// When Terragrunt needs to inspect a plan (e.g. to check it against policies or estimate its cost) and the user did not
+// pass in -out, we write the plan to this file in the working dir
+const TERRAGRUNT_PLAN_FILE = ".terragrunt-plan"
+
+// The JSON version of the plan, as returned by 'terraform show -json', is written to this file in the working dir
+const TERRAGRUNT_PLAN_JSON_FILE = ".terragrunt-plan.json"
+
+// The 'terraform show -json' command, which we use to convert a plan to JSON, was added in Terraform 0.12.0
+const MINIMUM_TERRAFORM_VERSION_FOR_PLAN_JSON = "v0.12.0"
+
+// The largest plan, as JSON, that Terragrunt reads into memory to hand off to the policy checks and cost estimation
+const MAX_PLAN_JSON_BYTES = 100 * 1024 * 1024
+
+// Make sure the plan command in terragruntOptions writes its plan to a file, adding an -out argument if the user
+// didn't specify one. Return the path to the plan file, relative to the working dir.
+func ensurePlanFileArg(terragruntOptions *options.TerragruntOptions) string {
+	if planFile := getPlanFileFromArgs(terragruntOptions.TerraformCliArgs); planFile != "" {
+		return planFile
+	}
+
+	terragruntOptions.InsertTerraformCliArgs(fmt.Sprintf("-out=%s", TERRAGRUNT_PLAN_FILE))
+	return TERRAGRUNT_PLAN_FILE
+}
+
+// Return the value of the -out argument in the given list of args (which may be specified either as -out=FILE or
+// -out FILE) or an empty string if there is no such argument
+func getPlanFileFromArgs(args []string) string {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-out=") {
+			return strings.TrimPrefix(arg, "-out=")
+		}
+		if arg == "-out" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// Convert the plan in the given plan file to JSON using 'terraform show -json', write the JSON into the working dir,
+// and return the path to the JSON file
+func exportPlanAsJson(planFile string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	minimumVersion, err := version.NewVersion(MINIMUM_TERRAFORM_VERSION_FOR_PLAN_JSON)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	if terragruntOptions.TerraformVersion != nil && terragruntOptions.TerraformVersion.LessThan(minimumVersion) {
+		return "", errors.WithStackTrace(PlanJsonNotSupported{CurrentVersion: terragruntOptions.TerraformVersion})
+	}
+
+	output, err := shell.RunTerraformCommandAndCaptureOutput(terragruntOptions, shell.CaptureOptions{MaxBytes: MAX_PLAN_JSON_BYTES}, "show", "-json", planFile)
+	if err != nil {
+		return "", err
+	}
+	if output.Truncated {
+		return "", errors.WithStackTrace(PlanJsonTooLarge{PlanFile: planFile, MaxBytes: MAX_PLAN_JSON_BYTES})
+	}
+	planJson := output.Stdout
+
+	planJsonPath, err := filepath.Abs(util.JoinPath(terragruntOptions.WorkingDir, TERRAGRUNT_PLAN_JSON_FILE))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	if err := ioutil.WriteFile(planJsonPath, []byte(planJson), 0644); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return filepath.ToSlash(planJsonPath), nil
+}
+
+// Returns true if the given Terragrunt config has any steps (e.g. policy checks) that need to inspect the plan after
+// 'terraform plan' runs
+func needsPlanFile(terragruntConfig *config.TerragruntConfig) bool {
+	return terragruntConfig.Policy != nil || terragruntConfig.CostEstimation != nil
+}
+
+// Convert the plan in the given plan file to JSON and hand it off to the steps configured in the Terragrunt config:
+// first the cost estimation command, so an estimate is available even if the plan violates a policy, and then the
+// policy checks.
+func processPlan(planFile string, terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	planJsonPath, err := exportPlanAsJson(planFile, terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	if terragruntConfig.CostEstimation != nil {
+		if err := runCostEstimation(planJsonPath, terragruntOptions, terragruntConfig.CostEstimation); err != nil {
+			return err
+		}
+	}
+
+	if terragruntConfig.Policy != nil {
+		if err := checkPlanAgainstPolicies(planJsonPath, terragruntOptions, terragruntConfig.Policy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Custom error types
+
+type PlanJsonNotSupported struct {
+	CurrentVersion *version.Version
+}
+
+func (err PlanJsonNotSupported) Error() string {
+	return fmt.Sprintf("Terragrunt needs to convert the plan to JSON, which requires Terraform %s or newer, but the currently installed version is %s.", MINIMUM_TERRAFORM_VERSION_FOR_PLAN_JSON, err.CurrentVersion.String())
+}
+
+type PlanJsonTooLarge struct {
+	PlanFile string
+	MaxBytes int
+}
+
+func (err PlanJsonTooLarge) Error() string {
+	return fmt.Sprintf("The plan in %s is larger than %d bytes as JSON, which is the most Terragrunt can check against policies or estimate the cost of.", err.PlanFile, err.MaxBytes)
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The structure of the JSON output of the 'opa eval --format json' command
+type opaEvalOutput struct {
+	Result []struct {
+		Expressions []struct {
+			Value interface{} `json:"value"`
+			Text  string      `json:"text"`
+		} `json:"expressions"`
+	} `json:"result"`
+}
+
+// Run the JSON plan at the given path through the OPA policies configured in the policy block of the Terragrunt
+// config. Return a PolicyViolations error if the policy query returns any results.
+func checkPlanAgainstPolicies(planJsonPath string, terragruntOptions *options.TerragruntOptions, policyConfig *config.PolicyConfig) error {
+	terragruntOptions.Logger.Printf("Checking plan in %s against the policies in %v", terragruntOptions.WorkingDir, policyConfig.Paths)
+
+	args := []string{"eval", "--format", "json", "--input", planJsonPath}
+	for _, policyPath := range policyConfig.Paths {
+		canonicalPolicyPath, err := util.CanonicalPath(policyPath, filepath.Dir(terragruntOptions.TerragruntConfigPath))
+		if err != nil {
+			return err
+		}
+		args = append(args, "--data", canonicalPolicyPath)
+	}
+	args = append(args, policyConfig.Query)
+
+	// Only stdout is the result of the query. Anything opa writes to stderr, such as warnings, must not end up in it.
+	output, err := shell.RunCommandAndCaptureOutput(terragruntOptions, shell.CaptureOptions{}, policyConfig.OpaPath, args...)
+	if err != nil {
+		errOutput := strings.TrimSpace(output.Stderr)
+		if errOutput == "" {
+			errOutput = err.Error()
+		}
+		return errors.WithStackTrace(OpaEvalError{ModulePath: filepath.Dir(terragruntOptions.TerragruntConfigPath), Output: errOutput, Underlying: err})
+	}
+
+	violations, err := parseOpaEvalOutput(output.Stdout)
+	if err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		for _, violation := range violations {
+			terragruntOptions.Logger.Printf("Policy violation: %s", violation)
+		}
+		return errors.WithStackTrace(PolicyViolations{ModulePath: filepath.Dir(terragruntOptions.TerragruntConfigPath), Violations: violations})
+	}
+
+	terragruntOptions.Logger.Printf("Plan in %s passed all policy checks", terragruntOptions.WorkingDir)
+	return nil
+}
+
+// Parse the output of 'opa eval --format json' and return the list of violations it contains. Each value returned by
+// the query counts as a violation: for a set or array (e.g. a typical 'deny' rule), each item is one violation; for any
+// other defined value that isn't false (e.g. a 'deny' rule that returns a single message), the value itself is one
+// violation.
+func parseOpaEvalOutput(output string) ([]string, error) {
+	evalOutput := opaEvalOutput{}
+	if err := json.Unmarshal([]byte(output), &evalOutput); err != nil {
+		return nil, errors.WithStackTrace(InvalidOpaOutput{Output: output, Underlying: err})
+	}
+
+	violations := []string{}
+	for _, result := range evalOutput.Result {
+		for _, expression := range result.Expressions {
+			switch value := expression.Value.(type) {
+			case nil:
+				continue
+			case bool:
+				if value {
+					violations = append(violations, fmt.Sprintf("%s is true", expression.Text))
+				}
+			case []interface{}:
+				for _, item := range value {
+					violations = append(violations, formatPolicyViolation(item))
+				}
+			default:
+				violations = append(violations, formatPolicyViolation(value))
+			}
+		}
+	}
+
+	sort.Strings(violations)
+	return violations, nil
+}
+
+// Convert a single value returned by an OPA query to a human-readable string
+func formatPolicyViolation(value interface{}) string {
+	if str, isString := value.(string); isString {
+		return str
+	}
+
+	asJson, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(asJson)
+}
+
+// Custom error types
+
+type PolicyViolations struct {
+	ModulePath string
+	Violations []string
+}
+
+func (err PolicyViolations) Error() string {
+	return fmt.Sprintf("The plan for module %s violates %d policies:\n  - %s", err.ModulePath, len(err.Violations), strings.Join(err.Violations, "\n  - "))
+}
+
+func (err PolicyViolations) Is(target error) bool {
+	return target == errors.ErrPolicyViolation
+}
+
+type OpaEvalError struct {
+	ModulePath string
+	Output     string
+	Underlying error
+}
+
+func (err OpaEvalError) Error() string {
+	return fmt.Sprintf("Could not check the plan for module %s against the policies with opa eval: %s", err.ModulePath, err.Output)
+}
+
+func (err OpaEvalError) Unwrap() error {
+	return err.Underlying
+}
+
+type InvalidOpaOutput struct {
+	Output     string
+	Underlying error
+}
+
+func (err InvalidOpaOutput) Error() string {
+	return fmt.Sprintf("Unable to parse the output of opa eval as JSON: %v. Output:\n%s", err.Underlying, err.Output)
+}
+
+func (err InvalidOpaOutput) Unwrap() error {
+	return err.Underlying
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPlanFileFromArgs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{}, ""},
+		{[]string{"plan"}, ""},
+		{[]string{"plan", "-input=false"}, ""},
+		{[]string{"plan", "-out=foo.plan"}, "foo.plan"},
+		{[]string{"plan", "-input=false", "-out", "foo.plan"}, "foo.plan"},
+		{[]string{"plan", "-out"}, ""},
+	}
+
+	for _, testCase := range testCases {
+		actual := getPlanFileFromArgs(testCase.args)
+		assert.Equal(t, testCase.expected, actual, "For args %v", testCase.args)
+	}
+}
+
+func TestParseOpaEvalOutput(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		output   string
+		expected []string
+	}{
+		{`{}`, []string{}},
+		{`{"result":[{"expressions":[{"value":[],"text":"data.terraform.deny"}]}]}`, []string{}},
+		{`{"result":[{"expressions":[{"value":false,"text":"data.terraform.deny"}]}]}`, []string{}},
+		{`{"result":[{"expressions":[{"value":true,"text":"data.terraform.deny"}]}]}`, []string{"data.terraform.deny is true"}},
+		{`{"result":[{"expressions":[{"value":"single message","text":"data.terraform.deny"}]}]}`, []string{"single message"}},
+		{`{"result":[{"expressions":[{"value":["b msg","a msg"],"text":"data.terraform.deny"}]}]}`, []string{"a msg", "b msg"}},
+		{`{"result":[{"expressions":[{"value":[{"rule":"no-open-sg"}],"text":"data.terraform.deny"}]}]}`, []string{`{"rule":"no-open-sg"}`}},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := parseOpaEvalOutput(testCase.output)
+		if assert.Nil(t, err, "Unexpected error for output %s: %v", testCase.output, err) {
+			assert.Equal(t, testCase.expected, actual, "For output %s", testCase.output)
+		}
+	}
+}
+
+func TestParseOpaEvalOutputInvalidJson(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseOpaEvalOutput("not json")
+	assert.Error(t, err)
+}
+
+// Write an sh script to the given folder that stands in for opa: it ignores its args, writes the given stdout and stderr,
+// and exits with the given code. Return the path of the script and options for a module in the same folder.
+func createFakeOpa(t *testing.T, dir string, stdout string, stderr string, exitCode int) (string, *options.TerragruntOptions) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake opa in this test is an sh script")
+	}
+
+	opaPath := util.JoinPath(dir, "opa")
+	script := fmt.Sprintf("#!/bin/sh\necho '%s'\necho '%s' >&2\nexit %d\n", stdout, stderr, exitCode)
+	assert.Nil(t, ioutil.WriteFile(opaPath, []byte(script), 0755))
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(dir, config.DefaultTerragruntConfigPath))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	terragruntOptions.WorkingDir = dir
+	return opaPath, terragruntOptions
+}
+
+func TestCheckPlanAgainstPoliciesIgnoresOpaStderr(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "policy-check-test")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	defer os.RemoveAll(dir)
+
+	stdout := `{"result":[{"expressions":[{"value":["open security group"],"text":"data.terraform.deny"}]}]}`
+	opaPath, terragruntOptions := createFakeOpa(t, dir, stdout, "warning: the rego.v1 import is deprecated", 0)
+	policyConfig := &config.PolicyConfig{Paths: []string{"policies"}, Query: "data.terraform.deny", OpaPath: opaPath}
+
+	err = checkPlanAgainstPolicies(util.JoinPath(dir, "plan.json"), terragruntOptions, policyConfig)
+	assert.True(t, errors.IsError(err, PolicyViolations{ModulePath: dir, Violations: []string{"open security group"}}), "Unexpected error: %v", err)
+}
+
+func TestCheckPlanAgainstPoliciesOpaFails(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "policy-check-test")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	defer os.RemoveAll(dir)
+
+	opaPath, terragruntOptions := createFakeOpa(t, dir, "{}", "policies/main.rego:3: rego_parse_error: unexpected eof token", 2)
+	policyConfig := &config.PolicyConfig{Paths: []string{"policies"}, Query: "data.terraform.deny", OpaPath: opaPath}
+
+	err = checkPlanAgainstPolicies(util.JoinPath(dir, "plan.json"), terragruntOptions, policyConfig)
+	opaEvalError := OpaEvalError{}
+	if assert.True(t, errors.As(err, &opaEvalError), "Unexpected error: %v", err) {
+		assert.Equal(t, "policies/main.rego:3: rego_parse_error: unexpected eof token", opaEvalError.Output)
+	}
+}
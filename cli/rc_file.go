@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/hashicorp/hcl"
+	"github.com/mitchellh/go-homedir"
+)
+
+// The name of the files that set defaults for the Terragrunt options: one in the home dir for the defaults of a user,
+// and one in the working dir, or one of its parents, for the defaults of a repo
+const RC_FILE_NAME = ".terragrunt.rc"
+
+// The environment variables of the options whose environment variable isn't TERRAGRUNT_ plus the option name without
+// its terragrunt- prefix
+var OPT_ENV_VARS = map[string]string{
+	OPT_NON_INTERACTIVE:         "TF_INPUT",
+	OPT_TERRAGRUNT_NO_AUTO_INIT: "TERRAGRUNT_AUTO_INIT",
+}
+
+// The options an rc file can't set, as Terragrunt needs them before it can look for the rc files
+var RC_FILE_UNSUPPORTED_OPTS = []string{OPT_WORKING_DIR}
+
+// Return the given args plus the defaults from the rc files for the options that neither the args nor the environment
+// variables set, and the paths of the rc files the defaults came from. The rc file in the given home dir has the lowest
+// precedence, then the one in the given working dir or the nearest of its parents, then the environment variables, and
+// then the args.
+func addDefaultsFromRcFiles(args []string, workingDir string, homeDir string) ([]string, []string, error) {
+	rcFiles := []string{}
+
+	if homeDir != "" {
+		userRcFile := util.JoinPath(homeDir, RC_FILE_NAME)
+		if util.FileExists(userRcFile) {
+			rcFiles = append(rcFiles, userRcFile)
+		}
+	}
+
+	repoRcFile, err := findRcFileInParentFolders(workingDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if repoRcFile != "" && !util.ListContainsElement(rcFiles, repoRcFile) {
+		rcFiles = append(rcFiles, repoRcFile)
+	}
+
+	defaults := map[string]string{}
+	for _, rcFile := range rcFiles {
+		rcFileDefaults, err := readRcFile(rcFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, value := range rcFileDefaults {
+			defaults[name] = value
+		}
+	}
+
+	names := []string{}
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	argsWithDefaults := util.CloneStringList(args)
+	for _, name := range names {
+		if util.ListContainsElement(args, fmt.Sprintf("--%s", name)) || os.Getenv(optEnvVar(name)) != "" {
+			continue
+		}
+
+		if util.ListContainsElement(ALL_TERRAGRUNT_BOOLEAN_OPTS, name) {
+			// A boolean option is off unless it's set, so an rc file can only turn it on
+			if defaults[name] == "true" {
+				argsWithDefaults = append(argsWithDefaults, fmt.Sprintf("--%s", name))
+			}
+		} else {
+			argsWithDefaults = append(argsWithDefaults, fmt.Sprintf("--%s", name), defaults[name])
+		}
+	}
+
+	return argsWithDefaults, rcFiles, nil
+}
+
+// Return the path of the rc file in the given folder or the nearest of its parents, or an empty string if there is none
+func findRcFileInParentFolders(folder string) (string, error) {
+	currentDir, err := filepath.Abs(folder)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	for i := 0; i < options.DEFAULT_MAX_FOLDERS_TO_CHECK; i++ {
+		rcFile := util.JoinPath(currentDir, RC_FILE_NAME)
+		if util.FileExists(rcFile) {
+			return filepath.ToSlash(rcFile), nil
+		}
+
+		parentDir := filepath.Dir(currentDir)
+		if parentDir == currentDir {
+			return "", nil
+		}
+		currentDir = parentDir
+	}
+
+	return "", nil
+}
+
+// Read the defaults in the rc file at the given path. It's an HCL file that sets options by their names, without the
+// leading dashes, such as terragrunt-tfpath = "terraform-0.11". Boolean options take true or false, and list options,
+// such as terragrunt-iam-roles, take either a comma-separated string or a list. Values that start with ./ or ../ are
+// paths relative to the folder of the rc file, so a repo's rc file works the same in every folder of the repo.
+func readRcFile(path string) (map[string]string, error) {
+	contents, err := util.ReadFileAsString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]interface{}{}
+	if err := hcl.Decode(&settings, contents); err != nil {
+		return nil, errors.WithStackTrace(ErrorParsingRcFile{Path: path, Underlying: err})
+	}
+
+	defaults := map[string]string{}
+	for name, value := range settings {
+		isBooleanOpt := util.ListContainsElement(ALL_TERRAGRUNT_BOOLEAN_OPTS, name)
+		isStringOpt := util.ListContainsElement(ALL_TERRAGRUNT_STRING_OPTS, name)
+		if (!isBooleanOpt && !isStringOpt) || util.ListContainsElement(RC_FILE_UNSUPPORTED_OPTS, name) {
+			return nil, errors.WithStackTrace(UnsupportedRcFileSetting{Path: path, Name: name})
+		}
+
+		defaultValue, err := rcFileValueToString(value, isBooleanOpt)
+		if err != nil {
+			return nil, errors.WithStackTrace(InvalidRcFileSetting{Path: path, Name: name, Underlying: err})
+		}
+		if util.IsLocalModuleSource(defaultValue) {
+			defaultValue = util.JoinPath(filepath.Dir(path), defaultValue)
+		}
+		defaults[name] = defaultValue
+	}
+
+	return defaults, nil
+}
+
+// Convert the given value from an rc file to the string the option would have on the command line
+func rcFileValueToString(value interface{}, isBooleanOpt bool) (string, error) {
+	if isBooleanOpt {
+		if boolValue, isBool := value.(bool); isBool {
+			return fmt.Sprintf("%t", boolValue), nil
+		}
+		return "", fmt.Errorf("expected true or false, but got %v", value)
+	}
+
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case int, int64, float64:
+		return fmt.Sprintf("%v", value), nil
+	case []interface{}:
+		elements := []string{}
+		for _, element := range value {
+			text, isString := element.(string)
+			if !isString {
+				return "", fmt.Errorf("expected a list of strings, but got %v", value)
+			}
+			elements = append(elements, text)
+		}
+		return strings.Join(elements, ","), nil
+	default:
+		return "", fmt.Errorf("expected a string, but got %v", value)
+	}
+}
+
+// Return the name of the environment variable that sets the given option
+func optEnvVar(name string) string {
+	if envVar, hasEnvVar := OPT_ENV_VARS[name]; hasEnvVar {
+		return envVar
+	}
+	return "TERRAGRUNT_" + strings.ToUpper(strings.Replace(strings.TrimPrefix(name, "terragrunt-"), "-", "_", -1))
+}
+
+// Return the home dir of the current user, or an empty string if it can't be found, in which case there's no rc file
+// with the defaults of the user
+func userHomeDir() string {
+	homeDir, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+	return homeDir
+}
+
+// Custom error types
+
+type ErrorParsingRcFile struct {
+	Path       string
+	Underlying error
+}
+
+func (err ErrorParsingRcFile) Error() string {
+	return fmt.Sprintf("Error parsing %s: %v", err.Path, err.Underlying)
+}
+
+func (err ErrorParsingRcFile) Unwrap() error {
+	return err.Underlying
+}
+
+type UnsupportedRcFileSetting struct {
+	Path string
+	Name string
+}
+
+func (err UnsupportedRcFileSetting) Error() string {
+	return fmt.Sprintf("%s sets %s, but only the --terragrunt-xxx options, except --%s, can be set in an rc file", err.Path, err.Name, OPT_WORKING_DIR)
+}
+
+type InvalidRcFileSetting struct {
+	Path       string
+	Name       string
+	Underlying error
+}
+
+func (err InvalidRcFileSetting) Error() string {
+	return fmt.Sprintf("Invalid value for %s in %s: %v", err.Name, err.Path, err.Underlying)
+}
+
+func (err InvalidRcFileSetting) Unwrap() error {
+	return err.Underlying
+}
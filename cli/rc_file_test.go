@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddDefaultsFromRcFiles(t *testing.T) {
+	t.Parallel()
+
+	homeDir := tmpDir(t)
+	defer os.RemoveAll(homeDir)
+	repoDir := tmpDir(t)
+	defer os.RemoveAll(repoDir)
+
+	writeSourceHashTestFile(t, homeDir, RC_FILE_NAME, `
+terragrunt-tfpath = "terraform-0.11"
+terragrunt-download-dir = "/tmp/terragrunt"
+terragrunt-no-progress = true
+terragrunt-fail-fast = false
+`)
+	writeSourceHashTestFile(t, repoDir, RC_FILE_NAME, `
+terragrunt-download-dir = "./.terragrunt-cache"
+terragrunt-iam-roles = ["arn:aws:iam::123:role/a", "arn:aws:iam::456:role/b"]
+terragrunt-download-max-entries = 10
+`)
+	moduleDir := util.JoinPath(repoDir, "live", "app")
+	if err := os.MkdirAll(moduleDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	args, rcFiles, err := addDefaultsFromRcFiles([]string{"plan", "--terragrunt-tfpath", "terraform-0.12"}, moduleDir, homeDir)
+	if !assert.Nil(t, err, "Unexpected error: %v", errors.PrintErrorWithStackTrace(err)) {
+		return
+	}
+
+	expected := []string{
+		"plan", "--terragrunt-tfpath", "terraform-0.12",
+		"--terragrunt-download-dir", util.JoinPath(repoDir, ".terragrunt-cache"),
+		"--terragrunt-download-max-entries", "10",
+		"--terragrunt-iam-roles", "arn:aws:iam::123:role/a,arn:aws:iam::456:role/b",
+		"--terragrunt-no-progress",
+	}
+	assert.Equal(t, expected, args)
+	assert.Equal(t, []string{util.JoinPath(homeDir, RC_FILE_NAME), util.JoinPath(repoDir, RC_FILE_NAME)}, rcFiles)
+}
+
+func TestAddDefaultsFromRcFilesNoRcFiles(t *testing.T) {
+	t.Parallel()
+
+	workingDir := tmpDir(t)
+	defer os.RemoveAll(workingDir)
+
+	args, rcFiles, err := addDefaultsFromRcFiles([]string{"apply"}, workingDir, "")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, []string{"apply"}, args)
+	assert.Empty(t, rcFiles)
+}
+
+func TestReadRcFileInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		contents     string
+		expectedType error
+	}{
+		{`terragrunt-tfpath = "terraform`, ErrorParsingRcFile{}},
+		{`terragrunt-no-such-option = "foo"`, UnsupportedRcFileSetting{}},
+		{`terragrunt-working-dir = "/live"`, UnsupportedRcFileSetting{}},
+		{`terragrunt-no-progress = "yes"`, InvalidRcFileSetting{}},
+		{`terragrunt-tfpath = true`, InvalidRcFileSetting{}},
+	}
+
+	for _, testCase := range testCases {
+		folder := tmpDir(t)
+		defer os.RemoveAll(folder)
+		writeSourceHashTestFile(t, folder, RC_FILE_NAME, testCase.contents)
+
+		_, err := readRcFile(util.JoinPath(folder, RC_FILE_NAME))
+		assert.IsType(t, testCase.expectedType, errors.Unwrap(err), "For rc file %s", testCase.contents)
+	}
+}
+
+func TestOptEnvVar(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "TERRAGRUNT_TFPATH", optEnvVar(OPT_TERRAGRUNT_TFPATH))
+	assert.Equal(t, "TERRAGRUNT_DOWNLOAD_MAX_AGE", optEnvVar(OPT_TERRAGRUNT_DOWNLOAD_MAX_AGE))
+	assert.Equal(t, "TF_INPUT", optEnvVar(OPT_NON_INTERACTIVE))
+}
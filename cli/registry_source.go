@@ -0,0 +1,352 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/hashicorp/go-version"
+)
+
+// The URL scheme for Terraform source URLs that point to a module in a Terraform Registry (e.g.
+// tfr:///terraform-aws-modules/vpc/aws?version=~>2.0)
+const TERRAFORM_REGISTRY_SCHEME = "tfr"
+
+// The registry to use when a Terraform Registry source URL doesn't specify a host (i.e. tfr:///org/module/provider)
+const DEFAULT_TERRAFORM_REGISTRY_HOST = "registry.terraform.io"
+
+// The path every Terraform Registry serves its service discovery document from. See
+// https://www.terraform.io/docs/internals/remote-service-discovery.html
+const TERRAFORM_REGISTRY_DISCOVERY_PATH = "/.well-known/terraform.json"
+
+// The name of the modules service in a Terraform Registry service discovery document
+const TERRAFORM_REGISTRY_MODULES_SERVICE = "modules.v1"
+
+// The environment variable that can contain an API token to use with every Terraform Registry
+const TERRAGRUNT_REGISTRY_TOKEN_ENV_VAR = "TERRAGRUNT_REGISTRY_TOKEN"
+
+// A module in a Terraform Registry, as parsed from a source URL of the form
+// tfr://<host>/<namespace>/<name>/<provider>//<subdir>?version=<constraint>
+type registryModule struct {
+	Host      string
+	Namespace string
+	Name      string
+	Provider  string
+	Subdir    string
+	Version   string
+}
+
+func (module registryModule) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s", module.Host, module.Namespace, module.Name, module.Provider)
+}
+
+// The response body of the versions endpoint of the modules service of a Terraform Registry
+type registryModuleVersions struct {
+	Modules []struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	} `json:"modules"`
+}
+
+// Returns true if the given source URL points to a module in a Terraform Registry
+func isRegistrySource(source string) bool {
+	return strings.HasPrefix(source, TERRAFORM_REGISTRY_SCHEME+"://")
+}
+
+// Resolve the given Terraform Registry source URL (e.g. tfr:///terraform-aws-modules/vpc/aws?version=~>2.0) into the
+// URL the registry says the code of that module is stored at (e.g. a Git URL). To do that, we look up the modules
+// service of the registry, pick the newest version of the module that matches the version constraint in the source
+// URL, and ask the registry for the download URL of that version. Any subdir in the source URL (the part after the
+// double-slash) is appended to the download URL, so the rest of the download code can treat it like any other source.
+func resolveRegistrySource(source string, httpClient *http.Client, terragruntOptions *options.TerragruntOptions) (string, error) {
+	module, err := parseRegistrySource(source)
+	if err != nil {
+		return "", err
+	}
+
+	if err := terragruntOptions.CheckNetworkAccess(fmt.Sprintf("look up the Terraform Registry module %s", module)); err != nil {
+		return "", err
+	}
+
+	modulesServiceUrl, err := getRegistryModulesServiceUrl(module, httpClient, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+
+	moduleVersion, err := getRegistryModuleVersion(module, modulesServiceUrl, httpClient, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+
+	downloadUrl, err := getRegistryModuleDownloadUrl(module, moduleVersion, modulesServiceUrl, httpClient, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+
+	terragruntOptions.Logger.Printf("Resolved Terraform Registry module %s version %s to %s", module, moduleVersion, downloadUrl)
+	return downloadUrl, nil
+}
+
+// Parse the given Terraform Registry source URL into a registryModule
+func parseRegistrySource(source string) (*registryModule, error) {
+	sourceUrl, err := url.Parse(source)
+	if err != nil {
+		return nil, errors.WithStackTrace(MalformedRegistrySource{Source: source})
+	}
+
+	host := sourceUrl.Host
+	if host == "" {
+		host = DEFAULT_TERRAFORM_REGISTRY_HOST
+	}
+
+	modulePath := strings.TrimPrefix(sourceUrl.Path, "/")
+	subdir := ""
+	if pathSplitOnDoubleSlash := strings.SplitN(modulePath, "//", 2); len(pathSplitOnDoubleSlash) > 1 {
+		modulePath = pathSplitOnDoubleSlash[0]
+		subdir = pathSplitOnDoubleSlash[1]
+	}
+
+	moduleAddress := strings.Split(modulePath, "/")
+	if len(moduleAddress) != 3 || moduleAddress[0] == "" || moduleAddress[1] == "" || moduleAddress[2] == "" {
+		return nil, errors.WithStackTrace(MalformedRegistrySource{Source: source})
+	}
+
+	return &registryModule{
+		Host:      host,
+		Namespace: moduleAddress[0],
+		Name:      moduleAddress[1],
+		Provider:  moduleAddress[2],
+		Subdir:    subdir,
+		Version:   sourceUrl.Query().Get("version"),
+	}, nil
+}
+
+// Use the service discovery protocol to find the base URL of the modules service of the registry of the given module
+func getRegistryModulesServiceUrl(module *registryModule, httpClient *http.Client, terragruntOptions *options.TerragruntOptions) (*url.URL, error) {
+	discoveryUrl := &url.URL{Scheme: "https", Host: module.Host, Path: TERRAFORM_REGISTRY_DISCOVERY_PATH}
+
+	services := map[string]interface{}{}
+	if _, err := sendRegistryRequest(discoveryUrl, module, httpClient, terragruntOptions, &services); err != nil {
+		return nil, err
+	}
+
+	modulesService, isString := services[TERRAFORM_REGISTRY_MODULES_SERVICE].(string)
+	if !isString || modulesService == "" {
+		return nil, errors.WithStackTrace(RegistryModulesNotSupported{Host: module.Host})
+	}
+
+	modulesServiceUrl, err := url.Parse(modulesService)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	// The modules service may be a path relative to the discovery document, so resolve it against the discovery URL
+	return discoveryUrl.ResolveReference(modulesServiceUrl), nil
+}
+
+// Return the newest version of the given module in its registry that matches the module's version constraint. If
+// there is no version constraint, return the newest version that isn't a pre-release.
+func getRegistryModuleVersion(module *registryModule, modulesServiceUrl *url.URL, httpClient *http.Client, terragruntOptions *options.TerragruntOptions) (string, error) {
+	versionsUrl := resolveRegistryPath(modulesServiceUrl, module.Namespace, module.Name, module.Provider, "versions")
+
+	moduleVersions := registryModuleVersions{}
+	if _, err := sendRegistryRequest(versionsUrl, module, httpClient, terragruntOptions, &moduleVersions); err != nil {
+		return "", err
+	}
+
+	availableVersions := []string{}
+	for _, moduleEntry := range moduleVersions.Modules {
+		for _, moduleVersion := range moduleEntry.Versions {
+			availableVersions = append(availableVersions, moduleVersion.Version)
+		}
+	}
+
+	return findNewestMatchingVersion(module, availableVersions)
+}
+
+// Return the newest of the given versions that matches the version constraint of the given module
+func findNewestMatchingVersion(module *registryModule, availableVersions []string) (string, error) {
+	var constraints version.Constraints
+	if module.Version != "" {
+		parsedConstraints, err := version.NewConstraint(module.Version)
+		if err != nil {
+			return "", errors.WithStackTrace(err)
+		}
+		constraints = parsedConstraints
+	}
+
+	matchingVersions := version.Collection{}
+	for _, availableVersion := range availableVersions {
+		parsedVersion, err := version.NewVersion(availableVersion)
+		if err != nil {
+			// Skip versions we can't parse rather than failing, as a registry may contain versions Terraform ignores too
+			continue
+		}
+
+		if constraints == nil && parsedVersion.Prerelease() != "" {
+			continue
+		}
+
+		if constraints == nil || constraints.Check(parsedVersion) {
+			matchingVersions = append(matchingVersions, parsedVersion)
+		}
+	}
+
+	if len(matchingVersions) == 0 {
+		return "", errors.WithStackTrace(NoMatchingRegistryModuleVersion{Module: module.String(), VersionConstraint: module.Version, AvailableVersions: availableVersions})
+	}
+
+	sort.Sort(matchingVersions)
+	return matchingVersions[len(matchingVersions)-1].Original(), nil
+}
+
+// Ask the registry of the given module where the code for the given version of that module is stored. The registry
+// returns this as a source URL in the X-Terraform-Get header, which may use any protocol that terraform init supports.
+func getRegistryModuleDownloadUrl(module *registryModule, moduleVersion string, modulesServiceUrl *url.URL, httpClient *http.Client, terragruntOptions *options.TerragruntOptions) (string, error) {
+	downloadEndpointUrl := resolveRegistryPath(modulesServiceUrl, module.Namespace, module.Name, module.Provider, moduleVersion, "download")
+
+	response, err := sendRegistryRequest(downloadEndpointUrl, module, httpClient, terragruntOptions, nil)
+	if err != nil {
+		return "", err
+	}
+
+	downloadUrl := response.Header.Get("X-Terraform-Get")
+	if downloadUrl == "" {
+		return "", errors.WithStackTrace(RegistryDownloadUrlMissing{Url: downloadEndpointUrl.String()})
+	}
+
+	// Just like Terraform, treat download URLs that look like paths as relative to the download endpoint
+	if strings.HasPrefix(downloadUrl, "/") || strings.HasPrefix(downloadUrl, "./") || strings.HasPrefix(downloadUrl, "../") {
+		relativeUrl, err := url.Parse(downloadUrl)
+		if err != nil {
+			return "", errors.WithStackTrace(err)
+		}
+		downloadUrl = downloadEndpointUrl.ResolveReference(relativeUrl).String()
+	}
+
+	if module.Subdir == "" {
+		return downloadUrl, nil
+	}
+
+	return addSubdirToSourceUrl(downloadUrl, module.Subdir)
+}
+
+// Add the given subdir to the given source URL, after a double-slash, or to the end of the existing subdir, if the
+// source URL already has one
+func addSubdirToSourceUrl(sourceUrl string, subdir string) (string, error) {
+	parsedSourceUrl, err := parseSourceUrl(sourceUrl)
+	if err != nil {
+		return "", err
+	}
+
+	cleanSubdir := strings.Trim(subdir, "/")
+	if strings.Contains(parsedSourceUrl.Path, "//") {
+		parsedSourceUrl.Path = strings.TrimRight(parsedSourceUrl.Path, "/") + "/" + cleanSubdir
+	} else {
+		parsedSourceUrl.Path = strings.TrimRight(parsedSourceUrl.Path, "/") + "//" + cleanSubdir
+	}
+
+	return parsedSourceUrl.String(), nil
+}
+
+// Return the given URL with the given path segments appended to its path
+func resolveRegistryPath(baseUrl *url.URL, pathSegments ...string) *url.URL {
+	resolvedUrl := *baseUrl
+	resolvedUrl.Path = strings.TrimRight(baseUrl.Path, "/") + "/" + strings.Join(pathSegments, "/")
+	resolvedUrl.RawPath = ""
+	return &resolvedUrl
+}
+
+// Send a GET request to the given registry URL, authenticating with the API token for the registry of the given module,
+// if there is one. If responseBody is not nil, parse the response body as JSON into it.
+func sendRegistryRequest(requestUrl *url.URL, module *registryModule, httpClient *http.Client, terragruntOptions *options.TerragruntOptions, responseBody interface{}) (*http.Response, error) {
+	request, err := http.NewRequest("GET", requestUrl.String(), nil)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	request = request.WithContext(terragruntOptions.GetContext())
+
+	if token := getRegistryToken(module.Host, terragruntOptions); token != "" {
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, errors.WithStackTrace(RegistryRequestFailed{Url: requestUrl.String(), StatusCode: response.StatusCode})
+	}
+
+	if responseBody != nil {
+		if err := json.NewDecoder(response.Body).Decode(responseBody); err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+	}
+
+	return response, nil
+}
+
+// Return the API token to use with the given registry host. Just like Terraform, we look for it in an environment
+// variable called TF_TOKEN_<host>, where periods in the host are replaced with underscores and dashes with double
+// underscores. If that isn't set, we fall back to the TERRAGRUNT_REGISTRY_TOKEN environment variable.
+func getRegistryToken(host string, terragruntOptions *options.TerragruntOptions) string {
+	hostEnvVarName := strings.NewReplacer(".", "_", "-", "__").Replace(host)
+	if token := terragruntOptions.Env["TF_TOKEN_"+hostEnvVarName]; token != "" {
+		return token
+	}
+	return terragruntOptions.Env[TERRAGRUNT_REGISTRY_TOKEN_ENV_VAR]
+}
+
+// Custom error types
+
+type MalformedRegistrySource struct {
+	Source string
+}
+
+func (err MalformedRegistrySource) Error() string {
+	return fmt.Sprintf("Terraform Registry source URL '%s' is malformed. It should be of the form %s://<host>/<namespace>/<name>/<provider>?version=<constraint>, where the host is optional.", err.Source, TERRAFORM_REGISTRY_SCHEME)
+}
+
+type RegistryModulesNotSupported struct {
+	Host string
+}
+
+func (err RegistryModulesNotSupported) Error() string {
+	return fmt.Sprintf("The Terraform Registry at %s does not support modules", err.Host)
+}
+
+type NoMatchingRegistryModuleVersion struct {
+	Module            string
+	VersionConstraint string
+	AvailableVersions []string
+}
+
+func (err NoMatchingRegistryModuleVersion) Error() string {
+	return fmt.Sprintf("No version of Terraform Registry module %s matches the version constraint '%s'. Available versions: %v", err.Module, err.VersionConstraint, err.AvailableVersions)
+}
+
+type RegistryDownloadUrlMissing struct {
+	Url string
+}
+
+func (err RegistryDownloadUrlMissing) Error() string {
+	return fmt.Sprintf("The Terraform Registry response from %s did not contain a download URL in the X-Terraform-Get header", err.Url)
+}
+
+type RegistryRequestFailed struct {
+	Url        string
+	StatusCode int
+}
+
+func (err RegistryRequestFailed) Error() string {
+	return fmt.Sprintf("Request to Terraform Registry URL %s failed with status code %d", err.Url, err.StatusCode)
+}
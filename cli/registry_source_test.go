@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRegistrySource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source   string
+		expected registryModule
+	}{
+		{"tfr:///terraform-aws-modules/vpc/aws", registryModule{Host: DEFAULT_TERRAFORM_REGISTRY_HOST, Namespace: "terraform-aws-modules", Name: "vpc", Provider: "aws"}},
+		{"tfr:///terraform-aws-modules/vpc/aws?version=~>2.0", registryModule{Host: DEFAULT_TERRAFORM_REGISTRY_HOST, Namespace: "terraform-aws-modules", Name: "vpc", Provider: "aws", Version: "~>2.0"}},
+		{"tfr:///terraform-aws-modules/vpc/aws//modules/vpc-endpoints?version=2.1.0", registryModule{Host: DEFAULT_TERRAFORM_REGISTRY_HOST, Namespace: "terraform-aws-modules", Name: "vpc", Provider: "aws", Subdir: "modules/vpc-endpoints", Version: "2.1.0"}},
+		{"tfr://registry.example.com/acme/network/aws?version=>=1.0,<2.0", registryModule{Host: "registry.example.com", Namespace: "acme", Name: "network", Provider: "aws", Version: ">=1.0,<2.0"}},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := parseRegistrySource(testCase.source)
+		if assert.Nil(t, err, "Unexpected error for source %s: %v", testCase.source, err) {
+			assert.Equal(t, testCase.expected, *actual, "For source %s", testCase.source)
+		}
+	}
+}
+
+func TestParseRegistrySourceMalformed(t *testing.T) {
+	t.Parallel()
+
+	testCases := []string{
+		"tfr:///",
+		"tfr:///terraform-aws-modules",
+		"tfr:///terraform-aws-modules/vpc",
+		"tfr:///terraform-aws-modules/vpc/aws/extra",
+		"tfr:///terraform-aws-modules//vpc/aws",
+	}
+
+	for _, source := range testCases {
+		_, err := parseRegistrySource(source)
+		_, isMalformedErr := errors.Unwrap(err).(MalformedRegistrySource)
+		assert.True(t, isMalformedErr, "Expected a MalformedRegistrySource error for source %s but got: %v", source, err)
+	}
+}
+
+func TestFindNewestMatchingVersion(t *testing.T) {
+	t.Parallel()
+
+	availableVersions := []string{"1.0.0", "1.2.0", "1.10.0", "2.0.0", "2.1.0", "2.78.0", "3.0.0-beta1", "not-a-version"}
+
+	testCases := []struct {
+		constraint string
+		expected   string
+	}{
+		{"", "2.78.0"},
+		{"~>2.0", "2.78.0"},
+		{"~>1.2.0", "1.2.0"},
+		{"~>1.2", "1.10.0"},
+		{">=1.0,<2.0", "1.10.0"},
+		{"2.1.0", "2.1.0"},
+	}
+
+	for _, testCase := range testCases {
+		module := &registryModule{Host: DEFAULT_TERRAFORM_REGISTRY_HOST, Namespace: "foo", Name: "bar", Provider: "aws", Version: testCase.constraint}
+		actual, err := findNewestMatchingVersion(module, availableVersions)
+		if assert.Nil(t, err, "Unexpected error for constraint %s: %v", testCase.constraint, err) {
+			assert.Equal(t, testCase.expected, actual, "For constraint %s", testCase.constraint)
+		}
+	}
+}
+
+func TestFindNewestMatchingVersionNoMatch(t *testing.T) {
+	t.Parallel()
+
+	module := &registryModule{Host: DEFAULT_TERRAFORM_REGISTRY_HOST, Namespace: "foo", Name: "bar", Provider: "aws", Version: "~>4.0"}
+	_, err := findNewestMatchingVersion(module, []string{"1.0.0", "2.0.0"})
+
+	_, isNoMatchErr := errors.Unwrap(err).(NoMatchingRegistryModuleVersion)
+	assert.True(t, isNoMatchErr, "Expected a NoMatchingRegistryModuleVersion error but got: %v", err)
+}
+
+func TestAddSubdirToSourceUrl(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		sourceUrl string
+		subdir    string
+		expected  string
+	}{
+		{"git::https://github.com/foo/bar?ref=v1.0.0", "modules/baz", "git::https://github.com/foo/bar//modules/baz?ref=v1.0.0"},
+		{"git::https://github.com/foo/bar//modules?ref=v1.0.0", "baz", "git::https://github.com/foo/bar//modules/baz?ref=v1.0.0"},
+		{"https://example.com/bar.tar.gz", "/modules/baz/", "https://example.com/bar.tar.gz//modules/baz"},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := addSubdirToSourceUrl(testCase.sourceUrl, testCase.subdir)
+		if assert.Nil(t, err, "Unexpected error for source URL %s: %v", testCase.sourceUrl, err) {
+			assert.Equal(t, testCase.expected, actual, "For source URL %s and subdir %s", testCase.sourceUrl, testCase.subdir)
+		}
+	}
+}
+
+func TestResolveRegistrySource(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(newMockRegistryHandler(""))
+	defer server.Close()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	source := fmt.Sprintf("tfr://%s/acme/network/aws//modules/subnets?version=~>1.0", registryHost(t, server))
+	actual, err := resolveRegistrySource(source, insecureHttpClient(), terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "git::https://github.com/acme/terraform-aws-network//modules/subnets?ref=v1.3.0", actual)
+}
+
+func TestResolveRegistrySourceWithToken(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(newMockRegistryHandler("secret-token"))
+	defer server.Close()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	source := fmt.Sprintf("tfr://%s/acme/network/aws?version=1.2.0", registryHost(t, server))
+
+	_, err = resolveRegistrySource(source, insecureHttpClient(), terragruntOptions)
+	requestFailedErr, isRequestFailedErr := errors.Unwrap(err).(RegistryRequestFailed)
+	if assert.True(t, isRequestFailedErr, "Expected a RegistryRequestFailed error but got: %v", err) {
+		assert.Equal(t, http.StatusUnauthorized, requestFailedErr.StatusCode)
+	}
+
+	terragruntOptions.Env[TERRAGRUNT_REGISTRY_TOKEN_ENV_VAR] = "secret-token"
+	actual, err := resolveRegistrySource(source, insecureHttpClient(), terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "git::https://github.com/acme/terraform-aws-network?ref=v1.2.0", actual)
+}
+
+func TestGetRegistryToken(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	assert.Equal(t, "", getRegistryToken("my-registry.example.com", terragruntOptions))
+
+	terragruntOptions.Env[TERRAGRUNT_REGISTRY_TOKEN_ENV_VAR] = "default-token"
+	assert.Equal(t, "default-token", getRegistryToken("my-registry.example.com", terragruntOptions))
+
+	terragruntOptions.Env["TF_TOKEN_my__registry_example_com"] = "host-token"
+	assert.Equal(t, "host-token", getRegistryToken("my-registry.example.com", terragruntOptions))
+}
+
+// Return an http.Handler that acts like a Terraform Registry containing a single module, acme/network/aws. If token is
+// not empty, every request must authenticate with it.
+func newMockRegistryHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(TERRAFORM_REGISTRY_DISCOVERY_PATH, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"modules.v1": "/v1/modules/"}`)
+	})
+
+	mux.HandleFunc("/v1/modules/acme/network/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"modules": [{"versions": [{"version": "1.0.0"}, {"version": "1.3.0"}, {"version": "1.2.0"}, {"version": "2.0.0"}]}]}`)
+	})
+
+	for _, moduleVersion := range []string{"1.0.0", "1.2.0", "1.3.0", "2.0.0"} {
+		downloadUrl := fmt.Sprintf("git::https://github.com/acme/terraform-aws-network?ref=v%s", moduleVersion)
+		mux.HandleFunc(fmt.Sprintf("/v1/modules/acme/network/aws/%s/download", moduleVersion), func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Terraform-Get", downloadUrl)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func registryHost(t *testing.T, server *httptest.Server) string {
+	serverUrl, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serverUrl.Host
+}
+
+// The mock registries use self-signed certificates, so skip certificate verification when talking to them
+func insecureHttpClient() *http.Client {
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+}
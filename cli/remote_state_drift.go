@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/remote"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Check whether the remote_state config has changed since Terraform was last initialized in the working dir. If it
+// has, Auto-Init would reinitialize the backend, and Terraform would either ask to copy the state to the new backend in
+// the middle of the run, or fail, so show the user exactly what changed first, and ask them to confirm. If they
+// decline, or Auto-Init is disabled, return an error that explains how to fix it.
+func checkRemoteStateDrift(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	if terragruntConfig.RemoteState == nil || !util.ListContainsElement(TERRAFORM_COMMANDS_THAT_USE_STATE, firstArg(terragruntOptions.TerraformCliArgs)) {
+		return nil
+	}
+
+	drift, err := terragruntConfig.RemoteState.DetectDrift(terragruntOptions)
+	if err != nil {
+		return err
+	}
+	if drift == nil || !drift.HasChanges() {
+		return nil
+	}
+
+	terragruntOptions.Logger.Printf("The remote_state config in %s is different from the backend config Terraform was initialized with:\n%s", terragruntOptions.TerragruntConfigPath, drift)
+
+	if !terragruntOptions.AutoInit {
+		return errors.WithStackTrace(RemoteStateConfigDrifted{ConfigPath: terragruntOptions.TerragruntConfigPath, Drift: *drift})
+	}
+
+	shouldReinitialize, err := shell.PromptUserForYesNo("Reinitialize the backend with the new remote_state config? Terraform may ask whether to copy your existing state to the new backend.", terragruntOptions)
+	if err != nil {
+		return err
+	}
+	if !shouldReinitialize {
+		return errors.WithStackTrace(RemoteStateConfigDrifted{ConfigPath: terragruntOptions.TerragruntConfigPath, Drift: *drift})
+	}
+	return nil
+}
+
+// Custom error types
+
+type RemoteStateConfigDrifted struct {
+	ConfigPath string
+	Drift      remote.BackendConfigDrift
+}
+
+func (err RemoteStateConfigDrifted) Error() string {
+	return fmt.Sprintf("The remote_state config in %s is different from the backend config Terraform was initialized with:\n%s\nRun 'terragrunt init' to reinitialize the backend with the new config, which lets Terraform migrate your state, or revert the remote_state config to match the current backend.", err.ConfigPath, err.Drift)
+}
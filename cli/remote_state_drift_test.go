@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/remote"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const remoteStateDriftTestState = `{
+  "version": 3,
+  "serial": 1,
+  "backend": {
+    "type": "s3",
+    "config": {
+      "bucket": "my-bucket",
+      "key": "old/terraform.tfstate",
+      "region": "us-east-1"
+    }
+  }
+}`
+
+func createRemoteStateDriftTestOptions(t *testing.T, workingDir string, autoInit bool, assumeNo bool) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(workingDir, config.DefaultTerragruntConfigPath))
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.WorkingDir = workingDir
+	terragruntOptions.TerraformCliArgs = []string{"plan"}
+	terragruntOptions.AutoInit = autoInit
+	terragruntOptions.AssumeNo = assumeNo
+	return terragruntOptions
+}
+
+func remoteStateDriftTestConfig(key string) *config.TerragruntConfig {
+	return &config.TerragruntConfig{
+		RemoteState: &remote.RemoteState{
+			Backend: "s3",
+			Config:  map[string]interface{}{"bucket": "my-bucket", "key": key, "region": "us-east-1"},
+		},
+	}
+}
+
+func TestCheckRemoteStateDriftNoDrift(t *testing.T) {
+	t.Parallel()
+
+	workingDir := tmpDir(t)
+	defer os.RemoveAll(workingDir)
+	writeSourceHashTestFile(t, workingDir, remote.DEFAULT_PATH_TO_REMOTE_STATE_FILE, remoteStateDriftTestState)
+
+	terragruntOptions := createRemoteStateDriftTestOptions(t, workingDir, true, true)
+	err := checkRemoteStateDrift(terragruntOptions, remoteStateDriftTestConfig("old/terraform.tfstate"))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+}
+
+func TestCheckRemoteStateDriftNotInitialized(t *testing.T) {
+	t.Parallel()
+
+	workingDir := tmpDir(t)
+	defer os.RemoveAll(workingDir)
+
+	terragruntOptions := createRemoteStateDriftTestOptions(t, workingDir, false, true)
+	err := checkRemoteStateDrift(terragruntOptions, remoteStateDriftTestConfig("new/terraform.tfstate"))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+}
+
+func TestCheckRemoteStateDriftDeclined(t *testing.T) {
+	t.Parallel()
+
+	workingDir := tmpDir(t)
+	defer os.RemoveAll(workingDir)
+	writeSourceHashTestFile(t, workingDir, remote.DEFAULT_PATH_TO_REMOTE_STATE_FILE, remoteStateDriftTestState)
+
+	testCases := []struct {
+		autoInit bool
+		assumeNo bool
+	}{
+		{autoInit: false, assumeNo: false},
+		{autoInit: true, assumeNo: true},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions := createRemoteStateDriftTestOptions(t, workingDir, testCase.autoInit, testCase.assumeNo)
+		err := checkRemoteStateDrift(terragruntOptions, remoteStateDriftTestConfig("new/terraform.tfstate"))
+
+		driftErr, isDriftErr := errors.Unwrap(err).(RemoteStateConfigDrifted)
+		assert.True(t, isDriftErr, "Expected a RemoteStateConfigDrifted error for %v but got: %v", testCase, err)
+		if isDriftErr {
+			assert.Equal(t, []remote.BackendConfigChange{{Key: "key", OldValue: "old/terraform.tfstate", NewValue: "new/terraform.tfstate"}}, driftErr.Drift.Changes)
+		}
+	}
+}
+
+func TestCheckRemoteStateDriftNonInteractive(t *testing.T) {
+	t.Parallel()
+
+	workingDir := tmpDir(t)
+	defer os.RemoveAll(workingDir)
+	writeSourceHashTestFile(t, workingDir, remote.DEFAULT_PATH_TO_REMOTE_STATE_FILE, remoteStateDriftTestState)
+
+	terragruntOptions := createRemoteStateDriftTestOptions(t, workingDir, true, false)
+	terragruntOptions.NonInteractive = true
+
+	err := checkRemoteStateDrift(terragruntOptions, remoteStateDriftTestConfig("new/terraform.tfstate"))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const CMD_RENDER_JSON = "render-json"
+
+// Pass this flag, followed by a path, to the render-json command to write the JSON to that file instead of stdout.
+// Relative paths are relative to the working dir.
+const RENDER_JSON_OUT_FLAG = "--out"
+
+// Write the given Terragrunt config, which has already been merged with its includes and had its interpolations
+// resolved, as JSON to stdout, or to the file passed with RENDER_JSON_OUT_FLAG
+func renderJSON(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	outPath, err := getRenderJSONOutPath(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := json.MarshalIndent(terragruntConfig, "", "  ")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	rendered = append(rendered, '\n')
+
+	if outPath == "" {
+		_, err := terragruntOptions.Writer.Write(rendered)
+		return errors.WithStackTrace(err)
+	}
+
+	terragruntOptions.Logger.Printf("Writing the rendered config of %s to %s", terragruntOptions.TerragruntConfigPath, outPath)
+	if err := ioutil.WriteFile(outPath, rendered, os.FileMode(0644)); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return nil
+}
+
+// Return the canonical path passed with RENDER_JSON_OUT_FLAG, as either "--out <path>" or "--out=<path>", or an empty
+// string if the flag isn't set
+func getRenderJSONOutPath(terragruntOptions *options.TerragruntOptions) (string, error) {
+	args := terragruntOptions.TerraformCliArgs
+	outPath := ""
+
+	for i, arg := range args {
+		if arg == RENDER_JSON_OUT_FLAG {
+			if i+1 >= len(args) {
+				return "", errors.WithStackTrace(MissingRenderJSONOutPath(RENDER_JSON_OUT_FLAG))
+			}
+			outPath = args[i+1]
+		} else if strings.HasPrefix(arg, RENDER_JSON_OUT_FLAG+"=") {
+			outPath = strings.TrimPrefix(arg, RENDER_JSON_OUT_FLAG+"=")
+		}
+	}
+
+	if outPath == "" {
+		return "", nil
+	}
+	return util.CanonicalPath(outPath, terragruntOptions.WorkingDir)
+}
+
+// Custom error types
+
+type MissingRenderJSONOutPath string
+
+func (flag MissingRenderJSONOutPath) Error() string {
+	return fmt.Sprintf("You must specify a path after the %s flag of the %s command", string(flag), CMD_RENDER_JSON)
+}
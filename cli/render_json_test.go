@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/remote"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const RENDER_JSON_TEST_EXPECTED = `{
+  "terraform": {
+    "extra_arguments": [
+      {
+        "name": "region",
+        "required_var_files": [
+          "region.tfvars"
+        ],
+        "commands": [
+          "plan"
+        ]
+      }
+    ],
+    "source": "git::git@github.com:foo/modules.git//app?ref=v0.0.1"
+  },
+  "remote_state": {
+    "backend": "s3",
+    "config": {
+      "bucket": "my-bucket",
+      "key": "app/terraform.tfstate"
+    }
+  }
+}
+`
+
+var renderJSONTestConfig = &config.TerragruntConfig{
+	Terraform: &config.TerraformConfig{
+		Source: "git::git@github.com:foo/modules.git//app?ref=v0.0.1",
+		ExtraArgs: []config.TerraformExtraArguments{
+			{Name: "region", RequiredVarFiles: []string{"region.tfvars"}, Commands: []string{"plan"}},
+		},
+	},
+	RemoteState: &remote.RemoteState{
+		Backend: "s3",
+		Config:  map[string]interface{}{"bucket": "my-bucket", "key": "app/terraform.tfstate"},
+	},
+}
+
+func createRenderJSONTestOptions(t *testing.T, workingDir string, args []string) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(workingDir, config.DefaultTerragruntConfigPath))
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.WorkingDir = workingDir
+	terragruntOptions.TerraformCliArgs = args
+	return terragruntOptions
+}
+
+func TestRenderJSONToStdout(t *testing.T) {
+	t.Parallel()
+
+	workingDir := tmpDir(t)
+	defer os.RemoveAll(workingDir)
+
+	var stdout bytes.Buffer
+	terragruntOptions := createRenderJSONTestOptions(t, workingDir, []string{CMD_RENDER_JSON})
+	terragruntOptions.Writer = &stdout
+
+	err := renderJSON(terragruntOptions, renderJSONTestConfig)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, RENDER_JSON_TEST_EXPECTED, stdout.String())
+}
+
+func TestRenderJSONToFile(t *testing.T) {
+	t.Parallel()
+
+	testCases := [][]string{
+		{CMD_RENDER_JSON, RENDER_JSON_OUT_FLAG, "rendered.json"},
+		{CMD_RENDER_JSON, RENDER_JSON_OUT_FLAG + "=rendered.json"},
+	}
+
+	for _, args := range testCases {
+		workingDir := tmpDir(t)
+		defer os.RemoveAll(workingDir)
+
+		var stdout bytes.Buffer
+		terragruntOptions := createRenderJSONTestOptions(t, workingDir, args)
+		terragruntOptions.Writer = &stdout
+
+		err := renderJSON(terragruntOptions, renderJSONTestConfig)
+		assert.Nil(t, err, "Unexpected error for args %v: %v", args, err)
+
+		rendered, err := ioutil.ReadFile(util.JoinPath(workingDir, "rendered.json"))
+		assert.Nil(t, err, "Unexpected error reading the rendered file for args %v: %v", args, err)
+		assert.Equal(t, RENDER_JSON_TEST_EXPECTED, string(rendered), "For args %v", args)
+		assert.Empty(t, stdout.String(), "For args %v", args)
+	}
+}
+
+func TestRenderJSONMissingOutPath(t *testing.T) {
+	t.Parallel()
+
+	workingDir := tmpDir(t)
+	defer os.RemoveAll(workingDir)
+
+	terragruntOptions := createRenderJSONTestOptions(t, workingDir, []string{CMD_RENDER_JSON, RENDER_JSON_OUT_FLAG})
+
+	err := renderJSON(terragruntOptions, renderJSONTestConfig)
+	_, isMissingOutPathErr := errors.Unwrap(err).(MissingRenderJSONOutPath)
+	assert.True(t, isMissingOutPathErr, "Expected a MissingRenderJSONOutPath error but got: %v", err)
+}
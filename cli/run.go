@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+)
+
+// RunResult is the result of running Terragrunt with RunWithOptions
+type RunResult struct {
+	// The Terragrunt command that was run (e.g. plan or apply-all)
+	Command string
+
+	// The exit code the Terragrunt CLI would have exited with after running the same command
+	ExitCode int
+
+	// The result of each module Terragrunt ran: one module for a Terraform command, or every module in the stack for
+	// one of the xxx-all commands
+	ModuleResults []configstack.ModuleResult
+}
+
+// RunWithOptions runs Terragrunt with the given options, without parsing any command line arguments. This allows other
+// Go programs, such as test harnesses and automation servers, to embed Terragrunt. The command to run is the first of
+// the TerraformCliArgs in the given options: either a Terraform command (e.g. plan), or one of the xxx-all commands
+// (e.g. apply-all), followed by its arguments. All input and output goes through the Reader, Writer, and ErrWriter of
+// the given options. Cancelling the given context kills any command Terragrunt is running. The given options are not
+// modified. The returned error is the same one the Terragrunt CLI would report for the same command.
+func RunWithOptions(ctx context.Context, terragruntOptions *options.TerragruntOptions) (*RunResult, error) {
+	if len(terragruntOptions.TerraformCliArgs) == 0 {
+		return nil, errors.WithStackTrace(NoCommandSpecified)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	runOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	runOptions.WorkingDir = terragruntOptions.WorkingDir
+	runOptions.Logger = terragruntOptions.Logger
+	runOptions.Context = ctx
+	runOptions.RunTerragrunt = runTerragrunt
+
+	command, err := checkDeprecated(firstArg(runOptions.TerraformCliArgs), runOptions)
+	if err != nil {
+		return nil, err
+	}
+	if isMultiModuleCommand(command) {
+		runOptions.TerraformCliArgs = runOptions.TerraformCliArgs[1:]
+	}
+
+	if runOptions.TerraformVersion == nil {
+		if err := PopulateTerraformVersion(runOptions); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := CheckTerraformVersion(DEFAULT_TERRAFORM_VERSION_CONSTRAINT, DEFAULT_TERRAFORM_VERSION_CONSTRAINT_SOURCE, runOptions); err != nil {
+		return nil, err
+	}
+
+	moduleResults, err := runCommand(command, runOptions)
+	return &RunResult{Command: command, ExitCode: getExitCodeForResult(err), ModuleResults: moduleResults}, err
+}
+
+// Return the exit code the Terragrunt CLI exits with for the given error
+func getExitCodeForResult(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	exitCode, exitCodeErr := shell.GetExitCode(err)
+	if exitCodeErr != nil {
+		return 1
+	}
+	return exitCode
+}
+
+// Custom error types
+
+var NoCommandSpecified = fmt.Errorf("No command specified. Set the TerraformCliArgs of the options to the command to run (e.g. plan or apply-all) and its arguments.")
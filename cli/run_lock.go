@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/remote"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The name of the lock file that a run holds in the folder of the Terragrunt config
+const RUN_LOCK_FILE = ".terragrunt-run-lock"
+
+// How long to wait between attempts to take a run lock that another run holds
+const RUN_LOCK_RETRY_INTERVAL = 10 * time.Second
+
+// The Terraform commands that change the state of a module, so a module with a run_lock block is locked while they run
+var TERRAFORM_COMMANDS_THAT_NEED_RUN_LOCK = []string{
+	CMD_APPLY,
+	CMD_DESTROY,
+	"import",
+	"refresh",
+	"state",
+	"taint",
+	"untaint",
+}
+
+// The 'terraform state' subcommands that only read the state, so they don't need the run lock
+var READ_ONLY_STATE_SUBCOMMANDS = []string{"list", "pull", "show"}
+
+// A lock that only one run at a time can hold
+type runLock interface {
+	// Try to take the lock, with the given info about this run. Return true if we got it, and otherwise, false and the
+	// info of the run that holds it.
+	TryAcquire(info string, terragruntOptions *options.TerragruntOptions) (bool, string, error)
+
+	Release(terragruntOptions *options.TerragruntOptions) error
+
+	String() string
+}
+
+// If the given config has a run_lock block and the command changes the state, take the run locks of the module, waiting
+// up to RunLockTimeout for other runs to release them, and return a function that releases them. Otherwise, return a
+// function that does nothing.
+func acquireRunLocksIfNecessary(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) (func(), error) {
+	noop := func() {}
+	if terragruntConfig.RunLock == nil || !commandNeedsRunLock(terragruntOptions.TerraformCliArgs) {
+		return noop, nil
+	}
+
+	locks := []runLock{fileRunLock{Path: util.JoinPath(filepath.Dir(terragruntOptions.TerragruntConfigPath), RUN_LOCK_FILE)}}
+	if terragruntConfig.RunLock.UseLockTable {
+		s3RunLock, err := remote.NewS3RunLock(terragruntConfig.RemoteState, terragruntOptions)
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, s3RunLock)
+	}
+
+	info := getRunLockInfo(terragruntOptions, time.Now())
+	acquiredLocks := []runLock{}
+	releaseLocks := func() {
+		for _, lock := range acquiredLocks {
+			if err := lock.Release(terragruntOptions); err != nil {
+				terragruntOptions.Logger.Printf("WARNING: failed to release the run lock %s, so you may have to delete it yourself: %v", lock, err)
+			}
+		}
+	}
+
+	for _, lock := range locks {
+		if err := acquireRunLock(lock, info, terragruntOptions); err != nil {
+			releaseLocks()
+			return nil, err
+		}
+		acquiredLocks = append(acquiredLocks, lock)
+	}
+
+	return releaseLocks, nil
+}
+
+// Return true if the Terraform command in the given args changes the state of the module
+func commandNeedsRunLock(args []string) bool {
+	command := firstArg(args)
+	if command == "state" && len(args) > 1 {
+		return !util.ListContainsElement(READ_ONLY_STATE_SUBCOMMANDS, args[1])
+	}
+	return util.ListContainsElement(TERRAFORM_COMMANDS_THAT_NEED_RUN_LOCK, command)
+}
+
+// Take the given lock, retrying every RUN_LOCK_RETRY_INTERVAL while another run holds it, for up to RunLockTimeout
+func acquireRunLock(lock runLock, info string, terragruntOptions *options.TerragruntOptions) error {
+	deadline := time.Now().Add(terragruntOptions.RunLockTimeout)
+	for {
+		acquired, holder, err := lock.TryAcquire(info, terragruntOptions)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			terragruntOptions.Logger.Printf("Acquired the run lock %s", lock)
+			return nil
+		}
+		if holder == "" {
+			// The lock was released in the meantime, or its holder hasn't written its info yet
+			holder = "an unknown run"
+		}
+
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			return errors.WithStackTrace(RunLockHeld{Lock: lock.String(), Holder: holder})
+		}
+
+		terragruntOptions.Logger.Printf("The run lock %s is held by %s. Will try again in %s.", lock, holder, RUN_LOCK_RETRY_INTERVAL)
+		if err := util.SleepWithContext(terragruntOptions.GetContext(), minDuration(RUN_LOCK_RETRY_INTERVAL, remaining)); err != nil {
+			return err
+		}
+	}
+}
+
+// Return a description of this run, for whoever finds the lock taken: who runs which command on which machine, and
+// since when
+func getRunLockInfo(terragruntOptions *options.TerragruntOptions, now time.Time) string {
+	user := terragruntOptions.Env["USER"]
+	if user == "" {
+		user = terragruntOptions.Env["USERNAME"]
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown host"
+	}
+
+	command := strings.Join(append([]string{"terraform"}, terragruntOptions.TerraformCliArgs...), " ")
+	return fmt.Sprintf("%s on %s (pid %d), running '%s' since %s", user, host, os.Getpid(), command, now.UTC().Format(time.RFC3339))
+}
+
+func minDuration(a time.Duration, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// fileRunLock is a run lock that's a file, which only one run at a time can create. It holds the info of that run.
+type fileRunLock struct {
+	Path string
+}
+
+func (lock fileRunLock) TryAcquire(info string, terragruntOptions *options.TerragruntOptions) (bool, string, error) {
+	file, err := os.OpenFile(lock.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if !os.IsExist(err) {
+			return false, "", errors.WithStackTrace(err)
+		}
+		holder, readErr := ioutil.ReadFile(lock.Path)
+		if readErr != nil && !os.IsNotExist(readErr) {
+			return false, "", errors.WithStackTrace(readErr)
+		}
+		return false, strings.TrimSpace(string(holder)), nil
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(info + "\n"); err != nil {
+		os.Remove(lock.Path)
+		return false, "", errors.WithStackTrace(err)
+	}
+	return true, "", nil
+}
+
+func (lock fileRunLock) Release(terragruntOptions *options.TerragruntOptions) error {
+	return errors.WithStackTrace(os.Remove(lock.Path))
+}
+
+func (lock fileRunLock) String() string {
+	return lock.Path
+}
+
+// Custom error types
+
+type RunLockHeld struct {
+	Lock   string
+	Holder string
+}
+
+func (err RunLockHeld) Error() string {
+	return fmt.Sprintf("Another run holds the run lock %s: %s. Wait for it to finish, or use --%s to wait longer. If you're sure no other run is in progress, delete the lock.", err.Lock, err.Holder, OPT_TERRAGRUNT_RUN_LOCK_TIMEOUT)
+}
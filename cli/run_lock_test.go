@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRunLockTestOptions(t *testing.T, modulePath string, args ...string) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(modulePath, config.DefaultTerragruntConfigPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.TerraformCliArgs = args
+	terragruntOptions.Env = map[string]string{"USER": "alice"}
+	return terragruntOptions
+}
+
+func TestAcquireRunLocksIfNecessary(t *testing.T) {
+	t.Parallel()
+
+	modulePath := tmpDir(t)
+	defer os.RemoveAll(modulePath)
+	lockPath := util.JoinPath(modulePath, RUN_LOCK_FILE)
+	terragruntConfig := &config.TerragruntConfig{RunLock: &config.RunLockConfig{}}
+
+	release, err := acquireRunLocksIfNecessary(createRunLockTestOptions(t, modulePath, CMD_APPLY, "-auto-approve"), terragruntConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(lockPath)
+	if assert.Nil(t, err, "Expected the lock file %s to exist: %v", lockPath, err) {
+		assert.True(t, strings.HasPrefix(string(contents), "alice on "), "Unexpected lock info: %s", string(contents))
+		assert.Contains(t, string(contents), "running 'terraform apply -auto-approve' since ")
+	}
+
+	_, err = acquireRunLocksIfNecessary(createRunLockTestOptions(t, modulePath, CMD_DESTROY), terragruntConfig)
+	if _, isRunLockHeldErr := errors.Unwrap(err).(RunLockHeld); assert.True(t, isRunLockHeldErr, "Expected a RunLockHeld error but got: %v", err) {
+		assert.Contains(t, err.Error(), strings.TrimSpace(string(contents)))
+	}
+
+	// Commands that don't change the state don't need the lock
+	releasePlan, err := acquireRunLocksIfNecessary(createRunLockTestOptions(t, modulePath, CMD_PLAN), terragruntConfig)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	releasePlan()
+	releaseStatePull, err := acquireRunLocksIfNecessary(createRunLockTestOptions(t, modulePath, "state", "pull"), terragruntConfig)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	releaseStatePull()
+	assert.True(t, util.FileExists(lockPath), "Expected the lock file %s to still exist", lockPath)
+
+	release()
+	assert.False(t, util.FileExists(lockPath), "Expected the lock file %s to be deleted", lockPath)
+
+	release, err = acquireRunLocksIfNecessary(createRunLockTestOptions(t, modulePath, CMD_DESTROY), terragruntConfig)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	release()
+}
+
+func TestAcquireRunLocksIfNecessaryWithoutRunLockBlock(t *testing.T) {
+	t.Parallel()
+
+	modulePath := tmpDir(t)
+	defer os.RemoveAll(modulePath)
+
+	release, err := acquireRunLocksIfNecessary(createRunLockTestOptions(t, modulePath, CMD_APPLY), &config.TerragruntConfig{})
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.False(t, util.FileExists(util.JoinPath(modulePath, RUN_LOCK_FILE)), "Expected no lock file without a run_lock block")
+	release()
+}
+
+// A run lock that's held by another run for the given number of attempts to take it
+type runLockHeldForAttempts struct {
+	attempts *int
+	heldFor  int
+}
+
+func (lock runLockHeldForAttempts) TryAcquire(info string, terragruntOptions *options.TerragruntOptions) (bool, string, error) {
+	*lock.attempts++
+	return *lock.attempts > lock.heldFor, "bob on ci", nil
+}
+
+func (lock runLockHeldForAttempts) Release(terragruntOptions *options.TerragruntOptions) error {
+	return nil
+}
+
+func (lock runLockHeldForAttempts) String() string {
+	return "test lock"
+}
+
+func TestAcquireRunLockWaitsForTimeout(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createRunLockTestOptions(t, "/live/app", CMD_APPLY)
+
+	attempts := 0
+	err := acquireRunLock(runLockHeldForAttempts{attempts: &attempts, heldFor: 1}, "alice", terragruntOptions)
+	_, isRunLockHeldErr := errors.Unwrap(err).(RunLockHeld)
+	assert.True(t, isRunLockHeldErr, "Expected a RunLockHeld error but got: %v", err)
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	terragruntOptions.RunLockTimeout = 50 * time.Millisecond
+	err = acquireRunLock(runLockHeldForAttempts{attempts: &attempts, heldFor: 1}, "alice", terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, 2, attempts)
+}
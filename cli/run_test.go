@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWithOptionsNoCommand(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	_, err = RunWithOptions(context.Background(), terragruntOptions)
+	assert.Equal(t, NoCommandSpecified, errors.Unwrap(err))
+}
+
+func TestRunWithOptionsContextAlreadyCancelled(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.TerraformCliArgs = []string{"plan"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = RunWithOptions(ctx, terragruntOptions)
+	assert.Equal(t, context.Canceled, errors.Unwrap(err))
+}
+
+func TestRunWithOptionsTargetNotSupported(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.TerraformCliArgs = []string{CMD_OUTPUT_ALL}
+	terragruntOptions.Targets = []string{"aws_instance.web"}
+	terragruntOptions.TerraformVersion = version.Must(version.NewVersion("0.12.0"))
+
+	_, err = RunWithOptions(context.Background(), terragruntOptions)
+	assert.Equal(t, TargetNotSupported(CMD_OUTPUT_ALL), errors.Unwrap(err))
+}
+
+func TestGetExitCodeForResult(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, getExitCodeForResult(nil))
+	assert.Equal(t, 1, getExitCodeForResult(fmt.Errorf("not an exit code error")))
+}
@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const CMD_SCAFFOLD = "scaffold"
+
+// The scheme of a URL, such as https://, which, unlike the double-slash of a subdir, isn't part of the path of a source
+var SOURCE_URL_SCHEME_REGEXP = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://`)
+
+// Create a folder with a Terragrunt config for the module at the source URL passed after the scaffold command, so that
+// adding a module is a matter of filling in the inputs, rather than copying the config of another module and missing
+// what has to change. The config includes the config in the nearest parent folder, if there is one, sets the source,
+// and has a commented-out input for each variable of the module, with its description. The folder is the one passed
+// after the source URL, or, if there isn't one, the folder named after the module in the working dir.
+func scaffold(terragruntOptions *options.TerragruntOptions) error {
+	source, moduleDir, err := parseScaffoldArgs(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	modulePath, err := util.CanonicalPath(moduleDir, terragruntOptions.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	configPath := util.JoinPath(modulePath, config.DefaultTerragruntConfigPath)
+	if util.FileExists(configPath) || util.FileExists(util.JoinPath(modulePath, config.OldTerragruntConfigPath)) {
+		return errors.WithStackTrace(ScaffoldConfigAlreadyExists(modulePath))
+	}
+
+	moduleSource, err := getScaffoldSource(source, terragruntOptions.WorkingDir, modulePath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(modulePath, 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	// Download the module the same way running Terraform in the new folder would, to read its variables
+	moduleOptions := terragruntOptions.Clone(configPath)
+	if err := downloadTerraformSource(moduleSource, moduleOptions, &config.TerragruntConfig{}); err != nil {
+		return err
+	}
+
+	terraformCode, err := util.ParseTerraformCode(moduleOptions.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	includeParent := hasParentConfig(modulePath, terragruntOptions.MaxFoldersToCheck)
+	contents := renderScaffoldConfig(moduleSource, includeParent, terraformCode.Variables)
+
+	terragruntOptions.Logger.Printf("Writing a Terragrunt config with %d inputs for %s to %s", len(terraformCode.Variables), moduleSource, configPath)
+	if err := ioutil.WriteFile(configPath, []byte(contents), os.FileMode(0644)); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if !isPinnedSource(moduleSource) {
+		terragruntOptions.Logger.Printf("WARNING: The source %s isn't pinned to a version, so each download gets whatever is newest. Add ?ref=<tag> (or ?version=<version> for the registry) to the source in %s to pin it.", moduleSource, configPath)
+	}
+	terragruntOptions.Logger.Printf("Set the inputs in %s, and run 'terragrunt %s' in %s to check that all the required ones are set", configPath, CMD_VALIDATE_INPUTS, modulePath)
+
+	return nil
+}
+
+// Return the source URL and the folder passed as args after the scaffold command. If there's no folder, it's the name of
+// the module in the source URL.
+func parseScaffoldArgs(terragruntOptions *options.TerragruntOptions) (string, string, error) {
+	args := terragruntOptions.TerraformCliArgs[1:]
+
+	switch len(args) {
+	case 1:
+		folder := getScaffoldFolderName(args[0])
+		if folder == "" {
+			return "", "", errors.WithStackTrace(ScaffoldFolderNotFound(args[0]))
+		}
+		return args[0], folder, nil
+	case 2:
+		return args[0], args[1], nil
+	default:
+		return "", "", errors.WithStackTrace(InvalidScaffoldArgs(args))
+	}
+}
+
+// Return the name of the module in the given source URL, which is the last folder in the subdir after the double-slash,
+// if there is one, or else the last folder of the URL, or, for a registry source, the name of the module. For example,
+// it's vpc for git::git@github.com:acme/modules.git//networking/vpc?ref=v0.1.0, ../modules/vpc, and
+// tfr:///terraform-aws-modules/vpc/aws.
+func getScaffoldFolderName(source string) string {
+	if index := strings.Index(source, "?"); index >= 0 {
+		source = source[:index]
+	}
+
+	if isRegistrySource(source) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(source, TERRAFORM_REGISTRY_SCHEME+"://"), "/"), "/")
+		// The path is [<hostname>/]<namespace>/<name>/<provider>
+		if len(parts) < 3 {
+			return ""
+		}
+		return parts[len(parts)-2]
+	}
+
+	if matches := forcedRegexp.FindStringSubmatch(source); matches != nil {
+		source = matches[2]
+	}
+	source = SOURCE_URL_SCHEME_REGEXP.ReplaceAllString(source, "")
+	if index := strings.Index(source, "//"); index >= 0 {
+		source = source[index+2:]
+	}
+
+	source = strings.TrimSuffix(strings.TrimRight(filepath.ToSlash(source), "/"), ".git")
+	name := source[strings.LastIndexAny(source, "/:")+1:]
+	if name == "." || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// Return the source to put in the config in the given module folder. A local path in the source is relative to the
+// working dir, where scaffold runs, but in the config, it must be relative to the module folder.
+func getScaffoldSource(source string, workingDir string, modulePath string) (string, error) {
+	if !util.IsLocalModuleSource(source) {
+		return source, nil
+	}
+
+	rootPath, subdir := source, ""
+	if index := strings.Index(source, "//"); index >= 0 {
+		rootPath, subdir = source[:index], source[index:]
+	}
+
+	canonicalRootPath, err := util.CanonicalPath(rootPath, workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	relativeRootPath, err := util.GetPathRelativeTo(canonicalRootPath, modulePath)
+	if err != nil {
+		return "", err
+	}
+	if !util.IsLocalModuleSource(relativeRootPath) {
+		relativeRootPath = "./" + relativeRootPath
+	}
+
+	return relativeRootPath + subdir, nil
+}
+
+// Return true if there's a Terragrunt config in one of the parent folders of the given folder for the config in that
+// folder to include
+func hasParentConfig(modulePath string, maxFoldersToCheck int) bool {
+	currentDir := modulePath
+	for i := 0; i < maxFoldersToCheck; i++ {
+		parentDir := filepath.Dir(currentDir)
+		if parentDir == currentDir {
+			return false
+		}
+		if util.FileExists(util.JoinPath(parentDir, config.DefaultTerragruntConfigPath)) || util.FileExists(util.JoinPath(parentDir, config.OldTerragruntConfigPath)) {
+			return true
+		}
+		currentDir = parentDir
+	}
+	return false
+}
+
+// Return true if the given source always downloads the same code: a local path, a Git URL with a ref, or a registry
+// source with a version
+func isPinnedSource(source string) bool {
+	if util.IsLocalModuleSource(source) || filepath.IsAbs(source) {
+		return true
+	}
+	return strings.Contains(source, "?ref=") || strings.Contains(source, "&ref=") || strings.Contains(source, "?version=") || strings.Contains(source, "&version=")
+}
+
+// Render the Terragrunt config for the module with the given source and variables, with the required variables first
+func renderScaffoldConfig(source string, includeParent bool, variables []util.TerraformVariable) string {
+	var out bytes.Buffer
+
+	out.WriteString("terragrunt = {\n")
+	if includeParent {
+		out.WriteString("  include {\n")
+		out.WriteString("    path = \"${find_in_parent_folders()}\"\n")
+		out.WriteString("  }\n\n")
+	}
+	out.WriteString("  terraform {\n")
+	fmt.Fprintf(&out, "    source = %s\n", hclString(source))
+	out.WriteString("  }\n")
+	out.WriteString("}\n")
+
+	required := []util.TerraformVariable{}
+	optional := []util.TerraformVariable{}
+	for _, variable := range variables {
+		if variable.HasDefault {
+			optional = append(optional, variable)
+		} else {
+			required = append(required, variable)
+		}
+	}
+
+	writeScaffoldInputs(&out, "REQUIRED INPUTS", "The module has no default for these variables, so you must set them.", required)
+	writeScaffoldInputs(&out, "OPTIONAL INPUTS", "The module has a default for these variables, so you only need to set them to override it.", optional)
+
+	return out.String()
+}
+
+// Write a section with a commented-out input for each of the given variables, with its description
+func writeScaffoldInputs(out *bytes.Buffer, title string, explanation string, variables []util.TerraformVariable) {
+	if len(variables) == 0 {
+		return
+	}
+
+	separator := "# " + strings.Repeat("-", 117) + "\n"
+	out.WriteString("\n")
+	out.WriteString(separator)
+	fmt.Fprintf(out, "# %s\n", title)
+	fmt.Fprintf(out, "# %s\n", explanation)
+	out.WriteString(separator)
+
+	for _, variable := range variables {
+		out.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimSpace(variable.Description), "\n") {
+			if line != "" {
+				fmt.Fprintf(out, "# %s\n", strings.TrimRight(line, " "))
+			}
+		}
+		fmt.Fprintf(out, "# %s = %s\n", variable.Name, scaffoldPlaceholder(variable.Type))
+	}
+}
+
+// Return an empty value of the given Terraform variable type
+func scaffoldPlaceholder(variableType string) string {
+	switch variableType {
+	case "list":
+		return "[]"
+	case "map":
+		return "{}"
+	default:
+		return `""`
+	}
+}
+
+// Return the given string as a quoted HCL string, leaving interpolations such as ${get_env(...)} as they are
+func hclString(value string) string {
+	return `"` + strings.Replace(strings.Replace(value, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+// Custom error types
+
+type InvalidScaffoldArgs []string
+
+func (args InvalidScaffoldArgs) Error() string {
+	return fmt.Sprintf("Invalid arguments for the %s command: %v. Expected the source URL of a module, and optionally the folder to create the config in.", CMD_SCAFFOLD, []string(args))
+}
+
+type ScaffoldFolderNotFound string
+
+func (source ScaffoldFolderNotFound) Error() string {
+	return fmt.Sprintf("Can't tell the name of the module from the source %s. Pass the folder to create the config in after the source.", string(source))
+}
+
+type ScaffoldConfigAlreadyExists string
+
+func (path ScaffoldConfigAlreadyExists) Error() string {
+	return fmt.Sprintf("There is already a Terragrunt config in %s. Pass another folder to the %s command, or delete the config first.", string(path), CMD_SCAFFOLD)
+}
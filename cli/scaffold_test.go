@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetScaffoldFolderName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{"git::git@github.com:acme/modules.git//networking/vpc?ref=v0.1.0", "vpc"},
+		{"git::https://github.com/acme/terraform-aws-vpc.git?ref=v1.0.0", "terraform-aws-vpc"},
+		{"github.com/acme/modules//mysql/", "mysql"},
+		{"git@github.com:acme/vpc.git", "vpc"},
+		{"../modules/vpc", "vpc"},
+		{"../modules//app", "app"},
+		{"tfr:///terraform-aws-modules/vpc/aws?version=~>2.0", "vpc"},
+		{"tfr://registry.acme.com/acme/mysql/aws", "mysql"},
+		{"./", ""},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, getScaffoldFolderName(testCase.source), "For source %s", testCase.source)
+	}
+}
+
+func TestGetScaffoldSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{"git::git@github.com:acme/modules.git//vpc?ref=v0.1.0", "git::git@github.com:acme/modules.git//vpc?ref=v0.1.0"},
+		{"../modules//vpc", "../../modules//vpc"},
+		{"./modules/vpc", "../modules/vpc"},
+		{"./prod/modules", "./modules"},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := getScaffoldSource(testCase.source, "/repo/live", "/repo/live/prod")
+		if assert.Nil(t, err, "Unexpected error for source %s: %v", testCase.source, err) {
+			assert.Equal(t, testCase.expected, actual, "For source %s", testCase.source)
+		}
+	}
+}
+
+func TestIsPinnedSource(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isPinnedSource("git::git@github.com:acme/modules.git//vpc?ref=v0.1.0"))
+	assert.True(t, isPinnedSource("tfr:///terraform-aws-modules/vpc/aws?version=2.0.0"))
+	assert.True(t, isPinnedSource("../modules//vpc"))
+	assert.False(t, isPinnedSource("git::git@github.com:acme/modules.git//vpc"))
+	assert.False(t, isPinnedSource("github.com/acme/modules//vpc?depth=1"))
+}
+
+func TestRenderScaffoldConfig(t *testing.T) {
+	t.Parallel()
+
+	variables := []util.TerraformVariable{
+		{Name: "name", Description: "The name of the VPC.\nMust be unique."},
+		{Name: "azs", HasDefault: true, Type: "list"},
+		{Name: "cidr_block", Description: "The CIDR block of the VPC"},
+	}
+
+	expected := `terragrunt = {
+  include {
+    path = "${find_in_parent_folders()}"
+  }
+
+  terraform {
+    source = "git::git@github.com:acme/modules.git//vpc?ref=v0.1.0"
+  }
+}
+
+# ---------------------------------------------------------------------------------------------------------------------
+# REQUIRED INPUTS
+# The module has no default for these variables, so you must set them.
+# ---------------------------------------------------------------------------------------------------------------------
+
+# The name of the VPC.
+# Must be unique.
+# name = ""
+
+# The CIDR block of the VPC
+# cidr_block = ""
+
+# ---------------------------------------------------------------------------------------------------------------------
+# OPTIONAL INPUTS
+# The module has a default for these variables, so you only need to set them to override it.
+# ---------------------------------------------------------------------------------------------------------------------
+
+# azs = []
+`
+	assert.Equal(t, expected, renderScaffoldConfig("git::git@github.com:acme/modules.git//vpc?ref=v0.1.0", true, variables))
+}
+
+func TestRenderScaffoldConfigWithoutParentOrVariables(t *testing.T) {
+	t.Parallel()
+
+	expected := `terragrunt = {
+  terraform {
+    source = "../modules//vpc"
+  }
+}
+`
+	assert.Equal(t, expected, renderScaffoldConfig("../modules//vpc", false, nil))
+}
+
+func TestHasParentConfig(t *testing.T) {
+	t.Parallel()
+
+	root := tmpDir(t)
+	defer os.RemoveAll(root)
+
+	modulePath := filepath.Join(root, "prod", "vpc")
+	assert.False(t, hasParentConfig(modulePath, options.DEFAULT_MAX_FOLDERS_TO_CHECK))
+
+	writeSourceHashTestFile(t, root, "terraform.tfvars", "terragrunt = {}")
+	assert.True(t, hasParentConfig(modulePath, options.DEFAULT_MAX_FOLDERS_TO_CHECK))
+	assert.False(t, hasParentConfig(modulePath, 1))
+}
+
+func TestParseScaffoldArgs(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("terraform.tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	terragruntOptions.TerraformCliArgs = []string{CMD_SCAFFOLD, "git::git@github.com:acme/modules.git//vpc?ref=v0.1.0"}
+	source, folder, err := parseScaffoldArgs(terragruntOptions)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		assert.Equal(t, "git::git@github.com:acme/modules.git//vpc?ref=v0.1.0", source)
+		assert.Equal(t, "vpc", folder)
+	}
+
+	terragruntOptions.TerraformCliArgs = []string{CMD_SCAFFOLD, "../modules//vpc", "prod/vpc"}
+	source, folder, err = parseScaffoldArgs(terragruntOptions)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		assert.Equal(t, "../modules//vpc", source)
+		assert.Equal(t, "prod/vpc", folder)
+	}
+
+	terragruntOptions.TerraformCliArgs = []string{CMD_SCAFFOLD}
+	_, _, err = parseScaffoldArgs(terragruntOptions)
+	_, isInvalidArgsErr := errors.Unwrap(err).(InvalidScaffoldArgs)
+	assert.True(t, isInvalidArgsErr, "Expected an InvalidScaffoldArgs error, but got %v", err)
+}
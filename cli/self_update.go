@@ -0,0 +1,484 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/hashicorp/go-version"
+	"golang.org/x/crypto/openpgp"
+)
+
+const CMD_SELF_UPDATE = "self-update"
+
+// The GitHub API URL of the Terragrunt releases. Set the SELF_UPDATE_URL_ENV_VAR environment variable to use another
+// URL that serves the same JSON, such as the releases of a fork, or of a mirror in GitHub Enterprise.
+const SELF_UPDATE_RELEASES_URL = "https://api.github.com/repos/gruntwork-io/terragrunt/releases?per_page=100"
+const SELF_UPDATE_URL_ENV_VAR = "TERRAGRUNT_SELF_UPDATE_URL"
+
+// The environment variable with a GitHub token to list the releases with, to avoid the rate limit of anonymous requests
+const SELF_UPDATE_TOKEN_ENV_VAR = "GITHUB_TOKEN"
+
+// The assets of a release with the SHA-256 checksums of its binaries, and with a detached PGP signature of that file
+const SELF_UPDATE_CHECKSUMS_ASSET = "SHA256SUMS"
+const SELF_UPDATE_SIGNATURE_ASSET = "SHA256SUMS.sig"
+
+// Pass this flag to the self-update command to only log which version it would install
+const SELF_UPDATE_DRY_RUN_FLAG = "--dry-run"
+
+// Pass this flag, followed by the path of a PGP public key, to the self-update command to also verify the signature of
+// the checksums. It may also be set with the SELF_UPDATE_PUBLIC_KEY_ENV_VAR environment variable.
+const SELF_UPDATE_PUBLIC_KEY_FLAG = "--public-key"
+const SELF_UPDATE_PUBLIC_KEY_ENV_VAR = "TERRAGRUNT_SELF_UPDATE_PUBLIC_KEY"
+
+// A release, as returned by the GitHub releases API
+type githubRelease struct {
+	TagName    string               `json:"tag_name"`
+	Draft      bool                 `json:"draft"`
+	Prerelease bool                 `json:"prerelease"`
+	Assets     []githubReleaseAsset `json:"assets"`
+}
+
+type githubReleaseAsset struct {
+	Name        string `json:"name"`
+	DownloadUrl string `json:"browser_download_url"`
+}
+
+// The arguments of the self-update command
+type selfUpdateArgs struct {
+	VersionConstraint string
+	PublicKeyPath     string
+	DryRun            bool
+}
+
+// Replace the running Terragrunt binary with the binary for this platform from the newest release that matches the
+// version constraint passed after the self-update command, or, without one, from the newest release that isn't a
+// pre-release. The binary must match its checksum in the SHA256SUMS asset of the release, and, if a public key is
+// given, that file must be signed with the key.
+func selfUpdate(terragruntOptions *options.TerragruntOptions) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	httpClient, err := terragruntOptions.HttpClient(0)
+	if err != nil {
+		return err
+	}
+
+	return selfUpdateExecutable(executable, httpClient, terragruntOptions)
+}
+
+func selfUpdateExecutable(executable string, httpClient *http.Client, terragruntOptions *options.TerragruntOptions) error {
+	args, err := parseSelfUpdateArgs(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	if err := terragruntOptions.CheckNetworkAccess("download the Terragrunt releases"); err != nil {
+		return err
+	}
+
+	releases, err := getGithubReleases(httpClient, terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	release, releaseVersion, err := findSelfUpdateRelease(releases, args.VersionConstraint)
+	if err != nil {
+		return err
+	}
+
+	if currentVersion, err := version.NewVersion(terragruntOptions.TerragruntVersion); err == nil {
+		// Without a constraint, only ever upgrade, but with one, install the version it picks, so a fleet can be pinned
+		comparison := currentVersion.Compare(releaseVersion)
+		if comparison == 0 || (args.VersionConstraint == "" && comparison > 0) {
+			terragruntOptions.Logger.Printf("Terragrunt %s is already installed at %s, so there's nothing to update", terragruntOptions.TerragruntVersion, executable)
+			return nil
+		}
+	}
+
+	assetName := selfUpdateAssetName(runtime.GOOS, runtime.GOARCH)
+	binaryAsset := findReleaseAsset(release, assetName)
+	if binaryAsset == nil {
+		return errors.WithStackTrace(ReleaseAssetNotFound{Release: release.TagName, Asset: assetName})
+	}
+
+	if args.DryRun {
+		terragruntOptions.Logger.Printf("Would replace Terragrunt %s at %s with Terragrunt %s from %s", terragruntOptions.TerragruntVersion, executable, release.TagName, binaryAsset.DownloadUrl)
+		return nil
+	}
+
+	expectedChecksum, err := getVerifiedChecksum(release, assetName, args.PublicKeyPath, httpClient, terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	terragruntOptions.Logger.Printf("Downloading Terragrunt %s from %s", release.TagName, binaryAsset.DownloadUrl)
+	if err := replaceExecutable(executable, binaryAsset.DownloadUrl, expectedChecksum, httpClient, terragruntOptions); err != nil {
+		return err
+	}
+
+	terragruntOptions.Logger.Printf("Replaced Terragrunt %s at %s with Terragrunt %s", terragruntOptions.TerragruntVersion, executable, release.TagName)
+	return nil
+}
+
+// Return the version constraint, the public key path, and whether it's a dry run from the args after the self-update
+// command
+func parseSelfUpdateArgs(terragruntOptions *options.TerragruntOptions) (*selfUpdateArgs, error) {
+	args := &selfUpdateArgs{PublicKeyPath: terragruntOptions.Env[SELF_UPDATE_PUBLIC_KEY_ENV_VAR]}
+
+	cliArgs := terragruntOptions.TerraformCliArgs
+	for i := 1; i < len(cliArgs); i++ {
+		arg := cliArgs[i]
+		switch {
+		case arg == SELF_UPDATE_DRY_RUN_FLAG:
+			args.DryRun = true
+		case arg == SELF_UPDATE_PUBLIC_KEY_FLAG:
+			if i+1 >= len(cliArgs) {
+				return nil, errors.WithStackTrace(MissingSelfUpdatePublicKey(SELF_UPDATE_PUBLIC_KEY_FLAG))
+			}
+			args.PublicKeyPath = cliArgs[i+1]
+			i++
+		case strings.HasPrefix(arg, SELF_UPDATE_PUBLIC_KEY_FLAG+"="):
+			args.PublicKeyPath = strings.TrimPrefix(arg, SELF_UPDATE_PUBLIC_KEY_FLAG+"=")
+		case strings.HasPrefix(arg, "-") || args.VersionConstraint != "":
+			return nil, errors.WithStackTrace(InvalidSelfUpdateArgs(cliArgs[1:]))
+		default:
+			args.VersionConstraint = arg
+		}
+	}
+
+	if args.PublicKeyPath != "" {
+		publicKeyPath, err := util.CanonicalPath(args.PublicKeyPath, terragruntOptions.WorkingDir)
+		if err != nil {
+			return nil, err
+		}
+		args.PublicKeyPath = publicKeyPath
+	}
+
+	return args, nil
+}
+
+// Return the releases listed by the GitHub releases API
+func getGithubReleases(httpClient *http.Client, terragruntOptions *options.TerragruntOptions) ([]githubRelease, error) {
+	releasesUrl := terragruntOptions.Env[SELF_UPDATE_URL_ENV_VAR]
+	if releasesUrl == "" {
+		releasesUrl = SELF_UPDATE_RELEASES_URL
+	}
+
+	headers := map[string]string{"Accept": "application/vnd.github.v3+json"}
+	if token := terragruntOptions.Env[SELF_UPDATE_TOKEN_ENV_VAR]; token != "" {
+		headers["Authorization"] = fmt.Sprintf("token %s", token)
+	}
+
+	var body bytes.Buffer
+	if err := sendSelfUpdateRequest(releasesUrl, headers, httpClient, terragruntOptions, &body); err != nil {
+		return nil, err
+	}
+
+	releases := []githubRelease{}
+	if err := json.Unmarshal(body.Bytes(), &releases); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return releases, nil
+}
+
+// Return the newest of the given releases that isn't a draft and matches the given version constraint, or, if there is
+// no constraint, the newest one that isn't a draft or a pre-release, with its version
+func findSelfUpdateRelease(releases []githubRelease, versionConstraint string) (*githubRelease, *version.Version, error) {
+	var constraints version.Constraints
+	if versionConstraint != "" {
+		parsedConstraints, err := version.NewConstraint(versionConstraint)
+		if err != nil {
+			return nil, nil, errors.WithStackTrace(err)
+		}
+		constraints = parsedConstraints
+	}
+
+	releasesByVersion := map[string]githubRelease{}
+	matchingVersions := version.Collection{}
+	availableVersions := []string{}
+
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		availableVersions = append(availableVersions, release.TagName)
+
+		parsedVersion, err := version.NewVersion(release.TagName)
+		if err != nil {
+			// Skip tags that aren't versions rather than failing, as a fork may have other releases
+			continue
+		}
+
+		if constraints == nil && (release.Prerelease || parsedVersion.Prerelease() != "") {
+			continue
+		}
+
+		if constraints == nil || constraints.Check(parsedVersion) {
+			matchingVersions = append(matchingVersions, parsedVersion)
+			releasesByVersion[parsedVersion.String()] = release
+		}
+	}
+
+	if len(matchingVersions) == 0 {
+		return nil, nil, errors.WithStackTrace(NoMatchingTerragruntRelease{VersionConstraint: versionConstraint, AvailableVersions: availableVersions})
+	}
+
+	sort.Sort(matchingVersions)
+	newestVersion := matchingVersions[len(matchingVersions)-1]
+	release := releasesByVersion[newestVersion.String()]
+	return &release, newestVersion, nil
+}
+
+// Return the name of the release asset with the Terragrunt binary for the given OS and architecture
+func selfUpdateAssetName(goos string, goarch string) string {
+	name := fmt.Sprintf("terragrunt_%s_%s", goos, goarch)
+	if goos == "windows" {
+		name = name + ".exe"
+	}
+	return name
+}
+
+// Return the asset of the given release with the given name, or nil if it doesn't have one
+func findReleaseAsset(release *githubRelease, name string) *githubReleaseAsset {
+	for _, asset := range release.Assets {
+		if asset.Name == name {
+			return &asset
+		}
+	}
+	return nil
+}
+
+// Return the checksum of the given asset from the checksums asset of the given release. If the given public key path
+// isn't empty, first check that the checksums asset is signed with that key.
+func getVerifiedChecksum(release *githubRelease, assetName string, publicKeyPath string, httpClient *http.Client, terragruntOptions *options.TerragruntOptions) (string, error) {
+	checksumsAsset := findReleaseAsset(release, SELF_UPDATE_CHECKSUMS_ASSET)
+	if checksumsAsset == nil {
+		return "", errors.WithStackTrace(ReleaseAssetNotFound{Release: release.TagName, Asset: SELF_UPDATE_CHECKSUMS_ASSET})
+	}
+
+	var checksums bytes.Buffer
+	if err := sendSelfUpdateRequest(checksumsAsset.DownloadUrl, nil, httpClient, terragruntOptions, &checksums); err != nil {
+		return "", err
+	}
+
+	if publicKeyPath != "" {
+		signatureAsset := findReleaseAsset(release, SELF_UPDATE_SIGNATURE_ASSET)
+		if signatureAsset == nil {
+			return "", errors.WithStackTrace(ReleaseAssetNotFound{Release: release.TagName, Asset: SELF_UPDATE_SIGNATURE_ASSET})
+		}
+
+		var signature bytes.Buffer
+		if err := sendSelfUpdateRequest(signatureAsset.DownloadUrl, nil, httpClient, terragruntOptions, &signature); err != nil {
+			return "", err
+		}
+
+		if err := verifySignature(checksums.Bytes(), signature.Bytes(), publicKeyPath); err != nil {
+			return "", err
+		}
+		terragruntOptions.Logger.Printf("The %s of Terragrunt %s are signed with the key in %s", SELF_UPDATE_CHECKSUMS_ASSET, release.TagName, publicKeyPath)
+	}
+
+	return findChecksum(checksums.String(), assetName, release.TagName)
+}
+
+// Check that the given signature, which can be armored or binary, is a signature of the given contents by the PGP key
+// in the armored public key file at the given path
+func verifySignature(contents []byte, signature []byte, publicKeyPath string) error {
+	publicKeyFile, err := os.Open(publicKeyPath)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer publicKeyFile.Close()
+
+	keyRing, err := openpgp.ReadArmoredKeyRing(publicKeyFile)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN")) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyRing, bytes.NewReader(contents), bytes.NewReader(signature))
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyRing, bytes.NewReader(contents), bytes.NewReader(signature))
+	}
+	if err != nil {
+		return errors.WithStackTrace(InvalidReleaseSignature{PublicKeyPath: publicKeyPath, Underlying: err})
+	}
+	return nil
+}
+
+// Return the checksum of the given asset in the given checksums file, which has a line with a hex SHA-256 checksum
+// and a file name for each asset, as written by the sha256sum tool
+func findChecksum(checksums string, assetName string, releaseName string) (string, error) {
+	for _, line := range strings.Split(checksums, "\n") {
+		fields := strings.Fields(line)
+		// sha256sum marks files it read in binary mode with a leading *
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == assetName {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", errors.WithStackTrace(ChecksumNotFound{Release: releaseName, Asset: assetName})
+}
+
+// Download the binary at the given URL next to the given executable, check it matches the given checksum, and only then
+// move it into the place of the executable, so a failed or tampered download never replaces a working binary
+func replaceExecutable(executable string, downloadUrl string, expectedChecksum string, httpClient *http.Client, terragruntOptions *options.TerragruntOptions) error {
+	// Download to the same folder, so the rename at the end doesn't cross file systems
+	tmpFile, err := ioutil.TempFile(filepath.Dir(executable), ".terragrunt-self-update")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	hash := sha256.New()
+	err = sendSelfUpdateRequest(downloadUrl, nil, httpClient, terragruntOptions, io.MultiWriter(tmpFile, hash))
+	if closeErr := tmpFile.Close(); err == nil && closeErr != nil {
+		err = errors.WithStackTrace(closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	actualChecksum := hex.EncodeToString(hash.Sum(nil))
+	if actualChecksum != expectedChecksum {
+		return errors.WithStackTrace(ChecksumMismatch{Url: downloadUrl, ExpectedChecksum: expectedChecksum, ActualChecksum: actualChecksum})
+	}
+
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	// Windows can't overwrite or delete a running executable, but it can rename one, so move the old one out of the way
+	// first. Deleting it then fails on Windows, so there it's left until the next update.
+	oldExecutable := executable + ".old"
+	os.Remove(oldExecutable)
+	if err := os.Rename(executable, oldExecutable); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if err := os.Rename(tmpPath, executable); err != nil {
+		os.Rename(oldExecutable, executable)
+		return errors.WithStackTrace(err)
+	}
+	os.Remove(oldExecutable)
+
+	return nil
+}
+
+// Send a GET request with the given headers to the given URL, and copy the response body to the given writer
+func sendSelfUpdateRequest(requestUrl string, headers map[string]string, httpClient *http.Client, terragruntOptions *options.TerragruntOptions, responseBody io.Writer) error {
+	request, err := http.NewRequest("GET", requestUrl, nil)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	request = request.WithContext(terragruntOptions.GetContext())
+
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return errors.WithStackTrace(SelfUpdateRequestFailed{Url: requestUrl, StatusCode: response.StatusCode})
+	}
+
+	_, err = io.Copy(responseBody, response.Body)
+	return errors.WithStackTrace(err)
+}
+
+// Custom error types
+
+type InvalidSelfUpdateArgs []string
+
+func (args InvalidSelfUpdateArgs) Error() string {
+	return fmt.Sprintf("Invalid arguments for the %s command: %v. Expected an optional version constraint (e.g. '~> 0.13'), %s, and %s <path>.", CMD_SELF_UPDATE, []string(args), SELF_UPDATE_DRY_RUN_FLAG, SELF_UPDATE_PUBLIC_KEY_FLAG)
+}
+
+type MissingSelfUpdatePublicKey string
+
+func (flag MissingSelfUpdatePublicKey) Error() string {
+	return fmt.Sprintf("You must specify a path after the %s flag of the %s command", string(flag), CMD_SELF_UPDATE)
+}
+
+type NoMatchingTerragruntRelease struct {
+	VersionConstraint string
+	AvailableVersions []string
+}
+
+func (err NoMatchingTerragruntRelease) Error() string {
+	return fmt.Sprintf("No Terragrunt release matches the version constraint '%s'. Available releases: %v", err.VersionConstraint, err.AvailableVersions)
+}
+
+type ReleaseAssetNotFound struct {
+	Release string
+	Asset   string
+}
+
+func (err ReleaseAssetNotFound) Error() string {
+	return fmt.Sprintf("Terragrunt release %s has no %s asset", err.Release, err.Asset)
+}
+
+type ChecksumNotFound struct {
+	Release string
+	Asset   string
+}
+
+func (err ChecksumNotFound) Error() string {
+	return fmt.Sprintf("The %s of Terragrunt release %s have no checksum for %s", SELF_UPDATE_CHECKSUMS_ASSET, err.Release, err.Asset)
+}
+
+type ChecksumMismatch struct {
+	Url              string
+	ExpectedChecksum string
+	ActualChecksum   string
+}
+
+func (err ChecksumMismatch) Error() string {
+	return fmt.Sprintf("The SHA-256 checksum of %s is %s, but the release says it should be %s, so the binary was not replaced", err.Url, err.ActualChecksum, err.ExpectedChecksum)
+}
+
+type InvalidReleaseSignature struct {
+	PublicKeyPath string
+	Underlying    error
+}
+
+func (err InvalidReleaseSignature) Error() string {
+	return fmt.Sprintf("The signature of the %s is not valid for the key in %s: %v", SELF_UPDATE_CHECKSUMS_ASSET, err.PublicKeyPath, err.Underlying)
+}
+
+func (err InvalidReleaseSignature) Unwrap() error {
+	return err.Underlying
+}
+
+type SelfUpdateRequestFailed struct {
+	Url        string
+	StatusCode int
+}
+
+func (err SelfUpdateRequestFailed) Error() string {
+	return fmt.Sprintf("Request to %s failed with status code %d", err.Url, err.StatusCode)
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestFindSelfUpdateRelease(t *testing.T) {
+	t.Parallel()
+
+	releases := []githubRelease{
+		{TagName: "v0.12.0"},
+		{TagName: "v0.14.0-beta1", Prerelease: true},
+		{TagName: "v0.13.2"},
+		{TagName: "v0.15.0", Draft: true},
+		{TagName: "nightly"},
+		{TagName: "v0.13.10"},
+	}
+
+	testCases := []struct {
+		constraint string
+		expected   string
+	}{
+		{"", "v0.13.10"},
+		{"~> 0.12.0", "v0.12.0"},
+		{"< 0.13.5", "v0.13.2"},
+		{">= 0.14.0-beta1", "v0.14.0-beta1"},
+	}
+
+	for _, testCase := range testCases {
+		release, releaseVersion, err := findSelfUpdateRelease(releases, testCase.constraint)
+		if assert.Nil(t, err, "Unexpected error for constraint '%s': %v", testCase.constraint, err) {
+			assert.Equal(t, testCase.expected, release.TagName, "For constraint '%s'", testCase.constraint)
+			assert.Equal(t, testCase.expected, releaseVersion.Original(), "For constraint '%s'", testCase.constraint)
+		}
+	}
+
+	_, _, err := findSelfUpdateRelease(releases, "> 1.0")
+	_, isNoMatchingReleaseErr := errors.Unwrap(err).(NoMatchingTerragruntRelease)
+	assert.True(t, isNoMatchingReleaseErr, "Expected a NoMatchingTerragruntRelease error, but got %v", err)
+}
+
+func TestFindChecksum(t *testing.T) {
+	t.Parallel()
+
+	checksums := "ABC123  terragrunt_linux_amd64\ndef456 *terragrunt_windows_amd64.exe\n"
+
+	checksum, err := findChecksum(checksums, "terragrunt_linux_amd64", "v0.13.0")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "abc123", checksum)
+
+	checksum, err = findChecksum(checksums, "terragrunt_windows_amd64.exe", "v0.13.0")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "def456", checksum)
+
+	_, err = findChecksum(checksums, "terragrunt_darwin_amd64", "v0.13.0")
+	_, isChecksumNotFoundErr := errors.Unwrap(err).(ChecksumNotFound)
+	assert.True(t, isChecksumNotFoundErr, "Expected a ChecksumNotFound error, but got %v", err)
+}
+
+func TestSelfUpdateAssetName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "terragrunt_linux_amd64", selfUpdateAssetName("linux", "amd64"))
+	assert.Equal(t, "terragrunt_windows_386.exe", selfUpdateAssetName("windows", "386"))
+}
+
+func TestParseSelfUpdateArgs(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := selfUpdateOptionsForTest(t, "", CMD_SELF_UPDATE, "~> 0.13", SELF_UPDATE_DRY_RUN_FLAG, SELF_UPDATE_PUBLIC_KEY_FLAG, "/keys/terragrunt.asc")
+	args, err := parseSelfUpdateArgs(terragruntOptions)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		assert.Equal(t, selfUpdateArgs{VersionConstraint: "~> 0.13", PublicKeyPath: "/keys/terragrunt.asc", DryRun: true}, *args)
+	}
+
+	terragruntOptions = selfUpdateOptionsForTest(t, "", CMD_SELF_UPDATE, "0.13.0", "0.14.0")
+	_, err = parseSelfUpdateArgs(terragruntOptions)
+	_, isInvalidArgsErr := errors.Unwrap(err).(InvalidSelfUpdateArgs)
+	assert.True(t, isInvalidArgsErr, "Expected an InvalidSelfUpdateArgs error, but got %v", err)
+}
+
+func TestSelfUpdateExecutable(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(newMockReleasesHandler("new binary", "", nil))
+	defer server.Close()
+
+	executable := writeTempFileForTest(t, "old binary")
+	defer os.RemoveAll(executable)
+
+	terragruntOptions := selfUpdateOptionsForTest(t, server.URL, CMD_SELF_UPDATE)
+	assert.Nil(t, selfUpdateExecutable(executable, http.DefaultClient, terragruntOptions))
+	assertFileContents(t, executable, "new binary")
+	assert.False(t, util.FileExists(executable+".old"))
+}
+
+func TestSelfUpdateExecutableAlreadyUpToDate(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(newMockReleasesHandler("new binary", "", nil))
+	defer server.Close()
+
+	executable := writeTempFileForTest(t, "old binary")
+	defer os.RemoveAll(executable)
+
+	terragruntOptions := selfUpdateOptionsForTest(t, server.URL, CMD_SELF_UPDATE)
+	terragruntOptions.TerragruntVersion = "v0.13.1"
+	assert.Nil(t, selfUpdateExecutable(executable, http.DefaultClient, terragruntOptions))
+	assertFileContents(t, executable, "old binary")
+}
+
+func TestSelfUpdateExecutableDryRun(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(newMockReleasesHandler("new binary", "", nil))
+	defer server.Close()
+
+	executable := writeTempFileForTest(t, "old binary")
+	defer os.RemoveAll(executable)
+
+	terragruntOptions := selfUpdateOptionsForTest(t, server.URL, CMD_SELF_UPDATE, SELF_UPDATE_DRY_RUN_FLAG)
+	assert.Nil(t, selfUpdateExecutable(executable, http.DefaultClient, terragruntOptions))
+	assertFileContents(t, executable, "old binary")
+}
+
+func TestSelfUpdateExecutableChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(newMockReleasesHandler("new binary", "tampered binary", nil))
+	defer server.Close()
+
+	executable := writeTempFileForTest(t, "old binary")
+	defer os.RemoveAll(executable)
+
+	terragruntOptions := selfUpdateOptionsForTest(t, server.URL, CMD_SELF_UPDATE)
+	err := selfUpdateExecutable(executable, http.DefaultClient, terragruntOptions)
+	_, isChecksumMismatchErr := errors.Unwrap(err).(ChecksumMismatch)
+	assert.True(t, isChecksumMismatchErr, "Expected a ChecksumMismatch error, but got %v", err)
+	assertFileContents(t, executable, "old binary")
+}
+
+func TestSelfUpdateExecutableWithSignature(t *testing.T) {
+	t.Parallel()
+
+	signer, err := openpgp.NewEntity("Terragrunt", "test", "test@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSigner, err := openpgp.NewEntity("Someone else", "test", "else@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKeyPath := writeTempFileForTest(t, armoredPublicKeyForTest(t, signer))
+	defer os.RemoveAll(publicKeyPath)
+
+	for _, testCase := range []struct {
+		signer        *openpgp.Entity
+		expectUpdated bool
+	}{
+		{signer, true},
+		{otherSigner, false},
+	} {
+		server := httptest.NewServer(newMockReleasesHandler("new binary", "", testCase.signer))
+		defer server.Close()
+
+		executable := writeTempFileForTest(t, "old binary")
+		defer os.RemoveAll(executable)
+
+		terragruntOptions := selfUpdateOptionsForTest(t, server.URL, CMD_SELF_UPDATE, SELF_UPDATE_PUBLIC_KEY_FLAG, publicKeyPath)
+		err := selfUpdateExecutable(executable, http.DefaultClient, terragruntOptions)
+
+		if testCase.expectUpdated {
+			assert.Nil(t, err, "Unexpected error: %v", err)
+			assertFileContents(t, executable, "new binary")
+		} else {
+			_, isInvalidSignatureErr := errors.Unwrap(err).(InvalidReleaseSignature)
+			assert.True(t, isInvalidSignatureErr, "Expected an InvalidReleaseSignature error, but got %v", err)
+			assertFileContents(t, executable, "old binary")
+		}
+	}
+}
+
+// Return a handler that serves the releases of a mock GitHub repo: v0.12.0 and v0.13.1, whose binary for the current
+// platform has the given contents, or, if servedBinary isn't empty, claims to, but serves servedBinary instead. If
+// signer isn't nil, the checksums of v0.13.1 are signed with it.
+func newMockReleasesHandler(binary string, servedBinary string, signer *openpgp.Entity) http.Handler {
+	if servedBinary == "" {
+		servedBinary = binary
+	}
+
+	assetName := selfUpdateAssetName(runtime.GOOS, runtime.GOARCH)
+	hash := sha256.Sum256([]byte(binary))
+	checksums := fmt.Sprintf("%s  %s\n", hex.EncodeToString(hash[:]), assetName)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, r *http.Request) {
+		baseUrl := "http://" + r.Host
+		fmt.Fprintf(w, `[
+  {"tag_name": "v0.13.1", "assets": [
+    {"name": "%s", "browser_download_url": "%s/download/%s"},
+    {"name": "SHA256SUMS", "browser_download_url": "%s/download/SHA256SUMS"},
+    {"name": "SHA256SUMS.sig", "browser_download_url": "%s/download/SHA256SUMS.sig"}
+  ]},
+  {"tag_name": "v0.12.0", "assets": []}
+]`, assetName, baseUrl, assetName, baseUrl, baseUrl)
+	})
+	mux.HandleFunc("/download/"+assetName, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, servedBinary)
+	})
+	mux.HandleFunc("/download/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, checksums)
+	})
+	mux.HandleFunc("/download/SHA256SUMS.sig", func(w http.ResponseWriter, r *http.Request) {
+		if signer == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		openpgp.ArmoredDetachSign(w, signer, bytes.NewReader([]byte(checksums)), nil)
+	})
+	return mux
+}
+
+func selfUpdateOptionsForTest(t *testing.T, serverUrl string, args ...string) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("terraform.tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.TerragruntVersion = "v0.12.0"
+	terragruntOptions.TerraformCliArgs = args
+	terragruntOptions.Env = map[string]string{SELF_UPDATE_URL_ENV_VAR: serverUrl + "/releases"}
+	return terragruntOptions
+}
+
+func writeTempFileForTest(t *testing.T, contents string) string {
+	file, err := ioutil.TempFile("", "self-update-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(contents); err != nil {
+		t.Fatal(err)
+	}
+	return file.Name()
+}
+
+func armoredPublicKeyForTest(t *testing.T, entity *openpgp.Entity) string {
+	var out bytes.Buffer
+	writer, err := armor.Encode(&out, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(writer); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func assertFileContents(t *testing.T, path string, expected string) {
+	actual, err := ioutil.ReadFile(path)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		assert.Equal(t, expected, string(actual))
+	}
+}
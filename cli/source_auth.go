@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The login to use with the token in SourceTokenEnvVar. GitHub requires this login for app and Actions tokens and
+// accepts it for personal access tokens, while GitLab and most other Git hosts accept any login with a token.
+const SOURCE_TOKEN_LOGIN = "x-access-token"
+
+// Configure the given options, which will be used to run terraform init to download the given source, with the
+// credentials in SourceSshKeyPath and SourceTokenEnvVar of the original options. We only set environment variables on
+// the terraform init process (and therefore on the git processes it runs), rather than modifying the user's git config,
+// so this is safe to use on shared CI machines. The returned function cleans up any temporary files this method
+// creates, and should be called once terraform init has finished.
+//
+// If SourceSshKeyPath is set, we set GIT_SSH_COMMAND so that git uses that key for SSH URLs.
+//
+// If SourceTokenEnvVar is set and the source is an HTTP or HTTPS URL, we use the token in that environment variable to
+// authenticate to the host of the source in two ways: we pass git an Authorization header for that host via the
+// GIT_CONFIG_COUNT environment variables, and we generate a netrc file for that host and point the NETRC environment
+// variable at it, which is what Terraform uses when downloading plain HTTP sources, such as archives.
+func configureSourceDownloadCredentials(terraformSource *TerraformSource, initOptions *options.TerragruntOptions, terragruntOptions *options.TerragruntOptions) (func(), error) {
+	cleanup := func() {}
+
+	if terragruntOptions.SourceSshKeyPath != "" {
+		sshKeyPath, err := util.CanonicalPath(terragruntOptions.SourceSshKeyPath, terragruntOptions.WorkingDir)
+		if err != nil {
+			return cleanup, err
+		}
+		if !util.FileExists(sshKeyPath) {
+			return cleanup, errors.WithStackTrace(SourceSshKeyNotFound(sshKeyPath))
+		}
+
+		terragruntOptions.Logger.Printf("Using SSH key %s to download Terraform configurations", sshKeyPath)
+		initOptions.Env["GIT_SSH_COMMAND"] = fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", shellQuote(sshKeyPath))
+	}
+
+	if terragruntOptions.SourceTokenEnvVar == "" {
+		return cleanup, nil
+	}
+
+	token := terragruntOptions.Env[terragruntOptions.SourceTokenEnvVar]
+	if token == "" {
+		return cleanup, errors.WithStackTrace(SourceTokenEnvVarNotSet(terragruntOptions.SourceTokenEnvVar))
+	}
+
+	host, isHttp := getHttpSourceHost(terraformSource)
+	if !isHttp {
+		terragruntOptions.Logger.Printf("Source %s is not an HTTP or HTTPS URL, so the token in %s will not be used to download it", terraformSource.CanonicalSourceURL, terragruntOptions.SourceTokenEnvVar)
+		return cleanup, nil
+	}
+
+	terragruntOptions.Logger.Printf("Using the token in %s to download Terraform configurations from %s", terragruntOptions.SourceTokenEnvVar, host)
+
+	netrcPath, err := writeNetrcFile(host, SOURCE_TOKEN_LOGIN, token)
+	if err != nil {
+		return cleanup, err
+	}
+	cleanup = func() {
+		if err := os.Remove(netrcPath); err != nil {
+			terragruntOptions.Logger.Printf("Failed to delete temporary netrc file %s: %v", netrcPath, err)
+		}
+	}
+	initOptions.Env["NETRC"] = netrcPath
+
+	basicAuth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", SOURCE_TOKEN_LOGIN, token)))
+	for _, scheme := range []string{"http", "https"} {
+		addGitConfigEnvVars(initOptions.Env, fmt.Sprintf("http.%s://%s/.extraHeader", scheme, host), fmt.Sprintf("Authorization: Basic %s", basicAuth))
+	}
+
+	return cleanup, nil
+}
+
+// If the given source is downloaded over HTTP or HTTPS (e.g. https://github.com/foo/bar or
+// git::https://github.com/foo/bar.git), return its host and true. Otherwise, return false.
+func getHttpSourceHost(terraformSource *TerraformSource) (string, bool) {
+	_, scheme := getForcedGetter(terraformSource.CanonicalSourceURL.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", false
+	}
+
+	return terraformSource.CanonicalSourceURL.Host, true
+}
+
+// Write a temporary netrc file with the given credentials for the given host and return its path. The file is only
+// readable by the current user.
+func writeNetrcFile(host string, login string, password string) (string, error) {
+	netrcFile, err := ioutil.TempFile("", "terragrunt-netrc")
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	defer netrcFile.Close()
+
+	if err := netrcFile.Chmod(0600); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	if _, err := fmt.Fprintf(netrcFile, "machine %s\nlogin %s\npassword %s\n", host, login, password); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return netrcFile.Name(), nil
+}
+
+// Add the given git config key and value to the given environment variables using the GIT_CONFIG_COUNT,
+// GIT_CONFIG_KEY_<n>, and GIT_CONFIG_VALUE_<n> environment variables, keeping any git config the environment variables
+// already contain. Git applies this config on top of the user's git config files, without modifying them.
+func addGitConfigEnvVars(env map[string]string, key string, value string) {
+	count, err := strconv.Atoi(env["GIT_CONFIG_COUNT"])
+	if err != nil {
+		count = 0
+	}
+
+	env[fmt.Sprintf("GIT_CONFIG_KEY_%d", count)] = key
+	env[fmt.Sprintf("GIT_CONFIG_VALUE_%d", count)] = value
+	env["GIT_CONFIG_COUNT"] = strconv.Itoa(count + 1)
+}
+
+// Quote the given string so a POSIX shell treats it as a single word
+func shellQuote(str string) string {
+	return "'" + strings.Replace(str, "'", `'\''`, -1) + "'"
+}
+
+// Custom error types
+
+type SourceSshKeyNotFound string
+
+func (path SourceSshKeyNotFound) Error() string {
+	return fmt.Sprintf("The SSH key %s specified via --%s does not exist", string(path), OPT_TERRAGRUNT_SOURCE_SSH_KEY)
+}
+
+type SourceTokenEnvVarNotSet string
+
+func (envVar SourceTokenEnvVarNotSet) Error() string {
+	return fmt.Sprintf("The environment variable %s specified via --%s is not set or is empty", string(envVar), OPT_TERRAGRUNT_SOURCE_TOKEN_ENV_VAR)
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureSourceDownloadCredentialsNoCredentials(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, initOptions := createSourceAuthTestOptions(t)
+
+	cleanup, err := configureSourceDownloadCredentials(createSourceAuthTestSource(t, "git::https://github.com/foo/bar.git"), initOptions, terragruntOptions)
+	defer cleanup()
+
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, terragruntOptions.Env, initOptions.Env)
+}
+
+func TestConfigureSourceDownloadCredentialsSshKey(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, initOptions := createSourceAuthTestOptions(t)
+	terragruntOptions.SourceSshKeyPath = "../test/fixture-download-source/hello-world/main.tf"
+
+	cleanup, err := configureSourceDownloadCredentials(createSourceAuthTestSource(t, "git::ssh://git@github.com/foo/bar.git"), initOptions, terragruntOptions)
+	defer cleanup()
+
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, fmt.Sprintf("ssh -i '%s' -o IdentitiesOnly=yes", absPath(t, "../test/fixture-download-source/hello-world/main.tf")), initOptions.Env["GIT_SSH_COMMAND"])
+}
+
+func TestConfigureSourceDownloadCredentialsSshKeyNotFound(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, initOptions := createSourceAuthTestOptions(t)
+	terragruntOptions.SourceSshKeyPath = "../test/fixture-download-source/does-not-exist"
+
+	cleanup, err := configureSourceDownloadCredentials(createSourceAuthTestSource(t, "git::ssh://git@github.com/foo/bar.git"), initOptions, terragruntOptions)
+	defer cleanup()
+
+	_, isSshKeyNotFoundErr := errors.Unwrap(err).(SourceSshKeyNotFound)
+	assert.True(t, isSshKeyNotFoundErr, "Expected a SourceSshKeyNotFound error but got: %v", err)
+}
+
+func TestConfigureSourceDownloadCredentialsToken(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, initOptions := createSourceAuthTestOptions(t)
+	terragruntOptions.SourceTokenEnvVar = "MY_GIT_TOKEN"
+	terragruntOptions.Env["MY_GIT_TOKEN"] = "secret-token"
+	initOptions.Env["GIT_CONFIG_COUNT"] = "1"
+	initOptions.Env["GIT_CONFIG_KEY_0"] = "core.autocrlf"
+	initOptions.Env["GIT_CONFIG_VALUE_0"] = "false"
+
+	cleanup, err := configureSourceDownloadCredentials(createSourceAuthTestSource(t, "git::https://github.com/foo/bar.git"), initOptions, terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	expectedHeader := fmt.Sprintf("Authorization: Basic %s", base64.StdEncoding.EncodeToString([]byte("x-access-token:secret-token")))
+	assert.Equal(t, "3", initOptions.Env["GIT_CONFIG_COUNT"])
+	assert.Equal(t, "core.autocrlf", initOptions.Env["GIT_CONFIG_KEY_0"])
+	assert.Equal(t, "http.http://github.com/.extraHeader", initOptions.Env["GIT_CONFIG_KEY_1"])
+	assert.Equal(t, expectedHeader, initOptions.Env["GIT_CONFIG_VALUE_1"])
+	assert.Equal(t, "http.https://github.com/.extraHeader", initOptions.Env["GIT_CONFIG_KEY_2"])
+	assert.Equal(t, expectedHeader, initOptions.Env["GIT_CONFIG_VALUE_2"])
+
+	netrcPath := initOptions.Env["NETRC"]
+	if assert.True(t, util.FileExists(netrcPath), "Expected netrc file %s to exist", netrcPath) {
+		netrcContents, err := util.ReadFileAsString(netrcPath)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, "machine github.com\nlogin x-access-token\npassword secret-token\n", netrcContents)
+
+		fileInfo, err := os.Stat(netrcPath)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, os.FileMode(0600), fileInfo.Mode().Perm())
+	}
+
+	cleanup()
+	assert.False(t, util.FileExists(netrcPath), "Expected netrc file %s to be deleted", netrcPath)
+}
+
+func TestConfigureSourceDownloadCredentialsTokenNotSet(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, initOptions := createSourceAuthTestOptions(t)
+	terragruntOptions.SourceTokenEnvVar = "MY_GIT_TOKEN"
+
+	cleanup, err := configureSourceDownloadCredentials(createSourceAuthTestSource(t, "git::https://github.com/foo/bar.git"), initOptions, terragruntOptions)
+	defer cleanup()
+
+	assert.Equal(t, SourceTokenEnvVarNotSet("MY_GIT_TOKEN"), errors.Unwrap(err))
+}
+
+func TestConfigureSourceDownloadCredentialsTokenNotHttpSource(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, initOptions := createSourceAuthTestOptions(t)
+	terragruntOptions.SourceTokenEnvVar = "MY_GIT_TOKEN"
+	terragruntOptions.Env["MY_GIT_TOKEN"] = "secret-token"
+
+	cleanup, err := configureSourceDownloadCredentials(createSourceAuthTestSource(t, "git::ssh://git@github.com/foo/bar.git"), initOptions, terragruntOptions)
+	defer cleanup()
+
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "", initOptions.Env["NETRC"])
+	assert.Equal(t, "", initOptions.Env["GIT_CONFIG_COUNT"])
+}
+
+func createSourceAuthTestOptions(t *testing.T) (*options.TerragruntOptions, *options.TerragruntOptions) {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.WorkingDir = "."
+
+	initOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	return terragruntOptions, initOptions
+}
+
+func createSourceAuthTestSource(t *testing.T, sourceUrl string) *TerraformSource {
+	canonicalSourceUrl, err := parseSourceUrl(sourceUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &TerraformSource{CanonicalSourceURL: canonicalSourceUrl}
+}
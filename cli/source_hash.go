@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// The optional prefix of the source_hash in the terraform block, which identifies the hash algorithm
+const SOURCE_HASH_PREFIX = "sha256:"
+
+// The names of the folders in a downloaded source that are excluded from its hash, as they are created or modified by
+// Terraform and Git rather than being part of the source code itself
+var SOURCE_HASH_EXCLUDED_FOLDERS = []string{".terraform", ".git"}
+
+// If the given source has an expected hash, calculate the hash of the code that was just downloaded into its
+// DownloadDir and return an error if the two don't match. On a mismatch, we also delete the DownloadDir, so the
+// unverified code can't be used by a later run.
+func verifySourceHash(terraformSource *TerraformSource, terragruntOptions *options.TerragruntOptions) error {
+	if terraformSource.ExpectedHash == "" {
+		return nil
+	}
+
+	actualHash, err := computeSourceHash(terraformSource.DownloadDir, terraformSource.VersionFile)
+	if err != nil {
+		return err
+	}
+
+	expectedHash := strings.ToLower(strings.TrimPrefix(terraformSource.ExpectedHash, SOURCE_HASH_PREFIX))
+	if actualHash != expectedHash {
+		terragruntOptions.Logger.Printf("The hash of the code downloaded from %s does not match source_hash, so deleting %s", terraformSource.CanonicalSourceURL, terraformSource.DownloadDir)
+		if err := os.RemoveAll(terraformSource.DownloadDir); err != nil {
+			return errors.WithStackTrace(err)
+		}
+		return errors.WithStackTrace(SourceHashMismatch{Source: terraformSource.CanonicalSourceURL.String(), ExpectedHash: terraformSource.ExpectedHash, ActualHash: SOURCE_HASH_PREFIX + actualHash})
+	}
+
+	terragruntOptions.Logger.Printf("The hash of the code downloaded from %s matches source_hash", terraformSource.CanonicalSourceURL)
+	return nil
+}
+
+// Calculate the sha256 hash of the tree of regular files in the given folder, excluding the given version file and the
+// files in any SOURCE_HASH_EXCLUDED_FOLDERS. To make the hash easy to reproduce with standard tools, it's the sha256 of
+// the output sha256sum would print for those files, sorted by path, with each path relative to the folder. That is,
+// it's the same as the output of the following command, run in the folder:
+//
+// find . -type f -not -path '*/.terraform/*' -not -path '*/.git/*' -not -path ./.terragrunt-source-version | cut -c 3- | LC_ALL=C sort | xargs sha256sum | sha256sum
+func computeSourceHash(folder string, versionFile string) (string, error) {
+	files := []string{}
+
+	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			for _, excludedFolder := range SOURCE_HASH_EXCLUDED_FOLDERS {
+				if info.Name() == excludedFolder {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() || filepath.ToSlash(path) == filepath.ToSlash(versionFile) {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(folder, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relativePath))
+		return nil
+	})
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	sort.Strings(files)
+
+	treeHash := sha256.New()
+	for _, file := range files {
+		fileHash, err := computeFileHash(filepath.Join(folder, filepath.FromSlash(file)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(treeHash, "%s  %s\n", fileHash, file)
+	}
+
+	return hex.EncodeToString(treeHash.Sum(nil)), nil
+}
+
+// Return the hex encoded sha256 hash of the contents of the given file
+func computeFileHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	defer file.Close()
+
+	fileHash := sha256.New()
+	if _, err := io.Copy(fileHash, file); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return hex.EncodeToString(fileHash.Sum(nil)), nil
+}
+
+// Custom error types
+
+type SourceHashMismatch struct {
+	Source       string
+	ExpectedHash string
+	ActualHash   string
+}
+
+func (err SourceHashMismatch) Error() string {
+	return fmt.Sprintf("The code downloaded from %s does not match the source_hash in the Terragrunt configuration. Expected %s but got %s.", err.Source, err.ExpectedHash, err.ActualHash)
+}
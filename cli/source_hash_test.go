@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeSourceHash(t *testing.T) {
+	t.Parallel()
+
+	downloadDir := createSourceHashTestFolder(t)
+	defer os.RemoveAll(downloadDir)
+
+	expectedTree := fmt.Sprintf("%s  main.tf\n%s  modules/app/main.tf\n", sha256Hex("# main"), sha256Hex("# app"))
+
+	actual, err := computeSourceHash(downloadDir, util.JoinPath(downloadDir, ".terragrunt-source-version"))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, sha256Hex(expectedTree), actual)
+}
+
+func TestComputeSourceHashChangesWithContents(t *testing.T) {
+	t.Parallel()
+
+	downloadDir := createSourceHashTestFolder(t)
+	defer os.RemoveAll(downloadDir)
+
+	versionFile := util.JoinPath(downloadDir, ".terragrunt-source-version")
+
+	before, err := computeSourceHash(downloadDir, versionFile)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	writeSourceHashTestFile(t, downloadDir, "modules/app/main.tf", "# app, modified")
+
+	after, err := computeSourceHash(downloadDir, versionFile)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.NotEqual(t, before, after)
+}
+
+func TestVerifySourceHash(t *testing.T) {
+	t.Parallel()
+
+	downloadDir := createSourceHashTestFolder(t)
+	defer os.RemoveAll(downloadDir)
+
+	terraformSource := createSourceHashTestSource(t, downloadDir)
+	expectedHash, err := computeSourceHash(downloadDir, terraformSource.VersionFile)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	for _, hash := range []string{"", expectedHash, SOURCE_HASH_PREFIX + expectedHash} {
+		terraformSource.ExpectedHash = hash
+		err := verifySourceHash(terraformSource, terragruntOptions)
+		assert.Nil(t, err, "Unexpected error for hash '%s': %v", hash, err)
+	}
+
+	assert.True(t, util.FileExists(downloadDir))
+}
+
+func TestVerifySourceHashMismatch(t *testing.T) {
+	t.Parallel()
+
+	downloadDir := createSourceHashTestFolder(t)
+	defer os.RemoveAll(downloadDir)
+
+	terraformSource := createSourceHashTestSource(t, downloadDir)
+	terraformSource.ExpectedHash = SOURCE_HASH_PREFIX + sha256Hex("not the right code")
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	err = verifySourceHash(terraformSource, terragruntOptions)
+	_, isMismatchErr := errors.Unwrap(err).(SourceHashMismatch)
+	assert.True(t, isMismatchErr, "Expected a SourceHashMismatch error but got: %v", err)
+	assert.False(t, util.FileExists(downloadDir), "Expected download dir %s to be deleted", downloadDir)
+}
+
+func TestGetSourceVersion(t *testing.T) {
+	t.Parallel()
+
+	terraformSource := &TerraformSource{CanonicalSourceURL: parseUrl(t, "http://www.some-url.com?ref=v0.0.1")}
+	withoutHash := getSourceVersion(terraformSource)
+	assert.Equal(t, encodeSourceVersion(terraformSource.CanonicalSourceURL), withoutHash)
+
+	terraformSource.ExpectedHash = "sha256:abc"
+	withHash := getSourceVersion(terraformSource)
+	assert.NotEqual(t, withoutHash, withHash)
+
+	terraformSource.ExpectedHash = "sha256:def"
+	assert.NotEqual(t, withHash, getSourceVersion(terraformSource))
+}
+
+// Create a folder that looks like downloaded source code, including the files that should not be part of its hash
+func createSourceHashTestFolder(t *testing.T) string {
+	downloadDir := tmpDir(t)
+
+	writeSourceHashTestFile(t, downloadDir, "main.tf", "# main")
+	writeSourceHashTestFile(t, downloadDir, "modules/app/main.tf", "# app")
+	writeSourceHashTestFile(t, downloadDir, ".terragrunt-source-version", "some-version")
+	writeSourceHashTestFile(t, downloadDir, ".terraform/terraform.tfstate", "{}")
+	writeSourceHashTestFile(t, downloadDir, "modules/app/.terraform/modules/modules.json", "{}")
+	writeSourceHashTestFile(t, downloadDir, ".git/HEAD", "ref: refs/heads/master")
+
+	return downloadDir
+}
+
+func createSourceHashTestSource(t *testing.T, downloadDir string) *TerraformSource {
+	return &TerraformSource{
+		CanonicalSourceURL: parseUrl(t, "http://www.some-url.com?ref=v0.0.1"),
+		DownloadDir:        downloadDir,
+		WorkingDir:         downloadDir,
+		VersionFile:        util.JoinPath(downloadDir, ".terragrunt-source-version"),
+	}
+}
+
+func writeSourceHashTestFile(t *testing.T, folder string, path string, contents string) {
+	fullPath := util.JoinPath(folder, path)
+	if err := os.MkdirAll(util.JoinPath(fullPath, ".."), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fullPath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sha256Hex(str string) string {
+	hash := sha256.Sum256([]byte(str))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The Terraform commands before which we back up the state if the Terragrunt config has a state_backup block
+var TERRAFORM_COMMANDS_THAT_NEED_STATE_BACKUP = []string{
+	CMD_APPLY,
+	CMD_DESTROY,
+}
+
+// The format of the timestamp in the names of state backup files. It sorts in chronological order and contains no
+// characters that are invalid in file names on Windows.
+const STATE_BACKUP_TIMESTAMP_FORMAT = "20060102T150405Z"
+
+// If the Terragrunt config has a state_backup block and the user is about to run a command that modifies state (e.g.
+// apply or destroy), save a copy of the current state, as returned by 'terraform state pull', into the backup folder
+func backupStateIfNecessary(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	command := firstArg(terragruntOptions.TerraformCliArgs)
+	if terragruntConfig.StateBackup == nil || !util.ListContainsElement(TERRAFORM_COMMANDS_THAT_NEED_STATE_BACKUP, command) {
+		return nil
+	}
+
+	backupDir, err := util.CanonicalPath(terragruntConfig.StateBackup.Path, filepath.Dir(terragruntOptions.TerragruntConfigPath))
+	if err != nil {
+		return err
+	}
+
+	// The backup must be the whole state, as it was, so that it can be pushed back, so it's neither limited nor redacted
+	output, err := shell.RunTerraformCommandAndCaptureOutput(terragruntOptions, shell.CaptureOptions{MaxBytes: -1}, "state", "pull")
+	if err != nil {
+		return errors.WithStackTrace(StateBackupFailed{WorkingDir: terragruntOptions.WorkingDir, Underlying: err})
+	}
+	state := output.Stdout
+
+	if strings.TrimSpace(state) == "" {
+		terragruntOptions.Logger.Printf("No state found for the module in %s, so there is nothing to back up", terragruntOptions.WorkingDir)
+		return nil
+	}
+
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	backupPath := util.JoinPath(backupDir, getStateBackupFileName(command, time.Now()))
+	terragruntOptions.Logger.Printf("Backing up the current state to %s before running 'terraform %s'", backupPath, command)
+
+	if err := ioutil.WriteFile(backupPath, []byte(state), 0600); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	return nil
+}
+
+// Return the name of the file to use for a backup of the state taken at the given time before running the given command
+func getStateBackupFileName(command string, now time.Time) string {
+	return fmt.Sprintf("%s-%s.tfstate", now.UTC().Format(STATE_BACKUP_TIMESTAMP_FORMAT), command)
+}
+
+// Custom error types
+
+type StateBackupFailed struct {
+	WorkingDir string
+	Underlying error
+}
+
+func (err StateBackupFailed) Error() string {
+	return fmt.Sprintf("Unable to back up the state of the module in %s using 'terraform state pull': %v", err.WorkingDir, err.Underlying)
+}
+
+func (err StateBackupFailed) Unwrap() error {
+	return err.Underlying
+}
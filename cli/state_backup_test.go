@@ -0,0 +1,17 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStateBackupFileName(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2018, time.March, 4, 15, 6, 7, 0, time.FixedZone("UTC-8", -8*60*60))
+
+	assert.Equal(t, "20180304T230607Z-apply.tfstate", getStateBackupFileName("apply", now))
+	assert.Equal(t, "20180304T230607Z-destroy.tfstate", getStateBackupFileName("destroy", now))
+}
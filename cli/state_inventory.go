@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const CMD_STATE_INVENTORY = "state-inventory"
+
+// Pass this flag, followed by one of the STATE_INVENTORY_FORMATS, to the state-inventory command to pick the format
+const STATE_INVENTORY_FORMAT_FLAG = "--format"
+
+const STATE_INVENTORY_FORMAT_JSON = "json"
+const STATE_INVENTORY_FORMAT_CSV = "csv"
+
+var STATE_INVENTORY_FORMATS = []string{STATE_INVENTORY_FORMAT_JSON, STATE_INVENTORY_FORMAT_CSV}
+
+// The columns of the state inventory in CSV format, which match the fields of the JSON format
+var STATE_INVENTORY_CSV_HEADER = []string{"module_path", "address", "type", "name", "id"}
+
+// Pull the state of every module in the stack in the subfolders of the working dir and write all the resources in
+// them, with their type, name, ID, and the path of their module, to the Writer of the given options, in the format
+// passed with STATE_INVENTORY_FORMAT_FLAG, so they can be loaded into a CMDB or kept for an audit. If some modules
+// fail, the resources of the other modules are still written, but the command fails.
+func stateInventory(terragruntOptions *options.TerragruntOptions) ([]configstack.ModuleResult, error) {
+	format, err := parseStateInventoryArgs(terragruntOptions.TerraformCliArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	// The stack adds the state pull command itself, so there are no args to pass on
+	terragruntOptions.TerraformCliArgs = []string{}
+
+	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	terragruntOptions.Logger.Printf("%s", stack.String())
+	inventory, results, err := stack.StateInventory(terragruntOptions)
+	if results == nil {
+		return nil, err
+	}
+
+	terragruntOptions.Logger.Printf("Found %d resources in %d modules", len(inventory), len(stack.Modules))
+	if writeErr := writeStateInventory(inventory, format, terragruntOptions.Writer); writeErr != nil {
+		return results, writeErr
+	}
+
+	return results, err
+}
+
+// Return the format passed with STATE_INVENTORY_FORMAT_FLAG in the given args after the state-inventory command, or
+// json if there is none
+func parseStateInventoryArgs(args []string) (string, error) {
+	format := STATE_INVENTORY_FORMAT_JSON
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == STATE_INVENTORY_FORMAT_FLAG:
+			if i+1 >= len(args) {
+				return "", errors.WithStackTrace(InvalidStateInventoryArgs(args))
+			}
+			format = args[i+1]
+			i++
+		case strings.HasPrefix(arg, STATE_INVENTORY_FORMAT_FLAG+"="):
+			format = strings.TrimPrefix(arg, STATE_INVENTORY_FORMAT_FLAG+"=")
+		default:
+			return "", errors.WithStackTrace(InvalidStateInventoryArgs(args))
+		}
+	}
+
+	if !util.ListContainsElement(STATE_INVENTORY_FORMATS, format) {
+		return "", errors.WithStackTrace(UnsupportedStateInventoryFormat(format))
+	}
+	return format, nil
+}
+
+// Write the given inventory to the given writer in the given format
+func writeStateInventory(inventory []configstack.InventoryResource, format string, writer io.Writer) error {
+	if format == STATE_INVENTORY_FORMAT_CSV {
+		csvWriter := csv.NewWriter(writer)
+		csvWriter.Write(STATE_INVENTORY_CSV_HEADER)
+		for _, resource := range inventory {
+			csvWriter.Write([]string{resource.ModulePath, resource.Address, resource.Type, resource.Name, resource.Id})
+		}
+		csvWriter.Flush()
+		return errors.WithStackTrace(csvWriter.Error())
+	}
+
+	out, err := json.MarshalIndent(map[string][]configstack.InventoryResource{"resources": inventory}, "", "  ")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	_, err = fmt.Fprintln(writer, string(out))
+	return errors.WithStackTrace(err)
+}
+
+// Custom error types
+
+type InvalidStateInventoryArgs []string
+
+func (args InvalidStateInventoryArgs) Error() string {
+	return fmt.Sprintf("Invalid args for the %s command: %v. Usage: terragrunt %s [%s <%s>]", CMD_STATE_INVENTORY, []string(args), CMD_STATE_INVENTORY, STATE_INVENTORY_FORMAT_FLAG, strings.Join(STATE_INVENTORY_FORMATS, "|"))
+}
+
+type UnsupportedStateInventoryFormat string
+
+func (format UnsupportedStateInventoryFormat) Error() string {
+	return fmt.Sprintf("Unsupported format for the %s command: '%s'. Supported formats are: %s", CMD_STATE_INVENTORY, string(format), strings.Join(STATE_INVENTORY_FORMATS, ", "))
+}
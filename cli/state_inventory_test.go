@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStateInventoryArgs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{}, STATE_INVENTORY_FORMAT_JSON},
+		{[]string{"--format", "csv"}, STATE_INVENTORY_FORMAT_CSV},
+		{[]string{"--format=json"}, STATE_INVENTORY_FORMAT_JSON},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := parseStateInventoryArgs(testCase.args)
+		assert.Nil(t, err, "Unexpected error for args %v: %v", testCase.args, err)
+		assert.Equal(t, testCase.expected, actual, "For args %v", testCase.args)
+	}
+
+	_, err := parseStateInventoryArgs([]string{"--format", "xml"})
+	_, isUnsupportedFormatErr := errors.Unwrap(err).(UnsupportedStateInventoryFormat)
+	assert.True(t, isUnsupportedFormatErr, "Expected an UnsupportedStateInventoryFormat error but got: %v", err)
+
+	for _, args := range [][]string{{"--format"}, {"-json"}} {
+		_, err := parseStateInventoryArgs(args)
+		_, isInvalidArgsErr := errors.Unwrap(err).(InvalidStateInventoryArgs)
+		assert.True(t, isInvalidArgsErr, "Expected an InvalidStateInventoryArgs error for args %v but got: %v", args, err)
+	}
+}
+
+func TestWriteStateInventory(t *testing.T) {
+	t.Parallel()
+
+	inventory := []configstack.InventoryResource{
+		{ModulePath: "/live/app", Address: `aws_iam_user.users["alice"]`, Type: "aws_iam_user", Name: "users", Id: "alice"},
+		{ModulePath: "/live/vpc", Address: "aws_vpc.main", Type: "aws_vpc", Name: "main", Id: "vpc-123"},
+	}
+
+	var csvOut bytes.Buffer
+	err := writeStateInventory(inventory, STATE_INVENTORY_FORMAT_CSV, &csvOut)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	expectedCSV := "module_path,address,type,name,id\n" +
+		`/live/app,"aws_iam_user.users[""alice""]",aws_iam_user,users,alice` + "\n" +
+		"/live/vpc,aws_vpc.main,aws_vpc,main,vpc-123\n"
+	assert.Equal(t, expectedCSV, csvOut.String())
+
+	var jsonOut bytes.Buffer
+	err = writeStateInventory(inventory[1:], STATE_INVENTORY_FORMAT_JSON, &jsonOut)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	expectedJSON := `{
+  "resources": [
+    {
+      "module_path": "/live/vpc",
+      "address": "aws_vpc.main",
+      "type": "aws_vpc",
+      "name": "main",
+      "id": "vpc-123"
+    }
+  ]
+}
+`
+	assert.Equal(t, expectedJSON, jsonOut.String())
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Terraform adds the args in this environment variable to every command it runs
+const TF_CLI_ARGS_ENV_VAR = "TF_CLI_ARGS"
+
+// Return the name of the environment variable with the args Terraform adds to the given command only, such as
+// TF_CLI_ARGS_plan
+func tfCliArgsEnvVarForCommand(command string) string {
+	return fmt.Sprintf("%s_%s", TF_CLI_ARGS_ENV_VAR, command)
+}
+
+// Terraform adds the args in the TF_CLI_ARGS and TF_CLI_ARGS_<command> environment variables right after the command,
+// before any other args. If we left that to Terraform, the args Terragrunt adds, such as -input=false, could duplicate
+// or conflict with them without Terragrunt knowing. Instead, we add the args from those environment variables to the
+// args of the user's command ourselves, after the command and before the user's own args, and remove the environment
+// variables when we run that command (see withoutTfCliArgsEnvVars). The args passed for the command with
+// --terragrunt-tf-arg are added the same way, after them. Since Terraform uses the last value of a flag that is set
+// more than once, the precedence is:
+//
+//  1. The args the user passed on the command line
+//  2. --terragrunt-tf-arg
+//  3. TF_CLI_ARGS_<command>
+//  4. TF_CLI_ARGS
+//  5. The args from extra_arguments and the ones Terragrunt adds itself, which Terragrunt only adds if the args above
+//     don't already set the same flag
+//
+// The environment variables are read from the env Terraform runs with, so if the env_vars of the config set one of
+// them, the value from the config replaces the one Terragrunt inherited, rather than adding to it.
+func mergeTfCliArgsEnvVars(terragruntOptions *options.TerragruntOptions) error {
+	command := firstArg(terragruntOptions.TerraformCliArgs)
+	if command == "" {
+		return nil
+	}
+
+	envArgs := []string{}
+	for _, envVar := range []string{TF_CLI_ARGS_ENV_VAR, tfCliArgsEnvVarForCommand(command)} {
+		value, isSet := terragruntOptions.Env[envVar]
+		if !isSet || value == "" {
+			continue
+		}
+
+		args, err := util.ParseShellWords(value)
+		if err != nil {
+			return err
+		}
+
+		logArgsForDebug(terragruntOptions, fmt.Sprintf("the %s environment variable", envVar), args)
+		envArgs = append(envArgs, args...)
+	}
+
+	if args := terraformArgsForCommand(terragruntOptions.TerraformArgs, command); len(args) > 0 {
+		logArgsForDebug(terragruntOptions, "--"+OPT_TERRAGRUNT_TF_ARG, args)
+		envArgs = append(envArgs, args...)
+	}
+
+	if len(envArgs) > 0 {
+		terragruntOptions.InsertTerraformCliArgs(envArgs...)
+	}
+
+	return nil
+}
+
+// Return the args for the given Terraform command in the given list of args passed with --terragrunt-tf-arg, each of
+// the form <command>=<arg>, e.g. apply=-target=module.vpc
+func terraformArgsForCommand(terraformArgs []string, command string) []string {
+	args := []string{}
+	for _, terraformArg := range terraformArgs {
+		if argCommand, arg, isValid := splitTerraformArg(terraformArg); isValid && argCommand == command {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// Split the given value of --terragrunt-tf-arg into the command and the arg. Returns false if it isn't of the form
+// <command>=<arg>, e.g. if the command was left out of -target=module.vpc.
+func splitTerraformArg(terraformArg string) (string, string, bool) {
+	parts := strings.SplitN(terraformArg, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || strings.HasPrefix(parts[0], "-") || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// Return a copy of the given options to run the user's command with, whose env doesn't contain the TF_CLI_ARGS
+// environment variables for that command, as mergeTfCliArgsEnvVars already added their args to the command
+func withoutTfCliArgsEnvVars(terragruntOptions *options.TerragruntOptions) *options.TerragruntOptions {
+	env := util.CloneStringMap(terragruntOptions.Env)
+	delete(env, TF_CLI_ARGS_ENV_VAR)
+	delete(env, tfCliArgsEnvVarForCommand(firstArg(terragruntOptions.TerraformCliArgs)))
+
+	optionsCopy := *terragruntOptions
+	optionsCopy.Env = env
+	return &optionsCopy
+}
+
+// If the user passed --terragrunt-debug-args, log the given args, which came from the given source
+func logArgsForDebug(terragruntOptions *options.TerragruntOptions, source string, args []string) {
+	if terragruntOptions.DebugArgs {
+		terragruntOptions.Logger.Printf("Args from %s: %v", source, args)
+	}
+}
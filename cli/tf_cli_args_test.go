@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeTfCliArgsEnvVars(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args     []string
+		env      map[string]string
+		expected []string
+	}{
+		{[]string{"plan"}, map[string]string{}, []string{"plan"}},
+		{[]string{"plan", "-out=foo"}, map[string]string{"TF_CLI_ARGS": "-input=false"}, []string{"plan", "-input=false", "-out=foo"}},
+		{[]string{"plan"}, map[string]string{"TF_CLI_ARGS": "-lock=false", "TF_CLI_ARGS_plan": "-var 'a=b c'"}, []string{"plan", "-lock=false", "-var", "a=b c"}},
+		{[]string{"apply"}, map[string]string{"TF_CLI_ARGS_plan": "-refresh=false"}, []string{"apply"}},
+		{[]string{"state", "list"}, map[string]string{"TF_CLI_ARGS_state": "-state=foo"}, []string{"state", "list", "-state=foo"}},
+		{[]string{}, map[string]string{"TF_CLI_ARGS": "-input=false"}, []string{}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.TerraformCliArgs = testCase.args
+		terragruntOptions.Env = testCase.env
+
+		err = mergeTfCliArgsEnvVars(terragruntOptions)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, testCase.expected, terragruntOptions.TerraformCliArgs, "For args %v and env %v", testCase.args, testCase.env)
+	}
+}
+
+func TestMergeTfCliArgsEnvVarsWithTerraformArgs(t *testing.T) {
+	t.Parallel()
+
+	terraformArgs := []string{"apply=-target=module.vpc", "plan=-refresh=false", "apply=-parallelism=2"}
+
+	testCases := []struct {
+		args     []string
+		env      map[string]string
+		expected []string
+	}{
+		{[]string{"apply", "-auto-approve"}, map[string]string{}, []string{"apply", "-target=module.vpc", "-parallelism=2", "-auto-approve"}},
+		{[]string{"plan"}, map[string]string{"TF_CLI_ARGS_plan": "-refresh=true"}, []string{"plan", "-refresh=true", "-refresh=false"}},
+		{[]string{"destroy"}, map[string]string{}, []string{"destroy"}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.TerraformCliArgs = testCase.args
+		terragruntOptions.Env = testCase.env
+		terragruntOptions.TerraformArgs = terraformArgs
+
+		err = mergeTfCliArgsEnvVars(terragruntOptions)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, testCase.expected, terragruntOptions.TerraformCliArgs, "For args %v and env %v", testCase.args, testCase.env)
+	}
+}
+
+func TestMergeTfCliArgsEnvVarsPrecedence(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.TerraformCliArgs = []string{"plan", "-parallelism=4"}
+	terragruntOptions.TerraformArgs = []string{"plan=-parallelism=3"}
+	terragruntOptions.Env = map[string]string{"TF_CLI_ARGS": "-parallelism=1", "TF_CLI_ARGS_plan": "-lock=true"}
+
+	// The value from the env_vars of the config replaces the inherited one
+	terragruntConfig := &config.TerragruntConfig{Terraform: &config.TerraformConfig{EnvVars: map[string]string{"TF_CLI_ARGS_plan": "-parallelism=2"}}}
+	setEnvVarsFromConfig(terragruntOptions, terragruntConfig)
+
+	err = mergeTfCliArgsEnvVars(terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	// Terraform uses the last value of a flag, so the args the user passed win, then --terragrunt-tf-arg, then
+	// TF_CLI_ARGS_plan, then TF_CLI_ARGS
+	assert.Equal(t, []string{"plan", "-parallelism=1", "-parallelism=2", "-parallelism=3", "-parallelism=4"}, terragruntOptions.TerraformCliArgs)
+}
+
+func TestMergeTfCliArgsEnvVarsPreventsDuplicateAutomationArgs(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.TerraformCliArgs = []string{"plan"}
+	terragruntOptions.Env = map[string]string{"TF_CLI_ARGS": "-input=true"}
+	terragruntOptions.NonInteractive = true
+
+	err = mergeTfCliArgsEnvVars(terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	err = addAutomationArgs(terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	assert.Equal(t, []string{"plan", "-input=true"}, terragruntOptions.TerraformCliArgs)
+}
+
+func TestWithoutTfCliArgsEnvVars(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.TerraformCliArgs = []string{"plan"}
+	terragruntOptions.Env = map[string]string{"TF_CLI_ARGS": "-input=false", "TF_CLI_ARGS_plan": "-lock=false", "TF_CLI_ARGS_apply": "-refresh=false", "FOO": "bar"}
+
+	actual := withoutTfCliArgsEnvVars(terragruntOptions)
+
+	assert.Equal(t, map[string]string{"TF_CLI_ARGS_apply": "-refresh=false", "FOO": "bar"}, actual.Env)
+	assert.Equal(t, terragruntOptions.TerraformCliArgs, actual.TerraformCliArgs)
+	assert.Equal(t, 4, len(terragruntOptions.Env), "Expected the env of the original options not to change")
+}
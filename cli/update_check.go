@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/hashicorp/go-version"
+)
+
+// Set this environment variable to true (or 1) to have Terragrunt check for a newer release. Terragrunt phones home
+// nowhere unless explicitly enabled, so the check is off by default.
+const UPDATE_CHECK_ENABLE_ENV_VAR = "TERRAGRUNT_ENABLE_UPDATE_CHECK"
+
+// The environment variable that stops Terraform from calling HashiCorp's checkpoint service to check for a newer
+// release and security bulletins. Terragrunt sets it for Terraform with --terragrunt-disable-checkpoint, which also
+// skips Terragrunt's own update check, even if it's enabled.
+const CHECKPOINT_DISABLE_ENV_VAR = "CHECKPOINT_DISABLE"
+
+// Terragrunt checks for a newer release at most once per UPDATE_CHECK_INTERVAL, and remembers the newest release it
+// found in the UPDATE_CHECK_CACHE_FILE in its folder of the XDG cache dir, so it can remind the user in between
+const UPDATE_CHECK_INTERVAL = 24 * time.Hour
+const UPDATE_CHECK_CACHE_FILE = "update-check.json"
+
+// How long to wait for the GitHub API, as the check must never hold up a command for long
+const UPDATE_CHECK_TIMEOUT = 2 * time.Second
+
+// The result of the last update check, as stored in the cache file
+type updateCheckCache struct {
+	CheckedAt     time.Time `json:"checked_at"`
+	LatestVersion string    `json:"latest_version"`
+}
+
+// If the update check is enabled, log a notice if there is a newer Terragrunt release than the one running. To not
+// slow every command down, the releases are only listed once a day. This is best effort: if anything goes wrong, such
+// as there being no network, no notice is logged, and the command runs as usual.
+func checkForUpdate(terragruntOptions *options.TerragruntOptions) {
+	if !updateCheckEnabled(terragruntOptions) {
+		return
+	}
+
+	cachePath := updateCheckCachePath(terragruntOptions)
+	if cachePath == "" {
+		return
+	}
+
+	httpClient, err := terragruntOptions.HttpClient(UPDATE_CHECK_TIMEOUT)
+	if err != nil {
+		return
+	}
+	latestVersion := getLatestVersion(cachePath, time.Now(), httpClient, terragruntOptions)
+	logUpdateNotice(latestVersion, terragruntOptions)
+}
+
+// The check only runs if it's been enabled, Terragrunt isn't offline and doesn't disable the checkpoint calls, and this
+// is a release build, as other builds have no version to compare with
+func updateCheckEnabled(terragruntOptions *options.TerragruntOptions) bool {
+	enabled := terragruntOptions.Env[UPDATE_CHECK_ENABLE_ENV_VAR]
+	if (enabled != "true" && enabled != "1") || terragruntOptions.DisableCheckpoint || terragruntOptions.Offline {
+		return false
+	}
+	_, err := version.NewVersion(terragruntOptions.TerragruntVersion)
+	return err == nil
+}
+
+// Return the path of the update check cache file in the XDG cache dir, which is $XDG_CACHE_HOME, or ~/.cache if that's
+// not set, or an empty string if neither can be found
+func updateCheckCachePath(terragruntOptions *options.TerragruntOptions) string {
+	cacheDir := terragruntOptions.Env["XDG_CACHE_HOME"]
+	if cacheDir == "" {
+		homeDir := userHomeDir()
+		if homeDir == "" {
+			return ""
+		}
+		cacheDir = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(cacheDir, "terragrunt", UPDATE_CHECK_CACHE_FILE)
+}
+
+// Return the newest Terragrunt release that isn't a pre-release, from the cache file at the given path if it was checked
+// less than UPDATE_CHECK_INTERVAL ago, or else from the GitHub API, in which case the result is written to the cache
+// file. If the releases can't be listed, that's remembered too, so a machine without access to GitHub doesn't wait for
+// the timeout on every command. Return an empty string if the newest release isn't known.
+func getLatestVersion(cachePath string, now time.Time, httpClient *http.Client, terragruntOptions *options.TerragruntOptions) string {
+	cache, err := readUpdateCheckCache(cachePath)
+	if err == nil && now.Sub(cache.CheckedAt) < UPDATE_CHECK_INTERVAL && !cache.CheckedAt.After(now) {
+		return cache.LatestVersion
+	}
+
+	cache = &updateCheckCache{CheckedAt: now}
+	if releases, err := getGithubReleases(httpClient, terragruntOptions); err == nil {
+		if release, _, err := findSelfUpdateRelease(releases, ""); err == nil {
+			cache.LatestVersion = release.TagName
+		}
+	}
+
+	// Failing to write the cache only means checking again next time
+	writeUpdateCheckCache(cachePath, cache)
+
+	return cache.LatestVersion
+}
+
+func readUpdateCheckCache(cachePath string) (*updateCheckCache, error) {
+	contents, err := ioutil.ReadFile(cachePath)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	cache := &updateCheckCache{}
+	if err := json.Unmarshal(contents, cache); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return cache, nil
+}
+
+func writeUpdateCheckCache(cachePath string, cache *updateCheckCache) error {
+	contents, err := json.Marshal(cache)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return errors.WithStackTrace(ioutil.WriteFile(cachePath, contents, 0600))
+}
+
+// Log a one line notice if the given latest version is newer than the running version of Terragrunt
+func logUpdateNotice(latestVersion string, terragruntOptions *options.TerragruntOptions) {
+	if latestVersion == "" {
+		return
+	}
+
+	parsedLatestVersion, err := version.NewVersion(latestVersion)
+	if err != nil {
+		return
+	}
+	currentVersion, err := version.NewVersion(terragruntOptions.TerragruntVersion)
+	if err != nil || currentVersion.Compare(parsedLatestVersion) >= 0 {
+		return
+	}
+
+	terragruntOptions.Logger.Printf("Terragrunt %s is available (you're running %s). Run 'terragrunt %s' to install it, or unset %s to stop checking.", latestVersion, terragruntOptions.TerragruntVersion, CMD_SELF_UPDATE, UPDATE_CHECK_ENABLE_ENV_VAR)
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLatestVersion(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	handler := newMockReleasesHandler("new binary", "", nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cacheDir := tmpDir(t)
+	defer os.RemoveAll(cacheDir)
+	cachePath := filepath.Join(cacheDir, "terragrunt", UPDATE_CHECK_CACHE_FILE)
+
+	terragruntOptions := selfUpdateOptionsForTest(t, server.URL)
+	now := time.Now()
+
+	assert.Equal(t, "v0.13.1", getLatestVersion(cachePath, now, http.DefaultClient, terragruntOptions))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Within a day, the cached version is used
+	assert.Equal(t, "v0.13.1", getLatestVersion(cachePath, now.Add(time.Hour), http.DefaultClient, terragruntOptions))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	assert.Equal(t, "v0.13.1", getLatestVersion(cachePath, now.Add(UPDATE_CHECK_INTERVAL+time.Hour), http.DefaultClient, terragruntOptions))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestGetLatestVersionRemembersFailures(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	cacheDir := tmpDir(t)
+	defer os.RemoveAll(cacheDir)
+	cachePath := filepath.Join(cacheDir, UPDATE_CHECK_CACHE_FILE)
+
+	terragruntOptions := selfUpdateOptionsForTest(t, server.URL)
+	now := time.Now()
+
+	assert.Equal(t, "", getLatestVersion(cachePath, now, http.DefaultClient, terragruntOptions))
+	assert.Equal(t, "", getLatestVersion(cachePath, now.Add(time.Hour), http.DefaultClient, terragruntOptions))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestGetLatestVersionCorruptCache(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(newMockReleasesHandler("new binary", "", nil))
+	defer server.Close()
+
+	cacheDir := tmpDir(t)
+	defer os.RemoveAll(cacheDir)
+	cachePath := filepath.Join(cacheDir, UPDATE_CHECK_CACHE_FILE)
+	if err := ioutil.WriteFile(cachePath, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	terragruntOptions := selfUpdateOptionsForTest(t, server.URL)
+	assert.Equal(t, "v0.13.1", getLatestVersion(cachePath, time.Now(), http.DefaultClient, terragruntOptions))
+	assert.True(t, util.FileExists(cachePath))
+}
+
+func TestLogUpdateNotice(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		currentVersion string
+		latestVersion  string
+		expectNotice   bool
+	}{
+		{"v0.12.0", "v0.13.1", true},
+		{"v0.13.1", "v0.13.1", false},
+		{"v0.14.0", "v0.13.1", false},
+		{"v0.12.0", "", false},
+		{"v0.12.0", "nightly", false},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, out := updateCheckOptionsForTest(t, testCase.currentVersion)
+		logUpdateNotice(testCase.latestVersion, terragruntOptions)
+
+		if testCase.expectNotice {
+			assert.Contains(t, out.String(), "Terragrunt "+testCase.latestVersion+" is available", "For %v", testCase)
+		} else {
+			assert.Empty(t, out.String(), "For %v", testCase)
+		}
+	}
+}
+
+func TestUpdateCheckEnabled(t *testing.T) {
+	t.Parallel()
+
+	// Off unless explicitly enabled
+	terragruntOptions, _ := updateCheckOptionsForTest(t, "v0.12.0")
+	assert.False(t, updateCheckEnabled(terragruntOptions))
+
+	terragruntOptions.Env[UPDATE_CHECK_ENABLE_ENV_VAR] = "true"
+	assert.True(t, updateCheckEnabled(terragruntOptions))
+
+	terragruntOptions.DisableCheckpoint = true
+	assert.False(t, updateCheckEnabled(terragruntOptions))
+
+	terragruntOptions.DisableCheckpoint = false
+	terragruntOptions.Offline = true
+	assert.False(t, updateCheckEnabled(terragruntOptions))
+
+	terragruntOptions, _ = updateCheckOptionsForTest(t, "")
+	terragruntOptions.Env[UPDATE_CHECK_ENABLE_ENV_VAR] = "1"
+	assert.False(t, updateCheckEnabled(terragruntOptions))
+}
+
+func TestUpdateCheckCachePath(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, _ := updateCheckOptionsForTest(t, "v0.12.0")
+	terragruntOptions.Env["XDG_CACHE_HOME"] = "/xdg/cache"
+	assert.Equal(t, filepath.Join("/xdg/cache", "terragrunt", UPDATE_CHECK_CACHE_FILE), updateCheckCachePath(terragruntOptions))
+}
+
+func updateCheckOptionsForTest(t *testing.T, terragruntVersion string) (*options.TerragruntOptions, *bytes.Buffer) {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("terraform.tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	terragruntOptions.TerragruntVersion = terragruntVersion
+	terragruntOptions.Env = map[string]string{}
+	terragruntOptions.Logger = util.CreateLoggerWithWriter(out, "")
+	return terragruntOptions, out
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const CMD_VALIDATE_CONFIG = "validate-config"
+
+// Parse the Terragrunt config of the module in the working dir, or, if any paths are passed after the command, each
+// of the config files at those paths, with strict validation enabled, so that syntax errors, unknown settings, and
+// invalid values all fail. Since this doesn't download any code or run Terraform, it's fast enough to run as a
+// pre-commit hook, which passes the changed files as args.
+func validateConfig(terragruntOptions *options.TerragruntOptions) error {
+	configPaths, err := getConfigPathsToValidate(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	invalidConfigPaths := []string{}
+
+	for _, configPath := range configPaths {
+		if !util.FileExists(configPath) {
+			terragruntOptions.Logger.Printf("Could not find a Terragrunt config at %s", configPath)
+			invalidConfigPaths = append(invalidConfigPaths, configPath)
+			continue
+		}
+
+		isTerragruntConfig, err := config.IsTerragruntConfigFile(configPath)
+		if err != nil {
+			terragruntOptions.Logger.Printf("%v", err)
+			invalidConfigPaths = append(invalidConfigPaths, configPath)
+			continue
+		}
+		if !isTerragruntConfig {
+			terragruntOptions.Logger.Printf("Skipping %s, as it does not contain a Terragrunt config", configPath)
+			continue
+		}
+
+		configOptions := terragruntOptions.Clone(configPath)
+		configOptions.StrictValidate = true
+
+		if _, err := config.ParseConfigFile(configPath, configOptions, nil); err != nil {
+			terragruntOptions.Logger.Printf("%v", err)
+			invalidConfigPaths = append(invalidConfigPaths, configPath)
+			continue
+		}
+
+		terragruntOptions.Logger.Printf("The Terragrunt config at %s is valid", configPath)
+	}
+
+	if len(invalidConfigPaths) > 0 {
+		return errors.WithStackTrace(InvalidConfigFiles(invalidConfigPaths))
+	}
+	return nil
+}
+
+// Return the canonical paths of the config files passed as args after the validate-config command, or the path of the
+// config file of the current module if there are none
+func getConfigPathsToValidate(terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	configPaths := []string{}
+
+	for _, arg := range terragruntOptions.TerraformCliArgs[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		configPath, err := util.CanonicalPath(arg, terragruntOptions.WorkingDir)
+		if err != nil {
+			return nil, err
+		}
+		configPaths = append(configPaths, configPath)
+	}
+
+	if len(configPaths) == 0 {
+		configPaths = append(configPaths, terragruntOptions.TerragruntConfigPath)
+	}
+	return configPaths, nil
+}
+
+// Custom error types
+
+type InvalidConfigFiles []string
+
+func (paths InvalidConfigFiles) Error() string {
+	return fmt.Sprintf("The following Terragrunt configs are invalid: %s", strings.Join(paths, ", "))
+}
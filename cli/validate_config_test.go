@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const VALIDATE_CONFIG_TEST_VALID = `
+terragrunt = {
+  terraform {
+    source = "../modules/app"
+  }
+}
+`
+
+const VALIDATE_CONFIG_TEST_TYPO = `
+terragrunt = {
+  terraform {
+    sorce = "../modules/app"
+  }
+}
+`
+
+func createValidateConfigTestOptions(t *testing.T, workingDir string, args []string) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(workingDir, config.DefaultTerragruntConfigPath))
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.WorkingDir = workingDir
+	terragruntOptions.TerraformCliArgs = args
+	return terragruntOptions
+}
+
+func TestValidateConfigCurrentModule(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		config        string
+		expectedError bool
+	}{
+		{VALIDATE_CONFIG_TEST_VALID, false},
+		{VALIDATE_CONFIG_TEST_TYPO, true},
+	}
+
+	for _, testCase := range testCases {
+		workingDir := tmpDir(t)
+		defer os.RemoveAll(workingDir)
+
+		writeSourceHashTestFile(t, workingDir, config.DefaultTerragruntConfigPath, testCase.config)
+
+		err := validateConfig(createValidateConfigTestOptions(t, workingDir, []string{CMD_VALIDATE_CONFIG}))
+		if testCase.expectedError {
+			_, isInvalidConfigFilesErr := errors.Unwrap(err).(InvalidConfigFiles)
+			assert.True(t, isInvalidConfigFilesErr, "Expected an InvalidConfigFiles error for config %s but got: %v", testCase.config, err)
+		} else {
+			assert.Nil(t, err, "Unexpected error for config %s: %v", testCase.config, err)
+		}
+	}
+}
+
+func TestValidateConfigGivenFiles(t *testing.T) {
+	t.Parallel()
+
+	workingDir := tmpDir(t)
+	defer os.RemoveAll(workingDir)
+
+	writeSourceHashTestFile(t, workingDir, "valid/"+config.DefaultTerragruntConfigPath, VALIDATE_CONFIG_TEST_VALID)
+	writeSourceHashTestFile(t, workingDir, "typo/"+config.DefaultTerragruntConfigPath, VALIDATE_CONFIG_TEST_TYPO)
+	writeSourceHashTestFile(t, workingDir, "vars-only/"+config.DefaultTerragruntConfigPath, "instance_type = \"t2.micro\"\n")
+
+	// Files without a terragrunt block are skipped
+	args := []string{CMD_VALIDATE_CONFIG, "valid/" + config.DefaultTerragruntConfigPath, "vars-only/" + config.DefaultTerragruntConfigPath}
+	err := validateConfig(createValidateConfigTestOptions(t, workingDir, args))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	args = []string{CMD_VALIDATE_CONFIG, "valid/" + config.DefaultTerragruntConfigPath, "typo/" + config.DefaultTerragruntConfigPath, "missing/" + config.DefaultTerragruntConfigPath}
+	err = validateConfig(createValidateConfigTestOptions(t, workingDir, args))
+	invalidConfigFiles, isInvalidConfigFilesErr := errors.Unwrap(err).(InvalidConfigFiles)
+	if assert.True(t, isInvalidConfigFilesErr, "Expected an InvalidConfigFiles error but got: %v", err) {
+		assert.Equal(t, InvalidConfigFiles{
+			util.JoinPath(workingDir, "typo/"+config.DefaultTerragruntConfigPath),
+			util.JoinPath(workingDir, "missing/"+config.DefaultTerragruntConfigPath),
+		}, invalidConfigFiles)
+	}
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/hashicorp/hcl"
+)
+
+const CMD_VALIDATE_INPUTS = "validate-inputs"
+
+// Pass this flag to the validate-inputs command to also fail if there are inputs that don't match any variable
+const VALIDATE_INPUTS_STRICT_FLAG = "--strict"
+
+// The Terraform command whose inputs validate-inputs checks. The extra_arguments for this command, such as var files,
+// count as inputs.
+const VALIDATE_INPUTS_TERRAFORM_COMMAND = "plan"
+
+// Terraform loads these var files from the working dir automatically
+var AUTO_LOADED_VAR_FILE_GLOBS = []string{"terraform.tfvars", "terraform.tfvars.json", "*.auto.tfvars", "*.auto.tfvars.json"}
+
+// Compare the variables declared in the Terraform code in the working dir with the inputs Terraform would get for the
+// plan command: the TF_VAR_xxx environment variables, the var files Terraform loads automatically, and the -var and
+// -var-file args from the command line, the TF_CLI_ARGS environment variables, and extra_arguments. Log the variables
+// without a default that no input sets, and the inputs that don't set any variable, and return an error if there are
+// any of the former, or, with VALIDATE_INPUTS_STRICT_FLAG, any of either.
+func validateInputs(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	variables, err := findDeclaredVariables(terragruntOptions.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	inputs, err := findInputs(terragruntOptions, terragruntConfig)
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for name, required := range variables {
+		if _, isSet := inputs[name]; required && !isSet {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	unused := []string{}
+	for name := range inputs {
+		if _, isDeclared := variables[name]; !isDeclared {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+
+	for _, name := range missing {
+		terragruntOptions.Logger.Printf("Required variable %s is not set by any input", name)
+	}
+	for _, name := range unused {
+		terragruntOptions.Logger.Printf("Input %s from %s does not match any variable", name, inputs[name])
+	}
+
+	strict := util.ListContainsElement(terragruntOptions.TerraformCliArgs, VALIDATE_INPUTS_STRICT_FLAG)
+	if len(missing) > 0 || (strict && len(unused) > 0) {
+		return errors.WithStackTrace(InvalidInputs{Missing: missing, Unused: unused})
+	}
+
+	terragruntOptions.Logger.Printf("All %d required variables in %s are set", countRequiredVariables(variables), terragruntOptions.WorkingDir)
+	return nil
+}
+
+// Return the variables declared in the Terraform code in the given folder, as a map from variable name to whether the
+// variable is required, which it is if it has no default
+func findDeclaredVariables(workingDir string) (map[string]bool, error) {
+	terraformCode, err := util.ParseTerraformCode(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := map[string]bool{}
+	for _, variable := range terraformCode.Variables {
+		variables[variable.Name] = !variable.HasDefault
+	}
+	return variables, nil
+}
+
+// Return the inputs Terraform would get for the plan command, as a map from variable name to a description of where
+// its value comes from
+func findInputs(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) (map[string]string, error) {
+	inputs := map[string]string{}
+
+	for key := range terragruntOptions.Env {
+		if strings.HasPrefix(key, "TF_VAR_") {
+			inputs[strings.TrimPrefix(key, "TF_VAR_")] = fmt.Sprintf("the %s environment variable", key)
+		}
+	}
+
+	for _, glob := range AUTO_LOADED_VAR_FILE_GLOBS {
+		paths, err := filepath.Glob(util.JoinPath(terragruntOptions.WorkingDir, glob))
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		for _, path := range paths {
+			if err := addInputsFromVarFile(path, terragruntOptions.WorkingDir, inputs); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	args, err := getArgsForValidateInputs(terragruntOptions, terragruntConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-var-file" && i+1 < len(args):
+			i++
+			if err := addInputsFromVarFile(args[i], terragruntOptions.WorkingDir, inputs); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(arg, "-var-file="):
+			if err := addInputsFromVarFile(strings.TrimPrefix(arg, "-var-file="), terragruntOptions.WorkingDir, inputs); err != nil {
+				return nil, err
+			}
+		case arg == "-var" && i+1 < len(args):
+			i++
+			addInputFromVarArg(args[i], inputs)
+		case strings.HasPrefix(arg, "-var="):
+			addInputFromVarArg(strings.TrimPrefix(arg, "-var="), inputs)
+		}
+	}
+
+	return inputs, nil
+}
+
+// Return the args Terraform would get for the plan command, other than the ones Terragrunt adds itself
+func getArgsForValidateInputs(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) ([]string, error) {
+	planOptions := *terragruntOptions
+	planOptions.TerraformCliArgs = []string{VALIDATE_INPUTS_TERRAFORM_COMMAND}
+	if len(terragruntOptions.TerraformCliArgs) > 1 {
+		planOptions.TerraformCliArgs = append(planOptions.TerraformCliArgs, util.RemoveElementFromList(terragruntOptions.TerraformCliArgs[1:], VALIDATE_INPUTS_STRICT_FLAG)...)
+	}
+
+	if err := mergeTfCliArgsEnvVars(&planOptions); err != nil {
+		return nil, err
+	}
+
+	if terragruntConfig.Terraform != nil && len(terragruntConfig.Terraform.ExtraArgs) > 0 {
+		extraArgs, err := filterTerraformExtraArgs(&planOptions, terragruntConfig)
+		if err != nil {
+			return nil, err
+		}
+		planOptions.InsertTerraformCliArgs(extraArgs...)
+	}
+
+	return planOptions.TerraformCliArgs, nil
+}
+
+// Add the variables set in the given var file to the given inputs. Relative paths are relative to the given working
+// dir, as that's where Terraform runs. We ignore the terragrunt = { ... } block of the terraform.tfvars file, which
+// isn't a variable.
+func addInputsFromVarFile(path string, workingDir string, inputs map[string]string) error {
+	if !filepath.IsAbs(path) {
+		path = util.JoinPath(workingDir, path)
+	}
+
+	contents, err := util.ReadFileAsString(path)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{}
+	if err := hcl.Decode(&values, contents); err != nil {
+		return errors.WithStackTrace(util.ErrorParsingTerraformFile{Path: path, Underlying: err})
+	}
+
+	for name := range values {
+		if name != "terragrunt" {
+			inputs[name] = fmt.Sprintf("the var file %s", path)
+		}
+	}
+
+	return nil
+}
+
+// Add the variable set by the given value of a -var arg, which has the form name=value, to the given inputs
+func addInputFromVarArg(value string, inputs map[string]string) {
+	name := strings.SplitN(value, "=", 2)[0]
+	inputs[name] = fmt.Sprintf("the arg -var %s", value)
+}
+
+func countRequiredVariables(variables map[string]bool) int {
+	count := 0
+	for _, required := range variables {
+		if required {
+			count++
+		}
+	}
+	return count
+}
+
+// Custom error types
+
+type InvalidInputs struct {
+	Missing []string
+	Unused  []string
+}
+
+func (err InvalidInputs) Error() string {
+	return fmt.Sprintf("The inputs of the module don't match its variables. Required variables that are not set: %v. Inputs that don't match any variable: %v.", err.Missing, err.Unused)
+}
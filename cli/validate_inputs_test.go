@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const VALIDATE_INPUTS_TEST_VARIABLES = `
+variable "region" {}
+
+variable "name" {
+  description = "The name of the app"
+}
+
+variable "instance_type" {
+  default = "t2.micro"
+}
+
+variable "tags" {
+  default = {}
+}
+`
+
+func TestFindDeclaredVariables(t *testing.T) {
+	t.Parallel()
+
+	workingDir := tmpDir(t)
+	defer os.RemoveAll(workingDir)
+
+	writeSourceHashTestFile(t, workingDir, "variables.tf", VALIDATE_INPUTS_TEST_VARIABLES)
+	writeSourceHashTestFile(t, workingDir, "outputs.tf.json", `{"variable": {"enabled": {"default": true}}}`)
+
+	actual, err := findDeclaredVariables(workingDir)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, map[string]bool{"region": true, "name": true, "instance_type": false, "tags": false, "enabled": false}, actual)
+}
+
+func TestValidateInputs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args          []string
+		env           map[string]string
+		expectedError bool
+	}{
+		// region is set in the var file from extra_arguments and name by TF_VAR_name
+		{[]string{CMD_VALIDATE_INPUTS}, map[string]string{"TF_VAR_name": "app"}, false},
+		// name isn't set at all
+		{[]string{CMD_VALIDATE_INPUTS}, map[string]string{}, true},
+		// name is set on the command line
+		{[]string{CMD_VALIDATE_INPUTS, "-var", "name=app"}, map[string]string{}, false},
+		// name is set in TF_CLI_ARGS_plan
+		{[]string{CMD_VALIDATE_INPUTS}, map[string]string{"TF_CLI_ARGS_plan": "-var=name=app"}, false},
+		// The unused input in terraform.tfvars is only an error in strict mode
+		{[]string{CMD_VALIDATE_INPUTS, VALIDATE_INPUTS_STRICT_FLAG}, map[string]string{"TF_VAR_name": "app"}, true},
+	}
+
+	for _, testCase := range testCases {
+		workingDir := tmpDir(t)
+		defer os.RemoveAll(workingDir)
+
+		writeSourceHashTestFile(t, workingDir, "variables.tf", VALIDATE_INPUTS_TEST_VARIABLES)
+		writeSourceHashTestFile(t, workingDir, config.DefaultTerragruntConfigPath, "terragrunt = {}\nunused = \"foo\"\n")
+		writeSourceHashTestFile(t, workingDir, "region.tfvars", "region = \"us-east-1\"\n")
+
+		terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(workingDir, config.DefaultTerragruntConfigPath))
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.WorkingDir = workingDir
+		terragruntOptions.TerraformCliArgs = testCase.args
+		terragruntOptions.Env = testCase.env
+
+		terragruntConfig := &config.TerragruntConfig{
+			Terraform: &config.TerraformConfig{
+				ExtraArgs: []config.TerraformExtraArguments{
+					{Name: "region", Commands: []string{"plan", "apply"}, RequiredVarFiles: []string{util.JoinPath(workingDir, "region.tfvars")}},
+				},
+			},
+		}
+
+		err = validateInputs(terragruntOptions, terragruntConfig)
+		if testCase.expectedError {
+			_, isInvalidInputsErr := errors.Unwrap(err).(InvalidInputs)
+			assert.True(t, isInvalidInputsErr, "Expected an InvalidInputs error for args %v and env %v but got: %v", testCase.args, testCase.env, err)
+		} else {
+			assert.Nil(t, err, "Unexpected error for args %v and env %v: %v", testCase.args, testCase.env, err)
+		}
+	}
+}
+
+func TestValidateInputsReportsMissingAndUnused(t *testing.T) {
+	t.Parallel()
+
+	workingDir := tmpDir(t)
+	defer os.RemoveAll(workingDir)
+
+	writeSourceHashTestFile(t, workingDir, "variables.tf", VALIDATE_INPUTS_TEST_VARIABLES)
+	writeSourceHashTestFile(t, workingDir, "extra.auto.tfvars", "region = \"us-east-1\"\ntypo_name = \"app\"\n")
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(workingDir, config.DefaultTerragruntConfigPath))
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.WorkingDir = workingDir
+	terragruntOptions.TerraformCliArgs = []string{CMD_VALIDATE_INPUTS}
+	terragruntOptions.Env = map[string]string{}
+
+	err = validateInputs(terragruntOptions, &config.TerragruntConfig{})
+	invalidInputsErr, isInvalidInputsErr := errors.Unwrap(err).(InvalidInputs)
+	if assert.True(t, isInvalidInputsErr, "Expected an InvalidInputs error but got: %v", err) {
+		assert.Equal(t, []string{"name"}, invalidInputsErr.Missing)
+		assert.Equal(t, []string{"typo_name"}, invalidInputsErr.Unused)
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const CMD_VERSION = "version"
+
+// The versions of Terragrunt and of the tools it depends on, as printed by the version command
+type versionInfo struct {
+	TerragruntVersion string `json:"terragrunt_version"`
+	TerraformVersion  string `json:"terraform_version"`
+	TerraformPath     string `json:"terraform_path"`
+	TerraformError    string `json:"terraform_error,omitempty"`
+	GoVersion         string `json:"go_version"`
+	Platform          string `json:"platform"`
+}
+
+// Print the versions of Terragrunt, of the Terraform binary it runs, and of Go, which Terragrunt was built with, and the
+// platform, which is what's needed to report a bug. Unlike other commands, this doesn't fail if Terraform can't be
+// run, as that's usually why someone wants to know which Terraform Terragrunt runs. With --json (or -json, as for
+// terraform version), the versions are printed as JSON.
+func printVersion(terragruntOptions *options.TerragruntOptions) error {
+	info := getVersionInfo(terragruntOptions)
+	asJSON := util.ListContainsElement(terragruntOptions.TerraformCliArgs, "--json") || util.ListContainsElement(terragruntOptions.TerraformCliArgs, "-json")
+	return writeVersionInfo(info, asJSON, terragruntOptions.Writer)
+}
+
+func getVersionInfo(terragruntOptions *options.TerragruntOptions) versionInfo {
+	info := versionInfo{
+		TerragruntVersion: terragruntOptions.TerragruntVersion,
+		TerraformPath:     terragruntOptions.TerraformPath,
+		GoVersion:         runtime.Version(),
+		Platform:          fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+	}
+
+	if path, err := exec.LookPath(terragruntOptions.TerraformPath); err == nil {
+		if absPath, err := filepath.Abs(path); err == nil {
+			info.TerraformPath = filepath.ToSlash(absPath)
+		}
+	}
+
+	if err := PopulateTerraformVersion(terragruntOptions); err != nil {
+		info.TerraformError = err.Error()
+	} else {
+		info.TerraformVersion = terragruntOptions.TerraformVersion.String()
+	}
+
+	return info
+}
+
+func writeVersionInfo(info versionInfo, asJSON bool, writer io.Writer) error {
+	if asJSON {
+		encoded, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		_, err = fmt.Fprintf(writer, "%s\n", encoded)
+		return errors.WithStackTrace(err)
+	}
+
+	terragruntVersion := info.TerragruntVersion
+	if terragruntVersion == "" {
+		// The version is only set in release builds
+		terragruntVersion = "unknown"
+	}
+
+	terraformVersion := "v" + info.TerraformVersion
+	if info.TerraformError != "" {
+		terraformVersion = fmt.Sprintf("unknown (%s)", info.TerraformError)
+	}
+
+	_, err := fmt.Fprintf(writer, "Terragrunt %s\nTerraform  %s at %s\nGo         %s\nPlatform   %s\n", terragruntVersion, terraformVersion, info.TerraformPath, info.GoVersion, info.Platform)
+	return errors.WithStackTrace(err)
+}
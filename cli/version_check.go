@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"os/exec"
 	"regexp"
+	"strings"
 
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
@@ -14,14 +16,36 @@ import (
 // where -dev and (commitid+CHANGES) is for custom builds or if TF_LOG is set for debug purposes
 var TERRAFORM_VERSION_REGEX = regexp.MustCompile("Terraform (v?[\\d\\.]+)(?:-dev)?(?: .+)?")
 
+// Where DEFAULT_TERRAFORM_VERSION_CONSTRAINT comes from, for the error message when Terraform doesn't meet it
+const DEFAULT_TERRAFORM_VERSION_CONSTRAINT_SOURCE = "Terragrunt"
+
+// The part of a version constraint that sets the lowest version that meets it, such as ">= v0.9.3" or "~> 0.12.31",
+// which is the version Terragrunt suggests installing when Terraform doesn't meet the constraint
+var TERRAFORM_VERSION_CONSTRAINT_MINIMUM_REGEX = regexp.MustCompile(`^\s*(?:>=|=|~>)?\s*v?(\d+\.\d+\.\d+)\s*$`)
+
+// A tool that installs and switches between versions of Terraform, which Terragrunt suggests a command for, if it's on
+// the PATH, when Terraform doesn't meet a version constraint
+type TerraformVersionManager struct {
+	Binary  string
+	Command string
+}
+
+var TERRAFORM_VERSION_MANAGERS = []TerraformVersionManager{
+	{Binary: "tfenv", Command: "tfenv install %[1]s && tfenv use %[1]s"},
+	{Binary: "tfswitch", Command: "tfswitch %[1]s"},
+}
+
+// The hint on how to install a compatible version of Terraform when none of the TERRAFORM_VERSION_MANAGERS can
+const TERRAFORM_DOWNLOAD_HINT = "Download a compatible version from https://releases.hashicorp.com/terraform/ and put it on the PATH, or point --terragrunt-tfpath at it."
+
 // Populate the currently installed version of Terraform into the given terragruntOptions
 func PopulateTerraformVersion(terragruntOptions *options.TerragruntOptions) error {
-	output, err := shell.RunTerraformCommandAndCaptureOutput(terragruntOptions, "--version")
+	output, err := shell.RunTerraformCommandAndCaptureOutput(terragruntOptions, shell.CaptureOptions{}, "--version")
 	if err != nil {
 		return err
 	}
 
-	version, err := parseTerraformVersion(output)
+	version, err := parseTerraformVersion(output.Stdout)
 	if err != nil {
 		return err
 	}
@@ -31,9 +55,49 @@ func PopulateTerraformVersion(terragruntOptions *options.TerragruntOptions) erro
 }
 
 // Check that the currently installed Terraform version works meets the specified version constraint and return an error
-// if it doesn't
-func CheckTerraformVersion(constraint string, terragruntOptions *options.TerragruntOptions) error {
-	return checkTerraformVersionMeetsConstraint(terragruntOptions.TerraformVersion, constraint)
+// if it doesn't. The error says where the constraint comes from (e.g. DEFAULT_TERRAFORM_VERSION_CONSTRAINT_SOURCE), which
+// Terraform binary Terragrunt found, and how to install a compatible version.
+func CheckTerraformVersion(constraint string, constraintSource string, terragruntOptions *options.TerragruntOptions) error {
+	err := checkTerraformVersionMeetsConstraint(terragruntOptions.TerraformVersion, constraint)
+
+	invalidVersionErr, isInvalidVersionErr := errors.Unwrap(err).(InvalidTerraformVersion)
+	if !isInvalidVersionErr {
+		return err
+	}
+
+	invalidVersionErr.ConstraintSource = constraintSource
+	invalidVersionErr.TerraformPath = terragruntOptions.TerraformPath
+	if path, lookPathErr := exec.LookPath(terragruntOptions.TerraformPath); lookPathErr == nil {
+		invalidVersionErr.TerraformPath = path
+	}
+	invalidVersionErr.InstallHint = getTerraformInstallHint(constraint, exec.LookPath)
+
+	return errors.WithStackTrace(invalidVersionErr)
+}
+
+// Return a hint on how to install a version of Terraform that meets the given constraint: the command of the first of
+// the TERRAFORM_VERSION_MANAGERS that the given function finds on the PATH, if the constraint sets a lowest version, or
+// where to download Terraform otherwise
+func getTerraformInstallHint(constraint string, lookPath func(file string) (string, error)) string {
+	if minimumVersion := getMinimumVersion(constraint); minimumVersion != "" {
+		for _, manager := range TERRAFORM_VERSION_MANAGERS {
+			if _, err := lookPath(manager.Binary); err == nil {
+				return fmt.Sprintf("To install a compatible version with %s, run: %s", manager.Binary, fmt.Sprintf(manager.Command, minimumVersion))
+			}
+		}
+	}
+	return TERRAFORM_DOWNLOAD_HINT
+}
+
+// Return the lowest version of Terraform that meets the given constraint, if the constraint sets one, or an empty string
+// otherwise. Of a constraint with several parts, such as ">= 0.12.0, < 0.14.0", only the first one is considered.
+func getMinimumVersion(constraint string) string {
+	firstPart := strings.Split(constraint, ",")[0]
+	matches := TERRAFORM_VERSION_CONSTRAINT_MINIMUM_REGEX.FindStringSubmatch(firstPart)
+	if len(matches) != 2 {
+		return ""
+	}
+	return matches[1]
 }
 
 // Check that the current version of Terraform meets the specified constraint and return an error if it doesn't
@@ -72,8 +136,18 @@ func (err InvalidTerraformVersionSyntax) Error() string {
 type InvalidTerraformVersion struct {
 	CurrentVersion     *version.Version
 	VersionConstraints version.Constraints
+	ConstraintSource   string
+	TerraformPath      string
+	InstallHint        string
 }
 
 func (err InvalidTerraformVersion) Error() string {
-	return fmt.Sprintf("The currently installed version of Terraform (%s) is not compatible with the version Terragrunt requires (%s).", err.CurrentVersion.String(), err.VersionConstraints.String())
+	if err.ConstraintSource == "" {
+		return fmt.Sprintf("The currently installed version of Terraform (%s) is not compatible with the version Terragrunt requires (%s).", err.CurrentVersion.String(), err.VersionConstraints.String())
+	}
+	return fmt.Sprintf("The currently installed version of Terraform (%s, at %s) is not compatible with the version %s requires (%s). %s", err.CurrentVersion.String(), err.TerraformPath, err.ConstraintSource, err.VersionConstraints.String(), err.InstallHint)
+}
+
+func (err InvalidTerraformVersion) Is(target error) bool {
+	return target == errors.ErrTerraformVersion
 }
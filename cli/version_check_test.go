@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"os/exec"
+	"testing"
+
 	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
 	"github.com/hashicorp/go-version"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func TestCheckTerraformVersionMeetsConstraintEqual(t *testing.T) {
@@ -37,6 +40,64 @@ func TestCheckTerraformVersionMeetsConstraintLessMajor(t *testing.T) {
 	testCheckTerraformVersionMeetsConstraint(t, "v0.8.8", ">= v0.9.3", false)
 }
 
+func TestCheckTerraformVersionError(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("version_check_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.TerraformPath = "/opt/terraform/bin/terraform-not-on-path"
+	terragruntOptions.TerraformVersion, err = version.NewVersion("v0.11.14")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, CheckTerraformVersion(">= v0.9.3", DEFAULT_TERRAFORM_VERSION_CONSTRAINT_SOURCE, terragruntOptions))
+
+	err = CheckTerraformVersion(">= 0.12.0, < 0.14.0", "the terraform_version_constraint of terraform.tfvars", terragruntOptions)
+	invalidVersionErr, isInvalidVersionErr := errors.Unwrap(err).(InvalidTerraformVersion)
+	if assert.True(t, isInvalidVersionErr, "Expected an InvalidTerraformVersion error but got: %v", err) {
+		assert.Equal(t, "the terraform_version_constraint of terraform.tfvars", invalidVersionErr.ConstraintSource)
+		assert.Equal(t, "/opt/terraform/bin/terraform-not-on-path", invalidVersionErr.TerraformPath)
+		assert.Contains(t, err.Error(), "0.11.14")
+		assert.Contains(t, err.Error(), ">= 0.12.0, < 0.14.0")
+		assert.NotEmpty(t, invalidVersionErr.InstallHint)
+	}
+}
+
+func TestGetTerraformInstallHint(t *testing.T) {
+	t.Parallel()
+
+	onPath := func(binaries ...string) func(file string) (string, error) {
+		return func(file string) (string, error) {
+			for _, binary := range binaries {
+				if file == binary {
+					return "/usr/local/bin/" + file, nil
+				}
+			}
+			return "", exec.ErrNotFound
+		}
+	}
+
+	testCases := []struct {
+		constraint   string
+		lookPath     func(file string) (string, error)
+		expectedHint string
+	}{
+		{">= v0.12.31", onPath("tfenv", "tfswitch"), "To install a compatible version with tfenv, run: tfenv install 0.12.31 && tfenv use 0.12.31"},
+		{"~> 0.13.7, < 0.14.0", onPath("tfswitch"), "To install a compatible version with tfswitch, run: tfswitch 0.13.7"},
+		{"= 1.0.0", onPath("tfenv"), "To install a compatible version with tfenv, run: tfenv install 1.0.0 && tfenv use 1.0.0"},
+		{">= 0.12.0", onPath(), TERRAFORM_DOWNLOAD_HINT},
+		{"< 0.12.0", onPath("tfenv"), TERRAFORM_DOWNLOAD_HINT},
+		{"~> 0.12", onPath("tfenv"), TERRAFORM_DOWNLOAD_HINT},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expectedHint, getTerraformInstallHint(testCase.constraint, testCase.lookPath), "For constraint %s", testCase.constraint)
+	}
+}
+
 func TestParseTerraformVersionNormal(t *testing.T) {
 	t.Parallel()
 	testParseTerraformVersion(t, "Terraform v0.9.3", "v0.9.3", nil)
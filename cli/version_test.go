@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteVersionInfo(t *testing.T) {
+	t.Parallel()
+
+	info := versionInfo{
+		TerragruntVersion: "v0.13.0",
+		TerraformVersion:  "0.11.14",
+		TerraformPath:     "/usr/local/bin/terraform",
+		GoVersion:         "go1.10",
+		Platform:          "linux/amd64",
+	}
+
+	var out bytes.Buffer
+	assert.Nil(t, writeVersionInfo(info, false, &out))
+	assert.Equal(t, "Terragrunt v0.13.0\nTerraform  v0.11.14 at /usr/local/bin/terraform\nGo         go1.10\nPlatform   linux/amd64\n", out.String())
+
+	out.Reset()
+	assert.Nil(t, writeVersionInfo(info, true, &out))
+	var actual versionInfo
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &actual))
+	assert.Equal(t, info, actual)
+}
+
+func TestWriteVersionInfoTerraformError(t *testing.T) {
+	t.Parallel()
+
+	info := versionInfo{TerraformPath: "terraform", TerraformError: "executable file not found in $PATH", GoVersion: "go1.10", Platform: "darwin/amd64"}
+
+	var out bytes.Buffer
+	assert.Nil(t, writeVersionInfo(info, false, &out))
+	assert.Equal(t, "Terragrunt unknown\nTerraform  unknown (executable file not found in $PATH) at terraform\nGo         go1.10\nPlatform   darwin/amd64\n", out.String())
+}
+
+func TestGetVersionInfoTerraformNotFound(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("terraform.tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.TerragruntVersion = "v0.13.0"
+	terragruntOptions.TerraformPath = "/does/not/exist/terraform"
+
+	info := getVersionInfo(terragruntOptions)
+	assert.Equal(t, "v0.13.0", info.TerragruntVersion)
+	assert.Equal(t, "/does/not/exist/terraform", info.TerraformPath)
+	assert.Equal(t, "", info.TerraformVersion)
+	assert.NotEmpty(t, info.TerraformError)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, info.Platform)
+}
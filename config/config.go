@@ -2,39 +2,122 @@ package config
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
 	"github.com/gruntwork-io/terragrunt/remote"
 	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/hcl"
 )
 
 const DefaultTerragruntConfigPath = "terraform.tfvars"
 const OldTerragruntConfigPath = ".terragrunt"
 
+// The file in the folder the xxx-all commands run in with gitignore-style patterns of the folders to skip
+const TerragruntIgnoreFile = ".terragrunt-ignore"
+
+// The folders that never contain the config of a module, so the xxx-all commands don't look for configs in them. The
+// .terraform folder of a module has copies of other modules, which may well have a terraform.tfvars of their own.
+var SkippedFolderNames = []string{".git", ".terraform"}
+
+// How many folders to look for configs in, or configs to parse, at once when finding the modules of the xxx-all
+// commands. This is mostly waiting on the file system, so it's more than the number of CPUs.
+var DiscoveryParallelism = 4 * runtime.NumCPU()
+
+// What to do when an extra_files block would overwrite a different file in the working dir: fail with an error, which
+// is the default, keep the file that's there, or overwrite it
+const ExtraFilesConflictError = "error"
+const ExtraFilesConflictSkip = "skip"
+const ExtraFilesConflictOverwrite = "overwrite"
+
+var ExtraFilesConflictPolicies = []string{ExtraFilesConflictError, ExtraFilesConflictSkip, ExtraFilesConflictOverwrite}
+
+// The defaults used for the policy block when query or opa_path are not specified
+const DefaultPolicyQuery = "data.terraform.deny"
+const DefaultOpaPath = "opa"
+
 // TerragruntConfig represents a parsed and expanded configuration
 type TerragruntConfig struct {
-	Terraform    *TerraformConfig
-	RemoteState  *remote.RemoteState
-	Dependencies *ModuleDependencies
+	Terraform      *TerraformConfig      `json:"terraform,omitempty"`
+	RemoteState    *remote.RemoteState   `json:"remote_state,omitempty"`
+	Dependencies   *ModuleDependencies   `json:"dependencies,omitempty"`
+	Policy         *PolicyConfig         `json:"policy,omitempty"`
+	CostEstimation *CostEstimationConfig `json:"cost_estimation,omitempty"`
+	StateBackup    *StateBackupConfig    `json:"state_backup,omitempty"`
+	RemoteExec     *RemoteExecConfig     `json:"remote_exec,omitempty"`
+	RunLock        *RunLockConfig        `json:"run_lock,omitempty"`
+	AwsProvider    *AwsProviderConfig    `json:"aws_provider,omitempty"`
+
+	// The ARNs of the IAM roles to assume one after the other before running Terraform, each with the credentials of
+	// the one before it (e.g. a role in a bastion account, and then a role in a workload account)
+	IamRoles []string `json:"iam_roles,omitempty"`
+
+	// A web identity token (e.g. an OIDC token from a CI system), or the path to a file that contains one, to assume
+	// the first of the IamRoles with, instead of using AWS credentials. It's a secret, so it's never rendered.
+	IamWebIdentityToken string `json:"-"`
+
+	// The tags to put on every AWS resource: the ones Terragrunt creates itself, such as the S3 bucket and DynamoDB
+	// table of the remote state, and, through the provider of the aws_provider block and the TF_VAR_default_tags
+	// environment variable, the ones Terraform creates
+	DefaultTags map[string]string `json:"default_tags,omitempty"`
+
+	// The only AWS accounts Terragrunt runs Terraform in, and the accounts it never runs Terraform in, respectively. If
+	// either is set, Terragrunt looks up the account of the current credentials before running Terraform.
+	AllowedAccountIds   []string `json:"allowed_account_ids,omitempty"`
+	ForbiddenAccountIds []string `json:"forbidden_account_ids,omitempty"`
+
+	// The only AWS region Terragrunt runs Terraform in. Terragrunt checks it against the region of the aws_provider block
+	// and the AWS_REGION and AWS_DEFAULT_REGION environment variables before running Terraform.
+	ExpectedRegion string `json:"expected_region,omitempty"`
+
+	// The versions of Terraform this module works with, in the constraint syntax of
+	// https://github.com/hashicorp/go-version (e.g. ">= 0.12.0, < 0.14.0")
+	TerraformVersionConstraint string `json:"terraform_version_constraint,omitempty"`
+
+	// If set to false, Terragrunt skips this module, both when running a command in it and in the xxx-all commands, so
+	// a module can be turned on and off per environment, e.g. with enabled = "${get_feature("new_vpc", "false") == "true"}"
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// The canonical path of the config file this config includes, if any. It's set when parsing, so it's never rendered.
+	IncludedConfigPath string `json:"-"`
 }
 
 func (conf *TerragruntConfig) String() string {
-	return fmt.Sprintf("TerragruntConfig{Terraform = %v, RemoteState = %v, Dependencies = %v}", conf.Terraform, conf.RemoteState, conf.Dependencies)
+	return fmt.Sprintf("TerragruntConfig{Terraform = %v, RemoteState = %v, Dependencies = %v, Policy = %v, CostEstimation = %v, StateBackup = %v, RemoteExec = %v, RunLock = %v, AwsProvider = %v, IamRoles = %v, DefaultTags = %v, AllowedAccountIds = %v, ForbiddenAccountIds = %v, ExpectedRegion = %v, TerraformVersionConstraint = %v, Enabled = %v}", conf.Terraform, conf.RemoteState, conf.Dependencies, conf.Policy, conf.CostEstimation, conf.StateBackup, conf.RemoteExec, conf.RunLock, conf.AwsProvider, conf.IamRoles, conf.DefaultTags, conf.AllowedAccountIds, conf.ForbiddenAccountIds, conf.ExpectedRegion, conf.TerraformVersionConstraint, conf.Enabled)
+}
+
+// Return false if this config sets enabled = false, and true otherwise
+func (conf *TerragruntConfig) IsEnabled() bool {
+	return conf.Enabled == nil || *conf.Enabled
 }
 
 // terragruntConfigFile represents the configuration supported in a Terragrunt configuration file (i.e.
 // terraform.tfvars or .terragrunt)
 type terragruntConfigFile struct {
-	Terraform    *TerraformConfig    `hcl:"terraform,omitempty"`
-	Include      *IncludeConfig      `hcl:"include,omitempty"`
-	Lock         *LockConfig         `hcl:"lock,omitempty"`
-	RemoteState  *remote.RemoteState `hcl:"remote_state,omitempty"`
-	Dependencies *ModuleDependencies `hcl:"dependencies,omitempty"`
+	Terraform                  *TerraformConfig      `hcl:"terraform,omitempty"`
+	Include                    *IncludeConfig        `hcl:"include,omitempty"`
+	Lock                       *LockConfig           `hcl:"lock,omitempty"`
+	RemoteState                *remote.RemoteState   `hcl:"remote_state,omitempty"`
+	Dependencies               *ModuleDependencies   `hcl:"dependencies,omitempty"`
+	Policy                     *PolicyConfig         `hcl:"policy,omitempty"`
+	CostEstimation             *CostEstimationConfig `hcl:"cost_estimation,omitempty"`
+	StateBackup                *StateBackupConfig    `hcl:"state_backup,omitempty"`
+	RemoteExec                 *RemoteExecConfig     `hcl:"remote_exec,omitempty"`
+	RunLock                    *RunLockConfig        `hcl:"run_lock,omitempty"`
+	AwsProvider                *AwsProviderConfig    `hcl:"aws_provider,omitempty"`
+	IamRoles                   []string              `hcl:"iam_roles,omitempty"`
+	IamWebIdentityToken        string                `hcl:"iam_web_identity_token,omitempty"`
+	DefaultTags                map[string]string     `hcl:"default_tags,omitempty"`
+	AllowedAccountIds          []string              `hcl:"allowed_account_ids,omitempty"`
+	ForbiddenAccountIds        []string              `hcl:"forbidden_account_ids,omitempty"`
+	ExpectedRegion             string                `hcl:"expected_region,omitempty"`
+	TerraformVersionConstraint string                `hcl:"terraform_version_constraint,omitempty"`
+	Enabled                    *bool                 `hcl:"enabled,omitempty"`
 }
 
 // Older versions of Terraform did not support locking, so Terragrunt offered locking as a feature. As of version 0.9.0,
@@ -51,22 +134,126 @@ type tfvarsFileWithTerragruntConfig struct {
 // "include" in a child Terragrunt configuration file
 type IncludeConfig struct {
 	Path string `hcl:"path"`
+
+	// Whether the attributes of the included config can be read in the current config with get_included_attribute
+	Expose bool `hcl:"expose,omitempty"`
 }
 
 // ModuleDependencies represents the paths to other Terraform modules that must be applied before the current module
 // can be applied
 type ModuleDependencies struct {
-	Paths []string `hcl:"paths"`
+	Paths []string `hcl:"paths" json:"paths"`
 }
 
 func (deps *ModuleDependencies) String() string {
 	return fmt.Sprintf("ModuleDependencies{Paths = %v}", deps.Paths)
 }
 
-// TerraformConfig specifies where to find the Terraform configuration files
+// PolicyConfig specifies the Open Policy Agent (OPA) policies that the plan of a module must satisfy. After a
+// successful 'terraform plan', Terragrunt converts the plan to JSON and evaluates Query against it using the policy
+// files in Paths. Any result returned by the query is treated as a violation.
+type PolicyConfig struct {
+	Paths   []string `hcl:"paths" json:"paths"`
+	Query   string   `hcl:"query,omitempty" json:"query,omitempty"`
+	OpaPath string   `hcl:"opa_path,omitempty" json:"opa_path,omitempty"`
+}
+
+func (conf *PolicyConfig) String() string {
+	return fmt.Sprintf("PolicyConfig{Paths = %v, Query = %v, OpaPath = %v}", conf.Paths, conf.Query, conf.OpaPath)
+}
+
+// CostEstimationConfig specifies an external command (e.g. infracost) that estimates the cost of the plan of a module.
+// After a successful 'terraform plan', Terragrunt converts the plan to JSON, runs Command with the given Arguments and
+// the path to the JSON plan in the TERRAGRUNT_PLAN_JSON environment variable, and expects a JSON object on stdout.
+type CostEstimationConfig struct {
+	Command   string   `hcl:"command" json:"command"`
+	Arguments []string `hcl:"arguments,omitempty" json:"arguments,omitempty"`
+}
+
+func (conf *CostEstimationConfig) String() string {
+	return fmt.Sprintf("CostEstimationConfig{Command = %v, Arguments = %v}", conf.Command, conf.Arguments)
+}
+
+// StateBackupConfig specifies a folder where Terragrunt saves a copy of the current state of a module, as returned by
+// 'terraform state pull', before running 'terraform apply' or 'terraform destroy' on it. Relative paths are relative
+// to the folder of the Terragrunt configuration file.
+type StateBackupConfig struct {
+	Path string `hcl:"path" json:"path"`
+}
+
+func (conf *StateBackupConfig) String() string {
+	return fmt.Sprintf("StateBackupConfig{Path = %v}", conf.Path)
+}
+
+// RemoteExecConfig specifies another host to run the Terraform commands of a module on, over SSH, such as a runner inside
+// a private network that can reach the resources of the module. Unless SyncFolders is set, the working dir and the
+// folder of the Terragrunt configuration must already be at the same paths on that host (e.g. on a shared file system).
+type RemoteExecConfig struct {
+	SshHost     string   `hcl:"ssh_host" json:"ssh_host"`
+	SshArgs     []string `hcl:"ssh_args,omitempty" json:"ssh_args,omitempty"`
+	SyncFolders bool     `hcl:"sync_folders,omitempty" json:"sync_folders,omitempty"`
+}
+
+func (conf *RemoteExecConfig) String() string {
+	return fmt.Sprintf("RemoteExecConfig{SshHost = %v, SshArgs = %v, SyncFolders = %v}", conf.SshHost, conf.SshArgs, conf.SyncFolders)
+}
+
+// RunLockConfig makes Terragrunt take a lock on a module for the whole of each command that changes its state, from
+// before the auto-init until Terraform exits, so two runs can't change the module at the same time. The lock is a file
+// in the folder of the Terragrunt configuration, which stops runs on the same machine, and if UseLockTable is set, an
+// item in the DynamoDB lock table of the S3 remote state, which stops runs anywhere.
+type RunLockConfig struct {
+	UseLockTable bool `hcl:"use_lock_table,omitempty" json:"use_lock_table,omitempty"`
+}
+
+func (conf *RunLockConfig) String() string {
+	return fmt.Sprintf("RunLockConfig{UseLockTable = %v}", conf.UseLockTable)
+}
+
+// AwsProviderConfig makes Terragrunt write a provider "aws" block with these settings into the working dir of the
+// module before running Terraform, so the provider config of every module can come from one parent config. A setting
+// that isn't set is left out of the block.
+type AwsProviderConfig struct {
+	Region      string                 `hcl:"region,omitempty" json:"region,omitempty"`
+	AssumeRole  *AwsProviderAssumeRole `hcl:"assume_role,omitempty" json:"assume_role,omitempty"`
+	DefaultTags map[string]string      `hcl:"default_tags,omitempty" json:"default_tags,omitempty"`
+}
+
+func (conf *AwsProviderConfig) String() string {
+	return fmt.Sprintf("AwsProviderConfig{Region = %v, AssumeRole = %v, DefaultTags = %v}", conf.Region, conf.AssumeRole, conf.DefaultTags)
+}
+
+// AwsProviderAssumeRole is the assume_role block of the provider "aws" block Terragrunt writes. Unlike iam_roles, which
+// Terragrunt assumes itself before running Terraform, the provider assumes this role.
+type AwsProviderAssumeRole struct {
+	RoleArn     string `hcl:"role_arn" json:"role_arn"`
+	SessionName string `hcl:"session_name,omitempty" json:"session_name,omitempty"`
+	ExternalId  string `hcl:"external_id,omitempty" json:"external_id,omitempty"`
+}
+
+func (conf *AwsProviderAssumeRole) String() string {
+	return fmt.Sprintf("AwsProviderAssumeRole{RoleArn = %v, SessionName = %v}", conf.RoleArn, conf.SessionName)
+}
+
+// TerraformConfig specifies where to find the Terraform configuration files and the environment variables to set for
+// every Terraform command run for the module
 type TerraformConfig struct {
-	ExtraArgs []TerraformExtraArguments `hcl:"extra_arguments"`
-	Source    string                    `hcl:"source"`
+	ExtraArgs []TerraformExtraArguments `hcl:"extra_arguments" json:"extra_arguments"`
+	Source    string                    `hcl:"source" json:"source"`
+	EnvVars   map[string]string         `hcl:"env_vars,omitempty" json:"env_vars,omitempty"`
+
+	// The expected sha256 hash of the code downloaded from Source. See the README for how it's calculated.
+	SourceHash string `hcl:"source_hash,omitempty" json:"source_hash,omitempty"`
+
+	// A command, plus its args, to prepend to every Terraform command, such as ["aws-vault", "exec", "prod", "--"]. The
+	// wrapper gets the path to Terraform and the Terraform args as its last args.
+	BinaryWrapper []string `hcl:"terraform_binary_wrapper,omitempty" json:"terraform_binary_wrapper,omitempty"`
+
+	// A Docker image, such as hashicorp/terraform:0.11.7, to run Terraform in rather than running it on the host
+	DockerImage string `hcl:"docker_image,omitempty" json:"docker_image,omitempty"`
+
+	// Files, such as a shared versions.tf or .terraform.lock.hcl, to copy into the working dir before running Terraform
+	ExtraFiles []TerraformExtraFiles `hcl:"extra_files,omitempty" json:"extra_files,omitempty"`
 }
 
 func (conf *TerraformConfig) String() string {
@@ -75,15 +262,52 @@ func (conf *TerraformConfig) String() string {
 
 // TerraformExtraArguments sets a list of arguments to pass to Terraform if command fits any in the `Commands` list
 type TerraformExtraArguments struct {
-	Name             string   `hcl:",key"`
-	Arguments        []string `hcl:"arguments,omitempty"`
-	RequiredVarFiles []string `hcl:"required_var_files,omitempty"`
-	OptionalVarFiles []string `hcl:"optional_var_files,omitempty"`
-	Commands         []string `hcl:"commands,omitempty"`
+	Name             string   `hcl:",key" json:"name"`
+	Arguments        []string `hcl:"arguments,omitempty" json:"arguments,omitempty"`
+	RequiredVarFiles []string `hcl:"required_var_files,omitempty" json:"required_var_files,omitempty"`
+	OptionalVarFiles []string `hcl:"optional_var_files,omitempty" json:"optional_var_files,omitempty"`
+	Commands         []string `hcl:"commands,omitempty" json:"commands,omitempty"`
+
+	// The path to a file with one argument per line, which are added after Arguments. Relative paths are relative to the
+	// folder of the Terragrunt configuration file.
+	ArgumentsFile string `hcl:"arguments_file,omitempty" json:"arguments_file,omitempty"`
+
+	// Blocks with a lower order are added to the command line first. Blocks with the same order keep the order in which
+	// they are defined, with the blocks from included configs first.
+	Order int `hcl:"order,omitempty" json:"order,omitempty"`
+
+	// If set, the arguments are only added if the condition is met
+	Condition *ExtraArgumentsCondition `hcl:"condition,omitempty" json:"condition,omitempty"`
 }
 
 func (conf *TerraformExtraArguments) String() string {
-	return fmt.Sprintf("TerraformArguments{Name = %s, Arguments = %v, Commands = %v}", conf.Name, conf.Arguments, conf.Commands)
+	return fmt.Sprintf("TerraformArguments{Name = %s, Arguments = %v, Commands = %v, Order = %d, Condition = %v}", conf.Name, conf.Arguments, conf.Commands, conf.Order, conf.Condition)
+}
+
+// TerraformExtraFiles specifies files to copy into the working dir of the module before running Terraform, e.g. the
+// versions.tf or .terraform.lock.hcl all the modules share, so they can live in one place. Relative paths are relative
+// to the folder of the Terragrunt configuration file. OnConflict says what to do if the working dir already has a
+// different file of the same name: one of ExtraFilesConflictPolicies.
+type TerraformExtraFiles struct {
+	Name       string   `hcl:",key" json:"name"`
+	Paths      []string `hcl:"paths" json:"paths"`
+	OnConflict string   `hcl:"on_conflict,omitempty" json:"on_conflict,omitempty"`
+}
+
+func (conf *TerraformExtraFiles) String() string {
+	return fmt.Sprintf("TerraformExtraFiles{Name = %s, Paths = %v, OnConflict = %s}", conf.Name, conf.Paths, conf.OnConflict)
+}
+
+// ExtraArgumentsCondition restricts an extra_arguments block to the runs where every environment variable in EnvVars
+// is set to a non-empty value and every argument in Args is passed on the command line after the command (e.g. list
+// for 'terraform state list', or -target for 'terraform plan -target=foo')
+type ExtraArgumentsCondition struct {
+	EnvVars []string `hcl:"env_vars,omitempty" json:"env_vars,omitempty"`
+	Args    []string `hcl:"args,omitempty" json:"args,omitempty"`
+}
+
+func (conf *ExtraArgumentsCondition) String() string {
+	return fmt.Sprintf("ExtraArgumentsCondition{EnvVars = %v, Args = %v}", conf.EnvVars, conf.Args)
 }
 
 // Return the default path to use for the Terragrunt configuration file. The reason this is a method rather than a
@@ -97,32 +321,137 @@ func DefaultConfigPath(workingDir string) string {
 	return util.JoinPath(workingDir, DefaultTerragruntConfigPath)
 }
 
+// Return the path of the Terragrunt config file in the nearest parent folder of the given folder that has one, like
+// git does for .git folders, checking at most the given number of folders. Returns an empty string if there is none.
+func FindConfigPathInParentFolders(workingDir string, maxFoldersToCheck int) (string, error) {
+	previousDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	for i := 0; i < maxFoldersToCheck; i++ {
+		currentDir := filepath.Dir(previousDir)
+		if currentDir == previousDir {
+			return "", nil
+		}
+
+		configPath := DefaultConfigPath(filepath.ToSlash(currentDir))
+		isTerragruntConfig, err := IsTerragruntConfigFile(configPath)
+		if err != nil {
+			return "", err
+		}
+		if isTerragruntConfig {
+			return configPath, nil
+		}
+
+		previousDir = currentDir
+	}
+
+	return "", nil
+}
+
 // Returns a list of all Terragrunt config files in the given path or any subfolder of the path. A file is a Terragrunt
 // config file if it has a name as returned by the DefaultConfigPath method and contains Terragrunt config contents
 // as returned by the IsTerragruntConfigFile method.
 func FindConfigFilesInPath(rootPath string) ([]string, error) {
-	configFiles := []string{}
+	return findConfigFilesInPath(rootPath, false)
+}
+
+// Like FindConfigFilesInPath, but also look in the folders that symlinks in the given path point to. The returned paths
+// go through the symlinks, so a module folder shared via symlinks shows up once for each symlink.
+func FindConfigFilesInPathFollowingSymlinks(rootPath string) ([]string, error) {
+	return findConfigFilesInPath(rootPath, true)
+}
+
+// Return all the Terragrunt config files in the working dir of the given options or any subfolder of it, following
+// symlinks if the FollowSymlinks option is set, and skipping the folders that match a pattern in the
+// TerragruntIgnoreFile in the working dir, if there is one
+func FindConfigFilesInWorkingDir(terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	var configFiles []string
+	var err error
+	if terragruntOptions.FollowSymlinks {
+		configFiles, err = FindConfigFilesInPathFollowingSymlinks(terragruntOptions.WorkingDir)
+	} else {
+		configFiles, err = FindConfigFilesInPath(terragruntOptions.WorkingDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return filterIgnoredConfigFiles(configFiles, terragruntOptions)
+}
+
+// Remove the config files whose folder matches a pattern in the TerragruntIgnoreFile in the working dir of the given
+// options from the given list
+func filterIgnoredConfigFiles(configFiles []string, terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	ignoreFilePath := util.JoinPath(terragruntOptions.WorkingDir, TerragruntIgnoreFile)
+	if !util.FileExists(ignoreFilePath) {
+		return configFiles, nil
+	}
+
+	contents, err := util.ReadFileAsString(ignoreFilePath)
+	if err != nil {
+		return nil, err
+	}
+	ignorePatterns, err := util.ParseIgnorePatterns(contents)
+	if err != nil {
+		return nil, errors.WithStackTrace(InvalidIgnoreFile{Path: ignoreFilePath, Underlying: err})
+	}
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	filtered := []string{}
+	for _, configFile := range configFiles {
+		relativePath, err := util.GetPathRelativeTo(filepath.Dir(configFile), terragruntOptions.WorkingDir)
 		if err != nil {
-			return err
+			return nil, err
 		}
-
-		if info.IsDir() {
-			configPath := DefaultConfigPath(path)
-			isTerragruntConfig, err := IsTerragruntConfigFile(configPath)
-			if err != nil {
-				return err
-			}
-			if isTerragruntConfig {
-				configFiles = append(configFiles, configPath)
-			}
+		if relativePath != "." && ignorePatterns.Matches(relativePath) {
+			terragruntOptions.Logger.Printf("Ignoring the module in %s, as it matches a pattern in %s", relativePath, ignoreFilePath)
+			continue
 		}
+		filtered = append(filtered, configFile)
+	}
+	return filtered, nil
+}
+
+// Look for config files in the folders under the given path in parallel, skipping the SkippedFolderNames, and return
+// them in the order filepath.Walk would find them, so the order doesn't depend on which folder was read first
+func findConfigFilesInPath(rootPath string, followSymlinks bool) ([]string, error) {
+	configPaths := map[string]string{}
+	var configPathsLock sync.Mutex
 
+	err := util.WalkFoldersInParallel(rootPath, DiscoveryParallelism, followSymlinks, isSkippedFolder, func(path string) error {
+		configPath := DefaultConfigPath(path)
+		isTerragruntConfig, err := IsTerragruntConfigFile(configPath)
+		if err != nil {
+			return err
+		}
+		if isTerragruntConfig {
+			configPathsLock.Lock()
+			defer configPathsLock.Unlock()
+			configPaths[path] = configPath
+		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return configFiles, err
+	folders := []string{}
+	for folder := range configPaths {
+		folders = append(folders, folder)
+	}
+	util.SortPathsInWalkOrder(folders)
+
+	configFiles := []string{}
+	for _, folder := range folders {
+		configFiles = append(configFiles, configPaths[folder])
+	}
+	return configFiles, nil
+}
+
+// Return true if the folder at the given path is one of the SkippedFolderNames
+func isSkippedFolder(path string) bool {
+	return util.ListContainsElement(SkippedFolderNames, filepath.Base(path))
 }
 
 // Returns true if the given path corresponds to file that could be a Terragrunt config file. A file could be a
@@ -179,17 +508,38 @@ func ReadTerragruntConfig(terragruntOptions *options.TerragruntOptions) (*Terrag
 }
 
 // Parse the Terragrunt config file at the given path. If the include parameter is not nil, then treat this as a config
-// included in some other config file when resolving relative paths.
+// included in some other config file when resolving relative paths. If the ParseCache option is set, a config that
+// hasn't changed since it was last parsed is read from the parse cache instead (see parseConfigFileWithCache).
 func ParseConfigFile(configPath string, terragruntOptions *options.TerragruntOptions, include *IncludeConfig) (*TerragruntConfig, error) {
+	if terragruntOptions.ParseCache && include == nil {
+		return parseConfigFileWithCache(configPath, terragruntOptions)
+	}
+	return parseConfigFile(configPath, terragruntOptions, include)
+}
+
+func parseConfigFile(configPath string, terragruntOptions *options.TerragruntOptions, include *IncludeConfig) (*TerragruntConfig, error) {
 	if isOldTerragruntConfig(configPath) {
-		terragruntOptions.Logger.Printf("DEPRECATION WARNING: Found deprecated config file format %s. This old config format will not be supported in the future. Please move your config files into a %s file.", configPath, DefaultTerragruntConfigPath)
+		if err := terragruntOptions.Deprecated(options.STRICT_CONTROL_OLD_CONFIG_FILE, fmt.Sprintf("Found deprecated config file format %s. This old config format will not be supported in the future. Please move your config files into a %s file.", configPath, DefaultTerragruntConfigPath)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := terragruntOptions.GetContext().Err(); err != nil {
+		return nil, errors.WithStackTrace(err)
 	}
 
+	recordFileDependency(terragruntOptions, configPath)
 	configString, err := util.ReadFileAsString(configPath)
 	if err != nil {
 		return nil, err
 	}
 
+	if terragruntOptions.StrictValidate {
+		if err := ValidateConfigSchema(configString, configPath); err != nil {
+			return nil, err
+		}
+	}
+
 	config, err := parseConfigString(configString, terragruntOptions, include, configPath)
 	if err != nil {
 		return nil, err
@@ -269,8 +619,22 @@ func mergeConfigWithIncludedConfig(config *TerragruntConfig, includedConfig *Ter
 		} else {
 			if config.Terraform.Source != "" {
 				includedConfig.Terraform.Source = config.Terraform.Source
+				// A source hash only applies to the source it was calculated for, so never keep the parent's hash for
+				// the child's source
+				includedConfig.Terraform.SourceHash = config.Terraform.SourceHash
+			}
+			if config.Terraform.SourceHash != "" {
+				includedConfig.Terraform.SourceHash = config.Terraform.SourceHash
+			}
+			if len(config.Terraform.BinaryWrapper) > 0 {
+				includedConfig.Terraform.BinaryWrapper = config.Terraform.BinaryWrapper
+			}
+			if config.Terraform.DockerImage != "" {
+				includedConfig.Terraform.DockerImage = config.Terraform.DockerImage
 			}
 			mergeExtraArgs(terragruntOptions, config.Terraform.ExtraArgs, &includedConfig.Terraform.ExtraArgs)
+			mergeExtraFiles(config.Terraform.ExtraFiles, &includedConfig.Terraform.ExtraFiles)
+			mergeEnvVars(config.Terraform.EnvVars, &includedConfig.Terraform.EnvVars)
 		}
 	}
 
@@ -278,6 +642,71 @@ func mergeConfigWithIncludedConfig(config *TerragruntConfig, includedConfig *Ter
 		includedConfig.Dependencies = config.Dependencies
 	}
 
+	if config.Policy != nil {
+		includedConfig.Policy = config.Policy
+	}
+
+	if config.CostEstimation != nil {
+		includedConfig.CostEstimation = config.CostEstimation
+	}
+
+	if config.StateBackup != nil {
+		includedConfig.StateBackup = config.StateBackup
+	}
+
+	if config.RemoteExec != nil {
+		includedConfig.RemoteExec = config.RemoteExec
+	}
+
+	if config.RunLock != nil {
+		includedConfig.RunLock = config.RunLock
+	}
+
+	if config.AwsProvider != nil {
+		if includedConfig.AwsProvider == nil {
+			includedConfig.AwsProvider = config.AwsProvider
+		} else {
+			if config.AwsProvider.Region != "" {
+				includedConfig.AwsProvider.Region = config.AwsProvider.Region
+			}
+			if config.AwsProvider.AssumeRole != nil {
+				includedConfig.AwsProvider.AssumeRole = config.AwsProvider.AssumeRole
+			}
+			mergeEnvVars(config.AwsProvider.DefaultTags, &includedConfig.AwsProvider.DefaultTags)
+		}
+	}
+
+	if len(config.IamRoles) > 0 {
+		includedConfig.IamRoles = config.IamRoles
+	}
+
+	if config.IamWebIdentityToken != "" {
+		includedConfig.IamWebIdentityToken = config.IamWebIdentityToken
+	}
+
+	// Tags merge the same way as environment variables: the child's value wins for a key both set
+	mergeEnvVars(config.DefaultTags, &includedConfig.DefaultTags)
+
+	if len(config.AllowedAccountIds) > 0 {
+		includedConfig.AllowedAccountIds = config.AllowedAccountIds
+	}
+
+	if len(config.ForbiddenAccountIds) > 0 {
+		includedConfig.ForbiddenAccountIds = config.ForbiddenAccountIds
+	}
+
+	if config.ExpectedRegion != "" {
+		includedConfig.ExpectedRegion = config.ExpectedRegion
+	}
+
+	if config.TerraformVersionConstraint != "" {
+		includedConfig.TerraformVersionConstraint = config.TerraformVersionConstraint
+	}
+
+	if config.Enabled != nil {
+		includedConfig.Enabled = config.Enabled
+	}
+
 	return includedConfig, nil
 }
 
@@ -310,6 +739,40 @@ func mergeExtraArgs(terragruntOptions *options.TerragruntOptions, childExtraArgs
 	*parentExtraArgs = result
 }
 
+// Merge the extra_files blocks of the child into those of the parent. As with extra_arguments, a child block replaces
+// the parent block with the same name, and the other child blocks are added after the parent's.
+func mergeExtraFiles(childExtraFiles []TerraformExtraFiles, parentExtraFiles *[]TerraformExtraFiles) {
+	result := append([]TerraformExtraFiles{}, *parentExtraFiles...)
+	for _, child := range childExtraFiles {
+		replaced := false
+		for i, parent := range result {
+			if parent.Name == child.Name {
+				result[i] = child
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, child)
+		}
+	}
+	*parentExtraFiles = result
+}
+
+// Merge the environment variables. If the child and the parent both set the same environment variable, the value from
+// the child wins.
+func mergeEnvVars(childEnvVars map[string]string, parentEnvVars *map[string]string) {
+	if len(childEnvVars) == 0 {
+		return
+	}
+
+	result := util.CloneStringMap(*parentEnvVars)
+	for key, value := range childEnvVars {
+		result[key] = value
+	}
+	*parentEnvVars = result
+}
+
 // Returns the index of the extraArgs with the given name,
 // or -1 if no extraArgs have the given name.
 func getIndexOfExtraArgsWithName(extraArgs []TerraformExtraArguments, name string) int {
@@ -339,7 +802,17 @@ func parseIncludedConfig(includedConfig *IncludeConfig, terragruntOptions *optio
 		resolvedIncludePath = util.JoinPath(filepath.Dir(terragruntOptions.TerragruntConfigPath), resolvedIncludePath)
 	}
 
-	return ParseConfigFile(resolvedIncludePath, terragruntOptions, includedConfig)
+	parsedConfig, err := ParseConfigFile(resolvedIncludePath, terragruntOptions, includedConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// The child's config is merged on top of the included config, so this ends up in the child's config
+	parsedConfig.IncludedConfigPath, err = util.CanonicalPath(resolvedIncludePath, ".")
+	if err != nil {
+		return nil, err
+	}
+	return parsedConfig, nil
 }
 
 // Convert the contents of a fully resolved Terragrunt configuration to a TerragruntConfig object
@@ -359,9 +832,79 @@ func convertToTerragruntConfig(terragruntConfigFromFile *terragruntConfigFile, t
 		terragruntConfig.RemoteState = terragruntConfigFromFile.RemoteState
 	}
 
+	if terragruntConfigFromFile.Terraform != nil {
+		for i, extraFiles := range terragruntConfigFromFile.Terraform.ExtraFiles {
+			if len(extraFiles.Paths) == 0 {
+				return nil, errors.WithStackTrace(ExtraFilesPathsMissing{ConfigPath: terragruntOptions.TerragruntConfigPath, Name: extraFiles.Name})
+			}
+			if extraFiles.OnConflict == "" {
+				terragruntConfigFromFile.Terraform.ExtraFiles[i].OnConflict = ExtraFilesConflictError
+			} else if !util.ListContainsElement(ExtraFilesConflictPolicies, extraFiles.OnConflict) {
+				return nil, errors.WithStackTrace(InvalidExtraFilesConflictPolicy{ConfigPath: terragruntOptions.TerragruntConfigPath, Name: extraFiles.Name, OnConflict: extraFiles.OnConflict})
+			}
+		}
+	}
+
 	terragruntConfig.Terraform = terragruntConfigFromFile.Terraform
 	terragruntConfig.Dependencies = terragruntConfigFromFile.Dependencies
 
+	if terragruntConfigFromFile.Policy != nil {
+		if len(terragruntConfigFromFile.Policy.Paths) == 0 {
+			return nil, errors.WithStackTrace(PolicyPathsMissing(terragruntOptions.TerragruntConfigPath))
+		}
+		if terragruntConfigFromFile.Policy.Query == "" {
+			terragruntConfigFromFile.Policy.Query = DefaultPolicyQuery
+		}
+		if terragruntConfigFromFile.Policy.OpaPath == "" {
+			terragruntConfigFromFile.Policy.OpaPath = DefaultOpaPath
+		}
+		terragruntConfig.Policy = terragruntConfigFromFile.Policy
+	}
+
+	if terragruntConfigFromFile.CostEstimation != nil {
+		if terragruntConfigFromFile.CostEstimation.Command == "" {
+			return nil, errors.WithStackTrace(CostEstimationCommandMissing(terragruntOptions.TerragruntConfigPath))
+		}
+		terragruntConfig.CostEstimation = terragruntConfigFromFile.CostEstimation
+	}
+
+	if terragruntConfigFromFile.StateBackup != nil {
+		if terragruntConfigFromFile.StateBackup.Path == "" {
+			return nil, errors.WithStackTrace(StateBackupPathMissing(terragruntOptions.TerragruntConfigPath))
+		}
+		terragruntConfig.StateBackup = terragruntConfigFromFile.StateBackup
+	}
+
+	if terragruntConfigFromFile.RemoteExec != nil {
+		if terragruntConfigFromFile.RemoteExec.SshHost == "" {
+			return nil, errors.WithStackTrace(RemoteExecSshHostMissing(terragruntOptions.TerragruntConfigPath))
+		}
+		terragruntConfig.RemoteExec = terragruntConfigFromFile.RemoteExec
+	}
+
+	if terragruntConfigFromFile.AwsProvider != nil {
+		if assumeRole := terragruntConfigFromFile.AwsProvider.AssumeRole; assumeRole != nil && assumeRole.RoleArn == "" {
+			return nil, errors.WithStackTrace(AwsProviderRoleArnMissing(terragruntOptions.TerragruntConfigPath))
+		}
+		terragruntConfig.AwsProvider = terragruntConfigFromFile.AwsProvider
+	}
+
+	if terragruntConfigFromFile.TerraformVersionConstraint != "" {
+		if _, err := version.NewConstraint(terragruntConfigFromFile.TerraformVersionConstraint); err != nil {
+			return nil, errors.WithStackTrace(InvalidTerraformVersionConstraint{ConfigPath: terragruntOptions.TerragruntConfigPath, Constraint: terragruntConfigFromFile.TerraformVersionConstraint, Underlying: err})
+		}
+		terragruntConfig.TerraformVersionConstraint = terragruntConfigFromFile.TerraformVersionConstraint
+	}
+
+	terragruntConfig.RunLock = terragruntConfigFromFile.RunLock
+	terragruntConfig.IamRoles = terragruntConfigFromFile.IamRoles
+	terragruntConfig.IamWebIdentityToken = terragruntConfigFromFile.IamWebIdentityToken
+	terragruntConfig.DefaultTags = terragruntConfigFromFile.DefaultTags
+	terragruntConfig.AllowedAccountIds = terragruntConfigFromFile.AllowedAccountIds
+	terragruntConfig.ForbiddenAccountIds = terragruntConfigFromFile.ForbiddenAccountIds
+	terragruntConfig.ExpectedRegion = terragruntConfigFromFile.ExpectedRegion
+	terragruntConfig.Enabled = terragruntConfigFromFile.Enabled
+
 	return terragruntConfig, nil
 }
 
@@ -373,6 +916,69 @@ func (err IncludedConfigMissingPath) Error() string {
 	return fmt.Sprintf("The include configuration in %s must specify a 'path' parameter", string(err))
 }
 
+type PolicyPathsMissing string
+
+func (err PolicyPathsMissing) Error() string {
+	return fmt.Sprintf("The policy configuration in %s must specify at least one entry in the 'paths' parameter", string(err))
+}
+
+type ExtraFilesPathsMissing struct {
+	ConfigPath string
+	Name       string
+}
+
+func (err ExtraFilesPathsMissing) Error() string {
+	return fmt.Sprintf("The extra_files block '%s' in %s must specify at least one entry in the 'paths' parameter", err.Name, err.ConfigPath)
+}
+
+type InvalidExtraFilesConflictPolicy struct {
+	ConfigPath string
+	Name       string
+	OnConflict string
+}
+
+func (err InvalidExtraFilesConflictPolicy) Error() string {
+	return fmt.Sprintf("The extra_files block '%s' in %s has an invalid on_conflict value '%s'. It must be one of %s.", err.Name, err.ConfigPath, err.OnConflict, strings.Join(ExtraFilesConflictPolicies, ", "))
+}
+
+type CostEstimationCommandMissing string
+
+func (err CostEstimationCommandMissing) Error() string {
+	return fmt.Sprintf("The cost_estimation configuration in %s must specify a 'command' parameter", string(err))
+}
+
+type StateBackupPathMissing string
+
+func (err StateBackupPathMissing) Error() string {
+	return fmt.Sprintf("The state_backup configuration in %s must specify a 'path' parameter", string(err))
+}
+
+type RemoteExecSshHostMissing string
+
+func (err RemoteExecSshHostMissing) Error() string {
+	return fmt.Sprintf("The remote_exec configuration in %s must specify an 'ssh_host' parameter", string(err))
+}
+
+type AwsProviderRoleArnMissing string
+
+func (err AwsProviderRoleArnMissing) Error() string {
+	return fmt.Sprintf("The assume_role block of the aws_provider configuration in %s must specify a 'role_arn' parameter", string(err))
+}
+
+type InvalidTerraformVersionConstraint struct {
+	ConfigPath string
+	Constraint string
+	Underlying error
+}
+
+func (err InvalidTerraformVersionConstraint) Error() string {
+	return fmt.Sprintf("The terraform_version_constraint %q in %s is not a valid version constraint: %v", err.Constraint, err.ConfigPath, err.Underlying)
+}
+
+func (err InvalidTerraformVersionConstraint) Unwrap() error {
+	return err.Underlying
+}
+
 type TooManyLevelsOfInheritance struct {
 	ConfigPath             string
 	FirstLevelIncludePath  string
@@ -389,6 +995,10 @@ func (err CouldNotResolveTerragruntConfigInFile) Error() string {
 	return fmt.Sprintf("Could not find Terragrunt configuration settings in %s", string(err))
 }
 
+func (err CouldNotResolveTerragruntConfigInFile) Is(target error) bool {
+	return target == errors.ErrConfigNotFound
+}
+
 type ErrorParsingTerragruntConfig struct {
 	ConfigPath string
 	Underlying error
@@ -397,3 +1007,24 @@ type ErrorParsingTerragruntConfig struct {
 func (err ErrorParsingTerragruntConfig) Error() string {
 	return fmt.Sprintf("Error parsing Terragrunt config at %s: %v", err.ConfigPath, err.Underlying)
 }
+
+func (err ErrorParsingTerragruntConfig) Unwrap() error {
+	return err.Underlying
+}
+
+func (err ErrorParsingTerragruntConfig) Is(target error) bool {
+	return target == errors.ErrConfigInvalid
+}
+
+type InvalidIgnoreFile struct {
+	Path       string
+	Underlying error
+}
+
+func (err InvalidIgnoreFile) Error() string {
+	return fmt.Sprintf("Invalid pattern in %s: %v", err.Path, err.Underlying)
+}
+
+func (err InvalidIgnoreFile) Unwrap() error {
+	return err.Underlying
+}
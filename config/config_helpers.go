@@ -1,25 +1,30 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/gruntwork-io/terragrunt/aws_helper"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
 	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/gruntwork-io/terragrunt/vault_helper"
+	"github.com/hashicorp/hcl"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 )
 
-var INTERPOLATION_PARAMETERS = `(\s*"[^"]*?"\s*,?\s*)*`
-var INTERPOLATION_SYNTAX_REGEX = regexp.MustCompile(fmt.Sprintf(`\$\{\s*\w+\(%s\)\s*\}`, INTERPOLATION_PARAMETERS))
-var INTERPOLATION_SYNTAX_REGEX_SINGLE = regexp.MustCompile(fmt.Sprintf(`"(%s)"`, INTERPOLATION_SYNTAX_REGEX))
-var INTERPOLATION_SYNTAX_REGEX_REMAINING = regexp.MustCompile(`\$\{.*?\}`)
-var HELPER_FUNCTION_SYNTAX_REGEX = regexp.MustCompile(`^\$\{\s*(.*?)\((.*?)\)\s*\}$`)
-var HELPER_FUNCTION_GET_ENV_PARAMETERS_SYNTAX_REGEX = regexp.MustCompile(`^\s*"(?P<env>[^=]+?)"\s*\,\s*"(?P<default>.*?)"\s*$`)
+var HELPER_FUNCTION_GET_ENV_PARAMETERS_SYNTAX_REGEX = regexp.MustCompile(`^\s*"(?P<env>(?:[^"\\=]|\\.)+?)"\s*\,\s*"(?P<default>(?:[^"\\]|\\.)*?)"\s*$`)
 
 // List of terraform commands that accept -lock-timeout
 var TERRAFORM_COMMANDS_NEED_LOCKING = []string{
@@ -59,20 +64,10 @@ type EnvVar struct {
 	DefaultValue string
 }
 
-// Given a string value from a Terragrunt configuration, parse the string, resolve any calls to helper functions using
-// the syntax ${...}, and return the final value.
-func ResolveTerragruntConfigString(terragruntConfigString string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions) (string, error) {
-	// First, we replace all single interpolation syntax (i.e. function directly enclosed within quotes "${function()}")
-	terragruntConfigString, err := processSingleInterpolationInString(terragruntConfigString, include, terragruntOptions)
-	if err != nil {
-		return terragruntConfigString, err
-	}
-	// Then, we replace all other interpolation functions (i.e. functions not directly enclosed within quotes)
-	return processMultipleInterpolationsInString(terragruntConfigString, include, terragruntOptions)
-}
-
 // Execute a single Terragrunt helper function and return the result
 func executeTerragruntHelperFunction(functionName string, parameters string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions) (interface{}, error) {
+	recordHelperFunctionCall(terragruntOptions, functionName)
+
 	switch functionName {
 	case "find_in_parent_folders":
 		return findInParentFolders(parameters, terragruntOptions)
@@ -80,14 +75,36 @@ func executeTerragruntHelperFunction(functionName string, parameters string, inc
 		return pathRelativeToInclude(include, terragruntOptions)
 	case "path_relative_from_include":
 		return pathRelativeFromInclude(include, terragruntOptions)
+	case "get_state_key":
+		return getStateKey(parameters, include, terragruntOptions)
 	case "get_env":
 		return getEnvironmentVariable(parameters, terragruntOptions)
+	case "get_feature":
+		return getFeature(parameters, terragruntOptions)
 	case "get_tfvars_dir":
 		return getTfVarsDir(terragruntOptions)
 	case "get_parent_tfvars_dir":
 		return getParentTfVarsDir(include, terragruntOptions)
+	case "get_var_file_hierarchy":
+		return getVarFileHierarchy(include, terragruntOptions)
 	case "get_aws_account_id":
 		return getAWSAccountID(terragruntOptions)
+	case "get_ssm_parameter":
+		return getSsmParameter(parameters, terragruntOptions)
+	case "get_secretsmanager_secret":
+		return getSecretsManagerSecret(parameters, terragruntOptions)
+	case "read_terragrunt_config":
+		return readTerragruntConfig(parameters, terragruntOptions)
+	case "read_yaml":
+		return readDataFile(functionName, parameters, terragruntOptions)
+	case "read_json":
+		return readDataFile(functionName, parameters, terragruntOptions)
+	case "get_included_attribute":
+		return getIncludedAttribute(parameters, include, terragruntOptions)
+	case "sops_decrypt_file":
+		return sopsDecryptFile(parameters, terragruntOptions)
+	case "get_vault_secret":
+		return getVaultSecret(parameters, terragruntOptions)
 	case "get_terraform_commands_that_need_vars":
 		return TERRAFORM_COMMANDS_NEED_VARS, nil
 	case "get_terraform_commands_that_need_locking":
@@ -99,70 +116,6 @@ func executeTerragruntHelperFunction(functionName string, parameters string, inc
 	}
 }
 
-// For all interpolation functions that are called using the syntax "${function_name()}" (i.e. single interpolation function within string,
-// functions that return a non-string value we have to get rid of the surrounding quotes and convert the output to HCL syntax. For example,
-// for an array, we need to return "v1", "v2", "v3".
-func processSingleInterpolationInString(terragruntConfigString string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions) (resolved string, finalErr error) {
-	// The function we pass to ReplaceAllStringFunc cannot return an error, so we have to use named error parameters to capture such errors.
-	resolved = INTERPOLATION_SYNTAX_REGEX_SINGLE.ReplaceAllStringFunc(terragruntConfigString, func(str string) string {
-		matches := INTERPOLATION_SYNTAX_REGEX_SINGLE.FindStringSubmatch(str)
-
-		out, err := resolveTerragruntInterpolation(matches[1], include, terragruntOptions)
-		if err != nil {
-			finalErr = err
-			return str
-		}
-
-		switch out := out.(type) {
-		case string:
-			return fmt.Sprintf(`"%s"`, out)
-		case []string:
-			return util.CommaSeparatedStrings(out)
-		default:
-			return fmt.Sprintf("%v", out)
-		}
-	})
-	return
-}
-
-// For all interpolation functions that are called using the syntax "${function_a()}-${function_b()}" (i.e. multiple interpolation function
-// within the same string) or "Some text ${function_name()}" (i.e. string composition), we just replace the interpolation function call
-// by the string representation of its return.
-func processMultipleInterpolationsInString(terragruntConfigString string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions) (resolved string, finalErr error) {
-	// The function we pass to ReplaceAllStringFunc cannot return an error, so we have to use named error parameters to capture such errors.
-	resolved = INTERPOLATION_SYNTAX_REGEX.ReplaceAllStringFunc(terragruntConfigString, func(str string) string {
-		out, err := resolveTerragruntInterpolation(str, include, terragruntOptions)
-		if err != nil {
-			finalErr = err
-			return str
-		}
-
-		return fmt.Sprintf("%v", out)
-	})
-
-	if finalErr == nil {
-		// If there is no error, we check if there are remaining look-a-like interpolation strings
-		// that have not been considered. If so, they are certainly malformed.
-		remaining := INTERPOLATION_SYNTAX_REGEX_REMAINING.FindAllString(resolved, -1)
-		if len(remaining) > 0 {
-			finalErr = InvalidInterpolationSyntax(strings.Join(remaining, ", "))
-		}
-	}
-
-	return
-}
-
-// Given a string value from a Terragrunt configuration, parse the string, resolve any calls to helper functions using
-// Resolve a single call to an interpolation function of the format ${some_function()} in a Terragrunt configuration
-func resolveTerragruntInterpolation(str string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions) (interface{}, error) {
-	matches := HELPER_FUNCTION_SYNTAX_REGEX.FindStringSubmatch(str)
-	if len(matches) == 3 {
-		return executeTerragruntHelperFunction(matches[1], matches[2], include, terragruntOptions)
-	} else {
-		return "", errors.WithStackTrace(InvalidInterpolationSyntax(str))
-	}
-}
-
 // Return the directory where the Terragrunt configuration file lives
 func getTfVarsDir(terragruntOptions *options.TerragruntOptions) (string, error) {
 	terragruntConfigFileAbsPath, err := filepath.Abs(terragruntOptions.TerragruntConfigPath)
@@ -189,6 +142,60 @@ func getParentTfVarsDir(include *IncludeConfig, terragruntOptions *options.Terra
 	return filepath.ToSlash(parentPath), nil
 }
 
+// Return the absolute paths of all the .tfvars files in each folder from the parent directory where the Terragrunt
+// configuration file lives down to the directory of the current Terragrunt configuration file. The files are ordered
+// from the parent folder down to the current folder, so values in more specific folders override those in more general
+// ones when passed to Terraform as -var-file arguments. Terragrunt configuration files (terraform.tfvars) are skipped.
+func getVarFileHierarchy(include *IncludeConfig, terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	rootDir, err := getParentTfVarsDir(include, terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	currentDir, err := getTfVarsDir(terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	relativePath, err := util.GetPathRelativeTo(currentDir, rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if relativePath == ".." || strings.HasPrefix(relativePath, "../") {
+		return nil, errors.WithStackTrace(VarFileHierarchyRootNotAParent{RootDir: rootDir, CurrentDir: currentDir})
+	}
+
+	folders := []string{rootDir}
+	if relativePath != "." {
+		folder := rootDir
+		for _, pathPart := range strings.Split(relativePath, "/") {
+			folder = util.JoinPath(folder, pathPart)
+			folders = append(folders, folder)
+		}
+	}
+
+	varFiles := []string{}
+	for _, folder := range folders {
+		pattern := filepath.Join(folder, "*.tfvars")
+		recordGlobDependency(terragruntOptions, pattern)
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+
+		// filepath.Glob returns the matches in lexical order
+		for _, match := range matches {
+			if filepath.Base(match) == DefaultTerragruntConfigPath {
+				continue
+			}
+			varFiles = append(varFiles, filepath.ToSlash(match))
+		}
+	}
+
+	return varFiles, nil
+}
+
 func parseGetEnvParameters(parameters string) (EnvVar, error) {
 	envVariable := EnvVar{}
 	matches := HELPER_FUNCTION_GET_ENV_PARAMETERS_SYNTAX_REGEX.FindStringSubmatch(parameters)
@@ -198,10 +205,10 @@ func parseGetEnvParameters(parameters string) (EnvVar, error) {
 
 	for index, name := range HELPER_FUNCTION_GET_ENV_PARAMETERS_SYNTAX_REGEX.SubexpNames() {
 		if name == "env" {
-			envVariable.Name = strings.TrimSpace(matches[index])
+			envVariable.Name = unescapeHclString(strings.TrimSpace(matches[index]))
 		}
 		if name == "default" {
-			envVariable.DefaultValue = strings.TrimSpace(matches[index])
+			envVariable.DefaultValue = unescapeHclString(strings.TrimSpace(matches[index]))
 		}
 	}
 
@@ -214,6 +221,7 @@ func getEnvironmentVariable(parameters string, terragruntOptions *options.Terrag
 	if err != nil {
 		return "", errors.WithStackTrace(err)
 	}
+	recordEnvVarDependency(terragruntOptions, parameterMap.Name)
 	envValue, exists := terragruntOptions.Env[parameterMap.Name]
 
 	if !exists {
@@ -223,6 +231,31 @@ func getEnvironmentVariable(parameters string, terragruntOptions *options.Terrag
 	return envValue, nil
 }
 
+// The prefix of the environment variables that set feature flags, e.g. TERRAGRUNT_FEATURE_NEW_VPC for new_vpc
+const FeatureEnvVarPrefix = "TERRAGRUNT_FEATURE_"
+
+// Return the value of the feature flag with the given name: the value set with --terragrunt-feature, if any, or else
+// the value of the TERRAGRUNT_FEATURE_<NAME> environment variable, with the name in upper case, if set, or else the
+// default passed as the second parameter, if any, or an empty string
+func getFeature(parameters string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	name, defaultValue, numParams, err := parseOptionalQuotedParam(parameters)
+	if err != nil || numParams == 0 || name == "" {
+		return "", errors.WithStackTrace(InvalidGetFeatureParams(parameters))
+	}
+
+	if value, isSet := terragruntOptions.Features[name]; isSet {
+		return value, nil
+	}
+
+	envVarName := FeatureEnvVarPrefix + strings.ToUpper(name)
+	recordEnvVarDependency(terragruntOptions, envVarName)
+	if value, isSet := terragruntOptions.Env[envVarName]; isSet {
+		return value, nil
+	}
+
+	return defaultValue, nil
+}
+
 // Find a parent Terragrunt configuration file in the parent folders above the current Terragrunt configuration file
 // and return its path
 func findInParentFolders(parameters string, terragruntOptions *options.TerragruntOptions) (string, error) {
@@ -260,7 +293,11 @@ func findInParentFolders(parameters string, terragruntOptions *options.Terragrun
 		fileToFind := DefaultConfigPath(currentDir)
 		if fileToFindParam != "" {
 			fileToFind = util.JoinPath(currentDir, fileToFindParam)
+		} else {
+			// Which of the two config files DefaultConfigPath returns depends on whether the old one exists
+			recordFileDependency(terragruntOptions, util.JoinPath(currentDir, OldTerragruntConfigPath))
 		}
+		recordFileDependency(terragruntOptions, fileToFind)
 
 		if util.FileExists(fileToFind) {
 			return util.GetPathRelativeTo(fileToFind, filepath.Dir(terragruntOptions.TerragruntConfigPath))
@@ -269,11 +306,17 @@ func findInParentFolders(parameters string, terragruntOptions *options.Terragrun
 		previousDir = currentDir
 	}
 
+	if numParams == 2 {
+		return fallbackParam, nil
+	}
 	return "", errors.WithStackTrace(ParentFileNotFound{Path: terragruntOptions.TerragruntConfigPath, File: fileToFindStr, Cause: fmt.Sprintf("Exceeded maximum folders to check (%d)", terragruntOptions.MaxFoldersToCheck)})
 }
 
-var oneQuotedParamRegex = regexp.MustCompile(`^"([^"]*?)"$`)
-var twoQuotedParamsRegex = regexp.MustCompile(`^"([^"]*?)"\s*,\s*"([^"]*?)"$`)
+// A parameter in quotes, in which quotes and backslashes are escaped, as escapeHclString does
+const quotedParamPattern = `"((?:[^"\\]|\\.)*)"`
+
+var oneQuotedParamRegex = regexp.MustCompile(`^` + quotedParamPattern + `$`)
+var twoQuotedParamsRegex = regexp.MustCompile(`^` + quotedParamPattern + `\s*,\s*` + quotedParamPattern + `$`)
 
 // Parse two optional parameters, wrapped in quotes, passed to a function, and return the parameter values and how many
 // of the parameters were actually set. For example, if you have a function foo(bar, baz), where bar and baz are
@@ -291,12 +334,12 @@ func parseOptionalQuotedParam(parameters string) (string, string, int, error) {
 
 	matches := oneQuotedParamRegex.FindStringSubmatch(trimmedParameters)
 	if len(matches) == 2 {
-		return matches[1], "", 1, nil
+		return unescapeHclString(matches[1]), "", 1, nil
 	}
 
 	matches = twoQuotedParamsRegex.FindStringSubmatch(trimmedParameters)
 	if len(matches) == 3 {
-		return matches[1], matches[2], 2, nil
+		return unescapeHclString(matches[1]), unescapeHclString(matches[2]), 2, nil
 	}
 
 	return "", "", 0, errors.WithStackTrace(InvalidStringParams(parameters))
@@ -345,23 +388,356 @@ func pathRelativeFromInclude(include *IncludeConfig, terragruntOptions *options.
 	return util.GetPathRelativeTo(includePath, currentPath)
 }
 
+// The name of the state file get_state_key puts at the end of the key, unless it's passed another one
+const DefaultStateFileName = "terraform.tfstate"
+
+// Return a key for the remote state of the current module that is unique to it: the path relative to the included
+// Terragrunt configuration file, as with path_relative_to_include, followed by the file name passed as the second
+// parameter, or DefaultStateFileName if it's not set. If the first parameter is set, the key starts with it, so several
+// stacks can share a bucket. For example, get_state_key("prod") returns prod/mysql/terraform.tfstate for the mysql
+// module. The key always uses forward slashes, whatever the OS.
+func getStateKey(parameters string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions) (string, error) {
+	prefix, fileName, numParams, err := parseOptionalQuotedParam(parameters)
+	if err != nil {
+		return "", errors.WithStackTrace(InvalidGetStateKeyParams(parameters))
+	}
+	if numParams == 2 && fileName == "" {
+		return "", errors.WithStackTrace(InvalidGetStateKeyParams(parameters))
+	}
+	if numParams < 2 {
+		fileName = DefaultStateFileName
+	}
+
+	relativePath, err := pathRelativeToInclude(include, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(prefix, filepath.ToSlash(relativePath), fileName), nil
+}
+
+// Return the value of the attribute with the given name in the given HCL file (e.g. a region.tfvars with
+// region = "us-east-1"), so a value can be defined once and used in the configs of many modules. A relative path is
+// relative to the folder of the current Terragrunt config, and if it's just a file name and there is no such file in
+// that folder, the file is looked up in the parent folders, as with find_in_parent_folders. The attribute name may
+// contain dots to read an attribute in a block (e.g. "terragrunt.remote_state.backend").
+func readTerragruntConfig(parameters string, terragruntOptions *options.TerragruntOptions) (interface{}, error) {
+	path, attribute, numParams, err := parseOptionalQuotedParam(parameters)
+	if err != nil || numParams != 2 || path == "" || attribute == "" {
+		return "", errors.WithStackTrace(InvalidReadTerragruntConfigParams(parameters))
+	}
+
+	filePath, err := findFileForHelperFunction(path, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := util.ReadFileAsString(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	var attributes map[string]interface{}
+	if err := hcl.Decode(&attributes, contents); err != nil {
+		return "", errors.WithStackTrace(ErrorParsingTerragruntConfig{ConfigPath: filePath, Underlying: err})
+	}
+
+	value, err := getHclAttribute(attributes, attribute)
+	if err != nil {
+		return "", errors.WithStackTrace(ReadTerragruntConfigAttributeError{Path: filePath, Attribute: attribute, Cause: err.Error()})
+	}
+	return value, nil
+}
+
+// Return the path of the file at the given path, as passed to a helper function such as read_terragrunt_config. A relative
+// path is relative to the folder of the current Terragrunt config, and if it's just a file name and there is no such file
+// in that folder, the file is looked up in the parent folders, as with find_in_parent_folders.
+func findFileForHelperFunction(path string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	filePath := path
+	if !filepath.IsAbs(filePath) {
+		filePath = util.JoinPath(filepath.Dir(terragruntOptions.TerragruntConfigPath), path)
+	}
+	recordFileDependency(terragruntOptions, filePath)
+	if !util.FileExists(filePath) && !strings.ContainsAny(path, `/\`) {
+		relativePath, err := findInParentFolders(fmt.Sprintf(`"%s"`, path), terragruntOptions)
+		if err != nil {
+			return "", err
+		}
+		filePath = util.JoinPath(filepath.Dir(terragruntOptions.TerragruntConfigPath), relativePath)
+	}
+
+	recordFileDependency(terragruntOptions, filePath)
+	return filePath, nil
+}
+
+// Read the YAML (for read_yaml) or JSON (for read_json) file at the given path and return its contents as a value that
+// can be used in an expression, such as read_yaml("accounts.yaml").prod.id, so shared data, such as a registry of
+// accounts or a map of CIDR blocks, can be kept in one data file rather than copied into the configs as HCL. The file
+// is looked up as with read_terragrunt_config.
+func readDataFile(functionName string, parameters string, terragruntOptions *options.TerragruntOptions) (cty.Value, error) {
+	path, _, numParams, err := parseOptionalQuotedParam(parameters)
+	if err != nil || numParams != 1 || path == "" {
+		return cty.NilVal, errors.WithStackTrace(InvalidReadDataFileParams{FunctionName: functionName, Params: parameters})
+	}
+
+	filePath, err := findFileForHelperFunction(path, terragruntOptions)
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	contents, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return cty.NilVal, errors.WithStackTrace(err)
+	}
+
+	impliedType, unmarshal := ctyjson.ImpliedType, ctyjson.Unmarshal
+	if functionName == "read_yaml" {
+		impliedType, unmarshal = ctyyaml.Standard.ImpliedType, ctyyaml.Standard.Unmarshal
+	}
+
+	valueType, err := impliedType(contents)
+	if err != nil {
+		return cty.NilVal, errors.WithStackTrace(ErrorParsingDataFile{Path: filePath, Underlying: err})
+	}
+	value, err := unmarshal(contents, valueType)
+	if err != nil {
+		return cty.NilVal, errors.WithStackTrace(ErrorParsingDataFile{Path: filePath, Underlying: err})
+	}
+	return value, nil
+}
+
+// Return the value of the attribute with the given dot-separated name in the given decoded HCL. Strings, numbers, and
+// booleans, and lists of strings, are supported, as those are the values an interpolation can return.
+func getHclAttribute(attributes map[string]interface{}, name string) (interface{}, error) {
+	var value interface{} = attributes
+
+	for _, key := range strings.Split(name, ".") {
+		// The HCL decoder turns each block into a list with a single map
+		if blocks, isBlockList := value.([]map[string]interface{}); isBlockList && len(blocks) == 1 {
+			value = blocks[0]
+		}
+
+		object, isObject := value.(map[string]interface{})
+		if !isObject {
+			return nil, fmt.Errorf("%s is not a block", key)
+		}
+
+		var found bool
+		value, found = object[key]
+		if !found {
+			return nil, fmt.Errorf("no attribute named %s", key)
+		}
+	}
+
+	switch value := value.(type) {
+	case string, bool, int, int64, float64:
+		return value, nil
+	case []interface{}:
+		strs := []string{}
+		for _, item := range value {
+			str, isString := item.(string)
+			if !isString {
+				return nil, fmt.Errorf("only lists of strings are supported, but the list contains %v", item)
+			}
+			strs = append(strs, str)
+		}
+		return strs, nil
+	default:
+		return nil, fmt.Errorf("only strings, numbers, booleans, and lists of strings are supported, but %s is a %T", name, value)
+	}
+}
+
+// Return the value of the attribute with the given dot-separated name (e.g. "aws_region", or
+// "terragrunt.remote_state.backend") in the config that the current config includes, after resolving the
+// interpolations in it the same way as when it's included. The include block of the current config must set
+// expose = true, so that the attributes of a parent config, such as the variables it sets for Terraform, can be used
+// to build up the config of its children, and not only be merged into them.
+func getIncludedAttribute(parameters string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions) (interface{}, error) {
+	attribute, _, numParams, err := parseOptionalQuotedParam(parameters)
+	if err != nil || numParams != 1 || attribute == "" {
+		return "", errors.WithStackTrace(InvalidGetIncludedAttributeParams(parameters))
+	}
+
+	// While the included config is being parsed, the include is the include block of the config that includes it
+	if include != nil {
+		return "", errors.WithStackTrace(IncludedAttributeInIncludedConfig(terragruntOptions.TerragruntConfigPath))
+	}
+
+	configString, err := util.ReadFileAsString(terragruntOptions.TerragruntConfigPath)
+	if err != nil {
+		return "", err
+	}
+	ownInclude, err := findIncludeInConfigString(configString, terragruntOptions.TerragruntConfigPath)
+	if err != nil {
+		return "", err
+	}
+	if ownInclude == nil || !ownInclude.Expose {
+		return "", errors.WithStackTrace(IncludeNotExposed(terragruntOptions.TerragruntConfigPath))
+	}
+
+	includePath, err := ResolveTerragruntConfigString(ownInclude.Path, nil, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(includePath) {
+		includePath = util.JoinPath(filepath.Dir(terragruntOptions.TerragruntConfigPath), includePath)
+	}
+
+	recordFileDependency(terragruntOptions, includePath)
+	includedConfigString, err := util.ReadFileAsString(includePath)
+	if err != nil {
+		return "", err
+	}
+	resolvedConfigString, err := ResolveTerragruntConfigString(includedConfigString, ownInclude, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+
+	var attributes map[string]interface{}
+	if err := hcl.Decode(&attributes, resolvedConfigString); err != nil {
+		return "", errors.WithStackTrace(ErrorParsingTerragruntConfig{ConfigPath: includePath, Underlying: err})
+	}
+
+	value, err := getHclAttribute(attributes, attribute)
+	if err != nil {
+		return "", errors.WithStackTrace(ReadTerragruntConfigAttributeError{Path: includePath, Attribute: attribute, Cause: err.Error()})
+	}
+	return value, nil
+}
+
+// Return the include block in the given config string, read from the given config file, without resolving any of the
+// interpolations in the config, or nil if there is none. Only the include block is decoded, as the rest of the config
+// may not be valid until its interpolations are resolved.
+func findIncludeInConfigString(configString string, configPath string) (*IncludeConfig, error) {
+	type configWithInclude struct {
+		Include *IncludeConfig `hcl:"include,omitempty"`
+	}
+
+	if isOldTerragruntConfig(configPath) {
+		config := &configWithInclude{}
+		if err := hcl.Decode(config, configString); err != nil {
+			return nil, errors.WithStackTrace(ErrorParsingTerragruntConfig{ConfigPath: configPath, Underlying: err})
+		}
+		return config.Include, nil
+	}
+
+	tfvarsConfig := &struct {
+		Terragrunt *configWithInclude `hcl:"terragrunt,omitempty"`
+	}{}
+	if err := hcl.Decode(tfvarsConfig, configString); err != nil {
+		return nil, errors.WithStackTrace(ErrorParsingTerragruntConfig{ConfigPath: configPath, Underlying: err})
+	}
+	if tfvarsConfig.Terragrunt == nil {
+		return nil, nil
+	}
+	return tfvarsConfig.Terragrunt.Include, nil
+}
+
+// Decrypt the given sops-encrypted file (e.g. a secrets.enc.yaml) by running the sops binary and return the decrypted
+// contents, or, if a key is given, only the value of that key (e.g. "db.password"). A relative path is relative to the
+// folder of the current Terragrunt config.
+func sopsDecryptFile(parameters string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	path, key, numParams, err := parseOptionalQuotedParam(parameters)
+	if err != nil || numParams == 0 || path == "" || (numParams == 2 && key == "") {
+		return "", errors.WithStackTrace(InvalidSopsDecryptFileParams(parameters))
+	}
+
+	if !filepath.IsAbs(path) {
+		path = util.JoinPath(filepath.Dir(terragruntOptions.TerragruntConfigPath), path)
+	}
+	if !util.FileExists(path) {
+		return "", errors.WithStackTrace(SopsDecryptError{Path: path, Output: "file does not exist"})
+	}
+
+	args := []string{"--decrypt"}
+	if key != "" {
+		args = append(args, "--extract", sopsExtractPath(key))
+	}
+	args = append(args, path)
+
+	// Only stdout is the decrypted value. Anything sops writes to stderr, such as warnings, must not end up in it.
+	output, err := shell.RunCommandAndCaptureOutput(terragruntOptions, shell.CaptureOptions{MaxBytes: -1}, "sops", args...)
+	if err != nil {
+		errOutput := strings.TrimSpace(output.Stderr)
+		if errOutput == "" {
+			errOutput = err.Error()
+		}
+		return "", errors.WithStackTrace(SopsDecryptError{Path: path, Output: errOutput})
+	}
+
+	return output.Stdout, nil
+}
+
+// Convert a dot-separated key, such as db.password, to the syntax sops uses for --extract, such as ["db"]["password"]
+func sopsExtractPath(key string) string {
+	var path bytes.Buffer
+	for _, part := range strings.Split(key, ".") {
+		path.WriteString(fmt.Sprintf(`[%q]`, part))
+	}
+	return path.String()
+}
+
+// Escape the backslashes, quotes, and newlines in the given string so it can be used inside a quoted HCL string
+func escapeHclString(str string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(str)
+}
+
+// Undo escapeHclString
+func unescapeHclString(str string) string {
+	return strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\r`, "\r", `\t`, "\t").Replace(str)
+}
+
+// Return the value of the given key in the given Vault secret (e.g. "secret/data/db", "password"). See the vault_helper
+// package for how Terragrunt connects to Vault.
+func getVaultSecret(parameters string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	path, key, numParams, err := parseOptionalQuotedParam(parameters)
+	if err != nil || numParams != 2 || path == "" || key == "" {
+		return "", errors.WithStackTrace(InvalidGetVaultSecretParams(parameters))
+	}
+
+	return vault_helper.GetSecretValue(path, key, terragruntOptions)
+}
+
 // Return the AWS account id associated to the current set of credentials
 func getAWSAccountID(terragruntOptions *options.TerragruntOptions) (string, error) {
-	sess, err := session.NewSession()
+	sess, err := aws_helper.CreateDefaultAwsSessionWithIamRoles("", terragruntOptions)
 	if err != nil {
-		return "", errors.WithStackTrace(err)
+		return "", err
 	}
 
-	if terragruntOptions.IamRole != "" {
-		sess.Config.Credentials = stscreds.NewCredentials(sess, terragruntOptions.IamRole)
+	return aws_helper.GetAwsAccountId(sts.New(sess), terragruntOptions)
+}
+
+// Return the value of the given SSM Parameter Store parameter, optionally in the given region, with the credentials of
+// the IAM role in the options, if any
+func getSsmParameter(parameters string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	name, region, numParams, err := parseOptionalQuotedParam(parameters)
+	if err != nil || numParams == 0 || name == "" {
+		return "", errors.WithStackTrace(InvalidGetSsmParameterParams(parameters))
 	}
 
-	identity, err := sts.New(sess).GetCallerIdentity(nil)
+	sess, err := aws_helper.CreateDefaultAwsSessionWithIamRoles(region, terragruntOptions)
 	if err != nil {
-		return "", errors.WithStackTrace(err)
+		return "", err
+	}
+
+	return aws_helper.GetSsmParameter(ssm.New(sess), name, terragruntOptions)
+}
+
+// Return the value of the given Secrets Manager secret, or of the given key in it, with the credentials of the IAM role
+// in the options, if any. A secret given by ARN is read from the region in the ARN.
+func getSecretsManagerSecret(parameters string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	secretId, key, numParams, err := parseOptionalQuotedParam(parameters)
+	if err != nil || numParams == 0 || secretId == "" || (numParams == 2 && key == "") {
+		return "", errors.WithStackTrace(InvalidGetSecretsManagerSecretParams(parameters))
+	}
+
+	sess, err := aws_helper.CreateDefaultAwsSessionWithIamRoles(aws_helper.RegionFromArn(secretId), terragruntOptions)
+	if err != nil {
+		return "", err
 	}
 
-	return *identity.Account, nil
+	return aws_helper.GetSecretsManagerSecret(secretsmanager.New(sess), secretId, key, terragruntOptions)
 }
 
 // Custom error types
@@ -369,7 +745,7 @@ func getAWSAccountID(terragruntOptions *options.TerragruntOptions) (string, erro
 type InvalidInterpolationSyntax string
 
 func (err InvalidInterpolationSyntax) Error() string {
-	return fmt.Sprintf("Invalid interpolation syntax. Expected syntax of the form '${function_name()}', but got '%s'", string(err))
+	return fmt.Sprintf("Invalid interpolation syntax. Expected an expression of the form '${function_name()}', '${condition ? a : b}', or '${[for item in list : item]}', but got '%s'", string(err))
 }
 
 type UnknownHelperFunction string
@@ -378,6 +754,18 @@ func (err UnknownHelperFunction) Error() string {
 	return fmt.Sprintf("Unknown helper function: %s", string(err))
 }
 
+type InvalidGetStateKeyParams string
+
+func (params InvalidGetStateKeyParams) Error() string {
+	return fmt.Sprintf("Invalid parameters. Expected syntax of the form '${get_state_key()}', '${get_state_key(\"prefix\")}', or '${get_state_key(\"prefix\", \"file name\")}', but got '%s'", string(params))
+}
+
+type InvalidGetFeatureParams string
+
+func (params InvalidGetFeatureParams) Error() string {
+	return fmt.Sprintf("Invalid parameters. Expected syntax of the form '${get_feature(\"name\")}' or '${get_feature(\"name\", \"default\")}', but got '%s'", string(params))
+}
+
 type ParentFileNotFound struct {
 	Path  string
 	File  string
@@ -405,3 +793,101 @@ type EmptyStringNotAllowed string
 func (err EmptyStringNotAllowed) Error() string {
 	return fmt.Sprintf("Empty string value is not allowed for %s", string(err))
 }
+
+type VarFileHierarchyRootNotAParent struct {
+	RootDir    string
+	CurrentDir string
+}
+
+func (err VarFileHierarchyRootNotAParent) Error() string {
+	return fmt.Sprintf("Cannot build a var file hierarchy: the parent Terragrunt configuration folder %s is not a parent of %s", err.RootDir, err.CurrentDir)
+}
+
+type InvalidReadTerragruntConfigParams string
+
+func (params InvalidReadTerragruntConfigParams) Error() string {
+	return fmt.Sprintf("Invalid parameters. Expected syntax of the form '${read_terragrunt_config(\"path\", \"attribute\")}', but got '%s'", string(params))
+}
+
+type ReadTerragruntConfigAttributeError struct {
+	Path      string
+	Attribute string
+	Cause     string
+}
+
+func (err ReadTerragruntConfigAttributeError) Error() string {
+	return fmt.Sprintf("Could not read attribute %s from %s: %s", err.Attribute, err.Path, err.Cause)
+}
+
+type InvalidReadDataFileParams struct {
+	FunctionName string
+	Params       string
+}
+
+func (err InvalidReadDataFileParams) Error() string {
+	return fmt.Sprintf("Invalid parameters. Expected syntax of the form '${%s(\"path\")}', but got '%s'", err.FunctionName, err.Params)
+}
+
+type ErrorParsingDataFile struct {
+	Path       string
+	Underlying error
+}
+
+func (err ErrorParsingDataFile) Error() string {
+	return fmt.Sprintf("Error parsing %s: %v", err.Path, err.Underlying)
+}
+
+func (err ErrorParsingDataFile) Unwrap() error {
+	return err.Underlying
+}
+
+type InvalidGetIncludedAttributeParams string
+
+func (params InvalidGetIncludedAttributeParams) Error() string {
+	return fmt.Sprintf("Invalid parameters. Expected syntax of the form '${get_included_attribute(\"attribute\")}', but got '%s'", string(params))
+}
+
+type IncludedAttributeInIncludedConfig string
+
+func (configPath IncludedAttributeInIncludedConfig) Error() string {
+	return fmt.Sprintf("get_included_attribute can only be used in the config that has the include block, not in the config it includes, which %s does", string(configPath))
+}
+
+type IncludeNotExposed string
+
+func (configPath IncludeNotExposed) Error() string {
+	return fmt.Sprintf("get_included_attribute can only be used in a config whose include block sets expose = true, but the include block in %s doesn't", string(configPath))
+}
+
+type InvalidSopsDecryptFileParams string
+
+func (params InvalidSopsDecryptFileParams) Error() string {
+	return fmt.Sprintf("Invalid parameters. Expected syntax of the form '${sops_decrypt_file(\"path\")}' or '${sops_decrypt_file(\"path\", \"key\")}', but got '%s'", string(params))
+}
+
+type InvalidGetVaultSecretParams string
+
+func (params InvalidGetVaultSecretParams) Error() string {
+	return fmt.Sprintf("Invalid parameters. Expected syntax of the form '${get_vault_secret(\"path\", \"key\")}', but got '%s'", string(params))
+}
+
+type InvalidGetSsmParameterParams string
+
+func (params InvalidGetSsmParameterParams) Error() string {
+	return fmt.Sprintf("Invalid parameters. Expected syntax of the form '${get_ssm_parameter(\"name\")}' or '${get_ssm_parameter(\"name\", \"region\")}', but got '%s'", string(params))
+}
+
+type InvalidGetSecretsManagerSecretParams string
+
+func (params InvalidGetSecretsManagerSecretParams) Error() string {
+	return fmt.Sprintf("Invalid parameters. Expected syntax of the form '${get_secretsmanager_secret(\"name\")}' or '${get_secretsmanager_secret(\"name\", \"key\")}', but got '%s'", string(params))
+}
+
+type SopsDecryptError struct {
+	Path   string
+	Output string
+}
+
+func (err SopsDecryptError) Error() string {
+	return fmt.Sprintf("Could not decrypt %s with sops: %s", err.Path, err.Output)
+}
@@ -69,6 +69,41 @@ func TestPathRelativeToInclude(t *testing.T) {
 	}
 }
 
+func TestGetStateKey(t *testing.T) {
+	t.Parallel()
+
+	include := &IncludeConfig{Path: "../../" + DefaultTerragruntConfigPath}
+	terragruntOptions := terragruntOptionsForTest(t, helpers.RootFolder+"prod/mysql/"+DefaultTerragruntConfigPath)
+
+	testCases := []struct {
+		params      string
+		include     *IncludeConfig
+		expectedKey string
+	}{
+		{``, include, "prod/mysql/terraform.tfstate"},
+		{`""`, include, "prod/mysql/terraform.tfstate"},
+		{`"us-east-1"`, include, "us-east-1/prod/mysql/terraform.tfstate"},
+		{`"us-east-1/", "default.tfstate"`, include, "us-east-1/prod/mysql/default.tfstate"},
+		{``, nil, "terraform.tfstate"},
+	}
+
+	for _, testCase := range testCases {
+		actualKey, actualErr := getStateKey(testCase.params, testCase.include, terragruntOptions)
+		assert.Nil(t, actualErr, "For params %s, unexpected error: %v", testCase.params, actualErr)
+		assert.Equal(t, testCase.expectedKey, actualKey, "For params %s", testCase.params)
+	}
+
+	for _, params := range []string{`"a", ""`, `"a", "b", "c"`, `a`} {
+		_, actualErr := getStateKey(params, include, terragruntOptions)
+		_, isInvalidParamsErr := errors.Unwrap(actualErr).(InvalidGetStateKeyParams)
+		assert.True(t, isInvalidParamsErr, "Expected an InvalidGetStateKeyParams error for params %s but got: %v", params, actualErr)
+	}
+
+	actualOut, actualErr := ResolveTerragruntConfigString(`key = "${get_state_key("stage")}"`, include, terragruntOptions)
+	assert.Nil(t, actualErr, "Unexpected error: %v", actualErr)
+	assert.Equal(t, `key = "stage/prod/mysql/terraform.tfstate"`, actualOut)
+}
+
 func TestPathRelativeFromInclude(t *testing.T) {
 	t.Parallel()
 
@@ -195,6 +230,18 @@ func TestFindInParentFolders(t *testing.T) {
 			"fallback.txt",
 			nil,
 		},
+		{
+			`"foo.txt", "fallback.txt"`,
+			terragruntOptionsForTestWithMaxFolders(t, "../test/fixture-parent-folders/no-terragrunt-in-root/child/sub-child/"+DefaultTerragruntConfigPath, 3),
+			"fallback.txt",
+			nil,
+		},
+		{
+			`"terraform.tfvars", "fallback.txt"`,
+			terragruntOptionsForTest(t, "../test/fixture-parent-folders/multiple-terragrunt-in-parents/child/sub-child/"+DefaultTerragruntConfigPath),
+			"../" + DefaultTerragruntConfigPath,
+			nil,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -212,6 +259,218 @@ func TestFindInParentFolders(t *testing.T) {
 	}
 }
 
+func TestReadTerragruntConfig(t *testing.T) {
+	t.Parallel()
+
+	appConfigPath := "../test/fixture-read-config/us-east-1/app/" + DefaultTerragruntConfigPath
+
+	testCases := []struct {
+		params            string
+		terragruntOptions *options.TerragruntOptions
+		expectedValue     interface{}
+		expectedErr       error
+	}{
+		{`"region.tfvars", "region"`, terragruntOptionsForTest(t, appConfigPath), "us-east-1", nil},
+		{`"../region.tfvars", "region"`, terragruntOptionsForTest(t, appConfigPath), "us-east-1", nil},
+		{`"region.tfvars", "instance_count"`, terragruntOptionsForTest(t, appConfigPath), 3, nil},
+		{`"account.tfvars", "account_name"`, terragruntOptionsForTest(t, appConfigPath), "prod", nil},
+		{`"account.tfvars", "account_ids"`, terragruntOptionsForTest(t, appConfigPath), []string{"111111111111", "222222222222"}, nil},
+		{`"account.tfvars", "terragrunt.remote_state.backend"`, terragruntOptionsForTest(t, appConfigPath), "s3", nil},
+		{`"account.tfvars", "terragrunt"`, terragruntOptionsForTest(t, appConfigPath), nil, ReadTerragruntConfigAttributeError{}},
+		{`"account.tfvars", "not_there"`, terragruntOptionsForTest(t, appConfigPath), nil, ReadTerragruntConfigAttributeError{}},
+		{`"account.tfvars", "account_name.foo"`, terragruntOptionsForTest(t, appConfigPath), nil, ReadTerragruntConfigAttributeError{}},
+		{`"not-there.tfvars", "region"`, terragruntOptionsForTest(t, appConfigPath), nil, ParentFileNotFound{}},
+		{`"region.tfvars"`, terragruntOptionsForTest(t, appConfigPath), nil, InvalidReadTerragruntConfigParams("")},
+		{``, terragruntOptionsForTest(t, appConfigPath), nil, InvalidReadTerragruntConfigParams("")},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.params, func(t *testing.T) {
+			actualValue, actualErr := readTerragruntConfig(testCase.params, testCase.terragruntOptions)
+			if testCase.expectedErr != nil {
+				if assert.Error(t, actualErr) {
+					assert.IsType(t, testCase.expectedErr, errors.Unwrap(actualErr))
+				}
+			} else {
+				assert.Nil(t, actualErr)
+				assert.Equal(t, testCase.expectedValue, actualValue)
+			}
+		})
+	}
+}
+
+func TestReadDataFile(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := terragruntOptionsForTest(t, "../test/fixture-read-config/us-east-1/app/"+DefaultTerragruntConfigPath)
+
+	testCases := []struct {
+		str         string
+		expectedOut string
+	}{
+		{`account_id = "${read_yaml("accounts.yaml").prod.id}"`, `account_id = "111111111111"`},
+		{`regions = ["${read_yaml("accounts.yaml")["stage"].regions}"]`, `regions = ["us-east-1"]`},
+		{`ids = ["${[for name, account in read_yaml("accounts.yaml") : account.id]}"]`, `ids = ["111111111111", "222222222222"]`},
+		{`vpc = "${read_json("cidrs.json").vpc}"`, `vpc = "10.0.0.0/16"`},
+		{`subnets = ["${read_json("../cidrs.json").subnets}"]`, `subnets = ["10.0.1.0/24", "10.0.2.0/24"]`},
+		{`count = "${read_json("cidrs.json").nat_gateways}"`, `count = 2`},
+	}
+
+	for _, testCase := range testCases {
+		actualOut, actualErr := ResolveTerragruntConfigString(testCase.str, nil, terragruntOptions)
+		assert.Nil(t, actualErr, "For string '%s', unexpected error: %v", testCase.str, actualErr)
+		assert.Equal(t, testCase.expectedOut, actualOut, "For string '%s'", testCase.str)
+	}
+}
+
+func TestReadDataFileErrors(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := terragruntOptionsForTest(t, "../test/fixture-read-config/us-east-1/app/"+DefaultTerragruntConfigPath)
+
+	testCases := []struct {
+		functionName string
+		params       string
+		expectedErr  error
+	}{
+		{"read_yaml", ``, InvalidReadDataFileParams{}},
+		{"read_json", `"cidrs.json", "vpc"`, InvalidReadDataFileParams{}},
+		{"read_yaml", `"not-there.yaml"`, ParentFileNotFound{}},
+		{"read_yaml", `"invalid.yaml"`, ErrorParsingDataFile{}},
+		{"read_json", `"accounts.yaml"`, ErrorParsingDataFile{}},
+	}
+
+	for _, testCase := range testCases {
+		_, actualErr := readDataFile(testCase.functionName, testCase.params, terragruntOptions)
+		if assert.Error(t, actualErr, "For %s(%s)", testCase.functionName, testCase.params) {
+			assert.IsType(t, testCase.expectedErr, errors.Unwrap(actualErr), "For %s(%s)", testCase.functionName, testCase.params)
+		}
+	}
+}
+
+func TestGetIncludedAttribute(t *testing.T) {
+	t.Parallel()
+
+	exposedConfigPath := "../test/fixture-include-expose/exposed/" + DefaultTerragruntConfigPath
+	notExposedConfigPath := "../test/fixture-include-expose/not-exposed/" + DefaultTerragruntConfigPath
+	rootConfigPath := "../test/fixture-include-expose/" + DefaultTerragruntConfigPath
+
+	testCases := []struct {
+		params            string
+		include           *IncludeConfig
+		terragruntOptions *options.TerragruntOptions
+		expectedValue     interface{}
+		expectedErr       error
+	}{
+		{`"aws_region"`, nil, terragruntOptionsForTest(t, exposedConfigPath), "us-west-2", nil},
+		{`"allowed_account_ids"`, nil, terragruntOptionsForTest(t, exposedConfigPath), []string{"111111111111"}, nil},
+		{`"terragrunt.remote_state.config.key"`, nil, terragruntOptionsForTest(t, exposedConfigPath), "exposed/terraform.tfstate", nil},
+		{`"not_there"`, nil, terragruntOptionsForTest(t, exposedConfigPath), nil, ReadTerragruntConfigAttributeError{}},
+		{`"aws_region"`, nil, terragruntOptionsForTest(t, notExposedConfigPath), nil, IncludeNotExposed("")},
+		{`"aws_region"`, nil, terragruntOptionsForTest(t, rootConfigPath), nil, IncludeNotExposed("")},
+		{`"aws_region"`, &IncludeConfig{Path: "../" + DefaultTerragruntConfigPath, Expose: true}, terragruntOptionsForTest(t, exposedConfigPath), nil, IncludedAttributeInIncludedConfig("")},
+		{`"aws_region", "us-east-1"`, nil, terragruntOptionsForTest(t, exposedConfigPath), nil, InvalidGetIncludedAttributeParams("")},
+		{``, nil, terragruntOptionsForTest(t, exposedConfigPath), nil, InvalidGetIncludedAttributeParams("")},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.params, func(t *testing.T) {
+			actualValue, actualErr := getIncludedAttribute(testCase.params, testCase.include, testCase.terragruntOptions)
+			if testCase.expectedErr != nil {
+				if assert.Error(t, actualErr) {
+					assert.IsType(t, testCase.expectedErr, errors.Unwrap(actualErr))
+				}
+			} else {
+				assert.Nil(t, actualErr)
+				assert.Equal(t, testCase.expectedValue, actualValue)
+			}
+		})
+	}
+}
+
+func TestResolveTerragruntConfigStringReadTerragruntConfig(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := terragruntOptionsForTest(t, "../test/fixture-read-config/us-east-1/app/"+DefaultTerragruntConfigPath)
+
+	actualOut, actualErr := ResolveTerragruntConfigString(`bucket = "${read_terragrunt_config("account.tfvars", "account_name")}-state" ids = ["${read_terragrunt_config("account.tfvars", "account_ids")}"]`, nil, terragruntOptions)
+	assert.Nil(t, actualErr)
+	assert.Equal(t, `bucket = "prod-state" ids = ["111111111111", "222222222222"]`, actualOut)
+}
+
+func TestSopsDecryptFileInvalidParams(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := terragruntOptionsForTest(t, "../test/fixture-read-config/us-east-1/app/"+DefaultTerragruntConfigPath)
+
+	testCases := []struct {
+		params      string
+		expectedErr error
+	}{
+		{``, InvalidSopsDecryptFileParams("")},
+		{`""`, InvalidSopsDecryptFileParams("")},
+		{`"secrets.enc.yaml", ""`, InvalidSopsDecryptFileParams("")},
+		{`"not-there.enc.yaml"`, SopsDecryptError{}},
+		{`"not-there.enc.yaml", "db.password"`, SopsDecryptError{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.params, func(t *testing.T) {
+			_, actualErr := sopsDecryptFile(testCase.params, terragruntOptions)
+			if assert.Error(t, actualErr) {
+				assert.IsType(t, testCase.expectedErr, errors.Unwrap(actualErr))
+			}
+		})
+	}
+}
+
+func TestGetVaultSecretInvalidParams(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := terragruntOptionsForTest(t, DefaultTerragruntConfigPath)
+
+	for _, params := range []string{``, `"secret/data/db"`, `"", "password"`, `"secret/data/db", ""`} {
+		_, actualErr := getVaultSecret(params, terragruntOptions)
+		if assert.Error(t, actualErr, params) {
+			assert.IsType(t, InvalidGetVaultSecretParams(""), errors.Unwrap(actualErr), params)
+		}
+	}
+}
+
+func TestAwsSecretHelpersInvalidParams(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := terragruntOptionsForTest(t, DefaultTerragruntConfigPath)
+
+	for _, params := range []string{``, `""`, `"", "us-east-1"`} {
+		_, actualErr := getSsmParameter(params, terragruntOptions)
+		if assert.Error(t, actualErr, params) {
+			assert.IsType(t, InvalidGetSsmParameterParams(""), errors.Unwrap(actualErr), params)
+		}
+	}
+
+	for _, params := range []string{``, `""`, `"prod/db", ""`} {
+		_, actualErr := getSecretsManagerSecret(params, terragruntOptions)
+		if assert.Error(t, actualErr, params) {
+			assert.IsType(t, InvalidGetSecretsManagerSecretParams(""), errors.Unwrap(actualErr), params)
+		}
+	}
+}
+
+func TestSopsExtractPath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `["password"]`, sopsExtractPath("password"))
+	assert.Equal(t, `["db"]["password"]`, sopsExtractPath("db.password"))
+}
+
+func TestEscapeHclString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "foo", escapeHclString("foo"))
+	assert.Equal(t, `a \"quoted\" value\nwith\ttabs \\ and newlines\n`, escapeHclString("a \"quoted\" value\nwith\ttabs \\ and newlines\n"))
+}
+
 func TestParseOptionalQuotedParamsHappyPath(t *testing.T) {
 	t.Parallel()
 
@@ -231,6 +490,8 @@ func TestParseOptionalQuotedParamsHappyPath(t *testing.T) {
 		{`"foo","bar"`, 2, "foo", "bar"},
 		{`"foo",     "bar"`, 2, "foo", "bar"},
 		{`"","bar"`, 2, "", "bar"},
+		{`"say \"hi\""`, 1, `say "hi"`, ""},
+		{`"C:\\dir", "a\nb"`, 2, `C:\dir`, "a\nb"},
 	}
 
 	for _, testCase := range testCases {
@@ -255,6 +516,7 @@ func TestParseOptionalQuotedParamsErrors(t *testing.T) {
 		{`"`, InvalidStringParams(`"`)},
 		{`"foo", "`, InvalidStringParams(`"foo", "`)},
 		{`"foo" "bar"`, InvalidStringParams(`"foo" "bar"`)},
+		{`"foo\"`, InvalidStringParams(`"foo\"`)},
 		{`"foo", "bar", "baz"`, InvalidStringParams(`"foo", "bar", "baz"`)},
 	}
 
@@ -449,8 +711,8 @@ func TestResolveTerragruntConfigString(t *testing.T) {
 			"${find_in_parent_folders ()}",
 			nil,
 			terragruntOptionsForTest(t, "../test/fixture-parent-folders/terragrunt-in-root/child/sub-child/"+DefaultTerragruntConfigPath),
-			"",
-			InvalidInterpolationSyntax("${find_in_parent_folders ()}"),
+			"../../" + DefaultTerragruntConfigPath,
+			nil,
 		},
 		{
 			"foo/${find_in_parent_folders()}/bar",
@@ -526,14 +788,14 @@ func TestResolveEnvInterpolationConfigString(t *testing.T) {
 			nil,
 			terragruntOptionsForTest(t, "/root/child/"+DefaultTerragruntConfigPath),
 			"",
-			InvalidGetEnvParams(`"",""`),
+			InvalidGetEnvParams(`"", ""`),
 		},
 		{
 			`foo/${get_env(   ""    ,   ""    )}/bar`,
 			nil,
 			terragruntOptionsForTest(t, "/root/child/"+DefaultTerragruntConfigPath),
 			"",
-			InvalidGetEnvParams(`   ""    ,   ""    `),
+			InvalidGetEnvParams(`"", ""`),
 		},
 		{
 			`${get_env("SOME_VAR", "SOME{VALUE}")}`,
@@ -615,6 +877,38 @@ TERRAGRUNT_HIT","")}`),
 	}
 }
 
+func TestGetFeature(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := terragruntOptionsForTestWithEnv(t, DefaultTerragruntConfigPath, map[string]string{"TERRAGRUNT_FEATURE_NEW_VPC": "from-env", "TERRAGRUNT_FEATURE_REPLICAS": "3"})
+	terragruntOptions.Features = map[string]string{"new_vpc": "from-flag"}
+
+	testCases := []struct {
+		params        string
+		expectedValue string
+		expectedErr   error
+	}{
+		{`"new_vpc"`, "from-flag", nil},
+		{`"replicas", "1"`, "3", nil},
+		{`"dark_mode", "off"`, "off", nil},
+		{`"dark_mode"`, "", nil},
+		{``, "", InvalidGetFeatureParams("")},
+		{`"", "off"`, "", InvalidGetFeatureParams("")},
+	}
+
+	for _, testCase := range testCases {
+		actualValue, actualErr := getFeature(testCase.params, terragruntOptions)
+		if testCase.expectedErr != nil {
+			if assert.Error(t, actualErr, "For params %s", testCase.params) {
+				assert.IsType(t, testCase.expectedErr, errors.Unwrap(actualErr), "For params %s", testCase.params)
+			}
+		} else {
+			assert.Nil(t, actualErr, "For params %s, unexpected error: %v", testCase.params, actualErr)
+			assert.Equal(t, testCase.expectedValue, actualValue, "For params %s", testCase.params)
+		}
+	}
+}
+
 func TestResolveCommandsInterpolationConfigString(t *testing.T) {
 	t.Parallel()
 
@@ -825,3 +1119,66 @@ func TestGetParentTfVarsDir(t *testing.T) {
 		assert.Equal(t, testCase.expectedPath, actualPath, "For include %v and options %v", testCase.include, testCase.terragruntOptions)
 	}
 }
+
+func TestGetVarFileHierarchy(t *testing.T) {
+	t.Parallel()
+
+	fixtureDir, err := filepath.Abs("../test/fixture-var-file-hierarchy")
+	assert.Nil(t, err, "Could not get absolute path of fixture: %v", err)
+	fixtureDir = filepath.ToSlash(fixtureDir)
+
+	testCases := []struct {
+		include           *IncludeConfig
+		terragruntOptions *options.TerragruntOptions
+		expectedVarFiles  []string
+	}{
+		{
+			nil,
+			terragruntOptionsForTest(t, fixtureDir+"/"+DefaultTerragruntConfigPath),
+			[]string{fixtureDir + "/account.tfvars", fixtureDir + "/common.tfvars"},
+		},
+		{
+			nil,
+			terragruntOptionsForTest(t, fixtureDir+"/us-east-1/app/"+DefaultTerragruntConfigPath),
+			[]string{fixtureDir + "/us-east-1/app/app.tfvars"},
+		},
+		{
+			&IncludeConfig{Path: "../../" + DefaultTerragruntConfigPath},
+			terragruntOptionsForTest(t, fixtureDir+"/us-east-1/app/"+DefaultTerragruntConfigPath),
+			[]string{
+				fixtureDir + "/account.tfvars",
+				fixtureDir + "/common.tfvars",
+				fixtureDir + "/us-east-1/region.tfvars",
+				fixtureDir + "/us-east-1/app/app.tfvars",
+			},
+		},
+		{
+			&IncludeConfig{Path: "${find_in_parent_folders()}"},
+			terragruntOptionsForTest(t, fixtureDir+"/us-east-1/app/"+DefaultTerragruntConfigPath),
+			[]string{
+				fixtureDir + "/account.tfvars",
+				fixtureDir + "/common.tfvars",
+				fixtureDir + "/us-east-1/region.tfvars",
+				fixtureDir + "/us-east-1/app/app.tfvars",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		actualVarFiles, actualErr := getVarFileHierarchy(testCase.include, testCase.terragruntOptions)
+		assert.Nil(t, actualErr, "For include %v and options %v, unexpected error: %v", testCase.include, testCase.terragruntOptions, actualErr)
+		assert.Equal(t, testCase.expectedVarFiles, actualVarFiles, "For include %v and options %v", testCase.include, testCase.terragruntOptions)
+	}
+}
+
+func TestGetVarFileHierarchyRootNotAParent(t *testing.T) {
+	t.Parallel()
+
+	include := &IncludeConfig{Path: "../other-child/" + DefaultTerragruntConfigPath}
+	terragruntOptions := terragruntOptionsForTest(t, helpers.RootFolder+"child/"+DefaultTerragruntConfigPath)
+
+	_, actualErr := getVarFileHierarchy(include, terragruntOptions)
+	assert.NotNil(t, actualErr)
+	_, isExpectedErr := errors.Unwrap(actualErr).(VarFileHierarchyRootNotAParent)
+	assert.True(t, isExpectedErr, "Unexpected error type: %v", actualErr)
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// The name of the block that holds the Terragrunt config in a terraform.tfvars file
+const terragruntBlockName = "terragrunt"
+
+// Check that every setting in the given config string, read from the given config file, is one Terragrunt knows
+// about. The HCL decoder silently ignores unknown settings, so without this check a typo such as extra_argument instead
+// of extra_arguments just means the setting has no effect. This runs on the raw config, before any interpolations are
+// processed, so the line and column numbers in the errors match the file.
+func ValidateConfigSchema(configString string, configPath string) error {
+	file, err := hcl.Parse(configString)
+	if err != nil {
+		return errors.WithStackTrace(ErrorParsingTerragruntConfig{ConfigPath: configPath, Underlying: err})
+	}
+
+	rootList, isObjectList := file.Node.(*ast.ObjectList)
+	if !isObjectList {
+		return nil
+	}
+
+	configFileType := reflect.TypeOf(terragruntConfigFile{})
+	unknownKeys := []UnknownConfigKey{}
+
+	if isOldTerragruntConfig(configPath) {
+		unknownKeys = validateObjectList(rootList, configFileType, terragruntBlockName, unknownKeys)
+	} else {
+		// The other settings in a terraform.tfvars file are Terraform variables, so only check the terragrunt block
+		for _, item := range rootList.Filter(terragruntBlockName).Items {
+			unknownKeys = validateValue(item.Val, configFileType, terragruntBlockName, unknownKeys)
+		}
+	}
+
+	if len(unknownKeys) > 0 {
+		return errors.WithStackTrace(InvalidConfigKeys{ConfigPath: configPath, UnknownKeys: unknownKeys})
+	}
+	return nil
+}
+
+// Check the keys of the given object list against the hcl tags of the fields of the given struct type, recursing into
+// the blocks that decode into structs themselves
+func validateObjectList(list *ast.ObjectList, structType reflect.Type, blockName string, unknownKeys []UnknownConfigKey) []UnknownConfigKey {
+	allowedKeys := getHclFieldTypes(structType)
+
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+
+		key := item.Keys[0]
+		keyName := strings.Trim(key.Token.Text, `"`)
+
+		fieldType, isAllowed := allowedKeys[keyName]
+		if !isAllowed {
+			unknownKeys = append(unknownKeys, UnknownConfigKey{
+				Key:        keyName,
+				Block:      blockName,
+				Line:       key.Pos().Line,
+				Column:     key.Pos().Column,
+				Suggestion: util.ClosestMatch(keyName, sortedKeys(allowedKeys)),
+			})
+			continue
+		}
+
+		if nestedType, isStruct := structTypeOf(fieldType); isStruct {
+			unknownKeys = validateValue(item.Val, nestedType, blockName+"."+keyName, unknownKeys)
+		}
+	}
+
+	return unknownKeys
+}
+
+// Check the given value, which should be a block or a list of blocks, against the given struct type. Values of any other
+// type are left for the HCL decoder to complain about.
+func validateValue(value ast.Node, structType reflect.Type, blockName string, unknownKeys []UnknownConfigKey) []UnknownConfigKey {
+	switch value := value.(type) {
+	case *ast.ObjectType:
+		return validateObjectList(value.List, structType, blockName, unknownKeys)
+	case *ast.ListType:
+		for _, element := range value.List {
+			unknownKeys = validateValue(element, structType, blockName, unknownKeys)
+		}
+	}
+	return unknownKeys
+}
+
+// Return a map from the name in the hcl tag of each field of the given struct type to the type of that field. Fields
+// that hold the key of a block (",key") aren't settings, so they are left out.
+func getHclFieldTypes(structType reflect.Type) map[string]reflect.Type {
+	fieldTypes := map[string]reflect.Type{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := strings.Split(field.Tag.Get("hcl"), ",")[0]
+		if name != "" {
+			fieldTypes[name] = field.Type
+		}
+	}
+	return fieldTypes
+}
+
+// If the given type is a struct, or a pointer to or slice of structs, return that struct type. Maps, such as the config
+// of remote_state, accept any keys, so they don't count.
+func structTypeOf(fieldType reflect.Type) (reflect.Type, bool) {
+	for fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType, fieldType.Kind() == reflect.Struct
+}
+
+func sortedKeys(fieldTypes map[string]reflect.Type) []string {
+	keys := []string{}
+	for key := range fieldTypes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// UnknownConfigKey is a setting in a Terragrunt config that Terragrunt doesn't know about
+type UnknownConfigKey struct {
+	Key        string
+	Block      string
+	Line       int
+	Column     int
+	Suggestion string
+}
+
+func (key UnknownConfigKey) String() string {
+	message := fmt.Sprintf("%d:%d: unknown setting '%s' in the %s block", key.Line, key.Column, key.Key, key.Block)
+	if key.Suggestion != "" {
+		message = fmt.Sprintf("%s. Did you mean '%s'?", message, key.Suggestion)
+	}
+	return message
+}
+
+// Custom error types
+
+type InvalidConfigKeys struct {
+	ConfigPath  string
+	UnknownKeys []UnknownConfigKey
+}
+
+func (err InvalidConfigKeys) Error() string {
+	lines := []string{fmt.Sprintf("Found %d unknown setting(s) in Terragrunt config %s:", len(err.UnknownKeys), err.ConfigPath)}
+	for _, key := range err.UnknownKeys {
+		lines = append(lines, fmt.Sprintf("  %s:%s", err.ConfigPath, key))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (err InvalidConfigKeys) Is(target error) bool {
+	return target == errors.ErrConfigInvalid
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfigSchemaValidConfig(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  terraform {
+    source = "git::git@github.com:foo/modules.git//app?ref=v0.0.1"
+
+    extra_arguments "region" {
+      commands = ["plan", "apply"]
+      required_var_files = ["${get_parent_tfvars_dir()}/region.tfvars"]
+
+      condition {
+        env_vars = ["CI"]
+      }
+    }
+
+    env_vars {
+      AWS_PROFILE = "prod"
+    }
+  }
+
+  remote_state {
+    backend = "s3"
+    config {
+      bucket = "my-bucket"
+      any_backend_setting = "is allowed"
+    }
+  }
+
+  include {
+    path = "${find_in_parent_folders()}"
+  }
+}
+
+# Terraform variables are not checked
+instance_type = "t2.micro"
+`
+
+	err := ValidateConfigSchema(config, DefaultTerragruntConfigPath)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+}
+
+func TestValidateConfigSchemaUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  terraform {
+    source = "../modules/app"
+
+    extra_argument "region" {
+      commands = ["plan"]
+    }
+
+    extra_arguments "vars" {
+      comands = ["apply"]
+    }
+  }
+
+  remote-state {
+    backend = "s3"
+  }
+
+  foo = "bar"
+}
+`
+
+	err := ValidateConfigSchema(config, DefaultTerragruntConfigPath)
+	invalidConfigKeys, isInvalidConfigKeysErr := errors.Unwrap(err).(InvalidConfigKeys)
+	if assert.True(t, isInvalidConfigKeysErr, "Expected an InvalidConfigKeys error but got: %v", err) {
+		assert.Equal(t, []UnknownConfigKey{
+			{Key: "extra_argument", Block: "terragrunt.terraform", Line: 6, Column: 5, Suggestion: "extra_arguments"},
+			{Key: "comands", Block: "terragrunt.terraform.extra_arguments", Line: 11, Column: 7, Suggestion: "commands"},
+			{Key: "remote-state", Block: "terragrunt", Line: 15, Column: 3, Suggestion: "remote_state"},
+			{Key: "foo", Block: "terragrunt", Line: 19, Column: 3, Suggestion: ""},
+		}, invalidConfigKeys.UnknownKeys)
+	}
+}
+
+func TestValidateConfigSchemaOldConfigFormat(t *testing.T) {
+	t.Parallel()
+
+	config := `
+dependencies {
+  path = ["../vpc"]
+}
+`
+
+	err := ValidateConfigSchema(config, OldTerragruntConfigPath)
+	invalidConfigKeys, isInvalidConfigKeysErr := errors.Unwrap(err).(InvalidConfigKeys)
+	if assert.True(t, isInvalidConfigKeysErr, "Expected an InvalidConfigKeys error but got: %v", err) {
+		assert.Equal(t, []UnknownConfigKey{
+			{Key: "path", Block: "terragrunt.dependencies", Line: 3, Column: 3, Suggestion: "paths"},
+		}, invalidConfigKeys.UnknownKeys)
+	}
+}
+
+func TestValidateConfigSchemaSyntaxError(t *testing.T) {
+	t.Parallel()
+
+	err := ValidateConfigSchema("terragrunt = {", DefaultTerragruntConfigPath)
+	_, isParsingErr := errors.Unwrap(err).(ErrorParsingTerragruntConfig)
+	assert.True(t, isParsingErr, "Expected an ErrorParsingTerragruntConfig error but got: %v", err)
+}
+
+func TestParseConfigFileStrictValidate(t *testing.T) {
+	t.Parallel()
+
+	configPath := "../test/fixture-strict-validate/" + DefaultTerragruntConfigPath
+	terragruntOptions := mockOptionsForTestWithConfigPath(t, configPath)
+	terragruntOptions.StrictValidate = true
+
+	_, err := ParseConfigFile(configPath, terragruntOptions, nil)
+	_, isInvalidConfigKeysErr := errors.Unwrap(err).(InvalidConfigKeys)
+	assert.True(t, isInvalidConfigKeysErr, "Expected an InvalidConfigKeys error but got: %v", err)
+
+	terragruntOptions.StrictValidate = false
+	_, err = ParseConfigFile(configPath, terragruntOptions, nil)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+}
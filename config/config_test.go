@@ -255,6 +255,10 @@ terragrunt = {
 			assert.Equal(t, "child/sub-child/sub-sub-child/terraform.tfstate", terragruntConfig.RemoteState.Config["key"])
 			assert.Equal(t, "us-east-1", terragruntConfig.RemoteState.Config["region"])
 		}
+
+		expectedIncludePath, err := util.CanonicalPath("../test/fixture-parent-folders/terragrunt-in-root/"+DefaultTerragruntConfigPath, ".")
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, expectedIncludePath, terragruntConfig.IncludedConfigPath)
 	}
 
 }
@@ -449,6 +453,8 @@ func TestParseTerragruntConfigEmptyConfigOldConfig(t *testing.T) {
 func TestMergeConfigIntoIncludedConfig(t *testing.T) {
 	t.Parallel()
 
+	enabled, disabled := true, false
+
 	testCases := []struct {
 		config         *TerragruntConfig
 		includedConfig *TerragruntConfig
@@ -504,6 +510,126 @@ func TestMergeConfigIntoIncludedConfig(t *testing.T) {
 			&TerragruntConfig{Terraform: &TerraformConfig{ExtraArgs: []TerraformExtraArguments{TerraformExtraArguments{Name: "overrideArgs", Arguments: []string{"-parent"}}}}},
 			&TerragruntConfig{Terraform: &TerraformConfig{ExtraArgs: []TerraformExtraArguments{TerraformExtraArguments{Name: "overrideArgs", Arguments: []string{"-child"}}}}},
 		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{ExtraFiles: []TerraformExtraFiles{{Name: "versions", Paths: []string{"child.tf"}}, {Name: "lock", Paths: []string{"lock"}}}}},
+			&TerragruntConfig{Terraform: &TerraformConfig{ExtraFiles: []TerraformExtraFiles{{Name: "versions", Paths: []string{"parent.tf"}}, {Name: "variables", Paths: []string{"variables.tf"}}}}},
+			&TerragruntConfig{Terraform: &TerraformConfig{ExtraFiles: []TerraformExtraFiles{{Name: "versions", Paths: []string{"child.tf"}}, {Name: "variables", Paths: []string{"variables.tf"}}, {Name: "lock", Paths: []string{"lock"}}}}},
+		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{EnvVars: map[string]string{"FOO": "child"}}},
+			&TerragruntConfig{Terraform: &TerraformConfig{}},
+			&TerragruntConfig{Terraform: &TerraformConfig{EnvVars: map[string]string{"FOO": "child"}}},
+		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{}},
+			&TerragruntConfig{Terraform: &TerraformConfig{EnvVars: map[string]string{"FOO": "parent"}}},
+			&TerragruntConfig{Terraform: &TerraformConfig{EnvVars: map[string]string{"FOO": "parent"}}},
+		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{EnvVars: map[string]string{"FOO": "child", "BAZ": "child"}}},
+			&TerragruntConfig{Terraform: &TerraformConfig{EnvVars: map[string]string{"FOO": "parent", "BAR": "parent"}}},
+			&TerragruntConfig{Terraform: &TerraformConfig{EnvVars: map[string]string{"FOO": "child", "BAR": "parent", "BAZ": "child"}}},
+		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{SourceHash: "child"}},
+			&TerragruntConfig{Terraform: &TerraformConfig{Source: "foo", SourceHash: "parent"}},
+			&TerragruntConfig{Terraform: &TerraformConfig{Source: "foo", SourceHash: "child"}},
+		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{}},
+			&TerragruntConfig{Terraform: &TerraformConfig{Source: "foo", SourceHash: "parent"}},
+			&TerragruntConfig{Terraform: &TerraformConfig{Source: "foo", SourceHash: "parent"}},
+		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{Source: "bar"}},
+			&TerragruntConfig{Terraform: &TerraformConfig{Source: "foo", SourceHash: "parent"}},
+			&TerragruntConfig{Terraform: &TerraformConfig{Source: "bar"}},
+		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{}},
+			&TerragruntConfig{Terraform: &TerraformConfig{BinaryWrapper: []string{"aws-vault", "exec", "stage", "--"}}},
+			&TerragruntConfig{Terraform: &TerraformConfig{BinaryWrapper: []string{"aws-vault", "exec", "stage", "--"}}},
+		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{BinaryWrapper: []string{"aws-vault", "exec", "prod", "--"}}},
+			&TerragruntConfig{Terraform: &TerraformConfig{BinaryWrapper: []string{"aws-vault", "exec", "stage", "--"}}},
+			&TerragruntConfig{Terraform: &TerraformConfig{BinaryWrapper: []string{"aws-vault", "exec", "prod", "--"}}},
+		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{}},
+			&TerragruntConfig{Terraform: &TerraformConfig{DockerImage: "hashicorp/terraform:0.11.7"}},
+			&TerragruntConfig{Terraform: &TerraformConfig{DockerImage: "hashicorp/terraform:0.11.7"}},
+		},
+		{
+			&TerragruntConfig{Terraform: &TerraformConfig{DockerImage: "acme/terraform:1.2"}},
+			&TerragruntConfig{Terraform: &TerraformConfig{DockerImage: "hashicorp/terraform:0.11.7"}},
+			&TerragruntConfig{Terraform: &TerraformConfig{DockerImage: "acme/terraform:1.2"}},
+		},
+		{
+			&TerragruntConfig{},
+			&TerragruntConfig{IamRoles: []string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::222222222222:role/stage"}},
+			&TerragruntConfig{IamRoles: []string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::222222222222:role/stage"}},
+		},
+		{
+			&TerragruntConfig{IamRoles: []string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::333333333333:role/prod"}},
+			&TerragruntConfig{IamRoles: []string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::222222222222:role/stage"}},
+			&TerragruntConfig{IamRoles: []string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::333333333333:role/prod"}},
+		},
+		{
+			&TerragruntConfig{},
+			&TerragruntConfig{IamWebIdentityToken: "parent-token"},
+			&TerragruntConfig{IamWebIdentityToken: "parent-token"},
+		},
+		{
+			&TerragruntConfig{},
+			&TerragruntConfig{AllowedAccountIds: []string{"111111111111"}, ForbiddenAccountIds: []string{"222222222222"}},
+			&TerragruntConfig{AllowedAccountIds: []string{"111111111111"}, ForbiddenAccountIds: []string{"222222222222"}},
+		},
+		{
+			&TerragruntConfig{AllowedAccountIds: []string{"333333333333"}},
+			&TerragruntConfig{AllowedAccountIds: []string{"111111111111"}, ForbiddenAccountIds: []string{"222222222222"}},
+			&TerragruntConfig{AllowedAccountIds: []string{"333333333333"}, ForbiddenAccountIds: []string{"222222222222"}},
+		},
+		{
+			&TerragruntConfig{},
+			&TerragruntConfig{ExpectedRegion: "us-east-1"},
+			&TerragruntConfig{ExpectedRegion: "us-east-1"},
+		},
+		{
+			&TerragruntConfig{ExpectedRegion: "eu-west-1"},
+			&TerragruntConfig{ExpectedRegion: "us-east-1"},
+			&TerragruntConfig{ExpectedRegion: "eu-west-1"},
+		},
+		{
+			&TerragruntConfig{},
+			&TerragruntConfig{Enabled: &disabled},
+			&TerragruntConfig{Enabled: &disabled},
+		},
+		{
+			&TerragruntConfig{Enabled: &enabled},
+			&TerragruntConfig{Enabled: &disabled},
+			&TerragruntConfig{Enabled: &enabled},
+		},
+		{
+			&TerragruntConfig{IamWebIdentityToken: "child-token"},
+			&TerragruntConfig{IamWebIdentityToken: "parent-token"},
+			&TerragruntConfig{IamWebIdentityToken: "child-token"},
+		},
+		{
+			&TerragruntConfig{AwsProvider: &AwsProviderConfig{Region: "eu-west-1", DefaultTags: map[string]string{"Team": "payments", "Env": "prod"}}},
+			&TerragruntConfig{AwsProvider: &AwsProviderConfig{Region: "us-east-1", AssumeRole: &AwsProviderAssumeRole{RoleArn: "arn:aws:iam::111111111111:role/terraform"}, DefaultTags: map[string]string{"Env": "stage", "Owner": "platform"}}},
+			&TerragruntConfig{AwsProvider: &AwsProviderConfig{Region: "eu-west-1", AssumeRole: &AwsProviderAssumeRole{RoleArn: "arn:aws:iam::111111111111:role/terraform"}, DefaultTags: map[string]string{"Team": "payments", "Env": "prod", "Owner": "platform"}}},
+		},
+		{
+			&TerragruntConfig{AwsProvider: &AwsProviderConfig{DefaultTags: map[string]string{"Team": "payments"}}},
+			&TerragruntConfig{},
+			&TerragruntConfig{AwsProvider: &AwsProviderConfig{DefaultTags: map[string]string{"Team": "payments"}}},
+		},
+		{
+			&TerragruntConfig{DefaultTags: map[string]string{"Env": "prod"}},
+			&TerragruntConfig{DefaultTags: map[string]string{"Env": "stage", "Team": "payments"}},
+			&TerragruntConfig{DefaultTags: map[string]string{"Env": "prod", "Team": "payments"}},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -561,6 +687,94 @@ terragrunt = {
 	}
 }
 
+func TestParseTerragruntConfigTerraformWithEnvVars(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  terraform {
+    env_vars = {
+      AWS_PROFILE = "prod"
+      TF_LOG      = "${get_env("TF_LOG_LEVEL", "INFO")}"
+    }
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.Terraform) {
+		assert.Equal(t, map[string]string{"AWS_PROFILE": "prod", "TF_LOG": "INFO"}, terragruntConfig.Terraform.EnvVars)
+	}
+}
+
+func TestParseTerragruntConfigTerraformWithSourceHash(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  terraform {
+    source      = "git::git@github.com:foo/modules.git//app?ref=v0.0.3"
+    source_hash = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.Terraform) {
+		assert.Equal(t, "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", terragruntConfig.Terraform.SourceHash)
+	}
+}
+
+func TestParseTerragruntConfigTerraformWithBinaryWrapper(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  terraform {
+    terraform_binary_wrapper = ["aws-vault", "exec", "prod", "--"]
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.Terraform) {
+		assert.Equal(t, []string{"aws-vault", "exec", "prod", "--"}, terragruntConfig.Terraform.BinaryWrapper)
+	}
+}
+
+func TestParseTerragruntConfigTerraformWithDockerImage(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  terraform {
+    docker_image = "hashicorp/terraform:0.11.7"
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.Terraform) {
+		assert.Equal(t, "hashicorp/terraform:0.11.7", terragruntConfig.Terraform.DockerImage)
+	}
+}
+
 func TestParseTerragruntConfigTerraformWithExtraArguments(t *testing.T) {
 	t.Parallel()
 
@@ -600,6 +814,42 @@ terragrunt = {
 	}
 }
 
+func TestParseTerragruntConfigTerraformWithExtraArgumentsOrderAndCondition(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  terraform {
+    extra_arguments "ci_only" {
+      arguments = ["-lock-timeout=20m"]
+      commands = ["apply", "plan"]
+      order = 10
+      arguments_file = "ci.args"
+
+      condition {
+        env_vars = ["CI"]
+        args = ["-target"]
+      }
+    }
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.Terraform) {
+		assert.Equal(t, 10, terragruntConfig.Terraform.ExtraArgs[0].Order)
+		assert.Equal(t, "ci.args", terragruntConfig.Terraform.ExtraArgs[0].ArgumentsFile)
+		if assert.NotNil(t, terragruntConfig.Terraform.ExtraArgs[0].Condition) {
+			assert.Equal(t, []string{"CI"}, terragruntConfig.Terraform.ExtraArgs[0].Condition.EnvVars)
+			assert.Equal(t, []string{"-target"}, terragruntConfig.Terraform.ExtraArgs[0].Condition.Args)
+		}
+	}
+}
+
 func TestParseTerragruntConfigTerraformWithMultipleExtraArguments(t *testing.T) {
 	t.Parallel()
 
@@ -667,6 +917,437 @@ terragrunt = {
 	}
 }
 
+func TestParseTerragruntConfigPolicyDefaults(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  policy {
+    paths = ["../policies"]
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.Policy) {
+		assert.Equal(t, []string{"../policies"}, terragruntConfig.Policy.Paths)
+		assert.Equal(t, DefaultPolicyQuery, terragruntConfig.Policy.Query)
+		assert.Equal(t, DefaultOpaPath, terragruntConfig.Policy.OpaPath)
+	}
+}
+
+func TestParseTerragruntConfigPolicyFullConfig(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  policy {
+    paths = ["../policies", "/shared/policies"]
+    query = "data.security.violations"
+    opa_path = "/usr/local/bin/opa"
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.Policy) {
+		assert.Equal(t, []string{"../policies", "/shared/policies"}, terragruntConfig.Policy.Paths)
+		assert.Equal(t, "data.security.violations", terragruntConfig.Policy.Query)
+		assert.Equal(t, "/usr/local/bin/opa", terragruntConfig.Policy.OpaPath)
+	}
+}
+
+func TestParseTerragruntConfigPolicyMissingPaths(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  policy {
+    query = "data.terraform.deny"
+  }
+}
+`
+
+	_, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	assert.True(t, errors.IsError(err, PolicyPathsMissing("test-time-mock")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigTerraformWithExtraFiles(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  terraform {
+    extra_files "shared" {
+      paths = ["../versions.tf", "../variables.tf"]
+    }
+
+    extra_files "lock" {
+      paths = ["../.terraform.lock.hcl"]
+      on_conflict = "skip"
+    }
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.Terraform) {
+		expected := []TerraformExtraFiles{
+			{Name: "shared", Paths: []string{"../versions.tf", "../variables.tf"}, OnConflict: ExtraFilesConflictError},
+			{Name: "lock", Paths: []string{"../.terraform.lock.hcl"}, OnConflict: ExtraFilesConflictSkip},
+		}
+		assert.Equal(t, expected, terragruntConfig.Terraform.ExtraFiles)
+	}
+}
+
+func TestParseTerragruntConfigTerraformWithInvalidExtraFiles(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		config      string
+		expectedErr error
+	}{
+		{
+			`terragrunt = { terraform { extra_files "shared" { paths = [] } } }`,
+			ExtraFilesPathsMissing{ConfigPath: "test-time-mock", Name: "shared"},
+		},
+		{
+			`
+terragrunt = {
+  terraform {
+    extra_files "shared" {
+      paths = ["versions.tf"]
+      on_conflict = "merge"
+    }
+  }
+}
+`,
+			InvalidExtraFilesConflictPolicy{ConfigPath: "test-time-mock", Name: "shared", OnConflict: "merge"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		_, err := parseConfigString(testCase.config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+		assert.True(t, errors.IsError(err, testCase.expectedErr), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+	}
+}
+
+func TestParseTerragruntConfigCostEstimation(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  cost_estimation {
+    command = "infracost"
+    arguments = ["breakdown", "--format", "json"]
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.CostEstimation) {
+		assert.Equal(t, "infracost", terragruntConfig.CostEstimation.Command)
+		assert.Equal(t, []string{"breakdown", "--format", "json"}, terragruntConfig.CostEstimation.Arguments)
+	}
+}
+
+func TestParseTerragruntConfigCostEstimationMissingCommand(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  cost_estimation {
+    arguments = ["breakdown"]
+  }
+}
+`
+
+	_, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	assert.True(t, errors.IsError(err, CostEstimationCommandMissing("test-time-mock")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigStateBackup(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  state_backup {
+    path = "../state-backups"
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.StateBackup) {
+		assert.Equal(t, "../state-backups", terragruntConfig.StateBackup.Path)
+	}
+}
+
+func TestParseTerragruntConfigStateBackupMissingPath(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  state_backup {
+  }
+}
+`
+
+	_, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	assert.True(t, errors.IsError(err, StateBackupPathMissing("test-time-mock")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigRemoteExec(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  remote_exec {
+    ssh_host     = "ubuntu@runner.internal"
+    ssh_args     = ["-i", "~/.ssh/runner"]
+    sync_folders = true
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.RemoteExec) {
+		assert.Equal(t, "ubuntu@runner.internal", terragruntConfig.RemoteExec.SshHost)
+		assert.Equal(t, []string{"-i", "~/.ssh/runner"}, terragruntConfig.RemoteExec.SshArgs)
+		assert.True(t, terragruntConfig.RemoteExec.SyncFolders)
+	}
+}
+
+func TestParseTerragruntConfigRemoteExecMissingSshHost(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  remote_exec {
+    sync_folders = true
+  }
+}
+`
+
+	_, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	assert.True(t, errors.IsError(err, RemoteExecSshHostMissing("test-time-mock")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigRunLock(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  run_lock {
+    use_lock_table = true
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NotNil(t, terragruntConfig.RunLock) {
+		assert.True(t, terragruntConfig.RunLock.UseLockTable)
+	}
+}
+
+func TestParseTerragruntConfigIamRoles(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  iam_roles = [
+    "arn:aws:iam::111111111111:role/bastion",
+    "arn:aws:iam::222222222222:role/stage",
+  ]
+  iam_web_identity_token = "/var/run/secrets/token"
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::222222222222:role/stage"}, terragruntConfig.IamRoles)
+	assert.Equal(t, "/var/run/secrets/token", terragruntConfig.IamWebIdentityToken)
+}
+
+func TestParseTerragruntConfigAccountIdsAndRegion(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  allowed_account_ids   = ["111111111111", "222222222222"]
+  forbidden_account_ids = ["333333333333"]
+  expected_region       = "us-east-1"
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"111111111111", "222222222222"}, terragruntConfig.AllowedAccountIds)
+	assert.Equal(t, []string{"333333333333"}, terragruntConfig.ForbiddenAccountIds)
+	assert.Equal(t, "us-east-1", terragruntConfig.ExpectedRegion)
+}
+
+func TestParseTerragruntConfigTerraformVersionConstraint(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  terraform_version_constraint = ">= 0.12.0, < 0.14.0"
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ">= 0.12.0, < 0.14.0", terragruntConfig.TerraformVersionConstraint)
+
+	config = `
+terragrunt = {
+  terraform_version_constraint = "at least 0.12"
+}
+`
+
+	_, err = parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	_, isInvalidConstraintErr := errors.Unwrap(err).(InvalidTerraformVersionConstraint)
+	assert.True(t, isInvalidConstraintErr, "Expected an InvalidTerraformVersionConstraint error but got: %v", err)
+}
+
+func TestParseTerragruntConfigEnabled(t *testing.T) {
+	t.Parallel()
+
+	terragruntConfig, err := parseConfigString("terragrunt = {}", mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, terragruntConfig.Enabled)
+	assert.True(t, terragruntConfig.IsEnabled())
+
+	config := `
+terragrunt = {
+  enabled = "${get_feature("new_vpc", "false") == "true"}"
+}
+`
+
+	opts := mockOptionsForTest(t)
+	terragruntConfig, err = parseConfigString(config, opts, nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, terragruntConfig.IsEnabled())
+
+	opts.Features = map[string]string{"new_vpc": "true"}
+	terragruntConfig, err = parseConfigString(config, opts, nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, terragruntConfig.IsEnabled())
+}
+
+func TestParseTerragruntConfigAwsProvider(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  aws_provider {
+    region = "us-east-1"
+
+    assume_role {
+      role_arn     = "arn:aws:iam::111111111111:role/terraform"
+      session_name = "terragrunt"
+    }
+
+    default_tags = {
+      Team = "payments"
+    }
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &AwsProviderConfig{
+		Region:      "us-east-1",
+		AssumeRole:  &AwsProviderAssumeRole{RoleArn: "arn:aws:iam::111111111111:role/terraform", SessionName: "terragrunt"},
+		DefaultTags: map[string]string{"Team": "payments"},
+	}
+	assert.Equal(t, expected, terragruntConfig.AwsProvider)
+}
+
+func TestParseTerragruntConfigDefaultTags(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  default_tags = {
+    Team       = "payments"
+    CostCenter = "42"
+  }
+}
+`
+
+	terragruntConfig, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]string{"Team": "payments", "CostCenter": "42"}, terragruntConfig.DefaultTags)
+}
+
+func TestParseTerragruntConfigAwsProviderMissingRoleArn(t *testing.T) {
+	t.Parallel()
+
+	config := `
+terragrunt = {
+  aws_provider {
+    assume_role {
+      session_name = "terragrunt"
+    }
+  }
+}
+`
+
+	_, err := parseConfigString(config, mockOptionsForTest(t), nil, DefaultTerragruntConfigPath)
+	assert.True(t, errors.IsError(err, AwsProviderRoleArnMissing("test-time-mock")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
 func TestFindConfigFilesInPathNone(t *testing.T) {
 	t.Parallel()
 
@@ -711,6 +1392,56 @@ func TestFindConfigFilesInPathMultipleConfigs(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestFindConfigFilesInPathSkipsTerraformFolders(t *testing.T) {
+	t.Parallel()
+
+	// app/.terraform/modules/vpc has a copy of a module with a Terragrunt config, which is not a module of its own
+	expected := []string{"../test/fixture-config-files/skipped-folders/app/terraform.tfvars"}
+	actual, err := FindConfigFilesInPath("../test/fixture-config-files/skipped-folders")
+
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestFindConfigFilesInPathIgnoresSymlinks(t *testing.T) {
+	t.Parallel()
+
+	expected := []string{}
+	actual, err := FindConfigFilesInPath("../test/fixture-config-files/symlinks/live")
+
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestFindConfigFilesInPathFollowingSymlinks(t *testing.T) {
+	t.Parallel()
+
+	// live/stage/loop points to live, so following it would never end
+	expected := []string{
+		"../test/fixture-config-files/symlinks/live/prod/app/terraform.tfvars",
+		"../test/fixture-config-files/symlinks/live/stage/app/terraform.tfvars",
+	}
+	actual, err := FindConfigFilesInPathFollowingSymlinks("../test/fixture-config-files/symlinks/live")
+
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestFindConfigFilesInWorkingDirWithIgnoreFile(t *testing.T) {
+	t.Parallel()
+
+	opts := mockOptionsForTestWithConfigPath(t, "../test/fixture-config-files/ignore/"+DefaultTerragruntConfigPath)
+
+	expected := []string{
+		"../test/fixture-config-files/ignore/app/terraform.tfvars",
+		"../test/fixture-config-files/ignore/stage/app/terraform.tfvars",
+	}
+	actual, err := FindConfigFilesInWorkingDir(opts)
+
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, expected, actual)
+}
+
 func mockOptionsForTestWithConfigPath(t *testing.T, configPath string) *options.TerragruntOptions {
 	opts, err := options.NewTerragruntOptionsForTest(configPath)
 	if err != nil {
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	yaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// Return the built-in Terraform functions that can be used in an interpolation, besides the Terragrunt helper functions,
+// by name. They behave as they do in Terraform 0.12+. A relative path passed to file is relative to the folder of the
+// current Terragrunt configuration file.
+func terraformFunctions(terragruntOptions *options.TerragruntOptions) map[string]function.Function {
+	return map[string]function.Function{
+		"concat":     stdlib.ConcatFunc,
+		"file":       makeFileFunc(terragruntOptions),
+		"format":     stdlib.FormatFunc,
+		"formatlist": stdlib.FormatListFunc,
+		"jsondecode": stdlib.JSONDecodeFunc,
+		"jsonencode": stdlib.JSONEncodeFunc,
+		"lookup":     lookupFunc,
+		"lower":      stdlib.LowerFunc,
+		"merge":      mergeFunc,
+		"upper":      stdlib.UpperFunc,
+		"yamldecode": yaml.YAMLDecodeFunc,
+		"yamlencode": yaml.YAMLEncodeFunc,
+	}
+}
+
+// Return a function that reads the file at the given path and returns its contents, as the file function of Terraform
+// does. The file is recorded as a dependency of the config, so a cached config is parsed again when it changes.
+func makeFileFunc(terragruntOptions *options.TerragruntOptions) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "path", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			path := args[0].AsString()
+			if !filepath.IsAbs(path) {
+				path = util.JoinPath(filepath.Dir(terragruntOptions.TerragruntConfigPath), path)
+			}
+
+			recordFileDependency(terragruntOptions, path)
+			contents, err := util.ReadFileAsString(path)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			return cty.StringVal(contents), nil
+		},
+	})
+}
+
+// Merge the given maps or objects into one object, as the merge function of Terraform does. Where several of them have
+// the same key, the value from the last one wins.
+var mergeFunc = function.New(&function.Spec{
+	VarParam: &function.Parameter{
+		Name:             "maps",
+		Type:             cty.DynamicPseudoType,
+		AllowDynamicType: true,
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		attributes := map[string]cty.Value{}
+		for index, arg := range args {
+			if !arg.Type().IsMapType() && !arg.Type().IsObjectType() {
+				return cty.NilVal, function.NewArgErrorf(index, "must be a map or an object, but got %s", arg.Type().FriendlyName())
+			}
+			for iterator := arg.ElementIterator(); iterator.Next(); {
+				key, value := iterator.Element()
+				attributes[key.AsString()] = value
+			}
+		}
+		return cty.ObjectVal(attributes), nil
+	},
+})
+
+// Return the value of the given key in the given map or object, or the default, if one is given and there is no such
+// key, as the lookup function of Terraform does
+var lookupFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "map", Type: cty.DynamicPseudoType},
+		{Name: "key", Type: cty.String},
+	},
+	VarParam: &function.Parameter{
+		Name: "default",
+		Type: cty.DynamicPseudoType,
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if len(args) > 3 {
+			return cty.NilVal, fmt.Errorf("lookup takes at most three arguments, but got %d", len(args))
+		}
+
+		object, key := args[0], args[1].AsString()
+		switch {
+		case object.Type().IsObjectType():
+			if object.Type().HasAttribute(key) {
+				return object.GetAttr(key), nil
+			}
+		case object.Type().IsMapType():
+			if object.HasIndex(cty.StringVal(key)).True() {
+				return object.Index(cty.StringVal(key)), nil
+			}
+		default:
+			return cty.NilVal, function.NewArgErrorf(0, "must be a map or an object, but got %s", object.Type().FriendlyName())
+		}
+
+		if len(args) == 3 {
+			return args[2], nil
+		}
+		return cty.NilVal, function.NewArgErrorf(1, "there is no key %q, and no default was given", key)
+	},
+})
+
+// Wrap the given built-in Terraform function so that when it returns an error, the first one is kept in the given
+// error, as HCL only reports it as a diagnostic. Some functions, such as jsondecode, return errors from working out the
+// type of their result, so those are kept too.
+func keepFunctionError(functionName string, fn function.Function, functionErr *error) function.Function {
+	keepErr := func(err error) {
+		if err != nil && *functionErr == nil {
+			*functionErr = errors.WithStackTrace(TerraformFunctionError{Function: functionName, Cause: err.Error()})
+		}
+	}
+
+	return function.New(&function.Spec{
+		Params:   fn.Params(),
+		VarParam: fn.VarParam(),
+		Type: func(args []cty.Value) (cty.Type, error) {
+			returnType, err := fn.ReturnTypeForValues(args)
+			keepErr(err)
+			return returnType, err
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			value, err := fn.Call(args)
+			keepErr(err)
+			return value, err
+		},
+	})
+}
+
+// Custom error types
+
+type TerraformFunctionError struct {
+	Function string
+	Cause    string
+}
+
+func (err TerraformFunctionError) Error() string {
+	return fmt.Sprintf("Error calling the %s function: %s", err.Function, err.Cause)
+}
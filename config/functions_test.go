@@ -0,0 +1,64 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveTerragruntConfigStringTerraformFunctions(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "terraform-functions-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	configPath := filepath.Join(tmpDir, DefaultTerragruntConfigPath)
+	writeFileForTest(t, filepath.Join(tmpDir, "settings.yaml"), "region: us-east-1\nzones:\n  - a\n  - b\n")
+
+	testCases := []struct {
+		str         string
+		expectedOut string
+	}{
+		{`name = "${format("%s-%03d", "app", 7)}"`, `name = "app-007"`},
+		{`name = "${upper(get_env("ENV", "dev"))}"`, `name = "PROD"`},
+		{`values = ["${concat(["a"], ["b"])}"]`, `values = ["a", "b"]`},
+		{`value = "${lookup({a = "1"}, "a")}"`, `value = "1"`},
+		{`value = "${lookup({a = "1"}, "b", "default")}"`, `value = "default"`},
+		{`tags = "${merge({Team = "payments", Env = "dev"}, {Env = get_env("ENV", "dev")})}"`, `tags = {"Env" = "prod", "Team" = "payments"}`},
+		{`value = "${jsonencode({a = 1})}"`, `value = "{\"a\":1}"`},
+		{`values = ["${jsondecode("[\"a\", \"b\"]")}"]`, `values = ["a", "b"]`},
+		{`region = "${yamldecode(file("settings.yaml")).region}"`, `region = "us-east-1"`},
+		{`zones = ["${yamldecode(file("${get_tfvars_dir()}/settings.yaml")).zones}"]`, `zones = ["a", "b"]`},
+	}
+
+	for _, testCase := range testCases {
+		actualOut, actualErr := ResolveTerragruntConfigString(testCase.str, nil, terragruntOptionsForTestWithEnv(t, configPath, map[string]string{"ENV": "prod"}))
+		assert.Nil(t, actualErr, "For string '%s', unexpected error: %v", testCase.str, actualErr)
+		assert.Equal(t, testCase.expectedOut, actualOut, "For string '%s'", testCase.str)
+	}
+}
+
+func TestResolveTerragruntConfigStringTerraformFunctionErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []string{
+		`${file("does-not-exist.txt")}`,
+		`${lookup({a = "1"}, "b")}`,
+		`${merge({a = "1"}, "b")}`,
+		`${jsondecode("{")}`,
+	}
+
+	for _, testCase := range testCases {
+		_, actualErr := ResolveTerragruntConfigString(testCase, nil, terragruntOptionsForTest(t, DefaultTerragruntConfigPath))
+		if assert.Error(t, actualErr, "For string '%s'", testCase) {
+			assert.IsType(t, TerraformFunctionError{}, errors.Unwrap(actualErr), "For string '%s'", testCase)
+		}
+	}
+}
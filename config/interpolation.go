@@ -0,0 +1,411 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	hcl2 "github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// Given a string value from a Terragrunt configuration, parse the string, evaluate each ${...} in it as an HCL2
+// expression, which may call the Terragrunt helper functions (e.g. ${find_in_parent_folders()}) and use conditionals
+// and for expressions (e.g. ${get_env("ENV", "dev") == "prod" ? "us-east-1" : "us-west-2"}), and return the final
+// value. $${ is an escape for a literal ${.
+//
+// An interpolation that is the whole of a quoted string (i.e. "${...}") is replaced, quotes included, by its value in
+// HCL syntax, so it can return a number, boolean, or map as well as a string. A list is replaced by its elements
+// without the brackets, so the ["${get_terraform_commands_that_need_vars()}"] syntax keeps working. Any other
+// interpolation is replaced by its value as a string.
+func ResolveTerragruntConfigString(terragruntConfigString string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions) (string, error) {
+	var out bytes.Buffer
+
+	// The index just after the last interpolation, so a quote it replaced isn't mistaken for the start of another string
+	afterInterpolation := 0
+
+	for i := 0; i < len(terragruntConfigString); {
+		if strings.HasPrefix(terragruntConfigString[i:], "$${") {
+			out.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(terragruntConfigString[i:], "${") {
+			out.WriteByte(terragruntConfigString[i])
+			i++
+			continue
+		}
+
+		end := findInterpolationEnd(terragruntConfigString, i)
+		if end < 0 {
+			if malformed := findMalformedInterpolation(terragruntConfigString, i); malformed != "" {
+				return "", errors.WithStackTrace(InvalidInterpolationSyntax(malformed))
+			}
+			// Not an interpolation, such as "${" at the end of a string
+			out.WriteString("${")
+			i += 2
+			continue
+		}
+
+		interpolation := terragruntConfigString[i : end+1]
+		value, err := evaluateInterpolation(interpolation, include, terragruntOptions)
+		if err != nil {
+			return "", err
+		}
+
+		wholeQuotedString := i > afterInterpolation && terragruntConfigString[i-1] == '"' && end+1 < len(terragruntConfigString) && terragruntConfigString[end+1] == '"'
+		if wholeQuotedString {
+			rendered, err := renderInterpolationAsHcl(interpolation, value)
+			if err != nil {
+				return "", err
+			}
+			// Replace the quotes around the interpolation too
+			out.Truncate(out.Len() - 1)
+			out.WriteString(rendered)
+			i = end + 2
+		} else {
+			rendered, err := renderInterpolationAsString(interpolation, value)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(rendered)
+			i = end + 1
+		}
+		afterInterpolation = i
+	}
+
+	return out.String(), nil
+}
+
+// Resolve a single interpolation of the format ${...} in a Terragrunt configuration and return its value as a string,
+// bool, int64, float64, []string, []interface{}, or map[string]interface{}
+func resolveTerragruntInterpolation(str string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions) (interface{}, error) {
+	if !strings.HasPrefix(str, "${") || findInterpolationEnd(str, 0) != len(str)-1 {
+		return "", errors.WithStackTrace(InvalidInterpolationSyntax(str))
+	}
+
+	value, err := evaluateInterpolation(str, include, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+	return interpolationValueToGo(str, value)
+}
+
+// Return the index of the } that closes the interpolation starting at the given index of the given string, or -1 if
+// there is none. Braces in quoted strings in the expression don't count, but the interpolations in those strings do.
+func findInterpolationEnd(str string, start int) int {
+	// Each entry is the depth of the braces in an expression, or -1 for a quoted string in an expression
+	nesting := []int{0}
+
+	for i := start + 2; i < len(str); i++ {
+		top := len(nesting) - 1
+
+		if nesting[top] < 0 {
+			switch {
+			case str[i] == '\\':
+				i++
+			case str[i] == '"':
+				nesting = nesting[:top]
+			case strings.HasPrefix(str[i:], "$${"):
+				i += 2
+			case strings.HasPrefix(str[i:], "${"):
+				nesting = append(nesting, 0)
+				i++
+			}
+			continue
+		}
+
+		switch str[i] {
+		case '"':
+			nesting = append(nesting, -1)
+		case '{':
+			nesting[top]++
+		case '}':
+			if nesting[top] > 0 {
+				nesting[top]--
+				continue
+			}
+			nesting = nesting[:top]
+			if len(nesting) == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// Given the index of a ${ that doesn't start a complete interpolation, such as ${get_env("FOO}, return the text up to
+// the next }, which is most likely a malformed interpolation, or an empty string if there is no } before the next ${.
+func findMalformedInterpolation(str string, start int) string {
+	end := strings.Index(str[start:], "}")
+	if end < 0 || strings.Contains(str[start+2:start+end], "${") {
+		return ""
+	}
+	return str[start : start+end+1]
+}
+
+// Parse the given interpolation of the format ${...} as an HCL2 expression and evaluate it. Each function the expression
+// calls is looked up among the built-in Terraform functions (see terraformFunctions), and then among the Terragrunt
+// helper functions, which take string parameters.
+func evaluateInterpolation(interpolation string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions) (cty.Value, error) {
+	expressionSource := interpolation[2 : len(interpolation)-1]
+	expression, diags := hclsyntax.ParseExpression([]byte(expressionSource), terragruntOptions.TerragruntConfigPath, hcl2.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return cty.NilVal, errors.WithStackTrace(InvalidInterpolationSyntax(interpolation))
+	}
+
+	// HCL reports an error in a function as a diagnostic, so keep the first one to return it as is
+	var functionErr error
+	builtInFunctions := terraformFunctions(terragruntOptions)
+	functions := map[string]function.Function{}
+	hclsyntax.VisitAll(expression, func(node hclsyntax.Node) hcl2.Diagnostics {
+		if call, isCall := node.(*hclsyntax.FunctionCallExpr); isCall {
+			if builtInFunction, isBuiltIn := builtInFunctions[call.Name]; isBuiltIn {
+				functions[call.Name] = keepFunctionError(call.Name, builtInFunction, &functionErr)
+			} else {
+				functions[call.Name] = helperFunctionForExpressions(call.Name, include, terragruntOptions, &functionErr)
+			}
+		}
+		return nil
+	})
+
+	value, diags := expression.Value(&hcl2.EvalContext{Functions: functions})
+	if functionErr != nil {
+		return cty.NilVal, functionErr
+	}
+	if diags.HasErrors() {
+		return cty.NilVal, errors.WithStackTrace(InvalidInterpolationSyntax(interpolation))
+	}
+	return value, nil
+}
+
+// Wrap the Terragrunt helper function with the given name so it can be called from an HCL2 expression. The helper
+// functions parse their parameters from the text between the parentheses, so the parameters are passed to them as a
+// list of quoted strings, escaped with escapeHclString, as in find_in_parent_folders("foo", "bar").
+func helperFunctionForExpressions(functionName string, include *IncludeConfig, terragruntOptions *options.TerragruntOptions, functionErr *error) function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "parameters", Type: cty.String},
+		Type:     function.StaticReturnType(cty.DynamicPseudoType),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			parameters := []string{}
+			for _, arg := range args {
+				parameters = append(parameters, `"`+escapeHclString(arg.AsString())+`"`)
+			}
+
+			out, err := executeTerragruntHelperFunction(functionName, strings.Join(parameters, ", "), include, terragruntOptions)
+			if err == nil {
+				var value cty.Value
+				if value, err = goValueToCty(out); err == nil {
+					return value, nil
+				}
+			}
+
+			if *functionErr == nil {
+				*functionErr = err
+			}
+			return cty.NilVal, err
+		},
+	})
+}
+
+// Convert the value returned by a Terragrunt helper function to a cty value, so it can be used in an HCL2 expression
+func goValueToCty(value interface{}) (cty.Value, error) {
+	switch value := value.(type) {
+	case cty.Value:
+		return value, nil
+	case string:
+		return cty.StringVal(value), nil
+	case bool:
+		return cty.BoolVal(value), nil
+	case int:
+		return cty.NumberIntVal(int64(value)), nil
+	case int64:
+		return cty.NumberIntVal(value), nil
+	case float64:
+		return cty.NumberFloatVal(value), nil
+	case []string:
+		if len(value) == 0 {
+			return cty.ListValEmpty(cty.String), nil
+		}
+		values := []cty.Value{}
+		for _, item := range value {
+			values = append(values, cty.StringVal(item))
+		}
+		return cty.ListVal(values), nil
+	case []interface{}:
+		values := []cty.Value{}
+		for _, item := range value {
+			itemValue, err := goValueToCty(item)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			values = append(values, itemValue)
+		}
+		return cty.TupleVal(values), nil
+	case map[string]interface{}:
+		values := map[string]cty.Value{}
+		for key, item := range value {
+			itemValue, err := goValueToCty(item)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			values[key] = itemValue
+		}
+		return cty.ObjectVal(values), nil
+	default:
+		return cty.NilVal, errors.WithStackTrace(fmt.Errorf("Unsupported value of type %T returned by a helper function: %v", value, value))
+	}
+}
+
+// Convert the value of the given interpolation to a string, bool, int64, float64, []string (for a list of strings),
+// []interface{}, or map[string]interface{}
+func interpolationValueToGo(interpolation string, value cty.Value) (interface{}, error) {
+	if value.IsNull() {
+		return nil, errors.WithStackTrace(UnsupportedInterpolationValue{Interpolation: interpolation, Cause: "the value is null"})
+	}
+	if !value.IsWhollyKnown() {
+		return nil, errors.WithStackTrace(UnsupportedInterpolationValue{Interpolation: interpolation, Cause: "the value is not known"})
+	}
+
+	valueType := value.Type()
+	switch {
+	case valueType == cty.String:
+		return value.AsString(), nil
+	case valueType == cty.Bool:
+		return value.True(), nil
+	case valueType == cty.Number:
+		number := value.AsBigFloat()
+		if number.IsInt() {
+			integer, _ := number.Int64()
+			return integer, nil
+		}
+		float, _ := number.Float64()
+		return float, nil
+	case valueType.IsListType() || valueType.IsSetType() || valueType.IsTupleType():
+		items := []interface{}{}
+		strs := []string{}
+		for iterator := value.ElementIterator(); iterator.Next(); {
+			_, itemValue := iterator.Element()
+			item, err := interpolationValueToGo(interpolation, itemValue)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			if str, isString := item.(string); isString {
+				strs = append(strs, str)
+			}
+		}
+		if len(strs) == len(items) {
+			return strs, nil
+		}
+		return items, nil
+	case valueType.IsMapType() || valueType.IsObjectType():
+		items := map[string]interface{}{}
+		for iterator := value.ElementIterator(); iterator.Next(); {
+			key, itemValue := iterator.Element()
+			item, err := interpolationValueToGo(interpolation, itemValue)
+			if err != nil {
+				return nil, err
+			}
+			items[key.AsString()] = item
+		}
+		return items, nil
+	default:
+		return nil, errors.WithStackTrace(UnsupportedInterpolationValue{Interpolation: interpolation, Cause: fmt.Sprintf("values of type %s are not supported", valueType.FriendlyName())})
+	}
+}
+
+// Render the value of the given interpolation, which is the whole of a quoted string, in HCL syntax. A list is rendered
+// without the brackets, as a list interpolation is used inside brackets, e.g. ["${get_terraform_commands_that_need_vars()}"].
+func renderInterpolationAsHcl(interpolation string, value cty.Value) (string, error) {
+	goValue, err := interpolationValueToGo(interpolation, value)
+	if err != nil {
+		return "", err
+	}
+
+	switch goValue := goValue.(type) {
+	case []string:
+		items := []string{}
+		for _, item := range goValue {
+			items = append(items, renderHclValue(item))
+		}
+		return strings.Join(items, ", "), nil
+	case []interface{}:
+		items := []string{}
+		for _, item := range goValue {
+			items = append(items, renderHclValue(item))
+		}
+		return strings.Join(items, ", "), nil
+	default:
+		return renderHclValue(goValue), nil
+	}
+}
+
+// Render the value of the given interpolation, which is part of a string, as a string. The string is escaped so it can
+// be used in a quoted HCL string.
+func renderInterpolationAsString(interpolation string, value cty.Value) (string, error) {
+	goValue, err := interpolationValueToGo(interpolation, value)
+	if err != nil {
+		return "", err
+	}
+
+	switch goValue := goValue.(type) {
+	case string:
+		return escapeHclString(goValue), nil
+	case map[string]interface{}:
+		return "", errors.WithStackTrace(UnsupportedInterpolationValue{Interpolation: interpolation, Cause: "a map can't be part of a string"})
+	default:
+		return fmt.Sprintf("%v", goValue), nil
+	}
+}
+
+// Render the given value, as returned by interpolationValueToGo, in HCL syntax
+func renderHclValue(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return fmt.Sprintf(`"%s"`, escapeHclString(value))
+	case []string:
+		items := []string{}
+		for _, item := range value {
+			items = append(items, renderHclValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []interface{}:
+		items := []string{}
+		for _, item := range value {
+			items = append(items, renderHclValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		items := []string{}
+		for _, key := range keys {
+			items = append(items, fmt.Sprintf("%s = %s", renderHclValue(key), renderHclValue(value[key])))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// Custom error types
+
+type UnsupportedInterpolationValue struct {
+	Interpolation string
+	Cause         string
+}
+
+func (err UnsupportedInterpolationValue) Error() string {
+	return fmt.Sprintf("The value of %s can't be used in a Terragrunt configuration: %s", err.Interpolation, err.Cause)
+}
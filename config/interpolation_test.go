@@ -0,0 +1,83 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveTerragruntConfigStringExpressions(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{"ENV": "prod", "NAME": `say "hi"`}
+
+	testCases := []struct {
+		str         string
+		expectedOut string
+	}{
+		{`${get_env("ENV", "dev") == "prod" ? "us-east-1" : "us-west-2"}`, "us-east-1"},
+		{`region = "${get_env("ENV", "dev") == "prod" ? "us-east-1" : "us-west-2"}"`, `region = "us-east-1"`},
+		{`region = "${get_env("OTHER_ENV", "dev") == "prod" ? "us-east-1" : "us-west-2"}"`, `region = "us-west-2"`},
+		{`names = ["${[for name in ["a", "b"] : "${name}-${get_env("ENV", "dev")}"]}"]`, `names = ["a-prod", "b-prod"]`},
+		{`env = "${get_env(get_env("VAR_NAME", "ENV"), "dev")}"`, `env = "prod"`},
+		{`count = "${1 + 2}"`, `count = 3`},
+		{`enabled = "${get_env("ENV", "dev") == "prod"}"`, `enabled = true`},
+		{`tags = "${{Env = get_env("ENV", "dev"), Team = "payments"}}"`, `tags = {"Env" = "prod", "Team" = "payments"}`},
+		{`greeting = "${get_env("NAME", "")}"`, `greeting = "say \"hi\""`},
+		{`greeting = "Hello, ${get_env("NAME", "")}!"`, `greeting = "Hello, say \"hi\"!"`},
+		{`value = "$${var.foo}"`, `value = "${var.foo}"`},
+		{`value = "${get_env("SOME_VAR", "a}b")}"`, `value = "a}b"`},
+		{`value = "${get_env("SOME_VAR", "say \"hi\" from C:\\dir")}"`, `value = "say \"hi\" from C:\\dir"`},
+	}
+
+	for _, testCase := range testCases {
+		actualOut, actualErr := ResolveTerragruntConfigString(testCase.str, nil, terragruntOptionsForTestWithEnv(t, DefaultTerragruntConfigPath, env))
+		assert.Nil(t, actualErr, "For string '%s', unexpected error: %v", testCase.str, actualErr)
+		assert.Equal(t, testCase.expectedOut, actualOut, "For string '%s'", testCase.str)
+	}
+}
+
+func TestResolveTerragruntConfigStringExpressionErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		str         string
+		expectedErr error
+	}{
+		{`${unknown_function()}`, UnknownHelperFunction("")},
+		{`${get_env("ENV", "dev") ==}`, InvalidInterpolationSyntax("")},
+		{`${var.foo}`, InvalidInterpolationSyntax("")},
+		{`value = "${null}"`, UnsupportedInterpolationValue{}},
+		{`value = "prefix-${{a = "b"}}"`, UnsupportedInterpolationValue{}},
+	}
+
+	for _, testCase := range testCases {
+		_, actualErr := ResolveTerragruntConfigString(testCase.str, nil, terragruntOptionsForTest(t, DefaultTerragruntConfigPath))
+		if assert.Error(t, actualErr, "For string '%s'", testCase.str) {
+			assert.IsType(t, testCase.expectedErr, errors.Unwrap(actualErr), "For string '%s'", testCase.str)
+		}
+	}
+}
+
+func TestFindInterpolationEnd(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		str         string
+		expectedEnd int
+	}{
+		{`${foo()}`, 7},
+		{`${foo()} bar`, 7},
+		{`${foo("}")}`, 10},
+		{`${foo("\"}")}`, 12},
+		{`${foo("${bar()}")}`, 17},
+		{`${ {a = 1} } bar`, 11},
+		{`${foo(`, -1},
+		{`${foo("})`, -1},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expectedEnd, findInterpolationEnd(testCase.str, 0), "For string '%s'", testCase.str)
+	}
+}
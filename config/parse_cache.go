@@ -0,0 +1,354 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The folder in the download dir with the parse cache: one JSON file per parsed config
+const ParseCacheFolder = "parse-cache"
+
+// The helper functions whose results depend on something other than files and environment variables, such as AWS
+// credentials, or that return secrets, which must never be written to disk. Configs that call them are never cached.
+var uncacheableHelperFunctions = []string{
+	"get_aws_account_id",
+	"get_ssm_parameter",
+	"get_secretsmanager_secret",
+	"sops_decrypt_file",
+	"get_vault_secret",
+}
+
+type parseContextKey int
+
+const parseDependenciesContextKey parseContextKey = 0
+
+// A cached config, plus everything parsing it depended on, so we can tell whether parsing it again would give the same
+// result
+type parseCacheEntry struct {
+	Key                string
+	Files              []fileDependency
+	EnvVars            []envVarDependency
+	Globs              []globDependency
+	Config             *TerragruntConfig
+	IncludedConfigPath string
+}
+
+// A file that parsing a config read or looked for, such as an included config, or the files find_in_parent_folders
+// checked. Existence is all that matters for the files that don't exist.
+type fileDependency struct {
+	Path    string
+	Exists  bool
+	Size    int64
+	ModTime time.Time
+	Sha256  string
+}
+
+// An environment variable get_env read. Only a hash of its value is stored, as that's all it takes to tell whether it
+// changed.
+type envVarDependency struct {
+	Name   string
+	IsSet  bool
+	Sha256 string
+}
+
+// A glob, such as the ones get_var_file_hierarchy uses, and the files it matched
+type globDependency struct {
+	Pattern string
+	Matches []string
+}
+
+// Collects what parsing a config depends on, while it's being parsed. It's in the context of the options used for
+// parsing, so the helper functions can add to it without having to pass it around.
+type parseDependencies struct {
+	lock                sync.Mutex
+	files               map[string]bool
+	envVars             map[string]bool
+	globs               map[string]bool
+	uncacheableFunction string
+}
+
+// Parse the Terragrunt config file at the given path, using the cached config if none of the files, environment
+// variables, and globs it depends on changed since it was cached, and caching it otherwise. Problems with the cache
+// are only logged, as the config can always be parsed again.
+func parseConfigFileWithCache(configPath string, terragruntOptions *options.TerragruntOptions) (*TerragruntConfig, error) {
+	cacheDir := filepath.Join(terragruntOptions.DownloadDir, ParseCacheFolder)
+	key := parseCacheKey(configPath, terragruntOptions)
+	entryPath := filepath.Join(cacheDir, key+".json")
+
+	if config, found := loadParseCacheEntry(entryPath, key, terragruntOptions); found {
+		return config, nil
+	}
+
+	dependencies := &parseDependencies{files: map[string]bool{}, envVars: map[string]bool{}, globs: map[string]bool{}}
+
+	parentContext := terragruntOptions.GetContext()
+	terragruntOptions.Context = context.WithValue(parentContext, parseDependenciesContextKey, dependencies)
+	config, err := parseConfigFile(configPath, terragruntOptions, nil)
+	terragruntOptions.Context = parentContext
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The web identity token may be a secret
+	if dependencies.uncacheableFunction == "" && config.IamWebIdentityToken == "" {
+		if err := storeParseCacheEntry(entryPath, key, dependencies, config, terragruntOptions); err != nil {
+			terragruntOptions.Logger.Printf("WARNING: failed to cache the parsed config %s in %s: %v", configPath, cacheDir, err)
+		}
+	}
+
+	return config, nil
+}
+
+// Return the key of the cache entry for the given config. It covers everything parsing depends on that's not recorded
+// in parseDependencies: the paths the config is parsed for, the options helper functions use, and the version of
+// Terragrunt, as a new version may parse the same config differently.
+func parseCacheKey(configPath string, terragruntOptions *options.TerragruntOptions) string {
+	absConfigPath, err := filepath.Abs(configPath)
+	if err != nil {
+		absConfigPath = configPath
+	}
+	absTerragruntConfigPath, err := filepath.Abs(terragruntOptions.TerragruntConfigPath)
+	if err != nil {
+		absTerragruntConfigPath = terragruntOptions.TerragruntConfigPath
+	}
+
+	encoded, _ := json.Marshal([]interface{}{
+		absConfigPath,
+		absTerragruntConfigPath,
+		terragruntOptions.MaxFoldersToCheck,
+		terragruntOptions.StrictValidate,
+		terragruntOptions.TerragruntVersion,
+		terragruntOptions.Features,
+	})
+	return sha256Hex(encoded)
+}
+
+// Return the cached config in the entry at the given path, if there is one and it's still valid
+func loadParseCacheEntry(entryPath string, key string, terragruntOptions *options.TerragruntOptions) (*TerragruntConfig, bool) {
+	contents, err := ioutil.ReadFile(entryPath)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry parseCacheEntry
+	if err := json.Unmarshal(contents, &entry); err != nil || entry.Key != key || entry.Config == nil {
+		return nil, false
+	}
+
+	for _, file := range entry.Files {
+		if !file.isUnchanged() {
+			return nil, false
+		}
+	}
+	for _, envVar := range entry.EnvVars {
+		if !envVar.isUnchanged(terragruntOptions) {
+			return nil, false
+		}
+	}
+	for _, glob := range entry.Globs {
+		if !glob.isUnchanged() {
+			return nil, false
+		}
+	}
+
+	entry.Config.IncludedConfigPath = entry.IncludedConfigPath
+	return entry.Config, true
+}
+
+// Write the given config, and what parsing it depended on, to the cache entry at the given path. The entry is written
+// to a temp file first, so other Terragrunt processes never read a half-written entry.
+func storeParseCacheEntry(entryPath string, key string, dependencies *parseDependencies, config *TerragruntConfig, terragruntOptions *options.TerragruntOptions) error {
+	entry := parseCacheEntry{Key: key, Config: config, IncludedConfigPath: config.IncludedConfigPath}
+
+	for _, path := range sortedKeys(dependencies.files) {
+		file, err := newFileDependency(path)
+		if err != nil {
+			return err
+		}
+		entry.Files = append(entry.Files, file)
+	}
+	for _, name := range sortedKeys(dependencies.envVars) {
+		entry.EnvVars = append(entry.EnvVars, newEnvVarDependency(name, terragruntOptions))
+	}
+	for _, pattern := range sortedKeys(dependencies.globs) {
+		glob, err := newGlobDependency(pattern)
+		if err != nil {
+			return err
+		}
+		entry.Globs = append(entry.Globs, glob)
+	}
+
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(entryPath), filepath.Base(entryPath))
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(encoded)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	return errors.WithStackTrace(os.Rename(tmpFile.Name(), entryPath))
+}
+
+func newFileDependency(path string) (fileDependency, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fileDependency{Path: path}, nil
+	}
+	if err != nil {
+		return fileDependency{}, errors.WithStackTrace(err)
+	}
+
+	file := fileDependency{Path: path, Exists: true, Size: info.Size(), ModTime: info.ModTime()}
+	if !info.IsDir() {
+		file.Sha256, err = sha256OfFile(path)
+		if err != nil {
+			return fileDependency{}, err
+		}
+	}
+	return file, nil
+}
+
+// Return true if the file still exists or still doesn't exist, and has the same contents. Checking the size and
+// modification time is enough for most files, but a git checkout changes the modification time of files whose contents
+// it doesn't change, so if the modification time changed, compare the contents.
+func (file fileDependency) isUnchanged() bool {
+	info, err := os.Stat(file.Path)
+	if os.IsNotExist(err) {
+		return !file.Exists
+	}
+	if err != nil || !file.Exists || info.Size() != file.Size || info.IsDir() != (file.Sha256 == "") {
+		return false
+	}
+	if info.ModTime().Equal(file.ModTime) || info.IsDir() {
+		return true
+	}
+
+	hash, err := sha256OfFile(file.Path)
+	return err == nil && hash == file.Sha256
+}
+
+func newEnvVarDependency(name string, terragruntOptions *options.TerragruntOptions) envVarDependency {
+	value, isSet := terragruntOptions.Env[name]
+	return envVarDependency{Name: name, IsSet: isSet, Sha256: sha256Hex([]byte(value))}
+}
+
+func (envVar envVarDependency) isUnchanged(terragruntOptions *options.TerragruntOptions) bool {
+	return newEnvVarDependency(envVar.Name, terragruntOptions) == envVar
+}
+
+func newGlobDependency(pattern string) (globDependency, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return globDependency{}, errors.WithStackTrace(err)
+	}
+	return globDependency{Pattern: pattern, Matches: matches}, nil
+}
+
+func (glob globDependency) isUnchanged() bool {
+	current, err := newGlobDependency(glob.Pattern)
+	if err != nil || len(current.Matches) != len(glob.Matches) {
+		return false
+	}
+	for index, match := range current.Matches {
+		if match != glob.Matches[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the dependencies being collected for the parse of a config with the given options, if any
+func getParseDependencies(terragruntOptions *options.TerragruntOptions) *parseDependencies {
+	dependencies, _ := terragruntOptions.GetContext().Value(parseDependenciesContextKey).(*parseDependencies)
+	return dependencies
+}
+
+// Record that parsing the config with the given options read, or looked for, the file at the given path
+func recordFileDependency(terragruntOptions *options.TerragruntOptions, path string) {
+	if dependencies := getParseDependencies(terragruntOptions); dependencies != nil {
+		dependencies.lock.Lock()
+		defer dependencies.lock.Unlock()
+		dependencies.files[path] = true
+	}
+}
+
+// Record that parsing the config with the given options read the environment variable with the given name
+func recordEnvVarDependency(terragruntOptions *options.TerragruntOptions, name string) {
+	if dependencies := getParseDependencies(terragruntOptions); dependencies != nil {
+		dependencies.lock.Lock()
+		defer dependencies.lock.Unlock()
+		dependencies.envVars[name] = true
+	}
+}
+
+// Record that parsing the config with the given options depends on the files that match the given glob
+func recordGlobDependency(terragruntOptions *options.TerragruntOptions, pattern string) {
+	if dependencies := getParseDependencies(terragruntOptions); dependencies != nil {
+		dependencies.lock.Lock()
+		defer dependencies.lock.Unlock()
+		dependencies.globs[pattern] = true
+	}
+}
+
+// Record that parsing the config with the given options called the given helper function, which means the config
+// can't be cached if the function is one of the uncacheableHelperFunctions
+func recordHelperFunctionCall(terragruntOptions *options.TerragruntOptions, functionName string) {
+	if !util.ListContainsElement(uncacheableHelperFunctions, functionName) {
+		return
+	}
+	if dependencies := getParseDependencies(terragruntOptions); dependencies != nil {
+		dependencies.lock.Lock()
+		defer dependencies.lock.Unlock()
+		dependencies.uncacheableFunction = functionName
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := []string{}
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sha256OfFile(path string) (string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return sha256Hex(contents), nil
+}
+
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+const parseCacheTestRootConfig = `
+terragrunt = {
+  remote_state {
+    backend = "s3"
+    config {
+      bucket = "${get_env("STATE_BUCKET", "default-bucket")}"
+      key    = "${path_relative_to_include()}/terraform.tfstate"
+      region = "us-east-1"
+    }
+  }
+}
+`
+
+const parseCacheTestChildConfig = `
+terragrunt = {
+  include {
+    path = "${find_in_parent_folders()}"
+  }
+}
+`
+
+func TestParseConfigFileWithCache(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "parse-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	rootConfigPath := filepath.Join(tmpDir, "live", DefaultTerragruntConfigPath)
+	childConfigPath := filepath.Join(tmpDir, "live", "stage", "app", DefaultTerragruntConfigPath)
+	writeFileForTest(t, rootConfigPath, parseCacheTestRootConfig)
+	writeFileForTest(t, childConfigPath, parseCacheTestChildConfig)
+
+	opts := terragruntOptionsForTestWithEnv(t, childConfigPath, map[string]string{"STATE_BUCKET": "bucket-1"})
+	opts.ParseCache = true
+	opts.DownloadDir = filepath.Join(tmpDir, "download")
+	entryPath := filepath.Join(opts.DownloadDir, ParseCacheFolder, parseCacheKey(childConfigPath, opts)+".json")
+
+	assertRemoteStateConfigForTest(t, opts, "bucket-1", "us-east-1")
+	assert.True(t, fileExistsForTest(entryPath), "Expected the config to be cached in %s", entryPath)
+
+	// As long as nothing changes, the cached config is used, which we can tell by changing it
+	tamperWithParseCacheEntryForTest(t, entryPath, "bucket-from-cache")
+	terragruntConfig := assertRemoteStateConfigForTest(t, opts, "bucket-from-cache", "us-east-1")
+	assert.Equal(t, filepath.ToSlash(rootConfigPath), terragruntConfig.IncludedConfigPath)
+
+	// Changing the modification time of a file, but not its contents, doesn't change the config
+	future := time.Now().Add(time.Hour)
+	assert.Nil(t, os.Chtimes(rootConfigPath, future, future))
+	assertRemoteStateConfigForTest(t, opts, "bucket-from-cache", "us-east-1")
+
+	// Changing an environment variable the config reads does
+	opts.Env["STATE_BUCKET"] = "bucket-2"
+	assertRemoteStateConfigForTest(t, opts, "bucket-2", "us-east-1")
+
+	// So does changing the included config
+	tamperWithParseCacheEntryForTest(t, entryPath, "bucket-from-cache")
+	writeFileForTest(t, rootConfigPath, strings.Replace(parseCacheTestRootConfig, "us-east-1", "us-west-2", 1))
+	assertRemoteStateConfigForTest(t, opts, "bucket-2", "us-west-2")
+
+	// And adding a config in a folder find_in_parent_folders looked in before it found the included config
+	tamperWithParseCacheEntryForTest(t, entryPath, "bucket-from-cache")
+	writeFileForTest(t, filepath.Join(tmpDir, "live", "stage", DefaultTerragruntConfigPath), strings.Replace(parseCacheTestRootConfig, "us-east-1", "eu-west-1", 1))
+	assertRemoteStateConfigForTest(t, opts, "bucket-2", "eu-west-1")
+}
+
+func TestParseConfigFileWithCacheSkipsWebIdentityToken(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "parse-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	configPath := filepath.Join(tmpDir, DefaultTerragruntConfigPath)
+	writeFileForTest(t, configPath, `
+terragrunt = {
+  iam_roles              = ["arn:aws:iam::123456789012:role/deploy"]
+  iam_web_identity_token = "secret-token"
+}
+`)
+
+	opts := terragruntOptionsForTest(t, configPath)
+	opts.ParseCache = true
+	opts.DownloadDir = filepath.Join(tmpDir, "download")
+
+	terragruntConfig, err := ParseConfigFile(configPath, opts, nil)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "secret-token", terragruntConfig.IamWebIdentityToken)
+	assert.False(t, fileExistsForTest(filepath.Join(opts.DownloadDir, ParseCacheFolder)), "Expected nothing to be cached")
+}
+
+func TestRecordHelperFunctionCall(t *testing.T) {
+	t.Parallel()
+
+	opts := terragruntOptionsForTest(t, DefaultTerragruntConfigPath)
+
+	// Without a parse in progress, there's nothing to record to
+	recordHelperFunctionCall(opts, "get_aws_account_id")
+
+	dependencies := &parseDependencies{files: map[string]bool{}, envVars: map[string]bool{}, globs: map[string]bool{}}
+	opts.Context = context.WithValue(context.Background(), parseDependenciesContextKey, dependencies)
+
+	recordHelperFunctionCall(opts, "find_in_parent_folders")
+	assert.Equal(t, "", dependencies.uncacheableFunction)
+
+	recordHelperFunctionCall(opts, "get_vault_secret")
+	assert.Equal(t, "get_vault_secret", dependencies.uncacheableFunction)
+
+	// Clones of the options, such as the ones used to parse included configs, record to the same dependencies
+	recordEnvVarDependency(opts.Clone(opts.TerragruntConfigPath), "AWS_REGION")
+	assert.Equal(t, map[string]bool{"AWS_REGION": true}, dependencies.envVars)
+}
+
+func assertRemoteStateConfigForTest(t *testing.T, opts *options.TerragruntOptions, expectedBucket string, expectedRegion string) *TerragruntConfig {
+	terragruntConfig, err := ParseConfigFile(opts.TerragruntConfigPath, opts, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assert.Equal(t, expectedBucket, terragruntConfig.RemoteState.Config["bucket"])
+	assert.Equal(t, expectedRegion, terragruntConfig.RemoteState.Config["region"])
+	assert.Equal(t, "stage/app/terraform.tfstate", terragruntConfig.RemoteState.Config["key"])
+	return terragruntConfig
+}
+
+// Change the bucket in the cached config in the given cache entry
+func tamperWithParseCacheEntryForTest(t *testing.T, entryPath string, bucket string) {
+	contents, err := ioutil.ReadFile(entryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entry parseCacheEntry
+	if err := json.Unmarshal(contents, &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.Config.RemoteState.Config["bucket"] = bucket
+
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(entryPath, encoded, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeFileForTest(t *testing.T, path string, contents string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fileExistsForTest(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,130 @@
+package configstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// When a module has a cost_estimation block, the JSON output of its cost estimation command is written to this file
+// in the module's working dir so that the xxx-all commands can include it in their summary
+const COST_ESTIMATE_FILE = ".terragrunt-cost-estimate.json"
+
+// The cost estimate for a single module, as returned by the cost estimation command. We don't know the exact format
+// the command uses, so we keep all the top-level fields of the JSON object it returns and treat any field that is a
+// number (or a string that contains a number, as is the case with infracost) as a cost that can be added up.
+type CostEstimate struct {
+	ModulePath string
+	Fields     map[string]interface{}
+}
+
+// Parse the given output of the cost estimation command for the module at the given path
+func ParseCostEstimate(modulePath string, output string) (*CostEstimate, error) {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(output), &fields); err != nil {
+		return nil, errors.WithStackTrace(InvalidCostEstimate{ModulePath: modulePath, Output: output, Underlying: err})
+	}
+
+	return &CostEstimate{ModulePath: modulePath, Fields: fields}, nil
+}
+
+// Read the cost estimate for the module at the given path from the COST_ESTIMATE_FILE in the given working dir.
+// Return nil if there is no such file (e.g. because the module failed before the cost estimation command ran).
+func ReadCostEstimate(modulePath string, workingDir string) (*CostEstimate, error) {
+	path := util.JoinPath(workingDir, COST_ESTIMATE_FILE)
+	if !util.FileExists(path) {
+		return nil, nil
+	}
+
+	output, err := util.ReadFileAsString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCostEstimate(modulePath, output)
+}
+
+// Write the given output of the cost estimation command to the COST_ESTIMATE_FILE in the given working dir
+func WriteCostEstimate(output string, workingDir string) error {
+	return errors.WithStackTrace(ioutil.WriteFile(util.JoinPath(workingDir, COST_ESTIMATE_FILE), []byte(output), 0644))
+}
+
+// Return the fields of this cost estimate that contain numbers
+func (estimate *CostEstimate) numericFields() map[string]float64 {
+	out := map[string]float64{}
+
+	for key, value := range estimate.Fields {
+		switch value := value.(type) {
+		case float64:
+			out[key] = value
+		case string:
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				out[key] = number
+			}
+		}
+	}
+
+	return out
+}
+
+// Render this cost estimate as a human-readable string
+func (estimate *CostEstimate) String() string {
+	return fmt.Sprintf("%s: %s", estimate.ModulePath, formatCostFields(estimate.numericFields()))
+}
+
+// Render the given cost estimates as a human-readable summary, with one line per module and a final line that adds
+// up the numeric fields of all the modules
+func FormatCostEstimates(estimates []*CostEstimate) string {
+	lines := []string{}
+	totals := map[string]float64{}
+
+	for _, estimate := range estimates {
+		lines = append(lines, fmt.Sprintf("  => %s", estimate.String()))
+		for key, value := range estimate.numericFields() {
+			totals[key] += value
+		}
+	}
+
+	sort.Strings(lines)
+	lines = append(lines, fmt.Sprintf("  => Total: %s", formatCostFields(totals)))
+
+	return fmt.Sprintf("Cost estimates:\n%s", strings.Join(lines, "\n"))
+}
+
+// Render the given map of fields to numbers as a string of the form key1 = value1, key2 = value2, sorted by key
+func formatCostFields(fields map[string]float64) string {
+	keys := []string{}
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s = %.2f", key, fields[key]))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Custom error types
+
+type InvalidCostEstimate struct {
+	ModulePath string
+	Output     string
+	Underlying error
+}
+
+func (err InvalidCostEstimate) Error() string {
+	return fmt.Sprintf("The cost estimation command for module %s must write a JSON object to stdout, but parsing its output failed: %v. Output:\n%s", err.ModulePath, err.Underlying, err.Output)
+}
+
+func (err InvalidCostEstimate) Unwrap() error {
+	return err.Underlying
+}
@@ -0,0 +1,41 @@
+package configstack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCostEstimate(t *testing.T) {
+	t.Parallel()
+
+	estimate, err := ParseCostEstimate("/stage/vpc", `{"currency": "USD", "totalMonthlyCost": "12.5", "diffTotalMonthlyCost": 2.25}`)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		assert.Equal(t, "/stage/vpc", estimate.ModulePath)
+		assert.Equal(t, map[string]float64{"totalMonthlyCost": 12.5, "diffTotalMonthlyCost": 2.25}, estimate.numericFields())
+		assert.Equal(t, "/stage/vpc: diffTotalMonthlyCost = 2.25, totalMonthlyCost = 12.50", estimate.String())
+	}
+}
+
+func TestParseCostEstimateInvalidJson(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseCostEstimate("/stage/vpc", "Error: not logged in")
+	assert.Error(t, err)
+}
+
+func TestFormatCostEstimates(t *testing.T) {
+	t.Parallel()
+
+	estimates := []*CostEstimate{
+		{ModulePath: "/stage/vpc", Fields: map[string]interface{}{"diffTotalMonthlyCost": "1.5"}},
+		{ModulePath: "/stage/mysql", Fields: map[string]interface{}{"diffTotalMonthlyCost": 10.0, "currency": "USD"}},
+	}
+
+	expected := `Cost estimates:
+  => /stage/mysql: diffTotalMonthlyCost = 10.00
+  => /stage/vpc: diffTotalMonthlyCost = 1.50
+  => Total: diffTotalMonthlyCost = 11.50`
+
+	assert.Equal(t, expected, FormatCostEstimates(estimates))
+}
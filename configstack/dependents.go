@@ -0,0 +1,92 @@
+package configstack
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Return the paths of the modules in the given folder or its subfolders that depend, directly or via other modules, on
+// any of the modules at the given paths, sorted, without the given modules themselves. This finds what would break if
+// the given modules were destroyed, including modules outside of the stack that's being destroyed. A config that can't
+// be parsed is logged and skipped, as a broken module elsewhere shouldn't keep anyone from checking the rest.
+func FindDependentModules(modulePaths []string, rootPath string, terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	canonicalModulePaths, err := util.CanonicalPaths(modulePaths, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	searchOptions := terragruntOptions.Clone(util.JoinPath(rootPath, config.DefaultTerragruntConfigPath))
+	terragruntConfigPaths, err := config.FindConfigFilesInWorkingDir(searchOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies := make([][]string, len(terragruntConfigPaths))
+	errs := make([]error, len(terragruntConfigPaths))
+	util.RunInParallel(len(terragruntConfigPaths), config.DiscoveryParallelism, func(index int) {
+		dependencies[index], errs[index] = getDependencyPaths(terragruntConfigPaths[index], terragruntOptions)
+	})
+
+	dependencyMap := map[string][]string{}
+	for index, terragruntConfigPath := range terragruntConfigPaths {
+		if errs[index] != nil {
+			terragruntOptions.Logger.Printf("WARNING: Unable to check whether the module in %s depends on the modules being destroyed: %v", terragruntConfigPath, errs[index])
+			continue
+		}
+		modulePath, err := util.CanonicalPath(filepath.Dir(terragruntConfigPath), ".")
+		if err != nil {
+			return nil, err
+		}
+		dependencyMap[modulePath] = dependencies[index]
+	}
+
+	return findDependents(canonicalModulePaths, dependencyMap), nil
+}
+
+// Return the canonical paths of the modules in the dependencies block of the Terragrunt config at the given path
+func getDependencyPaths(terragruntConfigPath string, terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	terragruntConfig, err := config.ParseConfigFile(terragruntConfigPath, terragruntOptions.Clone(terragruntConfigPath), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if terragruntConfig.Dependencies == nil {
+		return []string{}, nil
+	}
+	return util.CanonicalPaths(terragruntConfig.Dependencies.Paths, filepath.Dir(terragruntConfigPath))
+}
+
+// Return the paths of the modules in the given map from module path to the paths of its dependencies that depend,
+// directly or indirectly, on any of the given modules, sorted, without the given modules themselves
+func findDependents(modulePaths []string, dependencyMap map[string][]string) []string {
+	affectedModules := map[string]bool{}
+	for _, modulePath := range modulePaths {
+		affectedModules[modulePath] = true
+	}
+
+	// Keep adding the modules that depend on affected modules until there are no more to add
+	dependents := []string{}
+	for addedModule := true; addedModule; {
+		addedModule = false
+		for modulePath, dependencyPaths := range dependencyMap {
+			if affectedModules[modulePath] {
+				continue
+			}
+			for _, dependencyPath := range dependencyPaths {
+				if affectedModules[dependencyPath] {
+					affectedModules[modulePath] = true
+					dependents = append(dependents, modulePath)
+					addedModule = true
+					break
+				}
+			}
+		}
+	}
+
+	sort.Strings(dependents)
+	return dependents
+}
@@ -0,0 +1,66 @@
+package configstack
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDependents(t *testing.T) {
+	t.Parallel()
+
+	dependencyMap := map[string][]string{
+		"/infra/live/vpc":   {},
+		"/infra/live/mysql": {"/infra/live/vpc"},
+		"/infra/live/app":   {"/infra/live/mysql", "/infra/live/redis"},
+		"/infra/live/redis": {},
+		"/infra/live/dns":   {"/infra/other/zone"},
+	}
+
+	testCases := []struct {
+		modulePaths []string
+		expected    []string
+	}{
+		{[]string{}, []string{}},
+		{[]string{"/infra/live/app"}, []string{}},
+		{[]string{"/infra/live/redis"}, []string{"/infra/live/app"}},
+		{[]string{"/infra/live/vpc"}, []string{"/infra/live/app", "/infra/live/mysql"}},
+		{[]string{"/infra/live/vpc", "/infra/live/mysql"}, []string{"/infra/live/app"}},
+		{[]string{"/infra/other/zone"}, []string{"/infra/live/dns"}},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, findDependents(testCase.modulePaths, dependencyMap), "For modules %v", testCase.modulePaths)
+	}
+}
+
+func TestFindDependentModules(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(canonical(t, "../test/fixture-modules/"+config.DefaultTerragruntConfigPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dependents, err := FindDependentModules([]string{"../test/fixture-modules/module-a"}, canonical(t, "../test/fixture-modules"), terragruntOptions)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		expected := []string{
+			canonical(t, "../test/fixture-modules/module-c"),
+			canonical(t, "../test/fixture-modules/module-d"),
+			canonical(t, "../test/fixture-modules/module-e/module-e-child"),
+		}
+		assert.Equal(t, expected, dependents)
+	}
+
+	dependents, err = FindDependentModules([]string{"../test/fixture-modules/module-h"}, canonical(t, "../test/fixture-modules"), terragruntOptions)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		expected := []string{
+			canonical(t, "../test/fixture-modules/module-i"),
+			canonical(t, "../test/fixture-modules/module-j"),
+			canonical(t, "../test/fixture-modules/module-k"),
+		}
+		assert.Equal(t, expected, dependents)
+	}
+}
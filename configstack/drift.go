@@ -0,0 +1,211 @@
+package configstack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+)
+
+// The command the drift-all command runs in each module. With -detailed-exitcode, Terraform exits with code 2 if the
+// refresh found changes, i.e., if the real infrastructure has drifted from the state.
+var DRIFT_CHECK_COMMAND = []string{"plan", "-refresh-only", "-detailed-exitcode", "-input=false"}
+
+// The name the results of checking a stack for drift are saved under for --terragrunt-resume
+const DRIFT_RUN_NAME = "drift"
+
+// The exit code of Terraform with -detailed-exitcode, and of the drift-all command, if there is drift
+const DRIFT_EXIT_CODE = 2
+
+// The exit code of the drift-all command if it couldn't check some of the modules for drift
+const DRIFT_CHECK_FAILED_EXIT_CODE = 1
+
+// Whether a module has drifted from its state
+type DriftStatus string
+
+const (
+	// The refresh found no changes
+	DriftClean DriftStatus = "clean"
+	// The refresh found changes, so the real infrastructure has drifted from the state
+	DriftDrifted DriftStatus = "drifted"
+	// The module couldn't be checked for drift, e.g. because Terraform failed, or the run was cancelled
+	DriftError DriftStatus = "error"
+	// The module wasn't checked for drift, because it was skipped, e.g. by --terragrunt-git-diff
+	DriftSkipped DriftStatus = "skipped"
+)
+
+// The drift status of a single module
+type ModuleDrift struct {
+	Path   string      `json:"path"`
+	Status DriftStatus `json:"status"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// The drift status of all the modules in a stack, sorted by path
+type DriftReport struct {
+	Modules []ModuleDrift `json:"modules"`
+}
+
+// Return the number of modules in this report with the given status
+func (report *DriftReport) Count(status DriftStatus) int {
+	count := 0
+	for _, module := range report.Modules {
+		if module.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Render this report as a human-readable table, with one line per module and a final line that counts the modules
+// with each status
+func (report *DriftReport) String() string {
+	width := len("MODULE")
+	for _, module := range report.Modules {
+		if len(module.Path) > width {
+			width = len(module.Path)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%-*s  %s\n", width, "MODULE", "STATUS")
+	for _, module := range report.Modules {
+		line := fmt.Sprintf("%-*s  %s", width, module.Path, module.Status)
+		if module.Error != "" {
+			line = fmt.Sprintf("%s (%s)", line, firstLine(module.Error))
+		}
+		fmt.Fprintln(&out, line)
+	}
+	fmt.Fprintf(&out, "%d clean, %d drifted, %d error, %d skipped", report.Count(DriftClean), report.Count(DriftDrifted), report.Count(DriftError), report.Count(DriftSkipped))
+
+	return out.String()
+}
+
+// Render this report as indented JSON
+func (report *DriftReport) JSON() (string, error) {
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return string(out), nil
+}
+
+// Return an error with the exit code of the drift-all command for this report: DriftCheckFailed if some modules
+// couldn't be checked, even if others have drifted, as then the report is incomplete; DriftDetected if some modules
+// have drifted; and nil if all the modules are clean.
+func (report *DriftReport) Err() error {
+	if errorCount := report.Count(DriftError); errorCount > 0 {
+		return errors.WithStackTrace(DriftCheckFailed{Errors: errorCount, Drifted: report.Count(DriftDrifted)})
+	}
+	if driftedCount := report.Count(DriftDrifted); driftedCount > 0 {
+		return errors.WithStackTrace(DriftDetected{Drifted: driftedCount})
+	}
+	return nil
+}
+
+// Check all the modules in the given stack for drift by running a refresh-only plan in each of them, and return a
+// report with the drift status of each module, plus the result of each module. The modules run in dependency order,
+// as usual, but a module that has drifted or fails doesn't stop the modules that depend on it from being checked, as
+// a refresh doesn't need the dependencies to be up to date. The plan output of each module is captured and written to
+// the ErrWriter of the given options once the run is done, under a header with the path of the module, so the Writer
+// only gets the report.
+func (stack *Stack) Drift(terragruntOptions *options.TerragruntOptions) (*DriftReport, []ModuleResult, error) {
+	outputStreams := make([]bytes.Buffer, len(stack.Modules))
+	drifted := make([]bool, len(stack.Modules))
+
+	for n, module := range stack.Modules {
+		n := n
+		runTerragrunt := module.TerragruntOptions.RunTerragrunt
+		module.TerragruntOptions.RunTerragrunt = func(moduleOptions *options.TerragruntOptions) error {
+			err := runTerragrunt(moduleOptions)
+			if exitCode, exitCodeErr := shell.GetExitCode(err); err != nil && exitCodeErr == nil && exitCode == DRIFT_EXIT_CODE {
+				drifted[n] = true
+				return nil
+			}
+			return err
+		}
+		module.TerragruntOptions.Writer = &outputStreams[n]
+		module.TerragruntOptions.IgnoreDependencyErrors = true
+	}
+
+	results, err := stack.run(DRIFT_CHECK_COMMAND, DRIFT_RUN_NAME, NormalOrder)
+	if results == nil {
+		return nil, nil, err
+	}
+
+	stack.writeDriftPlans(terragruntOptions, outputStreams, drifted)
+
+	report := &DriftReport{Modules: []ModuleDrift{}}
+	for _, result := range results {
+		report.Modules = append(report.Modules, stack.moduleDrift(result, drifted))
+	}
+
+	return report, results, nil
+}
+
+// Return the drift status of the module with the given result
+func (stack *Stack) moduleDrift(result ModuleResult, drifted []bool) ModuleDrift {
+	switch result.Status {
+	case ModuleSkipped:
+		return ModuleDrift{Path: result.Path, Status: DriftSkipped}
+	case ModuleSucceeded:
+		for n, module := range stack.Modules {
+			if module.Path == result.Path && drifted[n] {
+				return ModuleDrift{Path: result.Path, Status: DriftDrifted}
+			}
+		}
+		return ModuleDrift{Path: result.Path, Status: DriftClean}
+	default:
+		message := result.Status.String()
+		if result.Err != nil {
+			message = result.Err.Error()
+		}
+		return ModuleDrift{Path: result.Path, Status: DriftError, Error: message}
+	}
+}
+
+// Write the captured plan output of each module that has drifted to the ErrWriter of the given options, so the user
+// can see what changed
+func (stack *Stack) writeDriftPlans(terragruntOptions *options.TerragruntOptions, outputStreams []bytes.Buffer, drifted []bool) {
+	for n, module := range stack.Modules {
+		if drifted[n] {
+			fmt.Fprintf(terragruntOptions.ErrWriter, "Module %s has drifted:\n%s\n", module.Path, outputStreams[n].String())
+		}
+	}
+}
+
+// Return the first line of the given string
+func firstLine(str string) string {
+	return strings.SplitN(strings.TrimSpace(str), "\n", 2)[0]
+}
+
+// Custom error types
+
+type DriftDetected struct {
+	Drifted int
+}
+
+func (err DriftDetected) Error() string {
+	return fmt.Sprintf("%d modules have drifted from their state", err.Drifted)
+}
+
+func (err DriftDetected) ExitStatus() (int, error) {
+	return DRIFT_EXIT_CODE, nil
+}
+
+type DriftCheckFailed struct {
+	Errors  int
+	Drifted int
+}
+
+func (err DriftCheckFailed) Error() string {
+	return fmt.Sprintf("Could not check %d modules for drift, and %d of the other modules have drifted from their state", err.Errors, err.Drifted)
+}
+
+func (err DriftCheckFailed) ExitStatus() (int, error) {
+	return DRIFT_CHECK_FAILED_EXIT_CODE, nil
+}
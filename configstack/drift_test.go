@@ -0,0 +1,127 @@
+package configstack
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+// An error with the given exit code, like the one Terraform's process exits with
+type exitCodeError int
+
+func (err exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", int(err))
+}
+
+func (err exitCodeError) ExitStatus() (int, error) {
+	return int(err), nil
+}
+
+func TestStackDrift(t *testing.T) {
+	t.Parallel()
+
+	stackPath := createTempFolder(t)
+	defer os.RemoveAll(stackPath)
+
+	stack := &Stack{Path: stackPath}
+	errs := map[string]error{"/stage/vpc": nil, "/stage/mysql": exitCodeError(DRIFT_EXIT_CODE), "/stage/app": exitCodeError(1), "/stage/dns": nil}
+	for _, path := range []string{"/stage/vpc", "/stage/mysql", "/stage/app", "/stage/dns"} {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		terragruntOptions.RunTerragrunt = func(moduleOptions *options.TerragruntOptions) error {
+			assert.Equal(t, DRIFT_CHECK_COMMAND, moduleOptions.TerraformCliArgs)
+			moduleOptions.Writer.Write([]byte("Plan output of " + moduleOptions.TerragruntConfigPath))
+			return errs[moduleOptions.TerragruntConfigPath]
+		}
+		stack.Modules = append(stack.Modules, &TerraformModule{Path: path, Dependencies: []*TerraformModule{}, TerragruntOptions: terragruntOptions})
+	}
+
+	// A module that has drifted or fails doesn't stop the modules that depend on it from being checked
+	stack.Modules[2].Dependencies = []*TerraformModule{stack.Modules[1]}
+	stack.Modules[3].Dependencies = []*TerraformModule{stack.Modules[2]}
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("drift_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errWriter := &bytes.Buffer{}
+	terragruntOptions.ErrWriter = errWriter
+
+	report, results, err := stack.Drift(terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Len(t, results, 4)
+
+	expected := []ModuleDrift{
+		{Path: "/stage/app", Status: DriftError, Error: "exit status 1"},
+		{Path: "/stage/dns", Status: DriftClean},
+		{Path: "/stage/mysql", Status: DriftDrifted},
+		{Path: "/stage/vpc", Status: DriftClean},
+	}
+	if assert.NotNil(t, report) {
+		assert.Equal(t, expected, report.Modules)
+	}
+	assert.Equal(t, "Module /stage/mysql has drifted:\nPlan output of /stage/mysql\n", errWriter.String())
+}
+
+func TestDriftReportString(t *testing.T) {
+	t.Parallel()
+
+	report := &DriftReport{Modules: []ModuleDrift{
+		{Path: "/stage/app", Status: DriftError, Error: "exit status 1\nmore details"},
+		{Path: "/stage/vpc", Status: DriftClean},
+		{Path: "/stage/mysql", Status: DriftDrifted},
+		{Path: "/stage/dns", Status: DriftSkipped},
+	}}
+
+	expected := "MODULE        STATUS\n" +
+		"/stage/app    error (exit status 1)\n" +
+		"/stage/vpc    clean\n" +
+		"/stage/mysql  drifted\n" +
+		"/stage/dns    skipped\n" +
+		"1 clean, 1 drifted, 1 error, 1 skipped"
+	assert.Equal(t, expected, report.String())
+
+	actualJSON, err := (&DriftReport{Modules: report.Modules[1:3]}).JSON()
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	expectedJSON := `{
+  "modules": [
+    {
+      "path": "/stage/vpc",
+      "status": "clean"
+    },
+    {
+      "path": "/stage/mysql",
+      "status": "drifted"
+    }
+  ]
+}`
+	assert.Equal(t, expectedJSON, actualJSON)
+}
+
+func TestDriftReportErr(t *testing.T) {
+	t.Parallel()
+
+	clean := &DriftReport{Modules: []ModuleDrift{{Path: "a", Status: DriftClean}, {Path: "b", Status: DriftSkipped}}}
+	assert.Nil(t, clean.Err())
+
+	drifted := &DriftReport{Modules: []ModuleDrift{{Path: "a", Status: DriftClean}, {Path: "b", Status: DriftDrifted}}}
+	_, isDriftDetectedErr := errors.Unwrap(drifted.Err()).(DriftDetected)
+	assert.True(t, isDriftDetectedErr, "Expected a DriftDetected error but got: %v", drifted.Err())
+	exitCode, err := errors.Unwrap(drifted.Err()).(errors.IErrorCode).ExitStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, exitCode)
+
+	failed := &DriftReport{Modules: []ModuleDrift{{Path: "a", Status: DriftError}, {Path: "b", Status: DriftDrifted}}}
+	_, isDriftCheckFailedErr := errors.Unwrap(failed.Err()).(DriftCheckFailed)
+	assert.True(t, isDriftCheckFailedErr, "Expected a DriftCheckFailed error but got: %v", failed.Err())
+	exitCode, err = errors.Unwrap(failed.Err()).(errors.IErrorCode).ExitStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, exitCode)
+}
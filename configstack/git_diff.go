@@ -0,0 +1,173 @@
+package configstack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Mark all the modules in this stack that are not affected by the changes between the given git ref and the working
+// tree as already applied, so the xxx-all commands skip them. See findAffectedModules for what counts as affected.
+func (stack *Stack) SkipModulesUnchangedSince(ref string, terragruntOptions *options.TerragruntOptions) error {
+	changedFiles, err := getFilesChangedSince(ref, terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	affectedModules, err := findAffectedModules(stack.Modules, changedFiles)
+	if err != nil {
+		return err
+	}
+
+	for _, module := range stack.Modules {
+		if !affectedModules[module.Path] {
+			terragruntOptions.Logger.Printf("Module %s has not changed since %s, so it will be skipped", module.Path, ref)
+			module.AssumeAlreadyApplied = true
+		}
+	}
+
+	return nil
+}
+
+// Return the canonical paths of all the files in the git repo of the working dir that differ between the given git ref
+// and the working tree, including untracked files that aren't ignored
+func getFilesChangedSince(ref string, terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	repoRoot, err := runGitCommand(ref, terragruntOptions, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+
+	changedFiles, err := runGitCommand(ref, terragruntOptions, "diff", "--name-only", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	untrackedFiles, err := runGitCommand(ref, terragruntOptions, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+
+	canonicalPaths := []string{}
+	for _, file := range append(strings.Split(changedFiles, "\n"), strings.Split(untrackedFiles, "\n")...) {
+		if file == "" {
+			continue
+		}
+
+		canonicalPath, err := util.CanonicalPath(file, repoRoot)
+		if err != nil {
+			return nil, err
+		}
+		canonicalPaths = append(canonicalPaths, canonicalPath)
+	}
+
+	return canonicalPaths, nil
+}
+
+// Run git with the given args in the working dir and return its output, with leading and trailing whitespace removed
+func runGitCommand(ref string, terragruntOptions *options.TerragruntOptions, args ...string) (string, error) {
+	gitOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	gitOptions.WorkingDir = terragruntOptions.WorkingDir
+
+	output, err := shell.RunShellCommandAndCaptureOutput(gitOptions, "git", args...)
+	if err != nil {
+		return "", errors.WithStackTrace(GitDiffFailed{Ref: ref, Underlying: err})
+	}
+
+	return strings.TrimSpace(output), nil
+}
+
+// Return the set of paths of the given modules that are affected by changes to the given files. A module is affected
+// if:
+//
+// 1. Any of the changed files are in the module's folder.
+// 2. Any of the changed files are in the folder of the module's Terraform source, if it's a local source.
+// 3. The module depends on another module that is affected.
+func findAffectedModules(modules []*TerraformModule, changedFiles []string) (map[string]bool, error) {
+	affectedModules := map[string]bool{}
+
+	for _, module := range modules {
+		moduleFolders := []string{module.Path}
+
+		localSource, err := getLocalTerraformSourcePath(module)
+		if err != nil {
+			return nil, err
+		}
+		if localSource != "" {
+			moduleFolders = append(moduleFolders, localSource)
+		}
+
+		if anyFileInFolders(changedFiles, moduleFolders) {
+			affectedModules[module.Path] = true
+		}
+	}
+
+	// Keep adding the modules that depend on affected modules until there are no more to add
+	for addedModule := true; addedModule; {
+		addedModule = false
+		for _, module := range modules {
+			if affectedModules[module.Path] {
+				continue
+			}
+			for _, dependency := range module.Dependencies {
+				if affectedModules[dependency.Path] {
+					affectedModules[module.Path] = true
+					addedModule = true
+					break
+				}
+			}
+		}
+	}
+
+	return affectedModules, nil
+}
+
+// If the given module uses a Terraform source on the local file system, return the canonical path of the folder that
+// Terragrunt copies when it downloads that source (i.e. the part of the source before the double slash, if any).
+// Otherwise, return an empty string.
+func getLocalTerraformSourcePath(module *TerraformModule) (string, error) {
+	if module.Config.Terraform == nil {
+		return "", nil
+	}
+
+	source := module.Config.Terraform.Source
+	if !strings.HasPrefix(source, "/") && !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+		return "", nil
+	}
+
+	if index := strings.Index(source[1:], "//"); index >= 0 {
+		source = source[:index+1]
+	}
+
+	return util.CanonicalPath(source, module.Path)
+}
+
+// Returns true if any of the given files are in any of the given folders (or their subfolders)
+func anyFileInFolders(files []string, folders []string) bool {
+	for _, file := range files {
+		for _, folder := range folders {
+			if file == folder || strings.HasPrefix(file, folder+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Custom error types
+
+type GitDiffFailed struct {
+	Ref        string
+	Underlying error
+}
+
+func (err GitDiffFailed) Error() string {
+	return fmt.Sprintf("Unable to determine which modules changed since git ref %s: %v", err.Ref, err.Underlying)
+}
+
+func (err GitDiffFailed) Unwrap() error {
+	return err.Underlying
+}
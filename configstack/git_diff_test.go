@@ -0,0 +1,63 @@
+package configstack
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAffectedModules(t *testing.T) {
+	t.Parallel()
+
+	vpc := &TerraformModule{Path: "/infra/live/vpc", Config: config.TerragruntConfig{Terraform: &config.TerraformConfig{Source: "../../modules//vpc"}}}
+	mysql := &TerraformModule{Path: "/infra/live/mysql", Dependencies: []*TerraformModule{vpc}, Config: config.TerragruntConfig{Terraform: &config.TerraformConfig{Source: "git::git@github.com:foo/modules.git//mysql?ref=v0.0.1"}}}
+	app := &TerraformModule{Path: "/infra/live/app", Dependencies: []*TerraformModule{mysql}}
+	redis := &TerraformModule{Path: "/infra/live/redis"}
+	modules := []*TerraformModule{vpc, mysql, app, redis}
+
+	testCases := []struct {
+		changedFiles []string
+		expected     map[string]bool
+	}{
+		{[]string{}, map[string]bool{}},
+		{[]string{"/infra/README.md"}, map[string]bool{}},
+		{[]string{"/infra/live/redis/terraform.tfvars"}, map[string]bool{"/infra/live/redis": true}},
+		{[]string{"/infra/live/redis-other/terraform.tfvars"}, map[string]bool{}},
+		{[]string{"/infra/live/app/main.tf"}, map[string]bool{"/infra/live/app": true}},
+		{[]string{"/infra/live/mysql/terraform.tfvars"}, map[string]bool{"/infra/live/mysql": true, "/infra/live/app": true}},
+		{[]string{"/infra/modules/vpc/main.tf"}, map[string]bool{"/infra/live/vpc": true, "/infra/live/mysql": true, "/infra/live/app": true}},
+		{[]string{"/infra/modules/mysql/main.tf"}, map[string]bool{"/infra/live/vpc": true, "/infra/live/mysql": true, "/infra/live/app": true}},
+		{[]string{"/infra/live/redis/main.tf", "/infra/live/app/main.tf"}, map[string]bool{"/infra/live/redis": true, "/infra/live/app": true}},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := findAffectedModules(modules, testCase.changedFiles)
+		assert.Nil(t, err, "Unexpected error for changed files %v: %v", testCase.changedFiles, err)
+		assert.Equal(t, testCase.expected, actual, "For changed files %v", testCase.changedFiles)
+	}
+}
+
+func TestGetLocalTerraformSourcePath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{"", ""},
+		{"git::git@github.com:foo/modules.git//vpc?ref=v0.0.1", ""},
+		{"github.com/foo/modules//vpc", ""},
+		{"../../modules//vpc", "/infra/modules"},
+		{"../../modules/vpc", "/infra/modules/vpc"},
+		{"./modules", "/infra/live/vpc/modules"},
+		{"/opt/modules//vpc", "/opt/modules"},
+	}
+
+	for _, testCase := range testCases {
+		module := &TerraformModule{Path: "/infra/live/vpc", Config: config.TerragruntConfig{Terraform: &config.TerraformConfig{Source: testCase.source}}}
+		actual, err := getLocalTerraformSourcePath(module)
+		assert.Nil(t, err, "Unexpected error for source %s: %v", testCase.source, err)
+		assert.Equal(t, testCase.expected, actual, "For source %s", testCase.source)
+	}
+}
@@ -0,0 +1,122 @@
+package configstack
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The name the results of importing resources into a stack are saved under for --terragrunt-resume
+const IMPORT_RUN_NAME = "import"
+
+// A resource to import into the state of a module of a stack, such as an existing VPC to import as aws_vpc.main
+type ImportMapping struct {
+	// The path of the module folder, relative to the working dir
+	ModulePath string
+
+	// The address of the resource in the module, such as aws_vpc.main or module.subnets.aws_subnet.private[0]
+	Address string
+
+	// The ID of the existing resource, in the format the resource type expects, such as vpc-0a1b2c3d
+	Id string
+}
+
+// Import the given existing resources into the state of the modules of this stack with terraform import, one resource
+// at a time, and return the result of each module. Each import runs with the RunTerragrunt command of its module, so
+// Terragrunt runs init, configures the remote state, assumes the IAM role, and downloads the source first, just like
+// for any other command. The given extra args (e.g. -var-file) are passed to each terraform import. The resources
+// already in the state of a module are skipped, so a partial import can be run again. The modules without any
+// resources to import are skipped, and a module that fails doesn't stop the others, as imports don't depend on each
+// other. Returns an error if a mapping is for a module that isn't in the stack.
+func (stack *Stack) Import(mappings []ImportMapping, extraArgs []string, terragruntOptions *options.TerragruntOptions) ([]ModuleResult, error) {
+	mappingsByModule := map[*TerraformModule][]ImportMapping{}
+	for _, mapping := range mappings {
+		module, err := stack.findModuleByPath(mapping.ModulePath, terragruntOptions)
+		if err != nil {
+			return nil, err
+		}
+		mappingsByModule[module] = append(mappingsByModule[module], mapping)
+	}
+
+	for _, module := range stack.Modules {
+		moduleMappings, hasMappings := mappingsByModule[module]
+		if !hasMappings {
+			terragruntOptions.Logger.Printf("Module %s has no resources to import, so it will be skipped", module.Path)
+			module.AssumeAlreadyApplied = true
+			continue
+		}
+
+		module.TerragruntOptions.RunTerragrunt = importResources(module.Path, moduleMappings, module.TerragruntOptions.RunTerragrunt)
+		module.TerragruntOptions.IgnoreDependencyErrors = true
+	}
+
+	return stack.run(append([]string{"import"}, extraArgs...), IMPORT_RUN_NAME, NormalOrder)
+}
+
+// Return a RunTerragrunt command for the module at the given path that imports the given resources with the given
+// RunTerragrunt command, one terraform import at a time, skipping the resources that are already in the state
+func importResources(modulePath string, mappings []ImportMapping, runTerragrunt func(*options.TerragruntOptions) error) func(*options.TerragruntOptions) error {
+	sort.Sort(importMappingsByAddress(mappings))
+
+	return func(moduleOptions *options.TerragruntOptions) error {
+		// Each Terragrunt run may change the options it gets (e.g. the working dir, if it downloads the source), so
+		// every command gets a fresh copy of the options of the module
+		baseOptions := moduleOptions.Clone(moduleOptions.TerragruntConfigPath)
+		baseOptions.RunTerragrunt = runTerragrunt
+
+		existingAddresses, err := listStateAddresses(modulePath, baseOptions)
+		if err != nil {
+			return err
+		}
+
+		for _, mapping := range mappings {
+			if util.ListContainsElement(existingAddresses, mapping.Address) {
+				moduleOptions.Logger.Printf("%s is already in the state, so it will not be imported again", mapping.Address)
+				continue
+			}
+
+			moduleOptions.Logger.Printf("Importing %s as %s", mapping.Id, mapping.Address)
+			importOptions := baseOptions.Clone(baseOptions.TerragruntConfigPath)
+			importOptions.AppendTerraformCliArgs(mapping.Address, mapping.Id)
+			if err := importOptions.RunTerragrunt(importOptions); err != nil {
+				return errors.WithStackTrace(ImportFailed{ModulePath: modulePath, Address: mapping.Address, Id: mapping.Id, Underlying: err})
+			}
+		}
+
+		return nil
+	}
+}
+
+type importMappingsByAddress []ImportMapping
+
+func (mappings importMappingsByAddress) Len() int {
+	return len(mappings)
+}
+
+func (mappings importMappingsByAddress) Swap(i, j int) {
+	mappings[i], mappings[j] = mappings[j], mappings[i]
+}
+
+func (mappings importMappingsByAddress) Less(i, j int) bool {
+	return mappings[i].Address < mappings[j].Address
+}
+
+// Custom error types
+
+type ImportFailed struct {
+	ModulePath string
+	Address    string
+	Id         string
+	Underlying error
+}
+
+func (err ImportFailed) Error() string {
+	return fmt.Sprintf("Could not import %s as %s in module %s: %v", err.Id, err.Address, err.ModulePath, err.Underlying)
+}
+
+func (err ImportFailed) Unwrap() error {
+	return err.Underlying
+}
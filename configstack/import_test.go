@@ -0,0 +1,78 @@
+package configstack
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackImport(t *testing.T) {
+	t.Parallel()
+
+	stackPath := createTempFolder(t)
+	defer os.RemoveAll(stackPath)
+
+	var lock sync.Mutex
+	commands := map[string][]string{}
+
+	stack := &Stack{Path: stackPath}
+	for _, path := range []string{"/stage/vpc", "/stage/app", "/stage/dns"} {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		terragruntOptions.RunTerragrunt = func(moduleOptions *options.TerragruntOptions) error {
+			lock.Lock()
+			defer lock.Unlock()
+
+			command := strings.Join(moduleOptions.TerraformCliArgs, " ")
+			commands[moduleOptions.TerragruntConfigPath] = append(commands[moduleOptions.TerragruntConfigPath], command)
+			if command == "state list" && moduleOptions.TerragruntConfigPath == "/stage/vpc" {
+				moduleOptions.Writer.Write([]byte("aws_vpc.main\n"))
+			}
+			return nil
+		}
+		stack.Modules = append(stack.Modules, &TerraformModule{Path: path, Dependencies: []*TerraformModule{}, TerragruntOptions: terragruntOptions})
+	}
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("import_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mappings := []ImportMapping{
+		{ModulePath: "/stage/vpc", Address: "aws_vpc.main", Id: "vpc-123"},
+		{ModulePath: "/stage/vpc", Address: "aws_subnet.private[0]", Id: "subnet-123"},
+		{ModulePath: "/stage/app", Address: "aws_instance.web", Id: "i-123"},
+	}
+
+	results, err := stack.Import(mappings, []string{"-var-file=prod.tfvars"}, terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Len(t, results, 3)
+
+	expected := map[string][]string{
+		"/stage/vpc": {"state list", "import -var-file=prod.tfvars aws_subnet.private[0] subnet-123"},
+		"/stage/app": {"state list", "import -var-file=prod.tfvars aws_instance.web i-123"},
+	}
+	assert.Equal(t, expected, commands)
+	assert.True(t, stack.Modules[2].AssumeAlreadyApplied)
+}
+
+func TestStackImportModuleNotInStack(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("/stage/vpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stack := &Stack{Path: "/stage", Modules: []*TerraformModule{{Path: "/stage/vpc", TerragruntOptions: terragruntOptions}}}
+
+	_, err = stack.Import([]ImportMapping{{ModulePath: "/stage/db", Address: "aws_db_instance.main", Id: "db"}}, []string{}, terragruntOptions)
+	_, isModuleNotInStackErr := errors.Unwrap(err).(ModuleNotInStack)
+	assert.True(t, isModuleNotInStackErr, "Expected a ModuleNotInStack error but got: %v", err)
+}
@@ -0,0 +1,62 @@
+package configstack
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Copy everything Terraform writes to stdout and stderr for each module in this stack that is going to run into a
+// <module path>.log file in the given folder, on top of writing it to the usual place. Returns a function that closes
+// all the log files, which should be called once the modules are done running.
+func (stack *Stack) teeOutputToLogFiles(logDir string) (func(), error) {
+	logFiles := []*os.File{}
+	closeLogFiles := func() {
+		for _, logFile := range logFiles {
+			logFile.Close()
+		}
+	}
+
+	for _, module := range stack.Modules {
+		if module.AssumeAlreadyApplied {
+			continue
+		}
+
+		logFilePath := util.JoinPath(logDir, stack.logFileName(module))
+		if err := os.MkdirAll(filepath.Dir(logFilePath), 0755); err != nil {
+			closeLogFiles()
+			return nil, errors.WithStackTrace(err)
+		}
+
+		logFile, err := os.Create(logFilePath)
+		if err != nil {
+			closeLogFiles()
+			return nil, errors.WithStackTrace(err)
+		}
+		logFiles = append(logFiles, logFile)
+
+		module.TerragruntOptions.Logger.Printf("Writing the output of module %s to %s", module.Path, logFilePath)
+		module.TerragruntOptions.Writer = io.MultiWriter(module.TerragruntOptions.Writer, logFile)
+		module.TerragruntOptions.ErrWriter = io.MultiWriter(module.TerragruntOptions.ErrWriter, logFile)
+	}
+
+	return closeLogFiles, nil
+}
+
+// Return the name of the log file for the given module: the path of the module relative to the stack, plus .log. For
+// example, the log file of the module in /stage/vpc of the stack in /stage is vpc.log. Modules outside of the stack's
+// folder, such as external dependencies, use their full path instead.
+func (stack *Stack) logFileName(module *TerraformModule) string {
+	relPath, err := filepath.Rel(stack.Path, module.Path)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		relPath = strings.Replace(filepath.ToSlash(module.Path), ":", "", -1)
+	} else if relPath == "." {
+		relPath = filepath.Base(module.Path)
+	}
+
+	return strings.TrimPrefix(filepath.ToSlash(relPath), "/") + ".log"
+}
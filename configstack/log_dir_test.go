@@ -0,0 +1,68 @@
+package configstack
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogFileName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		stackPath  string
+		modulePath string
+		expected   string
+	}{
+		{"/stage", "/stage/vpc", "vpc.log"},
+		{"/stage", "/stage/data/mysql", "data/mysql.log"},
+		{"/stage", "/stage", "stage.log"},
+		{"/stage", "/prod/vpc", "prod/vpc.log"},
+	}
+
+	for _, testCase := range testCases {
+		stack := &Stack{Path: testCase.stackPath}
+		actual := stack.logFileName(&TerraformModule{Path: testCase.modulePath})
+		assert.Equal(t, testCase.expected, actual, "For module %s in stack %s", testCase.modulePath, testCase.stackPath)
+	}
+}
+
+func TestTeeOutputToLogFiles(t *testing.T) {
+	t.Parallel()
+
+	logDir := createTempFolder(t)
+	defer os.RemoveAll(logDir)
+
+	stack := createResumeTestStack(t, "/stage", "/stage/vpc", "/stage/data/mysql", "/stage/redis")
+	stack.Modules[2].AssumeAlreadyApplied = true
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	for _, module := range stack.Modules {
+		module.TerragruntOptions.Writer = stdout
+		module.TerragruntOptions.ErrWriter = stderr
+	}
+
+	closeLogFiles, err := stack.teeOutputToLogFiles(logDir)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	for _, module := range stack.Modules {
+		fmt.Fprintf(module.TerragruntOptions.Writer, "stdout of %s\n", module.Path)
+		fmt.Fprintf(module.TerragruntOptions.ErrWriter, "stderr of %s\n", module.Path)
+	}
+	closeLogFiles()
+
+	for _, name := range []string{"vpc", "data/mysql"} {
+		contents, err := util.ReadFileAsString(util.JoinPath(logDir, name+".log"))
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, fmt.Sprintf("stdout of /stage/%s\nstderr of /stage/%s\n", name, name), contents)
+	}
+
+	assert.False(t, util.FileExists(util.JoinPath(logDir, "redis.log")), "Expected no log file for a module that doesn't run")
+	assert.Contains(t, stdout.String(), "stdout of /stage/vpc")
+	assert.Contains(t, stderr.String(), "stderr of /stage/vpc")
+}
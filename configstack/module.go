@@ -6,6 +6,7 @@ import (
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
 	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/telemetry"
 	"github.com/gruntwork-io/terragrunt/util"
 	"path/filepath"
 	"regexp"
@@ -58,12 +59,19 @@ func ResolveTerraformModules(terragruntConfigPaths []string, terragruntOptions *
 // into a TerraformModule struct. Note that this method will NOT fill in the Dependencies field of the TerraformModule
 // struct (see the crosslinkDependencies method for that). Return a map from module path to TerraformModule struct.
 func resolveModules(canonicalTerragruntConfigPaths []string, terragruntOptions *options.TerragruntOptions, howTheseModulesWereFound string) (map[string]*TerraformModule, error) {
+	// Parsing a config is mostly reading files and waiting on the helper functions in it, so parse several at once.
+	// The results are collected by index, so the error reported is always that of the first config that failed.
+	modules := make([]*TerraformModule, len(canonicalTerragruntConfigPaths))
+	errs := make([]error, len(canonicalTerragruntConfigPaths))
+	util.RunInParallel(len(canonicalTerragruntConfigPaths), config.DiscoveryParallelism, func(index int) {
+		modules[index], errs[index] = resolveTerraformModule(canonicalTerragruntConfigPaths[index], terragruntOptions, howTheseModulesWereFound)
+	})
+
 	moduleMap := map[string]*TerraformModule{}
 
-	for _, terragruntConfigPath := range canonicalTerragruntConfigPaths {
-		module, err := resolveTerraformModule(terragruntConfigPath, terragruntOptions, howTheseModulesWereFound)
-		if err != nil {
-			return moduleMap, err
+	for index, module := range modules {
+		if errs[index] != nil {
+			return moduleMap, errs[index]
 		}
 		if module != nil {
 			moduleMap[module.Path] = module
@@ -83,7 +91,11 @@ func resolveTerraformModule(terragruntConfigPath string, terragruntOptions *opti
 	}
 
 	opts := terragruntOptions.Clone(terragruntConfigPath)
-	terragruntConfig, err := config.ParseConfigFile(terragruntConfigPath, opts, nil)
+	var terragruntConfig *config.TerragruntConfig
+	err = telemetry.Trace(opts, "parse config", map[string]string{"path": terragruntConfigPath}, func() (err error) {
+		terragruntConfig, err = config.ParseConfigFile(terragruntConfigPath, opts, nil)
+		return err
+	})
 	if err != nil {
 		return nil, errors.WithStackTrace(ErrorProcessingModule{UnderlyingError: err, HowThisModuleWasFound: howThisModuleWasFound, ModulePath: terragruntConfigPath})
 	}
@@ -303,6 +315,10 @@ func (err ErrorProcessingModule) Error() string {
 	return fmt.Sprintf("Error processing module at '%s'. How this module was found: %s. Underlying error: %v", err.ModulePath, err.HowThisModuleWasFound, err.UnderlyingError)
 }
 
+func (err ErrorProcessingModule) Unwrap() error {
+	return err.UnderlyingError
+}
+
 type InvalidSourceUrl struct {
 	ModulePath       string
 	ModuleSourceUrl  string
@@ -0,0 +1,132 @@
+package configstack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Mark all the modules in this stack whose config doesn't include one of the given config files as already applied, so
+// the xxx-all commands skip them. The paths are relative to the working dir. Unless strictInclude is set, the modules
+// that the selected modules depend on aren't skipped either, so they are still applied first.
+func (stack *Stack) SkipModulesThatDontInclude(includePaths []string, strictInclude bool, terragruntOptions *options.TerragruntOptions) error {
+	canonicalIncludePaths, err := util.CanonicalPaths(includePaths, terragruntOptions.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	selectedModules := map[string]bool{}
+	for _, module := range stack.Modules {
+		if module.Config.IncludedConfigPath != "" && util.ListContainsElement(canonicalIncludePaths, module.Config.IncludedConfigPath) {
+			selectedModules[module.Path] = true
+		}
+	}
+
+	if !strictInclude {
+		for _, module := range stack.Modules {
+			if selectedModules[module.Path] {
+				selectDependencies(module, selectedModules)
+			}
+		}
+	}
+
+	for _, module := range stack.Modules {
+		if !selectedModules[module.Path] {
+			terragruntOptions.Logger.Printf("Module %s does not include any of %v, so it will be skipped", module.Path, includePaths)
+			module.AssumeAlreadyApplied = true
+		}
+	}
+
+	return nil
+}
+
+// Mark all the modules in this stack whose config sets enabled = false as already applied, so the xxx-all commands skip
+// them. The modules that depend on a disabled module still run, as if it had been applied.
+func (stack *Stack) SkipDisabledModules(terragruntOptions *options.TerragruntOptions) {
+	for _, module := range stack.Modules {
+		if !module.Config.IsEnabled() {
+			terragruntOptions.Logger.Printf("Module %s is disabled by the enabled setting of its config, so it will be skipped", module.Path)
+			module.AssumeAlreadyApplied = true
+		}
+	}
+}
+
+// Add all the modules the given module depends on, directly or indirectly, to the given set of selected modules
+func selectDependencies(module *TerraformModule, selectedModules map[string]bool) {
+	for _, dependency := range module.Dependencies {
+		if !selectedModules[dependency.Path] {
+			selectedModules[dependency.Path] = true
+			selectDependencies(dependency, selectedModules)
+		}
+	}
+}
+
+// Add the given dependencies, each of the form <module>=<dependency>, where both are paths of module folders relative
+// to the working dir, to the dependencies the modules in this stack declare in their configs. This makes it possible to
+// adjust the order of a one-off xxx-all run without editing any configs.
+func (stack *Stack) AddExtraDependencies(extraDependencies []string, terragruntOptions *options.TerragruntOptions) error {
+	for _, extraDependency := range extraDependencies {
+		parts := strings.SplitN(extraDependency, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return errors.WithStackTrace(InvalidExtraDependency(extraDependency))
+		}
+
+		module, err := stack.findModuleByPath(strings.TrimSpace(parts[0]), terragruntOptions)
+		if err != nil {
+			return err
+		}
+		dependency, err := stack.findModuleByPath(strings.TrimSpace(parts[1]), terragruntOptions)
+		if err != nil {
+			return err
+		}
+
+		if !moduleListContains(module.Dependencies, dependency) {
+			terragruntOptions.Logger.Printf("Adding %s as a dependency of %s", dependency.Path, module.Path)
+			module.Dependencies = append(module.Dependencies, dependency)
+		}
+	}
+
+	return nil
+}
+
+// Return the module in this stack at the given path, relative to the working dir
+func (stack *Stack) findModuleByPath(path string, terragruntOptions *options.TerragruntOptions) (*TerraformModule, error) {
+	canonicalPath, err := util.CanonicalPath(path, terragruntOptions.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, module := range stack.Modules {
+		if module.Path == canonicalPath {
+			return module, nil
+		}
+	}
+
+	return nil, errors.WithStackTrace(ModuleNotInStack(path))
+}
+
+func moduleListContains(modules []*TerraformModule, module *TerraformModule) bool {
+	for _, existing := range modules {
+		if existing == module {
+			return true
+		}
+	}
+	return false
+}
+
+// Custom error types
+
+type InvalidExtraDependency string
+
+func (dependency InvalidExtraDependency) Error() string {
+	return fmt.Sprintf("Invalid extra dependency '%s'. Extra dependencies must have the form <module>=<dependency>, e.g. app=vpc.", string(dependency))
+}
+
+type ModuleNotInStack string
+
+func (path ModuleNotInStack) Error() string {
+	return fmt.Sprintf("Could not find a module at %s in the stack", string(path))
+}
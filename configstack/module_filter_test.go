@@ -0,0 +1,110 @@
+package configstack
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func createModuleFilterTestOptions(t *testing.T) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("/infra/live/" + config.DefaultTerragruntConfigPath)
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.WorkingDir = "/infra/live"
+	return terragruntOptions
+}
+
+func createModuleFilterTestStack() *Stack {
+	vpc := &TerraformModule{Path: "/infra/live/vpc"}
+	mysql := &TerraformModule{Path: "/infra/live/stage/mysql", Dependencies: []*TerraformModule{vpc}, Config: config.TerragruntConfig{IncludedConfigPath: "/infra/live/stage/terraform.tfvars"}}
+	app := &TerraformModule{Path: "/infra/live/stage/app", Dependencies: []*TerraformModule{mysql}, Config: config.TerragruntConfig{IncludedConfigPath: "/infra/live/stage/terraform.tfvars"}}
+	redis := &TerraformModule{Path: "/infra/live/prod/redis", Config: config.TerragruntConfig{IncludedConfigPath: "/infra/live/prod/terraform.tfvars"}}
+	return &Stack{Path: "/infra/live", Modules: []*TerraformModule{vpc, mysql, app, redis}}
+}
+
+func getModulesNotSkipped(stack *Stack) map[string]bool {
+	notSkipped := map[string]bool{}
+	for _, module := range stack.Modules {
+		if !module.AssumeAlreadyApplied {
+			notSkipped[module.Path] = true
+		}
+	}
+	return notSkipped
+}
+
+func TestSkipModulesThatDontInclude(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		includePaths  []string
+		strictInclude bool
+		expected      map[string]bool
+	}{
+		{[]string{"stage/terraform.tfvars"}, false, map[string]bool{"/infra/live/vpc": true, "/infra/live/stage/mysql": true, "/infra/live/stage/app": true}},
+		{[]string{"stage/terraform.tfvars"}, true, map[string]bool{"/infra/live/stage/mysql": true, "/infra/live/stage/app": true}},
+		{[]string{"/infra/live/prod/terraform.tfvars"}, false, map[string]bool{"/infra/live/prod/redis": true}},
+		{[]string{"stage/terraform.tfvars", "prod/terraform.tfvars"}, true, map[string]bool{"/infra/live/stage/mysql": true, "/infra/live/stage/app": true, "/infra/live/prod/redis": true}},
+		{[]string{"dev/terraform.tfvars"}, false, map[string]bool{}},
+	}
+
+	for _, testCase := range testCases {
+		stack := createModuleFilterTestStack()
+		err := stack.SkipModulesThatDontInclude(testCase.includePaths, testCase.strictInclude, createModuleFilterTestOptions(t))
+		assert.Nil(t, err, "Unexpected error for %v: %v", testCase.includePaths, err)
+		assert.Equal(t, testCase.expected, getModulesNotSkipped(stack), "For include paths %v and strict include %t", testCase.includePaths, testCase.strictInclude)
+	}
+}
+
+func TestSkipDisabledModules(t *testing.T) {
+	t.Parallel()
+
+	disabled := false
+	enabled := true
+
+	stack := createModuleFilterTestStack()
+	stack.Modules[1].Config.Enabled = &disabled
+	stack.Modules[3].Config.Enabled = &enabled
+
+	stack.SkipDisabledModules(createModuleFilterTestOptions(t))
+	assert.Equal(t, map[string]bool{"/infra/live/vpc": true, "/infra/live/stage/app": true, "/infra/live/prod/redis": true}, getModulesNotSkipped(stack))
+}
+
+func TestAddExtraDependencies(t *testing.T) {
+	t.Parallel()
+
+	stack := createModuleFilterTestStack()
+	err := stack.AddExtraDependencies([]string{"prod/redis=stage/app", "stage/app=vpc", "prod/redis = /infra/live/vpc"}, createModuleFilterTestOptions(t))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	vpc, mysql, app, redis := stack.Modules[0], stack.Modules[1], stack.Modules[2], stack.Modules[3]
+	assert.Equal(t, []*TerraformModule{mysql, vpc}, app.Dependencies)
+	assert.Equal(t, []*TerraformModule{app, vpc}, redis.Dependencies)
+	assert.Nil(t, stack.CheckForCycles())
+}
+
+func TestAddExtraDependenciesCycle(t *testing.T) {
+	t.Parallel()
+
+	stack := createModuleFilterTestStack()
+	err := stack.AddExtraDependencies([]string{"vpc=stage/app"}, createModuleFilterTestOptions(t))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	_, isCycleErr := errors.Unwrap(stack.CheckForCycles()).(DependencyCycle)
+	assert.True(t, isCycleErr, "Expected a DependencyCycle error")
+}
+
+func TestAddExtraDependenciesInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, extraDependency := range []string{"vpc", "vpc=", "=vpc"} {
+		err := createModuleFilterTestStack().AddExtraDependencies([]string{extraDependency}, createModuleFilterTestOptions(t))
+		_, isInvalidErr := errors.Unwrap(err).(InvalidExtraDependency)
+		assert.True(t, isInvalidErr, "Expected an InvalidExtraDependency error for %s but got: %v", extraDependency, err)
+	}
+
+	err := createModuleFilterTestStack().AddExtraDependencies([]string{"stage/app=stage/redis"}, createModuleFilterTestOptions(t))
+	_, isNotInStackErr := errors.Unwrap(err).(ModuleNotInStack)
+	assert.True(t, isNotInStackErr, "Expected a ModuleNotInStack error but got: %v", err)
+}
@@ -0,0 +1,123 @@
+package configstack
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// How often to log the progress of the xxx-all commands while modules are running, so there's a sign of life even if
+// no module finishes for a long time
+const PROGRESS_LOG_INTERVAL = 30 * time.Second
+
+// Keeps track of how many modules are waiting, running, and finished during the xxx-all commands and periodically logs
+// a status line, so it's possible to tell how far along a run of a large stack is. The runningModules update it from
+// different goroutines, so all access goes through the mutex.
+type progressTracker struct {
+	Total     int
+	Running   int
+	Completed int
+	Failed    int
+	StartTime time.Time
+	Logger    *log.Logger
+	mutex     sync.Mutex
+	done      chan bool
+}
+
+// Create a progressTracker for the given modules, or return nil if the user disabled progress reporting. All the
+// methods of progressTracker are no-ops on nil.
+func newProgressTracker(modules map[string]*runningModule) *progressTracker {
+	terragruntOptions := sharedRunningModuleOptions(modules)
+	if terragruntOptions == nil || terragruntOptions.NoProgress {
+		return nil
+	}
+	return &progressTracker{
+		Total:     len(modules),
+		StartTime: time.Now(),
+		Logger:    util.CreateLoggerWithWriter(terragruntOptions.LogWriter(), ""),
+		done:      make(chan bool),
+	}
+}
+
+// Start logging the progress every PROGRESS_LOG_INTERVAL in the background until Stop is called
+func (tracker *progressTracker) Start() {
+	if tracker == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(PROGRESS_LOG_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				tracker.logProgress()
+			case <-tracker.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop the background logging and log the final progress
+func (tracker *progressTracker) Stop() {
+	if tracker == nil {
+		return
+	}
+
+	close(tracker.done)
+	tracker.logProgress()
+}
+
+// Record that a module started running
+func (tracker *progressTracker) ModuleStarted() {
+	if tracker == nil {
+		return
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	tracker.Running++
+}
+
+// Record that a module finished, either after running or because it could not run (e.g. a dependency failed), and log
+// the progress
+func (tracker *progressTracker) ModuleFinished(wasRunning bool, err error) {
+	if tracker == nil {
+		return
+	}
+
+	tracker.mutex.Lock()
+	if wasRunning {
+		tracker.Running--
+	}
+	if err == nil {
+		tracker.Completed++
+	} else {
+		tracker.Failed++
+	}
+	tracker.mutex.Unlock()
+
+	tracker.logProgress()
+}
+
+func (tracker *progressTracker) logProgress() {
+	tracker.Logger.Print(tracker.String())
+}
+
+// Return the status line with the current progress, e.g. "Progress: 12/80 modules done (10 completed, 2 failed), 4
+// running, 64 pending, 3m20s elapsed"
+func (tracker *progressTracker) String() string {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	pending := tracker.Total - tracker.Running - tracker.Completed - tracker.Failed
+	elapsed := time.Since(tracker.StartTime) / time.Second * time.Second
+
+	return fmt.Sprintf("Progress: %d/%d modules done (%d completed, %d failed), %d running, %d pending, %v elapsed", tracker.Completed+tracker.Failed, tracker.Total, tracker.Completed, tracker.Failed, tracker.Running, pending, elapsed)
+}
@@ -0,0 +1,95 @@
+package configstack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressTracker(t *testing.T) {
+	t.Parallel()
+
+	logOutput := &bytes.Buffer{}
+	tracker := &progressTracker{
+		Total:     4,
+		StartTime: time.Now().Add(-90 * time.Second),
+		Logger:    util.CreateLoggerWithWriter(logOutput, ""),
+		done:      make(chan bool),
+	}
+
+	assert.Contains(t, tracker.String(), "Progress: 0/4 modules done (0 completed, 0 failed), 0 running, 4 pending, 1m30s elapsed")
+
+	tracker.ModuleStarted()
+	tracker.ModuleStarted()
+	assert.Contains(t, tracker.String(), "Progress: 0/4 modules done (0 completed, 0 failed), 2 running, 2 pending")
+
+	tracker.ModuleFinished(true, nil)
+	assert.Contains(t, logOutput.String(), "Progress: 1/4 modules done (1 completed, 0 failed), 1 running, 2 pending")
+
+	tracker.ModuleFinished(true, fmt.Errorf("boom"))
+	tracker.ModuleFinished(false, fmt.Errorf("dependency failed"))
+	assert.Contains(t, tracker.String(), "Progress: 3/4 modules done (1 completed, 2 failed), 0 running, 1 pending")
+}
+
+func TestProgressTrackerNil(t *testing.T) {
+	t.Parallel()
+
+	var tracker *progressTracker
+
+	// All the methods must be safe to call when progress reporting is disabled
+	tracker.Start()
+	tracker.ModuleStarted()
+	tracker.ModuleFinished(true, nil)
+	tracker.Stop()
+}
+
+func TestNewProgressTracker(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("progress_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modules := map[string]*runningModule{
+		"a": newRunningModule(&TerraformModule{Path: "a", TerragruntOptions: terragruntOptions}),
+		"b": newRunningModule(&TerraformModule{Path: "b", TerragruntOptions: terragruntOptions}),
+	}
+
+	tracker := newProgressTracker(modules)
+	if assert.NotNil(t, tracker) {
+		assert.Equal(t, 2, tracker.Total)
+	}
+
+	assert.Nil(t, newProgressTracker(map[string]*runningModule{}))
+
+	noProgressOptions := terragruntOptions.Clone("progress_test")
+	noProgressOptions.NoProgress = true
+	modules["a"].Module.TerragruntOptions = noProgressOptions
+	modules["b"].Module.TerragruntOptions = noProgressOptions
+	assert.Nil(t, newProgressTracker(modules))
+}
+
+func TestRunModulesWithProgress(t *testing.T) {
+	t.Parallel()
+
+	logOutput := &bytes.Buffer{}
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("progress_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.ErrWriter = logOutput
+	terragruntOptions.RunTerragrunt = func(*options.TerragruntOptions) error { return nil }
+
+	moduleA := &TerraformModule{Path: "a", Dependencies: []*TerraformModule{}, TerragruntOptions: terragruntOptions}
+	moduleB := &TerraformModule{Path: "b", Dependencies: []*TerraformModule{moduleA}, TerragruntOptions: terragruntOptions}
+
+	err = RunModules([]*TerraformModule{moduleA, moduleB})
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Contains(t, logOutput.String(), "Progress: 2/2 modules done (2 completed, 0 failed), 0 running, 0 pending")
+}
@@ -0,0 +1,131 @@
+package configstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// At the end of every xxx-all run, the result of each module is written to a file in this folder of the download dir,
+// named after the root folder of the stack, so that a later run with --terragrunt-resume can skip the modules that
+// already succeeded. The file isn't written into the stack itself, so it never ends up in the repo of the stack.
+const RUN_RESULTS_FOLDER = "run-results"
+
+// The contents of the file with the results of a run: the Terraform command the stack ran (e.g. apply), or the name of the run (e.g.
+// drift), and the status of each module, keyed by module path
+type RunResults struct {
+	Command string            `json:"command"`
+	Modules map[string]string `json:"modules"`
+}
+
+// Return the path of the file in the given download dir with the results of the runs in the given stack folder
+func RunResultsPath(stackPath string, downloadDir string) string {
+	canonicalStackPath, err := util.CanonicalPath(stackPath, ".")
+	if err != nil {
+		canonicalStackPath = util.CleanPath(stackPath)
+	}
+	return util.JoinPath(downloadDir, RUN_RESULTS_FOLDER, util.EncodeBase64Sha1(canonicalStackPath)+".json")
+}
+
+// Read the RunResults of the previous run in the given stack folder from the given download dir. Return nil if there
+// are none.
+func ReadRunResults(stackPath string, downloadDir string) (*RunResults, error) {
+	path := RunResultsPath(stackPath, downloadDir)
+	if !util.FileExists(path) {
+		return nil, nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	runResults := &RunResults{}
+	if err := json.Unmarshal(contents, runResults); err != nil {
+		return nil, errors.WithStackTrace(InvalidRunResultsFile{Path: path, Underlying: err})
+	}
+
+	return runResults, nil
+}
+
+// Write the given results of running the given Terraform command in the given stack folder to the given download dir
+func WriteRunResults(stackPath string, downloadDir string, command string, results []ModuleResult) error {
+	runResults := RunResults{Command: command, Modules: map[string]string{}}
+	for _, result := range results {
+		runResults.Modules[result.Path] = result.Status.String()
+	}
+
+	contents, err := json.MarshalIndent(runResults, "", "  ")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	path := RunResultsPath(stackPath, downloadDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return errors.WithStackTrace(ioutil.WriteFile(path, contents, 0644))
+}
+
+// Returns true if the module at the given path finished the previous run without needing to run again: either it
+// succeeded, or it was skipped because it was assumed to be already applied. Modules that failed, that couldn't run
+// because a dependency failed, that were cancelled, or that weren't part of the previous run need to run again.
+func (runResults *RunResults) moduleDone(modulePath string) bool {
+	status := runResults.Modules[modulePath]
+	return status == ModuleSucceeded.String() || status == ModuleSkipped.String()
+}
+
+// Mark all the modules in this stack that were done at the end of the previous run of the given Terraform command as
+// already applied, so that only the modules that failed or never ran run again. Does nothing if there is no previous
+// run, or if the previous run was of a different command, as its results say nothing about this one.
+func (stack *Stack) SkipModulesDoneInPreviousRun(command string) error {
+	sharedOptions := stack.sharedOptions()
+	if sharedOptions == nil {
+		return nil
+	}
+	logger := sharedOptions.Logger
+
+	runResults, err := ReadRunResults(stack.Path, sharedOptions.DownloadDir)
+	if err != nil {
+		return err
+	}
+
+	if runResults == nil {
+		logger.Printf("Did not find the results of a previous run in %s, so running all modules", stack.Path)
+		return nil
+	}
+
+	if runResults.Command != command {
+		logger.Printf("The previous run in %s was of the command '%s', not '%s', so running all modules", stack.Path, runResults.Command, command)
+		return nil
+	}
+
+	for _, module := range stack.Modules {
+		if runResults.moduleDone(module.Path) {
+			module.TerragruntOptions.Logger.Printf("Module %s finished in the previous run of '%s', so it will be skipped", module.Path, command)
+			module.AssumeAlreadyApplied = true
+		}
+	}
+
+	return nil
+}
+
+// Custom error types
+
+type InvalidRunResultsFile struct {
+	Path       string
+	Underlying error
+}
+
+func (err InvalidRunResultsFile) Error() string {
+	return fmt.Sprintf("Could not parse the results of the previous run in %s: %v", err.Path, err.Underlying)
+}
+
+func (err InvalidRunResultsFile) Unwrap() error {
+	return err.Underlying
+}
@@ -0,0 +1,175 @@
+package configstack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAndReadRunResults(t *testing.T) {
+	t.Parallel()
+
+	stackPath := createTempFolder(t)
+	defer os.RemoveAll(stackPath)
+
+	downloadDir := resumeTestDownloadDir(stackPath)
+
+	runResults, err := ReadRunResults(stackPath, downloadDir)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Nil(t, runResults)
+
+	results := []ModuleResult{
+		{Path: "/stage/app", Status: ModuleDependencyFailed},
+		{Path: "/stage/mysql", Status: ModuleFailed, Err: fmt.Errorf("Expected error for mysql")},
+		{Path: "/stage/vpc", Status: ModuleSucceeded},
+	}
+	err = WriteRunResults(stackPath, downloadDir, "apply", results)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.True(t, util.FileExists(RunResultsPath(stackPath, downloadDir)))
+
+	runResults, err = ReadRunResults(stackPath, downloadDir)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	expected := &RunResults{
+		Command: "apply",
+		Modules: map[string]string{"/stage/app": "dependency failed", "/stage/mysql": "failed", "/stage/vpc": "succeeded"},
+	}
+	assert.Equal(t, expected, runResults)
+}
+
+func TestReadRunResultsInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	stackPath := createTempFolder(t)
+	defer os.RemoveAll(stackPath)
+
+	downloadDir := resumeTestDownloadDir(stackPath)
+	path := RunResultsPath(stackPath, downloadDir)
+	assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+	err := ioutil.WriteFile(path, []byte("not json"), 0644)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	_, err = ReadRunResults(stackPath, downloadDir)
+	_, isInvalidRunResultsFileErr := errors.Unwrap(err).(InvalidRunResultsFile)
+	assert.True(t, isInvalidRunResultsFileErr, "Expected an InvalidRunResultsFile error but got: %v", err)
+}
+
+func TestSkipModulesDoneInPreviousRun(t *testing.T) {
+	t.Parallel()
+
+	stackPath := createTempFolder(t)
+	defer os.RemoveAll(stackPath)
+
+	results := []ModuleResult{
+		{Path: "/stage/app", Status: ModuleCancelled},
+		{Path: "/stage/mysql", Status: ModuleFailed},
+		{Path: "/stage/redis", Status: ModuleSkipped},
+		{Path: "/stage/vpc", Status: ModuleSucceeded},
+	}
+	err := WriteRunResults(stackPath, resumeTestDownloadDir(stackPath), "apply", results)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	testCases := []struct {
+		command  string
+		expected map[string]bool
+	}{
+		{"apply", map[string]bool{"/stage/app": false, "/stage/mysql": false, "/stage/redis": true, "/stage/vpc": true, "/stage/new": false}},
+		{"destroy", map[string]bool{"/stage/app": false, "/stage/mysql": false, "/stage/redis": false, "/stage/vpc": false, "/stage/new": false}},
+	}
+
+	for _, testCase := range testCases {
+		stack := createResumeTestStack(t, stackPath, "/stage/app", "/stage/mysql", "/stage/redis", "/stage/vpc", "/stage/new")
+
+		err := stack.SkipModulesDoneInPreviousRun(testCase.command)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+
+		for _, module := range stack.Modules {
+			assert.Equal(t, testCase.expected[module.Path], module.AssumeAlreadyApplied, "For module %s and command %s", module.Path, testCase.command)
+		}
+	}
+}
+
+func TestSkipModulesDoneInPreviousRunNoPreviousRun(t *testing.T) {
+	t.Parallel()
+
+	stackPath := createTempFolder(t)
+	defer os.RemoveAll(stackPath)
+
+	stack := createResumeTestStack(t, stackPath, "/stage/vpc")
+
+	err := stack.SkipModulesDoneInPreviousRun("apply")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.False(t, stack.Modules[0].AssumeAlreadyApplied)
+}
+
+func TestRunWithResume(t *testing.T) {
+	t.Parallel()
+
+	stackPath := createTempFolder(t)
+	defer os.RemoveAll(stackPath)
+
+	stack := createResumeTestStack(t, stackPath, "a", "b")
+	moduleA, moduleB := stack.Modules[0], stack.Modules[1]
+	moduleB.Dependencies = []*TerraformModule{moduleA}
+
+	// The first run fails in module a, so module b can't run either
+	ran := map[string]int{}
+	failA := true
+	for _, module := range stack.Modules {
+		module.TerragruntOptions.RunTerragrunt = func(terragruntOptions *options.TerragruntOptions) error {
+			ran[terragruntOptions.TerragruntConfigPath]++
+			if terragruntOptions.TerragruntConfigPath == "a" && failA {
+				return fmt.Errorf("Expected error for module a")
+			}
+			return nil
+		}
+	}
+
+	_, err := stack.Run([]string{"apply"}, NormalOrder)
+	assert.NotNil(t, err)
+
+	// The second run resumes, so a and b run again
+	failA = false
+	for _, module := range stack.Modules {
+		module.TerragruntOptions.Resume = true
+		module.TerragruntOptions.TerraformCliArgs = []string{}
+	}
+	results, err := stack.Run([]string{"apply"}, NormalOrder)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, []ModuleResult{{Path: "a", Status: ModuleSucceeded}, {Path: "b", Status: ModuleSucceeded}}, results)
+
+	// The third run resumes too, but there's nothing left to do
+	for _, module := range stack.Modules {
+		module.TerragruntOptions.TerraformCliArgs = []string{}
+	}
+	results, err = stack.Run([]string{"apply"}, NormalOrder)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, []ModuleResult{{Path: "a", Status: ModuleSkipped}, {Path: "b", Status: ModuleSkipped}}, results)
+
+	assert.Equal(t, map[string]int{"a": 2, "b": 1}, ran)
+}
+
+// Return the download dir for the tests of the stack in the given folder, so they don't write to the default one
+func resumeTestDownloadDir(stackPath string) string {
+	return util.JoinPath(stackPath, ".terragrunt-cache")
+}
+
+// Create a Stack in the given folder with a module for each of the given paths
+func createResumeTestStack(t *testing.T, stackPath string, modulePaths ...string) *Stack {
+	stack := &Stack{Path: stackPath}
+	for _, modulePath := range modulePaths {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest(modulePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		terragruntOptions.DownloadDir = resumeTestDownloadDir(stackPath)
+		stack.Modules = append(stack.Modules, &TerraformModule{Path: modulePath, Dependencies: []*TerraformModule{}, TerragruntOptions: terragruntOptions})
+	}
+	return stack
+}
@@ -1,9 +1,13 @@
 package configstack
 
 import (
+	"context"
 	"fmt"
 	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
 	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/telemetry"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -25,6 +29,15 @@ type runningModule struct {
 	DependencyDone chan *runningModule
 	Dependencies   map[string]*runningModule
 	NotifyWhenDone []*runningModule
+	Progress       *progressTracker
+
+	// With --terragrunt-fail-fast, this context is cancelled as soon as any module fails, which stops the modules
+	// that haven't started yet from running. See setUpFailFast.
+	FailFastContext context.Context
+	CancelFailFast  context.CancelFunc
+
+	// Guards moduleFinished, which the panic handler of runModules calls again if there's a panic while it runs
+	finishOnce sync.Once
 }
 
 // This controls in what order dependencies should be enforced between modules
@@ -35,6 +48,47 @@ const (
 	ReverseOrder
 )
 
+// Represents what happened to a module when running the modules in a stack
+type ModuleResultStatus int
+
+const (
+	// The module ran and finished successfully
+	ModuleSucceeded ModuleResultStatus = iota
+	// The module ran and finished with an error
+	ModuleFailed
+	// The module did not run because it was assumed to be already applied
+	ModuleSkipped
+	// The module did not run because one of its dependencies finished with an error
+	ModuleDependencyFailed
+	// The module did not run because the run was cancelled before it could start
+	ModuleCancelled
+)
+
+func (status ModuleResultStatus) String() string {
+	switch status {
+	case ModuleSucceeded:
+		return "succeeded"
+	case ModuleFailed:
+		return "failed"
+	case ModuleSkipped:
+		return "skipped"
+	case ModuleDependencyFailed:
+		return "dependency failed"
+	case ModuleCancelled:
+		return "cancelled"
+	default:
+		return fmt.Sprintf("ModuleResultStatus(%d)", int(status))
+	}
+}
+
+// The result of running a single module in a stack. This allows programs that use this package as a library to find
+// out what happened to each module without having to parse the log output.
+type ModuleResult struct {
+	Path   string
+	Status ModuleResultStatus
+	Err    error
+}
+
 // Create a new RunningModule struct for the given module. This will initialize all fields to reasonable defaults,
 // except for the Dependencies and NotifyWhenDone, both of which will be empty. You should fill these using a
 // function such as crossLinkDependencies.
@@ -52,22 +106,29 @@ func newRunningModule(module *TerraformModule) *runningModule {
 // TerragruntOptions object. The modules will be executed in an order determined by their inter-dependencies, using
 // as much concurrency as possible.
 func RunModules(modules []*TerraformModule) error {
-	runningModules, err := toRunningModules(modules, NormalOrder)
-	if err != nil {
-		return err
-	}
-	return runModules(runningModules)
+	_, err := RunModulesWithResults(modules, NormalOrder)
+	return err
 }
 
 // Run the given map of module path to runningModule. To "run" a module, execute the RunTerragrunt command in its
 // TerragruntOptions object. The modules will be executed in the reverse order of their inter-dependencies, using
 // as much concurrency as possible.
 func RunModulesReverseOrder(modules []*TerraformModule) error {
-	runningModules, err := toRunningModules(modules, ReverseOrder)
+	_, err := RunModulesWithResults(modules, ReverseOrder)
+	return err
+}
+
+// Run the given modules in the given dependency order, as RunModules and RunModulesReverseOrder do, and return the
+// result of each module, sorted by path. The returned error is the same one those methods return: nil if all modules
+// succeeded or a MultiError with the errors of all the modules that did not.
+func RunModulesWithResults(modules []*TerraformModule, dependencyOrder DependencyOrder) ([]ModuleResult, error) {
+	runningModules, err := toRunningModules(modules, dependencyOrder)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return runModules(runningModules)
+
+	err = runModules(runningModules)
+	return collectResults(runningModules), err
 }
 
 // Convert the list of modules to a map from module path to a runningModule struct. This struct contains information
@@ -113,19 +174,62 @@ func crossLinkDependencies(modules map[string]*runningModule, dependencyOrder De
 func runModules(modules map[string]*runningModule) error {
 	var waitGroup sync.WaitGroup
 
+	progress := newProgressTracker(modules)
+	progress.Start()
+
+	for _, module := range modules {
+		module.Progress = progress
+	}
+
+	cancelFailFast := setUpFailFast(modules)
+	defer cancelFailFast()
+
 	for _, module := range modules {
 		waitGroup.Add(1)
 		go func(module *runningModule) {
 			defer waitGroup.Done()
+			// A panic would otherwise crash Terragrunt, and leave the modules that depend on this one waiting forever
+			defer errors.Recover(module.moduleFinished)
 			module.runModuleWhenReady()
 		}(module)
 	}
 
 	waitGroup.Wait()
+	progress.Stop()
 
 	return collectErrors(modules)
 }
 
+// If the user passed --terragrunt-fail-fast, give all the given modules a shared context that is cancelled as soon as
+// any of them fails, so the modules that haven't started yet don't run at all. With --terragrunt-fail-fast-interrupt,
+// that context also becomes the context of each module's TerragruntOptions, so the failure interrupts the modules that
+// are running too. Returns a function to release the context once the run is over.
+func setUpFailFast(modules map[string]*runningModule) context.CancelFunc {
+	terragruntOptions := sharedRunningModuleOptions(modules)
+	if terragruntOptions == nil || (!terragruntOptions.FailFast && !terragruntOptions.FailFastInterrupt) {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(terragruntOptions.GetContext())
+	for _, module := range modules {
+		module.FailFastContext = ctx
+		module.CancelFailFast = cancel
+		if terragruntOptions.FailFastInterrupt {
+			module.Module.TerragruntOptions.Context = ctx
+		}
+	}
+	return cancel
+}
+
+// Return the options of any one of the given modules, or nil if there are none, for the settings of the run as a whole,
+// such as --terragrunt-no-progress. All the modules share the same settings, so it doesn't matter which one we look at.
+func sharedRunningModuleOptions(modules map[string]*runningModule) *options.TerragruntOptions {
+	for _, module := range modules {
+		return module.Module.TerragruntOptions
+	}
+	return nil
+}
+
 // Collect the errors from the given modules and return a single error object to represent them, or nil if no errors
 // occurred
 func collectErrors(modules map[string]*runningModule) error {
@@ -143,15 +247,72 @@ func collectErrors(modules map[string]*runningModule) error {
 	}
 }
 
-// Run a module once all of its dependencies have finished executing.
+// Collect the results of the given modules once they have all finished running, sorted by path
+func collectResults(modules map[string]*runningModule) []ModuleResult {
+	paths := []string{}
+	for path := range modules {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	results := []ModuleResult{}
+	for _, path := range paths {
+		results = append(results, modules[path].result())
+	}
+	return results
+}
+
+// Return the result of this module, which must have finished running
+func (module *runningModule) result() ModuleResult {
+	status := ModuleSucceeded
+
+	if module.Err != nil {
+		if isContextError(module.Err) {
+			status = ModuleCancelled
+		} else if _, isDependencyError := module.Err.(DependencyFinishedWithError); isDependencyError {
+			status = ModuleDependencyFailed
+		} else {
+			status = ModuleFailed
+		}
+	} else if module.Module.AssumeAlreadyApplied {
+		status = ModuleSkipped
+	}
+
+	return ModuleResult{Path: module.Module.Path, Status: status, Err: module.Err}
+}
+
+// Returns true if the given error is the error of a context that was cancelled or timed out, or the error of a module
+// that could not run because one of its dependencies was cancelled
+func isContextError(err error) bool {
+	underlying := errors.Unwrap(err)
+	if dependencyErr, isDependencyError := underlying.(DependencyFinishedWithError); isDependencyError {
+		return isContextError(dependencyErr.Err)
+	}
+	return underlying == context.Canceled || underlying == context.DeadlineExceeded
+}
+
+// Run a module once all of its dependencies have finished executing, unless the run is cancelled in the meantime.
 func (module *runningModule) runModuleWhenReady() {
 	err := module.waitForDependencies()
 	if err == nil {
-		err = module.runNow()
+		if ctxErr := module.runContext().Err(); ctxErr != nil {
+			module.Module.TerragruntOptions.Logger.Printf("The run was cancelled, so module %s will not run", module.Module.Path)
+			err = errors.WithStackTrace(ctxErr)
+		} else {
+			err = module.runNow()
+		}
 	}
 	module.moduleFinished(err)
 }
 
+// Return the context that decides whether this module may still start running
+func (module *runningModule) runContext() context.Context {
+	if module.FailFastContext != nil {
+		return module.FailFastContext
+	}
+	return module.Module.TerragruntOptions.GetContext()
+}
+
 // Wait for all of this modules dependencies to finish executing. Return an error if any of those dependencies complete
 // with an error. Return immediately if this module has no dependencies.
 func (module *runningModule) waitForDependencies() error {
@@ -178,30 +339,44 @@ func (module *runningModule) waitForDependencies() error {
 // Run a module right now by executing the RunTerragrunt command of its TerragruntOptions field.
 func (module *runningModule) runNow() error {
 	module.Status = Running
+	module.Progress.ModuleStarted()
 
 	if module.Module.AssumeAlreadyApplied {
 		module.Module.TerragruntOptions.Logger.Printf("Assuming module %s has already been applied and skipping it", module.Module.Path)
 		return nil
 	} else {
 		module.Module.TerragruntOptions.Logger.Printf("Running module %s now", module.Module.Path)
-		return module.Module.TerragruntOptions.RunTerragrunt(module.Module.TerragruntOptions)
+		return telemetry.Trace(module.Module.TerragruntOptions, "module", map[string]string{"path": module.Module.Path}, func() error {
+			return module.Module.TerragruntOptions.RunTerragrunt(module.Module.TerragruntOptions)
+		})
 	}
 }
 
-// Record that a module has finished executing and notify all of this module's dependencies
+// Record that a module has finished executing and notify all of this module's dependencies. Only the first call has any
+// effect, so the dependencies are notified, and the module counted, only once.
 func (module *runningModule) moduleFinished(moduleErr error) {
-	if moduleErr == nil {
-		module.Module.TerragruntOptions.Logger.Printf("Module %s has finished successfully!", module.Module.Path)
-	} else {
-		module.Module.TerragruntOptions.Logger.Printf("Module %s has finished with an error: %v", module.Module.Path, moduleErr)
-	}
+	module.finishOnce.Do(func() {
+		if moduleErr == nil {
+			module.Module.TerragruntOptions.Logger.Printf("Module %s has finished successfully!", module.Module.Path)
+		} else {
+			module.Module.TerragruntOptions.Logger.Printf("Module %s has finished with an error: %v", module.Module.Path, moduleErr)
+		}
 
-	module.Status = Finished
-	module.Err = moduleErr
+		wasRunning := module.Status == Running
+		module.Status = Finished
+		module.Err = moduleErr
+		module.Progress.ModuleFinished(wasRunning, moduleErr)
+		telemetry.Count("terragrunt.modules", map[string]string{"status": module.result().Status.String()})
 
-	for _, toNotify := range module.NotifyWhenDone {
-		toNotify.DependencyDone <- module
-	}
+		if module.CancelFailFast != nil && module.result().Status == ModuleFailed {
+			module.Module.TerragruntOptions.Logger.Printf("Module %s failed and --terragrunt-fail-fast is set, so cancelling the rest of the run", module.Module.Path)
+			module.CancelFailFast()
+		}
+
+		for _, toNotify := range module.NotifyWhenDone {
+			toNotify.DependencyDone <- module
+		}
+	})
 }
 
 // Custom error types
@@ -216,6 +391,14 @@ func (err DependencyFinishedWithError) Error() string {
 	return fmt.Sprintf("Cannot process module %s because one of its dependencies, %s, finished with an error: %s", err.Module, err.Dependency, err.Err)
 }
 
+func (err DependencyFinishedWithError) Unwrap() error {
+	return err.Err
+}
+
+func (err DependencyFinishedWithError) Is(target error) bool {
+	return target == errors.ErrDependencyFailed
+}
+
 func (this DependencyFinishedWithError) ExitStatus() (int, error) {
 	if exitCode, err := shell.GetExitCode(this.Err); err == nil {
 		return exitCode, nil
@@ -235,6 +418,32 @@ func (err MultiError) Error() string {
 	return fmt.Sprintf("Encountered the following errors:\n%s", strings.Join(errorStrings, "\n"))
 }
 
+// Report whether any of the errors matches target, so errors.Is looks through all of them. Versions of Go before 1.20
+// don't look through the errors that Unwrap returns.
+func (err MultiError) Is(target error) bool {
+	for _, wrappedErr := range err.Errors {
+		if errors.Is(wrappedErr, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set target to the first of the errors that can be assigned to it, if any, so errors.As looks through all of them
+func (err MultiError) As(target interface{}) bool {
+	for _, wrappedErr := range err.Errors {
+		if errors.As(wrappedErr, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the errors, which is how Go 1.20 and later look through them in errors.Is and errors.As
+func (err MultiError) Unwrap() []error {
+	return err.Errors
+}
+
 func (this MultiError) ExitStatus() (int, error) {
 	exitCode := 0
 	for i := range this.Errors {
@@ -1,8 +1,10 @@
 package configstack
 
 import (
+	"context"
 	"fmt"
 	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -1072,3 +1074,239 @@ func TestRunModulesReverseOrderMultipleModulesWithDependenciesLargeGraphPartialF
 	assert.True(t, eRan)
 	assert.True(t, fRan)
 }
+
+func TestRunModulesWithResults(t *testing.T) {
+	t.Parallel()
+
+	aRan := false
+	moduleA := &TerraformModule{
+		Path:                 "a",
+		Dependencies:         []*TerraformModule{},
+		Config:               config.TerragruntConfig{},
+		TerragruntOptions:    optionsWithMockTerragruntCommand(t, "a", nil, &aRan),
+		AssumeAlreadyApplied: true,
+	}
+
+	bRan := false
+	moduleB := &TerraformModule{
+		Path:              "b",
+		Dependencies:      []*TerraformModule{moduleA},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: optionsWithMockTerragruntCommand(t, "b", nil, &bRan),
+	}
+
+	cRan := false
+	expectedErrC := fmt.Errorf("Expected error for module c")
+	moduleC := &TerraformModule{
+		Path:              "c",
+		Dependencies:      []*TerraformModule{moduleB},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: optionsWithMockTerragruntCommand(t, "c", expectedErrC, &cRan),
+	}
+
+	dRan := false
+	moduleD := &TerraformModule{
+		Path:              "d",
+		Dependencies:      []*TerraformModule{moduleC},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: optionsWithMockTerragruntCommand(t, "d", nil, &dRan),
+	}
+
+	expectedErrD := DependencyFinishedWithError{moduleD, moduleC, expectedErrC}
+
+	results, err := RunModulesWithResults([]*TerraformModule{moduleD, moduleC, moduleB, moduleA}, NormalOrder)
+	assertMultiErrorContains(t, err, expectedErrC, expectedErrD)
+
+	expected := []ModuleResult{
+		{Path: "a", Status: ModuleSkipped},
+		{Path: "b", Status: ModuleSucceeded},
+		{Path: "c", Status: ModuleFailed, Err: expectedErrC},
+		{Path: "d", Status: ModuleDependencyFailed, Err: expectedErrD},
+	}
+	assert.Equal(t, expected, results)
+
+	assert.False(t, aRan)
+	assert.True(t, bRan)
+	assert.True(t, cRan)
+	assert.False(t, dRan)
+}
+
+func TestRunModulesWithResultsCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	aRan := false
+	terragruntOptionsA := optionsWithMockTerragruntCommand(t, "a", nil, &aRan)
+	terragruntOptionsA.Context = ctx
+	terragruntOptionsA.RunTerragrunt = func(_ *options.TerragruntOptions) error {
+		aRan = true
+		cancel()
+		return nil
+	}
+	moduleA := &TerraformModule{
+		Path:              "a",
+		Dependencies:      []*TerraformModule{},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: terragruntOptionsA,
+	}
+
+	bRan := false
+	terragruntOptionsB := optionsWithMockTerragruntCommand(t, "b", nil, &bRan)
+	terragruntOptionsB.Context = ctx
+	moduleB := &TerraformModule{
+		Path:              "b",
+		Dependencies:      []*TerraformModule{moduleA},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: terragruntOptionsB,
+	}
+
+	cRan := false
+	terragruntOptionsC := optionsWithMockTerragruntCommand(t, "c", nil, &cRan)
+	terragruntOptionsC.Context = ctx
+	moduleC := &TerraformModule{
+		Path:              "c",
+		Dependencies:      []*TerraformModule{moduleB},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: terragruntOptionsC,
+	}
+
+	results, err := RunModulesWithResults([]*TerraformModule{moduleA, moduleB, moduleC}, NormalOrder)
+	assert.NotNil(t, err)
+
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, ModuleSucceeded, results[0].Status)
+		assert.Equal(t, ModuleCancelled, results[1].Status)
+		assert.Equal(t, ModuleCancelled, results[2].Status)
+	}
+
+	assert.True(t, aRan)
+	assert.False(t, bRan)
+	assert.False(t, cRan)
+}
+
+func TestSetUpFailFast(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		failFast          bool
+		failFastInterrupt bool
+	}{
+		{false, false},
+		{true, false},
+		{false, true},
+	}
+
+	for _, testCase := range testCases {
+		aRan, bRan := false, false
+		terragruntOptionsA := optionsWithMockTerragruntCommand(t, "a", nil, &aRan)
+		terragruntOptionsB := optionsWithMockTerragruntCommand(t, "b", nil, &bRan)
+		for _, terragruntOptions := range []*options.TerragruntOptions{terragruntOptionsA, terragruntOptionsB} {
+			terragruntOptions.FailFast = testCase.failFast
+			terragruntOptions.FailFastInterrupt = testCase.failFastInterrupt
+		}
+
+		moduleA := &TerraformModule{Path: "a", Dependencies: []*TerraformModule{}, TerragruntOptions: terragruntOptionsA}
+		moduleB := &TerraformModule{Path: "b", Dependencies: []*TerraformModule{}, TerragruntOptions: terragruntOptionsB}
+
+		runningModules, err := toRunningModules([]*TerraformModule{moduleA, moduleB}, NormalOrder)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+
+		cancel := setUpFailFast(runningModules)
+		runningModules["a"].moduleFinished(fmt.Errorf("Expected error for module a"))
+
+		failFast := testCase.failFast || testCase.failFastInterrupt
+		assert.Equal(t, failFast, runningModules["b"].runContext().Err() != nil, "For test case %v", testCase)
+		assert.Equal(t, testCase.failFastInterrupt, terragruntOptionsB.GetContext().Err() != nil, "For test case %v", testCase)
+
+		cancel()
+	}
+}
+
+func TestModuleFinishedOnlyOnce(t *testing.T) {
+	t.Parallel()
+
+	aRan, bRan := false, false
+	moduleA := &TerraformModule{Path: "a", Dependencies: []*TerraformModule{}, TerragruntOptions: optionsWithMockTerragruntCommand(t, "a", nil, &aRan)}
+	moduleB := &TerraformModule{Path: "b", Dependencies: []*TerraformModule{moduleA}, TerragruntOptions: optionsWithMockTerragruntCommand(t, "b", nil, &bRan)}
+
+	runningModules, err := toRunningModules([]*TerraformModule{moduleA, moduleB}, NormalOrder)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	runningModules["a"].moduleFinished(nil)
+	runningModules["a"].moduleFinished(fmt.Errorf("Expected error for module a"))
+
+	assert.Nil(t, runningModules["a"].Err)
+	assert.Len(t, runningModules["b"].DependencyDone, 1)
+}
+
+func TestRunModulesWithResultsFailFastInterrupt(t *testing.T) {
+	t.Parallel()
+
+	aRan := false
+	terragruntOptionsA := optionsWithMockTerragruntCommand(t, "a", fmt.Errorf("Expected error for module a"), &aRan)
+	terragruntOptionsA.FailFastInterrupt = true
+	moduleA := &TerraformModule{
+		Path:              "a",
+		Dependencies:      []*TerraformModule{},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: terragruntOptionsA,
+	}
+
+	// Module b runs until it's interrupted, which only happens if module a's failure cancels the run
+	bRan := false
+	terragruntOptionsB := optionsWithMockTerragruntCommand(t, "b", nil, &bRan)
+	terragruntOptionsB.FailFastInterrupt = true
+	terragruntOptionsB.RunTerragrunt = func(terragruntOptions *options.TerragruntOptions) error {
+		bRan = true
+		<-terragruntOptions.GetContext().Done()
+		return terragruntOptions.GetContext().Err()
+	}
+	moduleB := &TerraformModule{
+		Path:              "b",
+		Dependencies:      []*TerraformModule{},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: terragruntOptionsB,
+	}
+
+	cRan := false
+	terragruntOptionsC := optionsWithMockTerragruntCommand(t, "c", nil, &cRan)
+	terragruntOptionsC.FailFastInterrupt = true
+	moduleC := &TerraformModule{
+		Path:              "c",
+		Dependencies:      []*TerraformModule{moduleB},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: terragruntOptionsC,
+	}
+
+	results, err := RunModulesWithResults([]*TerraformModule{moduleA, moduleB, moduleC}, NormalOrder)
+	assert.NotNil(t, err)
+
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, ModuleFailed, results[0].Status)
+		assert.Equal(t, ModuleCancelled, results[1].Status)
+		assert.Equal(t, ModuleCancelled, results[2].Status)
+	}
+
+	assert.True(t, aRan)
+	assert.True(t, bRan)
+	assert.False(t, cRan)
+}
+
+func TestMultiErrorIsAndAs(t *testing.T) {
+	t.Parallel()
+
+	moduleA := &TerraformModule{Path: "a"}
+	moduleB := &TerraformModule{Path: "b"}
+	dependencyErr := DependencyFinishedWithError{Module: moduleB, Dependency: moduleA, Err: fmt.Errorf("Expected error for module a")}
+	multiErr := MultiError{Errors: []error{fmt.Errorf("Expected error for module c"), errors.WithStackTrace(dependencyErr)}}
+
+	assert.True(t, errors.Is(multiErr, errors.ErrDependencyFailed))
+	assert.False(t, errors.Is(multiErr, errors.ErrHookFailed))
+
+	var actualErr DependencyFinishedWithError
+	if assert.True(t, errors.As(multiErr, &actualErr)) {
+		assert.Equal(t, moduleA, actualErr.Dependency)
+	}
+}
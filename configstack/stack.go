@@ -1,3 +1,9 @@
+// Package configstack finds the Terraform modules (i.e. folders with Terragrunt configuration files) in a folder,
+// resolves the dependencies between them, and runs Terragrunt commands on all of them at once, in dependency order.
+// This is what powers the xxx-all commands, but the exported types and functions in this package can also be used by
+// other Go programs to embed Terragrunt orchestration: find a Stack with FindStackInSubfolders or
+// FindStackForConfigPaths, inspect its Modules and their Dependencies, and run a command on all of them with Run to get
+// back a ModuleResult for each module.
 package configstack
 
 import (
@@ -8,6 +14,8 @@ import (
 	"github.com/gruntwork-io/terragrunt/config"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/telemetry"
+	"github.com/gruntwork-io/terragrunt/util"
 	"sort"
 )
 
@@ -29,17 +37,41 @@ func (stack *Stack) String() string {
 }
 
 // Plan execute plan in the given stack in their specified order.
-func (stack *Stack) Plan(terragruntOptions *options.TerragruntOptions) error {
-	stack.setTerraformCommand([]string{"plan"})
-
+func (stack *Stack) Plan(terragruntOptions *options.TerragruntOptions) ([]ModuleResult, error) {
 	// We capture the out stream for each module
 	errorStreams := make([]bytes.Buffer, len(stack.Modules))
 	for n, module := range stack.Modules {
 		module.TerragruntOptions.ErrWriter = &errorStreams[n]
 	}
 	defer stack.summarizePlanAllErrors(terragruntOptions, errorStreams)
+	defer stack.summarizeCostEstimates(terragruntOptions)
 
-	return RunModules(stack.Modules)
+	return stack.Run([]string{"plan"}, NormalOrder)
+}
+
+// If any of the modules in this stack have a cost_estimation block, log the cost estimates of all those modules, plus
+// their total, so that cost deltas show up alongside the plan-all output
+func (stack *Stack) summarizeCostEstimates(terragruntOptions *options.TerragruntOptions) {
+	estimates := []*CostEstimate{}
+
+	for _, module := range stack.Modules {
+		if module.Config.CostEstimation == nil {
+			continue
+		}
+
+		estimate, err := ReadCostEstimate(module.Path, module.TerragruntOptions.WorkingDir)
+		if err != nil {
+			terragruntOptions.Logger.Printf("Unable to read cost estimate for module %s: %v", module.Path, err)
+			continue
+		}
+		if estimate != nil {
+			estimates = append(estimates, estimate)
+		}
+	}
+
+	if len(estimates) > 0 {
+		terragruntOptions.Logger.Printf("%s", FormatCostEstimates(estimates))
+	}
 }
 
 // We inspect the error streams to give an explicit message if the plan failed because there were references to
@@ -49,48 +81,134 @@ func (stack *Stack) summarizePlanAllErrors(terragruntOptions *options.Terragrunt
 	for i, errorStream := range errorStreams {
 		output := errorStream.String()
 		if strings.Contains(output, "Error running plan:") {
-			terragruntOptions.Logger.Println(output)
+			terragruntOptions.ErrorLogger().Println(output)
 			if strings.Contains(output, ": Resource 'data.terraform_remote_state.") {
 				var dependenciesMsg string
 				if len(stack.Modules[i].Dependencies) > 0 {
 					dependenciesMsg = fmt.Sprintf(" contains dependencies to %v and", stack.Modules[i].Config.Dependencies.Paths)
 				}
-				terragruntOptions.Logger.Printf("%v%v refers to remote state "+
+				terragruntOptions.ErrorLogger().Printf("%v%v refers to remote state "+
 					"you may have to apply your changes in the dependencies prior running terragrunt plan-all.\n",
 					stack.Modules[i].Path,
 					dependenciesMsg,
 				)
 			}
 		} else if errorStream.Len() > 0 {
-			terragruntOptions.Logger.Printf("Error with plan: %s", output)
+			terragruntOptions.ErrorLogger().Printf("Error with plan: %s", output)
 		}
 	}
 }
 
 // Apply all the modules in the given stack, making sure to apply the dependencies of each module in the stack in the
 // proper order.
-func (stack *Stack) Apply(terragruntOptions *options.TerragruntOptions) error {
-	stack.setTerraformCommand([]string{"apply", "-input=false", "-auto-approve"})
-	return RunModules(stack.Modules)
+func (stack *Stack) Apply(terragruntOptions *options.TerragruntOptions) ([]ModuleResult, error) {
+	return stack.Run([]string{"apply", "-input=false", "-auto-approve"}, NormalOrder)
 }
 
 // Destroy all the modules in the given stack, making sure to destroy the dependencies of each module in the stack in
 // the proper order.
-func (stack *Stack) Destroy(terragruntOptions *options.TerragruntOptions) error {
-	stack.setTerraformCommand([]string{"destroy", "-force", "-input=false"})
-	return RunModulesReverseOrder(stack.Modules)
+func (stack *Stack) Destroy(terragruntOptions *options.TerragruntOptions) ([]ModuleResult, error) {
+	return stack.Run([]string{"destroy", "-force", "-input=false"}, ReverseOrder)
 }
 
 // Output prints the outputs of all the modules in the given stack in their specified order.
-func (stack *Stack) Output(terragruntOptions *options.TerragruntOptions) error {
-	stack.setTerraformCommand([]string{"output"})
-	return RunModules(stack.Modules)
+func (stack *Stack) Output(terragruntOptions *options.TerragruntOptions) ([]ModuleResult, error) {
+	return stack.Run([]string{"output"}, NormalOrder)
 }
 
 // Validate runs terraform validate on each module
-func (stack *Stack) Validate(terragruntOptions *options.TerragruntOptions) error {
-	stack.setTerraformCommand([]string{"validate"})
-	return RunModules(stack.Modules)
+func (stack *Stack) Validate(terragruntOptions *options.TerragruntOptions) ([]ModuleResult, error) {
+	return stack.Run([]string{"validate"}, NormalOrder)
+}
+
+// StateList lists the resources in the state of each module in the given stack. As the modules run concurrently, we
+// capture the output of each module and only write it out once all the modules are done, prefixing each resource with
+// the path of its module, so the result can be used as an inventory of all the resources in the stack.
+func (stack *Stack) StateList(terragruntOptions *options.TerragruntOptions) ([]ModuleResult, error) {
+	outputStreams := make([]bytes.Buffer, len(stack.Modules))
+	for n, module := range stack.Modules {
+		module.TerragruntOptions.Writer = &outputStreams[n]
+	}
+	defer stack.writeStateInventory(terragruntOptions, outputStreams)
+
+	return stack.Run([]string{"state", "list"}, NormalOrder)
+}
+
+// Write the resources listed in the given output streams of the modules in this stack to the Writer of the given
+// options, one resource per line, prefixed by the path of the module and sorted
+func (stack *Stack) writeStateInventory(terragruntOptions *options.TerragruntOptions, outputStreams []bytes.Buffer) {
+	lines := []string{}
+	for i, outputStream := range outputStreams {
+		for _, resource := range strings.Split(outputStream.String(), "\n") {
+			if strings.TrimSpace(resource) != "" {
+				lines = append(lines, fmt.Sprintf("%s: %s", stack.Modules[i].Path, strings.TrimSpace(resource)))
+			}
+		}
+	}
+
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintln(terragruntOptions.Writer, line)
+	}
+}
+
+// Run the given Terraform command (e.g. []string{"plan"}) on all the modules in this stack in the given dependency
+// order and return the result of each module, sorted by path. The command is prepended to the Terraform args already in
+// the TerragruntOptions of each module. The returned error is nil if all the modules succeeded or a MultiError
+// otherwise.
+func (stack *Stack) Run(command []string, dependencyOrder DependencyOrder) ([]ModuleResult, error) {
+	return stack.run(command, command[0], dependencyOrder)
+}
+
+// Run the given Terraform command on all the modules in this stack, like Run, but save the results for
+// --terragrunt-resume under the given run name rather than the name of the Terraform command, so commands that run
+// the same Terraform command with different args (e.g. plan-all and drift-all) don't resume each other's runs
+func (stack *Stack) run(command []string, runName string, dependencyOrder DependencyOrder) ([]ModuleResult, error) {
+	// Modules that share a state would silently overwrite each other's state, so refuse to run any of them
+	if err := stack.CheckForDuplicateStateKeys(); err != nil {
+		return nil, err
+	}
+
+	stack.setTerraformCommand(command)
+
+	// The modules run in parallel, so they can't all take over the terminal with a pseudo-terminal
+	for _, module := range stack.Modules {
+		module.TerragruntOptions.NoPty = true
+	}
+
+	sharedOptions := stack.sharedOptions()
+
+	if sharedOptions != nil && sharedOptions.Resume {
+		if err := stack.SkipModulesDoneInPreviousRun(runName); err != nil {
+			return nil, err
+		}
+	}
+
+	if sharedOptions != nil && sharedOptions.LogDir != "" {
+		closeLogFiles, err := stack.teeOutputToLogFiles(sharedOptions.LogDir)
+		if err != nil {
+			return nil, err
+		}
+		defer closeLogFiles()
+	}
+
+	results, err := RunModulesWithResults(stack.Modules, dependencyOrder)
+	if sharedOptions != nil && len(results) > 0 {
+		if writeErr := WriteRunResults(stack.Path, sharedOptions.DownloadDir, runName, results); writeErr != nil {
+			sharedOptions.Logger.Printf("WARNING: could not save the results of this run, so it can't be resumed with --terragrunt-resume: %v", writeErr)
+		}
+	}
+
+	return results, err
+}
+
+// Return the options of the first module of this stack, or nil if it has none, for the settings of the run as a whole,
+// such as --terragrunt-resume. All the modules share the same settings, so it doesn't matter which one we look at.
+func (stack *Stack) sharedOptions() *options.TerragruntOptions {
+	if len(stack.Modules) == 0 {
+		return nil
+	}
+	return stack.Modules[0].TerragruntOptions
 }
 
 // Return an error if there is a dependency cycle in the modules of this stack.
@@ -101,19 +219,77 @@ func (stack *Stack) CheckForCycles() error {
 // Find all the Terraform modules in the subfolders of the working directory of the given TerragruntOptions and
 // assemble them into a Stack object that can be applied or destroyed in a single command
 func FindStackInSubfolders(terragruntOptions *options.TerragruntOptions) (*Stack, error) {
-	terragruntConfigFiles, err := config.FindConfigFilesInPath(terragruntOptions.WorkingDir)
+	var stack *Stack
+	err := telemetry.Trace(terragruntOptions, "resolve stack", map[string]string{"working_dir": terragruntOptions.WorkingDir}, func() (err error) {
+		stack, err = findStackInSubfolders(terragruntOptions)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The options of the modules are cloned while the span of resolving the stack is in their context, so give them
+	// back the context of the run, or the spans of running the modules would be children of resolving them
+	for _, module := range stack.Modules {
+		module.TerragruntOptions.Context = terragruntOptions.Context
+	}
+
+	return stack, nil
+}
+
+func findStackInSubfolders(terragruntOptions *options.TerragruntOptions) (*Stack, error) {
+	var terragruntConfigFiles []string
+	err := telemetry.Trace(terragruntOptions, "find config files", nil, func() (err error) {
+		terragruntConfigFiles, err = config.FindConfigFilesInWorkingDir(terragruntOptions)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	howThesePathsWereFound := fmt.Sprintf("Terragrunt config file found in a subdirectory of %s", terragruntOptions.WorkingDir)
-	return createStackForTerragruntConfigPaths(terragruntOptions.WorkingDir, terragruntConfigFiles, terragruntOptions, howThesePathsWereFound)
+	stack, err := createStackForTerragruntConfigPaths(terragruntOptions.WorkingDir, terragruntConfigFiles, terragruntOptions, howThesePathsWereFound)
+	if err != nil {
+		return nil, err
+	}
+
+	if terragruntOptions.GitDiffRef != "" {
+		if err := stack.SkipModulesUnchangedSince(terragruntOptions.GitDiffRef, terragruntOptions); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(terragruntOptions.ModulesThatInclude) > 0 {
+		if err := stack.SkipModulesThatDontInclude(terragruntOptions.ModulesThatInclude, terragruntOptions.StrictInclude, terragruntOptions); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(terragruntOptions.Targets) > 0 {
+		if err := stack.SelectModulesForTargets(terragruntOptions.Targets, terragruntOptions.TargetsFromState, terragruntOptions); err != nil {
+			return nil, err
+		}
+	}
+
+	return stack, nil
 }
 
-// Set the command in the TerragruntOptions object of each module in this stack to the given command.
+// Assemble the Terraform modules in the folders of the given Terragrunt config files, plus any modules they depend on,
+// into a Stack object rooted at the given path. Unlike FindStackInSubfolders, this does not search for config files,
+// so callers can choose exactly which modules to include.
+func FindStackForConfigPaths(path string, terragruntConfigPaths []string, terragruntOptions *options.TerragruntOptions) (*Stack, error) {
+	howThesePathsWereFound := fmt.Sprintf("Terragrunt config file passed to FindStackForConfigPaths for %s", path)
+	return createStackForTerragruntConfigPaths(path, terragruntConfigPaths, terragruntOptions, howThesePathsWereFound)
+}
+
+// Set the command in the TerragruntOptions object of each module in this stack to the given command. Each module gets
+// its own copy of the args, so inserting args for one module (e.g. its extra_arguments) never changes another's.
 func (stack *Stack) setTerraformCommand(command []string) {
 	for _, module := range stack.Modules {
-		module.TerragruntOptions.TerraformCliArgs = append(command, module.TerragruntOptions.TerraformCliArgs...)
+		args := make([]string, 0, len(command)+len(module.TerragruntOptions.TerraformCliArgs))
+		args = append(args, command...)
+		args = append(args, module.TerragruntOptions.TerraformCliArgs...)
+		module.TerragruntOptions.TerraformCliArgs = args
 	}
 }
 
@@ -130,13 +306,67 @@ func createStackForTerragruntConfigPaths(path string, terragruntConfigPaths []st
 	}
 
 	stack := &Stack{Path: path, Modules: modules}
+
+	if len(terragruntOptions.ExtraDependencies) > 0 {
+		if err := stack.AddExtraDependencies(terragruntOptions.ExtraDependencies, terragruntOptions); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := stack.CheckForCycles(); err != nil {
 		return nil, err
 	}
 
+	stack.SkipDisabledModules(terragruntOptions)
+
+	if err := stack.setModuleWriters(); err != nil {
+		return nil, err
+	}
+
+	if !terragruntOptions.NoColor && util.IsTerminal(terragruntOptions.ErrWriter) {
+		stack.setLogColors()
+	}
+
 	return stack, nil
 }
 
+// Give each module in this stack that is going to run the writers the ModuleWriterFactory and ModuleErrWriterFactory of
+// its options return for it, if set, so its output doesn't go to the writers all the modules share
+func (stack *Stack) setModuleWriters() error {
+	for _, module := range stack.Modules {
+		if module.AssumeAlreadyApplied {
+			continue
+		}
+
+		moduleOptions := module.TerragruntOptions
+		if moduleOptions.ModuleWriterFactory != nil {
+			writer, err := moduleOptions.ModuleWriterFactory(module.Path)
+			if err != nil {
+				return errors.WithStackTrace(ModuleWriterFactoryFailed{ModulePath: module.Path, Underlying: err})
+			}
+			moduleOptions.Writer = writer
+		}
+		if moduleOptions.ModuleErrWriterFactory != nil {
+			errWriter, err := moduleOptions.ModuleErrWriterFactory(module.Path)
+			if err != nil {
+				return errors.WithStackTrace(ModuleWriterFactoryFailed{ModulePath: module.Path, Underlying: err})
+			}
+			moduleOptions.ErrWriter = errWriter
+			moduleOptions.Logger = util.CreateColoredLoggerWithWriter(moduleOptions.LogWriter(), moduleOptions.WorkingDir, moduleOptions.LogColor)
+		}
+	}
+	return nil
+}
+
+// Give the log prefix of each module in this stack its own color, so it's easy to tell apart the log output of the
+// modules when they run in parallel
+func (stack *Stack) setLogColors() {
+	for i, module := range stack.Modules {
+		module.TerragruntOptions.LogColor = util.GetLogPrefixColor(i)
+		module.TerragruntOptions.Logger = util.CreateColoredLoggerWithWriter(module.TerragruntOptions.LogWriter(), module.TerragruntOptions.WorkingDir, module.TerragruntOptions.LogColor)
+	}
+}
+
 // Custom error types
 
 var NoTerraformModulesFound = fmt.Errorf("Could not find any subfolders with Terragrunt configuration files")
@@ -146,3 +376,16 @@ type DependencyCycle []string
 func (err DependencyCycle) Error() string {
 	return fmt.Sprintf("Found a dependency cycle between modules: %s", strings.Join([]string(err), " -> "))
 }
+
+type ModuleWriterFactoryFailed struct {
+	ModulePath string
+	Underlying error
+}
+
+func (err ModuleWriterFactoryFailed) Error() string {
+	return fmt.Sprintf("Could not create the writer for the output of module %s: %v", err.ModulePath, err.Underlying)
+}
+
+func (err ModuleWriterFactoryFailed) Unwrap() error {
+	return err.Underlying
+}
@@ -1,10 +1,14 @@
 package configstack
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
 	"github.com/gruntwork-io/terragrunt/util"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -55,6 +59,123 @@ func TestFindStackInSubfolders(t *testing.T) {
 
 }
 
+func TestWriteStateInventory(t *testing.T) {
+	t.Parallel()
+
+	stack := &Stack{
+		Path: "/stage",
+		Modules: []*TerraformModule{
+			&TerraformModule{Path: "/stage/vpc"},
+			&TerraformModule{Path: "/stage/mysql"},
+			&TerraformModule{Path: "/stage/empty"},
+		},
+	}
+
+	outputStreams := make([]bytes.Buffer, len(stack.Modules))
+	outputStreams[0].WriteString("aws_vpc.main\naws_subnet.private\n")
+	outputStreams[1].WriteString("aws_db_instance.mysql\n")
+
+	output := &bytes.Buffer{}
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("stack_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.Writer = output
+
+	stack.writeStateInventory(terragruntOptions, outputStreams)
+
+	expected := "/stage/mysql: aws_db_instance.mysql\n/stage/vpc: aws_subnet.private\n/stage/vpc: aws_vpc.main\n"
+	assert.Equal(t, expected, output.String())
+}
+
+func TestSetTerraformCommandCopiesArgsForEachModule(t *testing.T) {
+	t.Parallel()
+
+	stack := &Stack{Path: "/stage"}
+	for _, path := range []string{"/stage/vpc", "/stage/mysql"} {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		terragruntOptions.TerraformCliArgs = []string{"-var", `tags={"a"="b c"}`}
+		stack.Modules = append(stack.Modules, &TerraformModule{Path: path, TerragruntOptions: terragruntOptions})
+	}
+
+	// Leave spare capacity in the command, so appending to it in place would share the backing array between modules
+	command := make([]string, 1, 10)
+	command[0] = "plan"
+	stack.setTerraformCommand(command)
+
+	stack.Modules[0].TerragruntOptions.InsertTerraformCliArgs("-var-file=vpc.tfvars")
+	stack.Modules[0].TerragruntOptions.AppendTerraformCliArgs("-out=vpc.plan")
+
+	assert.Equal(t, []string{"plan", "-var-file=vpc.tfvars", "-var", `tags={"a"="b c"}`, "-out=vpc.plan"}, stack.Modules[0].TerragruntOptions.TerraformCliArgs)
+	assert.Equal(t, []string{"plan", "-var", `tags={"a"="b c"}`}, stack.Modules[1].TerragruntOptions.TerraformCliArgs)
+	assert.Equal(t, []string{"plan"}, command)
+}
+
+func TestSetLogColors(t *testing.T) {
+	t.Parallel()
+
+	stack := &Stack{Path: "/stage"}
+	for _, path := range []string{"/stage/vpc", "/stage/mysql", "/stage/app"} {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stack.Modules = append(stack.Modules, &TerraformModule{Path: path, TerragruntOptions: terragruntOptions})
+	}
+
+	stack.setLogColors()
+
+	colors := map[string]bool{}
+	for _, module := range stack.Modules {
+		assert.NotEmpty(t, module.TerragruntOptions.LogColor, "For module %s", module.Path)
+		assert.Contains(t, module.TerragruntOptions.Logger.Prefix(), module.TerragruntOptions.LogColor, "For module %s", module.Path)
+		colors[module.TerragruntOptions.LogColor] = true
+	}
+	assert.Equal(t, len(stack.Modules), len(colors), "Expected each module to get a different color")
+}
+
+func TestSetModuleWriters(t *testing.T) {
+	t.Parallel()
+
+	outputs := map[string]*bytes.Buffer{}
+	errOutputs := map[string]*bytes.Buffer{}
+
+	stack := &Stack{Path: "/stage"}
+	for _, path := range []string{"/stage/vpc", "/stage/mysql", "/stage/app"} {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs[path] = &bytes.Buffer{}
+		errOutputs[path] = &bytes.Buffer{}
+		terragruntOptions.ModuleWriterFactory = func(modulePath string) (io.Writer, error) { return outputs[modulePath], nil }
+		terragruntOptions.ModuleErrWriterFactory = func(modulePath string) (io.Writer, error) { return errOutputs[modulePath], nil }
+		stack.Modules = append(stack.Modules, &TerraformModule{Path: path, TerragruntOptions: terragruntOptions})
+	}
+	stack.Modules[2].AssumeAlreadyApplied = true
+
+	assert.Nil(t, stack.setModuleWriters())
+
+	for _, module := range stack.Modules[:2] {
+		assert.Equal(t, outputs[module.Path], module.TerragruntOptions.Writer, "For module %s", module.Path)
+		assert.Equal(t, errOutputs[module.Path], module.TerragruntOptions.ErrWriter, "For module %s", module.Path)
+		module.TerragruntOptions.Logger.Printf("Hello from %s", module.Path)
+		assert.Contains(t, errOutputs[module.Path].String(), "Hello from "+module.Path, "For module %s", module.Path)
+	}
+	assert.Equal(t, os.Stdout, stack.Modules[2].TerragruntOptions.Writer)
+	assert.Equal(t, os.Stderr, stack.Modules[2].TerragruntOptions.ErrWriter)
+
+	stack.Modules[0].TerragruntOptions.ModuleErrWriterFactory = func(modulePath string) (io.Writer, error) {
+		return nil, fmt.Errorf("no space left on device")
+	}
+	err := stack.setModuleWriters()
+	_, isFactoryErr := errors.Unwrap(err).(ModuleWriterFactoryFailed)
+	assert.True(t, isFactoryErr, "Expected a ModuleWriterFactoryFailed error but got: %v", err)
+}
+
 func createTempFolder(t *testing.T) string {
 	tmpFolder, err := ioutil.TempDir("", "")
 	if err != nil {
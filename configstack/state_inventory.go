@@ -0,0 +1,86 @@
+package configstack
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/remote"
+)
+
+// A resource in the state of one of the modules in a stack
+type InventoryResource struct {
+	ModulePath string `json:"module_path"`
+	Address    string `json:"address"`
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	Id         string `json:"id"`
+}
+
+// Pull the state of each module in the given stack with terraform state pull and return all the managed resources in
+// those states, sorted by module path and address, plus the result of each module. As with StateList, the output of
+// each module is captured rather than written out. If the state of a module can't be parsed, the resources of the
+// other modules are still returned, along with an error.
+func (stack *Stack) StateInventory(terragruntOptions *options.TerragruntOptions) ([]InventoryResource, []ModuleResult, error) {
+	outputStreams := make([]bytes.Buffer, len(stack.Modules))
+	for n, module := range stack.Modules {
+		module.TerragruntOptions.Writer = &outputStreams[n]
+	}
+
+	results, err := stack.Run([]string{"state", "pull"}, NormalOrder)
+
+	inventory := []InventoryResource{}
+	parseErrs := []error{}
+	for n, module := range stack.Modules {
+		resources, parseErr := remote.ParseTerraformStateResources(outputStreams[n].Bytes())
+		if parseErr != nil {
+			parseErrs = append(parseErrs, errors.WithStackTrace(InvalidStateForInventory{ModulePath: module.Path, Underlying: parseErr}))
+			continue
+		}
+
+		for _, resource := range resources {
+			inventory = append(inventory, InventoryResource{ModulePath: module.Path, Address: resource.Address, Type: resource.Type, Name: resource.Name, Id: resource.Id})
+		}
+	}
+	sort.Sort(inventoryByModulePathAndAddress(inventory))
+
+	if err == nil && len(parseErrs) > 0 {
+		err = errors.WithStackTrace(MultiError{Errors: parseErrs})
+	}
+
+	return inventory, results, err
+}
+
+type inventoryByModulePathAndAddress []InventoryResource
+
+func (inventory inventoryByModulePathAndAddress) Len() int {
+	return len(inventory)
+}
+
+func (inventory inventoryByModulePathAndAddress) Swap(i, j int) {
+	inventory[i], inventory[j] = inventory[j], inventory[i]
+}
+
+func (inventory inventoryByModulePathAndAddress) Less(i, j int) bool {
+	if inventory[i].ModulePath != inventory[j].ModulePath {
+		return inventory[i].ModulePath < inventory[j].ModulePath
+	}
+	return inventory[i].Address < inventory[j].Address
+}
+
+// Custom error types
+
+type InvalidStateForInventory struct {
+	ModulePath string
+	Underlying error
+}
+
+func (err InvalidStateForInventory) Error() string {
+	return fmt.Sprintf("Could not parse the output of 'terraform state pull' in module %s: %v", err.ModulePath, err.Underlying)
+}
+
+func (err InvalidStateForInventory) Unwrap() error {
+	return err.Underlying
+}
@@ -0,0 +1,74 @@
+package configstack
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackStateInventory(t *testing.T) {
+	t.Parallel()
+
+	stackPath := createTempFolder(t)
+	defer os.RemoveAll(stackPath)
+
+	states := map[string]string{
+		"/stage/vpc":   `{"version": 4, "resources": [{"mode": "managed", "type": "aws_vpc", "name": "main", "instances": [{"attributes": {"id": "vpc-123"}}]}]}`,
+		"/stage/mysql": `{"version": 4, "resources": [{"mode": "managed", "type": "aws_db_instance", "name": "mysql", "instances": [{"attributes": {"id": "mysql"}}]}, {"mode": "managed", "type": "aws_db_subnet_group", "name": "mysql", "instances": [{"attributes": {"id": "mysql-subnets"}}]}]}`,
+		"/stage/empty": "",
+	}
+
+	stack := createStateInventoryTestStack(t, stackPath, states)
+	inventory, results, err := stack.StateInventory(stack.Modules[0].TerragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Len(t, results, 3)
+
+	expected := []InventoryResource{
+		{ModulePath: "/stage/mysql", Address: "aws_db_instance.mysql", Type: "aws_db_instance", Name: "mysql", Id: "mysql"},
+		{ModulePath: "/stage/mysql", Address: "aws_db_subnet_group.mysql", Type: "aws_db_subnet_group", Name: "mysql", Id: "mysql-subnets"},
+		{ModulePath: "/stage/vpc", Address: "aws_vpc.main", Type: "aws_vpc", Name: "main", Id: "vpc-123"},
+	}
+	assert.Equal(t, expected, inventory)
+}
+
+func TestStackStateInventoryInvalidState(t *testing.T) {
+	t.Parallel()
+
+	stackPath := createTempFolder(t)
+	defer os.RemoveAll(stackPath)
+
+	states := map[string]string{
+		"/stage/vpc":     `{"version": 4, "resources": [{"mode": "managed", "type": "aws_vpc", "name": "main", "instances": [{"attributes": {"id": "vpc-123"}}]}]}`,
+		"/stage/invalid": "not a state",
+	}
+
+	stack := createStateInventoryTestStack(t, stackPath, states)
+	inventory, _, err := stack.StateInventory(stack.Modules[0].TerragruntOptions)
+	assert.Equal(t, []InventoryResource{{ModulePath: "/stage/vpc", Address: "aws_vpc.main", Type: "aws_vpc", Name: "main", Id: "vpc-123"}}, inventory)
+
+	if multiErr, isMultiErr := errors.Unwrap(err).(MultiError); assert.True(t, isMultiErr, "Expected a MultiError but got: %v", err) {
+		_, isInvalidStateErr := errors.Unwrap(multiErr.Errors[0]).(InvalidStateForInventory)
+		assert.True(t, isInvalidStateErr, "Expected an InvalidStateForInventory error but got: %v", multiErr.Errors[0])
+	}
+}
+
+// Create a Stack in the given folder with a module for each of the given paths, whose state is the given state
+func createStateInventoryTestStack(t *testing.T, stackPath string, states map[string]string) *Stack {
+	stack := &Stack{Path: stackPath}
+	for path := range states {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		terragruntOptions.RunTerragrunt = func(moduleOptions *options.TerragruntOptions) error {
+			assert.Equal(t, []string{"state", "pull"}, moduleOptions.TerraformCliArgs)
+			_, err := moduleOptions.Writer.Write([]byte(states[moduleOptions.TerragruntConfigPath]))
+			return err
+		}
+		stack.Modules = append(stack.Modules, &TerraformModule{Path: path, Dependencies: []*TerraformModule{}, TerragruntOptions: terragruntOptions})
+	}
+	return stack
+}
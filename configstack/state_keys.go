@@ -0,0 +1,63 @@
+package configstack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// Return the paths of the modules in this stack that store their remote state in the same location as another module,
+// by that location. Modules without remote_state, or with a backend whose location Terragrunt can't tell (see
+// remote.STATE_LOCATION_CONFIG_KEYS), are left out.
+func (stack *Stack) FindDuplicateStateKeys() map[string][]string {
+	modulesByStateLocation := map[string][]string{}
+	for _, module := range stack.Modules {
+		if module.Config.RemoteState == nil {
+			continue
+		}
+		stateLocation := module.Config.RemoteState.StateLocation()
+		if stateLocation == "" {
+			continue
+		}
+		modulesByStateLocation[stateLocation] = append(modulesByStateLocation[stateLocation], module.Path)
+	}
+
+	duplicates := map[string][]string{}
+	for stateLocation, modulePaths := range modulesByStateLocation {
+		if len(modulePaths) > 1 {
+			sort.Strings(modulePaths)
+			duplicates[stateLocation] = modulePaths
+		}
+	}
+	return duplicates
+}
+
+// Return an error if two modules of this stack store their remote state in the same location, as they would overwrite
+// each other's state
+func (stack *Stack) CheckForDuplicateStateKeys() error {
+	duplicates := stack.FindDuplicateStateKeys()
+	if len(duplicates) > 0 {
+		return errors.WithStackTrace(DuplicateStateKeys(duplicates))
+	}
+	return nil
+}
+
+// Custom error types
+
+type DuplicateStateKeys map[string][]string
+
+func (duplicates DuplicateStateKeys) Error() string {
+	stateLocations := []string{}
+	for stateLocation := range duplicates {
+		stateLocations = append(stateLocations, stateLocation)
+	}
+	sort.Strings(stateLocations)
+
+	lines := []string{}
+	for _, stateLocation := range stateLocations {
+		lines = append(lines, fmt.Sprintf("  %s: %s", stateLocation, strings.Join(duplicates[stateLocation], ", ")))
+	}
+	return fmt.Sprintf("Found modules that store their remote state in the same location, so they would overwrite each other's state. Give each of them its own key, e.g. with get_state_key():\n%s", strings.Join(lines, "\n"))
+}
@@ -0,0 +1,46 @@
+package configstack
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+func s3RemoteStateForTest(bucket string, key string) *remote.RemoteState {
+	return &remote.RemoteState{Backend: "s3", Config: map[string]interface{}{"bucket": bucket, "key": key, "region": "us-east-1"}}
+}
+
+func TestFindDuplicateStateKeys(t *testing.T) {
+	t.Parallel()
+
+	stack := &Stack{Path: "/stage", Modules: []*TerraformModule{
+		{Path: "/stage/vpc", Config: config.TerragruntConfig{RemoteState: s3RemoteStateForTest("state", "stage/vpc/terraform.tfstate")}},
+		{Path: "/stage/mysql", Config: config.TerragruntConfig{RemoteState: s3RemoteStateForTest("state", "terraform.tfstate")}},
+		{Path: "/stage/app", Config: config.TerragruntConfig{RemoteState: s3RemoteStateForTest("state", "terraform.tfstate")}},
+		{Path: "/stage/redis", Config: config.TerragruntConfig{RemoteState: s3RemoteStateForTest("other-state", "terraform.tfstate")}},
+		{Path: "/stage/dns", Config: config.TerragruntConfig{RemoteState: &remote.RemoteState{Backend: "local", Config: map[string]interface{}{"path": "terraform.tfstate"}}}},
+		{Path: "/stage/cdn", Config: config.TerragruntConfig{RemoteState: &remote.RemoteState{Backend: "local", Config: map[string]interface{}{"path": "terraform.tfstate"}}}},
+		{Path: "/stage/no-state"},
+	}}
+
+	expected := map[string][]string{"s3://state/terraform.tfstate": {"/stage/app", "/stage/mysql"}}
+	assert.Equal(t, expected, stack.FindDuplicateStateKeys())
+
+	err := stack.CheckForDuplicateStateKeys()
+	duplicates, isDuplicateStateKeysErr := errors.Unwrap(err).(DuplicateStateKeys)
+	if assert.True(t, isDuplicateStateKeysErr, "Expected a DuplicateStateKeys error but got: %v", err) {
+		assert.Equal(t, expected, map[string][]string(duplicates))
+	}
+
+	// A stack with duplicate keys refuses to run any of its modules
+	_, err = stack.Run([]string{"apply"}, NormalOrder)
+	_, isDuplicateStateKeysErr = errors.Unwrap(err).(DuplicateStateKeys)
+	assert.True(t, isDuplicateStateKeysErr, "Expected a DuplicateStateKeys error but got: %v", err)
+
+	stack.Modules = stack.Modules[:2]
+	assert.Empty(t, stack.FindDuplicateStateKeys())
+	assert.Nil(t, stack.CheckForDuplicateStateKeys())
+}
@@ -0,0 +1,179 @@
+package configstack
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Matches the index of a resource or module instance in an address, such as the [0] in aws_instance.web[0] or the
+// ["a"] in module.vpc["a"]
+var resourceIndexRegexp = regexp.MustCompile(`\[[^\]]*\]`)
+
+// Find the modules in this stack that contain the given resource addresses (e.g. aws_instance.web or
+// module.vpc.aws_subnet.private[0]), mark all the other modules as already applied, so the xxx-all commands skip them,
+// and pass each address the selected modules contain to Terraform with -target. If fromState is set, the addresses
+// are looked up in the state of each module with terraform state list. Otherwise, they are looked up in the Terraform
+// code of each module, which only works for modules whose code is local. Returns an error if an address isn't in any
+// module.
+func (stack *Stack) SelectModulesForTargets(targets []string, fromState bool, terragruntOptions *options.TerragruntOptions) error {
+	foundTargets := map[string]bool{}
+
+	for _, module := range stack.Modules {
+		var addresses []string
+		var err error
+		if fromState {
+			addresses, err = findAddressesInState(module)
+		} else {
+			addresses, err = findAddressesInCode(module)
+		}
+		if err != nil {
+			return err
+		}
+
+		moduleTargets := []string{}
+		for _, target := range targets {
+			if addressesContainTarget(addresses, target) {
+				moduleTargets = append(moduleTargets, target)
+				foundTargets[target] = true
+			}
+		}
+
+		if len(moduleTargets) == 0 {
+			terragruntOptions.Logger.Printf("Module %s does not contain any of %v, so it will be skipped", module.Path, targets)
+			module.AssumeAlreadyApplied = true
+			continue
+		}
+
+		terragruntOptions.Logger.Printf("Module %s contains %v, so it will be run with only those targets", module.Path, moduleTargets)
+		for _, target := range moduleTargets {
+			module.TerragruntOptions.AppendTerraformCliArgs("-target=" + target)
+		}
+	}
+
+	for _, target := range targets {
+		if !foundTargets[target] {
+			return errors.WithStackTrace(TargetNotInStack{Target: target, FromState: fromState})
+		}
+	}
+
+	return nil
+}
+
+// Return true if one of the given addresses is the given target or a resource inside it, such as
+// module.vpc.aws_subnet.private for the target module.vpc. Instance indexes are ignored on both sides, so
+// aws_instance.web[0] matches aws_instance.web, and vice versa.
+func addressesContainTarget(addresses []string, target string) bool {
+	target = resourceIndexRegexp.ReplaceAllString(target, "")
+	for _, address := range addresses {
+		address = resourceIndexRegexp.ReplaceAllString(address, "")
+		if address == target || strings.HasPrefix(address, target+".") || strings.HasPrefix(target, address+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the addresses of the resources, data sources, and modules declared in the Terraform code of the given module:
+// the code in its folder, plus the code of its terraform source, if that's a local folder
+func findAddressesInCode(module *TerraformModule) ([]string, error) {
+	folders := []string{module.Path}
+	if sourceFolder := getLocalSourceFolder(module); sourceFolder != "" {
+		folders = append(folders, sourceFolder)
+	}
+
+	addresses := []string{}
+	for _, folder := range folders {
+		code, err := util.ParseTerraformCode(folder)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range code.Resources {
+			addresses = append(addresses, resource.Address())
+		}
+		for _, terraformModule := range code.Modules {
+			addresses = append(addresses, "module."+terraformModule.Name)
+		}
+	}
+
+	return addresses, nil
+}
+
+// Return the folder of the Terraform code of the given module if it comes from the local file system, either via
+// --terragrunt-source or the source in its terraform block, or an empty string otherwise
+func getLocalSourceFolder(module *TerraformModule) string {
+	source := module.TerragruntOptions.Source
+	if source == "" && module.Config.Terraform != nil {
+		source = module.Config.Terraform.Source
+	}
+	if !strings.HasPrefix(source, "/") && !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+		return ""
+	}
+
+	// The double slash separates the folder Terragrunt copies from the folder of the module in it
+	folder, err := util.CanonicalPath(strings.Replace(source, "//", "/", -1), module.Path)
+	if err != nil || !util.IsDir(folder) {
+		return ""
+	}
+	return folder
+}
+
+// Return the addresses of the resources in the state of the given module, by running terraform state list in it
+func findAddressesInState(module *TerraformModule) ([]string, error) {
+	return listStateAddresses(module.Path, module.TerragruntOptions)
+}
+
+// Return the addresses of the resources in the state of the module at the given path, by running terraform state list
+// with the RunTerragrunt command of the given options of that module
+func listStateAddresses(modulePath string, terragruntOptions *options.TerragruntOptions) ([]string, error) {
+	var output bytes.Buffer
+
+	stateListOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	stateListOptions.TerraformCliArgs = []string{"state", "list"}
+	stateListOptions.Writer = &output
+	stateListOptions.NoPty = true
+
+	if err := stateListOptions.RunTerragrunt(stateListOptions); err != nil {
+		return nil, errors.WithStackTrace(StateListFailed{ModulePath: modulePath, Underlying: err})
+	}
+
+	addresses := []string{}
+	for _, line := range strings.Split(output.String(), "\n") {
+		if address := strings.TrimSpace(line); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses, nil
+}
+
+// Custom error types
+
+type TargetNotInStack struct {
+	Target    string
+	FromState bool
+}
+
+func (err TargetNotInStack) Error() string {
+	if err.FromState {
+		return fmt.Sprintf("Could not find %s in the state of any module in the stack", err.Target)
+	}
+	return fmt.Sprintf("Could not find %s in the Terraform code of any module in the stack. If the code of the module that contains it isn't local, pass --terragrunt-target-from-state to look it up in the state instead.", err.Target)
+}
+
+type StateListFailed struct {
+	ModulePath string
+	Underlying error
+}
+
+func (err StateListFailed) Error() string {
+	return fmt.Sprintf("Could not list the resources in the state of module %s: %v", err.ModulePath, err.Underlying)
+}
+
+func (err StateListFailed) Unwrap() error {
+	return err.Underlying
+}
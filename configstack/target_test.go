@@ -0,0 +1,109 @@
+package configstack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressesContainTarget(t *testing.T) {
+	t.Parallel()
+
+	addresses := []string{"aws_instance.web", "data.aws_ami.ubuntu", "module.vpc", "aws_eip.ip[0]"}
+
+	testCases := []struct {
+		target   string
+		expected bool
+	}{
+		{"aws_instance.web", true},
+		{"aws_instance.web[1]", true},
+		{"aws_instance.we", false},
+		{"data.aws_ami.ubuntu", true},
+		{"module.vpc", true},
+		{"module.vpc.aws_subnet.private[0]", true},
+		{`module.vpc["a"].aws_subnet.private`, true},
+		{"module.vpc2", false},
+		{"aws_eip.ip", true},
+		{"aws_eip.ip[0]", true},
+		{"module", false},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, addressesContainTarget(addresses, testCase.target), "For target %s", testCase.target)
+	}
+}
+
+func TestSelectModulesForTargets(t *testing.T) {
+	t.Parallel()
+
+	tmpFolder, err := ioutil.TempDir("", "")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	defer os.RemoveAll(tmpFolder)
+
+	files := map[string]string{
+		"live/vpc/main.tf":            `module "vpc" { source = "../../modules/vpc" }`,
+		"live/app/main.tf":            `resource "aws_instance" "web" {}`,
+		"live/mysql/terraform.tfvars": "",
+		"modules/mysql/main.tf":       `resource "aws_db_instance" "main" {}`,
+		"modules/vpc/main.tf":         `resource "aws_vpc" "main" {}`,
+		"live/redis/elasticache.tf":   `data "aws_vpc" "main" {}`,
+	}
+	for path, contents := range files {
+		fullPath := util.JoinPath(tmpFolder, path)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		assert.Nil(t, ioutil.WriteFile(fullPath, []byte(contents), 0644))
+	}
+
+	testCases := []struct {
+		targets     []string
+		expected    map[string][]string
+		expectedErr bool
+	}{
+		{[]string{"aws_instance.web[0]"}, map[string][]string{"app": {"-target=aws_instance.web[0]"}}, false},
+		{[]string{"module.vpc.aws_vpc.main", "aws_db_instance.main"}, map[string][]string{"vpc": {"-target=module.vpc.aws_vpc.main"}, "mysql": {"-target=aws_db_instance.main"}}, false},
+		{[]string{"data.aws_vpc.main", "aws_instance.web"}, map[string][]string{"redis": {"-target=data.aws_vpc.main"}, "app": {"-target=aws_instance.web"}}, false},
+		{[]string{"aws_vpc.main"}, nil, true},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest(util.JoinPath(tmpFolder, "live", config.DefaultTerragruntConfigPath))
+		assert.Nil(t, err, "Unexpected error: %v", err)
+
+		stack := &Stack{Path: util.JoinPath(tmpFolder, "live")}
+		modulesByName := map[string]*TerraformModule{}
+		for _, name := range []string{"vpc", "app", "mysql", "redis"} {
+			modulePath := util.JoinPath(tmpFolder, "live", name)
+			module := &TerraformModule{Path: modulePath, TerragruntOptions: terragruntOptions.Clone(util.JoinPath(modulePath, config.DefaultTerragruntConfigPath))}
+			if name == "mysql" {
+				module.Config = config.TerragruntConfig{Terraform: &config.TerraformConfig{Source: "../../modules//mysql"}}
+			}
+			stack.Modules = append(stack.Modules, module)
+			modulesByName[name] = module
+		}
+
+		err = stack.SelectModulesForTargets(testCase.targets, false, terragruntOptions)
+		if testCase.expectedErr {
+			_, isTargetNotInStackErr := errors.Unwrap(err).(TargetNotInStack)
+			assert.True(t, isTargetNotInStackErr, "Expected a TargetNotInStack error for targets %v but got: %v", testCase.targets, err)
+			continue
+		}
+		assert.Nil(t, err, "Unexpected error for targets %v: %v", testCase.targets, err)
+
+		for name, module := range modulesByName {
+			expectedArgs, isSelected := testCase.expected[name]
+			assert.Equal(t, !isSelected, module.AssumeAlreadyApplied, "For module %s and targets %v", name, testCase.targets)
+			if isSelected {
+				assert.Equal(t, expectedArgs, module.TerragruntOptions.TerraformCliArgs, "For module %s and targets %v", name, testCase.targets)
+			} else {
+				assert.Empty(t, module.TerragruntOptions.TerraformCliArgs, "For module %s and targets %v", name, testCase.targets)
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/gruntwork-io/terragrunt/aws_helper"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
@@ -36,38 +37,54 @@ func CreateDynamoDbClient(awsRegion, awsProfile string, iamRoleArn string, terra
 	return dynamodb.New(session), nil
 }
 
-// Create the lock table in DynamoDB if it doesn't already exist
-func CreateLockTableIfNecessary(tableName string, client *dynamodb.DynamoDB, terragruntOptions *options.TerragruntOptions) error {
-	tableExists, err := LockTableExistsAndIsActive(tableName, client)
+// Create the lock table in DynamoDB if it doesn't already exist. If the table exists, but isn't in "active" state yet
+// (e.g. because another Terragrunt process is creating it at the same time), wait until it is.
+func CreateLockTableIfNecessary(tableName string, client dynamodbiface.DynamoDBAPI, terragruntOptions *options.TerragruntOptions) error {
+	tableStatus, err := getLockTableStatus(tableName, client, terragruntOptions)
 	if err != nil {
 		return err
 	}
 
-	if !tableExists {
+	switch tableStatus {
+	case "":
 		terragruntOptions.Logger.Printf("Lock table %s does not exist in DynamoDB. Will need to create it just this first time.", tableName)
 		return CreateLockTable(tableName, DEFAULT_READ_CAPACITY_UNITS, DEFAULT_WRITE_CAPACITY_UNITS, client, terragruntOptions)
+	case dynamodb.TableStatusActive:
+		return nil
+	default:
+		terragruntOptions.Logger.Printf("Lock table %s exists in DynamoDB, but is in %s state. Will wait for it to be in active state.", tableName, tableStatus)
+		return waitForTableToBeActive(tableName, client, MAX_RETRIES_WAITING_FOR_TABLE_TO_BE_ACTIVE, SLEEP_BETWEEN_TABLE_STATUS_CHECKS, terragruntOptions)
 	}
-
-	return nil
 }
 
 // Return true if the lock table exists in DynamoDB and is in "active" state
-func LockTableExistsAndIsActive(tableName string, client *dynamodb.DynamoDB) (bool, error) {
-	output, err := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+func LockTableExistsAndIsActive(tableName string, client dynamodbiface.DynamoDBAPI, terragruntOptions *options.TerragruntOptions) (bool, error) {
+	tableStatus, err := getLockTableStatus(tableName, client, terragruntOptions)
+	if err != nil {
+		return false, err
+	}
+
+	return tableStatus == dynamodb.TableStatusActive, nil
+}
+
+// Return the status of the lock table in DynamoDB (e.g. "CREATING" or "ACTIVE") or an empty string if the table does
+// not exist
+func getLockTableStatus(tableName string, client dynamodbiface.DynamoDBAPI, terragruntOptions *options.TerragruntOptions) (string, error) {
+	output, err := client.DescribeTableWithContext(terragruntOptions.GetContext(), &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
 	if err != nil {
 		if awsErr, isAwsErr := err.(awserr.Error); isAwsErr && awsErr.Code() == "ResourceNotFoundException" {
-			return false, nil
+			return "", nil
 		} else {
-			return false, errors.WithStackTrace(err)
+			return "", errors.WithStackTrace(err)
 		}
 	}
 
-	return *output.Table.TableStatus == dynamodb.TableStatusActive, nil
+	return aws.StringValue(output.Table.TableStatus), nil
 }
 
 // Create a lock table in DynamoDB and wait until it is in "active" state. If the table already exists, merely wait
 // until it is in "active" state.
-func CreateLockTable(tableName string, readCapacityUnits int, writeCapacityUnits int, client *dynamodb.DynamoDB, terragruntOptions *options.TerragruntOptions) error {
+func CreateLockTable(tableName string, readCapacityUnits int, writeCapacityUnits int, client dynamodbiface.DynamoDBAPI, terragruntOptions *options.TerragruntOptions) error {
 	tableCreateDeleteSemaphore.Acquire()
 	defer tableCreateDeleteSemaphore.Release()
 
@@ -81,7 +98,7 @@ func CreateLockTable(tableName string, readCapacityUnits int, writeCapacityUnits
 		&dynamodb.KeySchemaElement{AttributeName: aws.String(ATTR_LOCK_ID), KeyType: aws.String(dynamodb.KeyTypeHash)},
 	}
 
-	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+	_, err := client.CreateTableWithContext(terragruntOptions.GetContext(), &dynamodb.CreateTableInput{
 		TableName:            aws.String(tableName),
 		AttributeDefinitions: attributeDefinitions,
 		KeySchema:            keySchema,
@@ -99,11 +116,38 @@ func CreateLockTable(tableName string, readCapacityUnits int, writeCapacityUnits
 		}
 	}
 
-	return waitForTableToBeActive(tableName, client, MAX_RETRIES_WAITING_FOR_TABLE_TO_BE_ACTIVE, SLEEP_BETWEEN_TABLE_STATUS_CHECKS, terragruntOptions)
+	if err := waitForTableToBeActive(tableName, client, MAX_RETRIES_WAITING_FOR_TABLE_TO_BE_ACTIVE, SLEEP_BETWEEN_TABLE_STATUS_CHECKS, terragruntOptions); err != nil {
+		return err
+	}
+
+	return tagTable(tableName, terragruntOptions.DefaultTags, client, terragruntOptions)
+}
+
+// Add the given tags to the given table in DynamoDB. Does nothing if there are no tags.
+func tagTable(tableName string, tags map[string]string, client dynamodbiface.DynamoDBAPI, terragruntOptions *options.TerragruntOptions) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	output, err := client.DescribeTableWithContext(terragruntOptions.GetContext(), &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	terragruntOptions.Logger.Printf("Tagging table %s in DynamoDB", tableName)
+	dynamodbTags := []*dynamodb.Tag{}
+	for _, key := range util.SortedKeys(tags) {
+		dynamodbTags = append(dynamodbTags, &dynamodb.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	_, err = client.TagResourceWithContext(terragruntOptions.GetContext(), &dynamodb.TagResourceInput{
+		ResourceArn: output.Table.TableArn,
+		Tags:        dynamodbTags,
+	})
+	return errors.WithStackTrace(err)
 }
 
 // Delete the given table in DynamoDB
-func DeleteTable(tableName string, client *dynamodb.DynamoDB) error {
+func DeleteTable(tableName string, client dynamodbiface.DynamoDBAPI) error {
 	tableCreateDeleteSemaphore.Acquire()
 	defer tableCreateDeleteSemaphore.Release()
 
@@ -119,16 +163,16 @@ func isTableAlreadyBeingCreatedError(err error) bool {
 
 // Wait for the given DynamoDB table to be in the "active" state. If it's not in "active" state, sleep for the
 // specified amount of time, and try again, up to a maximum of maxRetries retries.
-func waitForTableToBeActive(tableName string, client *dynamodb.DynamoDB, maxRetries int, sleepBetweenRetries time.Duration, terragruntOptions *options.TerragruntOptions) error {
+func waitForTableToBeActive(tableName string, client dynamodbiface.DynamoDBAPI, maxRetries int, sleepBetweenRetries time.Duration, terragruntOptions *options.TerragruntOptions) error {
 	return waitForTableToBeActiveWithRandomSleep(tableName, client, maxRetries, sleepBetweenRetries, sleepBetweenRetries, terragruntOptions)
 }
 
 // Waits for the given table as described above, but sleeps a random amount of time greater than sleepBetweenRetriesMin
 // and less than sleepBetweenRetriesMax between tries. This is to avoid an AWS issue where all waiting requests fire at
 // the same time, which continually triggered AWS's "subscriber limit exceeded" API error.
-func waitForTableToBeActiveWithRandomSleep(tableName string, client *dynamodb.DynamoDB, maxRetries int, sleepBetweenRetriesMin time.Duration, sleepBetweenRetriesMax time.Duration, terragruntOptions *options.TerragruntOptions) error {
+func waitForTableToBeActiveWithRandomSleep(tableName string, client dynamodbiface.DynamoDBAPI, maxRetries int, sleepBetweenRetriesMin time.Duration, sleepBetweenRetriesMax time.Duration, terragruntOptions *options.TerragruntOptions) error {
 	for i := 0; i < maxRetries; i++ {
-		tableReady, err := LockTableExistsAndIsActive(tableName, client)
+		tableReady, err := LockTableExistsAndIsActive(tableName, client, terragruntOptions)
 		if err != nil {
 			return err
 		}
@@ -140,7 +184,9 @@ func waitForTableToBeActiveWithRandomSleep(tableName string, client *dynamodb.Dy
 
 		sleepBetweenRetries := util.GetRandomTime(sleepBetweenRetriesMin, sleepBetweenRetriesMax)
 		terragruntOptions.Logger.Printf("Table %s is not yet in active state. Will check again after %s.", tableName, sleepBetweenRetries)
-		time.Sleep(sleepBetweenRetries)
+		if err := util.SleepWithContext(terragruntOptions.GetContext(), sleepBetweenRetries); err != nil {
+			return err
+		}
 	}
 
 	return errors.WithStackTrace(TableActiveRetriesExceeded{TableName: tableName, Retries: maxRetries})
@@ -163,3 +209,7 @@ type TableDoesNotExist struct {
 func (err TableDoesNotExist) Error() string {
 	return fmt.Sprintf("Table %s does not exist in DynamoDB! Original error from AWS: %v", err.TableName, err.Underlying)
 }
+
+func (err TableDoesNotExist) Unwrap() error {
+	return err.Underlying
+}
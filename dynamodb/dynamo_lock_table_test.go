@@ -6,7 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
 	"github.com/stretchr/testify/assert"
@@ -85,3 +89,55 @@ func TestCreateLockTableIfNecessaryTableAlreadyExists(t *testing.T) {
 		assert.Nil(t, err, "Unexpected error: %v", err)
 	})
 }
+
+// A mock DynamoDB client that keeps track of the tables it has. Calling any method it doesn't override panics, since
+// the embedded interface is nil.
+type mockDynamoDbClient struct {
+	dynamodbiface.DynamoDBAPI
+	mutex        sync.Mutex
+	tables       map[string]string
+	createdTable string
+}
+
+func (client *mockDynamoDbClient) DescribeTableWithContext(ctx aws.Context, input *dynamodb.DescribeTableInput, opts ...request.Option) (*dynamodb.DescribeTableOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	status, exists := client.tables[aws.StringValue(input.TableName)]
+	if !exists {
+		return nil, awserr.New("ResourceNotFoundException", "Requested resource not found", nil)
+	}
+	return &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{TableStatus: aws.String(status)}}, nil
+}
+
+func (client *mockDynamoDbClient) CreateTableWithContext(ctx aws.Context, input *dynamodb.CreateTableInput, opts ...request.Option) (*dynamodb.CreateTableOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	client.createdTable = aws.StringValue(input.TableName)
+	client.tables[client.createdTable] = dynamodb.TableStatusActive
+	return &dynamodb.CreateTableOutput{}, nil
+}
+
+func TestCreateLockTableIfNecessaryMockClient(t *testing.T) {
+	t.Parallel()
+
+	mockOptions, err := options.NewTerragruntOptionsForTest("dynamo_lock_test_utils")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &mockDynamoDbClient{tables: map[string]string{"existing-table": dynamodb.TableStatusActive}}
+
+	err = CreateLockTableIfNecessary("existing-table", client, mockOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "", client.createdTable)
+
+	err = CreateLockTableIfNecessary("new-table", client, mockOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "new-table", client.createdTable)
+
+	isActive, err := LockTableExistsAndIsActive("new-table", client, mockOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.True(t, isActive)
+}
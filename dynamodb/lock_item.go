@@ -0,0 +1,59 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// The attribute of a lock item with the details of who holds the lock. Terraform uses the same name for its own locks.
+const ATTR_INFO = "Info"
+
+// Put an item with the given lock ID and info in the given lock table, unless there already is an item with that ID.
+// Return true if the item was put, which means the caller now holds the lock, and otherwise, false and the info of the
+// item that's already there, so the caller can tell who holds the lock.
+func PutLockItemIfNotExists(tableName string, lockId string, info string, client dynamodbiface.DynamoDBAPI, terragruntOptions *options.TerragruntOptions) (bool, string, error) {
+	_, err := client.PutItemWithContext(terragruntOptions.GetContext(), &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item: map[string]*dynamodb.AttributeValue{
+			ATTR_LOCK_ID: &dynamodb.AttributeValue{S: aws.String(lockId)},
+			ATTR_INFO:    &dynamodb.AttributeValue{S: aws.String(info)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(" + ATTR_LOCK_ID + ")"),
+	})
+	if err == nil {
+		return true, "", nil
+	}
+	if awsErr, isAwsErr := err.(awserr.Error); !isAwsErr || awsErr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+		return false, "", errors.WithStackTrace(err)
+	}
+
+	output, err := client.GetItemWithContext(terragruntOptions.GetContext(), &dynamodb.GetItemInput{
+		TableName:      aws.String(tableName),
+		Key:            map[string]*dynamodb.AttributeValue{ATTR_LOCK_ID: &dynamodb.AttributeValue{S: aws.String(lockId)}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return false, "", errors.WithStackTrace(err)
+	}
+
+	// The item may have been deleted since we tried to put ours, in which case there's no info, and the caller can
+	// simply try again
+	if infoAttr, hasInfo := output.Item[ATTR_INFO]; hasInfo {
+		return false, aws.StringValue(infoAttr.S), nil
+	}
+	return false, "", nil
+}
+
+// Delete the item with the given lock ID from the given lock table, which releases the lock. This doesn't take the
+// context of the run, as the lock must be released even if the run was cancelled.
+func DeleteLockItem(tableName string, lockId string, client dynamodbiface.DynamoDBAPI) error {
+	_, err := client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key:       map[string]*dynamodb.AttributeValue{ATTR_LOCK_ID: &dynamodb.AttributeValue{S: aws.String(lockId)}},
+	})
+	return errors.WithStackTrace(err)
+}
@@ -0,0 +1,88 @@
+package dynamodb
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+// A mock DynamoDB client that keeps the Info of the lock items of a single table, by lock ID
+type mockLockItemClient struct {
+	dynamodbiface.DynamoDBAPI
+	mutex sync.Mutex
+	items map[string]string
+}
+
+func (client *mockLockItemClient) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	lockId := aws.StringValue(input.Item[ATTR_LOCK_ID].S)
+	if _, exists := client.items[lockId]; exists {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+	client.items[lockId] = aws.StringValue(input.Item[ATTR_INFO].S)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (client *mockLockItemClient) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	info, exists := client.items[aws.StringValue(input.Key[ATTR_LOCK_ID].S)]
+	if !exists {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	return &dynamodb.GetItemOutput{Item: map[string]*dynamodb.AttributeValue{
+		ATTR_LOCK_ID: input.Key[ATTR_LOCK_ID],
+		ATTR_INFO:    &dynamodb.AttributeValue{S: aws.String(info)},
+	}}, nil
+}
+
+func (client *mockLockItemClient) DeleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	delete(client.items, aws.StringValue(input.Key[ATTR_LOCK_ID].S))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestPutLockItemIfNotExists(t *testing.T) {
+	t.Parallel()
+
+	mockOptions, err := options.NewTerragruntOptionsForTest("dynamo_lock_item_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &mockLockItemClient{items: map[string]string{}}
+
+	acquired, holder, err := PutLockItemIfNotExists("table", "bucket/key-terragrunt-run", "alice", client, mockOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.True(t, acquired)
+	assert.Equal(t, "", holder)
+
+	acquired, holder, err = PutLockItemIfNotExists("table", "bucket/key-terragrunt-run", "bob", client, mockOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.False(t, acquired)
+	assert.Equal(t, "alice", holder)
+
+	acquired, _, err = PutLockItemIfNotExists("table", "bucket/other-key-terragrunt-run", "bob", client, mockOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.True(t, acquired)
+
+	err = DeleteLockItem("table", "bucket/key-terragrunt-run", client)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	acquired, _, err = PutLockItemIfNotExists("table", "bucket/key-terragrunt-run", "bob", client, mockOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.True(t, acquired)
+	assert.Equal(t, map[string]string{"bucket/key-terragrunt-run": "bob", "bucket/other-key-terragrunt-run": "bob"}, client.items)
+}
@@ -1,18 +1,61 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
+	"reflect"
+	"runtime/debug"
+
 	goerrors "github.com/go-errors/errors"
 )
 
+// Sentinel errors for the kinds of failure that programs which run Terragrunt most often need to handle. The error
+// types for these failures match them with the Is function of this package, or of the standard library, e.g.
+// errors.Is(err, errors.ErrConfigNotFound), so callers don't need to know the specific error type, or which package
+// it's in.
+var (
+	ErrConfigNotFound   = stderrors.New("Terragrunt config not found")
+	ErrConfigInvalid    = stderrors.New("Terragrunt config is invalid")
+	ErrTerraformVersion = stderrors.New("Terraform version does not satisfy the version constraint")
+	ErrWrongAwsAccount  = stderrors.New("Wrong AWS account")
+	ErrWrongAwsRegion   = stderrors.New("Wrong AWS region")
+	ErrAssumeRoleFailed = stderrors.New("Failed to assume IAM role")
+	ErrHookFailed       = stderrors.New("Hook failed")
+	ErrPolicyViolation  = stderrors.New("Policy violation")
+	ErrDependencyFailed = stderrors.New("Dependency finished with an error")
+)
+
+// An error with the stack trace of the place it was wrapped. Unlike the errors of go-errors, it implements Unwrap, so
+// the Is and As functions of the standard library look through it to the underlying error.
+type Error struct {
+	goError *goerrors.Error
+}
+
+func (err *Error) Error() string {
+	return err.goError.Error()
+}
+
+// Return the error message, followed by the stack trace
+func (err *Error) ErrorStack() string {
+	return err.goError.ErrorStack()
+}
+
+// Return the underlying error, without the stack trace
+func (err *Error) Unwrap() error {
+	return err.goError.Err
+}
+
 // Wrap the given error in an Error type that contains the stack trace. If the given error already has a stack trace,
 // it is used directly. If the given error is nil, return nil.
 func WithStackTrace(err error) error {
 	if err == nil {
 		return nil
 	}
+	if stackErr, hasStack := err.(*Error); hasStack {
+		return stackErr
+	}
 
-	return goerrors.Wrap(err, 1)
+	return &Error{goerrors.Wrap(err, 1)}
 }
 
 // Wrap the given error in an Error type that contains the stack trace and has the given message prepended as part of
@@ -22,14 +65,41 @@ func WithStackTraceAndPrefix(err error, message string, args ...interface{}) err
 	if err == nil {
 		return nil
 	}
+	if stackErr, hasStack := err.(*Error); hasStack {
+		return &Error{goerrors.WrapPrefix(stackErr.goError, fmt.Sprintf(message, args...), 0)}
+	}
 
-	return goerrors.WrapPrefix(err, fmt.Sprintf(message, args...), 1)
+	return &Error{goerrors.WrapPrefix(err, fmt.Sprintf(message, args...), 1)}
 }
 
-// Returns true if actual is the same type of error as expected. This method unwraps the given error objects (if they
-// are wrapped in objects with a stacktrace) and then does a simple equality check on them.
+// Returns true if actual is the same type of error as expected. This method looks through the chain of errors wrapped
+// by actual, including the ones wrapped with a stacktrace, for an error that is equal to expected (after unwrapping the
+// stacktrace of expected too), or that matches expected with an Is method, as errors.Is does.
 func IsError(actual error, expected error) bool {
-	return goerrors.Is(actual, expected)
+	expected = Unwrap(expected)
+	if stderrors.Is(actual, expected) {
+		return true
+	}
+
+	for err := actual; err != nil; err = stderrors.Unwrap(err) {
+		if reflect.DeepEqual(Unwrap(err), expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// Report whether any error in the chain of errors wrapped by err matches target. This is errors.Is from the standard
+// library, so code that imports this package doesn't need to import both.
+func Is(err error, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// Find the first error in the chain of errors wrapped by err that can be assigned to target, which must be a pointer,
+// and if there is one, set target to it and return true. This is errors.As from the standard library, so code that
+// imports this package doesn't need to import both.
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
 }
 
 // If the given error is a wrapper that contains a stacktrace, unwrap it and return the original, underlying error.
@@ -39,12 +109,14 @@ func Unwrap(err error) error {
 		return nil
 	}
 
-	goError, isGoError := err.(*goerrors.Error)
-	if isGoError {
-		return goError.Err
+	switch stackErr := err.(type) {
+	case *Error:
+		return stackErr.goError.Err
+	case *goerrors.Error:
+		return stackErr.Err
+	default:
+		return err
 	}
-
-	return err
 }
 
 // Convert the given error to a string, including the stack trace if available
@@ -54,6 +126,8 @@ func PrintErrorWithStackTrace(err error) string {
 	}
 
 	switch underlyingErr := err.(type) {
+	case *Error:
+		return underlyingErr.ErrorStack()
 	case *goerrors.Error:
 		return underlyingErr.ErrorStack()
 	default:
@@ -61,16 +135,31 @@ func PrintErrorWithStackTrace(err error) string {
 	}
 }
 
-// A method that tries to recover from panics, and if it succeeds, calls the given onPanic function with an error that
-// explains the cause of the panic. This function should only be called from a defer statement.
+// A method that tries to recover from panics, and if it succeeds, calls the given onPanic function with a PanicError
+// that explains the cause of the panic. This function should only be called from a defer statement.
 func Recover(onPanic func(cause error)) {
 	if rec := recover(); rec != nil {
-		err, isError := rec.(error)
-		if !isError {
-			err = fmt.Errorf("%v", rec)
-		}
-		onPanic(WithStackTrace(err))
+		onPanic(WithStackTrace(PanicError{Value: rec, Stack: string(debug.Stack())}))
+	}
+}
+
+// The error Recover passes on for a panic: the value the code panicked with, and the stack trace of the goroutine that
+// panicked, from the point of the panic, which is what's needed to report the bug
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+func (err PanicError) Error() string {
+	return fmt.Sprintf("%v", err.Value)
+}
+
+// If the code panicked with an error, return it
+func (err PanicError) Unwrap() error {
+	if underlying, isError := err.Value.(error); isError {
+		return underlying
 	}
+	return nil
 }
 
 // Interface to determine if we can retrieve an exit status from an error
@@ -0,0 +1,102 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testError struct {
+	Name       string
+	Underlying error
+}
+
+func (err testError) Error() string {
+	return fmt.Sprintf("test error %s: %v", err.Name, err.Underlying)
+}
+
+func (err testError) Unwrap() error {
+	return err.Underlying
+}
+
+func (err testError) Is(target error) bool {
+	return target == ErrConfigInvalid
+}
+
+func TestWithStackTraceUnwrap(t *testing.T) {
+	t.Parallel()
+
+	original := testError{Name: "foo", Underlying: ErrConfigNotFound}
+	err := WithStackTrace(original)
+
+	assert.Equal(t, original, Unwrap(err))
+	assert.Equal(t, original, stderrors.Unwrap(err))
+	assert.Equal(t, original.Error(), err.Error())
+	assert.True(t, err == WithStackTrace(err), "An error with a stack trace should not be wrapped again")
+	assert.Contains(t, PrintErrorWithStackTrace(err), "errors_test.go")
+
+	assert.True(t, Is(err, ErrConfigNotFound))
+	assert.True(t, Is(err, ErrConfigInvalid))
+	assert.False(t, Is(err, ErrTerraformVersion))
+
+	var actual testError
+	if assert.True(t, As(err, &actual)) {
+		assert.Equal(t, "foo", actual.Name)
+	}
+}
+
+func TestWithStackTraceAndPrefix(t *testing.T) {
+	t.Parallel()
+
+	original := testError{Name: "foo", Underlying: ErrConfigNotFound}
+	err := WithStackTraceAndPrefix(WithStackTrace(original), "Reading %s", "bar")
+
+	assert.Equal(t, "Reading bar: "+original.Error(), err.Error())
+	assert.Equal(t, original, Unwrap(err))
+	assert.True(t, Is(err, ErrConfigNotFound))
+	assert.Nil(t, WithStackTraceAndPrefix(nil, "Reading %s", "bar"))
+}
+
+func TestIsError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		actual   error
+		expected error
+		isError  bool
+	}{
+		{testError{Name: "foo"}, testError{Name: "foo"}, true},
+		{WithStackTrace(testError{Name: "foo"}), testError{Name: "foo"}, true},
+		{WithStackTrace(testError{Name: "foo"}), WithStackTrace(testError{Name: "foo"}), true},
+		{fmt.Errorf("wrapped: %w", WithStackTrace(testError{Name: "foo"})), testError{Name: "foo"}, true},
+		{WithStackTrace(testError{Name: "foo", Underlying: ErrHookFailed}), ErrHookFailed, true},
+		{WithStackTrace(testError{Name: "foo"}), ErrConfigInvalid, true},
+		{WithStackTrace(testError{Name: "foo"}), testError{Name: "bar"}, false},
+		{WithStackTrace(testError{Name: "foo"}), ErrHookFailed, false},
+		{nil, testError{Name: "foo"}, false},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.isError, IsError(testCase.actual, testCase.expected), "For errors %v and %v", testCase.actual, testCase.expected)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+
+	var cause error
+	func() {
+		defer Recover(func(err error) { cause = err })
+		panic(ErrHookFailed)
+	}()
+
+	var panicErr PanicError
+	if assert.True(t, As(cause, &panicErr)) {
+		assert.Equal(t, ErrHookFailed, panicErr.Value)
+		assert.Contains(t, panicErr.Stack, "errors_test.go")
+	}
+	assert.True(t, Is(cause, ErrHookFailed))
+	assert.Equal(t, ErrHookFailed.Error(), cause.Error())
+}
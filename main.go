@@ -5,6 +5,7 @@ import (
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/shell"
 	"github.com/gruntwork-io/terragrunt/util"
+	urfavecli "github.com/urfave/cli"
 	"os"
 )
 
@@ -14,20 +15,21 @@ var VERSION string
 
 // The main entrypoint for Terragrunt
 func main() {
-	defer errors.Recover(checkForErrorsAndExit)
-
 	app := cli.CreateTerragruntCli(VERSION, os.Stdout, os.Stderr)
+	defer errors.Recover(func(cause error) { checkForErrorsAndExit(app, cause) })
+
 	err := app.Run(os.Args)
 
-	checkForErrorsAndExit(err)
+	checkForErrorsAndExit(app, err)
 }
 
-// If there is an error, display it in the console and exit with a non-zero exit code. Otherwise, exit 0.
-func checkForErrorsAndExit(err error) {
+// If there is an error, display it in the console, with any secrets redacted, and exit with a non-zero exit code.
+// Otherwise, exit 0.
+func checkForErrorsAndExit(app *urfavecli.App, err error) {
 	if err == nil {
 		os.Exit(0)
 	} else {
-		logger := util.CreateLogger("")
+		logger := util.CreateLoggerWithWriter(cli.ErrorLogWriter(app), "")
 		if os.Getenv("TERRAGRUNT_DEBUG") != "" {
 			logger.Println(errors.PrintErrorWithStackTrace(err))
 		} else {
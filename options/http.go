@@ -0,0 +1,89 @@
+package options
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// The environment variables that point the tools Terragrunt runs at a CA bundle: Terraform and other Go programs, git,
+// and the AWS SDKs and CLI (including Terraform's AWS provider), respectively
+var CA_BUNDLE_ENV_VARS = []string{"SSL_CERT_FILE", "GIT_SSL_CAINFO", "AWS_CA_BUNDLE"}
+
+// Return an HTTP client for the outbound calls Terragrunt makes itself, such as to a Terraform Registry, Vault, or the
+// AWS APIs, with the given timeout (zero for none). It uses the proxy in the HTTPS_PROXY, HTTP_PROXY, and NO_PROXY
+// environment variables, and, if CaBundlePath is set, trusts the CA certificates in that file on top of the system's,
+// so it works behind a TLS-intercepting proxy.
+func (terragruntOptions *TerragruntOptions) HttpClient(timeout time.Duration) (*http.Client, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if terragruntOptions.CaBundlePath != "" {
+		rootCAs, err := loadCaBundle(terragruntOptions.CaBundlePath)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	}
+
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// Set the environment variables in CA_BUNDLE_ENV_VARS to CaBundlePath, if it's set, so the tools Terragrunt runs, such
+// as Terraform and git, trust the same CAs. Variables that are already set are left alone.
+func (terragruntOptions *TerragruntOptions) SetCaBundleEnvVars() {
+	if terragruntOptions.CaBundlePath == "" {
+		return
+	}
+	for _, envVar := range CA_BUNDLE_ENV_VARS {
+		if terragruntOptions.Env[envVar] == "" {
+			terragruntOptions.Env[envVar] = terragruntOptions.CaBundlePath
+		}
+	}
+}
+
+// Return the system's CA certificates plus the PEM encoded ones in the file at the given path
+func loadCaBundle(path string) (*x509.CertPool, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStackTrace(InvalidCaBundle{Path: path, Reason: err.Error()})
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		// Not every platform can list its system CAs (e.g. Windows, before Go 1.18)
+		rootCAs = x509.NewCertPool()
+	}
+
+	if !rootCAs.AppendCertsFromPEM(contents) {
+		return nil, errors.WithStackTrace(InvalidCaBundle{Path: path, Reason: "it has no PEM encoded certificates"})
+	}
+
+	return rootCAs, nil
+}
+
+// Custom error types
+
+type InvalidCaBundle struct {
+	Path   string
+	Reason string
+}
+
+func (err InvalidCaBundle) Error() string {
+	return fmt.Sprintf("Could not load the CA bundle %s: %s", err.Path, err.Reason)
+}
@@ -0,0 +1,26 @@
+package options
+
+import (
+	"fmt"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// Report that Terragrunt is about to do the given operation, such as "download the Terraform source at <url>", which
+// needs network access. In offline mode, this returns an error, which the caller should return, so an offline run fails
+// fast with a clear error rather than hanging until a connection times out. Otherwise, this returns nil. Terraform
+// itself is never checked, as Terragrunt doesn't know which of its commands need the network.
+func (terragruntOptions *TerragruntOptions) CheckNetworkAccess(operation string) error {
+	if terragruntOptions.Offline {
+		return errors.WithStackTrace(NetworkAccessInOfflineMode(operation))
+	}
+	return nil
+}
+
+// Custom error types
+
+type NetworkAccessInOfflineMode string
+
+func (operation NetworkAccessInOfflineMode) Error() string {
+	return fmt.Sprintf("Terragrunt is running in offline mode, but it would need network access to %s. Make it available locally first (e.g. by running the same command once while online, so the source is cached in the download dir), or run without --terragrunt-offline.", string(operation))
+}
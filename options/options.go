@@ -1,14 +1,19 @@
 package options
 
 import (
+	"context"
 	"fmt"
 	"github.com/mitchellh/go-homedir"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/util"
 	"github.com/hashicorp/go-version"
@@ -30,12 +35,36 @@ type TerragruntOptions struct {
 	// Location of the terraform binary
 	TerraformPath string
 
+	// A command, plus its args, to run Terraform with, such as ["aws-vault", "exec", "prod", "--"]. Set from the
+	// terraform_binary_wrapper in the Terragrunt config.
+	TerraformBinaryWrapper []string
+
+	// If set, Terraform runs in a container of this Docker image, with TerraformPath as the entrypoint, rather than on
+	// the host. Set via --terragrunt-docker-image or the docker_image in the Terragrunt config.
+	TerraformDockerImage string
+
+	// If set, Terraform runs on another host over SSH rather than on this machine. Set from the remote_exec block in the
+	// Terragrunt config.
+	RemoteExec *RemoteExecOptions
+
 	// Version of terraform (obtained by running 'terraform version')
 	TerraformVersion *version.Version
 
 	// Whether we should prompt the user for confirmation or always assume "yes"
 	NonInteractive bool
 
+	// Whether we should automatically approve destructive operations (e.g. destroy-all) instead of prompting the user.
+	// Unlike NonInteractive, this also tells Terraform to skip its own approval prompts (e.g. via -auto-approve).
+	AutoApprove bool
+
+	// Whether we should always assume "no" for all prompts, instead of prompting the user. This takes precedence over
+	// NonInteractive for Terragrunt's own prompts, and can't be combined with AutoApprove.
+	AssumeNo bool
+
+	// If the user doesn't answer a prompt within this time, use the prompt's default answer, as if they had just pressed
+	// Enter. Zero means wait forever.
+	PromptTimeout time.Duration
+
 	// Whether we should automatically run terraform init if necessary when executing other commands
 	AutoInit bool
 
@@ -61,12 +90,207 @@ type TerragruntOptions struct {
 	// Download Terraform configurations specified in the Source parameter into this folder
 	DownloadDir string
 
+	// Delete the folders in DownloadDir that haven't been used for longer than this. Zero means no limit.
+	DownloadMaxAge time.Duration
+
+	// Delete the least recently used folders in DownloadDir until their total size, in bytes, is at most this. Zero
+	// means no limit.
+	DownloadMaxSize int64
+
+	// Delete the least recently used folders in DownloadDir until there are at most this many. Zero means no limit.
+	DownloadMaxEntries int
+
+	// The path to an SSH private key to use when downloading Terraform configurations from Git repos over SSH
+	SourceSshKeyPath string
+
+	// The name of an environment variable that contains a token to use when downloading Terraform configurations over
+	// HTTPS (e.g. a GitHub or GitLab personal access token)
+	SourceTokenEnvVar string
+
+	// If set to true, only fetch the latest commit of Git sources, rather than their whole history
+	SourceShallowClone bool
+
+	// If set to true, only check out the folder after the double-slash in Git sources, rather than the whole repo
+	SourceSparseCheckout bool
+
+	// If set to true, don't check out the submodules of Git sources
+	SourceNoSubmodules bool
+
 	// The ARN of an IAM Role to assume before running Terraform
 	IamRole string
 
+	// The ARNs of IAM roles to assume one after the other before running Terraform, each with the credentials of the
+	// one before it. If IamRole is also set, it's assumed last. Use IamRoleChain to get the full list.
+	IamRoles []string
+
+	// A web identity token (e.g. an OIDC token from a CI system), or the path to a file that contains one. If set,
+	// the first role of the IamRoleChain is assumed with AssumeRoleWithWebIdentity, so no AWS credentials are needed.
+	IamWebIdentityToken string
+
+	// The tags of the default_tags config, which Terragrunt adds to the resources it creates itself, such as the S3
+	// bucket and DynamoDB table of the remote state
+	DefaultTags map[string]string
+
 	// If set to true, continue running *-all commands even if a dependency has errors. This is mostly useful for 'output-all <some_variable>'. See https://github.com/gruntwork-io/terragrunt/issues/193
 	IgnoreDependencyErrors bool
 
+	// If set, the xxx-all commands only run the modules that changed relative to this git ref (e.g. a branch name or
+	// commit SHA), plus the modules that depend on them
+	GitDiffRef string
+
+	// If set to true, the xxx-all commands also look for modules in the folders that symlinks point to
+	FollowSymlinks bool
+
+	// If set, the xxx-all commands only process the modules whose config includes one of these config files, plus the
+	// modules they depend on, unless StrictInclude is set
+	ModulesThatInclude []string
+
+	// If set to true, the xxx-all commands don't process the dependencies of the modules selected with
+	// ModulesThatInclude
+	StrictInclude bool
+
+	// If set to true, Terragrunt caches parsed configs in DownloadDir, and uses the cached config as long as none of the
+	// files and environment variables it depends on change. See config.ParseConfigFile.
+	ParseCache bool
+
+	// If set to true and there is no Terragrunt config at TerragruntConfigPath, run Terraform in the working dir without
+	// one, instead of failing
+	AllowMissingConfig bool
+
+	// If set, the xxx-all commands only process the modules that contain one of these resource addresses (e.g.
+	// aws_instance.web or module.vpc), and pass each address they contain to Terraform with -target
+	Targets []string
+
+	// If set to true, the modules that contain the Targets are found by listing the resources in the state of each
+	// module, rather than by scanning the Terraform code of each module
+	TargetsFromState bool
+
+	// Dependencies to add to the ones the modules declare in their configs, each of the form <module>=<dependency>,
+	// where both are paths relative to the working dir
+	ExtraDependencies []string
+
+	// The values of the feature flags set with --terragrunt-feature, by name, which configs read with get_feature() to
+	// turn features, or whole modules, on and off
+	Features map[string]string
+
+	// Patterns for secrets, set with --terragrunt-redact-pattern: each match of these in the output of the commands whose
+	// output Terragrunt captures and logs, such as the hooks, and, through the Redactor, in the log output, is replaced
+	// with util.REDACTED_VALUE
+	RedactPatterns []*regexp.Regexp
+
+	// Redacts the secrets Terragrunt knows about, i.e., the values of the environment variables that look like secrets
+	// and the matches of the RedactPatterns, from the log output. See LogWriter. It's shared by all the clones of these
+	// options, so a secret one module learns, such as the credentials of an IAM role it assumed, is redacted everywhere.
+	// If it's nil, nothing is redacted.
+	Redactor *util.Redactor
+
+	// If set to true, redact the output of Terraform, and the other commands Terragrunt runs, with the Redactor too.
+	// Terraform then never runs in a pseudo-terminal, as its output has to go through Terragrunt.
+	RedactTerraformOutput bool
+
+	// If set to true, never run Terraform in a pseudo-terminal, even if stdin and stdout are terminals
+	NoPty bool
+
+	// If set to true, don't use colors in the Terragrunt log output, and pass -no-color to the Terraform commands that
+	// support it
+	NoColor bool
+
+	// The ANSI color code to use for the prefix of the log messages about this module. Only set during the xxx-all
+	// commands, so the output of each module stands out from the others.
+	LogColor string
+
+	// If set to true, don't log the progress (how many modules are done, running, and pending) of the xxx-all commands
+	NoProgress bool
+
+	// If set to true, Terragrunt doesn't log anything but errors, and the prompts the user has to answer: the Logger
+	// discards everything, and errors go to the ErrorLogger. This doesn't change the output of Terraform.
+	Quiet bool
+
+	// If set to true, as soon as a module fails during the xxx-all commands, don't start any more modules
+	FailFast bool
+
+	// If set to true, as soon as a module fails during the xxx-all commands, don't start any more modules and interrupt
+	// the ones that are running
+	FailFastInterrupt bool
+
+	// If set to true, the xxx-all commands skip the modules that finished in the previous run of the same command, so
+	// only the modules that failed or never ran run again
+	Resume bool
+
+	// If set, the xxx-all commands copy the Terraform output of each module into a <module path>.log file in this folder
+	LogDir string
+
+	// If set, a record of every Terraform command Terragrunt runs is written here. This is either the path of a local
+	// file, which gets one JSON record per line, or an S3 URL of the form s3://bucket/prefix, which gets one object per
+	// record. See the audit package.
+	AuditLog string
+
+	// The version of Terragrunt itself, for the audit log
+	TerragruntVersion string
+
+	// If set, record a CPU profile of the run to this file, a heap profile to <Profile>.heap, and how long each phase
+	// of the run took to <Profile>.phases.txt. See the telemetry package.
+	Profile string
+
+	// If set to true, log where each of the args of the Terraform command came from (the command line, the TF_CLI_ARGS
+	// environment variables, extra_arguments) and the final list of args Terraform gets
+	DebugArgs bool
+
+	// If set to true, write the Terraform command of each module, with its environment and var files, to a debug bundle
+	// in the module, so it can be reproduced without Terragrunt
+	Debug bool
+
+	// The path of a PEM encoded CA bundle to trust, on top of the system's CAs, for every outbound call, e.g. for a
+	// TLS-intercepting proxy. See HttpClient and SetCaBundleEnvVars.
+	CaBundlePath string
+
+	// If set to true, Terragrunt never makes a network call of its own, such as downloading a source, calling AWS, or
+	// checking for updates: it uses what's cached locally, and fails with an error if that isn't enough. See
+	// CheckNetworkAccess. Terraform itself may still make network calls.
+	Offline bool
+
+	// If set to true, Terraform doesn't call HashiCorp's checkpoint service (CHECKPOINT_DISABLE is set for it), and
+	// Terragrunt doesn't check for a newer release of its own, even if enabled, so nothing phones home during the run
+	DisableCheckpoint bool
+
+	// If set to true and the remote state S3 bucket is in a different region than the remote_state config says, use the
+	// bucket's region instead of failing
+	FixS3Region bool
+
+	// If set to true, fail on any setting in a Terragrunt config that Terragrunt doesn't know about, such as a typo in
+	// the name of a block, instead of silently ignoring it
+	StrictValidate bool
+
+	// If set to true, fail with an error on every deprecated behavior (see STRICT_CONTROLS), instead of logging a
+	// deprecation warning
+	Strict bool
+
+	// The deprecated behaviors to fail with an error on, even if Strict isn't set
+	StrictControls []string
+
+	// The module sources the catalog command lists modules from: Terraform Registry namespaces
+	// (tfr://<host>/<namespace>), Git repos, or local folders
+	CatalogSources []string
+
+	// If set, the outputs the output-from command reads are cached on disk in DownloadDir for this long, or until a
+	// command that can change them, such as apply, runs in their module
+	OutputCacheTTL time.Duration
+
+	// How long to wait for another run to release the run lock of a module with a run_lock block before giving up
+	RunLockTimeout time.Duration
+
+	// Args to add to the Terraform commands Terragrunt runs, in every module, each of the form <command>=<arg> (e.g.
+	// apply=-target=module.vpc), so they are only added to that command
+	TerraformArgs []string
+
+	// Shell commands to run in the working dir before and after the Terraform command, e.g. to lint the code or to
+	// send a notification. They don't need a Terragrunt config, so they also work with AllowMissingConfig.
+	BeforeHook string
+	AfterHook  string
+
+	// If you want stdin to come from somewhere other than os.stdin
+	Reader io.Reader
+
 	// If you want stdout to go somewhere other than os.stdout
 	Writer io.Writer
 
@@ -77,6 +301,24 @@ type TerragruntOptions struct {
 	// exposed here primarily so we can set it to a low value at test time.
 	MaxFoldersToCheck int
 
+	// The context for this run of Terragrunt. When it's cancelled, Terragrunt interrupts any command it is running,
+	// aborts any AWS API calls in progress, and doesn't start any new modules. Use GetContext to read it.
+	Context context.Context
+
+	// If set, Terragrunt calls this function to create the session for every AWS API call it makes (S3, DynamoDB, and
+	// STS), instead of creating one from the standard AWS environment variables and config files. The region, custom
+	// S3 endpoint, profile, and IAM role come from the remote_state config, and are empty for calls that don't use it.
+	// This lets programs that embed Terragrunt point it at a mock of AWS, such as localstack, in their tests.
+	AwsSessionFactory func(awsRegion string, customS3Endpoint string, awsProfile string, iamRoleArn string) (*session.Session, error)
+
+	// If set, the xxx-all commands call these functions with the path of each module that's going to run, and send the
+	// stdout and stderr of that module, including its log output, to the writers they return, instead of to the Writer
+	// and ErrWriter, which all the modules share otherwise. This lets programs that embed Terragrunt keep the output of
+	// each module apart, e.g. in a file or buffer per module. The xxx-all commands that process the output of the
+	// modules, such as state-list-all, still capture it.
+	ModuleWriterFactory    func(modulePath string) (io.Writer, error)
+	ModuleErrWriterFactory func(modulePath string) (io.Writer, error)
+
 	// A command that can be used to run Terragrunt with the given options. This is useful for running Terragrunt
 	// multiple times (e.g. when spinning up a stack of Terraform modules). The actual command is normally defined
 	// in the cli package, which depends on almost all other packages, so we declare it here so that other
@@ -111,6 +353,7 @@ func NewTerragruntOptions(terragruntConfigPath string) (*TerragruntOptions, erro
 		TerraformPath:          "terraform",
 		AutoInit:               true,
 		NonInteractive:         false,
+		AutoApprove:            false,
 		TerraformCliArgs:       []string{},
 		WorkingDir:             workingDir,
 		Logger:                 logger,
@@ -119,9 +362,11 @@ func NewTerragruntOptions(terragruntConfigPath string) (*TerragruntOptions, erro
 		SourceUpdate:           false,
 		DownloadDir:            downloadDir,
 		IgnoreDependencyErrors: false,
+		Reader:                 os.Stdin,
 		Writer:                 os.Stdout,
 		ErrWriter:              os.Stderr,
 		MaxFoldersToCheck:      DEFAULT_MAX_FOLDERS_TO_CHECK,
+		Context:                context.Background(),
 		RunTerragrunt: func(terragruntOptions *TerragruntOptions) error {
 			return errors.WithStackTrace(RunTerragruntCommandNotSet)
 		},
@@ -154,27 +399,136 @@ func (terragruntOptions *TerragruntOptions) Clone(terragruntConfigPath string) *
 	return &TerragruntOptions{
 		TerragruntConfigPath:   terragruntConfigPath,
 		TerraformPath:          terragruntOptions.TerraformPath,
+		TerraformBinaryWrapper: util.CloneStringList(terragruntOptions.TerraformBinaryWrapper),
+		TerraformDockerImage:   terragruntOptions.TerraformDockerImage,
+		RemoteExec:             terragruntOptions.RemoteExec.Clone(),
 		TerraformVersion:       terragruntOptions.TerraformVersion,
 		AutoInit:               terragruntOptions.AutoInit,
 		NonInteractive:         terragruntOptions.NonInteractive,
+		AutoApprove:            terragruntOptions.AutoApprove,
+		AssumeNo:               terragruntOptions.AssumeNo,
+		PromptTimeout:          terragruntOptions.PromptTimeout,
 		TerraformCliArgs:       util.CloneStringList(terragruntOptions.TerraformCliArgs),
 		WorkingDir:             workingDir,
-		Logger:                 util.CreateLoggerWithWriter(terragruntOptions.ErrWriter, workingDir),
+		Logger:                 util.CreateColoredLoggerWithWriter(terragruntOptions.LogWriter(), workingDir, terragruntOptions.LogColor),
 		Env:                    util.CloneStringMap(terragruntOptions.Env),
 		Source:                 terragruntOptions.Source,
 		SourceUpdate:           terragruntOptions.SourceUpdate,
 		DownloadDir:            terragruntOptions.DownloadDir,
+		DownloadMaxAge:         terragruntOptions.DownloadMaxAge,
+		DownloadMaxSize:        terragruntOptions.DownloadMaxSize,
+		DownloadMaxEntries:     terragruntOptions.DownloadMaxEntries,
+		SourceSshKeyPath:       terragruntOptions.SourceSshKeyPath,
+		SourceTokenEnvVar:      terragruntOptions.SourceTokenEnvVar,
+		SourceShallowClone:     terragruntOptions.SourceShallowClone,
+		SourceSparseCheckout:   terragruntOptions.SourceSparseCheckout,
+		SourceNoSubmodules:     terragruntOptions.SourceNoSubmodules,
 		IamRole:                terragruntOptions.IamRole,
+		IamRoles:               util.CloneStringList(terragruntOptions.IamRoles),
+		IamWebIdentityToken:    terragruntOptions.IamWebIdentityToken,
+		DefaultTags:            util.CloneStringMap(terragruntOptions.DefaultTags),
 		IgnoreDependencyErrors: terragruntOptions.IgnoreDependencyErrors,
+		GitDiffRef:             terragruntOptions.GitDiffRef,
+		FollowSymlinks:         terragruntOptions.FollowSymlinks,
+		ModulesThatInclude:     util.CloneStringList(terragruntOptions.ModulesThatInclude),
+		StrictInclude:          terragruntOptions.StrictInclude,
+		ParseCache:             terragruntOptions.ParseCache,
+		AllowMissingConfig:     terragruntOptions.AllowMissingConfig,
+		Targets:                util.CloneStringList(terragruntOptions.Targets),
+		TargetsFromState:       terragruntOptions.TargetsFromState,
+		ExtraDependencies:      util.CloneStringList(terragruntOptions.ExtraDependencies),
+		Features:               util.CloneStringMap(terragruntOptions.Features),
+		RedactPatterns:         append([]*regexp.Regexp{}, terragruntOptions.RedactPatterns...),
+		Redactor:               terragruntOptions.Redactor,
+		RedactTerraformOutput:  terragruntOptions.RedactTerraformOutput,
+		NoPty:                  terragruntOptions.NoPty,
+		NoColor:                terragruntOptions.NoColor,
+		LogColor:               terragruntOptions.LogColor,
+		NoProgress:             terragruntOptions.NoProgress,
+		Quiet:                  terragruntOptions.Quiet,
+		FailFast:               terragruntOptions.FailFast,
+		FailFastInterrupt:      terragruntOptions.FailFastInterrupt,
+		Resume:                 terragruntOptions.Resume,
+		LogDir:                 terragruntOptions.LogDir,
+		AuditLog:               terragruntOptions.AuditLog,
+		TerragruntVersion:      terragruntOptions.TerragruntVersion,
+		Profile:                terragruntOptions.Profile,
+		DebugArgs:              terragruntOptions.DebugArgs,
+		Debug:                  terragruntOptions.Debug,
+		StrictValidate:         terragruntOptions.StrictValidate,
+		Strict:                 terragruntOptions.Strict,
+		StrictControls:         util.CloneStringList(terragruntOptions.StrictControls),
+		CaBundlePath:           terragruntOptions.CaBundlePath,
+		Offline:                terragruntOptions.Offline,
+		DisableCheckpoint:      terragruntOptions.DisableCheckpoint,
+		FixS3Region:            terragruntOptions.FixS3Region,
+		CatalogSources:         util.CloneStringList(terragruntOptions.CatalogSources),
+		OutputCacheTTL:         terragruntOptions.OutputCacheTTL,
+		RunLockTimeout:         terragruntOptions.RunLockTimeout,
+		TerraformArgs:          util.CloneStringList(terragruntOptions.TerraformArgs),
+		BeforeHook:             terragruntOptions.BeforeHook,
+		AfterHook:              terragruntOptions.AfterHook,
+		Reader:                 terragruntOptions.Reader,
 		Writer:                 terragruntOptions.Writer,
 		ErrWriter:              terragruntOptions.ErrWriter,
 		MaxFoldersToCheck:      terragruntOptions.MaxFoldersToCheck,
+		Context:                terragruntOptions.Context,
+		AwsSessionFactory:      terragruntOptions.AwsSessionFactory,
+		ModuleWriterFactory:    terragruntOptions.ModuleWriterFactory,
+		ModuleErrWriterFactory: terragruntOptions.ModuleErrWriterFactory,
 		RunTerragrunt:          terragruntOptions.RunTerragrunt,
 	}
 }
 
-// Inserts the given argsToInsert after the terraform command argument, but before the remaining args
+// Return the context for this run of Terragrunt, or an empty context that is never cancelled if none was set
+func (terragruntOptions *TerragruntOptions) GetContext() context.Context {
+	if terragruntOptions.Context == nil {
+		return context.Background()
+	}
+	return terragruntOptions.Context
+}
+
+// Return the writer Terragrunt's own log output should go to: the ErrWriter, with the secrets the Redactor knows about
+// redacted, or nowhere in Quiet mode. Use this, rather than the ErrWriter itself, to create a Logger.
+func (terragruntOptions *TerragruntOptions) LogWriter() io.Writer {
+	if terragruntOptions.Quiet {
+		return ioutil.Discard
+	}
+	return terragruntOptions.ErrorLogWriter()
+}
+
+// Return the writer for the errors Terragrunt logs, and anything else the user must see, such as prompts: the
+// ErrWriter, with the secrets the Redactor knows about redacted, even in Quiet mode
+func (terragruntOptions *TerragruntOptions) ErrorLogWriter() io.Writer {
+	return util.NewRedactingWriter(terragruntOptions.ErrWriter, terragruntOptions.Redactor)
+}
+
+// Return the logger for errors, and anything else the user must see, such as prompts. That's the Logger, unless
+// Terragrunt runs in Quiet mode, in which case it's a logger with the same prefix that writes to the ErrorLogWriter.
+func (terragruntOptions *TerragruntOptions) ErrorLogger() *log.Logger {
+	if !terragruntOptions.Quiet {
+		return terragruntOptions.Logger
+	}
+	return log.New(terragruntOptions.ErrorLogWriter(), terragruntOptions.Logger.Prefix(), terragruntOptions.Logger.Flags())
+}
+
+// Return the ARNs of the IAM roles to assume, in order: the IamRoles, followed by the IamRole, if set
+func (terragruntOptions *TerragruntOptions) IamRoleChain() []string {
+	chain := util.CloneStringList(terragruntOptions.IamRoles)
+	if terragruntOptions.IamRole != "" {
+		chain = append(chain, terragruntOptions.IamRole)
+	}
+	return chain
+}
+
+// Inserts the given argsToInsert after the terraform command argument, but before the remaining args. Each arg is a
+// single entry in the argv of the Terraform process, passed through as is: args are never joined into a string or
+// re-split on whitespace, so they may contain spaces, quotes, and other characters special to a shell.
 func (terragruntOptions *TerragruntOptions) InsertTerraformCliArgs(argsToInsert ...string) {
+	if len(terragruntOptions.TerraformCliArgs) == 0 {
+		terragruntOptions.TerraformCliArgs = util.CloneStringList(argsToInsert)
+		return
+	}
 
 	commandLength := 1
 	if util.ListContainsElement(TERRAFORM_COMMANDS_WITH_SUBCOMMAND, terragruntOptions.TerraformCliArgs[0]) {
@@ -185,16 +539,47 @@ func (terragruntOptions *TerragruntOptions) InsertTerraformCliArgs(argsToInsert
 
 	// Options must be inserted after command but before the other args
 	// command is either 1 word or 2 words
-	var args []string
+	args := make([]string, 0, len(terragruntOptions.TerraformCliArgs)+len(argsToInsert))
 	args = append(args, terragruntOptions.TerraformCliArgs[:commandLength]...)
 	args = append(args, argsToInsert...)
 	args = append(args, terragruntOptions.TerraformCliArgs[commandLength:]...)
 	terragruntOptions.TerraformCliArgs = args
 }
 
-// Appends the given argsToAppend after the current TerraformCliArgs
+// Appends the given argsToAppend after the current TerraformCliArgs. As with InsertTerraformCliArgs, each arg is passed
+// to Terraform as is. We always build a new list, as the current one may share its backing array with the args of
+// another TerragruntOptions (e.g. one it was sliced from), which appending in place would overwrite.
 func (terragruntOptions *TerragruntOptions) AppendTerraformCliArgs(argsToAppend ...string) {
-	terragruntOptions.TerraformCliArgs = append(terragruntOptions.TerraformCliArgs, argsToAppend...)
+	args := make([]string, 0, len(terragruntOptions.TerraformCliArgs)+len(argsToAppend))
+	args = append(args, terragruntOptions.TerraformCliArgs...)
+	args = append(args, argsToAppend...)
+	terragruntOptions.TerraformCliArgs = args
+}
+
+// RemoteExecOptions are the settings for running Terraform on another host over SSH, such as a runner inside a private
+// network that can reach the resources of the module
+type RemoteExecOptions struct {
+	// The host to SSH to, optionally with a user, such as ubuntu@runner.internal
+	Host string
+
+	// Extra args for ssh, such as ["-i", "~/.ssh/runner", "-p", "2222"]
+	SshArgs []string
+
+	// If true, the working dir and the folder of the Terragrunt config are copied to the host before each command, and
+	// the working dir is copied back after it. Otherwise, they must already be at the same paths on the host.
+	SyncFolders bool
+}
+
+// Create a copy of these options, or nil if they're nil
+func (remoteExecOptions *RemoteExecOptions) Clone() *RemoteExecOptions {
+	if remoteExecOptions == nil {
+		return nil
+	}
+	return &RemoteExecOptions{
+		Host:        remoteExecOptions.Host,
+		SshArgs:     util.CloneStringList(remoteExecOptions.SshArgs),
+		SyncFolders: remoteExecOptions.SyncFolders,
+	}
 }
 
 // Custom error types
@@ -0,0 +1,187 @@
+package options
+
+import (
+	"bytes"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertTerraformCliArgs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args         []string
+		argsToInsert []string
+		expected     []string
+	}{
+		{[]string{}, []string{"-input=false"}, []string{"-input=false"}},
+		{[]string{"plan"}, []string{"-var", `tags={"a"="b c"}`}, []string{"plan", "-var", `tags={"a"="b c"}`}},
+		{[]string{"apply", "my plan"}, []string{"-var=name=it's a test", ""}, []string{"apply", "-var=name=it's a test", "", "my plan"}},
+		{[]string{"state", "list", "aws_instance.foo"}, []string{`-state=C:\Program Files\state\`}, []string{"state", "list", `-state=C:\Program Files\state\`, "aws_instance.foo"}},
+		{[]string{"state"}, []string{"-lock=false"}, []string{"state", "-lock=false"}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.TerraformCliArgs = testCase.args
+
+		terragruntOptions.InsertTerraformCliArgs(testCase.argsToInsert...)
+		assert.Equal(t, testCase.expected, terragruntOptions.TerraformCliArgs, "For args %v", testCase.args)
+	}
+}
+
+func TestAppendTerraformCliArgsDoesNotModifySharedArgs(t *testing.T) {
+	t.Parallel()
+
+	allArgs := []string{"apply-all", "plan", "-var", "a=b c", "spare"}
+
+	terragruntOptions, err := NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.TerraformCliArgs = allArgs[1:4]
+
+	terragruntOptions.AppendTerraformCliArgs("-var", `x="y z"`)
+
+	assert.Equal(t, []string{"plan", "-var", "a=b c", "-var", `x="y z"`}, terragruntOptions.TerraformCliArgs)
+	assert.Equal(t, []string{"apply-all", "plan", "-var", "a=b c", "spare"}, allArgs)
+}
+
+func TestIamRoleChain(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		iamRoles []string
+		iamRole  string
+		expected []string
+	}{
+		{nil, "", []string{}},
+		{nil, "arn:aws:iam::222222222222:role/prod", []string{"arn:aws:iam::222222222222:role/prod"}},
+		{[]string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::222222222222:role/prod"}, "", []string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::222222222222:role/prod"}},
+		{[]string{"arn:aws:iam::111111111111:role/bastion"}, "arn:aws:iam::222222222222:role/prod", []string{"arn:aws:iam::111111111111:role/bastion", "arn:aws:iam::222222222222:role/prod"}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.IamRoles = testCase.iamRoles
+		terragruntOptions.IamRole = testCase.iamRole
+
+		assert.Equal(t, testCase.expected, terragruntOptions.IamRoleChain(), "For IamRoles %v and IamRole %s", testCase.iamRoles, testCase.iamRole)
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		strict         bool
+		strictControls []string
+		expectedErr    bool
+	}{
+		{false, nil, false},
+		{false, []string{STRICT_CONTROL_LOCK_TABLE}, false},
+		{false, []string{STRICT_CONTROL_LOCK_TABLE, STRICT_CONTROL_OLD_CONFIG_FILE}, true},
+		{true, nil, true},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.Strict = testCase.strict
+		terragruntOptions.StrictControls = testCase.strictControls
+
+		err = terragruntOptions.Deprecated(STRICT_CONTROL_OLD_CONFIG_FILE, "Found deprecated config file format.")
+		if testCase.expectedErr {
+			_, isDeprecatedBehaviorErr := errors.Unwrap(err).(DeprecatedBehaviorError)
+			assert.True(t, isDeprecatedBehaviorErr, "Expected a DeprecatedBehaviorError for %v but got: %v", testCase, err)
+		} else {
+			assert.Nil(t, err, "Unexpected error for %v: %v", testCase, err)
+		}
+	}
+}
+
+func TestCheckNetworkAccess(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	assert.Nil(t, terragruntOptions.CheckNetworkAccess("download the source"))
+
+	terragruntOptions.Offline = true
+	err = terragruntOptions.CheckNetworkAccess("download the source")
+	_, isOfflineErr := errors.Unwrap(err).(NetworkAccessInOfflineMode)
+	assert.True(t, isOfflineErr, "Expected a NetworkAccessInOfflineMode error but got: %v", err)
+
+	// Clones run in offline mode too
+	assert.NotNil(t, terragruntOptions.Clone("other/terraform.tfvars").CheckNetworkAccess("download the source"))
+}
+
+func TestQuiet(t *testing.T) {
+	t.Parallel()
+
+	var errOutput bytes.Buffer
+	terragruntOptions, err := NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.ErrWriter = &errOutput
+	terragruntOptions.Quiet = true
+
+	// Clones log to the LogWriter, which discards everything in quiet mode
+	moduleOptions := terragruntOptions.Clone("vpc/terraform.tfvars")
+	moduleOptions.Logger.Printf("Running command: terraform plan")
+	assert.Empty(t, errOutput.String())
+
+	moduleOptions.ErrorLogger().Printf("Module vpc failed")
+	assert.Contains(t, errOutput.String(), "[terragrunt] [vpc] ")
+	assert.Contains(t, errOutput.String(), "Module vpc failed")
+
+	moduleOptions.Quiet = false
+	assert.Equal(t, moduleOptions.Logger, moduleOptions.ErrorLogger())
+}
+
+func TestHttpClientCaBundle(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caBundle, err := ioutil.TempFile("", "ca-bundle")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	defer os.Remove(caBundle.Name())
+	assert.Nil(t, pem.Encode(caBundle, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	assert.Nil(t, caBundle.Close())
+
+	terragruntOptions, err := NewTerragruntOptionsForTest("mock-path-for-test.hcl")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	// The server's certificate is self-signed, so only a client that trusts the bundle can connect
+	httpClient, err := terragruntOptions.HttpClient(0)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	_, err = httpClient.Get(server.URL)
+	assert.NotNil(t, err)
+
+	terragruntOptions.CaBundlePath = caBundle.Name()
+	httpClient, err = terragruntOptions.HttpClient(0)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	resp, err := httpClient.Get(server.URL)
+	if assert.Nil(t, err, "Unexpected error: %v", err) {
+		resp.Body.Close()
+	}
+
+	terragruntOptions.Env = map[string]string{"GIT_SSL_CAINFO": "/etc/git-ca.pem"}
+	terragruntOptions.SetCaBundleEnvVars()
+	assert.Equal(t, map[string]string{"SSL_CERT_FILE": caBundle.Name(), "GIT_SSL_CAINFO": "/etc/git-ca.pem", "AWS_CA_BUNDLE": caBundle.Name()}, terragruntOptions.Env)
+
+	for _, path := range []string{"/does/not/exist.pem", "options.go"} {
+		terragruntOptions.CaBundlePath = path
+		_, err = terragruntOptions.HttpClient(0)
+		_, isInvalidCaBundleErr := errors.Unwrap(err).(InvalidCaBundle)
+		assert.True(t, isInvalidCaBundleErr, "Expected an InvalidCaBundle error for %s but got: %v", path, err)
+	}
+}
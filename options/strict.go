@@ -0,0 +1,59 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// The deprecated behaviors that strict mode can turn into errors, one by one with StrictControls, or all at once with
+// Strict. Each of them still works, with a deprecation warning, unless strict mode is on for it.
+const (
+	// The spin-up and tear-down commands, which run apply-all and destroy-all instead
+	STRICT_CONTROL_DEPRECATED_COMMANDS = "deprecated-commands"
+
+	// Terragrunt config in a .terragrunt file rather than in a terraform.tfvars file
+	STRICT_CONTROL_OLD_CONFIG_FILE = "old-config-file"
+
+	// The lock_table setting of the S3 backend, which has been renamed to dynamodb_table
+	STRICT_CONTROL_LOCK_TABLE = "lock-table"
+)
+
+var STRICT_CONTROLS = []string{STRICT_CONTROL_DEPRECATED_COMMANDS, STRICT_CONTROL_OLD_CONFIG_FILE, STRICT_CONTROL_LOCK_TABLE}
+
+// Return true if strict mode is on for the given deprecated behavior, i.e. either Strict is set, or the behavior is one
+// of the StrictControls
+func (terragruntOptions *TerragruntOptions) IsStrict(control string) bool {
+	if terragruntOptions.Strict {
+		return true
+	}
+	for _, strictControl := range terragruntOptions.StrictControls {
+		if strictControl == control {
+			return true
+		}
+	}
+	return false
+}
+
+// Report that the deprecated behavior with the given strict control is used, as described by the given message. If
+// strict mode is on for it, this returns an error, which the caller should return, so the behavior is never used.
+// Otherwise, this logs a deprecation warning and returns nil.
+func (terragruntOptions *TerragruntOptions) Deprecated(control string, message string) error {
+	if terragruntOptions.IsStrict(control) {
+		return errors.WithStackTrace(DeprecatedBehaviorError{Control: control, Message: message})
+	}
+	terragruntOptions.Logger.Printf("DEPRECATION WARNING: %s", message)
+	return nil
+}
+
+// Custom error types
+
+type DeprecatedBehaviorError struct {
+	Control string
+	Message string
+}
+
+func (err DeprecatedBehaviorError) Error() string {
+	return fmt.Sprintf("%s This is an error because strict mode is on for %s.", strings.TrimSpace(err.Message), err.Control)
+}
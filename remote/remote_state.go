@@ -2,18 +2,16 @@ package remote
 
 import (
 	"fmt"
-	"reflect"
-	"strconv"
+	"strings"
 
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
-	"github.com/gruntwork-io/terragrunt/util"
 )
 
 // Configuration for Terraform remote state
 type RemoteState struct {
-	Backend string                 `hcl:"backend"`
-	Config  map[string]interface{} `hcl:"config"`
+	Backend string                 `hcl:"backend" json:"backend"`
+	Config  map[string]interface{} `hcl:"config" json:"config"`
 }
 
 func (remoteState *RemoteState) String() string {
@@ -28,6 +26,17 @@ type RemoteStateInitializer interface {
 	Initialize(config map[string]interface{}, terragruntOptions *options.TerragruntOptions) error
 }
 
+// The settings of each backend that together identify where it stores a state, so two modules whose remote_state configs
+// have the same values for them share a state. Other settings, such as the region or credentials, don't change which
+// state Terraform reads and writes.
+var STATE_LOCATION_CONFIG_KEYS = map[string][]string{
+	"s3":      {"bucket", "key"},
+	"gcs":     {"bucket", "prefix"},
+	"azurerm": {"storage_account_name", "container_name", "key"},
+	"consul":  {"address", "path"},
+	"http":    {"address"},
+}
+
 // TODO: initialization actions for other remote state backends can be added here
 var remoteStateInitializers = map[string]RemoteStateInitializer{
 	"s3": S3Initializer{},
@@ -91,26 +100,9 @@ func (remoteState *RemoteState) NeedsInit(terragruntOptions *options.TerragruntO
 
 // Returns true if this remote state is different than the given remote state that is currently being used by terraform.
 func (remoteState *RemoteState) differsFrom(existingBackend *TerraformBackend, terragruntOptions *options.TerragruntOptions) bool {
-	if existingBackend.Type != remoteState.Backend {
-		terragruntOptions.Logger.Printf("Backend type has changed from %s to %s", existingBackend.Type, remoteState.Backend)
-		return true
-	}
-
-	// Terraform's `backend` configuration uses a boolean for the `encrypt` parameter. However, perhaps for backwards compatibility reasons,
-	// Terraform stores that parameter as a string in the `terraform.tfstate` file. Therefore, we have to convert it accordingly, or `DeepEqual`
-	// will fail.
-	if util.KindOf(existingBackend.Config["encrypt"]) == reflect.String && util.KindOf(remoteState.Config["encrypt"]) == reflect.Bool {
-		// If encrypt in remoteState is a bool and a string in existingBackend, DeepEqual will consider the maps to be different.
-		// So we convert the value from string to bool to make them equivalent.
-		if value, err := strconv.ParseBool(existingBackend.Config["encrypt"].(string)); err == nil {
-			existingBackend.Config["encrypt"] = value
-		} else {
-			terragruntOptions.Logger.Printf("Remote state configuration encrypt contains invalid value %v, should be boolean.", existingBackend.Config["encrypt"])
-		}
-	}
-
-	if !reflect.DeepEqual(existingBackend.Config, remoteState.Config) {
-		terragruntOptions.Logger.Printf("Backend config has changed from %s to %s", existingBackend.Config, remoteState.Config)
+	drift := remoteState.diffFrom(existingBackend, terragruntOptions)
+	if drift.HasChanges() {
+		terragruntOptions.Logger.Printf("Backend config has changed:\n%s", drift)
 		return true
 	}
 
@@ -129,4 +121,24 @@ func (remoteState RemoteState) ToTerraformInitArgs() []string {
 	return backendConfigArgs
 }
 
+// Return where this remote state stores the state, as the backend followed by the values of its
+// STATE_LOCATION_CONFIG_KEYS, such as s3://my-bucket/prod/mysql/terraform.tfstate, or an empty string for the backends
+// that aren't in STATE_LOCATION_CONFIG_KEYS, such as local, whose path is relative to the module
+func (remoteState *RemoteState) StateLocation() string {
+	configKeys, hasConfigKeys := STATE_LOCATION_CONFIG_KEYS[remoteState.Backend]
+	if !hasConfigKeys {
+		return ""
+	}
+
+	values := []string{}
+	for _, configKey := range configKeys {
+		value, isSet := remoteState.Config[configKey]
+		if !isSet {
+			value = ""
+		}
+		values = append(values, fmt.Sprintf("%v", value))
+	}
+	return fmt.Sprintf("%s://%s", remoteState.Backend, strings.Join(values, "/"))
+}
+
 var RemoteBackendMissing = fmt.Errorf("The remote_state.backend field cannot be empty")
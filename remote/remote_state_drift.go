@@ -0,0 +1,110 @@
+package remote
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// A setting of the backend config that is different in the remote_state config than in the backend Terraform is
+// currently using. OldValue is nil if the setting is new, and NewValue is nil if it was removed.
+type BackendConfigChange struct {
+	Key      string
+	OldValue interface{}
+	NewValue interface{}
+}
+
+// The differences between the remote_state config and the backend Terraform is currently using, as recorded in
+// .terraform/terraform.tfstate
+type BackendConfigDrift struct {
+	OldBackend string
+	NewBackend string
+	Changes    []BackendConfigChange
+}
+
+// Return true if the remote_state config is different in any way from the backend Terraform is currently using
+func (drift BackendConfigDrift) HasChanges() bool {
+	return drift.OldBackend != drift.NewBackend || len(drift.Changes) > 0
+}
+
+// Format the drift as a diff with one line per changed setting, in the style of a Terraform plan: + for new settings,
+// - for removed settings, and ~ for changed settings
+func (drift BackendConfigDrift) String() string {
+	lines := []string{}
+	if drift.OldBackend != drift.NewBackend {
+		lines = append(lines, fmt.Sprintf("  ~ backend: %s -> %s", drift.OldBackend, drift.NewBackend))
+	}
+	for _, change := range drift.Changes {
+		switch {
+		case change.OldValue == nil:
+			lines = append(lines, fmt.Sprintf("  + %s: %v", change.Key, change.NewValue))
+		case change.NewValue == nil:
+			lines = append(lines, fmt.Sprintf("  - %s: %v", change.Key, change.OldValue))
+		default:
+			lines = append(lines, fmt.Sprintf("  ~ %s: %v -> %v", change.Key, change.OldValue, change.NewValue))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Compare this remote state config against the backend config Terraform stored in .terraform/terraform.tfstate the
+// last time it was initialized in the working dir. Returns nil if Terraform hasn't been initialized with a remote
+// backend yet, as there is nothing to compare against.
+func (remoteState *RemoteState) DetectDrift(terragruntOptions *options.TerragruntOptions) (*BackendConfigDrift, error) {
+	state, err := ParseTerraformStateFileFromLocation(remoteState.Backend, remoteState.Config, terragruntOptions.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if state == nil || !state.IsRemote() {
+		return nil, nil
+	}
+
+	drift := remoteState.diffFrom(state.Backend, terragruntOptions)
+	return &drift, nil
+}
+
+// Return the differences between this remote state and the given remote state that is currently being used by
+// terraform
+func (remoteState *RemoteState) diffFrom(existingBackend *TerraformBackend, terragruntOptions *options.TerragruntOptions) BackendConfigDrift {
+	drift := BackendConfigDrift{OldBackend: existingBackend.Type, NewBackend: remoteState.Backend}
+
+	// Terraform's `backend` configuration uses a boolean for the `encrypt` parameter. However, perhaps for backwards compatibility reasons,
+	// Terraform stores that parameter as a string in the `terraform.tfstate` file. Therefore, we have to convert it accordingly, or `DeepEqual`
+	// will fail.
+	if util.KindOf(existingBackend.Config["encrypt"]) == reflect.String && util.KindOf(remoteState.Config["encrypt"]) == reflect.Bool {
+		// If encrypt in remoteState is a bool and a string in existingBackend, DeepEqual will consider the maps to be different.
+		// So we convert the value from string to bool to make them equivalent.
+		if value, err := strconv.ParseBool(existingBackend.Config["encrypt"].(string)); err == nil {
+			existingBackend.Config["encrypt"] = value
+		} else {
+			terragruntOptions.Logger.Printf("Remote state configuration encrypt contains invalid value %v, should be boolean.", existingBackend.Config["encrypt"])
+		}
+	}
+
+	keys := []string{}
+	for key := range existingBackend.Config {
+		keys = append(keys, key)
+	}
+	for key := range remoteState.Config {
+		if _, inExisting := existingBackend.Config[key]; !inExisting {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		oldValue := existingBackend.Config[key]
+		newValue := remoteState.Config[key]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			drift.Changes = append(drift.Changes, BackendConfigChange{Key: key, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+
+	return drift
+}
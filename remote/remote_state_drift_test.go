@@ -0,0 +1,65 @@
+package remote
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffFrom(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("remote_state_drift_test")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	existingBackend := TerraformBackend{
+		Type:   "s3",
+		Config: map[string]interface{}{"bucket": "foo", "key": "old/terraform.tfstate", "encrypt": "true", "lock_table": "locks"},
+	}
+	remoteState := RemoteState{
+		Backend: "s3",
+		Config:  map[string]interface{}{"bucket": "foo", "key": "new/terraform.tfstate", "encrypt": true, "dynamodb_table": "locks"},
+	}
+
+	drift := remoteState.diffFrom(&existingBackend, terragruntOptions)
+
+	assert.True(t, drift.HasChanges())
+	assert.Equal(t, []BackendConfigChange{
+		{Key: "dynamodb_table", OldValue: nil, NewValue: "locks"},
+		{Key: "key", OldValue: "old/terraform.tfstate", NewValue: "new/terraform.tfstate"},
+		{Key: "lock_table", OldValue: "locks", NewValue: nil},
+	}, drift.Changes)
+	assert.Equal(t, "  + dynamodb_table: locks\n  ~ key: old/terraform.tfstate -> new/terraform.tfstate\n  - lock_table: locks", drift.String())
+}
+
+func TestDiffFromBackendTypeChanged(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("remote_state_drift_test")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	existingBackend := TerraformBackend{Type: "atlas", Config: map[string]interface{}{"bucket": "foo"}}
+	remoteState := RemoteState{Backend: "s3", Config: map[string]interface{}{"bucket": "foo"}}
+
+	drift := remoteState.diffFrom(&existingBackend, terragruntOptions)
+
+	assert.True(t, drift.HasChanges())
+	assert.Empty(t, drift.Changes)
+	assert.Equal(t, "  ~ backend: atlas -> s3", drift.String())
+}
+
+func TestDiffFromNoChanges(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("remote_state_drift_test")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	existingBackend := TerraformBackend{Type: "s3", Config: map[string]interface{}{"bucket": "foo", "encrypt": "true"}}
+	remoteState := RemoteState{Backend: "s3", Config: map[string]interface{}{"bucket": "foo", "encrypt": true}}
+
+	drift := remoteState.diffFrom(&existingBackend, terragruntOptions)
+
+	assert.False(t, drift.HasChanges())
+	assert.Empty(t, drift.String())
+}
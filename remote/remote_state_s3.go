@@ -5,12 +5,15 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/gruntwork-io/terragrunt/aws_helper"
 	"github.com/gruntwork-io/terragrunt/dynamodb"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
 	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
 	"github.com/mitchellh/mapstructure"
+	"sync"
 	"time"
 )
 
@@ -41,6 +44,31 @@ const SLEEP_BETWEEN_RETRIES_WAITING_FOR_S3_BUCKET = 5 * time.Second
 
 type S3Initializer struct{}
 
+// The result of checking whether an S3 bucket exists and, if it didn't, offering to create it
+type s3BucketCheckResult struct {
+	exists bool
+	err    error
+}
+
+// The S3 buckets Terragrunt has already checked (and, if necessary, offered to create) during this run, keyed by
+// region and bucket name. When a command such as apply-all runs across many modules that share the same bucket, this
+// ensures we check for the bucket, prompt the user, and create the bucket only once.
+var checkedS3Buckets = struct {
+	sync.Mutex
+	results map[string]s3BucketCheckResult
+}{results: map[string]s3BucketCheckResult{}}
+
+// Process-wide locks, keyed by region and bucket or table name, that are held while an S3 bucket or DynamoDB table is
+// being checked and created. Modules that run concurrently and use the same bucket or table wait for the first one to
+// finish rather than racing to create it, while modules that use different buckets or tables don't block each other.
+var s3BucketLocks = util.KeyedMutex{}
+var lockTableLocks = util.KeyedMutex{}
+
+// How many times to retry S3 API calls that fail because a conflicting operation on the same bucket (e.g. its creation
+// by another Terragrunt process) is still in progress
+const MAX_RETRIES_FOR_CONFLICTING_S3_OPERATION = 6
+const SLEEP_BETWEEN_RETRIES_FOR_CONFLICTING_S3_OPERATION = 5 * time.Second
+
 // Returns true if the S3 bucket or DynamoDB table does not exist
 func (s3Initializer S3Initializer) NeedsInitialization(config map[string]interface{}, terragruntOptions *options.TerragruntOptions) (bool, error) {
 	s3Config, err := parseS3Config(config)
@@ -53,7 +81,7 @@ func (s3Initializer S3Initializer) NeedsInitialization(config map[string]interfa
 		return false, err
 	}
 
-	if !DoesS3BucketExist(s3Client, s3Config) {
+	if !s3BucketKnownToExist(s3Config) && !DoesS3BucketExist(s3Client, s3Config, terragruntOptions) {
 		return true, nil
 	}
 
@@ -63,7 +91,7 @@ func (s3Initializer S3Initializer) NeedsInitialization(config map[string]interfa
 			return false, err
 		}
 
-		tableExists, err := dynamodb.LockTableExistsAndIsActive(s3Config.GetLockTableName(), dynamodbClient)
+		tableExists, err := dynamodb.LockTableExistsAndIsActive(s3Config.GetLockTableName(), dynamodbClient, terragruntOptions)
 		if err != nil {
 			return false, err
 		}
@@ -76,7 +104,8 @@ func (s3Initializer S3Initializer) NeedsInitialization(config map[string]interfa
 }
 
 // Initialize the remote state S3 bucket specified in the given config. This function will validate the config
-// parameters, create the S3 bucket if it doesn't already exist, and check that versioning is enabled.
+// parameters, check that the S3 bucket is in the region the config specifies, create the S3 bucket if it doesn't
+// already exist, and check that versioning is enabled.
 func (s3Initializer S3Initializer) Initialize(config map[string]interface{}, terragruntOptions *options.TerragruntOptions) error {
 	s3Config, err := parseS3Config(config)
 	if err != nil {
@@ -92,6 +121,17 @@ func (s3Initializer S3Initializer) Initialize(config map[string]interface{}, ter
 		return err
 	}
 
+	regionCorrected, err := checkS3BucketRegion(s3Client, config, s3Config, terragruntOptions)
+	if err != nil {
+		return err
+	}
+	if regionCorrected {
+		s3Client, err = CreateS3Client(s3Config.Region, s3Config.Endpoint, s3Config.Profile, s3Config.RoleArn, terragruntOptions)
+		if err != nil {
+			return err
+		}
+	}
+
 	if err := createS3BucketIfNecessary(s3Client, s3Config, terragruntOptions); err != nil {
 		return err
 	}
@@ -131,6 +171,12 @@ func validateS3Config(config *RemoteStateConfigS3, terragruntOptions *options.Te
 		return errors.WithStackTrace(MissingRequiredS3RemoteStateConfig("key"))
 	}
 
+	if config.LockTable != "" {
+		if err := terragruntOptions.Deprecated(options.STRICT_CONTROL_LOCK_TABLE, "The lock_table setting of the S3 remote state config is deprecated. Please rename it to dynamodb_table."); err != nil {
+			return err
+		}
+	}
+
 	if !config.Encrypt {
 		terragruntOptions.Logger.Printf("WARNING: encryption is not enabled on the S3 remote state bucket %s. Terraform state files may contain secrets, so we STRONGLY recommend enabling encryption!", config.Bucket)
 	}
@@ -138,27 +184,129 @@ func validateS3Config(config *RemoteStateConfigS3, terragruntOptions *options.Te
 	return nil
 }
 
+// Check that the S3 bucket in the given config, if it exists, is in the region the config specifies. Otherwise, every
+// S3 API call fails with a confusing redirect error, and Terragrunt would offer to create a bucket that already exists.
+// Returns true if the region was corrected; see correctS3BucketRegion.
+func checkS3BucketRegion(s3Client s3iface.S3API, config map[string]interface{}, s3Config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) (bool, error) {
+	// S3-compatible services with a custom endpoint don't necessarily have regions
+	if s3Config.Endpoint != "" {
+		return false, nil
+	}
+
+	actualRegion, err := GetS3BucketRegion(s3Client, s3Config, terragruntOptions)
+	if err != nil {
+		// We can't tell, e.g. because the bucket doesn't exist yet, so leave it to the other checks
+		return false, nil
+	}
+
+	return correctS3BucketRegion(config, s3Config, actualRegion, terragruntOptions)
+}
+
+// If the given actual region of the S3 bucket in the given config is different from the region the config specifies,
+// return an error, or, if the FixS3Region option is set, update the region in both the S3 config and the remote state
+// config, so the backend config Terragrunt passes to terraform init uses the right region, and return true.
+func correctS3BucketRegion(config map[string]interface{}, s3Config *RemoteStateConfigS3, actualRegion string, terragruntOptions *options.TerragruntOptions) (bool, error) {
+	if actualRegion == "" || actualRegion == s3Config.Region {
+		return false, nil
+	}
+
+	if !terragruntOptions.FixS3Region {
+		return false, errors.WithStackTrace(S3BucketInDifferentRegion{Bucket: s3Config.Bucket, ConfiguredRegion: s3Config.Region, ActualRegion: actualRegion})
+	}
+
+	terragruntOptions.Logger.Printf("WARNING: The remote state S3 bucket %s is in region %s, not %s as the remote_state config says. Using %s instead. Update the region in your remote_state config to get rid of this warning.", s3Config.Bucket, actualRegion, s3Config.Region, actualRegion)
+	config["region"] = actualRegion
+	s3Config.Region = actualRegion
+	return true, nil
+}
+
+// Return the region of the S3 bucket in the given config. This comes from the X-Amz-Bucket-Region header of the
+// response to a HeadBucket request, which S3 sets even when the request went to the wrong region and failed.
+func GetS3BucketRegion(s3Client s3iface.S3API, s3Config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) (string, error) {
+	req, _ := s3Client.HeadBucketRequest(&s3.HeadBucketInput{Bucket: aws.String(s3Config.Bucket)})
+	req.SetContext(terragruntOptions.GetContext())
+	err := req.Send()
+
+	if req.HTTPResponse != nil {
+		if region := req.HTTPResponse.Header.Get("X-Amz-Bucket-Region"); region != "" {
+			return region, nil
+		}
+	}
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return "", errors.WithStackTrace(S3BucketRegionUnknown(s3Config.Bucket))
+}
+
 // If the bucket specified in the given config doesn't already exist, prompt the user to create it, and if the user
-// confirms, create the bucket and enable versioning for it.
-func createS3BucketIfNecessary(s3Client *s3.S3, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
-	if !DoesS3BucketExist(s3Client, config) {
+// confirms, create the bucket and enable versioning for it. This only happens once per bucket during a run: for any
+// subsequent modules that use the same bucket, we return the result of the first check.
+func createS3BucketIfNecessary(s3Client s3iface.S3API, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
+	_, err := checkS3BucketOnce(config, func() (bool, error) {
+		if DoesS3BucketExist(s3Client, config, terragruntOptions) {
+			return true, nil
+		}
+
 		prompt := fmt.Sprintf("Remote state S3 bucket %s does not exist or you don't have permissions to access it. Would you like Terragrunt to create it?", config.Bucket)
 		shouldCreateBucket, err := shell.PromptUserForYesNo(prompt, terragruntOptions)
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		if shouldCreateBucket {
-			return CreateS3BucketWithVersioning(s3Client, config, terragruntOptions)
+			return true, CreateS3BucketWithVersioning(s3Client, config, terragruntOptions)
 		}
+
+		return false, nil
+	})
+
+	return err
+}
+
+// Call the given function to check if the S3 bucket in the given config exists (creating it if necessary), unless it
+// was already called for this bucket during this run, in which case, return the result of that earlier call
+func checkS3BucketOnce(config *RemoteStateConfigS3, checkBucket func() (bool, error)) (bool, error) {
+	key := s3BucketKey(config)
+	s3BucketLocks.Lock(key)
+	defer s3BucketLocks.Unlock(key)
+
+	if result, alreadyChecked := getS3BucketCheckResult(key); alreadyChecked {
+		return result.exists, result.err
 	}
 
-	return nil
+	exists, err := checkBucket()
+
+	checkedS3Buckets.Lock()
+	defer checkedS3Buckets.Unlock()
+	checkedS3Buckets.results[key] = s3BucketCheckResult{exists: exists, err: err}
+
+	return exists, err
+}
+
+// Returns true if we already checked the S3 bucket in the given config during this run and found that it exists (or
+// created it)
+func s3BucketKnownToExist(config *RemoteStateConfigS3) bool {
+	result, alreadyChecked := getS3BucketCheckResult(s3BucketKey(config))
+	return alreadyChecked && result.exists && result.err == nil
+}
+
+// Look up the result of checking the S3 bucket with the given key in checkedS3Buckets
+func getS3BucketCheckResult(key string) (s3BucketCheckResult, bool) {
+	checkedS3Buckets.Lock()
+	defer checkedS3Buckets.Unlock()
+
+	result, alreadyChecked := checkedS3Buckets.results[key]
+	return result, alreadyChecked
+}
+
+// Return the key we use to identify the S3 bucket in the given config in checkedS3Buckets
+func s3BucketKey(config *RemoteStateConfigS3) string {
+	return fmt.Sprintf("%s/%s", config.Region, config.Bucket)
 }
 
 // Check if versioning is enabled for the S3 bucket specified in the given config and warn the user if it is not
-func checkIfVersioningEnabled(s3Client *s3.S3, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
-	out, err := s3Client.GetBucketVersioning(&s3.GetBucketVersioningInput{Bucket: aws.String(config.Bucket)})
+func checkIfVersioningEnabled(s3Client s3iface.S3API, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
+	out, err := s3Client.GetBucketVersioningWithContext(terragruntOptions.GetContext(), &s3.GetBucketVersioningInput{Bucket: aws.String(config.Bucket)})
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
@@ -172,8 +320,8 @@ func checkIfVersioningEnabled(s3Client *s3.S3, config *RemoteStateConfigS3, terr
 	return nil
 }
 
-// Create the given S3 bucket and enable versioning for it
-func CreateS3BucketWithVersioning(s3Client *s3.S3, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
+// Create the given S3 bucket, enable versioning for it, and tag it with the DefaultTags of the given options, if any
+func CreateS3BucketWithVersioning(s3Client s3iface.S3API, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
 	if err := CreateS3Bucket(s3Client, config, terragruntOptions); err != nil {
 		return err
 	}
@@ -186,19 +334,25 @@ func CreateS3BucketWithVersioning(s3Client *s3.S3, config *RemoteStateConfigS3,
 		return err
 	}
 
+	if err := TagS3Bucket(s3Client, config, terragruntOptions.DefaultTags, terragruntOptions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // AWS is eventually consistent, so after creating an S3 bucket, this method can be used to wait until the information
 // about that S3 bucket has propagated everywhere
-func WaitUntilS3BucketExists(s3Client *s3.S3, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
+func WaitUntilS3BucketExists(s3Client s3iface.S3API, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
 	for retries := 0; retries < MAX_RETRIES_WAITING_FOR_S3_BUCKET; retries++ {
-		if DoesS3BucketExist(s3Client, config) {
+		if DoesS3BucketExist(s3Client, config, terragruntOptions) {
 			terragruntOptions.Logger.Printf("S3 bucket %s created.", config.Bucket)
 			return nil
 		} else if retries < MAX_RETRIES_WAITING_FOR_S3_BUCKET-1 {
 			terragruntOptions.Logger.Printf("S3 bucket %s has not been created yet. Sleeping for %s and will check again.", config.Bucket, SLEEP_BETWEEN_RETRIES_WAITING_FOR_S3_BUCKET)
-			time.Sleep(SLEEP_BETWEEN_RETRIES_WAITING_FOR_S3_BUCKET)
+			if err := util.SleepWithContext(terragruntOptions.GetContext(), SLEEP_BETWEEN_RETRIES_WAITING_FOR_S3_BUCKET); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -206,9 +360,9 @@ func WaitUntilS3BucketExists(s3Client *s3.S3, config *RemoteStateConfigS3, terra
 }
 
 // Create the S3 bucket specified in the given config
-func CreateS3Bucket(s3Client *s3.S3, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
+func CreateS3Bucket(s3Client s3iface.S3API, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
 	terragruntOptions.Logger.Printf("Creating S3 bucket %s", config.Bucket)
-	_, err := s3Client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(config.Bucket)})
+	_, err := s3Client.CreateBucketWithContext(terragruntOptions.GetContext(), &s3.CreateBucketInput{Bucket: aws.String(config.Bucket)})
 
 	if err != nil {
 		if isBucketAlreadyOwnedByYourError(err) {
@@ -230,20 +384,72 @@ func isBucketAlreadyOwnedByYourError(err error) bool {
 }
 
 // Enable versioning for the S3 bucket specified in the given config
-func EnableVersioningForS3Bucket(s3Client *s3.S3, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
+func EnableVersioningForS3Bucket(s3Client s3iface.S3API, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) error {
 	terragruntOptions.Logger.Printf("Enabling versioning on S3 bucket %s", config.Bucket)
 	input := s3.PutBucketVersioningInput{
 		Bucket:                  aws.String(config.Bucket),
 		VersioningConfiguration: &s3.VersioningConfiguration{Status: aws.String(s3.BucketVersioningStatusEnabled)},
 	}
-	_, err := s3Client.PutBucketVersioning(&input)
-	return errors.WithStackTrace(err)
+	return retryConflictingS3Operation(fmt.Sprintf("Enable versioning on S3 bucket %s", config.Bucket), terragruntOptions, func() error {
+		_, err := s3Client.PutBucketVersioningWithContext(terragruntOptions.GetContext(), &input)
+		return err
+	})
+}
+
+// Set the given tags on the S3 bucket specified in the given config, replacing any it has. Does nothing if there are no
+// tags.
+func TagS3Bucket(s3Client s3iface.S3API, config *RemoteStateConfigS3, tags map[string]string, terragruntOptions *options.TerragruntOptions) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	terragruntOptions.Logger.Printf("Tagging S3 bucket %s", config.Bucket)
+	tagSet := []*s3.Tag{}
+	for _, key := range util.SortedKeys(tags) {
+		tagSet = append(tagSet, &s3.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	input := s3.PutBucketTaggingInput{
+		Bucket:  aws.String(config.Bucket),
+		Tagging: &s3.Tagging{TagSet: tagSet},
+	}
+	return retryConflictingS3Operation(fmt.Sprintf("Tag S3 bucket %s", config.Bucket), terragruntOptions, func() error {
+		_, err := s3Client.PutBucketTaggingWithContext(terragruntOptions.GetContext(), &input)
+		return err
+	})
+}
+
+// Run the given S3 operation, retrying it if it fails because a conflicting operation on the same bucket is still in
+// progress. This usually happens right after the bucket was created, especially if several Terragrunt processes (e.g.
+// parallel CI jobs) are creating it at the same time.
+func retryConflictingS3Operation(description string, terragruntOptions *options.TerragruntOptions, operation func() error) error {
+	for retries := 0; ; retries++ {
+		err := operation()
+		if err == nil {
+			return nil
+		}
+
+		if !isConflictingOperationError(err) || retries >= MAX_RETRIES_FOR_CONFLICTING_S3_OPERATION-1 {
+			return errors.WithStackTrace(err)
+		}
+
+		terragruntOptions.Logger.Printf("%s failed because a conflicting operation is in progress. Sleeping for %s and will try again.", description, SLEEP_BETWEEN_RETRIES_FOR_CONFLICTING_S3_OPERATION)
+		if err := util.SleepWithContext(terragruntOptions.GetContext(), SLEEP_BETWEEN_RETRIES_FOR_CONFLICTING_S3_OPERATION); err != nil {
+			return err
+		}
+	}
+}
+
+// Returns true if the given error is the error AWS returns when another operation on the same S3 bucket is still in
+// progress (HTTP 409 Conflict)
+func isConflictingOperationError(err error) bool {
+	awsErr, isAwsErr := err.(awserr.Error)
+	return isAwsErr && awsErr.Code() == "OperationAborted"
 }
 
 // Returns true if the S3 bucket specified in the given config exists and the current user has the ability to access
 // it.
-func DoesS3BucketExist(s3Client *s3.S3, config *RemoteStateConfigS3) bool {
-	_, err := s3Client.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String(config.Bucket)})
+func DoesS3BucketExist(s3Client s3iface.S3API, config *RemoteStateConfigS3, terragruntOptions *options.TerragruntOptions) bool {
+	_, err := s3Client.HeadBucketWithContext(terragruntOptions.GetContext(), &s3.HeadBucketInput{Bucket: aws.String(config.Bucket)})
 	return err == nil
 }
 
@@ -253,6 +459,10 @@ func createLockTableIfNecessary(s3Config *RemoteStateConfigS3, terragruntOptions
 		return nil
 	}
 
+	key := fmt.Sprintf("%s/%s", s3Config.Region, s3Config.GetLockTableName())
+	lockTableLocks.Lock(key)
+	defer lockTableLocks.Unlock(key)
+
 	dynamodbClient, err := dynamodb.CreateDynamoDbClient(s3Config.Region, s3Config.Profile, s3Config.RoleArn, terragruntOptions)
 	if err != nil {
 		return err
@@ -279,6 +489,22 @@ func (configName MissingRequiredS3RemoteStateConfig) Error() string {
 	return fmt.Sprintf("Missing required S3 remote state configuration %s", string(configName))
 }
 
+type S3BucketInDifferentRegion struct {
+	Bucket           string
+	ConfiguredRegion string
+	ActualRegion     string
+}
+
+func (err S3BucketInDifferentRegion) Error() string {
+	return fmt.Sprintf("The remote state S3 bucket %s is in region %s, but the remote_state config says it's in region %s. Set region = \"%s\" in the remote_state config, or pass --terragrunt-fix-s3-region to have Terragrunt use the bucket's region.", err.Bucket, err.ActualRegion, err.ConfiguredRegion, err.ActualRegion)
+}
+
+type S3BucketRegionUnknown string
+
+func (bucket S3BucketRegionUnknown) Error() string {
+	return fmt.Sprintf("Could not determine the region of S3 bucket %s", string(bucket))
+}
+
 type MaxRetriesWaitingForS3BucketExceeded string
 
 func (err MaxRetriesWaitingForS3BucketExceeded) Error() string {
@@ -0,0 +1,253 @@
+package remote
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckS3BucketOnceOnlyChecksEachBucketOnce(t *testing.T) {
+	t.Parallel()
+
+	config := &RemoteStateConfigS3{Bucket: "test-check-s3-bucket-once", Region: "us-east-1"}
+	checks := 0
+	checkBucket := func() (bool, error) {
+		checks++
+		return true, nil
+	}
+
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			exists, err := checkS3BucketOnce(config, checkBucket)
+			assert.Nil(t, err)
+			assert.True(t, exists)
+		}()
+	}
+	waitGroup.Wait()
+
+	assert.Equal(t, 1, checks)
+	assert.True(t, s3BucketKnownToExist(config))
+}
+
+func TestCheckS3BucketOnceRemembersErrorsAndDeclinedBuckets(t *testing.T) {
+	t.Parallel()
+
+	expectedErr := fmt.Errorf("test-time-mock")
+	failedConfig := &RemoteStateConfigS3{Bucket: "test-check-s3-bucket-once-error", Region: "us-east-1"}
+	declinedConfig := &RemoteStateConfigS3{Bucket: "test-check-s3-bucket-once-declined", Region: "us-east-1"}
+
+	for i := 0; i < 2; i++ {
+		_, err := checkS3BucketOnce(failedConfig, func() (bool, error) {
+			assert.Equal(t, 0, i, "Bucket should only be checked once")
+			return true, expectedErr
+		})
+		assert.Equal(t, expectedErr, err)
+
+		exists, err := checkS3BucketOnce(declinedConfig, func() (bool, error) {
+			assert.Equal(t, 0, i, "Bucket should only be checked once")
+			return false, nil
+		})
+		assert.Nil(t, err)
+		assert.False(t, exists)
+	}
+
+	assert.False(t, s3BucketKnownToExist(failedConfig))
+	assert.False(t, s3BucketKnownToExist(declinedConfig))
+	assert.False(t, s3BucketKnownToExist(&RemoteStateConfigS3{Bucket: "test-check-s3-bucket-never-checked", Region: "us-east-1"}))
+}
+
+func TestIsConflictingOperationError(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isConflictingOperationError(awserr.New("OperationAborted", "A conflicting conditional operation is currently in progress", nil)))
+	assert.False(t, isConflictingOperationError(awserr.New("AccessDenied", "Access Denied", nil)))
+	assert.False(t, isConflictingOperationError(fmt.Errorf("test-time-mock")))
+}
+
+func TestRetryConflictingS3OperationDoesNotRetryOtherErrors(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("remote_state_s3_test")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	calls := 0
+	err = retryConflictingS3Operation("Test operation", terragruntOptions, func() error {
+		calls++
+		return awserr.New("AccessDenied", "Access Denied", nil)
+	})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+// A mock S3 client that keeps track of the buckets it has and their versioning status. Calling any method it doesn't
+// override panics, since the embedded interface is nil.
+type mockS3Client struct {
+	s3iface.S3API
+	mutex      sync.Mutex
+	buckets    map[string]string
+	tags       map[string][]*s3.Tag
+	createErrs []error
+}
+
+func newMockS3Client() *mockS3Client {
+	return &mockS3Client{buckets: map[string]string{}, tags: map[string][]*s3.Tag{}}
+}
+
+func (client *mockS3Client) HeadBucketWithContext(ctx aws.Context, input *s3.HeadBucketInput, opts ...request.Option) (*s3.HeadBucketOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if _, exists := client.buckets[aws.StringValue(input.Bucket)]; !exists {
+		return nil, awserr.New("NotFound", "Not Found", nil)
+	}
+	return &s3.HeadBucketOutput{}, nil
+}
+
+func (client *mockS3Client) CreateBucketWithContext(ctx aws.Context, input *s3.CreateBucketInput, opts ...request.Option) (*s3.CreateBucketOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if len(client.createErrs) > 0 {
+		err := client.createErrs[0]
+		client.createErrs = client.createErrs[1:]
+		return nil, err
+	}
+	client.buckets[aws.StringValue(input.Bucket)] = ""
+	return &s3.CreateBucketOutput{}, nil
+}
+
+func (client *mockS3Client) GetBucketVersioningWithContext(ctx aws.Context, input *s3.GetBucketVersioningInput, opts ...request.Option) (*s3.GetBucketVersioningOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	status, exists := client.buckets[aws.StringValue(input.Bucket)]
+	if !exists {
+		return nil, awserr.New("NoSuchBucket", "The specified bucket does not exist", nil)
+	}
+	if status == "" {
+		return &s3.GetBucketVersioningOutput{}, nil
+	}
+	return &s3.GetBucketVersioningOutput{Status: aws.String(status)}, nil
+}
+
+func (client *mockS3Client) PutBucketVersioningWithContext(ctx aws.Context, input *s3.PutBucketVersioningInput, opts ...request.Option) (*s3.PutBucketVersioningOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	client.buckets[aws.StringValue(input.Bucket)] = aws.StringValue(input.VersioningConfiguration.Status)
+	return &s3.PutBucketVersioningOutput{}, nil
+}
+
+func (client *mockS3Client) PutBucketTaggingWithContext(ctx aws.Context, input *s3.PutBucketTaggingInput, opts ...request.Option) (*s3.PutBucketTaggingOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	client.tags[aws.StringValue(input.Bucket)] = input.Tagging.TagSet
+	return &s3.PutBucketTaggingOutput{}, nil
+}
+
+func TestCreateS3BucketWithVersioningMockClient(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("remote_state_s3_test")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	client := newMockS3Client()
+	config := &RemoteStateConfigS3{Bucket: "test-create-s3-bucket-with-versioning", Region: "us-east-1"}
+
+	assert.False(t, DoesS3BucketExist(client, config, terragruntOptions))
+
+	err = CreateS3BucketWithVersioning(client, config, terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	assert.True(t, DoesS3BucketExist(client, config, terragruntOptions))
+	assert.Equal(t, s3.BucketVersioningStatusEnabled, client.buckets[config.Bucket])
+	assert.Nil(t, checkIfVersioningEnabled(client, config, terragruntOptions))
+	assert.Empty(t, client.tags)
+}
+
+func TestCreateS3BucketWithVersioningMockClientDefaultTags(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("remote_state_s3_test")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.DefaultTags = map[string]string{"Team": "payments", "Env": "prod"}
+
+	client := newMockS3Client()
+	config := &RemoteStateConfigS3{Bucket: "test-create-s3-bucket-with-tags", Region: "us-east-1"}
+
+	err = CreateS3BucketWithVersioning(client, config, terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	expected := []*s3.Tag{{Key: aws.String("Env"), Value: aws.String("prod")}, {Key: aws.String("Team"), Value: aws.String("payments")}}
+	assert.Equal(t, expected, client.tags[config.Bucket])
+}
+
+func TestCreateS3BucketMockClientBucketAlreadyOwnedByYou(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("remote_state_s3_test")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	client := newMockS3Client()
+	config := &RemoteStateConfigS3{Bucket: "test-create-s3-bucket-already-owned", Region: "us-east-1"}
+
+	client.createErrs = []error{awserr.New("BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded", nil)}
+	assert.Nil(t, CreateS3Bucket(client, config, terragruntOptions))
+
+	client.createErrs = []error{awserr.New("AccessDenied", "Access Denied", nil)}
+	assert.NotNil(t, CreateS3Bucket(client, config, terragruntOptions))
+}
+
+func TestCorrectS3BucketRegion(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("remote_state_s3_test")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	testCases := []struct {
+		actualRegion    string
+		fixS3Region     bool
+		expectCorrected bool
+		expectErr       bool
+		expectedRegion  string
+	}{
+		{"", false, false, false, "us-east-1"},
+		{"us-east-1", false, false, false, "us-east-1"},
+		{"eu-west-1", false, false, true, "us-east-1"},
+		{"eu-west-1", true, true, false, "eu-west-1"},
+	}
+
+	for _, testCase := range testCases {
+		config := map[string]interface{}{"bucket": "my-bucket", "key": "terraform.tfstate", "region": "us-east-1"}
+		s3Config := &RemoteStateConfigS3{Bucket: "my-bucket", Key: "terraform.tfstate", Region: "us-east-1"}
+
+		opts := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+		opts.FixS3Region = testCase.fixS3Region
+
+		corrected, err := correctS3BucketRegion(config, s3Config, testCase.actualRegion, opts)
+		if testCase.expectErr {
+			_, isDifferentRegionErr := errors.Unwrap(err).(S3BucketInDifferentRegion)
+			assert.True(t, isDifferentRegionErr, "Expected an S3BucketInDifferentRegion error for %v but got: %v", testCase, err)
+		} else {
+			assert.Nil(t, err, "Unexpected error for %v: %v", testCase, err)
+		}
+
+		assert.Equal(t, testCase.expectCorrected, corrected, "For %v", testCase)
+		assert.Equal(t, testCase.expectedRegion, config["region"], "For %v", testCase)
+		assert.Equal(t, testCase.expectedRegion, s3Config.Region, "For %v", testCase)
+	}
+}
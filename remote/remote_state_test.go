@@ -104,3 +104,21 @@ func assertTerraformInitArgsEqual(t *testing.T, actualArgs []string, expectedArg
 		assert.Contains(t, actualArgs, expectedArg)
 	}
 }
+
+func TestStateLocation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		remoteState RemoteState
+		expected    string
+	}{
+		{RemoteState{Backend: "s3", Config: map[string]interface{}{"bucket": "my-bucket", "key": "prod/mysql/terraform.tfstate", "region": "us-east-1"}}, "s3://my-bucket/prod/mysql/terraform.tfstate"},
+		{RemoteState{Backend: "gcs", Config: map[string]interface{}{"bucket": "my-bucket", "prefix": "prod/mysql"}}, "gcs://my-bucket/prod/mysql"},
+		{RemoteState{Backend: "consul", Config: map[string]interface{}{"path": "prod/mysql"}}, "consul:///prod/mysql"},
+		{RemoteState{Backend: "local", Config: map[string]interface{}{"path": "terraform.tfstate"}}, ""},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testCase.remoteState.StateLocation(), "For %s", testCase.remoteState.String())
+	}
+}
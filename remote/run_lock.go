@@ -0,0 +1,96 @@
+package remote
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/gruntwork-io/terragrunt/dynamodb"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// The suffix of the ID of Terragrunt's run lock item in the DynamoDB lock table. Terraform uses the bucket and key of
+// the state as the ID of its state lock, and those plus -md5 for the digest of the state, so this can't clash with
+// either.
+const RUN_LOCK_ID_SUFFIX = "-terragrunt-run"
+
+// S3RunLock is a lock on the state of a module in an S3 backend that Terragrunt holds for the whole of a command that
+// changes the state, including the auto-init before it. It's an item in the DynamoDB lock table of the backend, next
+// to the state lock Terraform itself takes, which only exists while Terraform runs.
+type S3RunLock struct {
+	TableName string
+	LockId    string
+	Region    string
+	client    dynamodbiface.DynamoDBAPI
+}
+
+// Create the run lock for the state in the given remote state config, which must be an S3 backend with a DynamoDB lock
+// table. This doesn't take the lock.
+func NewS3RunLock(remoteState *RemoteState, terragruntOptions *options.TerragruntOptions) (*S3RunLock, error) {
+	if remoteState == nil || remoteState.Backend != "s3" {
+		return nil, errors.WithStackTrace(RunLockRequiresS3LockTable{})
+	}
+
+	s3Config, err := parseS3Config(remoteState.Config)
+	if err != nil {
+		return nil, err
+	}
+	if s3Config.GetLockTableName() == "" {
+		return nil, errors.WithStackTrace(RunLockRequiresS3LockTable{})
+	}
+
+	client, err := dynamodb.CreateDynamoDbClient(s3Config.Region, s3Config.Profile, s3Config.RoleArn, terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3RunLock{TableName: s3Config.GetLockTableName(), LockId: runLockId(s3Config), Region: s3Config.Region, client: client}, nil
+}
+
+// Return the ID of the run lock item for the state in the given S3 config
+func runLockId(s3Config *RemoteStateConfigS3) string {
+	return fmt.Sprintf("%s/%s%s", s3Config.Bucket, s3Config.Key, RUN_LOCK_ID_SUFFIX)
+}
+
+// Try to take the lock, with the given info about this run. Return true if we got it, and otherwise, false and the
+// info of the run that holds it. The lock is taken before the auto-init or the remote state initialization, which
+// create the lock table, so on the first run against a new backend, the table is created here.
+func (lock *S3RunLock) TryAcquire(info string, terragruntOptions *options.TerragruntOptions) (bool, string, error) {
+	acquired, holder, err := dynamodb.PutLockItemIfNotExists(lock.TableName, lock.LockId, info, lock.client, terragruntOptions)
+	if awsErr, isAwsErr := errors.Unwrap(err).(awserr.Error); !isAwsErr || awsErr.Code() != awsdynamodb.ErrCodeResourceNotFoundException {
+		return acquired, holder, err
+	}
+
+	if err := lock.createLockTable(terragruntOptions); err != nil {
+		return false, "", err
+	}
+	return dynamodb.PutLockItemIfNotExists(lock.TableName, lock.LockId, info, lock.client, terragruntOptions)
+}
+
+// Create the lock table, as createLockTableIfNecessary does for the remote state, so that the modules of an xxx-all
+// command that share a table don't all try to create it
+func (lock *S3RunLock) createLockTable(terragruntOptions *options.TerragruntOptions) error {
+	key := fmt.Sprintf("%s/%s", lock.Region, lock.TableName)
+	lockTableLocks.Lock(key)
+	defer lockTableLocks.Unlock(key)
+
+	return dynamodb.CreateLockTableIfNecessary(lock.TableName, lock.client, terragruntOptions)
+}
+
+func (lock *S3RunLock) Release(terragruntOptions *options.TerragruntOptions) error {
+	return dynamodb.DeleteLockItem(lock.TableName, lock.LockId, lock.client)
+}
+
+func (lock *S3RunLock) String() string {
+	return fmt.Sprintf("item %s in the DynamoDB table %s", lock.LockId, lock.TableName)
+}
+
+// Custom error types
+
+type RunLockRequiresS3LockTable struct{}
+
+func (err RunLockRequiresS3LockTable) Error() string {
+	return "The use_lock_table setting of the run_lock block requires a remote_state block with the s3 backend and a dynamodb_table."
+}
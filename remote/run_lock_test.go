@@ -0,0 +1,124 @@
+package remote
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/gruntwork-io/terragrunt/dynamodb"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunLockId(t *testing.T) {
+	t.Parallel()
+
+	s3Config := &RemoteStateConfigS3{Bucket: "my-bucket", Key: "prod/app/terraform.tfstate"}
+	assert.Equal(t, "my-bucket/prod/app/terraform.tfstate-terragrunt-run", runLockId(s3Config))
+}
+
+func TestNewS3RunLockRequiresS3LockTable(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("run_lock_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		remoteState *RemoteState
+	}{
+		{"no remote state", nil},
+		{"other backend", &RemoteState{Backend: "gcs", Config: map[string]interface{}{"bucket": "my-bucket"}}},
+		{"no lock table", &RemoteState{Backend: "s3", Config: map[string]interface{}{"bucket": "my-bucket", "key": "terraform.tfstate", "region": "us-east-1"}}},
+	}
+
+	for _, testCase := range testCases {
+		_, err := NewS3RunLock(testCase.remoteState, terragruntOptions)
+		_, isRunLockRequiresS3LockTableErr := errors.Unwrap(err).(RunLockRequiresS3LockTable)
+		assert.True(t, isRunLockRequiresS3LockTableErr, "Expected a RunLockRequiresS3LockTable error for %s but got: %v", testCase.name, err)
+	}
+}
+
+func TestS3RunLockCreatesMissingLockTable(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("run_lock_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &mockRunLockDynamoDbClient{tables: map[string]map[string]string{}}
+	lock := &S3RunLock{TableName: "new-lock-table", LockId: "my-bucket/terraform.tfstate-terragrunt-run", Region: "us-east-1", client: client}
+
+	acquired, holder, err := lock.TryAcquire("first run", terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.True(t, acquired)
+	assert.Equal(t, "", holder)
+	assert.Equal(t, "first run", client.tables["new-lock-table"][lock.LockId])
+
+	acquired, holder, err = lock.TryAcquire("second run", terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.False(t, acquired)
+	assert.Equal(t, "first run", holder)
+}
+
+// A DynamoDB client with the lock items of each table in memory. Like the real one, it fails to put an item into a
+// table that doesn't exist.
+type mockRunLockDynamoDbClient struct {
+	dynamodbiface.DynamoDBAPI
+	mutex  sync.Mutex
+	tables map[string]map[string]string
+}
+
+func (client *mockRunLockDynamoDbClient) DescribeTableWithContext(ctx aws.Context, input *awsdynamodb.DescribeTableInput, opts ...request.Option) (*awsdynamodb.DescribeTableOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if _, exists := client.tables[aws.StringValue(input.TableName)]; !exists {
+		return nil, awserr.New(awsdynamodb.ErrCodeResourceNotFoundException, "Requested resource not found", nil)
+	}
+	return &awsdynamodb.DescribeTableOutput{Table: &awsdynamodb.TableDescription{TableStatus: aws.String(awsdynamodb.TableStatusActive)}}, nil
+}
+
+func (client *mockRunLockDynamoDbClient) CreateTableWithContext(ctx aws.Context, input *awsdynamodb.CreateTableInput, opts ...request.Option) (*awsdynamodb.CreateTableOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	client.tables[aws.StringValue(input.TableName)] = map[string]string{}
+	return &awsdynamodb.CreateTableOutput{}, nil
+}
+
+func (client *mockRunLockDynamoDbClient) PutItemWithContext(ctx aws.Context, input *awsdynamodb.PutItemInput, opts ...request.Option) (*awsdynamodb.PutItemOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	items, exists := client.tables[aws.StringValue(input.TableName)]
+	if !exists {
+		return nil, awserr.New(awsdynamodb.ErrCodeResourceNotFoundException, "Requested resource not found", nil)
+	}
+	lockId := aws.StringValue(input.Item[dynamodb.ATTR_LOCK_ID].S)
+	if _, locked := items[lockId]; locked {
+		return nil, awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+	items[lockId] = aws.StringValue(input.Item[dynamodb.ATTR_INFO].S)
+	return &awsdynamodb.PutItemOutput{}, nil
+}
+
+func (client *mockRunLockDynamoDbClient) GetItemWithContext(ctx aws.Context, input *awsdynamodb.GetItemInput, opts ...request.Option) (*awsdynamodb.GetItemOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	lockId := aws.StringValue(input.Key[dynamodb.ATTR_LOCK_ID].S)
+	info, locked := client.tables[aws.StringValue(input.TableName)][lockId]
+	if !locked {
+		return &awsdynamodb.GetItemOutput{}, nil
+	}
+	return &awsdynamodb.GetItemOutput{Item: map[string]*awsdynamodb.AttributeValue{dynamodb.ATTR_INFO: {S: aws.String(info)}}}, nil
+}
@@ -0,0 +1,159 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// A resource in a Terraform state, with the ID its provider gave it, such as the ID of an EC2 instance
+type TerraformStateResource struct {
+	// The full address of the resource, as used by terraform state commands, e.g. module.vpc.aws_subnet.private[0]
+	Address string
+	Type    string
+	Name    string
+	Id      string
+}
+
+// The structure of the Terraform state file as of Terraform 0.12 (version 4 of the format), as far as the resources go
+type terraformStateV4 struct {
+	Version   int
+	Resources []struct {
+		Module    string
+		Mode      string
+		Type      string
+		Name      string
+		Instances []struct {
+			IndexKey   interface{}            `json:"index_key"`
+			Attributes map[string]interface{} `json:"attributes"`
+		}
+	}
+}
+
+// The structure of the resources in the modules of a Terraform state file before Terraform 0.12 (version 3 of the
+// format)
+type terraformStateV3Resource struct {
+	Type    string
+	Primary struct {
+		Id string
+	}
+}
+
+// Return the managed resources, i.e. not the data sources, in the given Terraform state, such as the output of
+// terraform state pull, sorted by address. Both the format of Terraform 0.12 and newer, and the older one, are
+// supported. An empty state, which is what terraform state pull returns for a module that has never been applied, has
+// no resources.
+func ParseTerraformStateResources(terraformStateData []byte) ([]TerraformStateResource, error) {
+	if strings.TrimSpace(string(terraformStateData)) == "" {
+		return []TerraformStateResource{}, nil
+	}
+
+	stateV4 := terraformStateV4{}
+	if err := json.Unmarshal(terraformStateData, &stateV4); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	var resources []TerraformStateResource
+	if stateV4.Version >= 4 {
+		resources = stateV4.managedResources()
+	} else {
+		state, err := parseTerraformState(terraformStateData)
+		if err != nil {
+			return nil, err
+		}
+		resources, err = managedResourcesV3(state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Sort(terraformStateResourcesByAddress(resources))
+	return resources, nil
+}
+
+// Return the managed resources in this state, with one resource per instance for resources with count or for_each
+func (state terraformStateV4) managedResources() []TerraformStateResource {
+	resources := []TerraformStateResource{}
+	for _, resource := range state.Resources {
+		if resource.Mode != "managed" {
+			continue
+		}
+
+		address := fmt.Sprintf("%s.%s", resource.Type, resource.Name)
+		if resource.Module != "" {
+			address = fmt.Sprintf("%s.%s", resource.Module, address)
+		}
+
+		for _, instance := range resource.Instances {
+			instanceAddress := address
+			switch indexKey := instance.IndexKey.(type) {
+			case float64:
+				instanceAddress = fmt.Sprintf("%s[%d]", address, int(indexKey))
+			case string:
+				instanceAddress = fmt.Sprintf("%s[%q]", address, indexKey)
+			}
+
+			id, _ := instance.Attributes["id"].(string)
+			resources = append(resources, TerraformStateResource{Address: instanceAddress, Type: resource.Type, Name: resource.Name, Id: id})
+		}
+	}
+	return resources
+}
+
+// Return the managed resources in the given state in the format before Terraform 0.12, where the resources of each
+// module are keyed by their address within the module, e.g. aws_subnet.private.0 or data.aws_ami.ubuntu
+func managedResourcesV3(state *TerraformState) ([]TerraformStateResource, error) {
+	resources := []TerraformStateResource{}
+	for _, module := range state.Modules {
+		modulePrefix := ""
+		for _, name := range module.Path {
+			if name != "root" {
+				modulePrefix += fmt.Sprintf("module.%s.", name)
+			}
+		}
+
+		for key, value := range module.Resources {
+			if strings.HasPrefix(key, "data.") {
+				continue
+			}
+
+			// The resources are only parsed as generic maps, so convert this one to its actual structure
+			resourceJSON, err := json.Marshal(value)
+			if err != nil {
+				return nil, errors.WithStackTrace(err)
+			}
+			resource := terraformStateV3Resource{}
+			if err := json.Unmarshal(resourceJSON, &resource); err != nil {
+				return nil, errors.WithStackTrace(err)
+			}
+
+			nameAndIndex := strings.TrimPrefix(key, resource.Type+".")
+			address := modulePrefix + key
+			name := nameAndIndex
+			if lastDot := strings.LastIndex(nameAndIndex, "."); lastDot >= 0 {
+				name = nameAndIndex[:lastDot]
+				address = fmt.Sprintf("%s%s.%s[%s]", modulePrefix, resource.Type, name, nameAndIndex[lastDot+1:])
+			}
+
+			resources = append(resources, TerraformStateResource{Address: address, Type: resource.Type, Name: name, Id: resource.Primary.Id})
+		}
+	}
+	return resources, nil
+}
+
+type terraformStateResourcesByAddress []TerraformStateResource
+
+func (byAddress terraformStateResourcesByAddress) Len() int {
+	return len(byAddress)
+}
+
+func (byAddress terraformStateResourcesByAddress) Swap(i, j int) {
+	byAddress[i], byAddress[j] = byAddress[j], byAddress[i]
+}
+
+func (byAddress terraformStateResourcesByAddress) Less(i, j int) bool {
+	return byAddress[i].Address < byAddress[j].Address
+}
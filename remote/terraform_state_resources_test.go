@@ -0,0 +1,111 @@
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTerraformStateResourcesV4(t *testing.T) {
+	t.Parallel()
+
+	stateFile := `
+{
+  "version": 4,
+  "terraform_version": "0.12.24",
+  "serial": 3,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_vpc",
+      "name": "main",
+      "provider": "provider.aws",
+      "instances": [{"schema_version": 1, "attributes": {"id": "vpc-123", "cidr_block": "10.0.0.0/16"}}]
+    },
+    {
+      "mode": "data",
+      "type": "aws_ami",
+      "name": "ubuntu",
+      "instances": [{"attributes": {"id": "ami-456"}}]
+    },
+    {
+      "module": "module.subnets",
+      "mode": "managed",
+      "type": "aws_subnet",
+      "name": "private",
+      "each": "list",
+      "instances": [
+        {"index_key": 0, "attributes": {"id": "subnet-a"}},
+        {"index_key": 1, "attributes": {"id": "subnet-b"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_iam_user",
+      "name": "users",
+      "each": "map",
+      "instances": [{"index_key": "alice", "attributes": {"id": "alice"}}]
+    }
+  ]
+}
+`
+
+	expected := []TerraformStateResource{
+		{Address: `aws_iam_user.users["alice"]`, Type: "aws_iam_user", Name: "users", Id: "alice"},
+		{Address: "aws_vpc.main", Type: "aws_vpc", Name: "main", Id: "vpc-123"},
+		{Address: "module.subnets.aws_subnet.private[0]", Type: "aws_subnet", Name: "private", Id: "subnet-a"},
+		{Address: "module.subnets.aws_subnet.private[1]", Type: "aws_subnet", Name: "private", Id: "subnet-b"},
+	}
+
+	actual, err := ParseTerraformStateResources([]byte(stateFile))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestParseTerraformStateResourcesV3(t *testing.T) {
+	t.Parallel()
+
+	stateFile := `
+{
+  "version": 3,
+  "serial": 5,
+  "modules": [
+    {
+      "path": ["root"],
+      "resources": {
+        "aws_vpc.main": {"type": "aws_vpc", "primary": {"id": "vpc-123"}},
+        "data.aws_ami.ubuntu": {"type": "aws_ami", "primary": {"id": "ami-456"}}
+      }
+    },
+    {
+      "path": ["root", "subnets"],
+      "resources": {
+        "aws_subnet.private.0": {"type": "aws_subnet", "primary": {"id": "subnet-a"}},
+        "aws_subnet.private.1": {"type": "aws_subnet", "primary": {"id": "subnet-b"}}
+      }
+    }
+  ]
+}
+`
+
+	expected := []TerraformStateResource{
+		{Address: "aws_vpc.main", Type: "aws_vpc", Name: "main", Id: "vpc-123"},
+		{Address: "module.subnets.aws_subnet.private[0]", Type: "aws_subnet", Name: "private", Id: "subnet-a"},
+		{Address: "module.subnets.aws_subnet.private[1]", Type: "aws_subnet", Name: "private", Id: "subnet-b"},
+	}
+
+	actual, err := ParseTerraformStateResources([]byte(stateFile))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestParseTerraformStateResourcesEmpty(t *testing.T) {
+	t.Parallel()
+
+	actual, err := ParseTerraformStateResources([]byte("\n"))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Empty(t, actual)
+
+	_, err = ParseTerraformStateResources([]byte("not json"))
+	assert.NotNil(t, err)
+}
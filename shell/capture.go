@@ -0,0 +1,132 @@
+package shell
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The most bytes RunCommandAndCaptureOutput keeps from each of stdout and stderr, unless the CaptureOptions say
+// otherwise, so a command that writes a lot doesn't use up all the memory
+const DEFAULT_MAX_CAPTURED_OUTPUT_BYTES = 10 * 1024 * 1024
+
+// How RunCommandAndCaptureOutput captures the output of a command
+type CaptureOptions struct {
+	// The most bytes to keep from each of stdout and stderr. The rest of the output is dropped, and the CapturedOutput
+	// is marked as Truncated. Zero means DEFAULT_MAX_CAPTURED_OUTPUT_BYTES, and a negative number means no limit.
+	MaxBytes int
+
+	// Each match of these in the output is replaced with util.REDACTED_VALUE before the output is logged or returned,
+	// as util.Redact does. The patterns are applied to the output that was kept, so a secret that starts just before
+	// MaxBytes may be partly kept.
+	RedactPatterns []*regexp.Regexp
+
+	// If set to true, capture stderr together with stdout, in the order the command writes them, in Stdout
+	CombineOutput bool
+
+	// If set to true, log the output with the Logger of the options once the command finishes, e.g. for a command whose
+	// output the user should see, but which may contain secrets
+	LogOutput bool
+}
+
+// The output of a command run with RunCommandAndCaptureOutput
+type CapturedOutput struct {
+	Stdout    string
+	Stderr    string
+	Truncated bool
+}
+
+// Run the given command with the given args, as RunShellCommand does, but capture its stdout and stderr in memory,
+// rather than connecting them to the Writer and ErrWriter of the given options, as set out in the given CaptureOptions.
+// The output is returned even if the command fails, so the caller can show why. Terraform commands are captured the same
+// way with RunTerraformCommandAndCaptureOutput. Reads that run a whole Terragrunt command in another module, such as
+// output-from, capture the Writer of its options instead, as they need the source, auto-init, and IAM role that
+// RunTerragrunt sets up.
+func RunCommandAndCaptureOutput(terragruntOptions *options.TerragruntOptions, captureOptions CaptureOptions, command string, args ...string) (*CapturedOutput, error) {
+	return captureCommandOutput(LocalExecutor{}, terragruntOptions, captureOptions, command, args)
+}
+
+// Run the given command with the given args with the given Executor, capturing its output as RunCommandAndCaptureOutput
+// does
+func captureCommandOutput(executor Executor, terragruntOptions *options.TerragruntOptions, captureOptions CaptureOptions, command string, args []string) (*CapturedOutput, error) {
+	maxBytes := captureOptions.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = DEFAULT_MAX_CAPTURED_OUTPUT_BYTES
+	}
+
+	stdout := &limitedBuffer{maxBytes: maxBytes}
+	stderr := stdout
+	if !captureOptions.CombineOutput {
+		stderr = &limitedBuffer{maxBytes: maxBytes}
+	}
+
+	terragruntOptionsCopy := *terragruntOptions
+	terragruntOptionsCopy.Writer = stdout
+	terragruntOptionsCopy.ErrWriter = stderr
+	// The output is redacted with the RedactPatterns of the CaptureOptions instead, if at all, as some callers parse it
+	terragruntOptionsCopy.RedactTerraformOutput = false
+
+	// runShellCommand sends the stdout of any command other than the Terraform command the user asked for to stderr, to
+	// keep the stdout of Terragrunt clean. That's not needed when capturing stdout, so pass the args of that command
+	// instead, unless the output is combined anyway. This never runs the command in a pseudo-terminal, as that only
+	// happens when the Writer is stdout.
+	terraformArgs := args
+	if !captureOptions.CombineOutput {
+		terraformArgs = terragruntOptionsCopy.TerraformCliArgs
+	}
+
+	err := executor.Run(&terragruntOptionsCopy, terraformArgs, command, args)
+
+	output := &CapturedOutput{
+		Stdout:    util.Redact(stdout.buffer.String(), captureOptions.RedactPatterns),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+	if !captureOptions.CombineOutput {
+		output.Stderr = util.Redact(stderr.buffer.String(), captureOptions.RedactPatterns)
+	}
+
+	if captureOptions.LogOutput {
+		logCapturedOutput(terragruntOptions, command, args, output)
+	}
+
+	return output, err
+}
+
+func logCapturedOutput(terragruntOptions *options.TerragruntOptions, command string, args []string, output *CapturedOutput) {
+	combined := output.Stdout + output.Stderr
+	if combined == "" {
+		return
+	}
+
+	truncatedNote := ""
+	if output.Truncated {
+		truncatedNote = " (truncated)"
+	}
+	terragruntOptions.Logger.Printf("Output of %s%s:\n%s", formatCommandForLog(command, args), truncatedNote, combined)
+}
+
+// An io.Writer that keeps the first maxBytes bytes written to it, or all of them if maxBytes is negative, and drops the
+// rest. Writes never fail, so a command that writes more doesn't get an error, or a broken pipe.
+type limitedBuffer struct {
+	buffer    bytes.Buffer
+	maxBytes  int
+	truncated bool
+}
+
+func (limited *limitedBuffer) Write(data []byte) (int, error) {
+	if limited.maxBytes < 0 {
+		return limited.buffer.Write(data)
+	}
+
+	room := limited.maxBytes - limited.buffer.Len()
+	if len(data) > room {
+		limited.truncated = true
+		if room > 0 {
+			limited.buffer.Write(data[:room])
+		}
+		return len(data), nil
+	}
+	return limited.buffer.Write(data)
+}
@@ -0,0 +1,118 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommandAndCaptureOutput(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		captureOptions    CaptureOptions
+		expectedStdout    string
+		expectedStderr    string
+		expectedTruncated bool
+	}{
+		{CaptureOptions{}, "token=abc123 on stdout\n", "password: hunter2 on stderr\n", false},
+		{CaptureOptions{RedactPatterns: []*regexp.Regexp{regexp.MustCompile(`token=(\w+)`), regexp.MustCompile(`hunter2`)}}, "token=REDACTED on stdout\n", "password: REDACTED on stderr\n", false},
+		{CaptureOptions{MaxBytes: 5}, "token", "passw", true},
+		{CaptureOptions{CombineOutput: true}, "token=abc123 on stdout\npassword: hunter2 on stderr\n", "", false},
+	}
+
+	for _, testCase := range testCases {
+		output, err := RunCommandAndCaptureOutput(helperProcessOptions(t), testCase.captureOptions, os.Args[0], "-test.run=TestHelperProcessWriteOutput")
+		if assert.Nil(t, err, "For capture options %v", testCase.captureOptions) {
+			assert.Equal(t, testCase.expectedStdout, output.Stdout, "For capture options %v", testCase.captureOptions)
+			assert.Equal(t, testCase.expectedStderr, output.Stderr, "For capture options %v", testCase.captureOptions)
+			assert.Equal(t, testCase.expectedTruncated, output.Truncated, "For capture options %v", testCase.captureOptions)
+		}
+	}
+}
+
+func TestRunCommandAndCaptureOutputLogOutput(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+	terragruntOptions := helperProcessOptions(t)
+	terragruntOptions.Logger = util.CreateLoggerWithWriter(&logs, "")
+
+	captureOptions := CaptureOptions{RedactPatterns: []*regexp.Regexp{regexp.MustCompile(`hunter2`)}, LogOutput: true}
+	_, err := RunCommandAndCaptureOutput(terragruntOptions, captureOptions, os.Args[0], "-test.run=TestHelperProcessWriteOutput")
+	assert.Nil(t, err)
+
+	assert.Contains(t, logs.String(), "Output of ")
+	assert.Contains(t, logs.String(), "password: REDACTED on stderr")
+	assert.NotContains(t, logs.String(), "hunter2")
+}
+
+func TestRunTerraformCommandAndCaptureOutput(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := helperProcessOptions(t)
+	terragruntOptions.TerraformPath = os.Args[0]
+
+	output, err := RunTerraformCommandAndCaptureOutput(terragruntOptions, CaptureOptions{MaxBytes: 5}, "-test.run=TestHelperProcessWriteOutput")
+	assert.Nil(t, err)
+	assert.Equal(t, "token", output.Stdout)
+	assert.Equal(t, "passw", output.Stderr)
+	assert.True(t, output.Truncated)
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		maxBytes          int
+		writes            []string
+		expected          string
+		expectedTruncated bool
+	}{
+		{10, []string{"abc", "def"}, "abcdef", false},
+		{4, []string{"abc", "def", "ghi"}, "abcd", true},
+		{3, []string{"abc"}, "abc", false},
+		{0, []string{"abc"}, "", true},
+		{-1, []string{strings.Repeat("a", 100)}, strings.Repeat("a", 100), false},
+	}
+
+	for _, testCase := range testCases {
+		limited := &limitedBuffer{maxBytes: testCase.maxBytes}
+		for _, write := range testCase.writes {
+			written, err := limited.Write([]byte(write))
+			assert.Nil(t, err)
+			assert.Equal(t, len(write), written)
+		}
+		assert.Equal(t, testCase.expected, limited.buffer.String(), "For max bytes %d and writes %v", testCase.maxBytes, testCase.writes)
+		assert.Equal(t, testCase.expectedTruncated, limited.truncated, "For max bytes %d and writes %v", testCase.maxBytes, testCase.writes)
+	}
+}
+
+func helperProcessOptions(t *testing.T) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Go programs on Windows need SYSTEMROOT to start
+	terragruntOptions.Env = map[string]string{"TERRAGRUNT_TEST_HELPER_PROCESS": "1", "SYSTEMROOT": os.Getenv("SYSTEMROOT")}
+	return terragruntOptions
+}
+
+// Not a real test: the tests of RunCommandAndCaptureOutput run the test binary with this test as a helper process,
+// which writes a line with a secret to each of stdout and stderr
+func TestHelperProcessWriteOutput(t *testing.T) {
+	if os.Getenv("TERRAGRUNT_TEST_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "token=abc123 on stdout\n")
+	fmt.Fprintf(os.Stderr, "password: hunter2 on stderr\n")
+	os.Exit(0)
+}
@@ -0,0 +1,146 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/mitchellh/go-homedir"
+)
+
+// The command that runs Terraform in a container when TerraformDockerImage is set
+const DOCKER_COMMAND = "docker"
+
+// The environment variables passed on to Terraform when it runs in a container or on another host: the ones with these
+// prefixes, which cover Terraform's own settings, TF_VAR_ variables, and the credentials of the common providers, plus
+// the proxy settings. The rest, such as PATH and HOME, describe this machine, and would break Terraform there.
+var PASSED_ON_ENV_VAR_PREFIXES = []string{
+	"TF_",
+	"AWS_",
+	"GOOGLE_",
+	"CLOUDSDK_",
+	"ARM_",
+	"AZURE_",
+	"VAULT_",
+	"HTTP_PROXY",
+	"HTTPS_PROXY",
+	"NO_PROXY",
+	"http_proxy",
+	"https_proxy",
+	"no_proxy",
+}
+
+// Return the docker command and args that run Terraform with the given args in a container of the
+// TerraformDockerImage, as the current user, so the files Terraform writes, such as the .terraform folder, belong to
+// the user rather than to root
+func terraformDockerCommand(terragruntOptions *options.TerragruntOptions, args []string) (string, []string) {
+	homeDir, err := homedir.Dir()
+	if err != nil {
+		terragruntOptions.Logger.Printf("Unable to find the home dir, so not passing ~/.aws on to the Terraform container: %v", err)
+	}
+	return DOCKER_COMMAND, dockerRunArgs(terragruntOptions, args, homeDir, os.Getuid(), os.Getgid())
+}
+
+// Return the args for 'docker run' that run Terraform with the given args in a container of the TerraformDockerImage.
+// The working dir, the folder of the Terragrunt config, the download dir, and the Terraform plugin cache, if any, are
+// mounted at the same paths they have on the host, so the absolute paths in the args, such as those of var files, work
+// in the container too. The environment variables in PASSED_ON_ENV_VAR_PREFIXES are passed by name only, so docker
+// reads their values from its own environment, and credentials don't end up in the logs. The ~/.aws folder in the
+// given home dir is mounted read-only, for the AWS profiles and credentials files.
+//
+// The entrypoint is TerraformPath, so it works both with images whose entrypoint is Terraform, such as
+// hashicorp/terraform, and with images that have Terraform on their PATH. On Windows, where there are no user IDs,
+// the given uid is -1, and the container runs as its default user.
+func dockerRunArgs(terragruntOptions *options.TerragruntOptions, args []string, homeDir string, uid int, gid int) []string {
+	dockerArgs := []string{"run", "--rm", "-i"}
+	if uid >= 0 {
+		dockerArgs = append(dockerArgs, "--user", fmt.Sprintf("%d:%d", uid, gid))
+	}
+	if terragruntOptions.Offline {
+		// Use the image only if it was pulled before, rather than letting docker pull it
+		dockerArgs = append(dockerArgs, "--pull", "never")
+	}
+	dockerArgs = append(dockerArgs, "--entrypoint", terragruntOptions.TerraformPath)
+
+	folders := []string{
+		terragruntOptions.WorkingDir,
+		filepath.Dir(terragruntOptions.TerragruntConfigPath),
+		terragruntOptions.DownloadDir,
+		terragruntOptions.Env["TF_PLUGIN_CACHE_DIR"],
+	}
+	for _, folder := range foldersToMount(folders) {
+		dockerArgs = append(dockerArgs, "-v", folder+":"+folder)
+	}
+
+	awsDir := filepath.Join(homeDir, ".aws")
+	if homeDir != "" && isDir(awsDir) {
+		dockerArgs = append(dockerArgs, "-v", awsDir+":"+awsDir+":ro")
+		if _, isSet := terragruntOptions.Env["AWS_CONFIG_FILE"]; !isSet {
+			dockerArgs = append(dockerArgs, "-e", "AWS_CONFIG_FILE="+filepath.Join(awsDir, "config"))
+		}
+		if _, isSet := terragruntOptions.Env["AWS_SHARED_CREDENTIALS_FILE"]; !isSet {
+			dockerArgs = append(dockerArgs, "-e", "AWS_SHARED_CREDENTIALS_FILE="+filepath.Join(awsDir, "credentials"))
+		}
+	}
+
+	for _, name := range envVarsToPassOn(terragruntOptions.Env) {
+		dockerArgs = append(dockerArgs, "-e", name)
+	}
+
+	dockerArgs = append(dockerArgs, "-w", terragruntOptions.WorkingDir, terragruntOptions.TerraformDockerImage)
+	return append(dockerArgs, args...)
+}
+
+// Return the given folders that exist, cleaned and sorted, without duplicates and folders inside other folders in the
+// list, as mounting those again is redundant. Folders that don't exist are skipped, as docker would create them, owned
+// by root.
+func foldersToMount(folders []string) []string {
+	existingFolders := []string{}
+	for _, folder := range folders {
+		if folder != "" && isDir(folder) {
+			existingFolders = append(existingFolders, filepath.Clean(folder))
+		}
+	}
+	sort.Strings(existingFolders)
+
+	mounts := []string{}
+	for _, folder := range existingFolders {
+		if !isInAnyFolder(folder, mounts) {
+			mounts = append(mounts, folder)
+		}
+	}
+	return mounts
+}
+
+// Return true if the given folder is one of the given folders or inside one of them
+func isInAnyFolder(folder string, folders []string) bool {
+	for _, otherFolder := range folders {
+		if folder == otherFolder || strings.HasPrefix(folder, strings.TrimSuffix(otherFolder, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the names of the given environment variables that start with one of the PASSED_ON_ENV_VAR_PREFIXES, sorted
+func envVarsToPassOn(env map[string]string) []string {
+	names := []string{}
+	for name := range env {
+		for _, prefix := range PASSED_ON_ENV_VAR_PREFIXES {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,111 @@
+package shell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerRunArgs(t *testing.T) {
+	t.Parallel()
+
+	rootDir, err := ioutil.TempDir("", "docker-run-args-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	moduleDir := filepath.Join(rootDir, "live", "app")
+	downloadDir := filepath.Join(moduleDir, ".terragrunt-cache")
+	homeDir := filepath.Join(rootDir, "home")
+	for _, dir := range []string{downloadDir, filepath.Join(homeDir, ".aws")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest(filepath.Join(moduleDir, "terraform.tfvars"))
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.WorkingDir = moduleDir
+	terragruntOptions.DownloadDir = downloadDir
+	terragruntOptions.TerraformDockerImage = "hashicorp/terraform:0.11.7"
+	terragruntOptions.Env = map[string]string{
+		"PATH":                  "/usr/bin",
+		"HOME":                  homeDir,
+		"TF_VAR_region":         "us-east-1",
+		"AWS_ACCESS_KEY_ID":     "AKIA",
+		"AWS_CONFIG_FILE":       "/etc/aws/config",
+		"TF_PLUGIN_CACHE_DIR":   filepath.Join(rootDir, "missing"),
+		"https_proxy":           "http://proxy:3128",
+		"TERRAGRUNT_IAM_ROLE":   "arn:aws:iam::123456789012:role/admin",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+	}
+
+	awsDir := filepath.Join(homeDir, ".aws")
+	expected := []string{
+		"run", "--rm", "-i", "--user", "1000:1000", "--entrypoint", "terraform",
+		"-v", moduleDir + ":" + moduleDir,
+		"-v", awsDir + ":" + awsDir + ":ro",
+		"-e", "AWS_SHARED_CREDENTIALS_FILE=" + filepath.Join(awsDir, "credentials"),
+		"-e", "AWS_ACCESS_KEY_ID",
+		"-e", "AWS_CONFIG_FILE",
+		"-e", "AWS_SECRET_ACCESS_KEY",
+		"-e", "TF_PLUGIN_CACHE_DIR",
+		"-e", "TF_VAR_region",
+		"-e", "https_proxy",
+		"-w", moduleDir, "hashicorp/terraform:0.11.7",
+		"plan", "-input=false",
+	}
+	assert.Equal(t, expected, dockerRunArgs(terragruntOptions, []string{"plan", "-input=false"}, homeDir, 1000, 1000))
+
+	expected = []string{
+		"run", "--rm", "-i", "--entrypoint", "terraform",
+		"-v", moduleDir + ":" + moduleDir,
+		"-e", "AWS_ACCESS_KEY_ID",
+		"-e", "AWS_CONFIG_FILE",
+		"-e", "AWS_SECRET_ACCESS_KEY",
+		"-e", "TF_PLUGIN_CACHE_DIR",
+		"-e", "TF_VAR_region",
+		"-e", "https_proxy",
+		"-w", moduleDir, "hashicorp/terraform:0.11.7",
+		"apply",
+	}
+	assert.Equal(t, expected, dockerRunArgs(terragruntOptions, []string{"apply"}, "", -1, -1))
+
+	// Offline, docker may not pull the image
+	terragruntOptions.Offline = true
+	actual := dockerRunArgs(terragruntOptions, []string{"apply"}, "", -1, -1)
+	assert.Equal(t, []string{"run", "--rm", "-i", "--pull", "never", "--entrypoint", "terraform"}, actual[:7])
+}
+
+func TestFoldersToMount(t *testing.T) {
+	t.Parallel()
+
+	rootDir, err := ioutil.TempDir("", "folders-to-mount-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	for _, dir := range []string{"a/b", "a-x", "c"} {
+		if err := os.MkdirAll(filepath.Join(rootDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	folders := []string{
+		filepath.Join(rootDir, "c"),
+		filepath.Join(rootDir, "a", "b"),
+		"",
+		filepath.Join(rootDir, "a-x"),
+		filepath.Join(rootDir, "a") + string(filepath.Separator),
+		filepath.Join(rootDir, "missing"),
+		filepath.Join(rootDir, "c"),
+	}
+	expected := []string{filepath.Join(rootDir, "a"), filepath.Join(rootDir, "a-x"), filepath.Join(rootDir, "c")}
+	assert.Equal(t, expected, foldersToMount(folders))
+}
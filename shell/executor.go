@@ -0,0 +1,166 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The commands the SshExecutor runs commands and copies folders with
+const SSH_COMMAND = "ssh"
+const RSYNC_COMMAND = "rsync"
+
+// An Executor runs the Terraform commands of a module, either on this machine or somewhere else
+type Executor interface {
+	// Run the given command with the given args, connecting its stdin, stdout, and stderr to the ones in the given
+	// options. The terraformArgs are the args of the Terraform command it runs, as for runShellCommand.
+	Run(terragruntOptions *options.TerragruntOptions, terraformArgs []string, command string, args []string) error
+}
+
+// Return the Executor for the Terraform commands of the module of the given options: an SshExecutor if RemoteExec is
+// set, and a LocalExecutor otherwise
+func GetExecutor(terragruntOptions *options.TerragruntOptions) Executor {
+	if terragruntOptions.RemoteExec != nil {
+		return SshExecutor{RemoteExec: *terragruntOptions.RemoteExec}
+	}
+	return LocalExecutor{}
+}
+
+// LocalExecutor runs commands on this machine
+type LocalExecutor struct{}
+
+func (executor LocalExecutor) Run(terragruntOptions *options.TerragruntOptions, terraformArgs []string, command string, args []string) error {
+	return runShellCommand(terragruntOptions, terraformArgs, command, args...)
+}
+
+// SshExecutor runs commands on another host over SSH, in the same working dir they'd run in on this machine, with the
+// environment variables in PASSED_ON_ENV_VAR_PREFIXES. If SyncFolders is set, it copies the folders the command needs
+// to the host with rsync first, and copies the working dir, where Terraform writes its files, back afterwards.
+type SshExecutor struct {
+	RemoteExec options.RemoteExecOptions
+}
+
+func (executor SshExecutor) Run(terragruntOptions *options.TerragruntOptions, terraformArgs []string, command string, args []string) error {
+	if err := terragruntOptions.CheckNetworkAccess(fmt.Sprintf("run %s on %s over SSH", command, executor.RemoteExec.Host)); err != nil {
+		return err
+	}
+
+	if executor.RemoteExec.SyncFolders {
+		if err := executor.syncFoldersToHost(terragruntOptions); err != nil {
+			return err
+		}
+	}
+
+	envFile, err := executor.uploadEnvVars(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	cmdErr := runShellCommand(terragruntOptions, terraformArgs, SSH_COMMAND, executor.sshArgs(terragruntOptions.WorkingDir, envFile, command, args)...)
+
+	if executor.RemoteExec.SyncFolders {
+		if err := executor.syncWorkingDirFromHost(terragruntOptions); err != nil {
+			if cmdErr != nil {
+				terragruntOptions.Logger.Printf("Error copying %s back from %s: %v", terragruntOptions.WorkingDir, executor.RemoteExec.Host, err)
+				return cmdErr
+			}
+			return err
+		}
+	}
+
+	return cmdErr
+}
+
+// Return the args for ssh that run the given command with the given args on the host: the extra SshArgs, the host, and
+// the command for the shell on the host, which loads and deletes the given file of environment variables, if any, goes
+// to the given working dir, and runs the command
+func (executor SshExecutor) sshArgs(workingDir string, envFile string, command string, args []string) []string {
+	remoteCommand := "cd " + util.QuoteShellWord(workingDir) + " && exec " + util.JoinShellWords(append([]string{command}, args...))
+	if envFile != "" {
+		remoteCommand = ". " + util.QuoteShellWord(envFile) + " && rm -f " + util.QuoteShellWord(envFile) + " && " + remoteCommand
+	}
+
+	sshArgs := util.CloneStringList(executor.RemoteExec.SshArgs)
+	return append(sshArgs, executor.RemoteExec.Host, remoteCommand)
+}
+
+// Write the environment variables to pass on to a file on the host that only the user can read, and return its path,
+// or an empty string if there are none. The values may be credentials, so rather than putting them in the command line
+// of ssh, where anyone on either machine could see them in the list of processes, they go through its stdin.
+func (executor SshExecutor) uploadEnvVars(terragruntOptions *options.TerragruntOptions) (string, error) {
+	names := envVarsToPassOn(terragruntOptions.Env)
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	var envFile bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&envFile, "export %s=%s\n", name, util.QuoteShellWord(terragruntOptions.Env[name]))
+	}
+
+	uploadOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	uploadOptions.Reader = &envFile
+	sshArgs := append(util.CloneStringList(executor.RemoteExec.SshArgs), executor.RemoteExec.Host, `umask 077 && file=$(mktemp) && cat > "$file" && echo "$file"`)
+
+	out, err := RunShellCommandAndCaptureOutput(uploadOptions, SSH_COMMAND, sshArgs...)
+	if err != nil {
+		return "", errors.WithStackTrace(RemoteExecFailed{Host: executor.RemoteExec.Host, Action: "copy the environment variables to", Underlying: err})
+	}
+
+	// ssh may print warnings, such as about adding the host to the known hosts, before the output of the command
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
+}
+
+// Copy the working dir and the folder of the Terragrunt config to the same paths on the host, deleting the files there
+// that no longer exist here
+func (executor SshExecutor) syncFoldersToHost(terragruntOptions *options.TerragruntOptions) error {
+	for _, folder := range foldersToMount([]string{terragruntOptions.WorkingDir, filepath.Dir(terragruntOptions.TerragruntConfigPath)}) {
+		terragruntOptions.Logger.Printf("Copying %s to %s", folder, executor.RemoteExec.Host)
+		rsyncArgs := append(executor.rsyncArgs(), "--delete", "--rsync-path", "mkdir -p "+util.QuoteShellWord(folder)+" && rsync", folder+"/", executor.RemoteExec.Host+":"+folder+"/")
+		if err := RunShellCommand(terragruntOptions, RSYNC_COMMAND, rsyncArgs...); err != nil {
+			return errors.WithStackTrace(RemoteExecFailed{Host: executor.RemoteExec.Host, Action: "copy " + folder + " to", Underlying: err})
+		}
+	}
+	return nil
+}
+
+// Copy the working dir back from the host, so the files Terraform wrote there, such as the .terraform folder and plan
+// files, are here too
+func (executor SshExecutor) syncWorkingDirFromHost(terragruntOptions *options.TerragruntOptions) error {
+	folder := filepath.Clean(terragruntOptions.WorkingDir)
+	terragruntOptions.Logger.Printf("Copying %s back from %s", folder, executor.RemoteExec.Host)
+	rsyncArgs := append(executor.rsyncArgs(), executor.RemoteExec.Host+":"+folder+"/", folder+"/")
+	if err := RunShellCommand(terragruntOptions, RSYNC_COMMAND, rsyncArgs...); err != nil {
+		return errors.WithStackTrace(RemoteExecFailed{Host: executor.RemoteExec.Host, Action: "copy " + folder + " back from", Underlying: err})
+	}
+	return nil
+}
+
+// Return the args rsync needs to copy folders to or from the host: archive mode, compression, paths that the shell on
+// the host doesn't split, and ssh with the extra SshArgs as the remote shell
+func (executor SshExecutor) rsyncArgs() []string {
+	sshCommand := append([]string{SSH_COMMAND}, executor.RemoteExec.SshArgs...)
+	return []string{"-az", "--protect-args", "-e", util.JoinShellWords(sshCommand)}
+}
+
+// Custom error types
+
+type RemoteExecFailed struct {
+	Host       string
+	Action     string
+	Underlying error
+}
+
+func (err RemoteExecFailed) Error() string {
+	return fmt.Sprintf("Unable to %s %s: %v", err.Action, err.Host, err.Underlying)
+}
+
+func (err RemoteExecFailed) Unwrap() error {
+	return err.Underlying
+}
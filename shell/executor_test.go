@@ -0,0 +1,55 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetExecutor(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	assert.Equal(t, LocalExecutor{}, GetExecutor(terragruntOptions))
+
+	terragruntOptions.RemoteExec = &options.RemoteExecOptions{Host: "runner.internal", SshArgs: []string{"-p", "2222"}}
+	assert.Equal(t, SshExecutor{RemoteExec: options.RemoteExecOptions{Host: "runner.internal", SshArgs: []string{"-p", "2222"}}}, GetExecutor(terragruntOptions))
+}
+
+func TestSshExecutorSshArgs(t *testing.T) {
+	t.Parallel()
+
+	executor := SshExecutor{RemoteExec: options.RemoteExecOptions{Host: "ubuntu@runner.internal", SshArgs: []string{"-i", "/home/me/.ssh/runner"}}}
+
+	actual := executor.sshArgs("/live/prod/my app", "", "terraform", []string{"plan", "-var", "name=it's"})
+	expected := []string{"-i", "/home/me/.ssh/runner", "ubuntu@runner.internal", `cd '/live/prod/my app' && exec terraform plan -var 'name=it'\''s'`}
+	assert.Equal(t, expected, actual)
+
+	actual = executor.sshArgs("/live/prod/app", "/tmp/tmp.abc123", "terraform", []string{"apply"})
+	expected = []string{"-i", "/home/me/.ssh/runner", "ubuntu@runner.internal", ". /tmp/tmp.abc123 && rm -f /tmp/tmp.abc123 && cd /live/prod/app && exec terraform apply"}
+	assert.Equal(t, expected, actual)
+}
+
+func TestSshExecutorOffline(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.Offline = true
+
+	executor := SshExecutor{RemoteExec: options.RemoteExecOptions{Host: "runner.internal", SyncFolders: true}}
+	err = executor.Run(terragruntOptions, []string{"plan"}, "terraform", []string{"plan"})
+	_, isOfflineErr := errors.Unwrap(err).(options.NetworkAccessInOfflineMode)
+	assert.True(t, isOfflineErr, "Expected a NetworkAccessInOfflineMode error but got: %v", err)
+}
+
+func TestSshExecutorRsyncArgs(t *testing.T) {
+	t.Parallel()
+
+	executor := SshExecutor{RemoteExec: options.RemoteExecOptions{Host: "runner.internal", SshArgs: []string{"-o", "ProxyJump=bastion user"}}}
+	assert.Equal(t, []string{"-az", "--protect-args", "-e", "ssh -o 'ProxyJump=bastion user'"}, executor.rsyncArgs())
+}
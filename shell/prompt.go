@@ -5,16 +5,30 @@ import (
 	"fmt"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
+	"io"
 	"os"
 	"strings"
+	"time"
 )
 
-// Prompt the user for text in the CLI. Returns the text entered by the user.
+// Prompt the user for text in the CLI. Returns the text entered by the user. If the user doesn't answer within the
+// PromptTimeout in the given options, returns an empty string, as if they had just pressed Enter.
 func PromptUserForInput(prompt string, terragruntOptions *options.TerragruntOptions) (string, error) {
 	if terragruntOptions.Logger.Prefix() != "" {
 		prompt = fmt.Sprintf("%s %s", terragruntOptions.Logger.Prefix(), prompt)
 	}
-	terragruntOptions.Logger.Print(prompt)
+	if terragruntOptions.AssumeNo || terragruntOptions.NonInteractive {
+		terragruntOptions.Logger.Print(prompt)
+	} else {
+		// The user has to see the prompt to answer it, even in quiet mode
+		terragruntOptions.ErrorLogger().Print(prompt)
+	}
+
+	if terragruntOptions.AssumeNo {
+		terragruntOptions.Logger.Println()
+		terragruntOptions.Logger.Printf("The assume-no flag is set to true, so assuming 'no' for all prompts")
+		return "no", nil
+	}
 
 	if terragruntOptions.NonInteractive {
 		terragruntOptions.Logger.Println()
@@ -22,19 +36,62 @@ func PromptUserForInput(prompt string, terragruntOptions *options.TerragruntOpti
 		return "yes", nil
 	}
 
-	reader := bufio.NewReader(os.Stdin)
+	var input io.Reader = os.Stdin
+	if terragruntOptions.Reader != nil {
+		input = terragruntOptions.Reader
+	}
+	reader := bufio.NewReader(input)
 
-	text, err := reader.ReadString('\n')
-	if err != nil {
-		return "", errors.WithStackTrace(err)
+	// Read the input in the background, so we can stop waiting for it if the run is cancelled
+	type readResult struct {
+		text string
+		err  error
+	}
+	readResults := make(chan readResult, 1)
+	go func() {
+		text, err := reader.ReadString('\n')
+		readResults <- readResult{text: text, err: err}
+	}()
+
+	// A nil channel blocks forever, so without a timeout, we wait until the user answers or the run is cancelled
+	var timeout <-chan time.Time
+	if terragruntOptions.PromptTimeout > 0 {
+		timer := time.NewTimer(terragruntOptions.PromptTimeout)
+		defer timer.Stop()
+		timeout = timer.C
 	}
 
-	return strings.TrimSpace(text), nil
+	select {
+	case result := <-readResults:
+		if result.err != nil {
+			return "", errors.WithStackTrace(result.err)
+		}
+		return strings.TrimSpace(result.text), nil
+	case <-timeout:
+		terragruntOptions.Logger.Println()
+		terragruntOptions.Logger.Printf("No answer after %v, so using the default answer", terragruntOptions.PromptTimeout)
+		return "", nil
+	case <-terragruntOptions.GetContext().Done():
+		terragruntOptions.Logger.Println()
+		return "", errors.WithStackTrace(terragruntOptions.GetContext().Err())
+	}
 }
 
-// Prompt the user for a yes/no response and return true if they entered yes.
+// Prompt the user for a yes/no response and return true if they entered yes. If they don't enter anything, or don't
+// answer within the PromptTimeout in the given options, assume no.
 func PromptUserForYesNo(prompt string, terragruntOptions *options.TerragruntOptions) (bool, error) {
-	resp, err := PromptUserForInput(fmt.Sprintf("%s (y/n) ", prompt), terragruntOptions)
+	return PromptUserForYesNoWithDefault(prompt, false, terragruntOptions)
+}
+
+// Prompt the user for a yes/no response and return true if they entered yes. If they don't enter anything, or don't
+// answer within the PromptTimeout in the given options, return the given default answer.
+func PromptUserForYesNoWithDefault(prompt string, defaultAnswer bool, terragruntOptions *options.TerragruntOptions) (bool, error) {
+	choices := "y/N"
+	if defaultAnswer {
+		choices = "Y/n"
+	}
+
+	resp, err := PromptUserForInput(fmt.Sprintf("%s (%s) ", prompt, choices), terragruntOptions)
 
 	if err != nil {
 		return false, errors.WithStackTrace(err)
@@ -43,7 +100,31 @@ func PromptUserForYesNo(prompt string, terragruntOptions *options.TerragruntOpti
 	switch strings.ToLower(resp) {
 	case "y", "yes":
 		return true, nil
+	case "":
+		return defaultAnswer, nil
 	default:
 		return false, nil
 	}
 }
+
+// Prompt the user to approve a destructive operation (e.g. destroy-all) and return true if they approved it. Unlike the
+// other prompts, the non-interactive flag alone does not assume "yes" here: in a non-interactive setting, the
+// operation is only approved if the auto-approve flag is set too.
+func PromptUserForApproval(prompt string, terragruntOptions *options.TerragruntOptions) (bool, error) {
+	if terragruntOptions.AssumeNo {
+		terragruntOptions.Logger.Printf("%s\nThe assume-no flag is set to true, so assuming 'no'", prompt)
+		return false, nil
+	}
+
+	if terragruntOptions.AutoApprove {
+		terragruntOptions.Logger.Printf("%s\nThe auto-approve flag is set to true, so assuming 'yes'", prompt)
+		return true, nil
+	}
+
+	if terragruntOptions.NonInteractive {
+		terragruntOptions.Logger.Printf("%s\nThe non-interactive flag is set to true, but the auto-approve flag is not, so assuming 'no'", prompt)
+		return false, nil
+	}
+
+	return PromptUserForYesNo(prompt, terragruntOptions)
+}
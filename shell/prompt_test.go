@@ -0,0 +1,131 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromptUserForInputReadsFromReader(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.NonInteractive = false
+	terragruntOptions.Reader = strings.NewReader("  foo bar \n")
+
+	text, err := PromptUserForInput("Enter some text: ", terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "foo bar", text)
+}
+
+func TestPromptUserForInputQuiet(t *testing.T) {
+	t.Parallel()
+
+	var errOutput bytes.Buffer
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.NonInteractive = false
+	terragruntOptions.Quiet = true
+	terragruntOptions.ErrWriter = &errOutput
+	terragruntOptions.Logger = util.CreateLoggerWithWriter(terragruntOptions.LogWriter(), "")
+	terragruntOptions.Reader = strings.NewReader("yes\n")
+
+	text, err := PromptUserForInput("Are you sure? ", terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "yes", text)
+	assert.Contains(t, errOutput.String(), "Are you sure? ")
+}
+
+func TestPromptUserForInputCancelled(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.NonInteractive = false
+
+	// Nothing is ever written to this pipe, so reading from it blocks until the prompt gives up
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	terragruntOptions.Reader = reader
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	terragruntOptions.Context = ctx
+
+	_, err = PromptUserForInput("Enter some text: ", terragruntOptions)
+	assert.Equal(t, context.Canceled, errors.Unwrap(err))
+}
+
+func TestPromptUserForYesNoWithDefault(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input         string
+		defaultAnswer bool
+		expected      bool
+	}{
+		{"y\n", false, true},
+		{"YES\n", false, true},
+		{"n\n", true, false},
+		{"foo\n", true, false},
+		{"\n", true, true},
+		{"\n", false, false},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.NonInteractive = false
+		terragruntOptions.Reader = strings.NewReader(testCase.input)
+
+		actual, err := PromptUserForYesNoWithDefault("Continue?", testCase.defaultAnswer, terragruntOptions)
+		assert.Nil(t, err, "Unexpected error for input %q: %v", testCase.input, err)
+		assert.Equal(t, testCase.expected, actual, "For input %q and default %v", testCase.input, testCase.defaultAnswer)
+	}
+}
+
+func TestPromptUserForYesNoTimeoutUsesDefault(t *testing.T) {
+	t.Parallel()
+
+	for _, defaultAnswer := range []bool{true, false} {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.NonInteractive = false
+		terragruntOptions.PromptTimeout = 10 * time.Millisecond
+
+		// Nothing is ever written to this pipe, so reading from it blocks until the prompt times out
+		reader, writer := io.Pipe()
+		defer writer.Close()
+		terragruntOptions.Reader = reader
+
+		actual, err := PromptUserForYesNoWithDefault("Continue?", defaultAnswer, terragruntOptions)
+		assert.Nil(t, err, "Unexpected error: %v", err)
+		assert.Equal(t, defaultAnswer, actual)
+	}
+}
+
+func TestPromptUserAssumeNo(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.AssumeNo = true
+	terragruntOptions.Reader = strings.NewReader("yes\n")
+
+	answer, err := PromptUserForYesNoWithDefault("Continue?", true, terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.False(t, answer, "Expected assume-no to take precedence over non-interactive and the default answer")
+
+	approved, err := PromptUserForApproval("Destroy everything?", terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.False(t, approved)
+}
@@ -0,0 +1,90 @@
+// +build !windows
+
+package shell
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"reflect"
+	"syscall"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/kr/pty"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Returns true if we should run the given command in a pseudo-terminal. When Terraform's stdout isn't a terminal, it
+// turns off colors and its progress output, and can't prompt for approval, so we give it a pseudo-terminal whenever the
+// user is at a terminal and the command is the one they asked for (rather than, say, the terraform init Terragrunt runs
+// before it). The output of every other command is captured or redirected by Terragrunt, so it runs as usual.
+func shouldRunInPty(terragruntOptions *options.TerragruntOptions, args []string) bool {
+	if terragruntOptions.NoPty || terragruntOptions.RedactTerraformOutput || !reflect.DeepEqual(terragruntOptions.TerraformCliArgs, args) {
+		return false
+	}
+
+	usesStdin := terragruntOptions.Reader == nil || terragruntOptions.Reader == io.Reader(os.Stdin)
+	usesStdout := terragruntOptions.Writer == io.Writer(os.Stdout)
+
+	return usesStdin && usesStdout && terminal.IsTerminal(int(os.Stdin.Fd())) && terminal.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Run the given command in a pseudo-terminal connected to the user's terminal, so it behaves exactly as if the user had
+// run it directly. We put the user's terminal in raw mode while the command runs, so every key press, including CTRL+C,
+// goes straight to the pseudo-terminal, which takes care of echoing input and turning CTRL+C into a signal for the
+// command. The command's stdout and stderr both go to the pseudo-terminal, just as they would go to the terminal.
+func runShellCommandInPty(cmd *exec.Cmd, terragruntOptions *options.TerragruntOptions) error {
+	stdinFd := int(os.Stdin.Fd())
+	oldState, err := terminal.MakeRaw(stdinFd)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer terminal.Restore(stdinFd, oldState)
+
+	// pty.Start only connects the command to the pseudo-terminal if these aren't set already
+	cmd.Stdin = nil
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer ptmx.Close()
+
+	// Keep the size of the pseudo-terminal in sync with the user's terminal, so the command's output wraps correctly
+	resizeSignals := make(chan os.Signal, 1)
+	signal.Notify(resizeSignals, syscall.SIGWINCH)
+	go func() {
+		for range resizeSignals {
+			if err := pty.InheritSize(os.Stdin, ptmx); err != nil {
+				terragruntOptions.Logger.Printf("Error resizing pseudo-terminal: %v", err)
+			}
+		}
+	}()
+	resizeSignals <- syscall.SIGWINCH
+	defer func() {
+		signal.Stop(resizeSignals)
+		close(resizeSignals)
+	}()
+
+	cmdChannel := make(chan error)
+	signalChannel := newSignalsForwarderWithContext(terragruntOptions.GetContext(), forwardSignals, cmd, terragruntOptions.Logger, cmdChannel)
+	defer signalChannel.Close()
+
+	// There's no way to interrupt a read from stdin, so this goroutine only exits once it reads the next input after the
+	// command has finished. That's OK, as the command Terragrunt runs in a pseudo-terminal is the last one that reads
+	// from stdin.
+	go io.Copy(ptmx, os.Stdin)
+
+	// Once the command exits, reading from the pseudo-terminal fails (with EIO on Linux), which is how we know all the
+	// output has been copied, so ignore that error
+	io.Copy(terragruntOptions.Writer, ptmx)
+
+	err = cmd.Wait()
+	cmdChannel <- err
+
+	return errors.WithStackTrace(err)
+}
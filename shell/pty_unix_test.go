@@ -0,0 +1,41 @@
+// +build !windows
+
+package shell
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldRunInPtyOnlyForUserCommandAtTerminal(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		args      []string
+		noPty     bool
+		useBuffer bool
+	}{
+		{"not the user's command", []string{"init"}, false, false},
+		{"pseudo-terminal disabled", []string{"apply"}, true, false},
+		{"output captured", []string{"apply"}, false, true},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.TerraformCliArgs = []string{"apply"}
+		terragruntOptions.NoPty = testCase.noPty
+		terragruntOptions.Reader = os.Stdin
+		terragruntOptions.Writer = os.Stdout
+		if testCase.useBuffer {
+			terragruntOptions.Writer = &bytes.Buffer{}
+		}
+
+		assert.False(t, shouldRunInPty(terragruntOptions, testCase.args), "For case: %s", testCase.name)
+	}
+}
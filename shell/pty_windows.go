@@ -0,0 +1,20 @@
+// +build windows
+
+package shell
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// Windows doesn't have pseudo-terminals, so commands always run with Terragrunt's stdin, stdout, and stderr
+func shouldRunInPty(terragruntOptions *options.TerragruntOptions, args []string) bool {
+	return false
+}
+
+func runShellCommandInPty(cmd *exec.Cmd, terragruntOptions *options.TerragruntOptions) error {
+	return errors.WithStackTrace(fmt.Errorf("Running commands in a pseudo-terminal is not supported on Windows"))
+}
@@ -1,58 +1,151 @@
 package shell
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"os/signal"
 	"reflect"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/gruntwork-io/terragrunt/audit"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
 )
 
-// Run the given Terraform command
+// How long to wait for a command to shut down gracefully after the run was cancelled before killing it
+const CANCELLED_COMMAND_GRACE_PERIOD = 1 * time.Minute
+
+// Run the given Terraform command with the Executor of the given options
 func RunTerraformCommand(terragruntOptions *options.TerragruntOptions, args ...string) error {
-	return RunShellCommand(terragruntOptions, terragruntOptions.TerraformPath, args...)
+	command, commandArgs := TerraformCommandWithWrapper(terragruntOptions, args)
+	startTime := time.Now()
+	err := GetExecutor(terragruntOptions).Run(terragruntOptions, args, command, commandArgs)
+	return auditTerraformCommand(terragruntOptions, args, startTime, err)
+}
+
+// Run the given Terraform command with the Executor of the given options, and capture its output as set out in the
+// given CaptureOptions, as RunCommandAndCaptureOutput does. Callers that parse stdout, such as the state or the plan as
+// JSON, shouldn't redact it, but stderr is always logged with the secrets redacted if the command fails, as it says why.
+func RunTerraformCommandAndCaptureOutput(terragruntOptions *options.TerragruntOptions, captureOptions CaptureOptions, args ...string) (*CapturedOutput, error) {
+	command, commandArgs := TerraformCommandWithWrapper(terragruntOptions, args)
+	startTime := time.Now()
+	terragruntOptionsCopy := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	output, err := captureCommandOutput(GetExecutor(terragruntOptions), terragruntOptionsCopy, captureOptions, command, commandArgs)
+	if err != nil && output.Stderr != "" {
+		terragruntOptions.ErrorLogger().Printf("Output of %s on stderr:\n%s", formatCommandForLog(command, commandArgs), output.Stderr)
+	}
+	return output, auditTerraformCommand(terragruntOptions, args, startTime, err)
 }
 
-// Run the given Terraform command and return the stdout as a string
-func RunTerraformCommandAndCaptureOutput(terragruntOptions *options.TerragruntOptions, args ...string) (string, error) {
-	return RunShellCommandAndCaptureOutput(terragruntOptions, terragruntOptions.TerraformPath, args...)
+// If the given options have an AuditLog, write a record of the Terraform command with the given args, which started at
+// the given time and returned the given error, to it. Return the error of the command or, if the command succeeded but
+// the record could not be written, the error writing it, as a run that isn't in the audit log must not look like a
+// success.
+func auditTerraformCommand(terragruntOptions *options.TerragruntOptions, args []string, startTime time.Time, cmdErr error) error {
+	if terragruntOptions.AuditLog == "" {
+		return cmdErr
+	}
+
+	exitCode := 0
+	if cmdErr != nil {
+		var err error
+		if exitCode, err = GetExitCode(cmdErr); err != nil {
+			exitCode = -1
+		}
+	}
+
+	if err := audit.Write(audit.NewRecord(terragruntOptions, args, startTime, exitCode), terragruntOptions); err != nil {
+		terragruntOptions.Logger.Printf("Error writing to the audit log %s: %v", terragruntOptions.AuditLog, err)
+		if cmdErr == nil {
+			return errors.WithStackTrace(AuditLogFailed{AuditLog: terragruntOptions.AuditLog, Underlying: err})
+		}
+	}
+
+	return cmdErr
+}
+
+// Return the command and args that run Terraform with the given args. That's Terraform with the given args, or, if there
+// is a TerraformDockerImage, docker running Terraform with the given args in a container of that image. If there is a
+// TerraformBinaryWrapper, the command is the wrapper, with the wrapper's own args, followed by that command and its
+// args, as its args.
+func TerraformCommandWithWrapper(terragruntOptions *options.TerragruntOptions, args []string) (string, []string) {
+	command, commandArgs := terragruntOptions.TerraformPath, args
+	if terragruntOptions.TerraformDockerImage != "" {
+		command, commandArgs = terraformDockerCommand(terragruntOptions, args)
+	}
+
+	wrapper := terragruntOptions.TerraformBinaryWrapper
+	if len(wrapper) == 0 {
+		return command, commandArgs
+	}
+
+	wrapperArgs := make([]string, 0, len(wrapper)+len(commandArgs))
+	wrapperArgs = append(wrapperArgs, wrapper[1:]...)
+	wrapperArgs = append(wrapperArgs, command)
+	wrapperArgs = append(wrapperArgs, commandArgs...)
+	return wrapper[0], wrapperArgs
 }
 
 // Run the specified shell command with the specified arguments. Connect the command's stdin, stdout, and stderr to
 // the currently running app.
 func RunShellCommand(terragruntOptions *options.TerragruntOptions, command string, args ...string) error {
-	terragruntOptions.Logger.Printf("Running command: %s %s", command, strings.Join(args, " "))
+	return runShellCommand(terragruntOptions, args, command, args...)
+}
+
+// Run the specified shell command with the specified arguments, as RunShellCommand does. The terraformArgs are the
+// args of the Terraform command it runs, which are the same as its own args unless Terraform runs inside a
+// TerraformBinaryWrapper. They tell us whether this is the Terraform command the user asked for.
+func runShellCommand(terragruntOptions *options.TerragruntOptions, terraformArgs []string, command string, args ...string) error {
+	// The env vars may have been set since the options were parsed, e.g. by a program that embeds Terragrunt
+	terragruntOptions.Redactor.AddSecretsFromEnv(terragruntOptions.Env)
+	terragruntOptions.Logger.Printf("Running command: %s", formatCommandForLog(command, args))
+
+	ctx := terragruntOptions.GetContext()
+	if err := ctx.Err(); err != nil {
+		return errors.WithStackTrace(err)
+	}
 
 	cmd := exec.Command(command, args...)
 
 	// TODO: consider adding prefix from terragruntOptions logger to stdout and stderr
 	cmd.Stdin = os.Stdin
+	if terragruntOptions.Reader != nil {
+		cmd.Stdin = terragruntOptions.Reader
+	}
 	cmd.Stdout = terragruntOptions.Writer
 	cmd.Stderr = terragruntOptions.ErrWriter
+	if terragruntOptions.RedactTerraformOutput {
+		cmd.Stdout = util.NewRedactingWriter(cmd.Stdout, terragruntOptions.Redactor)
+		cmd.Stderr = util.NewRedactingWriter(cmd.Stderr, terragruntOptions.Redactor)
+	}
 	cmd.Env = toEnvVarsList(terragruntOptions.Env)
 
 	// Terragrunt can run some commands (such as terraform remote config) before running the actual terraform
 	// command requested by the user. The output of these other commands should not end up on stdout as this
 	// breaks scripts relying on terraform's output.
-	if !reflect.DeepEqual(terragruntOptions.TerraformCliArgs, args) {
+	if !reflect.DeepEqual(terragruntOptions.TerraformCliArgs, terraformArgs) {
 		cmd.Stdout = cmd.Stderr
 	}
 
 	cmd.Dir = terragruntOptions.WorkingDir
 
+	if shouldRunInPty(terragruntOptions, terraformArgs) {
+		return runShellCommandInPty(cmd, terragruntOptions)
+	}
+
 	if err := cmd.Start(); err != nil {
 		// bad path, binary not executable, &c
 		return errors.WithStackTrace(err)
 	}
 	cmdChannel := make(chan error)
-	signalChannel := NewSignalsForwarder(forwardSignals, cmd, terragruntOptions.Logger, cmdChannel)
+	signalChannel := newSignalsForwarderWithContext(ctx, forwardSignals, cmd, terragruntOptions.Logger, cmdChannel)
 	defer signalChannel.Close()
 
 	err := cmd.Wait()
@@ -61,6 +154,20 @@ func RunShellCommand(terragruntOptions *options.TerragruntOptions, command strin
 	return errors.WithStackTrace(err)
 }
 
+// Return the given command and args as a single string for the logs. The args are passed to the command as separate
+// entries in its argv, without going through a shell, so this is just for display: any arg that is empty or contains
+// whitespace, quotes, or backslashes is quoted with Go syntax, so it's clear where each arg starts and ends.
+func formatCommandForLog(command string, args []string) string {
+	parts := []string{command}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\r\n\"'`\\") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
 func toEnvVarsList(envVarsAsMap map[string]string) []string {
 	envVarsAsList := []string{}
 	for key, value := range envVarsAsMap {
@@ -69,17 +176,13 @@ func toEnvVarsList(envVarsAsMap map[string]string) []string {
 	return envVarsAsList
 }
 
-// Run the specified shell command with the specified arguments. Capture the command's stdout and return it as a
-// string.
+// Run the specified shell command with the specified arguments, and return its stdout, together with its stderr, as a
+// string. The command runs in the folder of the Terragrunt config. The output isn't limited or redacted, as the callers
+// parse it, e.g. the output of git.
 func RunShellCommandAndCaptureOutput(terragruntOptions *options.TerragruntOptions, command string, args ...string) (string, error) {
-	stdout := new(bytes.Buffer)
-
 	terragruntOptionsCopy := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
-	terragruntOptionsCopy.Writer = stdout
-	terragruntOptionsCopy.ErrWriter = stdout
-
-	err := RunShellCommand(terragruntOptionsCopy, command, args...)
-	return stdout.String(), err
+	output, err := captureCommandOutput(LocalExecutor{}, terragruntOptionsCopy, CaptureOptions{MaxBytes: -1, CombineOutput: true}, command, args)
+	return output.Stdout, err
 }
 
 // Return the exit code of a command. If the error does not implement errors.IErrorCode or is not an exec.ExitError type,
@@ -100,18 +203,57 @@ type SignalsForwarder chan os.Signal
 
 // Forwards signals to a command, waiting for the command to finish.
 func NewSignalsForwarder(signals []os.Signal, c *exec.Cmd, logger *log.Logger, cmdChannel chan error) SignalsForwarder {
+	return newSignalsForwarderWithContext(context.Background(), signals, c, logger, cmdChannel)
+}
+
+// Forwards signals to a command, waiting for the command to finish. If the given context is cancelled before the
+// command finishes, interrupt the command so it can shut down gracefully (e.g. so Terraform can release its state
+// lock), unless a signal was already forwarded to it, and kill it if it's still running after
+// CANCELLED_COMMAND_GRACE_PERIOD.
+func newSignalsForwarderWithContext(ctx context.Context, signals []os.Signal, c *exec.Cmd, logger *log.Logger, cmdChannel chan error) SignalsForwarder {
 	signalChannel := make(chan os.Signal, 1)
 	signal.Notify(signalChannel, signals...)
 
 	go func() {
+		signalForwarded := false
+		ctxDone := ctx.Done()
+		var killTimer <-chan time.Time
+
+		forwardSignal := func(s os.Signal) {
+			logger.Printf("Forward signal %v to terraform.", s)
+			err := c.Process.Signal(s)
+			if err != nil {
+				logger.Printf("Error forwarding signal: %v", err)
+			}
+			signalForwarded = true
+		}
+
 		for {
 			select {
 			case s := <-signalChannel:
-				logger.Printf("Forward signal %v to terraform.", s)
-				err := c.Process.Signal(s)
-				if err != nil {
-					logger.Printf("Error forwarding signal: %v", err)
+				forwardSignal(s)
+			case <-ctxDone:
+				ctxDone = nil
+				killTimer = time.After(CANCELLED_COMMAND_GRACE_PERIOD)
+
+				// The context may have been cancelled because of the same signal we're about to forward, in which case,
+				// the command should only get that signal
+				select {
+				case s := <-signalChannel:
+					forwardSignal(s)
+				default:
+				}
+
+				if !signalForwarded {
+					logger.Printf("Run cancelled. Interrupting command.")
+					if err := c.Process.Signal(os.Interrupt); err != nil {
+						// Interrupts are not supported on all operating systems (e.g. Windows), so kill the command instead
+						killProcess(c, logger)
+					}
 				}
+			case <-killTimer:
+				logger.Printf("Command still running %s after the run was cancelled. Killing it.", CANCELLED_COMMAND_GRACE_PERIOD)
+				killProcess(c, logger)
 			case <-cmdChannel:
 				return
 			}
@@ -121,9 +263,56 @@ func NewSignalsForwarder(signals []os.Signal, c *exec.Cmd, logger *log.Logger, c
 	return signalChannel
 }
 
+// Kill the process of the given command, logging any errors
+func killProcess(c *exec.Cmd, logger *log.Logger) {
+	if err := c.Process.Kill(); err != nil {
+		logger.Printf("Error killing command: %v", err)
+	}
+}
+
+// Return a copy of the given context that is cancelled the first time Terragrunt receives an interrupt or any of the
+// signals it forwards to the commands it runs (e.g. when the user hits CTRL+C or a CI job is aborted), so that
+// Terragrunt stops the work in progress and doesn't start anything new. After that first signal, Terragrunt stops
+// listening, so a second signal terminates Terragrunt immediately, unless it's running a command at the time, in which
+// case the signal is forwarded to the command. Call the returned function to stop listening for signals.
+func CancelContextOnSignals(ctx context.Context, logger *log.Logger) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, append([]os.Signal{os.Interrupt}, forwardSignals...)...)
+
+	go func() {
+		select {
+		case s := <-signalChannel:
+			logger.Printf("Received signal %v. Cancelling the run.", s)
+			signal.Stop(signalChannel)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(signalChannel)
+		}
+	}()
+
+	return ctx, cancel
+}
+
 func (signalChannel *SignalsForwarder) Close() error {
 	signal.Stop(*signalChannel)
 	*signalChannel <- nil
 	close(*signalChannel)
 	return nil
 }
+
+// Custom error types
+
+type AuditLogFailed struct {
+	AuditLog   string
+	Underlying error
+}
+
+func (err AuditLogFailed) Error() string {
+	return fmt.Sprintf("Terraform ran, but the record of the run could not be written to the audit log %s: %v", err.AuditLog, err.Underlying)
+}
+
+func (err AuditLogFailed) Unwrap() error {
+	return err.Underlying
+}
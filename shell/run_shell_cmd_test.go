@@ -1,9 +1,16 @@
 package shell
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,3 +26,148 @@ func TestRunShellCommand(t *testing.T) {
 	cmd = RunShellCommand(terragruntOptions, "terraform", "not-a-real-command")
 	assert.Error(t, cmd)
 }
+
+func TestRunShellCommandRedactTerraformOutput(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	terragruntOptions := helperProcessOptions(t)
+	terragruntOptions.Env["TF_VAR_db_password"] = "hunter2"
+	terragruntOptions.Redactor = util.NewRedactor([]*regexp.Regexp{regexp.MustCompile(`token=(\w+)`)})
+	terragruntOptions.RedactTerraformOutput = true
+	terragruntOptions.Writer = &stdout
+	terragruntOptions.ErrWriter = &stderr
+
+	err := RunShellCommand(terragruntOptions, os.Args[0], "-test.run=TestHelperProcessWriteOutput")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	// This isn't the Terraform command the user asked for, so its stdout goes to stderr too
+	assert.Contains(t, stderr.String(), "token=REDACTED on stdout\n")
+	assert.Contains(t, stderr.String(), "password: REDACTED on stderr\n")
+	assert.NotContains(t, stderr.String(), "hunter2")
+}
+
+// Args that a shell, or the command line parsing on Windows, would mangle if we ever joined the args into a string and
+// re-split it
+var QUOTING_EDGE_CASE_ARGS = []string{
+	"-var",
+	`tags={"a"="b c"}`,
+	"-var=name=it's a test",
+	"",
+	"  leading and trailing spaces  ",
+	`C:\Program Files\terraform\`,
+	`trailing backslash before a quote\"`,
+	`\\server\share`,
+	"$HOME and ${HOME} and `whoami`",
+	"%PATH% ^& | < > * ?",
+	"tab\tseparated",
+	"ünïcødé",
+}
+
+func TestRunShellCommandPreservesArgs(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	// Go programs on Windows need SYSTEMROOT to start
+	terragruntOptions.Env = map[string]string{"TERRAGRUNT_TEST_HELPER_PROCESS": "1", "SYSTEMROOT": os.Getenv("SYSTEMROOT")}
+
+	args := append([]string{"-test.run=TestHelperProcessPrintArgs", "--"}, QUOTING_EDGE_CASE_ARGS...)
+	output, err := RunShellCommandAndCaptureOutput(terragruntOptions, os.Args[0], args...)
+	assert.Nil(t, err, "Unexpected error: %v\n%s", err, output)
+
+	actualArgs := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "ARG:") {
+			continue
+		}
+		var arg string
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "ARG:")), &arg); err != nil {
+			t.Fatal(err)
+		}
+		actualArgs = append(actualArgs, arg)
+	}
+
+	assert.Equal(t, QUOTING_EDGE_CASE_ARGS, actualArgs)
+}
+
+// Not a real test: TestRunShellCommandPreservesArgs runs the test binary with this test as a helper process, which
+// prints each of the args after -- exactly as it received them, JSON encoded, one per line
+func TestHelperProcessPrintArgs(t *testing.T) {
+	if os.Getenv("TERRAGRUNT_TEST_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	args := os.Args
+	for i, arg := range args {
+		if arg == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+
+	for _, arg := range args {
+		encoded, _ := json.Marshal(arg)
+		fmt.Printf("ARG:%s\n", encoded)
+	}
+	os.Exit(0)
+}
+
+func TestTerraformCommandWithWrapper(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		wrapper         []string
+		args            []string
+		expectedCommand string
+		expectedArgs    []string
+	}{
+		{nil, []string{"plan", "-input=false"}, "terraform", []string{"plan", "-input=false"}},
+		{[]string{}, []string{"plan"}, "terraform", []string{"plan"}},
+		{[]string{"aws-vault", "exec", "prod", "--"}, []string{"plan", "-var", "a=b c"}, "aws-vault", []string{"exec", "prod", "--", "terraform", "plan", "-var", "a=b c"}},
+		{[]string{"sandbox"}, []string{"apply"}, "sandbox", []string{"terraform", "apply"}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+		assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+		terragruntOptions.TerraformBinaryWrapper = testCase.wrapper
+
+		actualCommand, actualArgs := TerraformCommandWithWrapper(terragruntOptions, testCase.args)
+		assert.Equal(t, testCase.expectedCommand, actualCommand, "For wrapper %v", testCase.wrapper)
+		assert.Equal(t, testCase.expectedArgs, actualArgs, "For wrapper %v", testCase.wrapper)
+	}
+}
+
+func TestTerraformCommandWithWrapperInDocker(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+	terragruntOptions.TerraformDockerImage = "hashicorp/terraform:0.11.7"
+	terragruntOptions.TerraformBinaryWrapper = []string{"aws-vault", "exec", "prod", "--"}
+
+	actualCommand, actualArgs := TerraformCommandWithWrapper(terragruntOptions, []string{"plan", "-input=false"})
+	assert.Equal(t, "aws-vault", actualCommand)
+	assert.Equal(t, []string{"exec", "prod", "--", DOCKER_COMMAND, "run"}, actualArgs[:5])
+	assert.Equal(t, []string{"hashicorp/terraform:0.11.7", "plan", "-input=false"}, actualArgs[len(actualArgs)-3:])
+}
+
+func TestFormatCommandForLog(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{}, "terraform"},
+		{[]string{"plan", "-input=false"}, "terraform plan -input=false"},
+		{[]string{"plan", "-var", `tags={"a"="b c"}`}, `terraform plan -var "tags={\"a\"=\"b c\"}"`},
+		{[]string{"apply", ""}, `terraform apply ""`},
+		{[]string{"apply", `-var-file=C:\vars.tfvars`}, `terraform apply "-var-file=C:\\vars.tfvars"`},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, formatCommandForLog("terraform", testCase.args), "For args %v", testCase.args)
+	}
+}
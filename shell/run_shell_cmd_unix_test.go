@@ -3,6 +3,7 @@
 package shell
 
 import (
+	"context"
 	goerrors "errors"
 	"os"
 	"os/exec"
@@ -118,3 +119,19 @@ func TestNewSignalsForwarderMultipleUnix(t *testing.T) {
 	assert.True(t, retCode <= interrupts, "Subprocess received wrong number of signals")
 	assert.Equal(t, retCode, expectedInterrupts, "Subprocess didn't receive multiple signals")
 }
+
+func TestRunShellCommandKilledWhenContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("")
+	assert.Nil(t, err, "Unexpected error creating NewTerragruntOptionsForTest: %v", err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	terragruntOptions.Context = ctx
+
+	start := time.Now()
+	err = RunShellCommand(terragruntOptions, "sleep", "10")
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second, "Expected the command to be killed when the context was cancelled")
+}
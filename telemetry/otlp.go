@@ -0,0 +1,289 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// The OTLP span kind and status codes Terragrunt uses. See
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+const (
+	OTLP_SPAN_KIND_INTERNAL = 1
+	OTLP_STATUS_CODE_OK     = 1
+	OTLP_STATUS_CODE_ERROR  = 2
+)
+
+// Counters are cumulative: each export has the totals since the start of the run
+const OTLP_AGGREGATION_TEMPORALITY_CUMULATIVE = 2
+
+// How long to wait for the collector, so a collector that's down doesn't hold up the end of the run
+const OTLP_EXPORT_TIMEOUT = 10 * time.Second
+
+// Exports spans and counters to an OpenTelemetry collector with OTLP/HTTP, encoded as JSON. JSON rather than protobuf
+// keeps Terragrunt free of the OpenTelemetry and protobuf libraries, and every OTLP/HTTP receiver accepts it.
+type OtlpExporter struct {
+	Endpoint    string
+	Headers     map[string]string
+	ServiceName string
+	HttpClient  *http.Client
+}
+
+// Create an exporter for the collector at the given base URL, with the given comma-separated key=value headers and
+// service name, that sends its requests with the given HTTP client
+func NewOtlpExporter(endpoint string, headers string, serviceName string, httpClient *http.Client) *OtlpExporter {
+	if serviceName == "" {
+		serviceName = DEFAULT_SERVICE_NAME
+	}
+
+	parsedHeaders := map[string]string{}
+	for _, header := range strings.Split(headers, ",") {
+		keyAndValue := strings.SplitN(header, "=", 2)
+		if len(keyAndValue) == 2 && strings.TrimSpace(keyAndValue[0]) != "" {
+			parsedHeaders[strings.TrimSpace(keyAndValue[0])] = strings.TrimSpace(keyAndValue[1])
+		}
+	}
+
+	return &OtlpExporter{
+		Endpoint:    strings.TrimSuffix(endpoint, "/"),
+		Headers:     parsedHeaders,
+		ServiceName: serviceName,
+		HttpClient:  httpClient,
+	}
+}
+
+// Export the spans and counters of the given tracer
+func (exporter *OtlpExporter) Export(tracer *Tracer, terragruntOptions *options.TerragruntOptions) error {
+	spans := tracer.Spans()
+	counters := tracer.Counters()
+
+	if len(spans) > 0 {
+		if err := exporter.post("/v1/traces", exporter.tracesRequest(tracer, spans), terragruntOptions); err != nil {
+			return err
+		}
+	}
+	if len(counters) > 0 {
+		if err := exporter.post("/v1/metrics", exporter.metricsRequest(tracer, counters), terragruntOptions); err != nil {
+			return err
+		}
+	}
+
+	terragruntOptions.Logger.Printf("Exported %d spans and %d counters to %s", len(spans), len(counters), exporter.Endpoint)
+	return nil
+}
+
+// The types below are the parts of the OTLP JSON encoding Terragrunt uses. See
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/docs/specification.md#json-protobuf-encoding
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsInt             string         `json:"asInt"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpMetric struct {
+	Name string  `json:"name"`
+	Sum  otlpSum `json:"sum"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+func (exporter *OtlpExporter) resource() otlpResource {
+	return otlpResource{Attributes: toOtlpAttributes(map[string]string{"service.name": exporter.ServiceName})}
+}
+
+func (exporter *OtlpExporter) tracesRequest(tracer *Tracer, spans []*Span) otlpTracesRequest {
+	otlpSpans := []otlpSpan{}
+	for _, span := range spans {
+		status := otlpStatus{Code: OTLP_STATUS_CODE_OK}
+		if span.Err != nil {
+			status = otlpStatus{Code: OTLP_STATUS_CODE_ERROR, Message: span.Err.Error()}
+		}
+
+		otlpSpans = append(otlpSpans, otlpSpan{
+			TraceId:           span.TraceId,
+			SpanId:            span.SpanId,
+			ParentSpanId:      span.ParentSpanId,
+			Name:              span.Name,
+			Kind:              OTLP_SPAN_KIND_INTERNAL,
+			StartTimeUnixNano: unixNano(span.StartTime),
+			EndTimeUnixNano:   unixNano(span.EndTime),
+			Attributes:        toOtlpAttributes(span.Attributes),
+			Status:            status,
+		})
+	}
+
+	return otlpTracesRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   exporter.resource(),
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: DEFAULT_SERVICE_NAME, Version: tracer.TerragruntVersion}, Spans: otlpSpans}},
+	}}}
+}
+
+func (exporter *OtlpExporter) metricsRequest(tracer *Tracer, counters []*Counter) otlpMetricsRequest {
+	now := time.Now()
+
+	// Each counter name is one metric, with a data point for each set of attributes
+	metrics := []otlpMetric{}
+	metricIndexes := map[string]int{}
+	for _, counter := range counters {
+		index, found := metricIndexes[counter.Name]
+		if !found {
+			index = len(metrics)
+			metricIndexes[counter.Name] = index
+			metrics = append(metrics, otlpMetric{
+				Name: counter.Name,
+				Sum:  otlpSum{DataPoints: []otlpDataPoint{}, AggregationTemporality: OTLP_AGGREGATION_TEMPORALITY_CUMULATIVE, IsMonotonic: true},
+			})
+		}
+
+		metrics[index].Sum.DataPoints = append(metrics[index].Sum.DataPoints, otlpDataPoint{
+			Attributes:        toOtlpAttributes(counter.Attributes),
+			StartTimeUnixNano: unixNano(tracer.StartTime),
+			TimeUnixNano:      unixNano(now),
+			AsInt:             strconv.FormatInt(counter.Value, 10),
+		})
+	}
+
+	return otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     exporter.resource(),
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: DEFAULT_SERVICE_NAME, Version: tracer.TerragruntVersion}, Metrics: metrics}},
+	}}}
+}
+
+// Send the given request, encoded as JSON, to the given path of the collector
+func (exporter *OtlpExporter) post(path string, body interface{}, terragruntOptions *options.TerragruntOptions) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	req, err := http.NewRequest("POST", exporter.Endpoint+path, bytes.NewReader(encoded))
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range exporter.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := exporter.HttpClient.Do(req)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return errors.WithStackTrace(OtlpExportFailed{Url: exporter.Endpoint + path, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody))})
+	}
+	return nil
+}
+
+// Return the given attributes as OTLP attributes, sorted by key
+func toOtlpAttributes(attributes map[string]string) []otlpKeyValue {
+	keys := []string{}
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	otlpAttributes := []otlpKeyValue{}
+	for _, key := range keys {
+		otlpAttributes = append(otlpAttributes, otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: attributes[key]}})
+	}
+	return otlpAttributes
+}
+
+// OTLP JSON encodes 64-bit integers, such as timestamps, as strings
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// Custom error types
+
+type OtlpExportFailed struct {
+	Url        string
+	StatusCode int
+	Body       string
+}
+
+func (err OtlpExportFailed) Error() string {
+	return fmt.Sprintf("Exporting telemetry to %s failed with status %d: %s", err.Url, err.StatusCode, err.Body)
+}
@@ -0,0 +1,199 @@
+package telemetry
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// The suffixes of the files --terragrunt-profile writes next to the CPU profile
+const HEAP_PROFILE_SUFFIX = ".heap"
+const PHASE_TIMINGS_SUFFIX = ".phases.txt"
+
+// How many of the slowest spans the phase timings list one by one, such as the configs that took longest to parse
+const NUM_SLOWEST_SPANS = 20
+
+// Records the profiles and phase timings of a run for --terragrunt-profile. Nothing is sent anywhere: the profiles are
+// files for go tool pprof, and the phase timings are a text file, so a run can be profiled without a collector.
+type Profiler struct {
+	Path       string
+	tracer     *Tracer
+	cpuProfile *os.File
+}
+
+// The combined time of all the spans of one phase of a run, such as parsing configs
+type PhaseTiming struct {
+	Name  string
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Start recording a CPU profile of the run if the given options have a profile path, or return nil if they don't.
+// Call Start first, so the phase timings can be worked out from the spans of the run. Like telemetry, profiling must
+// never fail a run, so if the profile can't be started, that's logged, and nil is returned.
+func StartProfile(terragruntOptions *options.TerragruntOptions) *Profiler {
+	if terragruntOptions.Profile == "" {
+		return nil
+	}
+
+	profiler, err := startProfile(terragruntOptions)
+	if err != nil {
+		terragruntOptions.Logger.Printf("WARNING: not profiling the run, as the CPU profile %s could not be started: %v", terragruntOptions.Profile, err)
+		return nil
+	}
+	return profiler
+}
+
+func startProfile(terragruntOptions *options.TerragruntOptions) (*Profiler, error) {
+	if err := os.MkdirAll(filepath.Dir(terragruntOptions.Profile), 0755); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	cpuProfile, err := os.Create(terragruntOptions.Profile)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	if err := pprof.StartCPUProfile(cpuProfile); err != nil {
+		cpuProfile.Close()
+		return nil, errors.WithStackTrace(err)
+	}
+
+	return &Profiler{Path: terragruntOptions.Profile, tracer: getActiveTracer(), cpuProfile: cpuProfile}, nil
+}
+
+// Stop recording the CPU profile, and write the heap profile and phase timings. Like telemetry, profiling must never
+// fail a run, so errors are only logged. Does nothing if the profiler is nil, so it can always be deferred.
+func (profiler *Profiler) Stop(terragruntOptions *options.TerragruntOptions) {
+	if profiler == nil {
+		return
+	}
+
+	pprof.StopCPUProfile()
+	if err := profiler.cpuProfile.Close(); err != nil {
+		terragruntOptions.Logger.Printf("Error writing CPU profile to %s: %v", profiler.Path, err)
+	}
+
+	heapProfilePath := profiler.Path + HEAP_PROFILE_SUFFIX
+	if err := writeHeapProfile(heapProfilePath); err != nil {
+		terragruntOptions.Logger.Printf("Error writing heap profile to %s: %v", heapProfilePath, err)
+	}
+
+	if profiler.tracer == nil {
+		terragruntOptions.Logger.Printf("Wrote a CPU profile to %s and a heap profile to %s", profiler.Path, heapProfilePath)
+		return
+	}
+
+	phaseTimingsPath := profiler.Path + PHASE_TIMINGS_SUFFIX
+	phaseTimings := FormatPhaseTimings(profiler.tracer.Spans(), time.Since(profiler.tracer.StartTime))
+	if err := ioutil.WriteFile(phaseTimingsPath, []byte(phaseTimings), 0644); err != nil {
+		terragruntOptions.Logger.Printf("Error writing phase timings to %s: %v", phaseTimingsPath, err)
+	}
+
+	terragruntOptions.Logger.Printf("Wrote a CPU profile to %s, a heap profile to %s, and phase timings to %s:\n%s", profiler.Path, heapProfilePath, phaseTimingsPath, phaseTimings)
+}
+
+func writeHeapProfile(path string) error {
+	heapProfile, err := os.Create(path)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer heapProfile.Close()
+
+	// Get up-to-date statistics on the memory that's still in use
+	runtime.GC()
+	return errors.WithStackTrace(pprof.WriteHeapProfile(heapProfile))
+}
+
+// Group the given spans by name into phases, with the slowest phase first
+func PhaseTimings(spans []*Span) []PhaseTiming {
+	timings := []PhaseTiming{}
+	indexes := map[string]int{}
+
+	for _, span := range spans {
+		index, found := indexes[span.Name]
+		if !found {
+			index = len(timings)
+			indexes[span.Name] = index
+			timings = append(timings, PhaseTiming{Name: span.Name})
+		}
+
+		duration := span.EndTime.Sub(span.StartTime)
+		timings[index].Count++
+		timings[index].Total += duration
+		if duration > timings[index].Max {
+			timings[index].Max = duration
+		}
+	}
+
+	sort.Sort(phaseTimingsByTotal(timings))
+	return timings
+}
+
+// Format the phase timings of the given spans, plus the slowest of the spans, as tables
+func FormatPhaseTimings(spans []*Span, runTime time.Duration) string {
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "Run time: %s\n\n", formatDuration(runTime))
+	fmt.Fprintf(&out, "Phases (phases nest, and the modules of the xxx-all commands run in parallel, so the times can add up to more than the run time):\n")
+
+	table := tabwriter.NewWriter(&out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(table, "PHASE\tCOUNT\tTOTAL\tAVERAGE\tMAX\n")
+	for _, timing := range PhaseTimings(spans) {
+		average := timing.Total / time.Duration(timing.Count)
+		fmt.Fprintf(table, "%s\t%d\t%s\t%s\t%s\n", timing.Name, timing.Count, formatDuration(timing.Total), formatDuration(average), formatDuration(timing.Max))
+	}
+	table.Flush()
+
+	slowest := make([]*Span, len(spans))
+	copy(slowest, spans)
+	sort.Stable(spansByDuration(slowest))
+	if len(slowest) > NUM_SLOWEST_SPANS {
+		slowest = slowest[:NUM_SLOWEST_SPANS]
+	}
+
+	fmt.Fprintf(&out, "\nSlowest spans:\n")
+	table = tabwriter.NewWriter(&out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(table, "DURATION\tSPAN\tATTRIBUTES\n")
+	for _, span := range slowest {
+		fmt.Fprintf(table, "%s\t%s\t%s\n", formatDuration(span.EndTime.Sub(span.StartTime)), span.Name, formatAttributes(span.Attributes))
+	}
+	table.Flush()
+
+	return out.String()
+}
+
+// Format the given duration in seconds, to the millisecond, so the columns of the tables line up
+func formatDuration(duration time.Duration) string {
+	return fmt.Sprintf("%.3fs", duration.Seconds())
+}
+
+type phaseTimingsByTotal []PhaseTiming
+
+func (timings phaseTimingsByTotal) Len() int      { return len(timings) }
+func (timings phaseTimingsByTotal) Swap(i, j int) { timings[i], timings[j] = timings[j], timings[i] }
+func (timings phaseTimingsByTotal) Less(i, j int) bool {
+	if timings[i].Total == timings[j].Total {
+		return timings[i].Name < timings[j].Name
+	}
+	return timings[i].Total > timings[j].Total
+}
+
+type spansByDuration []*Span
+
+func (spans spansByDuration) Len() int      { return len(spans) }
+func (spans spansByDuration) Swap(i, j int) { spans[i], spans[j] = spans[j], spans[i] }
+func (spans spansByDuration) Less(i, j int) bool {
+	return spans[i].EndTime.Sub(spans[i].StartTime) > spans[j].EndTime.Sub(spans[j].StartTime)
+}
@@ -0,0 +1,134 @@
+package telemetry
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createSpanForTest(name string, path string, start time.Time, duration time.Duration) *Span {
+	attributes := map[string]string{}
+	if path != "" {
+		attributes["path"] = path
+	}
+	return &Span{Name: name, Attributes: attributes, StartTime: start, EndTime: start.Add(duration)}
+}
+
+func TestPhaseTimings(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	spans := []*Span{
+		createSpanForTest("terragrunt plan-all", "", start, 10*time.Second),
+		createSpanForTest("resolve stack", "", start, 8*time.Second),
+		createSpanForTest("parse config", "/live/vpc/terraform.tfvars", start, 1*time.Second),
+		createSpanForTest("parse config", "/live/app/terraform.tfvars", start, 5*time.Second),
+		createSpanForTest("find config files", "", start, 2*time.Second),
+	}
+
+	expected := []PhaseTiming{
+		{Name: "terragrunt plan-all", Count: 1, Total: 10 * time.Second, Max: 10 * time.Second},
+		{Name: "resolve stack", Count: 1, Total: 8 * time.Second, Max: 8 * time.Second},
+		{Name: "parse config", Count: 2, Total: 6 * time.Second, Max: 5 * time.Second},
+		{Name: "find config files", Count: 1, Total: 2 * time.Second, Max: 2 * time.Second},
+	}
+	assert.Equal(t, expected, PhaseTimings(spans))
+	assert.Equal(t, []PhaseTiming{}, PhaseTimings(nil))
+}
+
+func TestFormatPhaseTimings(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	spans := []*Span{
+		createSpanForTest("terragrunt plan-all", "", start, 10*time.Second),
+		createSpanForTest("parse config", "/live/vpc/terraform.tfvars", start, 1*time.Second),
+		createSpanForTest("parse config", "/live/app/terraform.tfvars", start, 5*time.Second),
+	}
+
+	expected := `Run time: 10.500s
+
+Phases (phases nest, and the modules of the xxx-all commands run in parallel, so the times can add up to more than the run time):
+PHASE                COUNT  TOTAL    AVERAGE  MAX
+terragrunt plan-all  1      10.000s  10.000s  10.000s
+parse config         2      6.000s   3.000s   5.000s
+
+Slowest spans:
+DURATION  SPAN                 ATTRIBUTES
+10.000s   terragrunt plan-all  {}
+5.000s    parse config         {path=/live/app/terraform.tfvars}
+1.000s    parse config         {path=/live/vpc/terraform.tfvars}
+`
+	assert.Equal(t, expected, FormatPhaseTimings(spans, 10500*time.Millisecond))
+}
+
+func TestStartProfileWithoutProfile(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := createTelemetryTestOptions(t, map[string]string{})
+	profiler := StartProfile(terragruntOptions)
+	assert.Nil(t, profiler)
+
+	// Stopping a nil profiler does nothing, so it can always be deferred
+	profiler.Stop(terragruntOptions)
+}
+
+func TestStartProfileError(t *testing.T) {
+	t.Parallel()
+
+	tmpFile, err := ioutil.TempFile("", "terragrunt-profile-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	// The folder of the profile can't be created, as there's a file in its place, which must not fail the run
+	terragruntOptions := createTelemetryTestOptions(t, map[string]string{})
+	terragruntOptions.Profile = filepath.Join(tmpFile.Name(), "cpu.pprof")
+
+	profiler := StartProfile(terragruntOptions)
+	assert.Nil(t, profiler)
+	profiler.Stop(terragruntOptions)
+}
+
+func TestProfile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "terragrunt-profile-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	terragruntOptions := createTelemetryTestOptions(t, map[string]string{})
+	terragruntOptions.Profile = filepath.Join(tmpDir, "profiles", "cpu.pprof")
+
+	Start(terragruntOptions)
+	defer Shutdown(terragruntOptions)
+
+	profiler := StartProfile(terragruntOptions)
+	assert.NotNil(t, profiler)
+
+	Trace(terragruntOptions, "terragrunt plan-all", nil, func() error {
+		return Trace(terragruntOptions, "resolve stack", nil, func() error {
+			return nil
+		})
+	})
+	profiler.Stop(terragruntOptions)
+
+	for _, path := range []string{terragruntOptions.Profile, terragruntOptions.Profile + HEAP_PROFILE_SUFFIX} {
+		info, err := os.Stat(path)
+		if assert.Nil(t, err, "Expected profile %s", path) {
+			assert.True(t, info.Size() > 0, "Expected profile %s not to be empty", path)
+		}
+	}
+
+	phaseTimings, err := ioutil.ReadFile(terragruntOptions.Profile + PHASE_TIMINGS_SUFFIX)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(phaseTimings), "terragrunt plan-all  1"), string(phaseTimings))
+	assert.True(t, strings.Contains(string(phaseTimings), "resolve stack        1"), string(phaseTimings))
+}
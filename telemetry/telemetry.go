@@ -0,0 +1,271 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// The environment variable that enables exporting the spans and counters of a run with OTLP. It's the base URL of an
+// OpenTelemetry collector's OTLP/HTTP receiver, such as http://localhost:4318, as in the OpenTelemetry SDKs.
+const OTLP_ENDPOINT_ENV_VAR = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+// Extra headers to send to the collector, as comma-separated key=value pairs, such as an API key for a hosted collector
+const OTLP_HEADERS_ENV_VAR = "OTEL_EXPORTER_OTLP_HEADERS"
+
+// The service.name of the spans and counters. Defaults to terragrunt.
+const SERVICE_NAME_ENV_VAR = "OTEL_SERVICE_NAME"
+
+// A W3C trace context (https://www.w3.org/TR/trace-context/) that the spans of the run should be part of, such as the
+// trace of the CI pipeline that runs Terragrunt
+const TRACEPARENT_ENV_VAR = "TRACEPARENT"
+
+const DEFAULT_SERVICE_NAME = "terragrunt"
+
+// The tracer of the current run, if telemetry is enabled
+var activeTracer *Tracer
+var activeTracerLock sync.Mutex
+
+type contextKey int
+
+const spanContextKey contextKey = 0
+
+// A timed phase of a run, such as parsing a config or running Terraform in a module
+type Span struct {
+	Name         string
+	Attributes   map[string]string
+	TraceId      string
+	SpanId       string
+	ParentSpanId string
+	StartTime    time.Time
+	EndTime      time.Time
+	Err          error
+}
+
+// A counter of how many times something happened in a run, such as a module failing, for one set of attributes
+type Counter struct {
+	Name       string
+	Attributes map[string]string
+	Value      int64
+}
+
+// Collects the spans and counters of a run
+type Tracer struct {
+	StartTime         time.Time
+	TerragruntVersion string
+
+	traceId      string
+	parentSpanId string
+	spans        []*Span
+	counters     map[string]*Counter
+	lock         sync.Mutex
+}
+
+// Create a tracer whose spans are part of the trace in the given W3C traceparent header, if any, or of a new trace
+func NewTracer(traceparent string, terragruntVersion string) *Tracer {
+	tracer := &Tracer{
+		StartTime:         time.Now(),
+		TerragruntVersion: terragruntVersion,
+		traceId:           newId(16),
+		counters:          map[string]*Counter{},
+	}
+
+	// The format is version-traceid-parentid-flags, such as 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) == 4 && len(parts[1]) == 32 && len(parts[2]) == 16 {
+		tracer.traceId = parts[1]
+		tracer.parentSpanId = parts[2]
+	}
+
+	return tracer
+}
+
+// Start collecting the spans and counters of this run if the environment variables in the given options enable
+// exporting them, or if the run is being profiled, which needs the spans for its phase timings
+func Start(terragruntOptions *options.TerragruntOptions) {
+	if terragruntOptions.Env[OTLP_ENDPOINT_ENV_VAR] == "" && terragruntOptions.Profile == "" {
+		return
+	}
+	setActiveTracer(NewTracer(terragruntOptions.Env[TRACEPARENT_ENV_VAR], terragruntOptions.TerragruntVersion))
+}
+
+// Stop collecting spans and counters and export the ones collected so far, if any. Telemetry must never fail a run,
+// so errors are only logged.
+func Shutdown(terragruntOptions *options.TerragruntOptions) {
+	tracer := setActiveTracer(nil)
+	if tracer == nil {
+		return
+	}
+
+	endpoint := terragruntOptions.Env[OTLP_ENDPOINT_ENV_VAR]
+	if endpoint == "" {
+		return
+	}
+
+	if err := terragruntOptions.CheckNetworkAccess("export telemetry to " + endpoint); err != nil {
+		terragruntOptions.Logger.Printf("Not exporting telemetry: %v", err)
+		return
+	}
+
+	httpClient, err := terragruntOptions.HttpClient(OTLP_EXPORT_TIMEOUT)
+	if err != nil {
+		terragruntOptions.Logger.Printf("Error exporting telemetry to %s: %v", endpoint, err)
+		return
+	}
+
+	exporter := NewOtlpExporter(endpoint, terragruntOptions.Env[OTLP_HEADERS_ENV_VAR], terragruntOptions.Env[SERVICE_NAME_ENV_VAR], httpClient)
+	if err := exporter.Export(tracer, terragruntOptions); err != nil {
+		terragruntOptions.Logger.Printf("Error exporting telemetry to %s: %v", endpoint, err)
+	}
+}
+
+// Make the given tracer the one of the current run, and return the one it replaces
+func setActiveTracer(tracer *Tracer) *Tracer {
+	activeTracerLock.Lock()
+	defer activeTracerLock.Unlock()
+
+	previous := activeTracer
+	activeTracer = tracer
+	return previous
+}
+
+func getActiveTracer() *Tracer {
+	activeTracerLock.Lock()
+	defer activeTracerLock.Unlock()
+
+	return activeTracer
+}
+
+// Run the given function in a span with the given name and attributes. The span is a child of the span in the context
+// of the given options, if any, and while the function runs, the options' context carries the new span, so the spans
+// started in the function are its children. If telemetry isn't enabled, just run the function.
+func Trace(terragruntOptions *options.TerragruntOptions, name string, attributes map[string]string, fn func() error) error {
+	tracer := getActiveTracer()
+	if tracer == nil {
+		return fn()
+	}
+
+	parentContext := terragruntOptions.GetContext()
+	span := tracer.startSpan(parentContext, name, attributes)
+
+	terragruntOptions.Context = context.WithValue(parentContext, spanContextKey, span)
+	err := fn()
+	terragruntOptions.Context = parentContext
+
+	tracer.endSpan(span, err)
+	return err
+}
+
+// Add one to the counter with the given name and attributes, if telemetry is enabled
+func Count(name string, attributes map[string]string) {
+	if tracer := getActiveTracer(); tracer != nil {
+		tracer.count(name, attributes)
+	}
+}
+
+func (tracer *Tracer) startSpan(parentContext context.Context, name string, attributes map[string]string) *Span {
+	span := &Span{
+		Name:         name,
+		Attributes:   attributes,
+		TraceId:      tracer.traceId,
+		SpanId:       newId(8),
+		ParentSpanId: tracer.parentSpanId,
+		StartTime:    time.Now(),
+	}
+	if parent, hasParent := parentContext.Value(spanContextKey).(*Span); hasParent {
+		span.ParentSpanId = parent.SpanId
+	}
+	return span
+}
+
+func (tracer *Tracer) endSpan(span *Span, err error) {
+	span.EndTime = time.Now()
+	span.Err = err
+
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	tracer.spans = append(tracer.spans, span)
+}
+
+func (tracer *Tracer) count(name string, attributes map[string]string) {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
+	key := name + formatAttributes(attributes)
+	counter, found := tracer.counters[key]
+	if !found {
+		counter = &Counter{Name: name, Attributes: attributes}
+		tracer.counters[key] = counter
+	}
+	counter.Value++
+}
+
+// Return the spans that have ended, in the order they started
+func (tracer *Tracer) Spans() []*Span {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
+	spans := make([]*Span, len(tracer.spans))
+	copy(spans, tracer.spans)
+	sort.Stable(spansByStartTime(spans))
+	return spans
+}
+
+// Return the counters, sorted by name and attributes
+func (tracer *Tracer) Counters() []*Counter {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
+	keys := []string{}
+	for key := range tracer.counters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	counters := []*Counter{}
+	for _, key := range keys {
+		counter := *tracer.counters[key]
+		counters = append(counters, &counter)
+	}
+	return counters
+}
+
+type spansByStartTime []*Span
+
+func (spans spansByStartTime) Len() int      { return len(spans) }
+func (spans spansByStartTime) Swap(i, j int) { spans[i], spans[j] = spans[j], spans[i] }
+func (spans spansByStartTime) Less(i, j int) bool {
+	return spans[i].StartTime.Before(spans[j].StartTime)
+}
+
+// Return the given attributes as a string that's the same for the same attributes, such as {command=apply,path=vpc}
+func formatAttributes(attributes map[string]string) string {
+	keys := []string{}
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, attributes[key]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Return a random id of the given number of bytes, hex encoded, as trace and span ids are
+func newId(numBytes int) string {
+	id := make([]byte, numBytes)
+	if _, err := rand.Read(id); err != nil {
+		// The ids only need to be unique, and a time-based id is unique enough for one run
+		return fmt.Sprintf("%0*x", numBytes*2, time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
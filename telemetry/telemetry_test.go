@@ -0,0 +1,185 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTelemetryTestOptions(t *testing.T, env map[string]string) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("telemetry_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.Env = env
+	return terragruntOptions
+}
+
+// The tests in this file share the active tracer, so they don't run in parallel
+func TestTraceWithoutTelemetry(t *testing.T) {
+	terragruntOptions := createTelemetryTestOptions(t, map[string]string{})
+	Start(terragruntOptions)
+	defer Shutdown(terragruntOptions)
+
+	ran := false
+	err := Trace(terragruntOptions, "parse config", nil, func() error {
+		ran = true
+		return fmt.Errorf("parse error")
+	})
+
+	assert.True(t, ran)
+	assert.EqualError(t, err, "parse error")
+	assert.Nil(t, getActiveTracer())
+}
+
+func TestTraceNestsSpans(t *testing.T) {
+	tracer := NewTracer("", "v0.14.0")
+	setActiveTracer(tracer)
+	defer setActiveTracer(nil)
+
+	terragruntOptions := createTelemetryTestOptions(t, map[string]string{})
+
+	err := Trace(terragruntOptions, "terragrunt apply-all", nil, func() error {
+		return Trace(terragruntOptions, "module", map[string]string{"path": "vpc"}, func() error {
+			return Trace(terragruntOptions, "terraform apply", nil, func() error {
+				return fmt.Errorf("apply failed")
+			})
+		})
+	})
+	assert.EqualError(t, err, "apply failed")
+
+	spans := tracer.Spans()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "terragrunt apply-all", spans[0].Name)
+		assert.Equal(t, "", spans[0].ParentSpanId)
+		assert.Equal(t, "module", spans[1].Name)
+		assert.Equal(t, spans[0].SpanId, spans[1].ParentSpanId)
+		assert.Equal(t, map[string]string{"path": "vpc"}, spans[1].Attributes)
+		assert.Equal(t, "terraform apply", spans[2].Name)
+		assert.Equal(t, spans[1].SpanId, spans[2].ParentSpanId)
+
+		for _, span := range spans {
+			assert.Equal(t, spans[0].TraceId, span.TraceId)
+			assert.Len(t, span.TraceId, 32)
+			assert.Len(t, span.SpanId, 16)
+			assert.EqualError(t, span.Err, "apply failed")
+			assert.False(t, span.EndTime.Before(span.StartTime))
+		}
+	}
+
+	// The context of the options is restored once the spans end
+	assert.Nil(t, terragruntOptions.GetContext().Value(spanContextKey))
+}
+
+func TestNewTracerWithTraceparent(t *testing.T) {
+	tracer := NewTracer("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "")
+	span := tracer.startSpan(createTelemetryTestOptions(t, nil).GetContext(), "terragrunt plan", nil)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceId)
+	assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanId)
+
+	tracer = NewTracer("not-a-traceparent", "")
+	span = tracer.startSpan(createTelemetryTestOptions(t, nil).GetContext(), "terragrunt plan", nil)
+	assert.Len(t, span.TraceId, 32)
+	assert.Equal(t, "", span.ParentSpanId)
+}
+
+func TestCount(t *testing.T) {
+	tracer := NewTracer("", "")
+	setActiveTracer(tracer)
+	defer setActiveTracer(nil)
+
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			status := "succeeded"
+			if i == 0 {
+				status = "failed"
+			}
+			Count("terragrunt.modules", map[string]string{"status": status})
+		}(i)
+	}
+	waitGroup.Wait()
+
+	counters := tracer.Counters()
+	if assert.Len(t, counters, 2) {
+		assert.Equal(t, Counter{Name: "terragrunt.modules", Attributes: map[string]string{"status": "failed"}, Value: 1}, *counters[0])
+		assert.Equal(t, Counter{Name: "terragrunt.modules", Attributes: map[string]string{"status": "succeeded"}, Value: 4}, *counters[1])
+	}
+}
+
+func TestShutdownOfflineDoesNotExport(t *testing.T) {
+	requests := 0
+	var lock sync.Mutex
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests++
+	}))
+	defer collector.Close()
+
+	terragruntOptions := createTelemetryTestOptions(t, map[string]string{OTLP_ENDPOINT_ENV_VAR: collector.URL})
+	terragruntOptions.Offline = true
+
+	Start(terragruntOptions)
+	Trace(terragruntOptions, "terragrunt plan", nil, func() error { return nil })
+	Shutdown(terragruntOptions)
+
+	assert.Nil(t, getActiveTracer())
+	assert.Equal(t, 0, requests)
+}
+
+func TestStartAndShutdownExportWithOtlp(t *testing.T) {
+	requests := map[string]map[string]interface{}{}
+	headers := map[string]string{}
+	var lock sync.Mutex
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var decoded map[string]interface{}
+		assert.Nil(t, json.Unmarshal(body, &decoded), "Invalid JSON: %s", string(body))
+
+		lock.Lock()
+		defer lock.Unlock()
+		requests[r.URL.Path] = decoded
+		headers[r.URL.Path] = r.Header.Get("Authorization")
+	}))
+	defer collector.Close()
+
+	terragruntOptions := createTelemetryTestOptions(t, map[string]string{
+		OTLP_ENDPOINT_ENV_VAR: collector.URL + "/",
+		OTLP_HEADERS_ENV_VAR:  "Authorization=Bearer abc,  x-other = 1",
+		SERVICE_NAME_ENV_VAR:  "infra-ci",
+	})
+	terragruntOptions.TerragruntVersion = "v0.14.0"
+
+	Start(terragruntOptions)
+	Trace(terragruntOptions, "terragrunt plan", map[string]string{"command": "plan"}, func() error {
+		Count("terragrunt.terraform.commands", map[string]string{"command": "plan", "succeeded": "true"})
+		return nil
+	})
+	Shutdown(terragruntOptions)
+
+	assert.Nil(t, getActiveTracer())
+	assert.Equal(t, "Bearer abc", headers["/v1/traces"])
+
+	tracesJson, _ := json.Marshal(requests["/v1/traces"])
+	assert.Contains(t, string(tracesJson), `"name":"terragrunt plan"`)
+	assert.Contains(t, string(tracesJson), `{"key":"service.name","value":{"stringValue":"infra-ci"}}`)
+	assert.Contains(t, string(tracesJson), `{"key":"command","value":{"stringValue":"plan"}}`)
+	assert.Contains(t, string(tracesJson), `"status":{"code":1}`)
+
+	metricsJson, _ := json.Marshal(requests["/v1/metrics"])
+	assert.Contains(t, string(metricsJson), `"name":"terragrunt.terraform.commands"`)
+	assert.Contains(t, string(metricsJson), `"asInt":"1"`)
+	assert.Contains(t, string(metricsJson), `"isMonotonic":true`)
+}
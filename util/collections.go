@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -73,6 +74,16 @@ func CloneStringList(listToClone []string) []string {
 	return out
 }
 
+// Return the keys of the given map of strings, sorted
+func SortedKeys(mapWithKeys map[string]string) []string {
+	keys := []string{}
+	for key := range mapWithKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Make a copy of the given map of strings
 func CloneStringMap(mapToClone map[string]string) map[string]string {
 	out := map[string]string{}
@@ -94,3 +94,10 @@ func TestCommaSeparatedStrings(t *testing.T) {
 		t.Logf("%v passed", testCase.list)
 	}
 }
+
+func TestSortedKeys(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{}, SortedKeys(nil))
+	assert.Equal(t, []string{"Env", "Team", "owner"}, SortedKeys(map[string]string{"Team": "a", "owner": "b", "Env": "c"}))
+}
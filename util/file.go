@@ -4,12 +4,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"fmt"
 	"github.com/gruntwork-io/terragrunt/errors"
-	"github.com/mattn/go-zglob"
 )
 
 // Return true if the given file exists
@@ -61,33 +59,6 @@ func DeleteFiles(files []string) error {
 	return nil
 }
 
-// Returns true if the given regex can be found in any of the files matched by the given glob
-func Grep(regex *regexp.Regexp, glob string) (bool, error) {
-	// Ideally, we'd use a builin Go library like filepath.Glob here, but per https://github.com/golang/go/issues/11862,
-	// the current go implementation doesn't support treating ** as zero or more directories, just zero or one.
-	// So we use a third-party library.
-	matches, err := zglob.Glob(glob)
-	if err != nil {
-		return false, errors.WithStackTrace(err)
-	}
-
-	for _, match := range matches {
-		if IsDir(match) {
-			continue
-		}
-		bytes, err := ioutil.ReadFile(match)
-		if err != nil {
-			return false, errors.WithStackTrace(err)
-		}
-
-		if regex.Match(bytes) {
-			return true, nil
-		}
-	}
-
-	return false, nil
-}
-
 // Return true if the path points to a directory
 func IsDir(path string) bool {
 	fileInfo, err := os.Stat(path)
@@ -134,6 +105,14 @@ func ReadFileAsString(path string) (string, error) {
 // Copy the files and folders within the source folder into the destination folder. Note that hidden files and folders
 // (those starting with a dot) will be skipped.
 func CopyFolderContents(source string, destination string) error {
+	return CopyFolderContentsWithFilter(source, destination, func(path string) bool {
+		return !PathContainsHiddenFileOrFolder(path)
+	})
+}
+
+// Copy the files and folders within the source folder into the destination folder, skipping those for which the given
+// filter, which is called with the path of each file and folder in the source folder, returns false
+func CopyFolderContentsWithFilter(source string, destination string, filter func(path string) bool) error {
 	files, err := ioutil.ReadDir(source)
 	if err != nil {
 		return errors.WithStackTrace(err)
@@ -143,14 +122,14 @@ func CopyFolderContents(source string, destination string) error {
 		src := filepath.Join(source, file.Name())
 		dest := filepath.Join(destination, file.Name())
 
-		if PathContainsHiddenFileOrFolder(src) {
+		if !filter(src) {
 			continue
 		} else if file.IsDir() {
 			if err := os.MkdirAll(dest, file.Mode()); err != nil {
 				return errors.WithStackTrace(err)
 			}
 
-			if err := CopyFolderContents(src, dest); err != nil {
+			if err := CopyFolderContentsWithFilter(src, dest, filter); err != nil {
 				return err
 			}
 		} else {
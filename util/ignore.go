@@ -0,0 +1,112 @@
+package util
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// A list of gitignore-style patterns, such as those in a .terragrunt-ignore file
+type IgnorePatterns struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	regexp   *regexp.Regexp
+	negated  bool
+	anchored bool
+}
+
+// Parse the given gitignore-style patterns, one per line. Blank lines and lines starting with # are skipped. A pattern
+// starting with ! re-includes paths an earlier pattern excluded. A pattern that contains a slash, other than a trailing
+// one, matches paths relative to the root; any other pattern matches a file or folder name at any depth. * and ?
+// don't match slashes, but ** does.
+func ParseIgnorePatterns(contents string) (*IgnorePatterns, error) {
+	ignorePatterns := &IgnorePatterns{}
+
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			pattern.negated = true
+			line = line[1:]
+		}
+
+		line = strings.TrimSuffix(line, "/")
+		pattern.anchored = strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		compiled, err := regexp.Compile("^" + globToRegexp(line) + "$")
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		pattern.regexp = compiled
+
+		ignorePatterns.patterns = append(ignorePatterns.patterns, pattern)
+	}
+
+	return ignorePatterns, nil
+}
+
+// Return true if the given slash-separated path, relative to the root, is ignored. As with gitignore, everything in an
+// ignored folder is ignored too, and the last pattern that matches a path decides whether it's ignored.
+func (ignorePatterns *IgnorePatterns) Matches(path string) bool {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i := range parts {
+		if ignorePatterns.matchesExactly(strings.Join(parts[:i+1], "/"), parts[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Return true if the last pattern that matches the given path, or its base name for patterns that aren't anchored, is
+// not negated
+func (ignorePatterns *IgnorePatterns) matchesExactly(path string, name string) bool {
+	ignored := false
+	for _, pattern := range ignorePatterns.patterns {
+		target := name
+		if pattern.anchored {
+			target = path
+		}
+		if pattern.regexp.MatchString(target) {
+			ignored = !pattern.negated
+		}
+	}
+	return ignored
+}
+
+// Convert the given glob to a regular expression
+func globToRegexp(glob string) string {
+	var out bytes.Buffer
+
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			out.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			out.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			out.WriteString("[^/]*")
+		case glob[i] == '?':
+			out.WriteString("[^/]")
+		default:
+			out.WriteString(regexp.QuoteMeta(string(glob[i])))
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnorePatternsMatches(t *testing.T) {
+	t.Parallel()
+
+	ignorePatterns, err := ParseIgnorePatterns(`
+# Comments and blank lines are skipped
+
+archive/
+examples
+/prod/legacy-*
+**/tmp/**
+stage/*/old
+!stage/app/old
+!archive/keep
+`)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{"archive", true},
+		{"archive/app", true},
+		{"archive/keep", true},
+		{"live/archive", true},
+		{"examples", true},
+		{"modules/examples/vpc", true},
+		{"examples-other", false},
+		{"prod/legacy-app", true},
+		{"dev/prod/legacy-app", false},
+		{"tmp/app", true},
+		{"live/tmp/app", true},
+		{"stage/db/old", true},
+		{"stage/db/other/old", false},
+		{"stage/app/old", false},
+		{"prod/app", false},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, ignorePatterns.Matches(testCase.path), "For path %s", testCase.path)
+	}
+}
+
+func TestIgnorePatternsEmpty(t *testing.T) {
+	t.Parallel()
+
+	ignorePatterns, err := ParseIgnorePatterns("")
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.False(t, ignorePatterns.Matches("app"))
+}
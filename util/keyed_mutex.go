@@ -0,0 +1,39 @@
+package util
+
+import "sync"
+
+// A KeyedMutex is a set of mutexes identified by string keys, such as the name of an S3 bucket. Locking one key blocks
+// other goroutines that try to lock the same key, but not those that lock a different key. The zero value is ready to
+// use.
+type KeyedMutex struct {
+	mutex   sync.Mutex
+	mutexes map[string]*sync.Mutex
+}
+
+// Lock the mutex for the given key, creating it if necessary
+func (keyedMutex *KeyedMutex) Lock(key string) {
+	keyedMutex.mutexFor(key).Lock()
+}
+
+// Unlock the mutex for the given key
+func (keyedMutex *KeyedMutex) Unlock(key string) {
+	keyedMutex.mutexFor(key).Unlock()
+}
+
+// Return the mutex for the given key, creating it if necessary
+func (keyedMutex *KeyedMutex) mutexFor(key string) *sync.Mutex {
+	keyedMutex.mutex.Lock()
+	defer keyedMutex.mutex.Unlock()
+
+	if keyedMutex.mutexes == nil {
+		keyedMutex.mutexes = map[string]*sync.Mutex{}
+	}
+
+	mutex, exists := keyedMutex.mutexes[key]
+	if !exists {
+		mutex = &sync.Mutex{}
+		keyedMutex.mutexes[key] = mutex
+	}
+
+	return mutex
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedMutexSameKey(t *testing.T) {
+	t.Parallel()
+
+	keyedMutex := KeyedMutex{}
+	counter := 0
+
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			keyedMutex.Lock("foo")
+			defer keyedMutex.Unlock("foo")
+			counter++
+		}()
+	}
+	waitGroup.Wait()
+
+	assert.Equal(t, 100, counter)
+}
+
+func TestKeyedMutexDifferentKeys(t *testing.T) {
+	t.Parallel()
+
+	keyedMutex := KeyedMutex{}
+	keyedMutex.Lock("foo")
+	defer keyedMutex.Unlock("foo")
+
+	locked := make(chan bool)
+	go func() {
+		keyedMutex.Lock("bar")
+		defer keyedMutex.Unlock("bar")
+		locked <- true
+	}()
+
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Locking key bar should not be blocked by a lock on key foo")
+	}
+}
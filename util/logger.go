@@ -5,8 +5,28 @@ import (
 	"io"
 	"log"
 	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
 )
 
+// The ANSI escape codes for the colors we use for the log prefixes of the modules in the xxx-all commands. We skip red,
+// as it looks like an error, and black and white, as they are invisible on some terminal backgrounds.
+var LOG_PREFIX_COLORS = []string{
+	"\033[36m", // cyan
+	"\033[32m", // green
+	"\033[33m", // yellow
+	"\033[35m", // magenta
+	"\033[34m", // blue
+	"\033[96m", // bright cyan
+	"\033[92m", // bright green
+	"\033[93m", // bright yellow
+	"\033[95m", // bright magenta
+	"\033[94m", // bright blue
+}
+
+// The ANSI escape code that resets the color
+const ANSI_RESET = "\033[0m"
+
 // Create a logger with the given prefix
 func CreateLogger(prefix string) *log.Logger {
 	return CreateLoggerWithWriter(os.Stderr, prefix)
@@ -14,8 +34,29 @@ func CreateLogger(prefix string) *log.Logger {
 
 // CreateLoggerWithWriter Create a lgogger around the given output stream and prefix
 func CreateLoggerWithWriter(writer io.Writer, prefix string) *log.Logger {
+	return CreateColoredLoggerWithWriter(writer, prefix, "")
+}
+
+// Create a logger around the given output stream and prefix, with the prefix in the given color, which should be one
+// of the LOG_PREFIX_COLORS. If color is empty, the prefix is not colored.
+func CreateColoredLoggerWithWriter(writer io.Writer, prefix string, color string) *log.Logger {
 	if prefix != "" {
 		prefix = fmt.Sprintf("[%s] ", prefix)
+		if color != "" {
+			prefix = fmt.Sprintf("%s%s%s", color, prefix, ANSI_RESET)
+		}
 	}
 	return log.New(writer, fmt.Sprintf("[terragrunt] %s", prefix), log.LstdFlags)
 }
+
+// Return the color to use for the log prefix of the module with the given index. Each module gets a different color,
+// until we run out and start over.
+func GetLogPrefixColor(index int) string {
+	return LOG_PREFIX_COLORS[index%len(LOG_PREFIX_COLORS)]
+}
+
+// Returns true if the given writer is a terminal, in which case it's safe to write colors to it
+func IsTerminal(writer io.Writer) bool {
+	file, isFile := writer.(*os.File)
+	return isFile && terminal.IsTerminal(int(file.Fd()))
+}
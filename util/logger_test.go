@@ -0,0 +1,42 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateColoredLoggerWithWriter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		prefix   string
+		color    string
+		expected string
+	}{
+		{"", "", "[terragrunt] "},
+		{"", LOG_PREFIX_COLORS[0], "[terragrunt] "},
+		{"/stage/vpc", "", "[terragrunt] [/stage/vpc] "},
+		{"/stage/vpc", LOG_PREFIX_COLORS[0], "[terragrunt] " + LOG_PREFIX_COLORS[0] + "[/stage/vpc] " + ANSI_RESET},
+	}
+
+	for _, testCase := range testCases {
+		logger := CreateColoredLoggerWithWriter(&bytes.Buffer{}, testCase.prefix, testCase.color)
+		assert.Equal(t, testCase.expected, logger.Prefix(), "For prefix %s and color %q", testCase.prefix, testCase.color)
+	}
+}
+
+func TestGetLogPrefixColor(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, LOG_PREFIX_COLORS[0], GetLogPrefixColor(0))
+	assert.Equal(t, LOG_PREFIX_COLORS[1], GetLogPrefixColor(1))
+	assert.Equal(t, LOG_PREFIX_COLORS[0], GetLogPrefixColor(len(LOG_PREFIX_COLORS)))
+}
+
+func TestIsTerminal(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, IsTerminal(&bytes.Buffer{}))
+}
@@ -0,0 +1,206 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// Call fn with each index from 0 to n - 1, with at most the given number of calls running at once, and wait for all the
+// calls to finish
+func RunInParallel(n int, parallelism int, fn func(index int)) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	indexes := make(chan int, n)
+	for index := 0; index < n; index++ {
+		indexes <- index
+	}
+	close(indexes)
+
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < parallelism && worker < n; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for index := range indexes {
+				fn(index)
+			}
+		}()
+	}
+	waitGroup.Wait()
+}
+
+// Call visit on the folder at root and on every folder below it, with at most the given number of folders being read
+// at once, so the time spent waiting on the file system overlaps. Unlike filepath.Walk, visit is only called for
+// folders, it's called concurrently and in no particular order, and it can't return filepath.SkipDir. Instead, the
+// folders below root for which skip returns true are neither visited nor read. If followSymlinks is set, symlinks to
+// folders are walked too, except those that point to a folder that contains them, as following those would never end.
+// Returns the first error from visit or from reading a folder, after which no more folders are visited.
+func WalkFoldersInParallel(root string, parallelism int, followSymlinks bool, skip func(path string) bool, visit func(path string) error) error {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if !info.IsDir() {
+		return nil
+	}
+
+	walk := &parallelWalk{
+		followSymlinks: followSymlinks,
+		skip:           skip,
+		visit:          visit,
+		workers:        make(chan struct{}, parallelism),
+	}
+
+	walk.waitGroup.Add(1)
+	go walk.walkFolder(root, nil)
+	walk.waitGroup.Wait()
+
+	return walk.err
+}
+
+type parallelWalk struct {
+	followSymlinks bool
+	skip           func(path string) bool
+	visit          func(path string) error
+	workers        chan struct{}
+	waitGroup      sync.WaitGroup
+	errLock        sync.Mutex
+	err            error
+}
+
+// The real paths of the folders above a folder in a walk that follows symlinks. Each folder only adds itself to the
+// list of its parent, so the walks of sibling folders can share it.
+type walkAncestors struct {
+	realPath string
+	parent   *walkAncestors
+}
+
+func (ancestors *walkAncestors) contains(realPath string) bool {
+	for ancestor := ancestors; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor.realPath == realPath {
+			return true
+		}
+	}
+	return false
+}
+
+// Visit and read the folder at the given path, and start walking its subfolders. Only reading the folder takes up a
+// worker, so a folder never holds on to a worker while its subfolders wait for one.
+func (walk *parallelWalk) walkFolder(path string, ancestors *walkAncestors) {
+	defer walk.waitGroup.Done()
+
+	walk.workers <- struct{}{}
+	subfolders, ancestors, err := walk.readFolder(path, ancestors)
+	<-walk.workers
+
+	if err != nil {
+		walk.setErr(err)
+		return
+	}
+
+	for _, subfolder := range subfolders {
+		walk.waitGroup.Add(1)
+		go walk.walkFolder(subfolder, ancestors)
+	}
+}
+
+// Visit the folder at the given path and return its subfolders that should be walked, plus the ancestors of those
+// subfolders
+func (walk *parallelWalk) readFolder(path string, ancestors *walkAncestors) ([]string, *walkAncestors, error) {
+	if walk.failed() {
+		return nil, nil, nil
+	}
+
+	if walk.followSymlinks {
+		realPath, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return nil, nil, errors.WithStackTrace(err)
+		}
+		if ancestors.contains(realPath) {
+			return nil, nil, nil
+		}
+		ancestors = &walkAncestors{realPath: realPath, parent: ancestors}
+	}
+
+	if err := walk.visit(path); err != nil {
+		return nil, nil, err
+	}
+
+	folder, err := os.Open(path)
+	if err != nil {
+		return nil, nil, errors.WithStackTrace(err)
+	}
+	infos, err := folder.Readdir(-1)
+	folder.Close()
+	if err != nil {
+		return nil, nil, errors.WithStackTrace(err)
+	}
+
+	subfolders := []string{}
+	for _, info := range infos {
+		subfolder := filepath.Join(path, info.Name())
+
+		isFolder := info.IsDir()
+		if info.Mode()&os.ModeSymlink != 0 && walk.followSymlinks {
+			// A symlink that points to nothing is just a file
+			if target, err := os.Stat(subfolder); err == nil {
+				isFolder = target.IsDir()
+			}
+		}
+
+		if isFolder && !walk.skip(subfolder) {
+			subfolders = append(subfolders, subfolder)
+		}
+	}
+
+	return subfolders, ancestors, nil
+}
+
+func (walk *parallelWalk) setErr(err error) {
+	walk.errLock.Lock()
+	defer walk.errLock.Unlock()
+
+	if walk.err == nil {
+		walk.err = err
+	}
+}
+
+func (walk *parallelWalk) failed() bool {
+	walk.errLock.Lock()
+	defer walk.errLock.Unlock()
+
+	return walk.err != nil
+}
+
+// Sort the given paths in the order filepath.Walk visits them: a folder comes before the folders in it, and the folders
+// in a folder are in lexical order. This is not the same as sorting the paths as strings, which puts a/b after a-b.
+func SortPathsInWalkOrder(paths []string) {
+	sort.Sort(pathsInWalkOrder(paths))
+}
+
+type pathsInWalkOrder []string
+
+func (paths pathsInWalkOrder) Len() int      { return len(paths) }
+func (paths pathsInWalkOrder) Swap(i, j int) { paths[i], paths[j] = paths[j], paths[i] }
+func (paths pathsInWalkOrder) Less(i, j int) bool {
+	iParts := strings.Split(filepath.ToSlash(paths[i]), "/")
+	jParts := strings.Split(filepath.ToSlash(paths[j]), "/")
+
+	for index := 0; index < len(iParts) && index < len(jParts); index++ {
+		if iParts[index] != jParts[index] {
+			return iParts[index] < jParts[index]
+		}
+	}
+	return len(iParts) < len(jParts)
+}
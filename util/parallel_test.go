@@ -0,0 +1,150 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunInParallel(t *testing.T) {
+	t.Parallel()
+
+	var lock sync.Mutex
+	running := 0
+	maxRunning := 0
+	called := make([]int, 50)
+
+	RunInParallel(len(called), 4, func(index int) {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+
+		time.Sleep(time.Millisecond)
+		called[index]++
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+	})
+
+	for index, count := range called {
+		assert.Equal(t, 1, count, "Expected index %d to be called once", index)
+	}
+	assert.True(t, maxRunning <= 4, "Expected at most 4 calls at once, but got %d", maxRunning)
+
+	// Nothing to do, and a parallelism of zero, are both fine
+	RunInParallel(0, 4, func(index int) { t.Errorf("Unexpected call for index %d", index) })
+	RunInParallel(1, 0, func(index int) { called[index]++ })
+	assert.Equal(t, 2, called[0])
+}
+
+func TestWalkFoldersInParallel(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "walk-folders-in-parallel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, folder := range []string{"a/b", "a-b", "c/.git/objects", "c/d/.terraform/modules"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, folder), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "a", "file.txt"), []byte("not a folder"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var lock sync.Mutex
+	actual := []string{}
+	skip := func(path string) bool {
+		return filepath.Base(path) == ".git" || filepath.Base(path) == ".terraform"
+	}
+	err = WalkFoldersInParallel(tmpDir, 2, false, skip, func(path string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		actual = append(actual, path)
+		return nil
+	})
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	SortPathsInWalkOrder(actual)
+	expected := []string{
+		tmpDir,
+		filepath.Join(tmpDir, "a"),
+		filepath.Join(tmpDir, "a", "b"),
+		filepath.Join(tmpDir, "a-b"),
+		filepath.Join(tmpDir, "c"),
+		filepath.Join(tmpDir, "c", "d"),
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestWalkFoldersInParallelFollowingSymlinks(t *testing.T) {
+	t.Parallel()
+
+	// live/stage/loop points to live, so following it would never end
+	root := "../test/fixture-config-files/symlinks/live"
+	expected := []string{
+		root,
+		filepath.Join(root, "prod"),
+		filepath.Join(root, "prod", "app"),
+		filepath.Join(root, "stage"),
+		filepath.Join(root, "stage", "app"),
+	}
+
+	var lock sync.Mutex
+	actual := []string{}
+	err := WalkFoldersInParallel(root, 3, true, func(path string) bool { return false }, func(path string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		actual = append(actual, path)
+		return nil
+	})
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	SortPathsInWalkOrder(actual)
+	assert.Equal(t, expected, actual)
+}
+
+func TestWalkFoldersInParallelError(t *testing.T) {
+	t.Parallel()
+
+	expectedErr := fmt.Errorf("Expected error for stage")
+	err := WalkFoldersInParallel("../test/fixture-config-files/symlinks/live", 2, false, func(path string) bool { return false }, func(path string) error {
+		if filepath.Base(path) == "stage" {
+			return expectedErr
+		}
+		return nil
+	})
+	assert.Equal(t, expectedErr, err)
+
+	err = WalkFoldersInParallel("../test/fixture-config-files/does-not-exist", 2, false, func(path string) bool { return false }, func(path string) error {
+		return nil
+	})
+	assert.True(t, os.IsNotExist(errors.Unwrap(err)), "Expected a not exist error, but got %v", err)
+}
+
+func TestSortPathsInWalkOrder(t *testing.T) {
+	t.Parallel()
+
+	paths := []string{"/live/a-b", "/live/a/b", "/live", "/live/a", "/live/c/d", "/live/b"}
+	SortPathsInWalkOrder(paths)
+	assert.Equal(t, []string{"/live", "/live/a", "/live/a/b", "/live/a-b", "/live/b", "/live/c/d"}, paths)
+
+	// Sorting the paths as strings puts a-b before a/b
+	sort.Strings(paths)
+	assert.Equal(t, []string{"/live", "/live/a", "/live/a-b", "/live/a/b", "/live/b", "/live/c/d"}, paths)
+}
@@ -0,0 +1,154 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// What Redact replaces secrets with
+const REDACTED_VALUE = "REDACTED"
+
+// An environment variable or -var arg whose name contains one of these, ignoring case, probably holds a secret, e.g.
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, or TF_VAR_db_password
+var SENSITIVE_NAME_PARTS = []string{"SECRET", "TOKEN", "PASSWORD", "PASSWD", "CREDENTIAL", "PRIVATE", "AUTH", "KEY"}
+
+// The values of environment variables that look like secrets are only redacted if they are at least this long, so a
+// setting such as TF_VAR_enable_auth=true doesn't redact every "true" in the logs
+const MIN_REDACTED_SECRET_LENGTH = 6
+
+// Returns true if the given name, e.g. of an environment variable, looks like it's for a secret
+func IsSensitiveName(name string) bool {
+	upperName := strings.ToUpper(name)
+	for _, part := range SENSITIVE_NAME_PARTS {
+		if strings.Contains(upperName, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return a copy of the given Terraform args with the values of the -var args that look like secrets, e.g.
+// -var db_password=abc, redacted
+func RedactVarArgs(args []string) []string {
+	redacted := CloneStringList(args)
+	for i, arg := range redacted {
+		if arg == "-var" && i+1 < len(redacted) {
+			redacted[i+1] = redactVarAssignment(redacted[i+1])
+		} else if strings.HasPrefix(arg, "-var=") {
+			redacted[i] = "-var=" + redactVarAssignment(strings.TrimPrefix(arg, "-var="))
+		}
+	}
+	return redacted
+}
+
+// Redact the value of the given name=value assignment if the name looks like a secret
+func redactVarAssignment(assignment string) string {
+	name := strings.SplitN(assignment, "=", 2)[0]
+	if IsSensitiveName(name) {
+		return name + "=" + REDACTED_VALUE
+	}
+	return assignment
+}
+
+// Return the given string with each match of the given patterns replaced with REDACTED_VALUE. If a pattern has a
+// group, only the text of its first group is replaced, so e.g. `password=(\S+)` keeps the `password=` part, which
+// makes it clear what was redacted.
+func Redact(str string, patterns []*regexp.Regexp) string {
+	for _, pattern := range patterns {
+		if pattern.NumSubexp() == 0 {
+			str = pattern.ReplaceAllLiteralString(str, REDACTED_VALUE)
+			continue
+		}
+
+		var redacted bytes.Buffer
+		last := 0
+		for _, match := range pattern.FindAllStringSubmatchIndex(str, -1) {
+			groupStart, groupEnd := match[2], match[3]
+			// The group is optional, and isn't part of this match
+			if groupStart < 0 {
+				continue
+			}
+			redacted.WriteString(str[last:groupStart])
+			redacted.WriteString(REDACTED_VALUE)
+			last = groupEnd
+		}
+		redacted.WriteString(str[last:])
+		str = redacted.String()
+	}
+	return str
+}
+
+// A Redactor replaces secrets in text with REDACTED_VALUE: the values of the environment variables that look like
+// secrets, which are added to it as Terragrunt learns them, e.g. when it assumes an IAM role, and the matches of a set
+// of patterns. It is safe to use from several goroutines, as all the modules of an xxx-all command share one. All its
+// methods are no-ops on nil.
+type Redactor struct {
+	patterns []*regexp.Regexp
+	secrets  []string
+	mutex    sync.RWMutex
+}
+
+// Create a Redactor that redacts the matches of the given patterns, as Redact does, on top of the secrets added to it
+func NewRedactor(patterns []*regexp.Regexp) *Redactor {
+	return &Redactor{patterns: patterns}
+}
+
+// Redact the values of the given environment variables whose names look like secrets from now on
+func (redactor *Redactor) AddSecretsFromEnv(env map[string]string) {
+	if redactor == nil {
+		return
+	}
+
+	redactor.mutex.Lock()
+	defer redactor.mutex.Unlock()
+
+	for name, value := range env {
+		if len(value) >= MIN_REDACTED_SECRET_LENGTH && IsSensitiveName(name) && !ListContainsElement(redactor.secrets, value) {
+			redactor.secrets = append(redactor.secrets, value)
+		}
+	}
+
+	// Replace the longest secrets first, so a secret that contains another one is replaced as a whole
+	sort.SliceStable(redactor.secrets, func(i, j int) bool { return len(redactor.secrets[i]) > len(redactor.secrets[j]) })
+}
+
+// Return the given string with the secrets, and the matches of the patterns, in it replaced with REDACTED_VALUE
+func (redactor *Redactor) Redact(str string) string {
+	if redactor == nil {
+		return str
+	}
+
+	redactor.mutex.RLock()
+	defer redactor.mutex.RUnlock()
+
+	for _, secret := range redactor.secrets {
+		str = strings.Replace(str, secret, REDACTED_VALUE, -1)
+	}
+	return Redact(str, redactor.patterns)
+}
+
+// Return a writer that writes to the given writer with the secrets the given Redactor knows about redacted, or the
+// given writer itself if the Redactor is nil. Each write is redacted on its own, so a secret that is split across two
+// writes isn't redacted, which doesn't happen with loggers, as they write each message at once.
+func NewRedactingWriter(writer io.Writer, redactor *Redactor) io.Writer {
+	if redactor == nil {
+		return writer
+	}
+	return redactingWriter{writer: writer, redactor: redactor}
+}
+
+type redactingWriter struct {
+	writer   io.Writer
+	redactor *Redactor
+}
+
+func (writer redactingWriter) Write(data []byte) (int, error) {
+	if _, err := io.WriteString(writer.writer, writer.redactor.Redact(string(data))); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
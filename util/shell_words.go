@@ -0,0 +1,88 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// Split the given string into words the way a POSIX shell would, without doing any expansions: words are separated by
+// whitespace, single quotes keep everything up to the closing quote as is, double quotes do the same except that a
+// backslash escapes the next character, and outside of quotes a backslash escapes the next character. This is how
+// Terraform splits the TF_CLI_ARGS environment variables.
+func ParseShellWords(str string) ([]string, error) {
+	words := []string{}
+	word := []rune{}
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, char := range str {
+		switch {
+		case escaped:
+			word = append(word, char)
+			escaped = false
+		case char == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				word = append(word, char)
+			}
+		case char == '\'' || char == '"':
+			quote = char
+			inWord = true
+		case char == ' ' || char == '\t' || char == '\n' || char == '\r':
+			if inWord {
+				words = append(words, string(word))
+				word = []rune{}
+				inWord = false
+			}
+		default:
+			word = append(word, char)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.WithStackTrace(UnterminatedShellWord(str))
+	}
+
+	if inWord {
+		words = append(words, string(word))
+	}
+
+	return words, nil
+}
+
+// Join the given words into a string that a POSIX shell splits back into the same words, without doing any expansions.
+// Words that only contain characters that are safe in a shell are left as they are, and the rest are single-quoted.
+func JoinShellWords(words []string) string {
+	quotedWords := make([]string, 0, len(words))
+	for _, word := range words {
+		quotedWords = append(quotedWords, QuoteShellWord(word))
+	}
+	return strings.Join(quotedWords, " ")
+}
+
+// Quote the given word, if necessary, so that a POSIX shell treats it as a single word, without doing any expansions
+func QuoteShellWord(word string) string {
+	if word != "" && strings.Trim(word, SHELL_SAFE_CHARS) == "" {
+		return word
+	}
+	return "'" + strings.Replace(word, "'", `'\''`, -1) + "'"
+}
+
+// The characters that have no special meaning in a POSIX shell, so words made of only these don't need quotes
+const SHELL_SAFE_CHARS = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./=:,@+%"
+
+// Custom error types
+
+type UnterminatedShellWord string
+
+func (str UnterminatedShellWord) Error() string {
+	return fmt.Sprintf("Unterminated quote or trailing backslash in: %s", string(str))
+}
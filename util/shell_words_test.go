@@ -0,0 +1,65 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseShellWords(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		str      string
+		expected []string
+	}{
+		{"", []string{}},
+		{"  -input=false  -lock=false ", []string{"-input=false", "-lock=false"}},
+		{`-var 'a=b c' -var "x=\"y\""`, []string{"-var", "a=b c", "-var", `x="y"`}},
+		{`-var='tags={"a"="b"}'`, []string{`-var=tags={"a"="b"}`}},
+		{`a\ b c`, []string{"a b", "c"}},
+		{`'C:\vars.tfvars'`, []string{`C:\vars.tfvars`}},
+		{`''`, []string{""}},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := ParseShellWords(testCase.str)
+		assert.Nil(t, err, "Unexpected error for %s: %v", testCase.str, err)
+		assert.Equal(t, testCase.expected, actual, "For string %s", testCase.str)
+	}
+}
+
+func TestParseShellWordsUnterminated(t *testing.T) {
+	t.Parallel()
+
+	for _, str := range []string{`"unterminated`, `'unterminated`, `trailing\`} {
+		_, err := ParseShellWords(str)
+		_, isUnterminatedShellWordErr := errors.Unwrap(err).(UnterminatedShellWord)
+		assert.True(t, isUnterminatedShellWordErr, "Expected an UnterminatedShellWord error for %s but got: %v", str, err)
+	}
+}
+
+func TestJoinShellWords(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		words    []string
+		expected string
+	}{
+		{[]string{}, ""},
+		{[]string{"terraform", "plan", "-input=false", "-var-file=/live/prod/app/vars.tfvars"}, "terraform plan -input=false -var-file=/live/prod/app/vars.tfvars"},
+		{[]string{"-var", "name=my app"}, "-var 'name=my app'"},
+		{[]string{"it's", ""}, `'it'\''s' ''`},
+		{[]string{"$HOME", "`whoami`", "a;b"}, "'$HOME' '`whoami`' 'a;b'"},
+	}
+
+	for _, testCase := range testCases {
+		actual := JoinShellWords(testCase.words)
+		assert.Equal(t, testCase.expected, actual, "For words %v", testCase.words)
+
+		parsed, err := ParseShellWords(actual)
+		assert.Nil(t, err, "Unexpected error for %s: %v", actual, err)
+		assert.Equal(t, testCase.words, parsed, "For words %v", testCase.words)
+	}
+}
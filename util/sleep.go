@@ -0,0 +1,22 @@
+package util
+
+import (
+	"context"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// Sleep for the given duration, unless the given context is cancelled first, in which case, return the context's error
+// right away. This is useful for retry loops that should stop as soon as the user cancels the run.
+func SleepWithContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStackTrace(ctx.Err())
+	}
+}
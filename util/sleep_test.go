@@ -0,0 +1,29 @@
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSleepWithContext(t *testing.T) {
+	t.Parallel()
+
+	err := SleepWithContext(context.Background(), 10*time.Millisecond)
+	assert.Nil(t, err)
+}
+
+func TestSleepWithContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := SleepWithContext(ctx, time.Minute)
+	assert.Equal(t, context.Canceled, errors.Unwrap(err))
+	assert.True(t, time.Since(start) < 10*time.Second, "Expected SleepWithContext to return as soon as the context was cancelled")
+}
@@ -0,0 +1,45 @@
+package util
+
+// Return the Levenshtein distance between the given strings: the number of single character insertions, deletions,
+// and substitutions needed to turn one into the other
+func LevenshteinDistance(a string, b string) int {
+	aRunes := []rune(a)
+	bRunes := []rune(b)
+
+	previous := make([]int, len(bRunes)+1)
+	current := make([]int, len(bRunes)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(aRunes); i++ {
+		current[0] = i
+		for j := 1; j <= len(bRunes); j++ {
+			cost := 1
+			if aRunes[i-1] == bRunes[j-1] {
+				cost = 0
+			}
+			current[j] = Min(Min(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(bRunes)]
+}
+
+// Return the candidate closest to the given value, to suggest as a "did you mean" for a typo, or an empty string if
+// none of the candidates is close enough to be a likely match
+func ClosestMatch(value string, candidates []string) string {
+	// Allow roughly one typo for every three characters, so short values don't match everything
+	maxDistance := Min(3, len(value)/3+1)
+
+	closest := ""
+	closestDistance := maxDistance + 1
+	for _, candidate := range candidates {
+		if distance := LevenshteinDistance(value, candidate); distance < closestDistance {
+			closest = candidate
+			closestDistance = distance
+		}
+	}
+	return closest
+}
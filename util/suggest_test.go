@@ -0,0 +1,51 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshteinDistance(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"source", "source", 0},
+		{"extra_argument", "extra_arguments", 1},
+		{"soruce", "source", 2},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, LevenshteinDistance(testCase.a, testCase.b), "For %q and %q", testCase.a, testCase.b)
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	t.Parallel()
+
+	candidates := []string{"terraform", "remote_state", "dependencies", "include", "extra_arguments"}
+
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{"extra_argument", "extra_arguments"},
+		{"terrafrom", "terraform"},
+		{"remote-state", "remote_state"},
+		{"dependency", "dependencies"},
+		{"foo", ""},
+		{"lock", ""},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, ClosestMatch(testCase.value, candidates), "For %q", testCase.value)
+	}
+}
@@ -0,0 +1,279 @@
+package util
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
+)
+
+// The globs of the files Terraform loads the code of a module from
+var TERRAFORM_CODE_GLOBS = []string{"*.tf", "*.tf.json"}
+
+// The parts of the Terraform code of a module that Terragrunt needs to know about. It comes from parsing the code, so
+// unlike searching the code for a pattern, commented-out blocks don't count, and the formatting doesn't matter.
+type TerraformCode struct {
+	// The types of the backends the terraform blocks configure, such as s3
+	Backends []string
+
+	// The module blocks
+	Modules []TerraformModule
+
+	// The variable blocks
+	Variables []TerraformVariable
+
+	// The resource and data blocks
+	Resources []TerraformResource
+
+	// The provider blocks
+	Providers []TerraformProvider
+}
+
+type TerraformModule struct {
+	Name   string
+	Source string
+}
+
+type TerraformVariable struct {
+	Name       string
+	HasDefault bool
+
+	// The description and type of the variable, if they're set as strings, which the type always is before Terraform 0.12
+	Description string
+	Type        string
+}
+
+// A resource or data block, such as resource "aws_instance" "web" { ... }
+type TerraformResource struct {
+	// True for a data block, false for a resource block
+	IsData bool
+	Type   string
+	Name   string
+}
+
+// A provider block, such as provider "aws" { alias = "east" ... }
+type TerraformProvider struct {
+	Name string
+
+	// The alias of the provider config, if it's set as a string. It's empty for the default config of the provider.
+	Alias string
+}
+
+// Return the address of this resource in the state of its module, such as aws_instance.web or data.aws_ami.ubuntu
+func (resource TerraformResource) Address() string {
+	if resource.IsData {
+		return fmt.Sprintf("data.%s.%s", resource.Type, resource.Name)
+	}
+	return fmt.Sprintf("%s.%s", resource.Type, resource.Name)
+}
+
+// Parse the Terraform code in the *.tf and *.tf.json files in the given folder. Like Terraform, this ignores the
+// subfolders of the folder.
+func ParseTerraformCode(folder string) (*TerraformCode, error) {
+	code := &TerraformCode{}
+
+	for _, glob := range TERRAFORM_CODE_GLOBS {
+		paths, err := filepath.Glob(JoinPath(folder, glob))
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+
+		for _, path := range paths {
+			if IsDir(path) {
+				continue
+			}
+			if err := code.addFile(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return code, nil
+}
+
+// Return the module blocks in the Terraform code in the given folder, plus the module blocks in the code of the local
+// modules they use, and so on, as Terraform downloads those too
+func FindTerraformModules(folder string) ([]TerraformModule, error) {
+	return findTerraformModules(folder, map[string]bool{}, []TerraformModule{})
+}
+
+func findTerraformModules(folder string, visitedFolders map[string]bool, modules []TerraformModule) ([]TerraformModule, error) {
+	canonicalFolder, err := CanonicalPath(folder, ".")
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	// Modules that use each other, or a local module used more than once, only need to be parsed once
+	if visitedFolders[canonicalFolder] {
+		return modules, nil
+	}
+	visitedFolders[canonicalFolder] = true
+
+	code, err := ParseTerraformCode(canonicalFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, module := range code.Modules {
+		modules = append(modules, module)
+		if IsLocalModuleSource(module.Source) {
+			modules, err = findTerraformModules(JoinPath(canonicalFolder, module.Source), visitedFolders, modules)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return modules, nil
+}
+
+// Return true if the given module source is a local path, which Terraform requires to start with ./ or ../
+func IsLocalModuleSource(source string) bool {
+	for _, prefix := range []string{"./", "../", ".\\", "..\\"} {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return true if a terraform block in the code configures a backend of the given type
+func (code *TerraformCode) DefinesBackend(backendType string) bool {
+	return ListContainsElement(code.Backends, backendType)
+}
+
+// Parse the Terraform file at the given path, which can be in HCL or JSON, and add its blocks to the code
+func (code *TerraformCode) addFile(path string) error {
+	contents, err := ReadFileAsString(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := hcl.Parse(contents)
+	if err != nil {
+		return errors.WithStackTrace(ErrorParsingTerraformFile{Path: path, Underlying: err})
+	}
+
+	root, isObjectList := file.Node.(*ast.ObjectList)
+	if !isObjectList {
+		return errors.WithStackTrace(ErrorParsingTerraformFile{Path: path, Underlying: fmt.Errorf("expected the file to contain blocks")})
+	}
+
+	for _, terraformBlock := range findTerraformBlocks(root, "terraform", 0) {
+		for _, backendBlock := range findTerraformBlocks(terraformBlock.body, "backend", 1) {
+			code.Backends = append(code.Backends, backendBlock.labels[0])
+		}
+	}
+
+	for _, moduleBlock := range findTerraformBlocks(root, "module", 1) {
+		code.Modules = append(code.Modules, TerraformModule{Name: moduleBlock.labels[0], Source: findStringAttribute(moduleBlock.body, "source")})
+	}
+
+	for _, variableBlock := range findTerraformBlocks(root, "variable", 1) {
+		code.Variables = append(code.Variables, TerraformVariable{
+			Name:        variableBlock.labels[0],
+			HasDefault:  len(variableBlock.body.Filter("default").Items) > 0,
+			Description: findStringAttribute(variableBlock.body, "description"),
+			Type:        findStringAttribute(variableBlock.body, "type"),
+		})
+	}
+
+	for _, resourceBlock := range findTerraformBlocks(root, "resource", 2) {
+		code.Resources = append(code.Resources, TerraformResource{Type: resourceBlock.labels[0], Name: resourceBlock.labels[1]})
+	}
+
+	for _, dataBlock := range findTerraformBlocks(root, "data", 2) {
+		code.Resources = append(code.Resources, TerraformResource{IsData: true, Type: dataBlock.labels[0], Name: dataBlock.labels[1]})
+	}
+
+	for _, providerBlock := range findTerraformBlocks(root, "provider", 1) {
+		code.Providers = append(code.Providers, TerraformProvider{Name: providerBlock.labels[0], Alias: findStringAttribute(providerBlock.body, "alias")})
+	}
+
+	return nil
+}
+
+// A block in Terraform code, such as module "vpc" { ... }, with its labels
+type terraformBlock struct {
+	labels []string
+	body   *ast.ObjectList
+}
+
+// Return the blocks of the given type with the given number of labels in the given list. In HCL, the labels of a block
+// are the keys of one item, as in module "vpc" { ... }, but in JSON, they're nested objects, as in
+// "module": {"vpc": { ... }}, a block can also be a list of objects, and the HCL parser merges the keys of nested
+// objects that have only one key into one item, as in "terraform": {"backend": {"s3": {}}}, so this handles all of these.
+func findTerraformBlocks(list *ast.ObjectList, blockType string, numLabels int) []terraformBlock {
+	blocks := []terraformBlock{}
+	for _, item := range list.Filter(blockType).Items {
+		blocks = collectTerraformBlocks(item.Keys, item.Val, numLabels, blocks)
+	}
+	return blocks
+}
+
+func collectTerraformBlocks(keys []*ast.ObjectKey, value ast.Node, numLabels int, blocks []terraformBlock) []terraformBlock {
+	// The keys after the labels are the start of a block in the body of this one
+	if len(keys) > numLabels {
+		body := &ast.ObjectList{Items: []*ast.ObjectItem{{Keys: keys[numLabels:], Val: value}}}
+		return append(blocks, terraformBlock{labels: keysToStrings(keys[:numLabels]), body: body})
+	}
+
+	switch value := value.(type) {
+	case *ast.ObjectType:
+		if len(keys) == numLabels {
+			return append(blocks, terraformBlock{labels: keysToStrings(keys), body: value.List})
+		}
+		for _, item := range value.List.Items {
+			itemKeys := append(append([]*ast.ObjectKey{}, keys...), item.Keys...)
+			blocks = collectTerraformBlocks(itemKeys, item.Val, numLabels, blocks)
+		}
+	case *ast.ListType:
+		for _, element := range value.List {
+			blocks = collectTerraformBlocks(keys, element, numLabels, blocks)
+		}
+	}
+	return blocks
+}
+
+// Return the given keys as strings, without quotes
+func keysToStrings(keys []*ast.ObjectKey) []string {
+	labels := []string{}
+	for _, key := range keys {
+		if text, isString := key.Token.Value().(string); isString {
+			labels = append(labels, text)
+		}
+	}
+	return labels
+}
+
+// Return the value of the string attribute with the given name in the given block body, or an empty string if it
+// doesn't have one
+func findStringAttribute(body *ast.ObjectList, name string) string {
+	for _, item := range body.Filter(name).Items {
+		if literal, isLiteral := item.Val.(*ast.LiteralType); isLiteral && (literal.Token.Type == token.STRING || literal.Token.Type == token.HEREDOC) {
+			if text, isString := literal.Token.Value().(string); isString {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// Custom error types
+
+type ErrorParsingTerraformFile struct {
+	Path       string
+	Underlying error
+}
+
+func (err ErrorParsingTerraformFile) Error() string {
+	return fmt.Sprintf("Error parsing Terraform file %s: %v", err.Path, err.Underlying)
+}
+
+func (err ErrorParsingTerraformFile) Unwrap() error {
+	return err.Underlying
+}
@@ -0,0 +1,260 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTerraformCode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		files    map[string]string
+		expected TerraformCode
+	}{
+		{
+			"empty",
+			map[string]string{},
+			TerraformCode{},
+		},
+		{
+			"hcl",
+			map[string]string{"main.tf": `
+terraform {
+  backend "s3" {}
+}
+
+module "vpc" {
+  source = "git::git@github.com:foo/modules.git//vpc?ref=v0.0.1"
+}
+
+variable "region" {}
+
+variable "name" {
+  default = "app"
+}
+`},
+			TerraformCode{
+				Backends:  []string{"s3"},
+				Modules:   []TerraformModule{{Name: "vpc", Source: "git::git@github.com:foo/modules.git//vpc?ref=v0.0.1"}},
+				Variables: []TerraformVariable{{Name: "region", HasDefault: false}, {Name: "name", HasDefault: true}},
+			},
+		},
+		{
+			"commented out",
+			map[string]string{"main.tf": `
+# terraform {
+#   backend "s3" {}
+# }
+
+/*
+module "vpc" {
+  source = "../vpc"
+}
+*/
+
+resource "aws_instance" "example" {
+  # module "foo" is not a module
+  user_data = "backend \"s3\""
+}
+`},
+			TerraformCode{
+				Resources: []TerraformResource{{Type: "aws_instance", Name: "example"}},
+			},
+		},
+		{
+			"unusual formatting",
+			map[string]string{"main.tf": `
+terraform { backend   s3 { bucket = "foo" } }
+
+module
+"vpc"
+{ source = "../vpc" }
+`},
+			TerraformCode{
+				Backends: []string{"s3"},
+				Modules:  []TerraformModule{{Name: "vpc", Source: "../vpc"}},
+			},
+		},
+		{
+			"json",
+			map[string]string{"main.tf.json": `
+{
+  "terraform": {"backend": {"gcs": {}}},
+  "module": {"vpc": {"source": "../vpc"}, "db": {"source": "../db"}},
+  "variable": {"region": {}, "name": {"default": "app"}}
+}
+`},
+			TerraformCode{
+				Backends:  []string{"gcs"},
+				Modules:   []TerraformModule{{Name: "vpc", Source: "../vpc"}, {Name: "db", Source: "../db"}},
+				Variables: []TerraformVariable{{Name: "region", HasDefault: false}, {Name: "name", HasDefault: true}},
+			},
+		},
+		{
+			"json lists",
+			map[string]string{"main.tf.json": `{"terraform": [{"backend": [{"s3": [{}]}]}], "module": [{"vpc": [{"source": "../vpc"}]}]}`},
+			TerraformCode{
+				Backends: []string{"s3"},
+				Modules:  []TerraformModule{{Name: "vpc", Source: "../vpc"}},
+			},
+		},
+		{
+			"resources",
+			map[string]string{"main.tf": `
+resource "aws_instance" "web" {}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+`, "db.tf.json": `{"resource": {"aws_db_instance": {"main": {}}}}`},
+			TerraformCode{
+				Resources: []TerraformResource{{Type: "aws_instance", Name: "web"}, {IsData: true, Type: "aws_ami", Name: "ubuntu"}, {Type: "aws_db_instance", Name: "main"}},
+			},
+		},
+		{
+			"providers",
+			map[string]string{"main.tf": `
+provider "aws" {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+`},
+			TerraformCode{
+				Providers: []TerraformProvider{{Name: "aws"}, {Name: "aws", Alias: "west"}},
+			},
+		},
+		{
+			"several files",
+			map[string]string{"main.tf": `module "vpc" { source = "../vpc" }`, "backend.tf": `terraform { backend "s3" {} }`},
+			TerraformCode{
+				Backends: []string{"s3"},
+				Modules:  []TerraformModule{{Name: "vpc", Source: "../vpc"}},
+			},
+		},
+		{
+			"variable details",
+			map[string]string{"variables.tf": `
+variable "region" {
+  description = "The AWS region to deploy to"
+}
+
+variable "azs" {
+  type    = "list"
+  default = []
+}
+`},
+			TerraformCode{
+				Variables: []TerraformVariable{{Name: "region", Description: "The AWS region to deploy to"}, {Name: "azs", HasDefault: true, Type: "list"}},
+			},
+		},
+		{
+			"subfolders are ignored",
+			map[string]string{"main.tf": `variable "region" {}`, ".terraform/modules/vpc/main.tf": `terraform { backend "s3" {} }`},
+			TerraformCode{
+				Variables: []TerraformVariable{{Name: "region", HasDefault: false}},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		folder := writeTerraformFilesForTest(t, testCase.files)
+		defer os.RemoveAll(folder)
+
+		actual, err := ParseTerraformCode(folder)
+		if assert.Nil(t, err, "Unexpected error for %s: %v", testCase.name, err) {
+			assert.Equal(t, testCase.expected, *actual, "For %s", testCase.name)
+		}
+	}
+}
+
+func TestParseTerraformCodeInvalidSyntax(t *testing.T) {
+	t.Parallel()
+
+	folder := writeTerraformFilesForTest(t, map[string]string{"main.tf": `module "vpc" {`})
+	defer os.RemoveAll(folder)
+
+	_, err := ParseTerraformCode(folder)
+	assert.IsType(t, ErrorParsingTerraformFile{}, err)
+}
+
+func TestTerraformCodeDefinesBackend(t *testing.T) {
+	t.Parallel()
+
+	code := TerraformCode{Backends: []string{"s3"}}
+	assert.True(t, code.DefinesBackend("s3"))
+	assert.False(t, code.DefinesBackend("gcs"))
+}
+
+func writeTerraformFilesForTest(t *testing.T, files map[string]string) string {
+	folder, err := ioutil.TempDir("", "tfparse-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, contents := range files {
+		fullPath := filepath.Join(folder, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return folder
+}
+
+func TestFindTerraformModules(t *testing.T) {
+	t.Parallel()
+
+	folder := writeTerraformFilesForTest(t, map[string]string{
+		"live/main.tf":                `module "app" { source = "../modules/app" }`,
+		"modules/app/main.tf.json":    `{"module": {"vpc": {"source": "../vpc"}, "db": {"source": "git::git@github.com:foo/modules.git//db"}}}`,
+		"modules/vpc/main.tf":         `module "subnets" { source = "./subnets" }`,
+		"modules/vpc/subnets/main.tf": `module "vpc" { source = "../" }`,
+	})
+	defer os.RemoveAll(folder)
+
+	actual, err := FindTerraformModules(filepath.Join(folder, "live"))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+
+	expected := []TerraformModule{
+		{Name: "app", Source: "../modules/app"},
+		{Name: "vpc", Source: "../vpc"},
+		{Name: "subnets", Source: "./subnets"},
+		{Name: "vpc", Source: "../"},
+		{Name: "db", Source: "git::git@github.com:foo/modules.git//db"},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestIsLocalModuleSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source   string
+		expected bool
+	}{
+		{"./vpc", true},
+		{"../modules/vpc", true},
+		{`..\modules\vpc`, true},
+		{"modules/vpc", false},
+		{"/modules/vpc", false},
+		{"git::git@github.com:foo/modules.git//vpc", false},
+		{"hashicorp/consul/aws", false},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, IsLocalModuleSource(testCase.source), "For source %s", testCase.source)
+	}
+}
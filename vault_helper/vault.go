@@ -0,0 +1,236 @@
+package vault_helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// The environment variables that tell Terragrunt how to connect to Vault. The first three are the ones the vault CLI
+// uses. If VAULT_TOKEN isn't set, Terragrunt logs in with AppRole auth using VAULT_ROLE_ID and VAULT_SECRET_ID.
+const (
+	VAULT_ADDR_ENV_VAR          = "VAULT_ADDR"
+	VAULT_TOKEN_ENV_VAR         = "VAULT_TOKEN"
+	VAULT_NAMESPACE_ENV_VAR     = "VAULT_NAMESPACE"
+	VAULT_ROLE_ID_ENV_VAR       = "VAULT_ROLE_ID"
+	VAULT_SECRET_ID_ENV_VAR     = "VAULT_SECRET_ID"
+	VAULT_APPROLE_MOUNT_ENV_VAR = "VAULT_APPROLE_MOUNT"
+)
+
+const DEFAULT_APPROLE_MOUNT = "approle"
+
+// The tokens from AppRole logins, keyed by address, namespace, mount, and credentials, so a config that reads several
+// secrets only logs in once
+var appRoleTokens = map[string]string{}
+var appRoleTokensLock sync.Mutex
+
+// A client for the Vault HTTP API
+type Client struct {
+	Address    string
+	Token      string
+	Namespace  string
+	HttpClient *http.Client
+}
+
+// Create a Vault client from the VAULT_* environment variables in the given options, logging in with AppRole auth if
+// there is no VAULT_TOKEN
+func NewClientFromEnv(terragruntOptions *options.TerragruntOptions) (*Client, error) {
+	env := terragruntOptions.Env
+
+	address := strings.TrimSuffix(env[VAULT_ADDR_ENV_VAR], "/")
+	if address == "" {
+		return nil, errors.WithStackTrace(VaultNotConfigured(fmt.Sprintf("%s is not set", VAULT_ADDR_ENV_VAR)))
+	}
+
+	httpClient, err := terragruntOptions.HttpClient(0)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		Address:    address,
+		Token:      env[VAULT_TOKEN_ENV_VAR],
+		Namespace:  env[VAULT_NAMESPACE_ENV_VAR],
+		HttpClient: httpClient,
+	}
+	if client.Token != "" {
+		return client, nil
+	}
+
+	roleId := env[VAULT_ROLE_ID_ENV_VAR]
+	secretId := env[VAULT_SECRET_ID_ENV_VAR]
+	if roleId == "" || secretId == "" {
+		return nil, errors.WithStackTrace(VaultNotConfigured(fmt.Sprintf("set either %s, or %s and %s", VAULT_TOKEN_ENV_VAR, VAULT_ROLE_ID_ENV_VAR, VAULT_SECRET_ID_ENV_VAR)))
+	}
+
+	mount := env[VAULT_APPROLE_MOUNT_ENV_VAR]
+	if mount == "" {
+		mount = DEFAULT_APPROLE_MOUNT
+	}
+
+	token, err := client.loginWithAppRole(mount, roleId, secretId, terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+	client.Token = token
+
+	return client, nil
+}
+
+// Log in with AppRole auth at the given mount and return the client token. The token is cached for the rest of the
+// run.
+func (client *Client) loginWithAppRole(mount string, roleId string, secretId string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	appRoleTokensLock.Lock()
+	defer appRoleTokensLock.Unlock()
+
+	cacheKey := strings.Join([]string{client.Address, client.Namespace, mount, roleId, secretId}, "|")
+	if token, found := appRoleTokens[cacheKey]; found {
+		return token, nil
+	}
+
+	terragruntOptions.Logger.Printf("Logging in to Vault at %s with AppRole auth", client.Address)
+
+	body := map[string]string{"role_id": roleId, "secret_id": secretId}
+	var response struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := client.request("POST", fmt.Sprintf("auth/%s/login", mount), body, &response, terragruntOptions); err != nil {
+		return "", err
+	}
+	if response.Auth.ClientToken == "" {
+		return "", errors.WithStackTrace(VaultRequestFailed{Path: fmt.Sprintf("auth/%s/login", mount), Errors: []string{"the response has no client token"}})
+	}
+
+	appRoleTokens[cacheKey] = response.Auth.ClientToken
+	return response.Auth.ClientToken, nil
+}
+
+// Read the secret at the given path (e.g. secret/data/db) and return its data. For a secret in a version 2 KV secrets
+// engine, this is the data of the latest version, without the metadata.
+func (client *Client) ReadSecret(path string, terragruntOptions *options.TerragruntOptions) (map[string]interface{}, error) {
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := client.request("GET", path, nil, &response, terragruntOptions); err != nil {
+		return nil, err
+	}
+
+	data, hasData := response.Data["data"].(map[string]interface{})
+	_, hasMetadata := response.Data["metadata"].(map[string]interface{})
+	if hasData && hasMetadata {
+		return data, nil
+	}
+	return response.Data, nil
+}
+
+// Send a request to the given path of the Vault API, with the given body encoded as JSON, and decode the JSON response
+// into the given value
+func (client *Client) request(method string, path string, body interface{}, out interface{}, terragruntOptions *options.TerragruntOptions) error {
+	var requestBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&requestBody).Encode(body); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/v1/%s", client.Address, strings.TrimPrefix(path, "/")), &requestBody)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	req = req.WithContext(terragruntOptions.GetContext())
+
+	if client.Token != "" {
+		req.Header.Set("X-Vault-Token", client.Token)
+	}
+	if client.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", client.Namespace)
+	}
+
+	resp, err := client.HttpClient.Do(req)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errorResponse struct {
+			Errors []string `json:"errors"`
+		}
+		// The body is only used to explain the error, so ignore any error decoding it
+		json.NewDecoder(resp.Body).Decode(&errorResponse)
+		return errors.WithStackTrace(VaultRequestFailed{Path: path, StatusCode: resp.StatusCode, Errors: errorResponse.Errors})
+	}
+
+	return errors.WithStackTrace(json.NewDecoder(resp.Body).Decode(out))
+}
+
+// Return the value of the given key in the Vault secret at the given path. A value that isn't a string, such as a
+// number or a map, is returned as JSON, which Terraform accepts for variables set through TF_VAR_ environment
+// variables.
+func GetSecretValue(path string, key string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	if err := terragruntOptions.CheckNetworkAccess(fmt.Sprintf("read secret %s from Vault", path)); err != nil {
+		return "", err
+	}
+
+	client, err := NewClientFromEnv(terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+
+	terragruntOptions.Logger.Printf("Reading secret %s from Vault at %s", path, client.Address)
+
+	data, err := client.ReadSecret(path, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+
+	value, found := data[key]
+	if !found {
+		return "", errors.WithStackTrace(VaultSecretKeyNotFound{Path: path, Key: key})
+	}
+
+	if str, isString := value.(string); isString {
+		return str, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return string(encoded), nil
+}
+
+// Custom error types
+
+type VaultNotConfigured string
+
+func (reason VaultNotConfigured) Error() string {
+	return fmt.Sprintf("Can't connect to Vault: %s", string(reason))
+}
+
+type VaultRequestFailed struct {
+	Path       string
+	StatusCode int
+	Errors     []string
+}
+
+func (err VaultRequestFailed) Error() string {
+	return fmt.Sprintf("Vault request to %s failed with status %d: %s", err.Path, err.StatusCode, strings.Join(err.Errors, ", "))
+}
+
+type VaultSecretKeyNotFound struct {
+	Path string
+	Key  string
+}
+
+func (err VaultSecretKeyNotFound) Error() string {
+	return fmt.Sprintf("The Vault secret %s has no key named %s", err.Path, err.Key)
+}
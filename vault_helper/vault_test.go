@@ -0,0 +1,116 @@
+package vault_helper
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+// A fake Vault server with a KV version 1 secret at secret/db, a KV version 2 secret at kv/data/app, and AppRole auth
+// for role id test-role and secret id test-secret, which logs in with the token approle-token
+func fakeVaultServer(t *testing.T, appRoleLogins *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/approle/login" {
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["role_id"] != "test-role" || body["secret_id"] != "test-secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors": ["invalid role or secret ID"]}`))
+				return
+			}
+			*appRoleLogins++
+			w.Write([]byte(`{"auth": {"client_token": "approle-token"}}`))
+			return
+		}
+
+		if token := r.Header.Get("X-Vault-Token"); token != "test-token" && token != "approle-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/db":
+			w.Write([]byte(`{"data": {"password": "hunter2", "port": 5432}}`))
+		case "/v1/kv/data/app":
+			w.Write([]byte(`{"data": {"data": {"api_key": "abc123", "tags": {"team": "core"}}, "metadata": {"version": 3}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+}
+
+func vaultOptionsForTest(t *testing.T, env map[string]string) *options.TerragruntOptions {
+	terragruntOptions, err := options.NewTerragruntOptionsForTest("vault_helper_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	terragruntOptions.Env = env
+	return terragruntOptions
+}
+
+func TestGetSecretValue(t *testing.T) {
+	t.Parallel()
+
+	appRoleLogins := 0
+	server := fakeVaultServer(t, &appRoleLogins)
+	defer server.Close()
+
+	tokenEnv := map[string]string{VAULT_ADDR_ENV_VAR: server.URL, VAULT_TOKEN_ENV_VAR: "test-token"}
+
+	testCases := []struct {
+		name          string
+		env           map[string]string
+		path          string
+		key           string
+		expectedValue string
+		expectedErr   error
+	}{
+		{"kv v1", tokenEnv, "secret/db", "password", "hunter2", nil},
+		{"kv v1 number", tokenEnv, "secret/db", "port", "5432", nil},
+		{"kv v2", tokenEnv, "kv/data/app", "api_key", "abc123", nil},
+		{"kv v2 map", tokenEnv, "kv/data/app", "tags", `{"team":"core"}`, nil},
+		{"leading slash", tokenEnv, "/secret/db", "password", "hunter2", nil},
+		{"missing key", tokenEnv, "secret/db", "username", "", VaultSecretKeyNotFound{}},
+		{"missing secret", tokenEnv, "secret/other", "password", "", VaultRequestFailed{}},
+		{"bad token", map[string]string{VAULT_ADDR_ENV_VAR: server.URL, VAULT_TOKEN_ENV_VAR: "bad-token"}, "secret/db", "password", "", VaultRequestFailed{}},
+		{"approle", map[string]string{VAULT_ADDR_ENV_VAR: server.URL, VAULT_ROLE_ID_ENV_VAR: "test-role", VAULT_SECRET_ID_ENV_VAR: "test-secret"}, "secret/db", "password", "hunter2", nil},
+		{"bad approle", map[string]string{VAULT_ADDR_ENV_VAR: server.URL, VAULT_ROLE_ID_ENV_VAR: "test-role", VAULT_SECRET_ID_ENV_VAR: "bad-secret"}, "secret/db", "password", "", VaultRequestFailed{}},
+		{"no address", map[string]string{VAULT_TOKEN_ENV_VAR: "test-token"}, "secret/db", "password", "", VaultNotConfigured("")},
+		{"no auth", map[string]string{VAULT_ADDR_ENV_VAR: server.URL}, "secret/db", "password", "", VaultNotConfigured("")},
+	}
+
+	for _, testCase := range testCases {
+		actualValue, actualErr := GetSecretValue(testCase.path, testCase.key, vaultOptionsForTest(t, testCase.env))
+		if testCase.expectedErr != nil {
+			if assert.Error(t, actualErr, testCase.name) {
+				assert.IsType(t, testCase.expectedErr, errors.Unwrap(actualErr), testCase.name)
+			}
+		} else {
+			assert.Nil(t, actualErr, "%s: unexpected error: %v", testCase.name, actualErr)
+			assert.Equal(t, testCase.expectedValue, actualValue, testCase.name)
+		}
+	}
+}
+
+func TestGetSecretValueCachesAppRoleToken(t *testing.T) {
+	t.Parallel()
+
+	appRoleLogins := 0
+	server := fakeVaultServer(t, &appRoleLogins)
+	defer server.Close()
+
+	env := map[string]string{VAULT_ADDR_ENV_VAR: server.URL, VAULT_ROLE_ID_ENV_VAR: "test-role", VAULT_SECRET_ID_ENV_VAR: "test-secret"}
+
+	for i := 0; i < 3; i++ {
+		_, err := GetSecretValue("secret/db", "password", vaultOptionsForTest(t, env))
+		assert.Nil(t, err, "Unexpected error: %v", err)
+	}
+	assert.Equal(t, 1, appRoleLogins)
+}